@@ -5,6 +5,8 @@ import (
 	"myflowhub/manager/internal/client"
 	binproto "myflowhub/pkg/protocol/binproto"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -31,7 +33,7 @@ func (h *DeviceHandler) HandleGetDevices(w http.ResponseWriter, r *http.Request)
 	// 优先二进制
 	if h.hubClient != nil && h.hubClient.IsConnected() {
 		payload := binproto.EncodeQueryNodesReq(token)
-		if resp, err := h.hubClient.SendBinaryRequest(binproto.TypeQueryNodesReq, binproto.TypeQueryNodesResp, payload, 5*time.Second); err == nil {
+		if resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeQueryNodesReq, binproto.TypeQueryNodesResp, payload, 5*time.Second); err == nil {
 			if _, items, err2 := binproto.DecodeQueryNodesResp(resp); err2 == nil {
 				// 直接返回为向后兼容的 JSON 结构：{ success:true, data:[devices] }
 				h.writeJSON(w, map[string]any{"success": true, "data": items})
@@ -90,7 +92,7 @@ func (h *DeviceHandler) HandleCreateDevice(w http.ResponseWriter, r *http.Reques
 		}
 		payload := binproto.EncodeCreateDeviceReq(token, item)
 		// 期待 OK；若收到 ERR 或解码异常，直接向前端报错，避免回退 JSON 触发匿名警告
-		if resp, err := h.hubClient.SendBinaryRequest(binproto.TypeCreateDeviceReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeCreateDeviceReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, e2 := binproto.DecodeOKResp(resp); e2 == nil {
 				if code == 0 {
 					h.writeJSON(w, map[string]any{"success": true})
@@ -153,8 +155,11 @@ func (h *DeviceHandler) HandleUpdateDevice(w http.ResponseWriter, r *http.Reques
 			vv := v
 			item.Approved = &vv
 		}
+		if v, ok := reqBody["Tags"].(string); ok {
+			item.Tags = &v
+		}
 		payload := binproto.EncodeUpdateDeviceReq(token, item)
-		if resp, err := h.hubClient.SendBinaryRequest(binproto.TypeUpdateDeviceReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUpdateDeviceReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, e2 := binproto.DecodeOKResp(resp); e2 == nil {
 				if code == 0 {
 					h.writeJSON(w, map[string]any{"success": true})
@@ -191,7 +196,7 @@ func (h *DeviceHandler) HandleDeleteDevice(w http.ResponseWriter, r *http.Reques
 			id = uint64(v)
 		}
 		payload := binproto.EncodeDeleteDeviceReq(id, token)
-		if resp, err := h.hubClient.SendBinaryRequest(binproto.TypeDeleteDeviceReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeDeleteDeviceReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, e2 := binproto.DecodeOKResp(resp); e2 == nil {
 				if code == 0 {
 					h.writeJSON(w, map[string]any{"success": true})
@@ -215,6 +220,95 @@ func (h *DeviceHandler) HandleGetDeviceByID(w http.ResponseWriter, r *http.Reque
 	h.writeError(w, http.StatusNotImplemented, "Get device by ID not implemented")
 }
 
+// HandleGetPresence 查询在线状态：GET ?deviceUids=1,2（缺省为全部可见设备）
+func (h *DeviceHandler) HandleGetPresence(w http.ResponseWriter, r *http.Request) {
+	var uids []uint64
+	if v := r.URL.Query().Get("deviceUids"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			uid, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				h.writeError(w, http.StatusBadRequest, "invalid deviceUids")
+				return
+			}
+			uids = append(uids, uid)
+		}
+	}
+	resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypePresenceQueryReq, binproto.TypePresenceQueryResp, binproto.EncodePresenceQueryReq(bearerToken(r), uids), 5*time.Second)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "hub error: "+err.Error())
+		return
+	}
+	_, items, err := binproto.DecodePresenceQueryResp(resp)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	arr := make([]map[string]any, 0, len(items))
+	for _, it := range items {
+		arr = append(arr, map[string]any{"deviceUid": it.DeviceUID, "online": it.Online, "lastSeen": it.LastSeenSec, "via": it.Via})
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": arr})
+}
+
+// HandleGetSessions 查询设备连接会话历史：GET ?deviceUid=&page=&pageSize=
+func (h *DeviceHandler) HandleGetSessions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	uid, err := strconv.ParseUint(q.Get("deviceUid"), 10, 64)
+	if err != nil || uid == 0 {
+		h.writeError(w, http.StatusBadRequest, "invalid deviceUid")
+		return
+	}
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+	resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeDeviceSessionListReq, binproto.TypeDeviceSessionListResp,
+		binproto.EncodeDeviceSessionListReq(bearerToken(r), uid, int32(page), int32(pageSize)), 5*time.Second)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "hub error: "+err.Error())
+		return
+	}
+	_, total, pg, size, items, err := binproto.DecodeDeviceSessionListResp(resp)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	arr := make([]map[string]any, 0, len(items))
+	for _, it := range items {
+		arr = append(arr, map[string]any{
+			"id": it.ID, "deviceUid": it.DeviceUID, "hub": it.Hub, "parentPath": it.ParentPath,
+			"remoteAddr": it.RemoteAddr, "userAgent": it.UserAgent, "protocol": it.Protocol,
+			"startedAt": it.StartedAt, "authedAt": it.AuthedAt, "endedAt": it.EndedAt, "durationMs": it.DurationMs,
+			"bytesIn": it.BytesIn, "bytesOut": it.BytesOut, "framesIn": it.FramesIn, "framesOut": it.FramesOut,
+			"rawBytesIn": it.RawBytesIn, "rawBytesOut": it.RawBytesOut, "closeReason": it.CloseReason,
+		})
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": map[string]any{"total": total, "page": pg, "pageSize": size, "items": arr}})
+}
+
+// HandleRotateSecret 轮换设备密钥：POST {deviceUid, graceSec, force}；新密钥仅在本次响应中返回
+func (h *DeviceHandler) HandleRotateSecret(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		DeviceUID uint64 `json:"deviceUid"`
+		GraceSec  uint32 `json:"graceSec"`
+		Force     bool   `json:"force"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.DeviceUID == 0 {
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeDeviceRotateSecretReq, binproto.TypeDeviceRotateSecretResp,
+		binproto.EncodeDeviceRotateSecretReq(body.DeviceUID, bearerToken(r), body.GraceSec, body.Force), 10*time.Second)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "hub error: "+err.Error())
+		return
+	}
+	_, uid, secret, graceUntil, err := binproto.DecodeDeviceRotateSecretResp(resp)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": map[string]any{"deviceUid": uid, "secret": secret, "graceUntil": graceUntil}})
+}
+
 // writeJSON 写入JSON响应
 func (h *DeviceHandler) writeJSON(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"myflowhub/manager/internal/client"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+type FileHandler struct{ hubClient *client.HubClient }
+
+func NewFileHandler(hc *client.HubClient) *FileHandler { return &FileHandler{hubClient: hc} }
+
+// HandleUpload 接收 multipart 表单文件（字段 file，可选 target）并以分片协议上传到 Hub
+func (h *FileHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	if !h.hubClient.IsConnected() {
+		h.writeError(w, http.StatusServiceUnavailable, "Not connected to hub")
+		return
+	}
+	f, hdr, err := r.FormFile("file")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "missing file")
+		return
+	}
+	defer f.Close()
+	var target uint64
+	if v := r.FormValue("target"); v != "" {
+		if target, err = strconv.ParseUint(v, 10, 64); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid target")
+			return
+		}
+	}
+	// 先落到临时文件，便于随机读取与断线续传
+	tmp, err := os.CreateTemp("", "mfh-upload-*")
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "temp file error")
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, f)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "read upload failed")
+		return
+	}
+	mime := hdr.Header.Get("Content-Type")
+	fileID, err := h.hubClient.UploadFile(target, tmp, size, hdr.Filename, mime, 30*time.Minute)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "upload failed: "+err.Error())
+		return
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": map[string]any{"fileId": fileID, "size": size, "filename": hdr.Filename}})
+}
+
+func (h *FileHandler) writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+func (h *FileHandler) writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": msg})
+}
@@ -33,7 +33,7 @@ func (h *LogHandler) HandleList(w http.ResponseWriter, r *http.Request) {
 		_ = json.NewDecoder(r.Body).Decode(&body)
 	}
 	// binary first
-	if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeSystemLogListReq, binproto.TypeSystemLogListResp,
+	if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeSystemLogListReq, binproto.TypeSystemLogListResp,
 		binproto.EncodeSystemLogListReq(token, body.Level, body.Source, body.Keyword, valueOrZero(body.StartAt), valueOrZero(body.EndAt), int32(body.Page), int32(body.PageSize)), 5*time.Second); err == nil {
 		if reqID, total, page, size, items, derr := binproto.DecodeSystemLogListResp(pld); derr == nil {
 			// map to JSON structure similar to controller output
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"myflowhub/manager/internal/client"
+	binproto "myflowhub/pkg/protocol/binproto"
+)
+
+type OTAHandler struct{ hubClient *client.HubClient }
+
+func NewOTAHandler(hc *client.HubClient) *OTAHandler { return &OTAHandler{hubClient: hc} }
+
+func bearerToken(r *http.Request) string {
+	token := r.Header.Get("Authorization")
+	if len(token) > 7 && token[:7] == "Bearer " {
+		token = token[7:]
+	}
+	return token
+}
+
+func otaArtifactJSON(a binproto.OTAArtifactItem) map[string]any {
+	return map[string]any{"id": a.ID, "version": a.Version, "hardwareModel": a.HardwareModel, "fileId": a.FileID, "size": a.Size, "sha256": a.SHA256, "createdAt": a.CreatedAt}
+}
+
+func otaCampaignJSON(c binproto.OTACampaignItem) map[string]any {
+	return map[string]any{
+		"id": c.ID, "name": c.Name, "artifactId": c.ArtifactID, "status": c.Status,
+		"ownerUserId": c.OwnerUserID, "parentId": c.ParentID, "tag": c.Tag,
+		"stages": c.Stages, "currentStage": c.CurrentStage, "maxFailurePct": c.MaxFailurePct,
+		"total": c.Total, "succeeded": c.Succeeded, "failed": c.Failed, "createdAt": c.CreatedAt,
+	}
+}
+
+// HandleCreateArtifact 上传固件（multipart：file、version、hwModel、可选 sha256）并登记为制品
+func (h *OTAHandler) HandleCreateArtifact(w http.ResponseWriter, r *http.Request) {
+	if !h.hubClient.IsConnected() {
+		h.writeError(w, http.StatusServiceUnavailable, "Not connected to hub")
+		return
+	}
+	f, hdr, err := r.FormFile("file")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "missing file")
+		return
+	}
+	defer f.Close()
+	version := r.FormValue("version")
+	if version == "" {
+		h.writeError(w, http.StatusBadRequest, "version required")
+		return
+	}
+	tmp, err := os.CreateTemp("", "mfh-fw-*")
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "temp file error")
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, f)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "read upload failed")
+		return
+	}
+	fileID, err := h.hubClient.UploadFile(0, tmp, size, hdr.Filename, "application/octet-stream", 30*time.Minute)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "upload failed: "+err.Error())
+		return
+	}
+	pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeOTAArtifactCreateReq, binproto.TypeOTAArtifactCreateResp,
+		binproto.EncodeOTAArtifactCreateReq(bearerToken(r), version, r.FormValue("hwModel"), fileID, r.FormValue("sha256")), 5*time.Second)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	_, item, err := binproto.DecodeOTAArtifactCreateResp(pld)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": otaArtifactJSON(item)})
+}
+
+func (h *OTAHandler) HandleListArtifacts(w http.ResponseWriter, r *http.Request) {
+	pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeOTAArtifactListReq, binproto.TypeOTAArtifactListResp, binproto.EncodeOTAArtifactListReq(bearerToken(r)), 5*time.Second)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	_, items, err := binproto.DecodeOTAArtifactListResp(pld)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	arr := make([]map[string]any, 0, len(items))
+	for _, it := range items {
+		arr = append(arr, otaArtifactJSON(it))
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": arr})
+}
+
+type otaCampaignBody struct {
+	Name          string   `json:"name"`
+	ArtifactID    uint64   `json:"artifactId"`
+	OwnerUserID   *uint64  `json:"ownerUserId"`
+	ParentID      *uint64  `json:"parentId"`
+	Tag           *string  `json:"tag"`
+	Stages        []uint32 `json:"stages"`
+	MaxFailurePct uint32   `json:"maxFailurePct"`
+}
+
+func (h *OTAHandler) HandleCreateCampaign(w http.ResponseWriter, r *http.Request) {
+	var body otaCampaignBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	spec := binproto.OTACampaignSpec{Name: body.Name, ArtifactID: body.ArtifactID, OwnerUserID: body.OwnerUserID, ParentID: body.ParentID, Tag: body.Tag, Stages: body.Stages, MaxFailurePct: body.MaxFailurePct}
+	pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeOTACampaignCreateReq, binproto.TypeOTACampaignCreateResp, binproto.EncodeOTACampaignCreateReq(bearerToken(r), spec), 10*time.Second)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	_, item, err := binproto.DecodeOTACampaignCreateResp(pld)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": otaCampaignJSON(item)})
+}
+
+func (h *OTAHandler) HandleListCampaigns(w http.ResponseWriter, r *http.Request) {
+	pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeOTACampaignListReq, binproto.TypeOTACampaignListResp, binproto.EncodeOTACampaignListReq(bearerToken(r)), 5*time.Second)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	_, items, err := binproto.DecodeOTACampaignListResp(pld)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	arr := make([]map[string]any, 0, len(items))
+	for _, it := range items {
+		arr = append(arr, otaCampaignJSON(it))
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": arr})
+}
+
+// HandleCampaignStatus GET ?id=<campaignId>
+func (h *OTAHandler) HandleCampaignStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeOTACampaignStatusReq, binproto.TypeOTACampaignStatusResp, binproto.EncodeOTACampaignStatusReq(bearerToken(r), id), 5*time.Second)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	_, item, devices, err := binproto.DecodeOTACampaignStatusResp(pld)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	arr := make([]map[string]any, 0, len(devices))
+	for _, d := range devices {
+		arr = append(arr, map[string]any{"deviceUid": d.DeviceUID, "stage": d.Stage, "state": d.State, "progress": d.Progress, "error": d.Error, "updatedAt": d.UpdatedAt})
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": map[string]any{"campaign": otaCampaignJSON(item), "devices": arr}})
+}
+
+// HandleCampaignControl POST {id, action: start|pause|resume|abort}
+func (h *OTAHandler) HandleCampaignControl(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ID     uint64 `json:"id"`
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeOTACampaignControlReq, binproto.TypeOKResp, binproto.EncodeOTACampaignControlReq(bearerToken(r), body.ID, body.Action), 5*time.Second)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	_, code, msg, _ := binproto.DecodeOKResp(pld)
+	h.writeJSON(w, map[string]any{"success": code == 0, "message": string(msg)})
+}
+
+func (h *OTAHandler) writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+func (h *OTAHandler) writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": msg})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"myflowhub/manager/internal/client"
+	binproto "myflowhub/pkg/protocol/binproto"
+)
+
+type TwinHandler struct{ hubClient *client.HubClient }
+
+func NewTwinHandler(hc *client.HubClient) *TwinHandler { return &TwinHandler{hubClient: hc} }
+
+// rawOrNull 将空文档渲染为 null，便于前端直接使用
+func rawOrNull(b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(b)
+}
+
+func twinDeviceUID(r *http.Request) (uint64, bool) {
+	v := r.URL.Query().Get("deviceUid")
+	if v == "" {
+		return 0, false
+	}
+	uid, err := strconv.ParseUint(v, 10, 64)
+	return uid, err == nil && uid != 0
+}
+
+// HandleGet GET ?deviceUid=
+func (h *TwinHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	uid, ok := twinDeviceUID(r)
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, "invalid deviceUid")
+		return
+	}
+	pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeTwinGetReq, binproto.TypeTwinGetResp, binproto.EncodeTwinGetReq(bearerToken(r), uid), 5*time.Second)
+	if err != nil {
+		h.writeHubError(w, err)
+		return
+	}
+	_, d, err := binproto.DecodeTwinGetResp(pld)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": map[string]any{
+		"deviceUid": d.DeviceUID,
+		"desired":   rawOrNull(d.Desired), "desiredVersion": d.DesiredVersion,
+		"reported": rawOrNull(d.Reported), "reportedVersion": d.ReportedVersion,
+		"delta": rawOrNull(d.Delta),
+	}})
+}
+
+// HandleUpdateDesired PUT {deviceUid, patch, expectedVersion?}，patch 为 JSON Merge Patch
+func (h *TwinHandler) HandleUpdateDesired(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		DeviceUID       uint64          `json:"deviceUid"`
+		Patch           json.RawMessage `json:"patch"`
+		ExpectedVersion *uint64         `json:"expectedVersion"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.DeviceUID == 0 || len(body.Patch) == 0 {
+		h.writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeTwinDesiredUpdateReq, binproto.TypeTwinUpdateResp,
+		binproto.EncodeTwinDesiredUpdateReq(bearerToken(r), body.DeviceUID, body.Patch, body.ExpectedVersion), 5*time.Second)
+	if err != nil {
+		h.writeHubError(w, err)
+		return
+	}
+	_, uid, version, err := binproto.DecodeTwinUpdateResp(pld)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": map[string]any{"deviceUid": uid, "desiredVersion": version}})
+}
+
+// HandleDelta GET ?deviceUid=
+func (h *TwinHandler) HandleDelta(w http.ResponseWriter, r *http.Request) {
+	uid, ok := twinDeviceUID(r)
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, "invalid deviceUid")
+		return
+	}
+	pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeTwinDeltaReq, binproto.TypeTwinDelta, binproto.EncodeTwinDeltaReq(bearerToken(r), uid), 5*time.Second)
+	if err != nil {
+		h.writeHubError(w, err)
+		return
+	}
+	_, duid, delta, dv, rv, err := binproto.DecodeTwinDelta(pld)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "decode failed")
+		return
+	}
+	h.writeJSON(w, map[string]any{"success": true, "data": map[string]any{"deviceUid": duid, "delta": rawOrNull(delta), "desiredVersion": dv, "reportedVersion": rv}})
+}
+
+// writeHubError 将 hub 的 403/409 透传为对应的 HTTP 状态码
+func (h *TwinHandler) writeHubError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "ERR 403"):
+		h.writeError(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "ERR 409"):
+		h.writeError(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "ERR 400"):
+		h.writeError(w, http.StatusBadRequest, msg)
+	default:
+		h.writeError(w, http.StatusBadGateway, msg)
+	}
+}
+
+func (h *TwinHandler) writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+func (h *TwinHandler) writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": msg})
+}
@@ -19,7 +19,7 @@ func (h *UserHandler) HandleListUsers(w http.ResponseWriter, r *http.Request) {
 		token = token[7:]
 	}
 	if h.hubClient != nil && h.hubClient.IsConnected() {
-		if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeUserListReq, binproto.TypeUserListResp, binproto.EncodeUserMeReq(token), 5*time.Second); err == nil {
+		if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserListReq, binproto.TypeUserListResp, binproto.EncodeUserMeReq(token), 5*time.Second); err == nil {
 			if _, items, derr := binproto.DecodeUserListResp(pld); derr == nil {
 				h.writeJSON(w, map[string]any{"success": true, "data": items})
 				return
@@ -44,7 +44,7 @@ func (h *UserHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	if h.hubClient != nil && h.hubClient.IsConnected() {
 		payload := binproto.EncodeUserCreateReq(token, body.Username, body.DisplayName, body.Password)
-		if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeUserCreateReq, binproto.TypeUserCreateResp, payload, 5*time.Second); err == nil {
+		if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserCreateReq, binproto.TypeUserCreateResp, payload, 5*time.Second); err == nil {
 			if _, id, derr := binproto.DecodeUserCreateResp(pld); derr == nil {
 				h.writeJSON(w, map[string]any{"success": true, "id": id})
 				return
@@ -74,7 +74,7 @@ func (h *UserHandler) HandleUpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	if h.hubClient != nil && h.hubClient.IsConnected() {
 		payload := binproto.EncodeUserUpdateReq(token, body.ID, body.DisplayName, body.Password, body.Disabled)
-		if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeUserUpdateReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserUpdateReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, derr := binproto.DecodeOKResp(pld); derr == nil {
 				h.writeJSON(w, map[string]any{"success": code == 0, "message": string(msg)})
 				return
@@ -101,7 +101,7 @@ func (h *UserHandler) HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
 	}
 	if h.hubClient != nil && h.hubClient.IsConnected() {
 		payload := binproto.EncodeUserDeleteReq(token, body.ID)
-		if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeUserDeleteReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserDeleteReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, derr := binproto.DecodeOKResp(pld); derr == nil {
 				h.writeJSON(w, map[string]any{"success": code == 0, "message": string(msg)})
 				return
@@ -129,7 +129,7 @@ func (h *UserHandler) HandleListUserPerms(w http.ResponseWriter, r *http.Request
 	}
 	if h.hubClient != nil && h.hubClient.IsConnected() {
 		payload := binproto.EncodeUserPermListReq(token, body.UserID)
-		if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeUserPermListReq, binproto.TypeUserPermListResp, payload, 5*time.Second); err == nil {
+		if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserPermListReq, binproto.TypeUserPermListResp, payload, 5*time.Second); err == nil {
 			if _, items, derr := binproto.DecodeUserPermListResp(pld); derr == nil {
 				h.writeJSON(w, map[string]any{"success": true, "data": items})
 				return
@@ -158,7 +158,7 @@ func (h *UserHandler) HandleAddUserPerm(w http.ResponseWriter, r *http.Request)
 	}
 	if h.hubClient != nil && h.hubClient.IsConnected() {
 		payload := binproto.EncodeUserPermAddReq(token, body.UserID, body.Node)
-		if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeUserPermAddReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserPermAddReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, derr := binproto.DecodeOKResp(pld); derr == nil {
 				h.writeJSON(w, map[string]any{"success": code == 0, "message": string(msg)})
 				return
@@ -187,7 +187,7 @@ func (h *UserHandler) HandleRemoveUserPerm(w http.ResponseWriter, r *http.Reques
 	}
 	if h.hubClient != nil && h.hubClient.IsConnected() {
 		payload := binproto.EncodeUserPermRemoveReq(token, body.UserID, body.Node)
-		if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeUserPermRemoveReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserPermRemoveReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, derr := binproto.DecodeOKResp(pld); derr == nil {
 				h.writeJSON(w, map[string]any{"success": code == 0, "message": string(msg)})
 				return
@@ -215,7 +215,7 @@ func (h *UserHandler) HandleSelfUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 	if h.hubClient != nil && h.hubClient.IsConnected() {
 		payload := binproto.EncodeUserSelfUpdateReq(token, body.DisplayName)
-		if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeUserSelfUpdateReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserSelfUpdateReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, derr := binproto.DecodeOKResp(pld); derr == nil {
 				h.writeJSON(w, map[string]any{"success": code == 0, "message": string(msg)})
 				return
@@ -241,7 +241,7 @@ func (h *UserHandler) HandleSelfPassword(w http.ResponseWriter, r *http.Request)
 	}
 	if h.hubClient != nil && h.hubClient.IsConnected() {
 		payload := binproto.EncodeUserSelfPasswordReq(token, body.OldPassword, body.NewPassword)
-		if pld, err := h.hubClient.SendBinaryRequest(binproto.TypeUserSelfPasswordReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if pld, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserSelfPasswordReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, derr := binproto.DecodeOKResp(pld); derr == nil {
 				h.writeJSON(w, map[string]any{"success": code == 0, "message": string(msg)})
 				return
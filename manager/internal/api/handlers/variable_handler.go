@@ -40,7 +40,7 @@ func (h *VariableHandler) HandleGetVariables(w http.ResponseWriter, r *http.Requ
 			}
 		}
 		payload := binproto.EncodeVarListReq(token, devUIDPtr)
-		if resp, err := h.hubClient.SendBinaryRequest(binproto.TypeVarListReq, binproto.TypeVarListResp, payload, 5*time.Second); err == nil {
+		if resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeVarListReq, binproto.TypeVarListResp, payload, 5*time.Second); err == nil {
 			if _, items, e2 := binproto.DecodeVarListResp(resp); e2 == nil {
 				// 兼容前端期望的结构：[]DeviceVariable
 				out := make([]map[string]any, 0, len(items))
@@ -102,7 +102,7 @@ func (h *VariableHandler) HandleUpdateVariable(w http.ResponseWriter, r *http.Re
 			items = append(items, binproto.VarUpdateItem{DeviceUID: uid, Name: name, Value: vb})
 		}
 		payload := binproto.EncodeVarUpdateReq(token, items)
-		if resp, err := h.hubClient.SendBinaryRequest(binproto.TypeVarUpdateReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeVarUpdateReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, e2 := binproto.DecodeOKResp(resp); e2 == nil {
 				if code == 0 {
 					h.writeJSON(w, map[string]any{"success": true})
@@ -140,7 +140,7 @@ func (h *VariableHandler) HandleDeleteVariable(w http.ResponseWriter, r *http.Re
 			}
 		}
 		payload := binproto.EncodeVarDeleteReq(token, items)
-		if resp, err := h.hubClient.SendBinaryRequest(binproto.TypeVarDeleteReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
+		if resp, err := h.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeVarDeleteReq, binproto.TypeOKResp, payload, 5*time.Second); err == nil {
 			if _, code, msg, e2 := binproto.DecodeOKResp(resp); e2 == nil {
 				if code == 0 {
 					h.writeJSON(w, map[string]any{"success": true})
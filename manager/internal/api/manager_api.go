@@ -55,6 +55,9 @@ func (api *ManagerAPI) handleAPI(w http.ResponseWriter, r *http.Request) {
 	userHandler := handlers.NewUserHandler(api.hubClient)
 	keyHandler := handlers.NewKeyHandler(api.hubClient)
 	logHandler := handlers.NewLogHandler(api.hubClient)
+	fileHandler := handlers.NewFileHandler(api.hubClient)
+	otaHandler := handlers.NewOTAHandler(api.hubClient)
+	twinHandler := handlers.NewTwinHandler(api.hubClient)
 
 	// 简单鉴权：除登录外的接口都需要 Authorization: Bearer <token>
 	if path != "auth/login" {
@@ -83,6 +86,12 @@ func (api *ManagerAPI) handleAPI(w http.ResponseWriter, r *http.Request) {
 		deviceHandler.HandleUpdateDevice(w, r)
 	case path == "nodes" && r.Method == "DELETE":
 		deviceHandler.HandleDeleteDevice(w, r)
+	case path == "presence" && r.Method == "GET":
+		deviceHandler.HandleGetPresence(w, r)
+	case path == "nodes/sessions" && r.Method == "GET":
+		deviceHandler.HandleGetSessions(w, r)
+	case path == "nodes/rotate-secret" && r.Method == "POST":
+		deviceHandler.HandleRotateSecret(w, r)
 
 	// 变量相关路由
 	case path == "variables" && r.Method == "GET":
@@ -134,6 +143,28 @@ func (api *ManagerAPI) handleAPI(w http.ResponseWriter, r *http.Request) {
 	// 日志
 	case path == "logs" && (r.Method == "GET" || r.Method == "POST"):
 		logHandler.HandleList(w, r)
+	// 文件上传
+	case path == "files" && r.Method == "POST":
+		fileHandler.HandleUpload(w, r)
+	// OTA 固件与活动
+	case path == "ota/artifacts" && r.Method == "POST":
+		otaHandler.HandleCreateArtifact(w, r)
+	case path == "ota/artifacts" && r.Method == "GET":
+		otaHandler.HandleListArtifacts(w, r)
+	case path == "ota/campaigns" && r.Method == "POST":
+		otaHandler.HandleCreateCampaign(w, r)
+	case path == "ota/campaigns" && r.Method == "GET":
+		otaHandler.HandleListCampaigns(w, r)
+	case path == "ota/campaigns/status" && r.Method == "GET":
+		otaHandler.HandleCampaignStatus(w, r)
+	case path == "ota/campaigns/control" && r.Method == "POST":
+		otaHandler.HandleCampaignControl(w, r)
+	case path == "twin" && r.Method == "GET":
+		twinHandler.HandleGet(w, r)
+	case path == "twin/desired" && r.Method == "PUT":
+		twinHandler.HandleUpdateDesired(w, r)
+	case path == "twin/delta" && r.Method == "GET":
+		twinHandler.HandleDelta(w, r)
 	default:
 		api.writeError(w, http.StatusNotFound, "API endpoint not found")
 	}
@@ -187,7 +218,7 @@ func (api *ManagerAPI) handleLogin(w http.ResponseWriter, r *http.Request) {
 
 	if api.hubClient.IsConnected() {
 		// Prefer binary
-		if payload, err := api.hubClient.SendBinaryRequest(binproto.TypeUserLoginReq, binproto.TypeUserLoginResp, binproto.EncodeUserLoginReq(creds.Username, creds.Password), 5*time.Second); err == nil {
+		if payload, err := api.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserLoginReq, binproto.TypeUserLoginResp, binproto.EncodeUserLoginReq(creds.Username, creds.Password), 5*time.Second); err == nil {
 			// Decode to JSON-like struct
 			reqID, keyID, userID, token, username, displayName, perms, derr := binproto.DecodeUserLoginResp(payload)
 			if derr == nil {
@@ -218,7 +249,7 @@ func (api *ManagerAPI) handleMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	token := authz[7:]
-	if payload, err := api.hubClient.SendBinaryRequest(binproto.TypeUserMeReq, binproto.TypeUserMeResp, binproto.EncodeUserMeReq(token), 5*time.Second); err == nil {
+	if payload, err := api.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserMeReq, binproto.TypeUserMeResp, binproto.EncodeUserMeReq(token), 5*time.Second); err == nil {
 		if reqID, userID, username, displayName, perms, derr := binproto.DecodeUserMeResp(payload); derr == nil {
 			api.writeJSON(w, map[string]any{
 				"success":     true,
@@ -244,7 +275,7 @@ func (api *ManagerAPI) handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	token := authz[7:]
-	if payload, err := api.hubClient.SendBinaryRequest(binproto.TypeUserLogoutReq, binproto.TypeOKResp, binproto.EncodeUserLogoutReq(token), 5*time.Second); err == nil {
+	if payload, err := api.hubClient.SendBinaryRequestContext(r.Context(), binproto.TypeUserLogoutReq, binproto.TypeOKResp, binproto.EncodeUserLogoutReq(token), 5*time.Second); err == nil {
 		if _, code, msgb, derr := binproto.DecodeOKResp(payload); derr == nil {
 			api.writeJSON(w, map[string]any{"success": code == 0, "message": string(msgb)})
 			return
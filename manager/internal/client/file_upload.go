@@ -0,0 +1,172 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"myflowhub/pkg/config"
+	binproto "myflowhub/pkg/protocol/binproto"
+	"myflowhub/pkg/protocol/binproto/filexfer"
+
+	"github.com/rs/zerolog/log"
+)
+
+// upload 一次进行中的上传
+type upload struct {
+	target uint64
+	sender *filexfer.Sender
+	fileID uint64
+}
+
+// uploadTable 按 transfer_id 索引进行中的上传，供 readPump 分发响应
+type uploadTable struct {
+	mu sync.Mutex
+	m  map[uint64]*upload
+}
+
+// UploadFile 以分片协议将 src 上传到 target（0 表示当前连接的 Hub），返回 Hub 分配的文件 ID。
+// 连接中断后会在重连认证成功时自动续传；timeout 为整体超时。
+func (c *HubClient) UploadFile(target uint64, src io.ReaderAt, size int64, filename, mime string, timeout time.Duration) (uint64, error) {
+	if !c.IsConnected() {
+		return 0, ErrNotConnected
+	}
+	hash, err := filexfer.HashReaderAt(src, size)
+	if err != nil {
+		return 0, err
+	}
+	var rnd [8]byte
+	if _, err := rand.Read(rnd[:]); err != nil {
+		return 0, err
+	}
+	init := binproto.FileInit{
+		TransferID: binary.LittleEndian.Uint64(rnd[:]),
+		TotalSize:  uint64(size),
+		Mime:       mime,
+		Filename:   filename,
+		FileHash:   hash,
+		ChunkSize:  uint32(config.AppConfig.File.ChunkSize),
+	}
+	up := &upload{target: target}
+	up.sender = filexfer.NewSender(src, init, func(typeID uint16, payload []byte) error {
+		return c.sendFileFrame(target, typeID, payload)
+	})
+	c.uploads.mu.Lock()
+	if c.uploads.m == nil {
+		c.uploads.m = make(map[uint64]*upload)
+	}
+	c.uploads.m[init.TransferID] = up
+	c.uploads.mu.Unlock()
+	defer func() {
+		c.uploads.mu.Lock()
+		delete(c.uploads.m, init.TransferID)
+		c.uploads.mu.Unlock()
+	}()
+
+	if err := up.sender.Start(); err != nil {
+		return 0, err
+	}
+	ackTimeout := time.Duration(config.AppConfig.File.AckTimeout) * time.Second
+	if ackTimeout <= 0 {
+		ackTimeout = 10 * time.Second
+	}
+	ticker := time.NewTicker(ackTimeout / 2)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case err := <-up.sender.Done():
+			if err != nil {
+				return 0, err
+			}
+			return up.fileID, nil
+		case <-ticker.C:
+			// 断线期间不重传，等待重连后续传
+			if c.IsConnected() {
+				if err := up.sender.CheckTimeout(ackTimeout); err != nil {
+					log.Warn().Err(err).Uint64("transferID", init.TransferID).Msg("文件分片重传失败")
+				}
+			}
+		case <-deadline:
+			_ = up.sender.Cancel("timeout")
+			return 0, ErrTimeout
+		case <-c.quitCh:
+			return 0, ErrClientClosed
+		}
+	}
+}
+
+// sendFileFrame 通过单写协程发送文件分片帧
+func (c *HubClient) sendFileFrame(target uint64, typeID uint16, payload []byte) error {
+	h := binproto.HeaderV1{TypeID: typeID, MsgID: c.nextMsgID(), Source: c.deviceID, Target: target, Timestamp: time.Now().UnixMilli()}
+	frame, err := binproto.EncodeFrame(h, payload)
+	if err != nil {
+		return err
+	}
+	return c.ConnWriteBinary(frame)
+}
+
+// dispatchFileFrame 将文件协议响应分发给对应的上传（在 readPump 中调用）
+func (c *HubClient) dispatchFileFrame(h binproto.HeaderV1, pl []byte) {
+	var transferID uint64
+	var err error
+	switch h.TypeID {
+	case binproto.TypeFileInitResp:
+		_, transferID, _, _, _, _, err = binproto.DecodeFileInitResp(pl)
+	case binproto.TypeFileChunkAck:
+		transferID, _, err = binproto.DecodeFileChunkAck(pl)
+	case binproto.TypeFileCompleteResp:
+		_, transferID, _, _, err = binproto.DecodeFileCompleteResp(pl)
+	case binproto.TypeFileCancel:
+		transferID, _, err = binproto.DecodeFileCancel(pl)
+	default:
+		return
+	}
+	if err != nil {
+		log.Warn().Err(err).Uint16("typeID", h.TypeID).Msg("解析文件协议响应失败")
+		return
+	}
+	c.uploads.mu.Lock()
+	up, ok := c.uploads.m[transferID]
+	c.uploads.mu.Unlock()
+	if !ok {
+		return
+	}
+	switch h.TypeID {
+	case binproto.TypeFileInitResp:
+		_, _, okResp, resume, chunkSize, window, _ := binproto.DecodeFileInitResp(pl)
+		err = up.sender.HandleInitResp(okResp, resume, chunkSize, window)
+	case binproto.TypeFileChunkAck:
+		_, next, _ := binproto.DecodeFileChunkAck(pl)
+		err = up.sender.HandleAck(next)
+	case binproto.TypeFileCompleteResp:
+		_, _, okResp, fileID, _ := binproto.DecodeFileCompleteResp(pl)
+		up.fileID = fileID
+		up.sender.HandleCompleteResp(okResp)
+	case binproto.TypeFileCancel:
+		_, reason, _ := binproto.DecodeFileCancel(pl)
+		up.sender.HandleCancel(reason)
+	}
+	if err != nil {
+		log.Warn().Err(err).Uint64("transferID", transferID).Uint16("typeID", h.TypeID).Msg("处理文件协议响应失败")
+	}
+}
+
+// resumeUploads 重连认证成功后对所有进行中的上传重新发送 INIT 以断点续传
+func (c *HubClient) resumeUploads() {
+	c.uploads.mu.Lock()
+	ups := make([]*upload, 0, len(c.uploads.m))
+	for _, up := range c.uploads.m {
+		ups = append(ups, up)
+	}
+	c.uploads.mu.Unlock()
+	for _, up := range ups {
+		if err := up.sender.Resume(); err != nil {
+			log.Warn().Err(err).Uint64("transferID", up.sender.TransferID()).Msg("续传失败")
+			continue
+		}
+		log.Info().Uint64("transferID", up.sender.TransferID()).Uint64("target", up.target).Uint64("acked", up.sender.Acked()).Msg("重连后续传文件")
+	}
+}
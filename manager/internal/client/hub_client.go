@@ -1,9 +1,17 @@
 package client
 
 import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -30,8 +38,24 @@ type HubClient struct {
 	binWaiters map[uint64]chan binproto.HeaderV1
 	msgSeq     uint64
 	Send       chan []byte
+	// 进行中的文件上传（按 transfer_id 分发响应）
+	uploads uploadTable
 	// 控制帧：用于通过单写协程发送 Pong，避免与业务写并发
 	pongCh chan string
+	// 帧认证：每次连接前准备认证请求的 MsgID 与临时密钥；readPump 收到认证响应后设置 rxMAC/txMAC 并关闭 authDone，
+	// writePump 写出认证请求后等待 authDone 再写后续帧，保证之后的帧均附带标签
+	authMsgID uint64
+	macPriv   *ecdh.PrivateKey
+	authDone  chan struct{}
+	rxMAC     *binproto.FrameMAC // 仅 readPump 使用
+	txMAC     *binproto.FrameMAC // readPump 在关闭 authDone 前设置，之后仅 writePump 使用
+	// 会话恢复：resumeToken 为 Hub 下发的恢复令牌，resumeSeq 为已收到的下行消息序号（仅 readPump 与 prepareAuth 使用）；
+	// resumeReq 非空表示本次连接以 RESUME_REQ 代替完整认证（prepareAuth 设置）
+	resumeToken string
+	resumeSeq   uint64
+	resumeReq   []byte
+	// deadlines Hub 在 HELLO 应答中同意 deadline 特性，请求可附带截止时刻（readPump 设置，prepareAuth 重置）
+	deadlines atomic.Bool
 
 	// 连接状态
 	connected bool
@@ -73,6 +97,20 @@ func (c *HubClient) Connect() error {
 
 	dialer := *websocket.DefaultDialer
 	dialer.Subprotocols = []string{"myflowhub.bin.v1"}
+	// 请求 permessage-deflate，Hub 接受时设备列表等较大响应按消息压缩
+	dialer.EnableCompression = true
+	// wss：配置 Hub.CAFile 时仅信任该 CA
+	if caFile := config.AppConfig.Hub.CAFile; caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in %s", caFile)
+		}
+		dialer.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
 	conn, _, err := dialer.Dial(u.String(), http.Header{})
 	if err != nil {
 		return err
@@ -90,6 +128,11 @@ func (c *HubClient) Connect() error {
 	// 为本次连接创建独立的关闭信号
 	c.connDone = make(chan struct{})
 	c.pongCh = make(chan string, 8)
+	if err := c.prepareAuth(); err != nil {
+		c.conn.Close()
+		c.setConnected(false)
+		return err
+	}
 
 	// 启动读写协程
 	go c.writePump()
@@ -105,11 +148,48 @@ func (c *HubClient) Connect() error {
 	return nil
 }
 
+// prepareAuth 为本次连接准备认证请求 MsgID 与帧认证临时密钥（FrameMAC.Mode 为 off 时不协商）
+func (c *HubClient) prepareAuth() error {
+	c.authMsgID = c.nextMsgID()
+	c.authDone = make(chan struct{})
+	c.macPriv, c.rxMAC, c.txMAC, c.resumeReq = nil, nil, nil, nil
+	c.deadlines.Store(false)
+	var macPub []byte
+	if config.AppConfig.FrameMAC.Mode != "off" {
+		k, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		c.macPriv, macPub = k, k.PublicKey().Bytes()
+	}
+	// 持有恢复令牌时以 RESUME_REQ 恢复断线前的会话，Hub 随后重放未送达的帧；被拒绝时断开并以完整认证重连
+	if c.resumeToken != "" {
+		c.resumeReq = binproto.EncodeResumeReq(c.resumeToken, c.resumeSeq, macPub)
+	}
+	return nil
+}
+
 // authenticate 使用管理员令牌进行认证
 func (c *HubClient) authenticate() error {
-	// 二进制：发送 ManagerAuthReq 帧
-	payload := binproto.EncodeManagerAuthReq(c.managerToken)
-	h := binproto.HeaderV1{TypeID: binproto.TypeManagerAuthReq, MsgID: c.nextMsgID(), Source: 0, Target: 0, Timestamp: time.Now().UnixMilli()}
+	// 连接握手：先声明协议版本与所用特性（旧版 Hub 以错误响应拒绝 HELLO，不影响认证）
+	hello := binproto.EncodeHelloReq(binproto.Hello{Versions: []uint32{1}, Features: []string{binproto.FeatureFile, binproto.FeatureResume, binproto.FeatureDeadline}, ClientName: "myflowhub-manager"})
+	hh := binproto.HeaderV1{TypeID: binproto.TypeHelloReq, MsgID: c.nextMsgID(), Source: 0, Target: 0, Timestamp: time.Now().UnixMilli()}
+	if hf, err := binproto.EncodeFrame(hh, hello); err == nil {
+		c.Send <- hf
+	}
+	if c.resumeReq != nil {
+		h := binproto.HeaderV1{TypeID: binproto.TypeResumeReq, MsgID: c.authMsgID, Timestamp: time.Now().UnixMilli()}
+		frame, _ := binproto.EncodeFrame(h, c.resumeReq)
+		c.Send <- frame
+		return nil
+	}
+	// 二进制：发送 ManagerAuthReq 帧（携带帧认证临时公钥）
+	var macPub []byte
+	if c.macPriv != nil {
+		macPub = c.macPriv.PublicKey().Bytes()
+	}
+	payload := binproto.EncodeManagerAuthReq(c.managerToken, macPub)
+	h := binproto.HeaderV1{TypeID: binproto.TypeManagerAuthReq, MsgID: c.authMsgID, Source: 0, Target: 0, Timestamp: time.Now().UnixMilli()}
 	frame, _ := binproto.EncodeFrame(h, payload)
 	c.Send <- frame
 	return nil
@@ -148,6 +228,7 @@ func (c *HubClient) readPump() {
 		return nil
 	})
 
+	authed := false
 	for {
 		log.Debug().Msg("readPump: 等待读取消息...")
 		mt, data, err := c.conn.ReadMessage()
@@ -161,6 +242,15 @@ func (c *HubClient) readPump() {
 		// 每次成功读取都刷新读超时，提升稳健性
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
 		log.Debug().Int("type", mt).Int("bytes", len(data)).Msg("readPump: 成功读取消息")
+		if c.rxMAC != nil {
+			if data, err = c.rxMAC.Open(data); err != nil {
+				log.Error().Err(err).Msg("帧认证失败，断开并重连")
+				break
+			}
+		} else if fh := (binproto.HeaderV1{}); fh.Decode(data) == nil && fh.Flags&binproto.FlagMAC != 0 {
+			log.Warn().Uint64("msgID", fh.MsgID).Msg("readPump: 链路未协商帧认证，丢弃带 FlagMAC 的帧")
+			continue
+		}
 
 		if h, pl, err := binproto.DecodeFrame(data); err == nil {
 			c.storeLastPayload(h.MsgID, pl)
@@ -176,16 +266,115 @@ func (c *HubClient) readPump() {
 			} else {
 				c.binRespMu.Unlock()
 			}
-			if h.TypeID == binproto.TypeManagerAuthResp {
-				if _, uid, _, e := binproto.DecodeManagerAuthResp(pl); e == nil {
-					c.deviceID = uid
-					log.Info().Uint64("deviceID", c.deviceID).Msg("管理员(二进制)认证成功")
+			if h.MsgID == c.authMsgID && (h.TypeID == binproto.TypeManagerAuthResp || h.TypeID == binproto.TypeResumeResp || h.TypeID == binproto.TypeErrResp) {
+				if err := c.finishAuth(h, pl); err != nil {
+					log.Error().Err(err).Msg("认证未完成，断开并重连")
+					break
+				}
+				authed = true
+				// 完整认证时令牌通知先于认证响应到达，认证响应本身计入序号；恢复应答不计入
+				if c.resumeReq == nil {
+					c.countResumeSeq(h, pl)
+				}
+			} else if authed || c.resumeReq == nil {
+				// 恢复完成前的下行消息（如 HELLO 应答）不计入序号
+				c.countResumeSeq(h, pl)
+			}
+			if h.TypeID == binproto.TypeHelloResp {
+				if _, r, err := binproto.DecodeHelloResp(pl); err == nil {
+					c.deadlines.Store(slices.Contains(r.Features, binproto.FeatureDeadline))
+					log.Info().Str("hub", r.HardwareID).Uint32("version", r.Version).Strs("features", r.Features).Msg("连接握手完成")
 				}
 			}
+			if binproto.IsFileTransferType(h.TypeID) {
+				c.dispatchFileFrame(h, pl)
+			}
 		}
 	}
 }
 
+// finishAuth 处理认证或恢复响应：成功且 Hub 应答公钥时启用帧认证，随后放行写协程；被拒或应答异常时返回错误（仅 readPump 调用）
+func (c *HubClient) finishAuth(h binproto.HeaderV1, pl []byte) error {
+	defer close(c.authDone)
+	if c.resumeReq != nil {
+		return c.finishResume(h, pl)
+	}
+	// 认证被拒或应答无法解析时不得以未认证（且未启用帧认证）的链路继续
+	switch h.TypeID {
+	case binproto.TypeManagerAuthResp:
+	case binproto.TypeErrResp:
+		_, code, msg, _ := binproto.DecodeErrResp(pl)
+		return fmt.Errorf("manager auth rejected: %d %s", code, msg)
+	default:
+		return fmt.Errorf("unexpected auth response type %d", h.TypeID)
+	}
+	_, uid, _, peerPub, err := binproto.DecodeManagerAuthResp(pl)
+	if err != nil {
+		return fmt.Errorf("decode manager auth response: %w", err)
+	}
+	if err := c.enableFrameMAC(peerPub, []byte(c.managerToken)); err != nil {
+		return err
+	}
+	c.deviceID = uid
+	log.Info().Uint64("deviceID", c.deviceID).Bool("frameMAC", c.txMAC != nil).Msg("管理员(二进制)认证成功")
+	// 认证完成后续传断线前未完成的上传
+	go c.resumeUploads()
+	return nil
+}
+
+// finishResume 处理 RESUME_RESP：恢复设备身份并以 Hub 给出的重放起点继续计数；被拒绝时清除令牌，重连后完整认证
+func (c *HubClient) finishResume(h binproto.HeaderV1, pl []byte) error {
+	binding := []byte(c.resumeToken)
+	c.resumeToken = ""
+	if h.TypeID != binproto.TypeResumeResp {
+		_, code, msg, _ := binproto.DecodeErrResp(pl)
+		return fmt.Errorf("resume rejected: %d %s", code, msg)
+	}
+	_, r, err := binproto.DecodeResumeResp(pl)
+	if err != nil {
+		return err
+	}
+	if err := c.enableFrameMAC(r.FrameMACPub, binding); err != nil {
+		return err
+	}
+	if r.Seq > c.resumeSeq {
+		log.Warn().Uint64("lastSeq", c.resumeSeq).Uint64("seq", r.Seq).Msg("部分断线期间的消息已超出 Hub 缓存")
+	}
+	c.deviceID, c.resumeToken, c.resumeSeq = r.DeviceUID, r.Token, r.Seq
+	log.Info().Uint64("deviceID", c.deviceID).Uint32("replayed", r.Replayed).Bool("frameMAC", c.txMAC != nil).Msg("会话已恢复")
+	go c.resumeUploads()
+	return nil
+}
+
+// countResumeSeq 记录恢复令牌并为下行消息计数，恢复时据此请求重放（仅 readPump 调用）
+func (c *HubClient) countResumeSeq(h binproto.HeaderV1, pl []byte) {
+	if h.TypeID == binproto.TypeResumeTokenNotify {
+		if token, seq, _, err := binproto.DecodeResumeTokenNotify(pl); err == nil {
+			c.resumeToken, c.resumeSeq = token, seq
+		}
+		return
+	}
+	if c.resumeToken != "" {
+		c.resumeSeq++
+	}
+}
+
+// enableFrameMAC Hub 应答公钥时以 binding 派生链路密钥并启用帧认证；require 模式下 Hub 未应答则失败
+func (c *HubClient) enableFrameMAC(peerPub, binding []byte) error {
+	if c.macPriv != nil && len(peerPub) > 0 {
+		myPub := c.macPriv.PublicKey().Bytes()
+		key, err := binproto.DeriveFrameMACKey(c.macPriv, peerPub, myPub, peerPub, binding)
+		if err != nil {
+			return err
+		}
+		c.rxMAC = binproto.NewFrameMAC(key, binproto.FrameMACServerToClient)
+		c.txMAC = binproto.NewFrameMAC(key, binproto.FrameMACClientToServer)
+	} else if config.AppConfig.FrameMAC.Mode == "require" {
+		return fmt.Errorf("hub did not negotiate frame mac")
+	}
+	return nil
+}
+
 // storeLast holds recent binary payloads by MsgID for short time.
 var binPayloadStore = struct {
 	mu sync.RWMutex
@@ -221,11 +410,22 @@ func (c *HubClient) loadLastPayload(msgID uint64) ([]byte, bool) {
 
 // SendBinaryRequest 发送二进制请求并等待指定响应类型
 func (c *HubClient) SendBinaryRequest(typeIDReq, typeIDResp uint16, payload []byte, timeout time.Duration) ([]byte, error) {
+	return c.SendBinaryRequestContext(context.Background(), typeIDReq, typeIDResp, payload, timeout)
+}
+
+// SendBinaryRequestContext 同 SendBinaryRequest，并受 ctx 约束：Hub 同意 deadline 特性时截止时间（ctx 与 timeout 中较早者）
+// 随请求发送（FlagDeadline），Hub 超时后中止处理；ctx 取消或超时时撤销等待并发送 CANCEL_REQ，超时返回 ErrTimeout
+func (c *HubClient) SendBinaryRequestContext(ctx context.Context, typeIDReq, typeIDResp uint16, payload []byte, timeout time.Duration) ([]byte, error) {
 	if !c.IsConnected() {
 		return nil, ErrNotConnected
 	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 	msgID := c.nextMsgID()
 	h := binproto.HeaderV1{TypeID: typeIDReq, MsgID: msgID, Source: c.deviceID, Target: 0, Timestamp: time.Now().UnixMilli()}
+	if deadline, _ := ctx.Deadline(); c.deadlines.Load() {
+		payload = binproto.AppendDeadline(&h, payload, deadline)
+	}
 	frame, _ := binproto.EncodeFrame(h, payload)
 	ch := make(chan binproto.HeaderV1, 1)
 	c.binRespMu.Lock()
@@ -236,6 +436,9 @@ func (c *HubClient) SendBinaryRequest(typeIDReq, typeIDResp uint16, payload []by
 	// 将请求放入发送队列
 	select {
 	case c.Send <- frame:
+	case <-ctx.Done():
+		c.dropWaiter(msgID)
+		return nil, requestCtxErr(ctx)
 	case <-c.connDone:
 		return nil, ErrClientClosed
 	}
@@ -259,17 +462,45 @@ func (c *HubClient) SendBinaryRequest(typeIDReq, typeIDResp uint16, payload []by
 			return p, nil
 		}
 		return nil, fmt.Errorf("payload missing")
-	case <-time.After(timeout):
-		c.binRespMu.Lock()
-		delete(c.binWaiters, msgID)
-		c.binRespMu.Unlock()
-		log.Error().Uint64("msgID", msgID).Uint16("typeIDReq", typeIDReq).Dur("elapsed", time.Since(start)).Msg("SendBinaryRequest: timeout")
-		return nil, ErrTimeout
+	case <-ctx.Done():
+		c.dropWaiter(msgID)
+		c.sendCancel(msgID)
+		log.Error().Err(ctx.Err()).Uint64("msgID", msgID).Uint16("typeIDReq", typeIDReq).Dur("elapsed", time.Since(start)).Msg("SendBinaryRequest: timeout")
+		return nil, requestCtxErr(ctx)
 	case <-c.connDone:
 		return nil, ErrClientClosed
 	}
 }
 
+// dropWaiter 撤销对 msgID 响应的等待，迟到的响应由 readPump 丢弃
+func (c *HubClient) dropWaiter(msgID uint64) {
+	c.binRespMu.Lock()
+	delete(c.binWaiters, msgID)
+	c.binRespMu.Unlock()
+}
+
+// sendCancel 通知 Hub 放弃处理 msgID 对应的请求（不等待应答；Hub 未同意 deadline 特性或发送队列已满时放弃）
+func (c *HubClient) sendCancel(msgID uint64) {
+	if !c.deadlines.Load() {
+		return
+	}
+	h := binproto.HeaderV1{TypeID: binproto.TypeCancelReq, MsgID: c.nextMsgID(), Source: c.deviceID, Target: 0, Timestamp: time.Now().UnixMilli()}
+	frame, _ := binproto.EncodeFrame(h, binproto.EncodeCancelReq(msgID))
+	select {
+	case c.Send <- frame:
+	default:
+		log.Warn().Uint64("msgID", msgID).Msg("发送队列已满，未发送 CANCEL_REQ")
+	}
+}
+
+// requestCtxErr 超时映射为 ErrTimeout，调用方取消时返回 ctx 的错误
+func requestCtxErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ctx.Err()
+}
+
 // nextMsgID 生成全局唯一的消息ID，减少并发冲突
 func (c *HubClient) nextMsgID() uint64 {
 	seq := atomic.AddUint64(&c.msgSeq, 1)
@@ -305,6 +536,7 @@ func (c *HubClient) writePump() {
 	defer func() {
 		c.conn.Close()
 	}()
+	authed := false
 	for {
 		select {
 		case message, ok := <-c.Send:
@@ -316,11 +548,23 @@ func (c *HubClient) writePump() {
 				log.Info().Msg("writePump: channel 已关闭，正常退出")
 				return
 			}
+			if c.txMAC != nil {
+				message = c.txMAC.Seal(message)
+			}
 			if err := c.conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
 				log.Error().Err(err).Msg("writePump: 写入二进制消息失败")
 				return
 			}
 			log.Debug().Int("bytes", len(message)).Msg("writePump: 成功写入消息")
+			if !authed && isFrameMsgID(message, c.authMsgID) {
+				// 认证响应到达（启用或放弃帧认证）之前不写后续帧
+				select {
+				case <-c.authDone:
+					authed = true
+				case <-c.connDone:
+					return
+				}
+			}
 		case appData := <-c.pongCh:
 			// 通过单写协程发送 Pong 控制帧
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
@@ -406,3 +650,9 @@ const (
 	// 提高读取上限，避免较大二进制帧触发 read limit exceeded 进而导致 1006/EOF
 	maxMessageSize = 4 * 1024 * 1024 // 4MB
 )
+
+// isFrameMsgID 判断帧头 MsgID 是否为 msgID
+func isFrameMsgID(frame []byte, msgID uint64) bool {
+	var h binproto.HeaderV1
+	return h.Decode(frame) == nil && h.MsgID == msgID
+}
@@ -21,6 +21,14 @@ type Config struct {
 		HardwareID   string `json:"HardwareID"`
 		ManagerToken string `json:"ManagerToken"`
 		RelayToken   string `json:"RelayToken"`
+		// 轮换 RelayToken 时保留的旧令牌，RelayTokenPrevUntil（RFC3339）之前 ParentAuth 仍接受
+		RelayTokenPrev      string `json:"RelayTokenPrev"`
+		RelayTokenPrevUntil string `json:"RelayTokenPrevUntil"`
+		// 下级中继 ParentAuth 会话：有效期（秒，默认 3600，到期前中继须重新认证）与授予的权限（默认 forward/presence/cert）
+		ParentSessionSec int      `json:"ParentSessionSec"`
+		RelayPerms       []string `json:"RelayPerms"`
+		// 监听 TLS（HTTPS/WSS 与 gRPC）：CertFile/KeyFile 同时配置时启用，文件变化后自动重新加载
+		TLS          ListenerTLS `json:"TLS"`
 		DefaultAdmin struct {
 			Username string `json:"Username"`
 			Password string `json:"Password"`
@@ -28,6 +36,7 @@ type Config struct {
 	} `json:"Server"`
 	Hub struct {
 		Address string `json:"Address"`
+		CAFile  string `json:"CAFile"` // Address 为 wss:// 时固定信任的 CA（为空使用系统根证书）
 	} `json:"Hub"`
 	Relay struct {
 		Enabled     bool   `json:"Enabled"`
@@ -35,12 +44,142 @@ type Config struct {
 		ListenAddr  string `json:"ListenAddr"`
 		HardwareID  string `json:"HardwareID"`
 		SharedToken string `json:"SharedToken"`
+		// 上级链路 TLS（ParentAddr 为 wss:// 时生效）
+		TLS DialTLS `json:"TLS"`
+		// 同步上级内置 CA 吊销列表的周期（秒，默认 300）；本地监听启用 mTLS 时生效
+		CRLSyncSec int `json:"CRLSyncSec"`
+		// 为 true 时不接受未签名的 ManagerAuthResp，也不再以同一令牌回退旧的 ManagerAuth
+		DisableManagerAuth bool `json:"DisableManagerAuth"`
 	} `json:"Relay"`
 	// WebSocket 全局配置（server 与 manager 共同使用）
 	WS struct {
 		// Send 队列容量（默认 256）
 		SendQueueSize int `json:"SendQueueSize"`
 	} `json:"WS"`
+	// 文件分片传输（Hub 侧存储与收发参数）
+	File struct {
+		StorageDir  string `json:"StorageDir"`  // 上传文件存储目录，默认 ./data/files
+		ChunkSize   int    `json:"ChunkSize"`   // 分片大小（字节），默认 128KB，范围 4KB~256KB
+		Window      int    `json:"Window"`      // 在途未确认分片数，默认 4
+		MaxFileSize int64  `json:"MaxFileSize"` // 单文件上限（字节），默认 1GB
+		AckTimeout  int    `json:"AckTimeout"`  // 发送端确认超时（秒），默认 10
+	} `json:"File"`
+	// 原始 TCP 接入（受限设备）：4 字节小端长度前缀 + 二进制帧；ListenAddr 为空时不启用
+	TCP struct {
+		ListenAddr string `json:"ListenAddr"` // 如 :8082
+		CertFile   string `json:"CertFile"`   // 与 KeyFile 同时配置时启用 TLS
+		KeyFile    string `json:"KeyFile"`
+	} `json:"TCP"`
+	// 内置 MQTT 3.1.1 网桥：ListenAddr 为空时不启用
+	MQTT struct {
+		ListenAddr string `json:"ListenAddr"` // 如 :1883
+		CertFile   string `json:"CertFile"`   // 与 KeyFile 同时配置时启用 TLS（如 :8883）
+		KeyFile    string `json:"KeyFile"`
+	} `json:"MQTT"`
+	// CoAP 网关（UDP）：ListenAddr 为空时不启用
+	CoAP struct {
+		ListenAddr     string `json:"ListenAddr"`     // 如 :5683
+		SessionIdleSec int    `json:"SessionIdleSec"` // 会话空闲超时（秒，默认 600，适配休眠节点）
+	} `json:"CoAP"`
+	// Modbus TCP 轮询适配器：每个适配器作为一个虚拟设备出现在设备树中
+	Modbus struct {
+		Adapters []ModbusAdapter `json:"Adapters"`
+	} `json:"Modbus"`
+	// 心跳与在线状态
+	Presence struct {
+		HeartbeatSec     int `json:"HeartbeatSec"`     // 心跳周期（秒），随 ParentAuthResp 下发，默认 30
+		MissedHeartbeats int `json:"MissedHeartbeats"` // 连续错过心跳次数上限，超过即判定离线，默认 3
+	} `json:"Presence"`
+	// 设备认证：Ed25519 挑战-应答（TypeID 380~384）；设备密钥（bcrypt）认证在迁移期间保留
+	DeviceAuth struct {
+		DisableSecret   bool `json:"DisableSecret"`   // 为 true 时拒绝设备密钥认证（MQTT/CoAP/HTTP 仍可用 userKey），也不能再以密钥登记公钥
+		ChallengeTTLSec int  `json:"ChallengeTTLSec"` // 挑战 nonce 有效期（秒），默认 30
+		RotateGraceSec  int  `json:"RotateGraceSec"`  // 设备密钥轮换后旧密钥的默认宽限期（秒），默认 3600，上限 7 天
+	} `json:"DeviceAuth"`
+	// 签名请求（ParentAuth、公钥轮换签名等）的 nonce 防重放存储
+	Nonce struct {
+		TTLSec     int  `json:"TTLSec"`     // nonce 保留时长（秒），默认且最小 600，以覆盖请求时间窗（±5 分钟）
+		MaxEntries int  `json:"MaxEntries"` // 内存中保留的 nonce 上限，默认 100000；达到上限时拒绝新请求
+		Persist    bool `json:"Persist"`    // 为 true 时同时写入数据库，重启后及多个 Hub 进程间仍保持唯一
+	} `json:"Nonce"`
+	// 二进制链路（WS 二进制 / 原始 TCP）的帧认证：认证时协商链路密钥，此后每帧附带 MAC
+	FrameMAC struct {
+		Mode string `json:"Mode"` // off：不协商；negotiate（默认）：对端提供公钥时启用；require：认证须协商帧认证，否则拒绝（426）
+	} `json:"FrameMAC"`
+	// 二进制协议 v2 分片：发往 v2 对端的帧按 FragmentSize 拆分，接收方在限额内重组
+	Fragment struct {
+		FragmentSize    int `json:"FragmentSize"`    // 单个分片的负载上限（字节），默认 1048576，不超过单帧上限 4MB
+		MaxMessageBytes int `json:"MaxMessageBytes"` // 单条链路上重组中的负载总量上限（字节），默认 67108864
+		MaxPending      int `json:"MaxPending"`      // 单条链路上同时重组的消息数上限，默认 16
+		TimeoutSec      int `json:"TimeoutSec"`      // 未完成消息的保留时长（秒），默认 60
+	} `json:"Fragment"`
+	// 压缩：WS 链路使用 permessage-deflate；原始 TCP 等非 WS 链路（或未启用 permessage-deflate 的链路）经 HELLO 协商帧级压缩
+	Compression struct {
+		Disabled bool   `json:"Disabled"` // 关闭压缩（监听、上级链路与帧级压缩）
+		MinBytes int    `json:"MinBytes"` // 负载不小于该值才压缩（字节），默认 256
+		Level    int    `json:"Level"`    // deflate 压缩级别 1-9，默认 6
+		DictFile string `json:"DictFile"` // 帧级压缩的预置字典文件（可选），双方持有同一份字典时使用
+	} `json:"Compression"`
+	// 会话恢复：HELLO 协商 resume 的连接认证后获得恢复令牌，断线后在宽限期内以 RESUME_REQ 恢复并重放未送达的帧
+	Resume struct {
+		Disabled     bool `json:"Disabled"`     // 关闭会话恢复（HELLO 不再协商 resume）
+		GraceSec     int  `json:"GraceSec"`     // 断线后会话保留时长（秒），默认 60
+		BufferFrames int  `json:"BufferFrames"` // 每个会话缓存的下行帧数上限，默认 128
+		BufferBytes  int  `json:"BufferBytes"`  // 每个会话缓存的下行字节上限，默认 1048576
+	} `json:"Resume"`
+	// 请求时限：Hub 在各连接的工作协程内按序处理二进制请求，超过时限的请求被取消（数据库查询随之中止）并返回 ERR 504；
+	// 协商 deadline 特性的请求可携带更早的截止时间（FlagDeadline）
+	Deadline struct {
+		DefaultMs int            `json:"DefaultMs"` // 默认时限（毫秒），默认 5000
+		PerType   map[string]int `json:"PerType"`   // 按 TypeID 覆盖时限（毫秒），键为注册表名称（如 OTA_CAMPAIGN_CREATE_REQ）或十进制 TypeID
+		Workers   int            `json:"Workers"`   // 同时执行的请求数上限（全部连接合计），默认 64
+	} `json:"Deadline"`
+	// 内置 CA：为已审批设备签发短期客户端证书（CSR TypeID 370），并维护吊销列表（TypeID 372）
+	CA struct {
+		Enabled      bool   `json:"Enabled"`
+		CertFile     string `json:"CertFile"`     // CA 证书，默认 ./data/ca/ca.crt；与 KeyFile 均不存在时自动生成自签根 CA
+		KeyFile      string `json:"KeyFile"`      // CA 私钥，默认 ./data/ca/ca.key
+		ChainFile    string `json:"ChainFile"`    // CertFile 为中间 CA 时的上级证书链（PEM，可选），随签发结果下发
+		CertTTLHours int    `json:"CertTTLHours"` // 签发证书有效期（小时），默认 24
+	} `json:"CA"`
+}
+
+// ListenerTLS 监听端 TLS；配置 ClientCAFile 时校验客户端证书（mTLS），证书 Subject CN 视为下级 HardwareID
+type ListenerTLS struct {
+	CertFile          string `json:"CertFile"`
+	KeyFile           string `json:"KeyFile"`
+	ClientCAFile      string `json:"ClientCAFile"`      // 签发下级客户端证书的 CA
+	RequireClientCert bool   `json:"RequireClientCert"` // 为 true 时拒绝未携带有效客户端证书的连接
+}
+
+// DialTLS 拨号端 TLS
+type DialTLS struct {
+	CAFile     string `json:"CAFile"`   // 固定信任的 CA（为空使用系统根证书）
+	CertFile   string `json:"CertFile"` // 客户端证书（mTLS），与 KeyFile 同时配置时出示；CN 应为本节点 HardwareID
+	KeyFile    string `json:"KeyFile"`
+	ServerName string `json:"ServerName"` // 覆盖证书校验用的服务器名（可选）
+	// 为 true 时向上级内置 CA 申请并自动续期 CertFile/KeyFile（文件不存在时先以共享令牌认证）
+	AutoEnroll bool `json:"AutoEnroll"`
+}
+
+// ModbusAdapter 单个 Modbus TCP 端点及其寄存器映射
+type ModbusAdapter struct {
+	Name      string           `json:"Name"`      // 适配器名称，亦为虚拟设备名（HardwareID 为 modbus:<Name>）
+	Endpoint  string           `json:"Endpoint"`  // 如 192.168.1.10:502
+	UnitID    uint8            `json:"UnitID"`    // 从站地址，默认 1
+	PollMs    int              `json:"PollMs"`    // 轮询周期（毫秒），默认 1000
+	TimeoutMs int              `json:"TimeoutMs"` // 单次请求超时（毫秒），默认 2000
+	Registers []ModbusRegister `json:"Registers"`
+}
+
+// ModbusRegister 寄存器到设备变量的映射
+type ModbusRegister struct {
+	Name     string  `json:"Name"`     // 变量名
+	Address  uint16  `json:"Address"`  // 起始地址（0 基）
+	Kind     string  `json:"Kind"`     // holding（默认）/input/coil/discrete
+	Type     string  `json:"Type"`     // uint16（默认）/int16/uint32/int32/float32/bool；32 位按高字在前
+	Scale    float64 `json:"Scale"`    // 变量值 = 原始值 × Scale，默认 1
+	Writable bool    `json:"Writable"` // 变量被写入时回写（仅 holding/coil）
 }
 
 // AppConfig 是全局配置实例
@@ -2,9 +2,10 @@ module myflowhub/pkg/config
 
 go 1.21
 
+require github.com/rs/zerolog v1.34.0
+
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
-	github.com/rs/zerolog v1.34.0 // indirect
 	golang.org/x/sys v0.12.0 // indirect
 )
@@ -47,6 +47,11 @@ func createDBIfNotExist(postgresDsn, dbName string) bool {
 	return false // 数据库已存在
 }
 
+// Models 返回需自动迁移的全部模型
+func Models() []any {
+	return []any{&Device{}, &DeviceVariable{}, &AccessPermission{}, &User{}, &Permission{}, &Key{}, &Grant{}, &AuditLog{}, &SystemLog{}, &StoredFile{}, &FirmwareArtifact{}, &OTACampaign{}, &OTADeviceState{}, &DeviceTwin{}, &DeviceSession{}, &DeviceCertificate{}, &UsedNonce{}}
+}
+
 // InitDatabase 初始化数据库连接并运行迁移
 func InitDatabase(dsn, postgresDsn, dbName string) {
 	WasDBCreated = createDBIfNotExist(postgresDsn, dbName)
@@ -63,7 +68,7 @@ func InitDatabase(dsn, postgresDsn, dbName string) {
 	log.Info().Msg("正在运行数据库迁移...")
 	// 迁移前记录 user 表是否存在
 	hadUserTable := DB.Migrator().HasTable(&User{})
-	err = DB.AutoMigrate(&Device{}, &DeviceVariable{}, &AccessPermission{}, &User{}, &Permission{}, &Key{}, &Grant{}, &AuditLog{}, &SystemLog{})
+	err = DB.AutoMigrate(Models()...)
 	if err != nil {
 		log.Fatal().Err(err).Msg("数据库迁移失败")
 	}
@@ -24,7 +24,9 @@ const (
 type Device struct {
 	ID            uint64     `gorm:"primaryKey"`
 	DeviceUID     uint64     `gorm:"unique;not null;autoIncrement;start:10000"`
-	SecretKeyHash string     `gorm:"not null"`
+	SecretKeyHash string     `gorm:"not null"` // 旧的设备密钥（bcrypt），迁移到 PublicKey 后仅在允许密钥认证时使用
+	PublicKey     []byte     // Ed25519 公钥（32 字节），为空表示尚未登记
+	KeyUpdatedAt  *time.Time // 公钥最近一次登记/轮换时间
 	HardwareID    string     `gorm:"unique"`
 	Role          DeviceRole `gorm:"type:varchar(20)"`
 	Approved      bool       `gorm:"default:false;index"` // 审批通过后方可使用网络功能
@@ -33,9 +35,22 @@ type Device struct {
 	Children      []Device `gorm:"foreignKey:ParentID"`
 	Name          string
 	OwnerUserID   *uint64 // 设备所有者用户ID，可为空（无主）
+	Tags          string  `gorm:"size:512"` // 标签（逗号分隔），仅用户/管理员经 UPDATE_DEVICE 设置，设备自身不可修改
 	LastSeen      *time.Time
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
+
+	// 密钥轮换宽限期内仍可认证的旧密钥（bcrypt）
+	PrevSecretKeyHash   string
+	PrevSecretExpiresAt *time.Time
+
+	// 端到端加密公钥（X25519，32 字节），经 E2E 公钥目录分发给其他设备
+	E2EPublicKey    []byte     `gorm:"column:e2e_public_key"`
+	E2EKeyUpdatedAt *time.Time `gorm:"column:e2e_key_updated_at"`
+
+	// 最近一次连接协商的协议版本与可选特性（逗号分隔，如 "file,pubsub"）；未发送 HELLO 的旧客户端版本为 1
+	ProtocolVersion uint32 `gorm:"column:protocol_version"`
+	Capabilities    string `gorm:"column:capabilities;size:512"`
 }
 
 // DeviceVariable 对应于 'device_variables' 表
@@ -132,3 +147,121 @@ type SystemLog struct {
 	Details datatypes.JSON // 任意结构：可包含 ip/ua/stack 等
 	At      time.Time      `gorm:"index"`
 }
+
+// StoredFile Hub 侧通过分片协议接收的文件
+type StoredFile struct {
+	ID            uint64 `gorm:"primaryKey"`
+	TransferID    uint64 `gorm:"uniqueIndex:idx_file_transfer;not null"`
+	OwnerDeviceID uint64 `gorm:"uniqueIndex:idx_file_transfer;not null"` // 上传方设备 UID
+	Filename      string `gorm:"size:255"`
+	Mime          string `gorm:"size:100"`
+	Size          uint64
+	SHA256        string `gorm:"size:64;index"` // hex
+	Path          string `gorm:"size:1024"`
+	Received      uint64 // 已连续接收字节数（断点续传）
+	Status        string `gorm:"size:20;index"` // uploading | completed | cancelled
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	CompletedAt   *time.Time
+}
+
+// FirmwareArtifact OTA 固件制品（文件本体为 StoredFile）
+type FirmwareArtifact struct {
+	ID            uint64 `gorm:"primaryKey"`
+	Version       string `gorm:"size:64;not null;uniqueIndex:idx_fw_version_model"`
+	HardwareModel string `gorm:"size:100;uniqueIndex:idx_fw_version_model"` // 空表示不限型号
+	FileID        uint64 `gorm:"not null"`                                  // StoredFile.ID
+	Size          uint64
+	SHA256        string `gorm:"size:64"` // hex
+	CreatedBy     *uint64
+	CreatedAt     time.Time
+}
+
+// OTACampaign OTA 升级活动
+type OTACampaign struct {
+	ID                uint64 `gorm:"primaryKey"`
+	Name              string `gorm:"size:200"`
+	ArtifactID        uint64 `gorm:"index;not null"`
+	Status            string `gorm:"size:20;index"` // draft/running/paused/aborted/completed
+	TargetOwnerUserID *uint64
+	TargetParentID    *uint64        // 设备 UID，目标为其整棵子树
+	TargetTag         *string        `gorm:"size:100"`
+	Stages            datatypes.JSON // 累计百分比数组，如 [10,50,100]
+	CurrentStage      uint32
+	StageStartedAt    *time.Time // 当前批次开始（或活动恢复）的时刻，待上线设备的跳过时限据此计算
+	MaxFailurePct     uint32
+	CreatedBy         *uint64
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// OTADeviceState 活动内单台设备的升级状态
+type OTADeviceState struct {
+	ID         uint64 `gorm:"primaryKey"`
+	CampaignID uint64 `gorm:"uniqueIndex:idx_ota_campaign_device;not null"`
+	DeviceUID  uint64 `gorm:"uniqueIndex:idx_ota_campaign_device;not null"`
+	Stage      uint32
+	State      string `gorm:"size:20;index"` // pending/downloading/installing/succeeded/failed/skipped
+	Progress   uint32
+	Error      string `gorm:"size:512"`
+	StartedAt  *time.Time
+	// InstallingSince 下载完成、进入 installing 的时刻，安装超时据此计算（UpdatedAt 每次保存都会刷新）
+	InstallingSince *time.Time
+	UpdatedAt       time.Time
+}
+
+// DeviceTwin 设备孪生：desired 由用户写入，reported 由设备上报，二者分别维护版本号
+type DeviceTwin struct {
+	ID              uint64         `gorm:"primaryKey"`
+	DeviceUID       uint64         `gorm:"uniqueIndex;not null"`
+	Desired         datatypes.JSON // JSON 对象
+	DesiredVersion  uint64
+	Reported        datatypes.JSON // JSON 对象
+	ReportedVersion uint64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// DeviceSession 一次连接会话：建立时写入，认证后补充设备 UID，断开时记录统计与原因
+type DeviceSession struct {
+	ID          uint64    `gorm:"primaryKey"`
+	DeviceUID   uint64    `gorm:"index"`    // 未认证为 0
+	Hub         string    `gorm:"size:100"` // 接入节点 HardwareID
+	ParentPath  string    `gorm:"size:255"` // "/" 直连中枢；"/<中继UID>/…" 经由的中继链（自上而下）
+	RemoteAddr  string    `gorm:"size:100"`
+	UserAgent   string    `gorm:"size:255"`
+	Protocol    string    `gorm:"size:50"`
+	StartedAt   time.Time `gorm:"index"`
+	AuthedAt    *time.Time
+	EndedAt     *time.Time
+	DurationMs  int64
+	BytesIn     uint64
+	BytesOut    uint64
+	FramesIn    uint64
+	FramesOut   uint64
+	CloseReason string `gorm:"size:255"`
+	RawBytesIn  uint64 // 未压缩的二进制帧字节数，与 BytesIn 之比为压缩率
+	RawBytesOut uint64
+}
+
+// DeviceCertificate 内置 CA 签发的设备客户端证书；RevokedAt 非空表示已吊销
+type DeviceCertificate struct {
+	ID           uint64 `gorm:"primaryKey"`
+	Serial       string `gorm:"size:64;uniqueIndex"` // 十六进制序列号
+	DeviceUID    uint64 `gorm:"index"`
+	HardwareID   string `gorm:"size:255"` // 证书 Subject CN
+	NotBefore    time.Time
+	NotAfter     time.Time `gorm:"index"`
+	RevokedAt    *time.Time
+	RevokeReason string `gorm:"size:255"`
+	CreatedAt    time.Time
+}
+
+// UsedNonce 已使用的签名请求 nonce（按 Scope 区分请求类型），用于跨重启、跨 Hub 实例的防重放；过期后清理
+type UsedNonce struct {
+	ID        uint64    `gorm:"primaryKey"`
+	Scope     string    `gorm:"size:50;uniqueIndex:idx_used_nonce"`
+	Value     string    `gorm:"size:64;uniqueIndex:idx_used_nonce"` // SHA-256(scope \0 nonce) 十六进制
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
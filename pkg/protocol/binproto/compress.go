@@ -0,0 +1,178 @@
+package binproto
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// 帧级负载压缩（用于非 WebSocket 传输，或未启用 permessage-deflate 的链路）：
+// 置 Header.Flags 的 Compressed 位，负载 = algo(1) | dict_id(u32 LE，0 表示无字典) | raw_len(u32 LE) | 压缩数据。
+// 压缩仅在单条链路上有效，接收方解压并清除标志后再路由或转发。
+const (
+	CompressionDeflate uint8 = 1 // RFC 1951 raw deflate，可带预置字典
+
+	compressedHeaderSize = 9
+)
+
+var (
+	ErrCompressedMalformed = errors.New("compression: malformed payload")
+	ErrCompressionAlgo     = errors.New("compression: unsupported algorithm")
+	ErrCompressionDict     = errors.New("compression: unknown dictionary")
+	ErrCompressedTooLarge  = errors.New("compression: payload too large")
+)
+
+// CompressionDict 预置字典：对小而重复的 Protobuf 负载（变量、设备列表等）预先提供常见字节序列。
+// ID 为字典内容 SHA-256 的前 4 字节（LE），双方据此确认使用同一份字典。
+type CompressionDict struct {
+	ID   uint32
+	Data []byte
+}
+
+// NewCompressionDict 由字典内容创建字典（deflate 仅使用末尾 32KB）
+func NewCompressionDict(data []byte) *CompressionDict {
+	if len(data) == 0 {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	id := binary.LittleEndian.Uint32(sum[:4])
+	if id == 0 {
+		id = 1
+	}
+	return &CompressionDict{ID: id, Data: data}
+}
+
+func (d *CompressionDict) id() uint32 {
+	if d == nil {
+		return 0
+	}
+	return d.ID
+}
+
+func (d *CompressionDict) data() []byte {
+	if d == nil {
+		return nil
+	}
+	return d.Data
+}
+
+// Compressor 单条链路的帧压缩器，可并发使用
+type Compressor struct {
+	level    int
+	minBytes int
+	dict     *CompressionDict
+	writers  sync.Pool
+}
+
+// NewCompressor 创建压缩器：负载不少于 minBytes 时尝试压缩，dict 可为空
+func NewCompressor(level, minBytes int, dict *CompressionDict) *Compressor {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		level = flate.DefaultCompression
+	}
+	return &Compressor{level: level, minBytes: minBytes, dict: dict}
+}
+
+// DictID 返回压缩使用的字典 ID（0 表示无字典）
+func (c *Compressor) DictID() uint32 {
+	return c.dict.id()
+}
+
+// CompressFrame 压缩 v1 帧的负载并置 Compressed 位；负载过小、已压缩或端到端加密（不可压缩）的帧，
+// 以及压缩后不更小的帧原样返回，第二个返回值为 false
+func (c *Compressor) CompressFrame(frame []byte) ([]byte, bool) {
+	h, payload, err := DecodeFrame(frame)
+	if err != nil || len(payload) < c.minBytes || len(payload) == 0 || h.Flags&(FlagCompressed|FlagE2E) != 0 {
+		return frame, false
+	}
+	var buf bytes.Buffer
+	buf.Grow(HeaderSizeV1 + compressedHeaderSize + len(payload)/2)
+	h.Flags |= FlagCompressed
+	hb, _ := h.Encode(nil)
+	buf.Write(hb)
+	var meta [compressedHeaderSize]byte
+	meta[0] = CompressionDeflate
+	binary.LittleEndian.PutUint32(meta[1:5], c.dict.id())
+	binary.LittleEndian.PutUint32(meta[5:9], uint32(len(payload)))
+	buf.Write(meta[:])
+
+	w, _ := c.writers.Get().(*flate.Writer)
+	if w == nil {
+		if w, err = flate.NewWriterDict(&buf, c.level, c.dict.data()); err != nil {
+			return frame, false
+		}
+	} else {
+		w.Reset(&buf)
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(payload); err != nil {
+		return frame, false
+	}
+	if err := w.Close(); err != nil {
+		return frame, false
+	}
+	if buf.Len() >= len(frame) {
+		return frame, false
+	}
+	return buf.Bytes(), true
+}
+
+var flateReaders sync.Pool
+
+// DecompressFrame 解压置 Compressed 位的 v1 帧并清除该标志；未压缩的帧原样返回。
+// dicts 按 ID 查找预置字典（可为空），解压后负载超过 maxLen 时返回 ErrCompressedTooLarge
+func DecompressFrame(frame []byte, dicts func(id uint32) *CompressionDict, maxLen int) ([]byte, error) {
+	h, payload, err := DecodeFrame(frame)
+	if err != nil {
+		return nil, err
+	}
+	if h.Flags&FlagCompressed == 0 {
+		return frame, nil
+	}
+	if len(payload) < compressedHeaderSize {
+		return nil, ErrCompressedMalformed
+	}
+	if payload[0] != CompressionDeflate {
+		return nil, ErrCompressionAlgo
+	}
+	dictID := binary.LittleEndian.Uint32(payload[1:5])
+	rawLen := binary.LittleEndian.Uint32(payload[5:9])
+	if int64(rawLen) > int64(maxLen) {
+		return nil, ErrCompressedTooLarge
+	}
+	var dict *CompressionDict
+	if dictID != 0 {
+		if dicts != nil {
+			dict = dicts(dictID)
+		}
+		if dict == nil || dict.ID != dictID {
+			return nil, ErrCompressionDict
+		}
+	}
+	src := bytes.NewReader(payload[compressedHeaderSize:])
+	r, _ := flateReaders.Get().(io.ReadCloser)
+	if r == nil {
+		r = flate.NewReaderDict(src, dict.data())
+	} else if err := r.(flate.Resetter).Reset(src, dict.data()); err != nil {
+		return nil, err
+	}
+	defer flateReaders.Put(r)
+
+	h.Flags &^= FlagCompressed
+	out := make([]byte, HeaderSizeV1, HeaderSizeV1+int(rawLen))
+	if _, err := h.Encode(out); err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(out)
+	n, err := io.Copy(buf, io.LimitReader(r, int64(rawLen)+1))
+	if err != nil {
+		return nil, ErrCompressedMalformed
+	}
+	if n != int64(rawLen) {
+		return nil, ErrCompressedMalformed
+	}
+	return buf.Bytes(), nil
+}
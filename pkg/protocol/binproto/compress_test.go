@@ -0,0 +1,102 @@
+package binproto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestCompressFrameRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte(`{"name":"temperature","value":21.5}`), 40)
+	frame, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, MsgID: 7, Source: 2, Target: 3}, payload)
+	c := NewCompressor(6, 64, nil)
+	out, ok := c.CompressFrame(frame)
+	if !ok || len(out) >= len(frame) {
+		t.Fatalf("not compressed: %d -> %d", len(frame), len(out))
+	}
+	if h, _, _ := DecodeFrame(out); h.Flags&FlagCompressed == 0 {
+		t.Fatal("compressed flag not set")
+	}
+	got, err := DecompressFrame(out, nil, 1<<20)
+	if err != nil || !bytes.Equal(got, frame) {
+		t.Fatalf("round trip: %v", err)
+	}
+	if plain, _ := DecompressFrame(frame, nil, 1<<20); !bytes.Equal(plain, frame) {
+		t.Fatal("uncompressed frame modified")
+	}
+}
+
+func TestCompressFrameSkips(t *testing.T) {
+	c := NewCompressor(6, 64, nil)
+	small, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend}, bytes.Repeat([]byte("a"), 32))
+	e2e, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, Flags: FlagE2E}, bytes.Repeat([]byte("a"), 512))
+	random := make([]byte, 512)
+	for i := range random {
+		random[i] = byte(i*131 + i>>3)
+	}
+	noisy, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend}, random)
+	for name, f := range map[string][]byte{"small": small, "e2e": e2e} {
+		if out, ok := c.CompressFrame(f); ok || !bytes.Equal(out, f) {
+			t.Fatalf("%s frame compressed", name)
+		}
+	}
+	if out, ok := c.CompressFrame(noisy); ok && len(out) >= len(noisy) {
+		t.Fatal("compressed frame not smaller")
+	}
+}
+
+func TestCompressFrameDict(t *testing.T) {
+	dict := NewCompressionDict([]byte(`"device_uid""hardware_id""online""last_seen""variables"`))
+	payload := []byte(`{"device_uid":1,"hardware_id":"dev-1","online":true,"last_seen":0}`)
+	frame, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, MsgID: 1}, payload)
+	withDict, ok := NewCompressor(9, 16, dict).CompressFrame(frame)
+	if !ok {
+		t.Fatal("not compressed with dictionary")
+	}
+	lookup := func(id uint32) *CompressionDict {
+		if id == dict.ID {
+			return dict
+		}
+		return nil
+	}
+	if got, err := DecompressFrame(withDict, lookup, 1<<20); err != nil || !bytes.Equal(got, frame) {
+		t.Fatalf("dict round trip: %v", err)
+	}
+	if _, err := DecompressFrame(withDict, nil, 1<<20); !errors.Is(err, ErrCompressionDict) {
+		t.Fatalf("missing dict: %v", err)
+	}
+	other := NewCompressionDict([]byte("other"))
+	if _, err := DecompressFrame(withDict, func(uint32) *CompressionDict { return other }, 1<<20); !errors.Is(err, ErrCompressionDict) {
+		t.Fatalf("wrong dict: %v", err)
+	}
+}
+
+func TestDecompressFrameLimits(t *testing.T) {
+	payload := bytes.Repeat([]byte{0}, 1<<16)
+	frame, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend}, payload)
+	out, ok := NewCompressor(9, 0, nil).CompressFrame(frame)
+	if !ok {
+		t.Fatal("not compressed")
+	}
+	if _, err := DecompressFrame(out, nil, 1024); !errors.Is(err, ErrCompressedTooLarge) {
+		t.Fatalf("bomb: %v", err)
+	}
+	// 声明长度小于实际解压长度视为格式错误
+	h, pl, _ := DecodeFrame(out)
+	pl = append([]byte(nil), pl...)
+	binary.LittleEndian.PutUint32(pl[5:9], 100)
+	lying, _ := EncodeFrame(h, pl)
+	if _, err := DecompressFrame(lying, nil, 1<<20); !errors.Is(err, ErrCompressedMalformed) {
+		t.Fatalf("raw_len mismatch: %v", err)
+	}
+	pl[0] = 9
+	badAlgo, _ := EncodeFrame(h, pl)
+	if _, err := DecompressFrame(badAlgo, nil, 1<<20); !errors.Is(err, ErrCompressionAlgo) {
+		t.Fatalf("algo: %v", err)
+	}
+	short, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, Flags: FlagCompressed}, []byte{1, 2})
+	if _, err := DecompressFrame(short, nil, 1<<20); !errors.Is(err, ErrCompressedMalformed) {
+		t.Fatalf("short: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package binproto
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// DeadlineSize FlagDeadline 帧负载末尾截止时刻的长度（int64 Unix 毫秒，LE）
+const DeadlineSize = 8
+
+// ErrDeadlineMalformed 置 FlagDeadline 的帧负载不足以容纳截止时刻
+var ErrDeadlineMalformed = errors.New("deadline trailer malformed")
+
+// AppendDeadline 为请求附带截止时刻：置 h 的 FlagDeadline 并返回末尾追加截止时刻的负载。
+// 截止时刻逐跳有效，仅在对端经 HELLO 协商 deadline 特性后使用；Timestamp 仍为发送时刻
+func AppendDeadline(h *HeaderV1, payload []byte, t time.Time) []byte {
+	h.Flags |= FlagDeadline
+	out := make([]byte, len(payload), len(payload)+DeadlineSize)
+	copy(out, payload)
+	return binary.LittleEndian.AppendUint64(out, uint64(t.UnixMilli()))
+}
+
+// SplitDeadline 取出 FlagDeadline 帧负载末尾的截止时刻并清除 h 的标志；未置标志时返回零值与原负载
+func SplitDeadline(h *HeaderV1, payload []byte) (time.Time, []byte, error) {
+	if h.Flags&FlagDeadline == 0 {
+		return time.Time{}, payload, nil
+	}
+	if len(payload) < DeadlineSize {
+		return time.Time{}, nil, ErrDeadlineMalformed
+	}
+	n := len(payload) - DeadlineSize
+	h.Flags &^= FlagDeadline
+	return time.UnixMilli(int64(binary.LittleEndian.Uint64(payload[n:]))), payload[:n], nil
+}
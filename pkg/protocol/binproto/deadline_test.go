@@ -0,0 +1,39 @@
+package binproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeadlineRoundTrip(t *testing.T) {
+	at := time.UnixMilli(1_700_000_000_123)
+	h := HeaderV1{TypeID: TypeVarListReq, MsgID: 5, Timestamp: 1}
+	payload := []byte("req")
+	withDeadline := AppendDeadline(&h, payload, at)
+	if h.Flags&FlagDeadline == 0 || h.Timestamp != 1 || !bytes.Equal(payload, []byte("req")) {
+		t.Fatalf("header/payload modified: %+v %q", h, payload)
+	}
+	frame, _ := EncodeFrame(h, withDeadline)
+	h2, pl, err := DecodeFrame(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, pl, err := SplitDeadline(&h2, pl)
+	if err != nil || !d.Equal(at) || !bytes.Equal(pl, payload) || h2.Flags&FlagDeadline != 0 {
+		t.Fatalf("split: %v %q %v %+v", d, pl, err, h2)
+	}
+}
+
+func TestSplitDeadlineWithoutFlag(t *testing.T) {
+	h := HeaderV1{TypeID: TypeVarListReq}
+	d, pl, err := SplitDeadline(&h, []byte("12345678"))
+	if err != nil || !d.IsZero() || string(pl) != "12345678" {
+		t.Fatalf("no flag: %v %q %v", d, pl, err)
+	}
+	h.Flags = FlagDeadline
+	if _, _, err := SplitDeadline(&h, []byte{1, 2}); !errors.Is(err, ErrDeadlineMalformed) {
+		t.Fatalf("short: %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+// Package filexfer 实现文件分片协议（TypeID 300~306）的收发状态机。
+//
+// 发送端：INIT → (CHUNK × N，受窗口约束) → COMPLETE → 结束；任意时刻可 CANCEL。
+// 接收端：校验 INIT 并返回断点（resume_offset）；按偏移顺序接收分片并累计确认；
+// 收到 COMPLETE 后校验整文件 SHA-256。
+//
+// 状态机与传输解耦：发送通过 SendFunc 回调完成，调用方负责把响应帧喂回 Handle* 方法。
+package filexfer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash/crc32"
+	"io"
+	"sync"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+const (
+	DefaultChunkSize = 128 * 1024
+	MinChunkSize     = 4 * 1024
+	MaxChunkSize     = 256 * 1024
+	DefaultWindow    = 4
+	MaxWindow        = 64
+	DefaultMaxRetry  = 5
+)
+
+var (
+	ErrCancelled     = errors.New("transfer cancelled")
+	ErrRejected      = errors.New("transfer rejected by receiver")
+	ErrBadState      = errors.New("unexpected message for current state")
+	ErrCRCMismatch   = errors.New("chunk crc32 mismatch")
+	ErrHashMismatch  = errors.New("file hash mismatch")
+	ErrIncomplete    = errors.New("transfer incomplete")
+	ErrOutOfRange    = errors.New("chunk out of range")
+	ErrRetryExceeded = errors.New("retransmit limit exceeded")
+)
+
+// State 传输状态
+type State int
+
+const (
+	StateIdle State = iota
+	StateInit
+	StateSending
+	StateCompleting
+	StateDone
+	StateCancelled
+	StateFailed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateInit:
+		return "init"
+	case StateSending:
+		return "sending"
+	case StateCompleting:
+		return "completing"
+	case StateDone:
+		return "done"
+	case StateCancelled:
+		return "cancelled"
+	default:
+		return "failed"
+	}
+}
+
+// NormalizeChunkSize 将分片大小约束到 [MinChunkSize, MaxChunkSize]，0 取默认值
+func NormalizeChunkSize(n uint32) uint32 {
+	if n == 0 {
+		return DefaultChunkSize
+	}
+	if n < MinChunkSize {
+		return MinChunkSize
+	}
+	if n > MaxChunkSize {
+		return MaxChunkSize
+	}
+	return n
+}
+
+// NormalizeWindow 将窗口约束到 [1, MaxWindow]，0 取默认值
+func NormalizeWindow(n uint32) uint32 {
+	if n == 0 {
+		return DefaultWindow
+	}
+	if n > MaxWindow {
+		return MaxWindow
+	}
+	return n
+}
+
+// HashReaderAt 计算 [0,size) 的 SHA-256
+func HashReaderAt(r io.ReaderAt, size int64) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(r, 0, size)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// SendFunc 发送一帧（由调用方封装帧头与 Target）
+type SendFunc func(typeID uint16, payload []byte) error
+
+// Sender 发送端状态机；并发安全
+type Sender struct {
+	mu        sync.Mutex
+	init      bin.FileInit
+	src       io.ReaderAt
+	send      SendFunc
+	state     State
+	chunkSize uint32
+	window    uint32
+	acked     uint64 // 接收端已累计确认的偏移
+	next      uint64 // 下一个待发送的偏移
+	lastAckAt time.Time
+	retries   int
+	MaxRetry  int
+	done      chan error
+}
+
+// NewSender 创建发送端；init.TotalSize 与 init.FileHash 需由调用方事先计算
+func NewSender(src io.ReaderAt, init bin.FileInit, send SendFunc) *Sender {
+	return &Sender{init: init, src: src, send: send, MaxRetry: DefaultMaxRetry, done: make(chan error, 1)}
+}
+
+// TransferID 返回传输 ID
+func (s *Sender) TransferID() uint64 { return s.init.TransferID }
+
+// State 返回当前状态
+func (s *Sender) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Acked 返回已确认字节数
+func (s *Sender) Acked() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acked
+}
+
+// Done 在传输结束（成功为 nil）时返回结果
+func (s *Sender) Done() <-chan error { return s.done }
+
+// Start 发送 FILE_INIT；重连后亦可再次调用以断点续传
+func (s *Sender) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state >= StateDone {
+		return ErrBadState
+	}
+	s.state = StateInit
+	s.lastAckAt = time.Now()
+	return s.send(bin.TypeFileInitReq, bin.EncodeFileInitReq(s.init))
+}
+
+// Resume 等价于 Start：由接收端通过 resume_offset 决定续传位置
+func (s *Sender) Resume() error { return s.Start() }
+
+// HandleInitResp 处理 FILE_INIT_RESP
+func (s *Sender) HandleInitResp(ok bool, resumeOffset *uint64, chunkSize, window uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state != StateInit {
+		return ErrBadState
+	}
+	if !ok {
+		s.finishLocked(StateFailed, ErrRejected)
+		return ErrRejected
+	}
+	s.chunkSize = NormalizeChunkSize(chunkSize)
+	s.window = NormalizeWindow(window)
+	s.acked = 0
+	if resumeOffset != nil && *resumeOffset <= s.init.TotalSize {
+		s.acked = *resumeOffset
+	}
+	s.next = s.acked
+	s.retries = 0
+	s.lastAckAt = time.Now()
+	s.state = StateSending
+	return s.pumpLocked()
+}
+
+// HandleAck 处理 FILE_CHUNK_ACK（累计确认）
+func (s *Sender) HandleAck(nextOffset uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state != StateSending {
+		return nil // 迟到的确认直接忽略
+	}
+	if nextOffset > s.init.TotalSize {
+		return ErrOutOfRange
+	}
+	if nextOffset > s.acked {
+		s.acked = nextOffset
+		s.retries = 0
+		s.lastAckAt = time.Now()
+	}
+	if s.next < s.acked {
+		s.next = s.acked
+	}
+	return s.pumpLocked()
+}
+
+// HandleCompleteResp 处理 FILE_COMPLETE_RESP
+func (s *Sender) HandleCompleteResp(ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state != StateCompleting {
+		return
+	}
+	if ok {
+		s.finishLocked(StateDone, nil)
+		return
+	}
+	s.finishLocked(StateFailed, ErrHashMismatch)
+}
+
+// HandleCancel 处理对端发来的 FILE_CANCEL
+func (s *Sender) HandleCancel(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state >= StateDone {
+		return
+	}
+	err := ErrCancelled
+	if reason != "" {
+		err = errors.New("transfer cancelled: " + reason)
+	}
+	s.finishLocked(StateCancelled, err)
+}
+
+// Cancel 主动取消并通知对端
+func (s *Sender) Cancel(reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state >= StateDone {
+		return nil
+	}
+	s.finishLocked(StateCancelled, ErrCancelled)
+	return s.send(bin.TypeFileCancel, bin.EncodeFileCancel(s.init.TransferID, reason))
+}
+
+// CheckTimeout 在确认超时后从已确认偏移重传（go-back-N）；超过重试上限则取消
+func (s *Sender) CheckTimeout(timeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state >= StateDone || s.state == StateIdle || time.Since(s.lastAckAt) < timeout {
+		return nil
+	}
+	s.retries++
+	if s.retries > s.MaxRetry {
+		s.finishLocked(StateFailed, ErrRetryExceeded)
+		return s.send(bin.TypeFileCancel, bin.EncodeFileCancel(s.init.TransferID, "retransmit limit exceeded"))
+	}
+	s.lastAckAt = time.Now()
+	switch s.state {
+	case StateInit:
+		return s.send(bin.TypeFileInitReq, bin.EncodeFileInitReq(s.init))
+	case StateCompleting:
+		return s.send(bin.TypeFileCompleteReq, bin.EncodeFileCompleteReq(s.init.TransferID))
+	}
+	s.next = s.acked
+	return s.pumpLocked()
+}
+
+// pumpLocked 在窗口允许范围内发送分片；全部确认后发送 COMPLETE
+func (s *Sender) pumpLocked() error {
+	if s.acked >= s.init.TotalSize {
+		s.state = StateCompleting
+		return s.send(bin.TypeFileCompleteReq, bin.EncodeFileCompleteReq(s.init.TransferID))
+	}
+	limit := s.acked + uint64(s.window)*uint64(s.chunkSize)
+	buf := make([]byte, s.chunkSize)
+	for s.next < s.init.TotalSize && s.next < limit {
+		n := uint64(s.chunkSize)
+		if rem := s.init.TotalSize - s.next; rem < n {
+			n = rem
+		}
+		if _, err := s.src.ReadAt(buf[:n], int64(s.next)); err != nil && err != io.EOF {
+			s.finishLocked(StateFailed, err)
+			return err
+		}
+		sum := crc32.ChecksumIEEE(buf[:n])
+		if err := s.send(bin.TypeFileChunk, bin.EncodeFileChunk(s.init.TransferID, s.next, buf[:n], &sum)); err != nil {
+			return err
+		}
+		s.next += n
+	}
+	return nil
+}
+
+func (s *Sender) finishLocked(st State, err error) {
+	s.state = st
+	select {
+	case s.done <- err:
+	default:
+	}
+}
+
+// Blob 接收端的落盘目标（通常为 *os.File）
+type Blob interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// Receiver 接收端状态机；仅接受按序分片，乱序或重复分片以当前偏移重新确认
+type Receiver struct {
+	mu       sync.Mutex
+	init     bin.FileInit
+	dst      Blob
+	received uint64
+	state    State
+}
+
+// NewReceiver 创建接收端；received 为断点续传时已持有的连续字节数
+func NewReceiver(init bin.FileInit, dst Blob, received uint64) *Receiver {
+	if received > init.TotalSize {
+		received = init.TotalSize
+	}
+	return &Receiver{init: init, dst: dst, received: received, state: StateSending}
+}
+
+// Init 返回 INIT 元信息
+func (r *Receiver) Init() bin.FileInit { return r.init }
+
+// Received 返回已连续接收的字节数
+func (r *Receiver) Received() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.received
+}
+
+// HandleChunk 写入分片并返回累计确认偏移
+func (r *Receiver) HandleChunk(offset uint64, chunk []byte, crc *uint32) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state != StateSending {
+		return r.received, ErrBadState
+	}
+	if offset != r.received {
+		// 乱序/重复：不写入，重新确认当前偏移促使发送端回退
+		return r.received, nil
+	}
+	if offset+uint64(len(chunk)) > r.init.TotalSize || len(chunk) > MaxChunkSize {
+		return r.received, ErrOutOfRange
+	}
+	if crc != nil && crc32.ChecksumIEEE(chunk) != *crc {
+		return r.received, ErrCRCMismatch
+	}
+	if _, err := r.dst.WriteAt(chunk, int64(offset)); err != nil {
+		return r.received, err
+	}
+	r.received += uint64(len(chunk))
+	return r.received, nil
+}
+
+// Complete 校验长度与整文件哈希
+func (r *Receiver) Complete() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.received != r.init.TotalSize {
+		return ErrIncomplete
+	}
+	sum, err := HashReaderAt(r.dst, int64(r.init.TotalSize))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(sum, r.init.FileHash) {
+		return ErrHashMismatch
+	}
+	r.state = StateDone
+	return nil
+}
+
+// Cancel 标记为取消
+func (r *Receiver) Cancel() {
+	r.mu.Lock()
+	r.state = StateCancelled
+	r.mu.Unlock()
+}
@@ -0,0 +1,91 @@
+package binproto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func frameMACPair(t *testing.T, binding []byte) (client, server []byte) {
+	t.Helper()
+	c, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	s, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	cp, sp := c.PublicKey().Bytes(), s.PublicKey().Bytes()
+	ck, err := DeriveFrameMACKey(c, sp, cp, sp, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, err := DeriveFrameMACKey(s, cp, cp, sp, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ck, sk
+}
+
+func TestFrameMACSealOpen(t *testing.T) {
+	ck, sk := frameMACPair(t, []byte("token"))
+	if !bytes.Equal(ck, sk) {
+		t.Fatal("derived keys differ")
+	}
+	tx := NewFrameMAC(ck, FrameMACClientToServer)
+	rx := NewFrameMAC(sk, FrameMACClientToServer)
+
+	f1, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, MsgID: 1, Source: 2, Target: 3}, []byte("one"))
+	f2, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, MsgID: 2, Source: 2, Target: 3}, []byte("two"))
+	orig := append([]byte(nil), f1...)
+	s1, s2 := tx.Seal(f1), tx.Seal(f2)
+	if !bytes.Equal(f1, orig) {
+		t.Fatal("seal modified input")
+	}
+	if len(s1) != len(f1)+FrameMACSize {
+		t.Fatalf("sealed len %d", len(s1))
+	}
+
+	// 乱序：序号不符，失败且不推进序号
+	if _, err := rx.Open(append([]byte(nil), s2...)); !errors.Is(err, ErrFrameMACInvalid) {
+		t.Fatalf("reordered: %v", err)
+	}
+	// 篡改帧头
+	bad := append([]byte(nil), s1...)
+	bad[22] ^= 1
+	if _, err := rx.Open(bad); !errors.Is(err, ErrFrameMACInvalid) {
+		t.Fatalf("tampered: %v", err)
+	}
+	out, err := rx.Open(append([]byte(nil), s1...))
+	if err != nil || !bytes.Equal(out, f1) {
+		t.Fatalf("open: %v", err)
+	}
+	// 重放
+	if _, err := rx.Open(append([]byte(nil), s1...)); !errors.Is(err, ErrFrameMACInvalid) {
+		t.Fatalf("replay: %v", err)
+	}
+	if out, err := rx.Open(s2); err != nil || !bytes.Equal(out, f2) {
+		t.Fatalf("open second: %v", err)
+	}
+	// 未带标签
+	if _, err := rx.Open(f1); !errors.Is(err, ErrFrameMACMissing) {
+		t.Fatalf("missing: %v", err)
+	}
+}
+
+func TestFrameMACDirectionAndBinding(t *testing.T) {
+	_, sk := frameMACPair(t, []byte("token"))
+	f, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, MsgID: 1}, []byte("x"))
+
+	// 下行帧不能被当作上行帧接受（反射）
+	down := NewFrameMAC(sk, FrameMACServerToClient).Seal(f)
+	if _, err := NewFrameMAC(sk, FrameMACClientToServer).Open(down); !errors.Is(err, ErrFrameMACInvalid) {
+		t.Fatalf("reflected: %v", err)
+	}
+	// 绑定值不同则密钥不同
+	c, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	s, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	cp, sp := c.PublicKey().Bytes(), s.PublicKey().Bytes()
+	k1, _ := DeriveFrameMACKey(c, sp, cp, sp, []byte("token"))
+	k2, _ := DeriveFrameMACKey(c, sp, cp, sp, []byte("other"))
+	if bytes.Equal(k1, k2) {
+		t.Fatal("binding not mixed into key")
+	}
+}
@@ -10,7 +10,8 @@ import (
 // Layout (little endian):
 //
 //	TypeID[2] uint16
-//	Reserved[4] uint32 = 0
+//	Flags[2] uint16（见 Flag* 常量；旧实现视为保留字段写 0）
+//	Reserved[2] uint16 = 0（首字节在 v2 中为版本号，见 HeaderV2）
 //	MsgID [8] uint64
 //	Source[8] uint64
 //	Target[8] uint64
@@ -19,6 +20,7 @@ import (
 // Total: 38 bytes
 type HeaderV1 struct {
 	TypeID    uint16
+	Flags     uint16
 	MsgID     uint64
 	Source    uint64
 	Target    uint64
@@ -27,6 +29,31 @@ type HeaderV1 struct {
 
 const HeaderSizeV1 = 38
 
+// Header 标志位：FlagE2E、FlagAckRequested 与优先级由 Hub 与中继原样透传；
+// FlagMAC、FlagCompressed、FlagFragment、FlagDeadline 仅在单条链路上有效（FlagFragment 只出现在 v2 帧中）
+const (
+	FlagE2E          uint16 = 1 << 0 // 负载为端到端加密（见 SealE2E），仅收发双方可解密
+	FlagMAC          uint16 = 1 << 1 // 帧尾带 16 字节链路认证标签（见 FrameMAC），逐跳校验后去除
+	FlagCompressed   uint16 = 1 << 2 // 负载经帧级压缩（见 Compressor），接收方解压后清除
+	FlagFragment     uint16 = 1 << 3 // v2 分片帧（见 HeaderV2），重组后清除
+	FlagAckRequested uint16 = 1 << 4 // 发送方请求接收方确认
+	FlagDeadline     uint16 = 1 << 5 // 负载末尾带 8 字节请求截止时刻（见 AppendDeadline），接收方去除后清除
+
+	// 优先级占 bit8-9：0 普通，1 低，2 高，3 紧急
+	FlagPriorityMask  uint16 = 3 << 8
+	FlagPriorityShift        = 8
+)
+
+// Priority 返回帧头中的优先级（0-3）
+func (h *HeaderV1) Priority() uint8 {
+	return uint8((h.Flags & FlagPriorityMask) >> FlagPriorityShift)
+}
+
+// SetPriority 设置帧头中的优先级（取低 2 位）
+func (h *HeaderV1) SetPriority(p uint8) {
+	h.Flags = h.Flags&^FlagPriorityMask | uint16(p&3)<<FlagPriorityShift
+}
+
 func (h *HeaderV1) Encode(dst []byte) ([]byte, error) {
 	if dst == nil {
 		dst = make([]byte, HeaderSizeV1)
@@ -34,10 +61,9 @@ func (h *HeaderV1) Encode(dst []byte) ([]byte, error) {
 		return nil, errors.New("buffer too small for header")
 	}
 	binary.LittleEndian.PutUint16(dst[0:2], h.TypeID)
-	// reserved 4 bytes set to zero
-	for i := 2; i < 6; i++ {
-		dst[i] = 0
-	}
+	binary.LittleEndian.PutUint16(dst[2:4], h.Flags)
+	// reserved 2 bytes set to zero
+	dst[4], dst[5] = 0, 0
 	binary.LittleEndian.PutUint64(dst[6:14], h.MsgID)
 	binary.LittleEndian.PutUint64(dst[14:22], h.Source)
 	binary.LittleEndian.PutUint64(dst[22:30], h.Target)
@@ -50,7 +76,8 @@ func (h *HeaderV1) Decode(src []byte) error {
 		return errors.New("buffer too small for header")
 	}
 	h.TypeID = binary.LittleEndian.Uint16(src[0:2])
-	// skip reserved [2:6]
+	h.Flags = binary.LittleEndian.Uint16(src[2:4])
+	// skip reserved [4:6]
 	h.MsgID = binary.LittleEndian.Uint64(src[6:14])
 	h.Source = binary.LittleEndian.Uint64(src[14:22])
 	h.Target = binary.LittleEndian.Uint64(src[22:30])
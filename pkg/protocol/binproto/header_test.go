@@ -6,7 +6,7 @@ import (
 )
 
 func TestHeaderCodec(t *testing.T) {
-	h := HeaderV1{TypeID: 1, MsgID: 123, Source: 10, Target: 20, Timestamp: 99}
+	h := HeaderV1{TypeID: 1, Flags: FlagE2E, MsgID: 123, Source: 10, Target: 20, Timestamp: 99}
 	b, err := h.Encode(nil)
 	if err != nil {
 		t.Fatal(err)
@@ -41,3 +41,10 @@ func TestOKErrCodec(t *testing.T) {
 		t.Fatalf("bad decode err: %d %d %q", rid2, code2, string(msg2))
 	}
 }
+
+func TestCancelReqCodec(t *testing.T) {
+	id, err := DecodeCancelReq(EncodeCancelReq(5))
+	if err != nil || id != 5 {
+		t.Fatalf("cancel: %d %v", id, err)
+	}
+}
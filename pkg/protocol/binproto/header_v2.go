@@ -0,0 +1,260 @@
+package binproto
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// HeaderV2 is the fixed 46-byte header of v2: the v1 layout with a version byte in the first
+// reserved byte, followed by fragment fields.
+// Layout (little endian):
+//
+//	TypeID[2] uint16
+//	Flags[2] uint16（含 FlagFragment；优先级见 FlagPriorityMask）
+//	Version[1] uint8 = 2（v1 帧此字节为保留字段 0，据此区分版本）
+//	Reserved[1] uint8 = 0
+//	MsgID [8] uint64
+//	Source[8] uint64
+//	Target[8] uint64
+//	Timestamp[8] int64 (ms)
+//	FragIndex[2] uint16（分片序号，从 0 开始）
+//	FragCount[2] uint16（分片总数，未分片为 1）
+//	TotalLen[4] uint32（完整负载长度）
+//
+// Total: 46 bytes
+//
+// 一条消息的各分片具有相同的 TypeID/MsgID/Source/Target，并按序号依次发送；重组后得到等价的 v1 帧。
+type HeaderV2 struct {
+	HeaderV1
+	FragIndex uint16
+	FragCount uint16
+	TotalLen  uint32
+}
+
+const (
+	HeaderSizeV2       = 46
+	Version1     uint8 = 1
+	Version2     uint8 = 2
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported frame version")
+	ErrFrameTooLarge      = errors.New("frame too large")
+	ErrReassemblyTooLarge = errors.New("reassembly: message too large")
+	ErrReassemblyPending  = errors.New("reassembly: too many pending messages")
+	ErrFragmentSequence   = errors.New("reassembly: fragment out of sequence")
+)
+
+func (h *HeaderV2) Encode(dst []byte) ([]byte, error) {
+	if dst == nil {
+		dst = make([]byte, HeaderSizeV2)
+	} else if len(dst) < HeaderSizeV2 {
+		return nil, errors.New("buffer too small for header")
+	}
+	if _, err := h.HeaderV1.Encode(dst); err != nil {
+		return nil, err
+	}
+	dst[4] = Version2
+	binary.LittleEndian.PutUint16(dst[38:40], h.FragIndex)
+	binary.LittleEndian.PutUint16(dst[40:42], h.FragCount)
+	binary.LittleEndian.PutUint32(dst[42:46], h.TotalLen)
+	return dst[:HeaderSizeV2], nil
+}
+
+func (h *HeaderV2) Decode(src []byte) error {
+	if len(src) < HeaderSizeV2 {
+		return errors.New("buffer too small for header")
+	}
+	if src[4] != Version2 {
+		return ErrUnsupportedVersion
+	}
+	if err := h.HeaderV1.Decode(src); err != nil {
+		return err
+	}
+	h.FragIndex = binary.LittleEndian.Uint16(src[38:40])
+	h.FragCount = binary.LittleEndian.Uint16(src[40:42])
+	h.TotalLen = binary.LittleEndian.Uint32(src[42:46])
+	return nil
+}
+
+// FrameVersion 返回帧的协议版本：版本字节为 0 时为 v1，否则为该字节的值
+func FrameVersion(frame []byte) uint8 {
+	if len(frame) < HeaderSizeV1 || frame[4] == 0 {
+		return Version1
+	}
+	return frame[4]
+}
+
+// EncodeFrameV2 builds header(46B)+payload.
+func EncodeFrameV2(h HeaderV2, payload []byte) ([]byte, error) {
+	out := make([]byte, HeaderSizeV2, HeaderSizeV2+len(payload))
+	if _, err := h.Encode(out); err != nil {
+		return nil, err
+	}
+	return append(out, payload...), nil
+}
+
+// DecodeFrameV2 splits header and payload view without copy.
+func DecodeFrameV2(b []byte) (HeaderV2, []byte, error) {
+	var h HeaderV2
+	if err := h.Decode(b); err != nil {
+		return HeaderV2{}, nil, err
+	}
+	return h, b[HeaderSizeV2:], nil
+}
+
+// SplitFrameV2 将 v1 帧转换为 v2 帧；负载超过 maxPayload 时拆分为多个置 FlagFragment 的分片
+func SplitFrameV2(frame []byte, maxPayload int) ([][]byte, error) {
+	h1, payload, err := DecodeFrame(frame)
+	if err != nil {
+		return nil, err
+	}
+	if maxPayload <= 0 {
+		return nil, errors.New("invalid fragment size")
+	}
+	count := (len(payload) + maxPayload - 1) / maxPayload
+	if count == 0 {
+		count = 1
+	}
+	if count > 0xffff || uint64(len(payload)) > 0xffffffff {
+		return nil, ErrFrameTooLarge
+	}
+	h := HeaderV2{HeaderV1: h1, FragCount: uint16(count), TotalLen: uint32(len(payload))}
+	h.Flags &^= FlagFragment
+	if count > 1 {
+		h.Flags |= FlagFragment
+	}
+	out := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * maxPayload
+		if end > len(payload) {
+			end = len(payload)
+		}
+		h.FragIndex = uint16(i)
+		f, err := EncodeFrameV2(h, payload[i*maxPayload:end])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// Reassembler 单条链路上 v2 分片的重组状态；不可并发使用（由该链路的读协程或 Run 协程持有）。
+// 未完成消息的缓冲总量不超过 maxBytes，同时进行的消息数不超过 maxPending，超过 timeout 未完成的消息被丢弃。
+type Reassembler struct {
+	maxBytes   int
+	maxPending int
+	timeout    time.Duration
+
+	pending  map[fragKey]*fragPartial
+	buffered int
+}
+
+type fragKey struct {
+	typeID uint16
+	msgID  uint64
+	source uint64
+	target uint64
+}
+
+type fragPartial struct {
+	frame   []byte // v1 帧头 + 已收到的负载
+	next    uint16
+	count   uint16
+	total   uint32
+	started time.Time
+}
+
+// NewReassembler 创建分片重组器
+func NewReassembler(maxBytes, maxPending int, timeout time.Duration) *Reassembler {
+	return &Reassembler{maxBytes: maxBytes, maxPending: maxPending, timeout: timeout, pending: make(map[fragKey]*fragPartial)}
+}
+
+// Add 处理一个已去除链路认证标签的帧：v1 帧原样返回；未分片的 v2 帧转换为 v1 帧返回；
+// 分片在最后一片到达后返回重组的 v1 帧，此前返回 nil。出错时丢弃该消息已收到的分片。
+func (r *Reassembler) Add(frame []byte) ([]byte, error) {
+	switch FrameVersion(frame) {
+	case Version1:
+		return frame, nil
+	case Version2:
+	default:
+		return nil, ErrUnsupportedVersion
+	}
+	h, payload, err := DecodeFrameV2(frame)
+	if err != nil {
+		return nil, err
+	}
+	if h.Flags&FlagFragment == 0 {
+		if h.FragCount > 1 || h.FragIndex != 0 || int(h.TotalLen) != len(payload) {
+			return nil, ErrFragmentSequence
+		}
+		return EncodeFrame(h.HeaderV1, payload)
+	}
+	r.expire(time.Now())
+	key := fragKey{typeID: h.TypeID, msgID: h.MsgID, source: h.Source, target: h.Target}
+	p := r.pending[key]
+	if h.FragIndex == 0 {
+		// 首片：同键的未完成消息视为被放弃
+		r.drop(key)
+		if h.FragCount < 2 {
+			return nil, ErrFragmentSequence
+		}
+		if int64(h.TotalLen) > int64(r.maxBytes) {
+			return nil, ErrReassemblyTooLarge
+		}
+		if len(r.pending) >= r.maxPending {
+			return nil, ErrReassemblyPending
+		}
+		h1 := h.HeaderV1
+		h1.Flags &^= FlagFragment
+		hb, _ := h1.Encode(nil)
+		p = &fragPartial{frame: hb, count: h.FragCount, total: h.TotalLen, started: time.Now()}
+		r.pending[key] = p
+	} else if p == nil || h.FragIndex != p.next || h.FragCount != p.count || h.TotalLen != p.total {
+		r.drop(key)
+		return nil, ErrFragmentSequence
+	}
+	have := len(p.frame) - HeaderSizeV1
+	if uint64(have)+uint64(len(payload)) > uint64(p.total) {
+		r.drop(key)
+		return nil, ErrFragmentSequence
+	}
+	if r.buffered+len(payload) > r.maxBytes {
+		r.drop(key)
+		return nil, ErrReassemblyTooLarge
+	}
+	p.frame = append(p.frame, payload...)
+	r.buffered += len(payload)
+	p.next++
+	if p.next < p.count {
+		return nil, nil
+	}
+	out := p.frame
+	r.drop(key)
+	if len(out)-HeaderSizeV1 != int(p.total) {
+		return nil, ErrFragmentSequence
+	}
+	return out, nil
+}
+
+// Pending 返回未完成的消息数
+func (r *Reassembler) Pending() int {
+	return len(r.pending)
+}
+
+func (r *Reassembler) drop(key fragKey) {
+	if p, ok := r.pending[key]; ok {
+		r.buffered -= len(p.frame) - HeaderSizeV1
+		delete(r.pending, key)
+	}
+}
+
+func (r *Reassembler) expire(now time.Time) {
+	for k, p := range r.pending {
+		if now.Sub(p.started) > r.timeout {
+			r.drop(k)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package binproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHeaderV2RoundTrip(t *testing.T) {
+	h := HeaderV2{HeaderV1: HeaderV1{TypeID: 7, Flags: FlagE2E, MsgID: 9, Source: 1, Target: 2, Timestamp: 3}, FragIndex: 1, FragCount: 4, TotalLen: 100}
+	h.SetPriority(2)
+	b, err := h.Encode(nil)
+	if err != nil || len(b) != HeaderSizeV2 {
+		t.Fatalf("encode: %v", err)
+	}
+	if FrameVersion(b) != Version2 {
+		t.Fatalf("version %d", FrameVersion(b))
+	}
+	var got HeaderV2
+	if err := got.Decode(b); err != nil || got != h || got.Priority() != 2 {
+		t.Fatalf("decode: %+v %v", got, err)
+	}
+	v1, _ := EncodeFrame(h.HeaderV1, nil)
+	if FrameVersion(v1) != Version1 {
+		t.Fatal("v1 frame misdetected")
+	}
+	if err := got.Decode(append(v1, make([]byte, 8)...)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("v1 as v2: %v", err)
+	}
+}
+
+func TestSplitReassemble(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 25)
+	frame, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, Flags: FlagAckRequested, MsgID: 5, Source: 1, Target: 2}, payload)
+
+	parts, err := SplitFrameV2(frame, 100)
+	if err != nil || len(parts) != 3 {
+		t.Fatalf("split: %d %v", len(parts), err)
+	}
+	r := NewReassembler(1000, 4, time.Minute)
+	for i, p := range parts {
+		out, err := r.Add(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < len(parts)-1 && out != nil {
+			t.Fatal("early output")
+		}
+		if i == len(parts)-1 && !bytes.Equal(out, frame) {
+			t.Fatal("reassembled frame differs")
+		}
+	}
+	if r.Pending() != 0 {
+		t.Fatal("pending not cleared")
+	}
+
+	// 未分片的 v2 帧与 v1 帧
+	one, _ := SplitFrameV2(frame, 1000)
+	if out, err := r.Add(one[0]); err != nil || !bytes.Equal(out, frame) {
+		t.Fatalf("single: %v", err)
+	}
+	if out, err := r.Add(frame); err != nil || !bytes.Equal(out, frame) {
+		t.Fatalf("v1: %v", err)
+	}
+
+	// 乱序
+	if _, err := r.Add(parts[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Add(parts[2]); !errors.Is(err, ErrFragmentSequence) {
+		t.Fatalf("out of order: %v", err)
+	}
+	if r.Pending() != 0 {
+		t.Fatal("partial not dropped")
+	}
+	// 缺少首片
+	if _, err := r.Add(parts[1]); !errors.Is(err, ErrFragmentSequence) {
+		t.Fatalf("missing first: %v", err)
+	}
+}
+
+func TestReassemblerLimits(t *testing.T) {
+	payload := make([]byte, 300)
+	frame, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, MsgID: 1}, payload)
+	parts, _ := SplitFrameV2(frame, 100)
+
+	if _, err := NewReassembler(200, 4, time.Minute).Add(parts[0]); !errors.Is(err, ErrReassemblyTooLarge) {
+		t.Fatalf("too large: %v", err)
+	}
+
+	r := NewReassembler(1000, 1, time.Minute)
+	if _, err := r.Add(parts[0]); err != nil {
+		t.Fatal(err)
+	}
+	other, _ := EncodeFrame(HeaderV1{TypeID: TypeMsgSend, MsgID: 2}, payload)
+	otherParts, _ := SplitFrameV2(other, 100)
+	if _, err := r.Add(otherParts[0]); !errors.Is(err, ErrReassemblyPending) {
+		t.Fatalf("pending: %v", err)
+	}
+
+	r = NewReassembler(1000, 4, time.Millisecond)
+	if _, err := r.Add(parts[0]); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := r.Add(parts[1]); !errors.Is(err, ErrFragmentSequence) {
+		t.Fatalf("expired: %v", err)
+	}
+}
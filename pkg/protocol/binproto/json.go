@@ -0,0 +1,137 @@
+package binproto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// JSONEnvelope 文本帧的 JSON 信封；payload 为 protojson（透传类型为 base64 字符串）。
+// 64 位字段按 protojson 惯例输出为字符串，输入时数字与字符串均可。
+//
+//	{"type":"VAR_LIST_REQ","msgId":"1","source":"0","target":"0","timestamp":"0","payload":{...}}
+type JSONEnvelope struct {
+	Type      json.RawMessage `json:"type"` // TypeID 数字或名称
+	TypeName  string          `json:"typeName,omitempty"`
+	MsgID     jsonUint64      `json:"msgId"`
+	Source    jsonUint64      `json:"source"`
+	Target    jsonUint64      `json:"target"`
+	Timestamp jsonUint64      `json:"timestamp"`
+	Flags     uint16          `json:"flags,omitempty"` // Header.Flags（如 FlagE2E）
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+type jsonUint64 uint64
+
+func (v jsonUint64) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatUint(uint64(v), 10) + `"`), nil
+}
+
+func (v *jsonUint64) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if len(s) >= 2 && s[0] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	if s == "" || s == "null" {
+		*v = 0
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	*v = jsonUint64(n)
+	return err
+}
+
+var (
+	ErrUnknownType = errors.New("unknown message type")
+	jsonMarshal    = protojson.MarshalOptions{UseProtoNames: true}
+	jsonUnmarshal  = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// FrameFromJSON 将 JSON 信封转换为二进制帧；timestamp 缺省取当前时间
+func FrameFromJSON(b []byte) ([]byte, error) {
+	var env JSONEnvelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return nil, err
+	}
+	t, err := envelopeType(env.Type)
+	if err != nil {
+		return nil, err
+	}
+	var payload []byte
+	if t.New == nil {
+		if len(env.Payload) > 0 {
+			var s string
+			if err := json.Unmarshal(env.Payload, &s); err != nil {
+				return nil, fmt.Errorf("%s payload must be a base64 string", t.Name)
+			}
+			if payload, err = base64.StdEncoding.DecodeString(s); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		m := t.New()
+		if len(env.Payload) > 0 {
+			if err := jsonUnmarshal.Unmarshal(env.Payload, m); err != nil {
+				return nil, err
+			}
+		}
+		if payload, err = proto.Marshal(m); err != nil {
+			return nil, err
+		}
+	}
+	ts := int64(env.Timestamp)
+	if ts == 0 {
+		ts = time.Now().UnixMilli()
+	}
+	return EncodeFrame(HeaderV1{TypeID: t.ID, MsgID: uint64(env.MsgID), Source: uint64(env.Source), Target: uint64(env.Target), Timestamp: ts, Flags: env.Flags}, payload)
+}
+
+func envelopeType(raw json.RawMessage) (MessageType, error) {
+	var id uint16
+	if err := json.Unmarshal(raw, &id); err == nil {
+		if t, ok := LookupType(id); ok {
+			return t, nil
+		}
+		return MessageType{}, fmt.Errorf("%w: %d", ErrUnknownType, id)
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err != nil {
+		return MessageType{}, ErrUnknownType
+	}
+	if t, ok := LookupTypeName(name); ok {
+		return t, nil
+	}
+	return MessageType{}, fmt.Errorf("%w: %s", ErrUnknownType, name)
+}
+
+// FrameToJSON 将二进制帧转换为 JSON 信封；未注册或无法解析的负载以 base64 字符串输出
+func FrameToJSON(frame []byte) ([]byte, error) {
+	h, pl, err := DecodeFrame(frame)
+	if err != nil {
+		return nil, err
+	}
+	env := JSONEnvelope{Type: json.RawMessage(strconv.Itoa(int(h.TypeID))), MsgID: jsonUint64(h.MsgID), Source: jsonUint64(h.Source), Target: jsonUint64(h.Target), Timestamp: jsonUint64(h.Timestamp), Flags: h.Flags}
+	t, ok := LookupType(h.TypeID)
+	if ok {
+		env.TypeName = t.Name
+	}
+	if ok && t.New != nil {
+		m := t.New()
+		if proto.Unmarshal(pl, m) == nil {
+			if env.Payload, err = jsonMarshal.Marshal(m); err != nil {
+				return nil, err
+			}
+			return json.Marshal(env)
+		}
+	}
+	if len(pl) > 0 {
+		env.Payload, _ = json.Marshal(base64.StdEncoding.EncodeToString(pl))
+	}
+	return json.Marshal(env)
+}
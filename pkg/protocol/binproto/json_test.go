@@ -0,0 +1,54 @@
+package binproto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFrameFromJSON(t *testing.T) {
+	in := `{"type":"VAR_UPDATE_REQ","msgId":"42","source":7,"payload":{"items":[{"device_uid":"9","name":"temp","value":"MjE="}]}}`
+	f, err := FrameFromJSON([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, pl, err := DecodeFrame(f)
+	if err != nil || h.TypeID != TypeVarUpdateReq || h.MsgID != 42 || h.Source != 7 || h.Timestamp == 0 {
+		t.Fatalf("header mismatch: %+v %v", h, err)
+	}
+	_, items, err := DecodeVarUpdateReq(pl)
+	if err != nil || len(items) != 1 || items[0].DeviceUID != 9 || items[0].Name != "temp" || string(items[0].Value) != "21" {
+		t.Fatalf("payload mismatch: %+v %v", items, err)
+	}
+
+	// 数字 TypeID 与透传负载
+	f, err = FrameFromJSON([]byte(`{"type":10,"target":"5","payload":"aGk="}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, pl, _ = DecodeFrame(f); h.TypeID != TypeMsgSend || h.Target != 5 || string(pl) != "hi" {
+		t.Fatalf("msg send mismatch: %+v %q", h, pl)
+	}
+
+	if _, err := FrameFromJSON([]byte(`{"type":"NO_SUCH_TYPE"}`)); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("expected unknown type, got %v", err)
+	}
+}
+
+func TestFrameToJSONRoundtrip(t *testing.T) {
+	h := HeaderV1{TypeID: TypeErrResp, MsgID: 1<<63 + 1, Source: 1, Target: 2, Timestamp: 1000}
+	f, _ := EncodeFrame(h, EncodeErrResp(3, 403, []byte("denied")))
+	out, err := FrameToJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env map[string]any
+	if err := json.Unmarshal(out, &env); err != nil || env["typeName"] != "ERR_RESP" || env["msgId"] != "9223372036854775809" {
+		t.Fatalf("unexpected envelope: %s", out)
+	}
+	back, err := FrameFromJSON(out)
+	if err != nil || !bytes.Equal(back, f) {
+		t.Fatalf("roundtrip mismatch: %v\n%x\n%x", err, back, f)
+	}
+}
@@ -1,17 +1,20 @@
 package binproto
 
 import (
+	"encoding/binary"
 	"errors"
 	pb "myflowhub/pkg/protocol/pb"
+	"strings"
 
 	"google.golang.org/protobuf/proto"
 )
 
 // Stable Type IDs per DOCS.md (subset)
 const (
-	TypeOKResp  uint16 = 0
-	TypeErrResp uint16 = 1
-	TypeMsgSend uint16 = 10
+	TypeOKResp    uint16 = 0
+	TypeErrResp   uint16 = 1
+	TypeCancelReq uint16 = 2
+	TypeMsgSend   uint16 = 10
 	// Devices
 	TypeQueryNodesReq   uint16 = 20
 	TypeCreateDeviceReq uint16 = 21
@@ -102,74 +105,108 @@ func DecodeErrResp(b []byte) (uint64, int32, []byte, error) {
 	return m.GetRequestId(), m.GetCode(), append([]byte(nil), m.GetMessage()...), nil
 }
 
+// CancelReq: {msg_id:u64}，取消同一连接上 MsgID 为 msgID 的请求
+func EncodeCancelReq(msgID uint64) []byte {
+	b, _ := proto.Marshal(&pb.CancelReq{MsgId: msgID})
+	return b
+}
+
+func DecodeCancelReq(b []byte) (uint64, error) {
+	var m pb.CancelReq
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return 0, err
+	}
+	return m.GetMsgId(), nil
+}
+
 // ManagerAuth: Req {token:len16+utf8}
-func EncodeManagerAuthReq(token string) []byte {
-	m := &pb.ManagerAuthReq{Token: token}
+func EncodeManagerAuthReq(token string, frameMACPub []byte) []byte {
+	m := &pb.ManagerAuthReq{Token: token, FrameMacPub: frameMACPub}
 	b, _ := proto.Marshal(m)
 	return b
 }
 
-func DecodeManagerAuthReq(b []byte) (string, error) {
+func DecodeManagerAuthReq(b []byte) (token string, frameMACPub []byte, err error) {
 	var m pb.ManagerAuthReq
-	if err := proto.Unmarshal(b, &m); err != nil {
-		return "", err
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", nil, err
 	}
-	return m.GetToken(), nil
+	return m.GetToken(), m.GetFrameMacPub(), nil
 }
 
-// ManagerAuth: Resp {request_id:u64, device_uid:u64, role(len16+utf8 optional, 0 长度视为缺省)}
-func EncodeManagerAuthResp(requestID, deviceUID uint64, role string) []byte {
-	m := &pb.ManagerAuthResp{RequestId: requestID, DeviceUid: deviceUID, Role: role}
+// ManagerAuth: Resp {request_id:u64, device_uid:u64, role(len16+utf8 optional, 0 长度视为缺省), frame_mac_pub:bytes}
+func EncodeManagerAuthResp(requestID, deviceUID uint64, role string, frameMACPub []byte) []byte {
+	m := &pb.ManagerAuthResp{RequestId: requestID, DeviceUid: deviceUID, Role: role, FrameMacPub: frameMACPub}
 	b, _ := proto.Marshal(m)
 	return b
 }
 
-func DecodeManagerAuthResp(b []byte) (reqID, deviceUID uint64, role string, err error) {
+func DecodeManagerAuthResp(b []byte) (reqID, deviceUID uint64, role string, frameMACPub []byte, err error) {
 	var m pb.ManagerAuthResp
 	if err = proto.Unmarshal(b, &m); err != nil {
-		return 0, 0, "", err
+		return 0, 0, "", nil, err
 	}
-	return m.GetRequestId(), m.GetDeviceUid(), m.GetRole(), nil
+	return m.GetRequestId(), m.GetDeviceUid(), m.GetRole(), m.GetFrameMacPub(), nil
 }
 
-// ParentAuthReq: {version:u8, ts:i64(ms), nonce:16B, hardware_id:len16+str, caps:len16+str, sig:32B}
-func EncodeParentAuthReq(version uint8, ts int64, nonce [16]byte, hardwareID, caps string, sig [32]byte) []byte {
+// ParentAuthReq: {version:u8, ts:i64(ms), nonce:16B, hardware_id:len16+str, caps:len16+str, sig:32B, frame_mac_pub:bytes}
+func EncodeParentAuthReq(version uint8, ts int64, nonce [16]byte, hardwareID, caps string, sig [32]byte, frameMACPub []byte) []byte {
 	m := &pb.ParentAuthReq{
-		Version:    uint32(version),
-		TsMs:       ts,
-		Nonce:      append([]byte(nil), nonce[:]...),
-		HardwareId: hardwareID,
-		Caps:       caps,
-		Sig:        append([]byte(nil), sig[:]...),
+		Version:     uint32(version),
+		TsMs:        ts,
+		Nonce:       append([]byte(nil), nonce[:]...),
+		HardwareId:  hardwareID,
+		Caps:        caps,
+		Sig:         append([]byte(nil), sig[:]...),
+		FrameMacPub: frameMACPub,
 	}
 	b, _ := proto.Marshal(m)
 	return b
 }
 
-func DecodeParentAuthReq(b []byte) (version uint8, ts int64, nonce [16]byte, hardwareID, caps string, sig [32]byte, err error) {
+func DecodeParentAuthReq(b []byte) (version uint8, ts int64, nonce [16]byte, hardwareID, caps string, sig [32]byte, frameMACPub []byte, err error) {
 	var m pb.ParentAuthReq
 	if err = proto.Unmarshal(b, &m); err != nil {
-		return 0, 0, nonce, "", "", sig, err
+		return 0, 0, nonce, "", "", sig, nil, err
 	}
 	version = uint8(m.GetVersion() & 0xff)
 	ts = m.GetTsMs()
 	nb := m.GetNonce()
 	if len(nb) != 16 {
-		return 0, 0, nonce, "", "", sig, errors.New("invalid nonce length")
+		return 0, 0, nonce, "", "", sig, nil, errors.New("invalid nonce length")
 	}
 	copy(nonce[:], nb)
 	hardwareID = m.GetHardwareId()
 	caps = m.GetCaps()
 	sb := m.GetSig()
 	if len(sb) != 32 {
-		return 0, 0, nonce, "", "", sig, errors.New("invalid sig length")
+		return 0, 0, nonce, "", "", sig, nil, errors.New("invalid sig length")
 	}
 	copy(sig[:], sb)
+	frameMACPub = m.GetFrameMacPub()
 	return
 }
 
-// ParentAuthResp: {request_id:u64, device_uid:u64, session_id:16B, heartbeat_sec:u16, perms:[len16+str], exp:i64, sig:32B}
-func EncodeParentAuthResp(requestID, deviceUID uint64, sessionID [16]byte, heartbeatSec uint16, perms []string, exp int64, sig [32]byte) []byte {
+// ParentAuthRespSignedData 上级对 ParentAuthResp 签名（HMAC-SHA256，密钥为认证所用共享令牌）的内容：
+// 请求 nonce(16B) + device_uid(u64 LE) + session_id(16B) + heartbeat_sec(u16 LE) + exp(i64 LE) + perms（以 \n 连接）
+// [+ \x00 + frame_mac_pub（仅非空时）]
+func ParentAuthRespSignedData(reqNonce [16]byte, deviceUID uint64, sessionID [16]byte, heartbeatSec uint16, perms []string, exp int64, frameMACPub []byte) []byte {
+	b := make([]byte, 0, 64)
+	b = append(b, reqNonce[:]...)
+	b = binary.LittleEndian.AppendUint64(b, deviceUID)
+	b = append(b, sessionID[:]...)
+	b = binary.LittleEndian.AppendUint16(b, heartbeatSec)
+	b = binary.LittleEndian.AppendUint64(b, uint64(exp))
+	b = append(b, strings.Join(perms, "\n")...)
+	if len(frameMACPub) > 0 {
+		b = append(b, 0)
+		b = append(b, frameMACPub...)
+	}
+	return b
+}
+
+// ParentAuthResp: {request_id:u64, device_uid:u64, session_id:16B, heartbeat_sec:u16, perms:[len16+str], exp:i64(ms), sig:32B, frame_mac_pub:bytes}
+func EncodeParentAuthResp(requestID, deviceUID uint64, sessionID [16]byte, heartbeatSec uint16, perms []string, exp int64, sig [32]byte, frameMACPub []byte) []byte {
 	m := &pb.ParentAuthResp{
 		RequestId:    requestID,
 		DeviceUid:    deviceUID,
@@ -178,21 +215,22 @@ func EncodeParentAuthResp(requestID, deviceUID uint64, sessionID [16]byte, heart
 		Perms:        append([]string(nil), perms...),
 		Exp:          exp,
 		Sig:          append([]byte(nil), sig[:]...),
+		FrameMacPub:  frameMACPub,
 	}
 	b, _ := proto.Marshal(m)
 	return b
 }
 
-func DecodeParentAuthResp(b []byte) (requestID, deviceUID uint64, sessionID [16]byte, heartbeatSec uint16, perms []string, exp int64, sig [32]byte, err error) {
+func DecodeParentAuthResp(b []byte) (requestID, deviceUID uint64, sessionID [16]byte, heartbeatSec uint16, perms []string, exp int64, sig [32]byte, frameMACPub []byte, err error) {
 	var m pb.ParentAuthResp
 	if err = proto.Unmarshal(b, &m); err != nil {
-		return 0, 0, sessionID, 0, nil, 0, sig, err
+		return 0, 0, sessionID, 0, nil, 0, sig, nil, err
 	}
 	requestID = m.GetRequestId()
 	deviceUID = m.GetDeviceUid()
 	sid := m.GetSessionId()
 	if len(sid) != 16 {
-		return 0, 0, sessionID, 0, nil, 0, sig, errors.New("invalid sessionID length")
+		return 0, 0, sessionID, 0, nil, 0, sig, nil, errors.New("invalid sessionID length")
 	}
 	copy(sessionID[:], sid)
 	heartbeatSec = uint16(m.GetHeartbeatSec() & 0xffff)
@@ -200,9 +238,10 @@ func DecodeParentAuthResp(b []byte) (requestID, deviceUID uint64, sessionID [16]
 	exp = m.GetExp()
 	sb := m.GetSig()
 	if len(sb) != 32 {
-		return 0, 0, sessionID, 0, nil, 0, sig, errors.New("invalid sig length")
+		return 0, 0, sessionID, 0, nil, 0, sig, nil, errors.New("invalid sig length")
 	}
 	copy(sig[:], sb)
+	frameMACPub = m.GetFrameMacPub()
 	return
 }
 
@@ -624,10 +663,12 @@ type DeviceItem struct {
 	Name         string
 	ParentID     *uint64
 	OwnerUserID  *uint64
-	LastSeenSec  *int64 // epoch seconds
-	CreatedAtSec int64  // epoch seconds
-	UpdatedAtSec int64  // epoch seconds
-	Approved     *bool  // 审批状态（可空）
+	LastSeenSec  *int64  // epoch seconds
+	CreatedAtSec int64   // epoch seconds
+	UpdatedAtSec int64   // epoch seconds
+	Approved     *bool   // 审批状态（可空）
+	Online       *bool   // 在线状态（可空）
+	Tags         *string // 标签（逗号分隔，可空：更新时为空表示不修改）
 }
 
 // protobuf mapping helpers for DeviceItem
@@ -652,6 +693,11 @@ func toPBDeviceItem(d DeviceItem) *pb.DeviceItem {
 		v := *d.Approved
 		approved = &v
 	}
+	var online *bool
+	if d.Online != nil {
+		v := *d.Online
+		online = &v
+	}
 	return &pb.DeviceItem{
 		Id:           d.ID,
 		DeviceUid:    d.DeviceUID,
@@ -664,6 +710,8 @@ func toPBDeviceItem(d DeviceItem) *pb.DeviceItem {
 		CreatedAtSec: d.CreatedAtSec,
 		UpdatedAtSec: d.UpdatedAtSec,
 		Approved:     approved,
+		Online:       online,
+		Tags:         d.Tags,
 	}
 }
 
@@ -693,6 +741,14 @@ func fromPBDeviceItem(p *pb.DeviceItem) DeviceItem {
 		v := p.GetApproved()
 		it.Approved = &v
 	}
+	if p.Online != nil {
+		v := p.GetOnline()
+		it.Online = &v
+	}
+	if p.Tags != nil {
+		v := p.GetTags()
+		it.Tags = &v
+	}
 	return it
 }
 
@@ -820,6 +876,8 @@ const (
 	TypeVarListResp  uint16 = 161 // reserved for later
 	TypeVarUpdateReq uint16 = 162
 	TypeVarDeleteReq uint16 = 163
+	// Hub → 设备：其变量被其他请求方修改
+	TypeVarChangedNotify uint16 = 164
 )
 
 // ========== Variables: List/Query ==========
@@ -935,6 +993,27 @@ func DecodeVarUpdateReq(b []byte) (userKey string, items []VarUpdateItem, err er
 	return
 }
 
+// VarChangedNotify {source_device_uid:u64, items:[{device_uid, name, value}]}
+func EncodeVarChangedNotify(sourceDeviceUID uint64, items []VarUpdateItem) []byte {
+	arr := make([]*pb.VarUpdateItem, 0, len(items))
+	for _, it := range items {
+		arr = append(arr, &pb.VarUpdateItem{DeviceUid: it.DeviceUID, Name: it.Name, Value: it.Value})
+	}
+	b, _ := proto.Marshal(&pb.VarChangedNotify{SourceDeviceUid: sourceDeviceUID, Items: arr})
+	return b
+}
+func DecodeVarChangedNotify(b []byte) (sourceDeviceUID uint64, items []VarUpdateItem, err error) {
+	var m pb.VarChangedNotify
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, nil, err
+	}
+	items = make([]VarUpdateItem, 0, len(m.GetItems()))
+	for _, it := range m.GetItems() {
+		items = append(items, VarUpdateItem{DeviceUID: it.GetDeviceUid(), Name: it.GetName(), Value: append([]byte(nil), it.GetValue()...)})
+	}
+	return m.GetSourceDeviceUid(), items, nil
+}
+
 type VarDeleteItem struct {
 	DeviceUID uint64
 	Name      string
@@ -0,0 +1,79 @@
+package binproto
+
+import (
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== Device Certificates (built-in CA) ==========
+const (
+	TypeCertCSRReq  uint16 = 370
+	TypeCertCSRResp uint16 = 371
+	TypeCertCRLReq  uint16 = 372
+	TypeCertCRLResp uint16 = 373
+)
+
+// CertRevokedItem 吊销列表条目（时间为 epoch 秒）
+type CertRevokedItem struct {
+	Serial    string
+	DeviceUID uint64
+	RevokedAt int64
+	NotAfter  int64
+}
+
+// CertCSRReq: {csr:bytes(DER PKCS#10)}
+func EncodeCertCSRReq(csr []byte) []byte {
+	b, _ := proto.Marshal(&pb.CertCsrReq{Csr: csr})
+	return b
+}
+
+func DecodeCertCSRReq(b []byte) (csr []byte, err error) {
+	var m pb.CertCsrReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m.GetCsr(), nil
+}
+
+// CertCSRResp: {request_id:u64, cert:bytes, chain:[bytes], serial:str, not_after:i64}
+func EncodeCertCSRResp(requestID uint64, cert []byte, chain [][]byte, serial string, notAfter int64) []byte {
+	b, _ := proto.Marshal(&pb.CertCsrResp{RequestId: requestID, Cert: cert, Chain: chain, Serial: serial, NotAfter: notAfter})
+	return b
+}
+
+func DecodeCertCSRResp(b []byte) (requestID uint64, cert []byte, chain [][]byte, serial string, notAfter int64, err error) {
+	var m pb.CertCsrResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, nil, nil, "", 0, err
+	}
+	return m.GetRequestId(), m.GetCert(), m.GetChain(), m.GetSerial(), m.GetNotAfter(), nil
+}
+
+// CertCRLReq: {}
+func EncodeCertCRLReq() []byte {
+	b, _ := proto.Marshal(&pb.CertCrlReq{})
+	return b
+}
+
+// CertCRLResp: {request_id:u64, items:[CertRevokedItem], generated_at:i64}
+func EncodeCertCRLResp(requestID uint64, items []CertRevokedItem, generatedAt int64) []byte {
+	list := make([]*pb.CertRevokedItem, 0, len(items))
+	for _, it := range items {
+		list = append(list, &pb.CertRevokedItem{Serial: it.Serial, DeviceUid: it.DeviceUID, RevokedAt: it.RevokedAt, NotAfter: it.NotAfter})
+	}
+	b, _ := proto.Marshal(&pb.CertCrlResp{RequestId: requestID, Items: list, GeneratedAt: generatedAt})
+	return b
+}
+
+func DecodeCertCRLResp(b []byte) (requestID uint64, items []CertRevokedItem, generatedAt int64, err error) {
+	var m pb.CertCrlResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, nil, 0, err
+	}
+	items = make([]CertRevokedItem, 0, len(m.GetItems()))
+	for _, it := range m.GetItems() {
+		items = append(items, CertRevokedItem{Serial: it.GetSerial(), DeviceUID: it.GetDeviceUid(), RevokedAt: it.GetRevokedAt(), NotAfter: it.GetNotAfter()})
+	}
+	return m.GetRequestId(), items, m.GetGeneratedAt(), nil
+}
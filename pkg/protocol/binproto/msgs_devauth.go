@@ -0,0 +1,157 @@
+package binproto
+
+import (
+	"encoding/binary"
+
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== Device Key Auth (Ed25519 challenge-response) ==========
+const (
+	TypeDeviceChallengeReq  uint16 = 380
+	TypeDeviceChallengeResp uint16 = 381
+	TypeDeviceAuthReq       uint16 = 382
+	TypeDeviceAuthResp      uint16 = 383
+	TypeDeviceKeySetReq     uint16 = 384
+)
+
+const (
+	deviceAuthContext = "myflowhub-device-auth-v1\x00"
+	keyRotateContext  = "myflowhub-key-rotate-v1\x00"
+)
+
+// DeviceAuthMessage 设备对挑战签名的内容：context + nonce + hardware_id [+ \x00 + frame_mac_pub（仅非空时）]
+func DeviceAuthMessage(nonce []byte, hardwareID string, frameMACPub []byte) []byte {
+	b := make([]byte, 0, len(deviceAuthContext)+len(nonce)+len(hardwareID)+1+len(frameMACPub))
+	b = append(b, deviceAuthContext...)
+	b = append(b, nonce...)
+	b = append(b, hardwareID...)
+	if len(frameMACPub) > 0 {
+		b = append(b, 0)
+		b = append(b, frameMACPub...)
+	}
+	return b
+}
+
+// DeviceKeyRotateMessage 旧私钥对新公钥签名的内容：context + device_uid(u64 LE) + new_key
+func DeviceKeyRotateMessage(deviceUID uint64, newKey []byte) []byte {
+	b := make([]byte, 0, len(keyRotateContext)+8+len(newKey))
+	b = append(b, keyRotateContext...)
+	b = binary.LittleEndian.AppendUint64(b, deviceUID)
+	return append(b, newKey...)
+}
+
+// DeviceKeySet 登记/轮换公钥请求；OldKeySig、Secret、UserKey 任选其一作为凭据
+type DeviceKeySet struct {
+	DeviceUID uint64
+	PublicKey []byte
+	OldKeySig []byte
+	Secret    string
+	UserKey   string
+}
+
+// DeviceChallengeReq: {hardware_id:str}
+func EncodeDeviceChallengeReq(hardwareID string) []byte {
+	b, _ := proto.Marshal(&pb.DeviceChallengeReq{HardwareId: hardwareID})
+	return b
+}
+
+func DecodeDeviceChallengeReq(b []byte) (hardwareID string, err error) {
+	var m pb.DeviceChallengeReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", err
+	}
+	return m.GetHardwareId(), nil
+}
+
+// DeviceChallengeResp: {request_id:u64, nonce:bytes, expires_at:i64(ms), frame_mac:bool}
+func EncodeDeviceChallengeResp(requestID uint64, nonce []byte, expiresAt int64, frameMAC bool) []byte {
+	b, _ := proto.Marshal(&pb.DeviceChallengeResp{RequestId: requestID, Nonce: nonce, ExpiresAt: expiresAt, FrameMac: frameMAC})
+	return b
+}
+
+func DecodeDeviceChallengeResp(b []byte) (requestID uint64, nonce []byte, expiresAt int64, frameMAC bool, err error) {
+	var m pb.DeviceChallengeResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, nil, 0, false, err
+	}
+	return m.GetRequestId(), m.GetNonce(), m.GetExpiresAt(), m.GetFrameMac(), nil
+}
+
+// DeviceAuthReq: {hardware_id:str, signature:bytes, frame_mac_pub:bytes}
+func EncodeDeviceAuthReq(hardwareID string, signature, frameMACPub []byte) []byte {
+	b, _ := proto.Marshal(&pb.DeviceAuthReq{HardwareId: hardwareID, Signature: signature, FrameMacPub: frameMACPub})
+	return b
+}
+
+func DecodeDeviceAuthReq(b []byte) (hardwareID string, signature, frameMACPub []byte, err error) {
+	var m pb.DeviceAuthReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", nil, nil, err
+	}
+	return m.GetHardwareId(), m.GetSignature(), m.GetFrameMacPub(), nil
+}
+
+// DeviceAuthResp: {request_id:u64, device_uid:u64, heartbeat_sec:u32, frame_mac_pub:bytes}
+func EncodeDeviceAuthResp(requestID, deviceUID uint64, heartbeatSec uint32, frameMACPub []byte) []byte {
+	b, _ := proto.Marshal(&pb.DeviceAuthResp{RequestId: requestID, DeviceUid: deviceUID, HeartbeatSec: heartbeatSec, FrameMacPub: frameMACPub})
+	return b
+}
+
+func DecodeDeviceAuthResp(b []byte) (requestID, deviceUID uint64, heartbeatSec uint32, frameMACPub []byte, err error) {
+	var m pb.DeviceAuthResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, 0, 0, nil, err
+	}
+	return m.GetRequestId(), m.GetDeviceUid(), m.GetHeartbeatSec(), m.GetFrameMacPub(), nil
+}
+
+// DeviceKeySetReq: {device_uid:u64, public_key:bytes, old_key_sig:bytes, secret:str, user_key:str}
+func EncodeDeviceKeySetReq(r DeviceKeySet) []byte {
+	b, _ := proto.Marshal(&pb.DeviceKeySetReq{DeviceUid: r.DeviceUID, PublicKey: r.PublicKey, OldKeySig: r.OldKeySig, Secret: r.Secret, UserKey: r.UserKey})
+	return b
+}
+
+func DecodeDeviceKeySetReq(b []byte) (DeviceKeySet, error) {
+	var m pb.DeviceKeySetReq
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return DeviceKeySet{}, err
+	}
+	return DeviceKeySet{DeviceUID: m.GetDeviceUid(), PublicKey: m.GetPublicKey(), OldKeySig: m.GetOldKeySig(), Secret: m.GetSecret(), UserKey: m.GetUserKey()}, nil
+}
+
+// ========== Device Secret Rotation ==========
+const (
+	TypeDeviceRotateSecretReq  uint16 = 390
+	TypeDeviceRotateSecretResp uint16 = 391
+)
+
+// DeviceRotateSecretReq: {device_uid:u64, user_key:str, grace_sec:u32, force:bool}
+func EncodeDeviceRotateSecretReq(deviceUID uint64, userKey string, graceSec uint32, force bool) []byte {
+	b, _ := proto.Marshal(&pb.DeviceRotateSecretReq{DeviceUid: deviceUID, UserKey: userKey, GraceSec: graceSec, Force: force})
+	return b
+}
+
+func DecodeDeviceRotateSecretReq(b []byte) (deviceUID uint64, userKey string, graceSec uint32, force bool, err error) {
+	var m pb.DeviceRotateSecretReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, "", 0, false, err
+	}
+	return m.GetDeviceUid(), m.GetUserKey(), m.GetGraceSec(), m.GetForce(), nil
+}
+
+// DeviceRotateSecretResp: {request_id:u64, device_uid:u64, secret:str, grace_until:i64(s)}
+func EncodeDeviceRotateSecretResp(requestID, deviceUID uint64, secret string, graceUntil int64) []byte {
+	b, _ := proto.Marshal(&pb.DeviceRotateSecretResp{RequestId: requestID, DeviceUid: deviceUID, Secret: secret, GraceUntil: graceUntil})
+	return b
+}
+
+func DecodeDeviceRotateSecretResp(b []byte) (requestID, deviceUID uint64, secret string, graceUntil int64, err error) {
+	var m pb.DeviceRotateSecretResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, 0, "", 0, err
+	}
+	return m.GetRequestId(), m.GetDeviceUid(), m.GetSecret(), m.GetGraceUntil(), nil
+}
@@ -0,0 +1,242 @@
+package binproto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== End-to-End Encryption (MSG_SEND) ==========
+const (
+	TypeE2EKeyPublishReq    uint16 = 400
+	TypeE2EKeyGetReq        uint16 = 401
+	TypeE2EKeyGetResp       uint16 = 402
+	TypeE2EKeyChangedNotify uint16 = 403
+)
+
+// E2EKey 公钥目录条目（UpdatedAt 为 epoch ms）
+type E2EKey struct {
+	DeviceUID uint64
+	PublicKey []byte
+	UpdatedAt int64
+}
+
+// E2EKeyPublishReq: {public_key:bytes}
+func EncodeE2EKeyPublishReq(publicKey []byte) []byte {
+	b, _ := proto.Marshal(&pb.E2EKeyPublishReq{PublicKey: publicKey})
+	return b
+}
+
+func DecodeE2EKeyPublishReq(b []byte) (publicKey []byte, err error) {
+	var m pb.E2EKeyPublishReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m.GetPublicKey(), nil
+}
+
+// E2EKeyGetReq: {device_uids:[u64]}
+func EncodeE2EKeyGetReq(deviceUIDs []uint64) []byte {
+	b, _ := proto.Marshal(&pb.E2EKeyGetReq{DeviceUids: deviceUIDs})
+	return b
+}
+
+func DecodeE2EKeyGetReq(b []byte) (deviceUIDs []uint64, err error) {
+	var m pb.E2EKeyGetReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m.GetDeviceUids(), nil
+}
+
+// E2EKeyGetResp: {request_id:u64, keys:[E2EKey]}
+func EncodeE2EKeyGetResp(requestID uint64, keys []E2EKey) []byte {
+	b, _ := proto.Marshal(&pb.E2EKeyGetResp{RequestId: requestID, Keys: e2eKeysToPB(keys)})
+	return b
+}
+
+func DecodeE2EKeyGetResp(b []byte) (requestID uint64, keys []E2EKey, err error) {
+	var m pb.E2EKeyGetResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, nil, err
+	}
+	return m.GetRequestId(), e2eKeysFromPB(m.GetKeys()), nil
+}
+
+// E2EKeyChangedNotify: {keys:[E2EKey]}
+func EncodeE2EKeyChangedNotify(keys []E2EKey) []byte {
+	b, _ := proto.Marshal(&pb.E2EKeyChangedNotify{Keys: e2eKeysToPB(keys)})
+	return b
+}
+
+func DecodeE2EKeyChangedNotify(b []byte) (keys []E2EKey, err error) {
+	var m pb.E2EKeyChangedNotify
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return e2eKeysFromPB(m.GetKeys()), nil
+}
+
+func e2eKeysToPB(keys []E2EKey) []*pb.E2EKey {
+	list := make([]*pb.E2EKey, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, &pb.E2EKey{DeviceUid: k.DeviceUID, PublicKey: k.PublicKey, UpdatedAt: k.UpdatedAt})
+	}
+	return list
+}
+
+func e2eKeysFromPB(list []*pb.E2EKey) []E2EKey {
+	keys := make([]E2EKey, 0, len(list))
+	for _, k := range list {
+		keys = append(keys, E2EKey{DeviceUID: k.GetDeviceUid(), PublicKey: k.GetPublicKey(), UpdatedAt: k.GetUpdatedAt()})
+	}
+	return keys
+}
+
+// E2E 负载格式（v1）：
+//
+//	version[1]=1 | sender_key_id[8] | recipient_key_id[8] | nonce[12] | AES-256-GCM 密文+tag
+//
+// 对称密钥按设备对派生：HKDF-SHA256(X25519 共享秘密, salt=context,
+// info=context | uid_lo(u64 LE) | uid_hi(u64 LE) | pub_lo | pub_hi)，lo/hi 按 UID 升序，双方得到同一密钥。
+// AAD = context | TypeID | Flags | MsgID | Source | Target（均 LE）| 负载前 17 字节，
+// 因此改写 Source/Target 或以他人的密钥冒充发送方都会导致解密失败。
+const (
+	e2eContext    = "myflowhub-e2e-v1\x00"
+	e2eVersion    = 1
+	e2eHeaderSize = 1 + 8 + 8 + 12
+)
+
+var (
+	ErrE2EMalformed   = errors.New("e2e: malformed payload")
+	ErrE2EKeyMismatch = errors.New("e2e: key id mismatch") // 任一方公钥已更换，应重新查询公钥目录
+	ErrE2EDecrypt     = errors.New("e2e: decryption failed")
+)
+
+// E2EKeyID 公钥指纹：SHA-256 的前 8 字节
+func E2EKeyID(publicKey []byte) [8]byte {
+	sum := sha256.Sum256(publicKey)
+	var id [8]byte
+	copy(id[:], sum[:8])
+	return id
+}
+
+// E2EKeyIDs 返回负载中的发送方与接收方公钥指纹，供接收方选择（或刷新）对应公钥
+func E2EKeyIDs(payload []byte) (sender, recipient [8]byte, err error) {
+	if len(payload) < e2eHeaderSize || payload[0] != e2eVersion {
+		return sender, recipient, ErrE2EMalformed
+	}
+	copy(sender[:], payload[1:9])
+	copy(recipient[:], payload[9:17])
+	return sender, recipient, nil
+}
+
+// E2EPairKey 派生 selfUID 与 peerUID 之间的对称密钥
+func E2EPairKey(priv *ecdh.PrivateKey, selfUID uint64, peerPub []byte, peerUID uint64) ([]byte, error) {
+	pub, err := ecdh.X25519().NewPublicKey(peerPub)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	selfPub := priv.PublicKey().Bytes()
+	loUID, hiUID, loPub, hiPub := selfUID, peerUID, selfPub, peerPub
+	if peerUID < selfUID {
+		loUID, hiUID, loPub, hiPub = peerUID, selfUID, peerPub, selfPub
+	}
+	info := make([]byte, 0, len(e2eContext)+16+len(loPub)+len(hiPub)+1)
+	info = append(info, e2eContext...)
+	info = binary.LittleEndian.AppendUint64(info, loUID)
+	info = binary.LittleEndian.AppendUint64(info, hiUID)
+	info = append(info, loPub...)
+	info = append(info, hiPub...)
+	// HKDF-SHA256：extract 后 expand 单块（32 字节）
+	ext := hmac.New(sha256.New, []byte(e2eContext))
+	ext.Write(shared)
+	exp := hmac.New(sha256.New, ext.Sum(nil))
+	exp.Write(info)
+	exp.Write([]byte{1})
+	return exp.Sum(nil), nil
+}
+
+// SealE2E 以发送方私钥与接收方公钥加密 MSG_SEND 负载，并在 h.Flags 上置 FlagE2E。
+// h.Source/h.Target 须分别为发送方与接收方 UID，h.MsgID 在加密前确定。
+func SealE2E(priv *ecdh.PrivateKey, h *HeaderV1, recipientPub, plaintext []byte) ([]byte, error) {
+	key, err := E2EPairKey(priv, h.Source, recipientPub, h.Target)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := e2eAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	h.Flags |= FlagE2E
+	out := make([]byte, e2eHeaderSize, e2eHeaderSize+len(plaintext)+aead.Overhead())
+	out[0] = e2eVersion
+	sid := E2EKeyID(priv.PublicKey().Bytes())
+	rid := E2EKeyID(recipientPub)
+	copy(out[1:9], sid[:])
+	copy(out[9:17], rid[:])
+	if _, err := rand.Read(out[17:e2eHeaderSize]); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, out[17:e2eHeaderSize], plaintext, e2eAAD(*h, out[:17])), nil
+}
+
+// OpenE2E 以接收方私钥与公钥目录中 h.Source 的公钥解密负载。负载中的发送方指纹必须与该公钥一致，
+// 且密钥按 h.Source 派生，从而确认发送方确为帧头声明的 Source。
+func OpenE2E(priv *ecdh.PrivateKey, h HeaderV1, senderPub, payload []byte) ([]byte, error) {
+	if h.Flags&FlagE2E == 0 {
+		return nil, ErrE2EMalformed
+	}
+	sid, rid, err := E2EKeyIDs(payload)
+	if err != nil {
+		return nil, err
+	}
+	if sid != E2EKeyID(senderPub) || rid != E2EKeyID(priv.PublicKey().Bytes()) {
+		return nil, ErrE2EKeyMismatch
+	}
+	key, err := E2EPairKey(priv, h.Target, senderPub, h.Source)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := e2eAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	pt, err := aead.Open(nil, payload[17:e2eHeaderSize], payload[e2eHeaderSize:], e2eAAD(h, payload[:17]))
+	if err != nil {
+		return nil, ErrE2EDecrypt
+	}
+	return pt, nil
+}
+
+func e2eAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func e2eAAD(h HeaderV1, prefix []byte) []byte {
+	b := make([]byte, 0, len(e2eContext)+28+len(prefix))
+	b = append(b, e2eContext...)
+	b = binary.LittleEndian.AppendUint16(b, h.TypeID)
+	b = binary.LittleEndian.AppendUint16(b, h.Flags)
+	b = binary.LittleEndian.AppendUint64(b, h.MsgID)
+	b = binary.LittleEndian.AppendUint64(b, h.Source)
+	b = binary.LittleEndian.AppendUint64(b, h.Target)
+	return append(b, prefix...)
+}
@@ -0,0 +1,52 @@
+package binproto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestE2ESealOpen(t *testing.T) {
+	alice, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	bob, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	eve, _ := ecdh.X25519().GenerateKey(rand.Reader)
+
+	h := HeaderV1{TypeID: TypeMsgSend, MsgID: 7, Source: 11, Target: 22}
+	ct, err := SealE2E(alice, &h, bob.PublicKey().Bytes(), []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Flags&FlagE2E == 0 {
+		t.Fatal("flag not set")
+	}
+	pt, err := OpenE2E(bob, h, alice.PublicKey().Bytes(), ct)
+	if err != nil || !bytes.Equal(pt, []byte("hello")) {
+		t.Fatalf("open: %q %v", pt, err)
+	}
+
+	// 改写 Source：密钥按帧头 Source 派生，解密失败
+	spoof := h
+	spoof.Source = 33
+	if _, err := OpenE2E(bob, spoof, alice.PublicKey().Bytes(), ct); !errors.Is(err, ErrE2EDecrypt) {
+		t.Fatalf("spoofed source: %v", err)
+	}
+	// 以其他发送方公钥校验：指纹不符
+	if _, err := OpenE2E(bob, h, eve.PublicKey().Bytes(), ct); !errors.Is(err, ErrE2EKeyMismatch) {
+		t.Fatalf("wrong sender key: %v", err)
+	}
+	// 篡改密文
+	ct[len(ct)-1] ^= 1
+	if _, err := OpenE2E(bob, h, alice.PublicKey().Bytes(), ct); !errors.Is(err, ErrE2EDecrypt) {
+		t.Fatalf("tampered: %v", err)
+	}
+}
+
+func TestE2EKeyGetRespCodec(t *testing.T) {
+	keys := []E2EKey{{DeviceUID: 5, PublicKey: []byte{1, 2, 3}, UpdatedAt: 99}}
+	rid, got, err := DecodeE2EKeyGetResp(EncodeE2EKeyGetResp(3, keys))
+	if err != nil || rid != 3 || len(got) != 1 || got[0].DeviceUID != 5 || !bytes.Equal(got[0].PublicKey, keys[0].PublicKey) || got[0].UpdatedAt != 99 {
+		t.Fatalf("mismatch: %d %+v %v", rid, got, err)
+	}
+}
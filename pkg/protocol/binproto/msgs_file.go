@@ -0,0 +1,180 @@
+package binproto
+
+import (
+	"errors"
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== File Transfer ==========
+const (
+	TypeFileInitReq      uint16 = 300
+	TypeFileInitResp     uint16 = 301
+	TypeFileChunk        uint16 = 302
+	TypeFileCompleteReq  uint16 = 303
+	TypeFileCompleteResp uint16 = 304
+	TypeFileCancel       uint16 = 305
+	TypeFileChunkAck     uint16 = 306
+)
+
+// IsFileTransferType 判断 TypeID 是否属于文件分片协议（300~306）
+func IsFileTransferType(typeID uint16) bool {
+	return typeID >= TypeFileInitReq && typeID <= TypeFileChunkAck
+}
+
+// FileInit 描述一次传输的元信息
+type FileInit struct {
+	TransferID uint64
+	TotalSize  uint64
+	Mime       string
+	Filename   string
+	FileHash   []byte // SHA-256, 32B
+	Thumbnail  []byte // 可选
+	ChunkSize  uint32
+}
+
+// FileInitReq: {transfer_id:u64, total_size:u64, mime:str, filename:str, file_hash:32B, thumbnail?:bytes, chunk_size:u32}
+func EncodeFileInitReq(f FileInit) []byte {
+	m := &pb.FileInitReq{
+		TransferId: f.TransferID,
+		TotalSize:  f.TotalSize,
+		Mime:       f.Mime,
+		Filename:   f.Filename,
+		FileHash:   append([]byte(nil), f.FileHash...),
+		ChunkSize:  f.ChunkSize,
+	}
+	if f.Thumbnail != nil {
+		m.Thumbnail = append([]byte(nil), f.Thumbnail...)
+	}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeFileInitReq(b []byte) (FileInit, error) {
+	var m pb.FileInitReq
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return FileInit{}, err
+	}
+	if len(m.GetFileHash()) != 32 {
+		return FileInit{}, errors.New("invalid file_hash length")
+	}
+	f := FileInit{
+		TransferID: m.GetTransferId(),
+		TotalSize:  m.GetTotalSize(),
+		Mime:       m.GetMime(),
+		Filename:   m.GetFilename(),
+		FileHash:   append([]byte(nil), m.GetFileHash()...),
+		ChunkSize:  m.GetChunkSize(),
+	}
+	if m.Thumbnail != nil {
+		f.Thumbnail = append([]byte(nil), m.GetThumbnail()...)
+	}
+	return f, nil
+}
+
+// FileInitResp: {request_id:u64, transfer_id:u64, ok:bool, resume_offset?:u64, chunk_size:u32, window:u32}
+func EncodeFileInitResp(requestID, transferID uint64, ok bool, resumeOffset *uint64, chunkSize, window uint32) []byte {
+	m := &pb.FileInitResp{RequestId: requestID, TransferId: transferID, Ok: ok, ChunkSize: chunkSize, Window: window}
+	if resumeOffset != nil {
+		v := *resumeOffset
+		m.ResumeOffset = &v
+	}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeFileInitResp(b []byte) (requestID, transferID uint64, ok bool, resumeOffset *uint64, chunkSize, window uint32, err error) {
+	var m pb.FileInitResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, 0, false, nil, 0, 0, err
+	}
+	if m.ResumeOffset != nil {
+		v := m.GetResumeOffset()
+		resumeOffset = &v
+	}
+	return m.GetRequestId(), m.GetTransferId(), m.GetOk(), resumeOffset, m.GetChunkSize(), m.GetWindow(), nil
+}
+
+// FileChunk: {transfer_id:u64, offset:u64, chunk:bytes, crc32?:u32}
+func EncodeFileChunk(transferID, offset uint64, chunk []byte, crc32 *uint32) []byte {
+	m := &pb.FileChunk{TransferId: transferID, Offset: offset, Chunk: chunk}
+	if crc32 != nil {
+		v := *crc32
+		m.Crc32 = &v
+	}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeFileChunk(b []byte) (transferID, offset uint64, chunk []byte, crc32 *uint32, err error) {
+	var m pb.FileChunk
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, 0, nil, nil, err
+	}
+	if m.Crc32 != nil {
+		v := m.GetCrc32()
+		crc32 = &v
+	}
+	return m.GetTransferId(), m.GetOffset(), m.GetChunk(), crc32, nil
+}
+
+// FileChunkAck: {transfer_id:u64, next_offset:u64}
+func EncodeFileChunkAck(transferID, nextOffset uint64) []byte {
+	m := &pb.FileChunkAck{TransferId: transferID, NextOffset: nextOffset}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeFileChunkAck(b []byte) (transferID, nextOffset uint64, err error) {
+	var m pb.FileChunkAck
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, 0, err
+	}
+	return m.GetTransferId(), m.GetNextOffset(), nil
+}
+
+// FileCompleteReq: {transfer_id:u64}
+func EncodeFileCompleteReq(transferID uint64) []byte {
+	m := &pb.FileCompleteReq{TransferId: transferID}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeFileCompleteReq(b []byte) (uint64, error) {
+	var m pb.FileCompleteReq
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return 0, err
+	}
+	return m.GetTransferId(), nil
+}
+
+// FileCompleteResp: {request_id:u64, transfer_id:u64, ok:bool, file_id:u64}
+func EncodeFileCompleteResp(requestID, transferID uint64, ok bool, fileID uint64) []byte {
+	m := &pb.FileCompleteResp{RequestId: requestID, TransferId: transferID, Ok: ok, FileId: fileID}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeFileCompleteResp(b []byte) (requestID, transferID uint64, ok bool, fileID uint64, err error) {
+	var m pb.FileCompleteResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, 0, false, 0, err
+	}
+	return m.GetRequestId(), m.GetTransferId(), m.GetOk(), m.GetFileId(), nil
+}
+
+// FileCancel: {transfer_id:u64, reason:str}
+func EncodeFileCancel(transferID uint64, reason string) []byte {
+	m := &pb.FileCancel{TransferId: transferID, Reason: reason}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeFileCancel(b []byte) (transferID uint64, reason string, err error) {
+	var m pb.FileCancel
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, "", err
+	}
+	return m.GetTransferId(), m.GetReason(), nil
+}
@@ -0,0 +1,185 @@
+package binproto
+
+import (
+	"sort"
+	"strings"
+
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== Connection Handshake (HELLO) ==========
+const (
+	TypeHelloReq  uint16 = 410
+	TypeHelloResp uint16 = 411
+)
+
+// 可选特性名（HELLO features 与 ParentAuthReq.caps 共用）
+const (
+	FeatureRelay       = "relay"       // 对端为中继，可代理下级设备
+	FeatureCompression = "compression" // 负载压缩（Header.Flags 的 Compressed 位）
+	FeatureAck         = "ack"         // 端到端确认（Header.Flags 的 AckRequested 位）
+	FeaturePubSub      = "pubsub"      // 服务端主动推送（变量变更、孪生差量、E2E 公钥变更等通知）
+	FeatureFile        = "file"        // 文件传输（FILE_*）
+	FeatureE2E         = "e2e"         // 端到端加密与公钥目录
+	FeatureResume      = "resume"      // 断线后会话恢复（RESUME_*）
+	FeatureDeadline    = "deadline"    // 请求截止时间（Header.Flags 的 Deadline 位）与 CANCEL_REQ
+)
+
+// HelloLimits 一端声明的收发限制，0 表示未声明
+type HelloLimits struct {
+	MaxFrameBytes   uint32
+	MaxMessageBytes uint32
+	MaxPending      uint32
+}
+
+// Hello HELLO 请求/响应的能力描述
+type Hello struct {
+	Versions   []uint32 // 请求：支持的协议版本；响应：仅含选定版本
+	Features   []string
+	TypeIDs    []uint16
+	Limits     HelloLimits
+	ClientName string
+	// CompressionDicts 请求：持有的帧级压缩预置字典 ID；响应：选用的字典 ID
+	CompressionDicts []uint32
+}
+
+// HelloResult 服务端在 HELLO_RESP 中返回的协商结果与自身标识
+type HelloResult struct {
+	Version      uint32
+	Features     []string
+	TypeIDs      []uint16
+	Limits       HelloLimits
+	HardwareID   string
+	DeviceUID    uint64
+	HeartbeatSec uint32
+	// CompressionDicts 帧级压缩选用的预置字典 ID（至多一个）
+	CompressionDicts []uint32
+}
+
+// HelloReq: {versions:[u32], features:[string], type_ids:[u32], limits, client_name, compression_dicts:[u32]}
+func EncodeHelloReq(h Hello) []byte {
+	b, _ := proto.Marshal(&pb.HelloReq{Versions: h.Versions, Features: h.Features, TypeIds: typeIDsToPB(h.TypeIDs), Limits: helloLimitsToPB(h.Limits), ClientName: h.ClientName, CompressionDicts: h.CompressionDicts})
+	return b
+}
+
+func DecodeHelloReq(b []byte) (Hello, error) {
+	var m pb.HelloReq
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return Hello{}, err
+	}
+	return Hello{Versions: m.GetVersions(), Features: m.GetFeatures(), TypeIDs: typeIDsFromPB(m.GetTypeIds()), Limits: helloLimitsFromPB(m.GetLimits()), ClientName: m.GetClientName(), CompressionDicts: m.GetCompressionDicts()}, nil
+}
+
+// HelloResp: {request_id:u64, version:u32, features:[string], type_ids:[u32], limits, hardware_id, device_uid, heartbeat_sec, compression_dicts:[u32]}
+func EncodeHelloResp(requestID uint64, r HelloResult) []byte {
+	b, _ := proto.Marshal(&pb.HelloResp{
+		RequestId:        requestID,
+		Version:          r.Version,
+		Features:         r.Features,
+		TypeIds:          typeIDsToPB(r.TypeIDs),
+		Limits:           helloLimitsToPB(r.Limits),
+		HardwareId:       r.HardwareID,
+		DeviceUid:        r.DeviceUID,
+		HeartbeatSec:     r.HeartbeatSec,
+		CompressionDicts: r.CompressionDicts,
+	})
+	return b
+}
+
+func DecodeHelloResp(b []byte) (requestID uint64, r HelloResult, err error) {
+	var m pb.HelloResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, HelloResult{}, err
+	}
+	return m.GetRequestId(), HelloResult{
+		Version:          m.GetVersion(),
+		Features:         m.GetFeatures(),
+		TypeIDs:          typeIDsFromPB(m.GetTypeIds()),
+		Limits:           helloLimitsFromPB(m.GetLimits()),
+		HardwareID:       m.GetHardwareId(),
+		DeviceUID:        m.GetDeviceUid(),
+		HeartbeatSec:     m.GetHeartbeatSec(),
+		CompressionDicts: m.GetCompressionDicts(),
+	}, nil
+}
+
+// NegotiateVersion 返回双方共同支持的最高版本，无交集时返回 0
+func NegotiateVersion(local, remote []uint32) uint32 {
+	var best uint32
+	for _, a := range local {
+		for _, b := range remote {
+			if a == b && a > best {
+				best = a
+			}
+		}
+	}
+	return best
+}
+
+// IntersectFeatures 返回两端特性的交集（按名称排序、去重，忽略大小写与空白）
+func IntersectFeatures(local, remote []string) []string {
+	have := make(map[string]struct{}, len(local))
+	for _, f := range local {
+		have[strings.ToLower(strings.TrimSpace(f))] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(remote))
+	out := make([]string, 0, len(remote))
+	for _, f := range remote {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if _, ok := have[f]; !ok || f == "" {
+			continue
+		}
+		if _, dup := seen[f]; dup {
+			continue
+		}
+		seen[f] = struct{}{}
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ParseFeatures 解析逗号分隔的特性列表（如 ParentAuthReq.caps 或 Device.Capabilities）
+func ParseFeatures(s string) []string {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func helloLimitsToPB(l HelloLimits) *pb.HelloLimits {
+	if l == (HelloLimits{}) {
+		return nil
+	}
+	return &pb.HelloLimits{MaxFrameBytes: l.MaxFrameBytes, MaxMessageBytes: l.MaxMessageBytes, MaxPending: l.MaxPending}
+}
+
+func helloLimitsFromPB(m *pb.HelloLimits) HelloLimits {
+	return HelloLimits{MaxFrameBytes: m.GetMaxFrameBytes(), MaxMessageBytes: m.GetMaxMessageBytes(), MaxPending: m.GetMaxPending()}
+}
+
+func typeIDsToPB(ids []uint16) []uint32 {
+	if len(ids) == 0 {
+		return nil
+	}
+	out := make([]uint32, len(ids))
+	for i, id := range ids {
+		out[i] = uint32(id)
+	}
+	return out
+}
+
+func typeIDsFromPB(ids []uint32) []uint16 {
+	out := make([]uint16, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0xffff {
+			out = append(out, uint16(id))
+		}
+	}
+	return out
+}
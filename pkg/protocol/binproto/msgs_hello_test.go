@@ -0,0 +1,36 @@
+package binproto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHelloRoundTrip(t *testing.T) {
+	req := Hello{Versions: []uint32{1, 2}, Features: []string{FeatureFile, FeatureAck}, TypeIDs: []uint16{TypeOKResp, TypeFileChunk}, Limits: HelloLimits{MaxFrameBytes: 65536}, ClientName: "dev/1.0"}
+	got, err := DecodeHelloReq(EncodeHelloReq(req))
+	if err != nil || !reflect.DeepEqual(got, req) {
+		t.Fatalf("req: %+v %v", got, err)
+	}
+
+	res := HelloResult{Version: 2, Features: []string{FeatureFile}, TypeIDs: []uint16{TypeFileChunk}, Limits: HelloLimits{MaxFrameBytes: 1 << 22, MaxMessageBytes: 1 << 26, MaxPending: 16}, HardwareID: "hub", DeviceUID: 1, HeartbeatSec: 30}
+	id, r, err := DecodeHelloResp(EncodeHelloResp(9, res))
+	if err != nil || id != 9 || !reflect.DeepEqual(r, res) {
+		t.Fatalf("resp: %d %+v %v", id, r, err)
+	}
+}
+
+func TestHelloNegotiation(t *testing.T) {
+	if v := NegotiateVersion([]uint32{1, 2}, []uint32{2, 1, 3}); v != 2 {
+		t.Fatalf("version %d", v)
+	}
+	if v := NegotiateVersion([]uint32{1}, []uint32{2}); v != 0 {
+		t.Fatalf("no common version: %d", v)
+	}
+	got := IntersectFeatures([]string{FeatureRelay, FeaturePubSub, FeatureFile}, []string{" File", "compression", "pubsub", "file"})
+	if !reflect.DeepEqual(got, []string{FeatureFile, FeaturePubSub}) {
+		t.Fatalf("features %v", got)
+	}
+	if got := ParseFeatures("relay, ,E2E"); !reflect.DeepEqual(got, []string{FeatureRelay, FeatureE2E}) {
+		t.Fatalf("parse %v", got)
+	}
+}
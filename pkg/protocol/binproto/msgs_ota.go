@@ -0,0 +1,310 @@
+package binproto
+
+import (
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== Firmware OTA ==========
+const (
+	TypeOTAArtifactCreateReq  uint16 = 320
+	TypeOTAArtifactCreateResp uint16 = 321
+	TypeOTAArtifactListReq    uint16 = 322
+	TypeOTAArtifactListResp   uint16 = 323
+	TypeOTACampaignCreateReq  uint16 = 324
+	TypeOTACampaignCreateResp uint16 = 325
+	TypeOTACampaignListReq    uint16 = 326
+	TypeOTACampaignListResp   uint16 = 327
+	TypeOTACampaignControlReq uint16 = 328
+	TypeOTACampaignStatusReq  uint16 = 329
+	TypeOTACampaignStatusResp uint16 = 330
+)
+
+type OTAArtifactItem struct {
+	ID            uint64
+	Version       string
+	HardwareModel string
+	FileID        uint64
+	Size          uint64
+	SHA256        string
+	CreatedAt     int64
+}
+
+func toPBOTAArtifact(a OTAArtifactItem) *pb.OtaArtifactItem {
+	return &pb.OtaArtifactItem{Id: a.ID, Version: a.Version, HardwareModel: a.HardwareModel, FileId: a.FileID, Size: a.Size, Sha256: a.SHA256, CreatedAt: a.CreatedAt}
+}
+
+func fromPBOTAArtifact(m *pb.OtaArtifactItem) OTAArtifactItem {
+	return OTAArtifactItem{ID: m.GetId(), Version: m.GetVersion(), HardwareModel: m.GetHardwareModel(), FileID: m.GetFileId(), Size: m.GetSize(), SHA256: m.GetSha256(), CreatedAt: m.GetCreatedAt()}
+}
+
+// OTACampaignSpec 创建活动的参数
+type OTACampaignSpec struct {
+	Name          string
+	ArtifactID    uint64
+	OwnerUserID   *uint64
+	ParentID      *uint64
+	Tag           *string
+	Stages        []uint32
+	MaxFailurePct uint32
+}
+
+type OTACampaignItem struct {
+	OTACampaignSpec
+	ID           uint64
+	Status       string
+	CurrentStage uint32
+	Total        uint32
+	Succeeded    uint32
+	Failed       uint32
+	CreatedAt    int64
+}
+
+func toPBOTACampaign(c OTACampaignItem) *pb.OtaCampaignItem {
+	return &pb.OtaCampaignItem{
+		Id:            c.ID,
+		Name:          c.Name,
+		ArtifactId:    c.ArtifactID,
+		Status:        c.Status,
+		OwnerUserId:   c.OwnerUserID,
+		ParentId:      c.ParentID,
+		Tag:           c.Tag,
+		Stages:        append([]uint32(nil), c.Stages...),
+		CurrentStage:  c.CurrentStage,
+		MaxFailurePct: c.MaxFailurePct,
+		Total:         c.Total,
+		Succeeded:     c.Succeeded,
+		Failed:        c.Failed,
+		CreatedAt:     c.CreatedAt,
+	}
+}
+
+func fromPBOTACampaign(m *pb.OtaCampaignItem) OTACampaignItem {
+	return OTACampaignItem{
+		OTACampaignSpec: OTACampaignSpec{
+			Name:          m.GetName(),
+			ArtifactID:    m.GetArtifactId(),
+			OwnerUserID:   m.OwnerUserId,
+			ParentID:      m.ParentId,
+			Tag:           m.Tag,
+			Stages:        append([]uint32(nil), m.GetStages()...),
+			MaxFailurePct: m.GetMaxFailurePct(),
+		},
+		ID:           m.GetId(),
+		Status:       m.GetStatus(),
+		CurrentStage: m.GetCurrentStage(),
+		Total:        m.GetTotal(),
+		Succeeded:    m.GetSucceeded(),
+		Failed:       m.GetFailed(),
+		CreatedAt:    m.GetCreatedAt(),
+	}
+}
+
+type OTADeviceStateItem struct {
+	DeviceUID uint64
+	Stage     uint32
+	State     string
+	Progress  uint32
+	Error     string
+	UpdatedAt int64
+}
+
+// OTAArtifactCreateReq: {user_key:str, version:str, hardware_model:str, file_id:u64, sha256:str(hex)}
+func EncodeOTAArtifactCreateReq(userKey, version, hardwareModel string, fileID uint64, sha256Hex string) []byte {
+	m := &pb.OtaArtifactCreateReq{UserKey: userKey, Version: version, HardwareModel: hardwareModel, FileId: fileID, Sha256: sha256Hex}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeOTAArtifactCreateReq(b []byte) (userKey, version, hardwareModel string, fileID uint64, sha256Hex string, err error) {
+	var m pb.OtaArtifactCreateReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", "", "", 0, "", err
+	}
+	return m.GetUserKey(), m.GetVersion(), m.GetHardwareModel(), m.GetFileId(), m.GetSha256(), nil
+}
+
+// OTAArtifactCreateResp: {request_id:u64, item:OtaArtifactItem}
+func EncodeOTAArtifactCreateResp(requestID uint64, item OTAArtifactItem) []byte {
+	m := &pb.OtaArtifactCreateResp{RequestId: requestID, Item: toPBOTAArtifact(item)}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeOTAArtifactCreateResp(b []byte) (requestID uint64, item OTAArtifactItem, err error) {
+	var m pb.OtaArtifactCreateResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, OTAArtifactItem{}, err
+	}
+	return m.GetRequestId(), fromPBOTAArtifact(m.GetItem()), nil
+}
+
+// OTAArtifactListReq: {user_key:str}
+func EncodeOTAArtifactListReq(userKey string) []byte {
+	b, _ := proto.Marshal(&pb.OtaArtifactListReq{UserKey: userKey})
+	return b
+}
+
+func DecodeOTAArtifactListReq(b []byte) (string, error) {
+	var m pb.OtaArtifactListReq
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return "", err
+	}
+	return m.GetUserKey(), nil
+}
+
+// OTAArtifactListResp: {request_id:u64, items:[OtaArtifactItem]}
+func EncodeOTAArtifactListResp(requestID uint64, items []OTAArtifactItem) []byte {
+	arr := make([]*pb.OtaArtifactItem, 0, len(items))
+	for _, it := range items {
+		arr = append(arr, toPBOTAArtifact(it))
+	}
+	b, _ := proto.Marshal(&pb.OtaArtifactListResp{RequestId: requestID, Items: arr})
+	return b
+}
+
+func DecodeOTAArtifactListResp(b []byte) (requestID uint64, items []OTAArtifactItem, err error) {
+	var m pb.OtaArtifactListResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, nil, err
+	}
+	items = make([]OTAArtifactItem, 0, len(m.GetItems()))
+	for _, it := range m.GetItems() {
+		items = append(items, fromPBOTAArtifact(it))
+	}
+	return m.GetRequestId(), items, nil
+}
+
+// OTACampaignCreateReq: {user_key:str, name:str, artifact_id:u64, owner_user_id?:u64, parent_id?:u64, tag?:str, stages:[u32], max_failure_pct:u32}
+func EncodeOTACampaignCreateReq(userKey string, spec OTACampaignSpec) []byte {
+	m := &pb.OtaCampaignCreateReq{
+		UserKey:       userKey,
+		Name:          spec.Name,
+		ArtifactId:    spec.ArtifactID,
+		OwnerUserId:   spec.OwnerUserID,
+		ParentId:      spec.ParentID,
+		Tag:           spec.Tag,
+		Stages:        append([]uint32(nil), spec.Stages...),
+		MaxFailurePct: spec.MaxFailurePct,
+	}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeOTACampaignCreateReq(b []byte) (userKey string, spec OTACampaignSpec, err error) {
+	var m pb.OtaCampaignCreateReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", OTACampaignSpec{}, err
+	}
+	spec = OTACampaignSpec{
+		Name:          m.GetName(),
+		ArtifactID:    m.GetArtifactId(),
+		OwnerUserID:   m.OwnerUserId,
+		ParentID:      m.ParentId,
+		Tag:           m.Tag,
+		Stages:        append([]uint32(nil), m.GetStages()...),
+		MaxFailurePct: m.GetMaxFailurePct(),
+	}
+	return m.GetUserKey(), spec, nil
+}
+
+// OTACampaignCreateResp: {request_id:u64, item:OtaCampaignItem}
+func EncodeOTACampaignCreateResp(requestID uint64, item OTACampaignItem) []byte {
+	b, _ := proto.Marshal(&pb.OtaCampaignCreateResp{RequestId: requestID, Item: toPBOTACampaign(item)})
+	return b
+}
+
+func DecodeOTACampaignCreateResp(b []byte) (requestID uint64, item OTACampaignItem, err error) {
+	var m pb.OtaCampaignCreateResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, OTACampaignItem{}, err
+	}
+	return m.GetRequestId(), fromPBOTACampaign(m.GetItem()), nil
+}
+
+// OTACampaignListReq: {user_key:str}
+func EncodeOTACampaignListReq(userKey string) []byte {
+	b, _ := proto.Marshal(&pb.OtaCampaignListReq{UserKey: userKey})
+	return b
+}
+
+func DecodeOTACampaignListReq(b []byte) (string, error) {
+	var m pb.OtaCampaignListReq
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return "", err
+	}
+	return m.GetUserKey(), nil
+}
+
+// OTACampaignListResp: {request_id:u64, items:[OtaCampaignItem]}
+func EncodeOTACampaignListResp(requestID uint64, items []OTACampaignItem) []byte {
+	arr := make([]*pb.OtaCampaignItem, 0, len(items))
+	for _, it := range items {
+		arr = append(arr, toPBOTACampaign(it))
+	}
+	b, _ := proto.Marshal(&pb.OtaCampaignListResp{RequestId: requestID, Items: arr})
+	return b
+}
+
+func DecodeOTACampaignListResp(b []byte) (requestID uint64, items []OTACampaignItem, err error) {
+	var m pb.OtaCampaignListResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, nil, err
+	}
+	items = make([]OTACampaignItem, 0, len(m.GetItems()))
+	for _, it := range m.GetItems() {
+		items = append(items, fromPBOTACampaign(it))
+	}
+	return m.GetRequestId(), items, nil
+}
+
+// OTACampaignControlReq: {user_key:str, campaign_id:u64, action:str}
+func EncodeOTACampaignControlReq(userKey string, campaignID uint64, action string) []byte {
+	b, _ := proto.Marshal(&pb.OtaCampaignControlReq{UserKey: userKey, CampaignId: campaignID, Action: action})
+	return b
+}
+
+func DecodeOTACampaignControlReq(b []byte) (userKey string, campaignID uint64, action string, err error) {
+	var m pb.OtaCampaignControlReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", 0, "", err
+	}
+	return m.GetUserKey(), m.GetCampaignId(), m.GetAction(), nil
+}
+
+// OTACampaignStatusReq: {user_key:str, campaign_id:u64}
+func EncodeOTACampaignStatusReq(userKey string, campaignID uint64) []byte {
+	b, _ := proto.Marshal(&pb.OtaCampaignStatusReq{UserKey: userKey, CampaignId: campaignID})
+	return b
+}
+
+func DecodeOTACampaignStatusReq(b []byte) (userKey string, campaignID uint64, err error) {
+	var m pb.OtaCampaignStatusReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", 0, err
+	}
+	return m.GetUserKey(), m.GetCampaignId(), nil
+}
+
+// OTACampaignStatusResp: {request_id:u64, campaign:OtaCampaignItem, devices:[OtaDeviceStateItem]}
+func EncodeOTACampaignStatusResp(requestID uint64, campaign OTACampaignItem, devices []OTADeviceStateItem) []byte {
+	arr := make([]*pb.OtaDeviceStateItem, 0, len(devices))
+	for _, d := range devices {
+		arr = append(arr, &pb.OtaDeviceStateItem{DeviceUid: d.DeviceUID, Stage: d.Stage, State: d.State, Progress: d.Progress, Error: d.Error, UpdatedAt: d.UpdatedAt})
+	}
+	b, _ := proto.Marshal(&pb.OtaCampaignStatusResp{RequestId: requestID, Campaign: toPBOTACampaign(campaign), Devices: arr})
+	return b
+}
+
+func DecodeOTACampaignStatusResp(b []byte) (requestID uint64, campaign OTACampaignItem, devices []OTADeviceStateItem, err error) {
+	var m pb.OtaCampaignStatusResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, OTACampaignItem{}, nil, err
+	}
+	devices = make([]OTADeviceStateItem, 0, len(m.GetDevices()))
+	for _, d := range m.GetDevices() {
+		devices = append(devices, OTADeviceStateItem{DeviceUID: d.GetDeviceUid(), Stage: d.GetStage(), State: d.GetState(), Progress: d.GetProgress(), Error: d.GetError(), UpdatedAt: d.GetUpdatedAt()})
+	}
+	return m.GetRequestId(), fromPBOTACampaign(m.GetCampaign()), devices, nil
+}
@@ -0,0 +1,95 @@
+package binproto
+
+import (
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== Heartbeat / Presence ==========
+const (
+	TypeHeartbeat         uint16 = 350
+	TypePresenceEvent     uint16 = 351
+	TypePresenceQueryReq  uint16 = 352
+	TypePresenceQueryResp uint16 = 353
+)
+
+// PresenceItem 单个设备的在线状态
+type PresenceItem struct {
+	DeviceUID   uint64
+	Online      bool
+	LastSeenSec int64
+	Via         uint64 // 经由的直连中继 UID；0 表示直连
+}
+
+func toPBPresenceItems(items []PresenceItem) []*pb.PresenceItem {
+	out := make([]*pb.PresenceItem, 0, len(items))
+	for _, it := range items {
+		out = append(out, &pb.PresenceItem{DeviceUid: it.DeviceUID, Online: it.Online, LastSeenSec: it.LastSeenSec, Via: it.Via})
+	}
+	return out
+}
+
+func fromPBPresenceItems(items []*pb.PresenceItem) []PresenceItem {
+	out := make([]PresenceItem, 0, len(items))
+	for _, it := range items {
+		out = append(out, PresenceItem{DeviceUID: it.GetDeviceUid(), Online: it.GetOnline(), LastSeenSec: it.GetLastSeenSec(), Via: it.GetVia()})
+	}
+	return out
+}
+
+// Heartbeat: {ts_ms:i64}
+func EncodeHeartbeat(tsMs int64) []byte {
+	b, _ := proto.Marshal(&pb.Heartbeat{TsMs: tsMs})
+	return b
+}
+
+func DecodeHeartbeat(b []byte) (tsMs int64, err error) {
+	var m pb.Heartbeat
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, err
+	}
+	return m.GetTsMs(), nil
+}
+
+// PresenceEvent: {items:[PresenceItem], snapshot:bool}
+func EncodePresenceEvent(items []PresenceItem, snapshot bool) []byte {
+	b, _ := proto.Marshal(&pb.PresenceEvent{Items: toPBPresenceItems(items), Snapshot: snapshot})
+	return b
+}
+
+func DecodePresenceEvent(b []byte) (items []PresenceItem, snapshot bool, err error) {
+	var m pb.PresenceEvent
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return nil, false, err
+	}
+	return fromPBPresenceItems(m.GetItems()), m.GetSnapshot(), nil
+}
+
+// PresenceQueryReq: {user_key:str, device_uids:[u64]}；device_uids 为空表示查询全部可见设备
+func EncodePresenceQueryReq(userKey string, deviceUIDs []uint64) []byte {
+	b, _ := proto.Marshal(&pb.PresenceQueryReq{UserKey: userKey, DeviceUids: deviceUIDs})
+	return b
+}
+
+func DecodePresenceQueryReq(b []byte) (userKey string, deviceUIDs []uint64, err error) {
+	var m pb.PresenceQueryReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", nil, err
+	}
+	return m.GetUserKey(), m.GetDeviceUids(), nil
+}
+
+// PresenceQueryResp: {request_id:u64, items:[PresenceItem]}
+func EncodePresenceQueryResp(requestID uint64, items []PresenceItem) []byte {
+	b, _ := proto.Marshal(&pb.PresenceQueryResp{RequestId: requestID, Items: toPBPresenceItems(items)})
+	return b
+}
+
+func DecodePresenceQueryResp(b []byte) (requestID uint64, items []PresenceItem, err error) {
+	var m pb.PresenceQueryResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, nil, err
+	}
+	return m.GetRequestId(), fromPBPresenceItems(m.GetItems()), nil
+}
@@ -0,0 +1,81 @@
+package binproto
+
+import (
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== Session Resumption (RESUME) ==========
+const (
+	TypeResumeTokenNotify uint16 = 412
+	TypeResumeReq         uint16 = 413
+	TypeResumeResp        uint16 = 414
+)
+
+// ResumeResult 服务端在 RESUME_RESP 中返回的恢复结果；Seq 为重放起点，随后依次重放 Seq+1 起的 Replayed 条消息
+type ResumeResult struct {
+	DeviceUID   uint64
+	Token       string
+	Seq         uint64
+	Replayed    uint32
+	GraceSec    uint32
+	FrameMACPub []byte
+}
+
+// ResumeTokenNotify: {token:str, seq:u64, grace_sec:u32}
+func EncodeResumeTokenNotify(token string, seq uint64, graceSec uint32) []byte {
+	b, _ := proto.Marshal(&pb.ResumeTokenNotify{Token: token, Seq: seq, GraceSec: graceSec})
+	return b
+}
+
+func DecodeResumeTokenNotify(b []byte) (token string, seq uint64, graceSec uint32, err error) {
+	var m pb.ResumeTokenNotify
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", 0, 0, err
+	}
+	return m.GetToken(), m.GetSeq(), m.GetGraceSec(), nil
+}
+
+// ResumeReq: {token:str, last_seq:u64, frame_mac_pub:bytes}
+func EncodeResumeReq(token string, lastSeq uint64, frameMACPub []byte) []byte {
+	b, _ := proto.Marshal(&pb.ResumeReq{Token: token, LastSeq: lastSeq, FrameMacPub: frameMACPub})
+	return b
+}
+
+func DecodeResumeReq(b []byte) (token string, lastSeq uint64, frameMACPub []byte, err error) {
+	var m pb.ResumeReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", 0, nil, err
+	}
+	return m.GetToken(), m.GetLastSeq(), m.GetFrameMacPub(), nil
+}
+
+// ResumeResp: {request_id:u64, device_uid:u64, token:str, seq:u64, replayed:u32, grace_sec:u32, frame_mac_pub:bytes}
+func EncodeResumeResp(requestID uint64, r ResumeResult) []byte {
+	b, _ := proto.Marshal(&pb.ResumeResp{
+		RequestId:   requestID,
+		DeviceUid:   r.DeviceUID,
+		Token:       r.Token,
+		Seq:         r.Seq,
+		Replayed:    r.Replayed,
+		GraceSec:    r.GraceSec,
+		FrameMacPub: r.FrameMACPub,
+	})
+	return b
+}
+
+func DecodeResumeResp(b []byte) (requestID uint64, r ResumeResult, err error) {
+	var m pb.ResumeResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, ResumeResult{}, err
+	}
+	return m.GetRequestId(), ResumeResult{
+		DeviceUID:   m.GetDeviceUid(),
+		Token:       m.GetToken(),
+		Seq:         m.GetSeq(),
+		Replayed:    m.GetReplayed(),
+		GraceSec:    m.GetGraceSec(),
+		FrameMACPub: m.GetFrameMacPub(),
+	}, nil
+}
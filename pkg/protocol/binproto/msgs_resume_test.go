@@ -0,0 +1,22 @@
+package binproto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResumeRoundTrip(t *testing.T) {
+	token, seq, grace, err := DecodeResumeTokenNotify(EncodeResumeTokenNotify("tok", 3, 60))
+	if err != nil || token != "tok" || seq != 3 || grace != 60 {
+		t.Fatalf("notify: %q %d %d %v", token, seq, grace, err)
+	}
+	token, last, pub, err := DecodeResumeReq(EncodeResumeReq("tok", 7, []byte{1, 2}))
+	if err != nil || token != "tok" || last != 7 || !reflect.DeepEqual(pub, []byte{1, 2}) {
+		t.Fatalf("req: %q %d %v %v", token, last, pub, err)
+	}
+	res := ResumeResult{DeviceUID: 9, Token: "next", Seq: 5, Replayed: 2, GraceSec: 60, FrameMACPub: []byte{3}}
+	id, r, err := DecodeResumeResp(EncodeResumeResp(4, res))
+	if err != nil || id != 4 || !reflect.DeepEqual(r, res) {
+		t.Fatalf("resp: %d %+v %v", id, r, err)
+	}
+}
@@ -0,0 +1,109 @@
+package binproto
+
+import (
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== Device Session History ==========
+const (
+	TypeDeviceSessionListReq  uint16 = 360
+	TypeDeviceSessionListResp uint16 = 361
+)
+
+// DeviceSessionItem 一次连接会话（时间为 epoch 秒，EndedAt=0 表示仍在连接）
+type DeviceSessionItem struct {
+	ID          uint64
+	DeviceUID   uint64
+	Hub         string
+	ParentPath  string
+	RemoteAddr  string
+	UserAgent   string
+	Protocol    string
+	StartedAt   int64
+	AuthedAt    int64
+	EndedAt     int64
+	DurationMs  int64
+	BytesIn     uint64
+	BytesOut    uint64
+	FramesIn    uint64
+	FramesOut   uint64
+	CloseReason string
+	RawBytesIn  uint64
+	RawBytesOut uint64
+}
+
+// DeviceSessionListReq: {user_key:str, device_uid:u64, page:i32, page_size:i32}
+func EncodeDeviceSessionListReq(userKey string, deviceUID uint64, page, pageSize int32) []byte {
+	b, _ := proto.Marshal(&pb.DeviceSessionListReq{UserKey: userKey, DeviceUid: deviceUID, Page: page, PageSize: pageSize})
+	return b
+}
+
+func DecodeDeviceSessionListReq(b []byte) (userKey string, deviceUID uint64, page, pageSize int32, err error) {
+	var m pb.DeviceSessionListReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", 0, 0, 0, err
+	}
+	return m.GetUserKey(), m.GetDeviceUid(), m.GetPage(), m.GetPageSize(), nil
+}
+
+// DeviceSessionListResp: {request_id:u64, total:i64, page:i32, page_size:i32, items:[DeviceSessionItem]}
+func EncodeDeviceSessionListResp(requestID uint64, total int64, page, pageSize int32, list []DeviceSessionItem) []byte {
+	items := make([]*pb.DeviceSessionItem, 0, len(list))
+	for _, it := range list {
+		items = append(items, &pb.DeviceSessionItem{
+			Id:          it.ID,
+			DeviceUid:   it.DeviceUID,
+			Hub:         it.Hub,
+			ParentPath:  it.ParentPath,
+			RemoteAddr:  it.RemoteAddr,
+			UserAgent:   it.UserAgent,
+			Protocol:    it.Protocol,
+			StartedAt:   it.StartedAt,
+			AuthedAt:    it.AuthedAt,
+			EndedAt:     it.EndedAt,
+			DurationMs:  it.DurationMs,
+			BytesIn:     it.BytesIn,
+			BytesOut:    it.BytesOut,
+			FramesIn:    it.FramesIn,
+			FramesOut:   it.FramesOut,
+			CloseReason: it.CloseReason,
+			RawBytesIn:  it.RawBytesIn,
+			RawBytesOut: it.RawBytesOut,
+		})
+	}
+	b, _ := proto.Marshal(&pb.DeviceSessionListResp{RequestId: requestID, Total: total, Page: page, PageSize: pageSize, Items: items})
+	return b
+}
+
+func DecodeDeviceSessionListResp(b []byte) (requestID uint64, total int64, page, pageSize int32, list []DeviceSessionItem, err error) {
+	var m pb.DeviceSessionListResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, 0, 0, 0, nil, err
+	}
+	list = make([]DeviceSessionItem, 0, len(m.GetItems()))
+	for _, it := range m.GetItems() {
+		list = append(list, DeviceSessionItem{
+			ID:          it.GetId(),
+			DeviceUID:   it.GetDeviceUid(),
+			Hub:         it.GetHub(),
+			ParentPath:  it.GetParentPath(),
+			RemoteAddr:  it.GetRemoteAddr(),
+			UserAgent:   it.GetUserAgent(),
+			Protocol:    it.GetProtocol(),
+			StartedAt:   it.GetStartedAt(),
+			AuthedAt:    it.GetAuthedAt(),
+			EndedAt:     it.GetEndedAt(),
+			DurationMs:  it.GetDurationMs(),
+			BytesIn:     it.GetBytesIn(),
+			BytesOut:    it.GetBytesOut(),
+			FramesIn:    it.GetFramesIn(),
+			FramesOut:   it.GetFramesOut(),
+			CloseReason: it.GetCloseReason(),
+			RawBytesIn:  it.GetRawBytesIn(),
+			RawBytesOut: it.GetRawBytesOut(),
+		})
+	}
+	return m.GetRequestId(), m.GetTotal(), m.GetPage(), m.GetPageSize(), list, nil
+}
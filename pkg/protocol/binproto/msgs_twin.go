@@ -0,0 +1,143 @@
+package binproto
+
+import (
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ========== Device Twin ==========
+const (
+	TypeTwinGetReq            uint16 = 340
+	TypeTwinGetResp           uint16 = 341
+	TypeTwinDesiredUpdateReq  uint16 = 342
+	TypeTwinReportedUpdateReq uint16 = 343
+	TypeTwinUpdateResp        uint16 = 344
+	TypeTwinDeltaReq          uint16 = 345
+	TypeTwinDelta             uint16 = 346
+)
+
+// TwinDoc 完整孪生文档（JSON 字节）
+type TwinDoc struct {
+	DeviceUID       uint64
+	Desired         []byte
+	DesiredVersion  uint64
+	Reported        []byte
+	ReportedVersion uint64
+	Delta           []byte
+}
+
+// TwinGetReq: {user_key:str, device_uid:u64}
+func EncodeTwinGetReq(userKey string, deviceUID uint64) []byte {
+	b, _ := proto.Marshal(&pb.TwinGetReq{UserKey: userKey, DeviceUid: deviceUID})
+	return b
+}
+
+func DecodeTwinGetReq(b []byte) (userKey string, deviceUID uint64, err error) {
+	var m pb.TwinGetReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", 0, err
+	}
+	return m.GetUserKey(), m.GetDeviceUid(), nil
+}
+
+// TwinGetResp: {request_id:u64, device_uid:u64, desired:json, desired_version:u64, reported:json, reported_version:u64, delta:json}
+func EncodeTwinGetResp(requestID uint64, d TwinDoc) []byte {
+	m := &pb.TwinGetResp{
+		RequestId:       requestID,
+		DeviceUid:       d.DeviceUID,
+		Desired:         d.Desired,
+		DesiredVersion:  d.DesiredVersion,
+		Reported:        d.Reported,
+		ReportedVersion: d.ReportedVersion,
+		Delta:           d.Delta,
+	}
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func DecodeTwinGetResp(b []byte) (requestID uint64, d TwinDoc, err error) {
+	var m pb.TwinGetResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, TwinDoc{}, err
+	}
+	d = TwinDoc{
+		DeviceUID:       m.GetDeviceUid(),
+		Desired:         m.GetDesired(),
+		DesiredVersion:  m.GetDesiredVersion(),
+		Reported:        m.GetReported(),
+		ReportedVersion: m.GetReportedVersion(),
+		Delta:           m.GetDelta(),
+	}
+	return m.GetRequestId(), d, nil
+}
+
+// TwinDesiredUpdateReq: {user_key:str, device_uid:u64, patch:json, expected_version?:u64}
+func EncodeTwinDesiredUpdateReq(userKey string, deviceUID uint64, patch []byte, expectedVersion *uint64) []byte {
+	b, _ := proto.Marshal(&pb.TwinDesiredUpdateReq{UserKey: userKey, DeviceUid: deviceUID, Patch: patch, ExpectedVersion: expectedVersion})
+	return b
+}
+
+func DecodeTwinDesiredUpdateReq(b []byte) (userKey string, deviceUID uint64, patch []byte, expectedVersion *uint64, err error) {
+	var m pb.TwinDesiredUpdateReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", 0, nil, nil, err
+	}
+	return m.GetUserKey(), m.GetDeviceUid(), m.GetPatch(), m.ExpectedVersion, nil
+}
+
+// TwinReportedUpdateReq: {patch:json, expected_version?:u64}（仅设备自身）
+func EncodeTwinReportedUpdateReq(patch []byte, expectedVersion *uint64) []byte {
+	b, _ := proto.Marshal(&pb.TwinReportedUpdateReq{Patch: patch, ExpectedVersion: expectedVersion})
+	return b
+}
+
+func DecodeTwinReportedUpdateReq(b []byte) (patch []byte, expectedVersion *uint64, err error) {
+	var m pb.TwinReportedUpdateReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return nil, nil, err
+	}
+	return m.GetPatch(), m.ExpectedVersion, nil
+}
+
+// TwinUpdateResp: {request_id:u64, device_uid:u64, version:u64}
+func EncodeTwinUpdateResp(requestID, deviceUID, version uint64) []byte {
+	b, _ := proto.Marshal(&pb.TwinUpdateResp{RequestId: requestID, DeviceUid: deviceUID, Version: version})
+	return b
+}
+
+func DecodeTwinUpdateResp(b []byte) (requestID, deviceUID, version uint64, err error) {
+	var m pb.TwinUpdateResp
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, 0, 0, err
+	}
+	return m.GetRequestId(), m.GetDeviceUid(), m.GetVersion(), nil
+}
+
+// TwinDeltaReq: {user_key:str, device_uid:u64}
+func EncodeTwinDeltaReq(userKey string, deviceUID uint64) []byte {
+	b, _ := proto.Marshal(&pb.TwinDeltaReq{UserKey: userKey, DeviceUid: deviceUID})
+	return b
+}
+
+func DecodeTwinDeltaReq(b []byte) (userKey string, deviceUID uint64, err error) {
+	var m pb.TwinDeltaReq
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return "", 0, err
+	}
+	return m.GetUserKey(), m.GetDeviceUid(), nil
+}
+
+// TwinDelta: {request_id:u64, device_uid:u64, delta:json, desired_version:u64, reported_version:u64}
+func EncodeTwinDelta(requestID, deviceUID uint64, delta []byte, desiredVersion, reportedVersion uint64) []byte {
+	b, _ := proto.Marshal(&pb.TwinDelta{RequestId: requestID, DeviceUid: deviceUID, Delta: delta, DesiredVersion: desiredVersion, ReportedVersion: reportedVersion})
+	return b
+}
+
+func DecodeTwinDelta(b []byte) (requestID, deviceUID uint64, delta []byte, desiredVersion, reportedVersion uint64, err error) {
+	var m pb.TwinDelta
+	if err = proto.Unmarshal(b, &m); err != nil {
+		return 0, 0, nil, 0, 0, err
+	}
+	return m.GetRequestId(), m.GetDeviceUid(), m.GetDelta(), m.GetDesiredVersion(), m.GetReportedVersion(), nil
+}
@@ -0,0 +1,139 @@
+package binproto
+
+import (
+	pb "myflowhub/pkg/protocol/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// MessageType TypeID 与其 Protobuf 负载的对应关系；New 为空表示负载为透传字节（如 MSG_SEND）
+type MessageType struct {
+	ID   uint16
+	Name string
+	New  func() proto.Message
+}
+
+// messageTypes TypeID → 负载消息注册表（名称与 DOCS.md 一致），供 JSON 转码等通用场景使用
+var messageTypes = []MessageType{
+	{TypeOKResp, "OK_RESP", func() proto.Message { return &pb.OKResp{} }},
+	{TypeErrResp, "ERR_RESP", func() proto.Message { return &pb.ErrResp{} }},
+	{TypeCancelReq, "CANCEL_REQ", func() proto.Message { return &pb.CancelReq{} }},
+	{TypeMsgSend, "MSG_SEND", nil},
+	{TypeQueryNodesReq, "QUERY_NODES_REQ", func() proto.Message { return &pb.QueryNodesReq{} }},
+	{TypeCreateDeviceReq, "CREATE_DEVICE_REQ", func() proto.Message { return &pb.CreateDeviceReq{} }},
+	{TypeUpdateDeviceReq, "UPDATE_DEVICE_REQ", func() proto.Message { return &pb.UpdateDeviceReq{} }},
+	{TypeDeleteDeviceReq, "DELETE_DEVICE_REQ", func() proto.Message { return &pb.DeleteDeviceReq{} }},
+	{TypeQueryNodesResp, "QUERY_NODES_RESP", func() proto.Message { return &pb.QueryNodesResp{} }},
+	{TypeManagerAuthReq, "MANAGER_AUTH_REQ", func() proto.Message { return &pb.ManagerAuthReq{} }},
+	{TypeManagerAuthResp, "MANAGER_AUTH_RESP", func() proto.Message { return &pb.ManagerAuthResp{} }},
+	{TypeUserLoginReq, "USER_LOGIN_REQ", func() proto.Message { return &pb.UserLoginReq{} }},
+	{TypeUserLoginResp, "USER_LOGIN_RESP", func() proto.Message { return &pb.UserLoginResp{} }},
+	{TypeUserMeReq, "USER_ME_REQ", func() proto.Message { return &pb.UserMeReq{} }},
+	{TypeUserMeResp, "USER_ME_RESP", func() proto.Message { return &pb.UserMeResp{} }},
+	{TypeUserLogoutReq, "USER_LOGOUT_REQ", func() proto.Message { return &pb.UserLogoutReq{} }},
+	{TypeUserLogoutResp, "USER_LOGOUT_RESP", func() proto.Message { return &pb.OKResp{} }},
+	{TypeParentAuthReq, "PARENT_AUTH_REQ", func() proto.Message { return &pb.ParentAuthReq{} }},
+	{TypeParentAuthResp, "PARENT_AUTH_RESP", func() proto.Message { return &pb.ParentAuthResp{} }},
+	{TypeSystemLogListReq, "SYSTEMLOG_LIST_REQ", func() proto.Message { return &pb.SystemLogListReq{} }},
+	{TypeSystemLogListResp, "SYSTEMLOG_LIST_RESP", func() proto.Message { return &pb.SystemLogListResp{} }},
+	{TypeVarListReq, "VAR_LIST_REQ", func() proto.Message { return &pb.VarListReq{} }},
+	{TypeVarListResp, "VAR_LIST_RESP", func() proto.Message { return &pb.VarListResp{} }},
+	{TypeVarUpdateReq, "VAR_UPDATE_REQ", func() proto.Message { return &pb.VarUpdateReq{} }},
+	{TypeVarDeleteReq, "VAR_DELETE_REQ", func() proto.Message { return &pb.VarDeleteReq{} }},
+	{TypeVarChangedNotify, "VAR_CHANGED_NOTIFY", func() proto.Message { return &pb.VarChangedNotify{} }},
+	{TypeKeyListReq, "KEY_LIST_REQ", func() proto.Message { return &pb.KeyListReq{} }},
+	{TypeKeyListResp, "KEY_LIST_RESP", func() proto.Message { return &pb.KeyListResp{} }},
+	{TypeKeyCreateReq, "KEY_CREATE_REQ", func() proto.Message { return &pb.KeyCreateReq{} }},
+	{TypeKeyCreateResp, "KEY_CREATE_RESP", func() proto.Message { return &pb.KeyCreateResp{} }},
+	{TypeKeyUpdateReq, "KEY_UPDATE_REQ", func() proto.Message { return &pb.KeyUpdateReq{} }},
+	{TypeKeyDeleteReq, "KEY_DELETE_REQ", func() proto.Message { return &pb.KeyDeleteReq{} }},
+	{TypeKeyDevicesReq, "KEY_DEVICES_REQ", func() proto.Message { return &pb.KeyDevicesReq{} }},
+	{TypeKeyDevicesResp, "KEY_DEVICES_RESP", func() proto.Message { return &pb.KeyDevicesResp{} }},
+	{TypeUserListReq, "USER_LIST_REQ", func() proto.Message { return &pb.UserListReq{} }},
+	{TypeUserListResp, "USER_LIST_RESP", func() proto.Message { return &pb.UserListResp{} }},
+	{TypeUserCreateReq, "USER_CREATE_REQ", func() proto.Message { return &pb.UserCreateReq{} }},
+	{TypeUserCreateResp, "USER_CREATE_RESP", func() proto.Message { return &pb.UserCreateResp{} }},
+	{TypeUserUpdateReq, "USER_UPDATE_REQ", func() proto.Message { return &pb.UserUpdateReq{} }},
+	{TypeUserDeleteReq, "USER_DELETE_REQ", func() proto.Message { return &pb.UserDeleteReq{} }},
+	{TypeUserPermListReq, "USER_PERM_LIST_REQ", func() proto.Message { return &pb.UserPermListReq{} }},
+	{TypeUserPermListResp, "USER_PERM_LIST_RESP", func() proto.Message { return &pb.UserPermListResp{} }},
+	{TypeUserPermAddReq, "USER_PERM_ADD_REQ", func() proto.Message { return &pb.UserPermAddReq{} }},
+	{TypeUserPermRemoveReq, "USER_PERM_REMOVE_REQ", func() proto.Message { return &pb.UserPermRemoveReq{} }},
+	{TypeUserSelfUpdateReq, "USER_SELF_UPDATE_REQ", func() proto.Message { return &pb.UserSelfUpdateReq{} }},
+	{TypeUserSelfPasswordReq, "USER_SELF_PASSWORD_REQ", func() proto.Message { return &pb.UserSelfPasswordReq{} }},
+	{TypeFileInitReq, "FILE_INIT_REQ", func() proto.Message { return &pb.FileInitReq{} }},
+	{TypeFileInitResp, "FILE_INIT_RESP", func() proto.Message { return &pb.FileInitResp{} }},
+	{TypeFileChunk, "FILE_CHUNK", func() proto.Message { return &pb.FileChunk{} }},
+	{TypeFileCompleteReq, "FILE_COMPLETE_REQ", func() proto.Message { return &pb.FileCompleteReq{} }},
+	{TypeFileCompleteResp, "FILE_COMPLETE_RESP", func() proto.Message { return &pb.FileCompleteResp{} }},
+	{TypeFileCancel, "FILE_CANCEL", func() proto.Message { return &pb.FileCancel{} }},
+	{TypeFileChunkAck, "FILE_CHUNK_ACK", func() proto.Message { return &pb.FileChunkAck{} }},
+	{TypeOTAArtifactCreateReq, "OTA_ARTIFACT_CREATE_REQ", func() proto.Message { return &pb.OtaArtifactCreateReq{} }},
+	{TypeOTAArtifactCreateResp, "OTA_ARTIFACT_CREATE_RESP", func() proto.Message { return &pb.OtaArtifactCreateResp{} }},
+	{TypeOTAArtifactListReq, "OTA_ARTIFACT_LIST_REQ", func() proto.Message { return &pb.OtaArtifactListReq{} }},
+	{TypeOTAArtifactListResp, "OTA_ARTIFACT_LIST_RESP", func() proto.Message { return &pb.OtaArtifactListResp{} }},
+	{TypeOTACampaignCreateReq, "OTA_CAMPAIGN_CREATE_REQ", func() proto.Message { return &pb.OtaCampaignCreateReq{} }},
+	{TypeOTACampaignCreateResp, "OTA_CAMPAIGN_CREATE_RESP", func() proto.Message { return &pb.OtaCampaignCreateResp{} }},
+	{TypeOTACampaignListReq, "OTA_CAMPAIGN_LIST_REQ", func() proto.Message { return &pb.OtaCampaignListReq{} }},
+	{TypeOTACampaignListResp, "OTA_CAMPAIGN_LIST_RESP", func() proto.Message { return &pb.OtaCampaignListResp{} }},
+	{TypeOTACampaignControlReq, "OTA_CAMPAIGN_CONTROL_REQ", func() proto.Message { return &pb.OtaCampaignControlReq{} }},
+	{TypeOTACampaignStatusReq, "OTA_CAMPAIGN_STATUS_REQ", func() proto.Message { return &pb.OtaCampaignStatusReq{} }},
+	{TypeOTACampaignStatusResp, "OTA_CAMPAIGN_STATUS_RESP", func() proto.Message { return &pb.OtaCampaignStatusResp{} }},
+	{TypeTwinGetReq, "TWIN_GET_REQ", func() proto.Message { return &pb.TwinGetReq{} }},
+	{TypeTwinGetResp, "TWIN_GET_RESP", func() proto.Message { return &pb.TwinGetResp{} }},
+	{TypeTwinDesiredUpdateReq, "TWIN_DESIRED_UPDATE_REQ", func() proto.Message { return &pb.TwinDesiredUpdateReq{} }},
+	{TypeTwinReportedUpdateReq, "TWIN_REPORTED_UPDATE_REQ", func() proto.Message { return &pb.TwinReportedUpdateReq{} }},
+	{TypeTwinUpdateResp, "TWIN_UPDATE_RESP", func() proto.Message { return &pb.TwinUpdateResp{} }},
+	{TypeTwinDeltaReq, "TWIN_DELTA_REQ", func() proto.Message { return &pb.TwinDeltaReq{} }},
+	{TypeTwinDelta, "TWIN_DELTA", func() proto.Message { return &pb.TwinDelta{} }},
+	{TypeHeartbeat, "HEARTBEAT", func() proto.Message { return &pb.Heartbeat{} }},
+	{TypePresenceEvent, "PRESENCE_EVENT", func() proto.Message { return &pb.PresenceEvent{} }},
+	{TypePresenceQueryReq, "PRESENCE_QUERY_REQ", func() proto.Message { return &pb.PresenceQueryReq{} }},
+	{TypePresenceQueryResp, "PRESENCE_QUERY_RESP", func() proto.Message { return &pb.PresenceQueryResp{} }},
+	{TypeDeviceSessionListReq, "DEVICE_SESSION_LIST_REQ", func() proto.Message { return &pb.DeviceSessionListReq{} }},
+	{TypeDeviceSessionListResp, "DEVICE_SESSION_LIST_RESP", func() proto.Message { return &pb.DeviceSessionListResp{} }},
+	{TypeCertCSRReq, "CERT_CSR_REQ", func() proto.Message { return &pb.CertCsrReq{} }},
+	{TypeCertCSRResp, "CERT_CSR_RESP", func() proto.Message { return &pb.CertCsrResp{} }},
+	{TypeCertCRLReq, "CERT_CRL_REQ", func() proto.Message { return &pb.CertCrlReq{} }},
+	{TypeCertCRLResp, "CERT_CRL_RESP", func() proto.Message { return &pb.CertCrlResp{} }},
+	{TypeDeviceChallengeReq, "DEVICE_CHALLENGE_REQ", func() proto.Message { return &pb.DeviceChallengeReq{} }},
+	{TypeDeviceChallengeResp, "DEVICE_CHALLENGE_RESP", func() proto.Message { return &pb.DeviceChallengeResp{} }},
+	{TypeDeviceAuthReq, "DEVICE_AUTH_REQ", func() proto.Message { return &pb.DeviceAuthReq{} }},
+	{TypeDeviceAuthResp, "DEVICE_AUTH_RESP", func() proto.Message { return &pb.DeviceAuthResp{} }},
+	{TypeDeviceKeySetReq, "DEVICE_KEY_SET_REQ", func() proto.Message { return &pb.DeviceKeySetReq{} }},
+	{TypeDeviceRotateSecretReq, "DEVICE_ROTATE_SECRET_REQ", func() proto.Message { return &pb.DeviceRotateSecretReq{} }},
+	{TypeDeviceRotateSecretResp, "DEVICE_ROTATE_SECRET_RESP", func() proto.Message { return &pb.DeviceRotateSecretResp{} }},
+	{TypeE2EKeyPublishReq, "E2E_KEY_PUBLISH_REQ", func() proto.Message { return &pb.E2EKeyPublishReq{} }},
+	{TypeE2EKeyGetReq, "E2E_KEY_GET_REQ", func() proto.Message { return &pb.E2EKeyGetReq{} }},
+	{TypeE2EKeyGetResp, "E2E_KEY_GET_RESP", func() proto.Message { return &pb.E2EKeyGetResp{} }},
+	{TypeE2EKeyChangedNotify, "E2E_KEY_CHANGED_NOTIFY", func() proto.Message { return &pb.E2EKeyChangedNotify{} }},
+	{TypeHelloReq, "HELLO_REQ", func() proto.Message { return &pb.HelloReq{} }},
+	{TypeHelloResp, "HELLO_RESP", func() proto.Message { return &pb.HelloResp{} }},
+	{TypeResumeTokenNotify, "RESUME_TOKEN_NOTIFY", func() proto.Message { return &pb.ResumeTokenNotify{} }},
+	{TypeResumeReq, "RESUME_REQ", func() proto.Message { return &pb.ResumeReq{} }},
+	{TypeResumeResp, "RESUME_RESP", func() proto.Message { return &pb.ResumeResp{} }},
+}
+
+var (
+	typesByID   = make(map[uint16]MessageType, len(messageTypes))
+	typesByName = make(map[string]MessageType, len(messageTypes))
+)
+
+func init() {
+	for _, t := range messageTypes {
+		typesByID[t.ID] = t
+		typesByName[t.Name] = t
+	}
+}
+
+// LookupType 按 TypeID 查找注册项
+func LookupType(id uint16) (MessageType, bool) {
+	t, ok := typesByID[id]
+	return t, ok
+}
+
+// LookupTypeName 按名称（如 VAR_UPDATE_REQ）查找注册项
+func LookupTypeName(name string) (MessageType, bool) {
+	t, ok := typesByName[name]
+	return t, ok
+}
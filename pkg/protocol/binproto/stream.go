@@ -0,0 +1,50 @@
+package binproto
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// 流式传输（TCP 等）的分帧：每帧前置 4 字节小端长度（不含自身），随后为 header(38B)+payload。
+// 长度为 0 的记录是保活帧，不携带业务数据。
+const StreamPrefixSize = 4
+
+var ErrStreamFrameTooLarge = errors.New("stream frame too large")
+
+// AppendStreamFrame 将长度前缀与帧追加到 dst
+func AppendStreamFrame(dst, frame []byte) []byte {
+	var p [StreamPrefixSize]byte
+	binary.LittleEndian.PutUint32(p[:], uint32(len(frame)))
+	dst = append(dst, p[:]...)
+	return append(dst, frame...)
+}
+
+// WriteStreamFrame 以一次 Write 写出带长度前缀的帧；frame 为空时写出保活帧
+func WriteStreamFrame(w io.Writer, frame []byte) error {
+	_, err := w.Write(AppendStreamFrame(make([]byte, 0, StreamPrefixSize+len(frame)), frame))
+	return err
+}
+
+// ReadStreamFrame 读取一帧；返回空切片表示保活帧。长度超过 max（>0 时）返回 ErrStreamFrameTooLarge
+func ReadStreamFrame(r io.Reader, max int) ([]byte, error) {
+	var p [StreamPrefixSize]byte
+	if _, err := io.ReadFull(r, p[:]); err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint32(p[:])
+	if max > 0 && uint64(n) > uint64(max) {
+		return nil, ErrStreamFrameTooLarge
+	}
+	if n == 0 {
+		return []byte{}, nil
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,44 @@
+package binproto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestStreamFrameRoundtrip(t *testing.T) {
+	h := HeaderV1{TypeID: TypeHeartbeat, MsgID: 7, Source: 1, Target: 0, Timestamp: 1}
+	f, err := EncodeFrame(h, EncodeHeartbeat(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteStreamFrame(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteStreamFrame(&buf, nil); err != nil { // 保活帧
+		t.Fatal(err)
+	}
+	got, err := ReadStreamFrame(&buf, 1<<20)
+	if err != nil || !bytes.Equal(got, f) {
+		t.Fatalf("frame mismatch: %v", err)
+	}
+	got, err = ReadStreamFrame(&buf, 1<<20)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("expected keepalive, got %d bytes err=%v", len(got), err)
+	}
+	if _, err := ReadStreamFrame(&buf, 1<<20); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestStreamFrameLimits(t *testing.T) {
+	b := AppendStreamFrame(nil, make([]byte, 100))
+	if _, err := ReadStreamFrame(bytes.NewReader(b), 50); !errors.Is(err, ErrStreamFrameTooLarge) {
+		t.Fatalf("expected too large, got %v", err)
+	}
+	if _, err := ReadStreamFrame(bytes.NewReader(b[:60]), 0); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+}
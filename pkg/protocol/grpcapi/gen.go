@@ -0,0 +1,5 @@
+//go:build tools
+
+package grpcapi
+
+//go:generate protoc -I . -I ../pb --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative myflowhub_service.proto
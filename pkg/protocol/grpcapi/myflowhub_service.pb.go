@@ -0,0 +1,300 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v3.20.3
+// source: myflowhub_service.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	pb "myflowhub/pkg/protocol/pb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// device_uids 为空时订阅全部有权限的设备
+type WatchVariablesReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	DeviceUids    []uint64               `protobuf:"varint,2,rep,packed,name=device_uids,json=deviceUids,proto3" json:"device_uids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchVariablesReq) Reset() {
+	*x = WatchVariablesReq{}
+	mi := &file_myflowhub_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchVariablesReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchVariablesReq) ProtoMessage() {}
+
+func (x *WatchVariablesReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchVariablesReq.ProtoReflect.Descriptor instead.
+func (*WatchVariablesReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WatchVariablesReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *WatchVariablesReq) GetDeviceUids() []uint64 {
+	if x != nil {
+		return x.DeviceUids
+	}
+	return nil
+}
+
+type WatchPresenceReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	DeviceUids    []uint64               `protobuf:"varint,2,rep,packed,name=device_uids,json=deviceUids,proto3" json:"device_uids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchPresenceReq) Reset() {
+	*x = WatchPresenceReq{}
+	mi := &file_myflowhub_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPresenceReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPresenceReq) ProtoMessage() {}
+
+func (x *WatchPresenceReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPresenceReq.ProtoReflect.Descriptor instead.
+func (*WatchPresenceReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WatchPresenceReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *WatchPresenceReq) GetDeviceUids() []uint64 {
+	if x != nil {
+		return x.DeviceUids
+	}
+	return nil
+}
+
+var File_myflowhub_service_proto protoreflect.FileDescriptor
+
+const file_myflowhub_service_proto_rawDesc = "" +
+	"\n" +
+	"\x17myflowhub_service.proto\x12\fmyflowhub.v1\x1a\x0fmyflowhub.proto\"O\n" +
+	"\x11WatchVariablesReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1f\n" +
+	"\vdevice_uids\x18\x02 \x03(\x04R\n" +
+	"deviceUids\"N\n" +
+	"\x10WatchPresenceReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1f\n" +
+	"\vdevice_uids\x18\x02 \x03(\x04R\n" +
+	"deviceUids2\xdd\f\n" +
+	"\tMyFlowHub\x12G\n" +
+	"\n" +
+	"QueryNodes\x12\x1b.myflowhub.v1.QueryNodesReq\x1a\x1c.myflowhub.v1.QueryNodesResp\x12C\n" +
+	"\fCreateDevice\x12\x1d.myflowhub.v1.CreateDeviceReq\x1a\x14.myflowhub.v1.OKResp\x12C\n" +
+	"\fUpdateDevice\x12\x1d.myflowhub.v1.UpdateDeviceReq\x1a\x14.myflowhub.v1.OKResp\x12C\n" +
+	"\fDeleteDevice\x12\x1d.myflowhub.v1.DeleteDeviceReq\x1a\x14.myflowhub.v1.OKResp\x12D\n" +
+	"\rListVariables\x12\x18.myflowhub.v1.VarListReq\x1a\x19.myflowhub.v1.VarListResp\x12C\n" +
+	"\x0fUpdateVariables\x12\x1a.myflowhub.v1.VarUpdateReq\x1a\x14.myflowhub.v1.OKResp\x12C\n" +
+	"\x0fDeleteVariables\x12\x1a.myflowhub.v1.VarDeleteReq\x1a\x14.myflowhub.v1.OKResp\x127\n" +
+	"\x02Me\x12\x17.myflowhub.v1.UserMeReq\x1a\x18.myflowhub.v1.UserMeResp\x12B\n" +
+	"\tListUsers\x12\x19.myflowhub.v1.UserListReq\x1a\x1a.myflowhub.v1.UserListResp\x12G\n" +
+	"\n" +
+	"CreateUser\x12\x1b.myflowhub.v1.UserCreateReq\x1a\x1c.myflowhub.v1.UserCreateResp\x12?\n" +
+	"\n" +
+	"UpdateUser\x12\x1b.myflowhub.v1.UserUpdateReq\x1a\x14.myflowhub.v1.OKResp\x12?\n" +
+	"\n" +
+	"DeleteUser\x12\x1b.myflowhub.v1.UserDeleteReq\x1a\x14.myflowhub.v1.OKResp\x12N\n" +
+	"\rListUserPerms\x12\x1d.myflowhub.v1.UserPermListReq\x1a\x1e.myflowhub.v1.UserPermListResp\x12A\n" +
+	"\vAddUserPerm\x12\x1c.myflowhub.v1.UserPermAddReq\x1a\x14.myflowhub.v1.OKResp\x12G\n" +
+	"\x0eRemoveUserPerm\x12\x1f.myflowhub.v1.UserPermRemoveReq\x1a\x14.myflowhub.v1.OKResp\x12?\n" +
+	"\bListKeys\x12\x18.myflowhub.v1.KeyListReq\x1a\x19.myflowhub.v1.KeyListResp\x12D\n" +
+	"\tCreateKey\x12\x1a.myflowhub.v1.KeyCreateReq\x1a\x1b.myflowhub.v1.KeyCreateResp\x12=\n" +
+	"\tUpdateKey\x12\x1a.myflowhub.v1.KeyUpdateReq\x1a\x14.myflowhub.v1.OKResp\x12=\n" +
+	"\tDeleteKey\x12\x1a.myflowhub.v1.KeyDeleteReq\x1a\x14.myflowhub.v1.OKResp\x12G\n" +
+	"\n" +
+	"KeyDevices\x12\x1b.myflowhub.v1.KeyDevicesReq\x1a\x1c.myflowhub.v1.KeyDevicesResp\x12Q\n" +
+	"\x0eListSystemLogs\x12\x1e.myflowhub.v1.SystemLogListReq\x1a\x1f.myflowhub.v1.SystemLogListResp\x12S\n" +
+	"\x0eWatchVariables\x12\x1f.myflowhub.v1.WatchVariablesReq\x1a\x1e.myflowhub.v1.VarChangedNotify0\x01\x12N\n" +
+	"\rWatchPresence\x12\x1e.myflowhub.v1.WatchPresenceReq\x1a\x1b.myflowhub.v1.PresenceEvent0\x01B(Z&myflowhub/pkg/protocol/grpcapi;grpcapib\x06proto3"
+
+var (
+	file_myflowhub_service_proto_rawDescOnce sync.Once
+	file_myflowhub_service_proto_rawDescData []byte
+)
+
+func file_myflowhub_service_proto_rawDescGZIP() []byte {
+	file_myflowhub_service_proto_rawDescOnce.Do(func() {
+		file_myflowhub_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_myflowhub_service_proto_rawDesc), len(file_myflowhub_service_proto_rawDesc)))
+	})
+	return file_myflowhub_service_proto_rawDescData
+}
+
+var file_myflowhub_service_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_myflowhub_service_proto_goTypes = []any{
+	(*WatchVariablesReq)(nil),    // 0: myflowhub.v1.WatchVariablesReq
+	(*WatchPresenceReq)(nil),     // 1: myflowhub.v1.WatchPresenceReq
+	(*pb.QueryNodesReq)(nil),     // 2: myflowhub.v1.QueryNodesReq
+	(*pb.CreateDeviceReq)(nil),   // 3: myflowhub.v1.CreateDeviceReq
+	(*pb.UpdateDeviceReq)(nil),   // 4: myflowhub.v1.UpdateDeviceReq
+	(*pb.DeleteDeviceReq)(nil),   // 5: myflowhub.v1.DeleteDeviceReq
+	(*pb.VarListReq)(nil),        // 6: myflowhub.v1.VarListReq
+	(*pb.VarUpdateReq)(nil),      // 7: myflowhub.v1.VarUpdateReq
+	(*pb.VarDeleteReq)(nil),      // 8: myflowhub.v1.VarDeleteReq
+	(*pb.UserMeReq)(nil),         // 9: myflowhub.v1.UserMeReq
+	(*pb.UserListReq)(nil),       // 10: myflowhub.v1.UserListReq
+	(*pb.UserCreateReq)(nil),     // 11: myflowhub.v1.UserCreateReq
+	(*pb.UserUpdateReq)(nil),     // 12: myflowhub.v1.UserUpdateReq
+	(*pb.UserDeleteReq)(nil),     // 13: myflowhub.v1.UserDeleteReq
+	(*pb.UserPermListReq)(nil),   // 14: myflowhub.v1.UserPermListReq
+	(*pb.UserPermAddReq)(nil),    // 15: myflowhub.v1.UserPermAddReq
+	(*pb.UserPermRemoveReq)(nil), // 16: myflowhub.v1.UserPermRemoveReq
+	(*pb.KeyListReq)(nil),        // 17: myflowhub.v1.KeyListReq
+	(*pb.KeyCreateReq)(nil),      // 18: myflowhub.v1.KeyCreateReq
+	(*pb.KeyUpdateReq)(nil),      // 19: myflowhub.v1.KeyUpdateReq
+	(*pb.KeyDeleteReq)(nil),      // 20: myflowhub.v1.KeyDeleteReq
+	(*pb.KeyDevicesReq)(nil),     // 21: myflowhub.v1.KeyDevicesReq
+	(*pb.SystemLogListReq)(nil),  // 22: myflowhub.v1.SystemLogListReq
+	(*pb.QueryNodesResp)(nil),    // 23: myflowhub.v1.QueryNodesResp
+	(*pb.OKResp)(nil),            // 24: myflowhub.v1.OKResp
+	(*pb.VarListResp)(nil),       // 25: myflowhub.v1.VarListResp
+	(*pb.UserMeResp)(nil),        // 26: myflowhub.v1.UserMeResp
+	(*pb.UserListResp)(nil),      // 27: myflowhub.v1.UserListResp
+	(*pb.UserCreateResp)(nil),    // 28: myflowhub.v1.UserCreateResp
+	(*pb.UserPermListResp)(nil),  // 29: myflowhub.v1.UserPermListResp
+	(*pb.KeyListResp)(nil),       // 30: myflowhub.v1.KeyListResp
+	(*pb.KeyCreateResp)(nil),     // 31: myflowhub.v1.KeyCreateResp
+	(*pb.KeyDevicesResp)(nil),    // 32: myflowhub.v1.KeyDevicesResp
+	(*pb.SystemLogListResp)(nil), // 33: myflowhub.v1.SystemLogListResp
+	(*pb.VarChangedNotify)(nil),  // 34: myflowhub.v1.VarChangedNotify
+	(*pb.PresenceEvent)(nil),     // 35: myflowhub.v1.PresenceEvent
+}
+var file_myflowhub_service_proto_depIdxs = []int32{
+	2,  // 0: myflowhub.v1.MyFlowHub.QueryNodes:input_type -> myflowhub.v1.QueryNodesReq
+	3,  // 1: myflowhub.v1.MyFlowHub.CreateDevice:input_type -> myflowhub.v1.CreateDeviceReq
+	4,  // 2: myflowhub.v1.MyFlowHub.UpdateDevice:input_type -> myflowhub.v1.UpdateDeviceReq
+	5,  // 3: myflowhub.v1.MyFlowHub.DeleteDevice:input_type -> myflowhub.v1.DeleteDeviceReq
+	6,  // 4: myflowhub.v1.MyFlowHub.ListVariables:input_type -> myflowhub.v1.VarListReq
+	7,  // 5: myflowhub.v1.MyFlowHub.UpdateVariables:input_type -> myflowhub.v1.VarUpdateReq
+	8,  // 6: myflowhub.v1.MyFlowHub.DeleteVariables:input_type -> myflowhub.v1.VarDeleteReq
+	9,  // 7: myflowhub.v1.MyFlowHub.Me:input_type -> myflowhub.v1.UserMeReq
+	10, // 8: myflowhub.v1.MyFlowHub.ListUsers:input_type -> myflowhub.v1.UserListReq
+	11, // 9: myflowhub.v1.MyFlowHub.CreateUser:input_type -> myflowhub.v1.UserCreateReq
+	12, // 10: myflowhub.v1.MyFlowHub.UpdateUser:input_type -> myflowhub.v1.UserUpdateReq
+	13, // 11: myflowhub.v1.MyFlowHub.DeleteUser:input_type -> myflowhub.v1.UserDeleteReq
+	14, // 12: myflowhub.v1.MyFlowHub.ListUserPerms:input_type -> myflowhub.v1.UserPermListReq
+	15, // 13: myflowhub.v1.MyFlowHub.AddUserPerm:input_type -> myflowhub.v1.UserPermAddReq
+	16, // 14: myflowhub.v1.MyFlowHub.RemoveUserPerm:input_type -> myflowhub.v1.UserPermRemoveReq
+	17, // 15: myflowhub.v1.MyFlowHub.ListKeys:input_type -> myflowhub.v1.KeyListReq
+	18, // 16: myflowhub.v1.MyFlowHub.CreateKey:input_type -> myflowhub.v1.KeyCreateReq
+	19, // 17: myflowhub.v1.MyFlowHub.UpdateKey:input_type -> myflowhub.v1.KeyUpdateReq
+	20, // 18: myflowhub.v1.MyFlowHub.DeleteKey:input_type -> myflowhub.v1.KeyDeleteReq
+	21, // 19: myflowhub.v1.MyFlowHub.KeyDevices:input_type -> myflowhub.v1.KeyDevicesReq
+	22, // 20: myflowhub.v1.MyFlowHub.ListSystemLogs:input_type -> myflowhub.v1.SystemLogListReq
+	0,  // 21: myflowhub.v1.MyFlowHub.WatchVariables:input_type -> myflowhub.v1.WatchVariablesReq
+	1,  // 22: myflowhub.v1.MyFlowHub.WatchPresence:input_type -> myflowhub.v1.WatchPresenceReq
+	23, // 23: myflowhub.v1.MyFlowHub.QueryNodes:output_type -> myflowhub.v1.QueryNodesResp
+	24, // 24: myflowhub.v1.MyFlowHub.CreateDevice:output_type -> myflowhub.v1.OKResp
+	24, // 25: myflowhub.v1.MyFlowHub.UpdateDevice:output_type -> myflowhub.v1.OKResp
+	24, // 26: myflowhub.v1.MyFlowHub.DeleteDevice:output_type -> myflowhub.v1.OKResp
+	25, // 27: myflowhub.v1.MyFlowHub.ListVariables:output_type -> myflowhub.v1.VarListResp
+	24, // 28: myflowhub.v1.MyFlowHub.UpdateVariables:output_type -> myflowhub.v1.OKResp
+	24, // 29: myflowhub.v1.MyFlowHub.DeleteVariables:output_type -> myflowhub.v1.OKResp
+	26, // 30: myflowhub.v1.MyFlowHub.Me:output_type -> myflowhub.v1.UserMeResp
+	27, // 31: myflowhub.v1.MyFlowHub.ListUsers:output_type -> myflowhub.v1.UserListResp
+	28, // 32: myflowhub.v1.MyFlowHub.CreateUser:output_type -> myflowhub.v1.UserCreateResp
+	24, // 33: myflowhub.v1.MyFlowHub.UpdateUser:output_type -> myflowhub.v1.OKResp
+	24, // 34: myflowhub.v1.MyFlowHub.DeleteUser:output_type -> myflowhub.v1.OKResp
+	29, // 35: myflowhub.v1.MyFlowHub.ListUserPerms:output_type -> myflowhub.v1.UserPermListResp
+	24, // 36: myflowhub.v1.MyFlowHub.AddUserPerm:output_type -> myflowhub.v1.OKResp
+	24, // 37: myflowhub.v1.MyFlowHub.RemoveUserPerm:output_type -> myflowhub.v1.OKResp
+	30, // 38: myflowhub.v1.MyFlowHub.ListKeys:output_type -> myflowhub.v1.KeyListResp
+	31, // 39: myflowhub.v1.MyFlowHub.CreateKey:output_type -> myflowhub.v1.KeyCreateResp
+	24, // 40: myflowhub.v1.MyFlowHub.UpdateKey:output_type -> myflowhub.v1.OKResp
+	24, // 41: myflowhub.v1.MyFlowHub.DeleteKey:output_type -> myflowhub.v1.OKResp
+	32, // 42: myflowhub.v1.MyFlowHub.KeyDevices:output_type -> myflowhub.v1.KeyDevicesResp
+	33, // 43: myflowhub.v1.MyFlowHub.ListSystemLogs:output_type -> myflowhub.v1.SystemLogListResp
+	34, // 44: myflowhub.v1.MyFlowHub.WatchVariables:output_type -> myflowhub.v1.VarChangedNotify
+	35, // 45: myflowhub.v1.MyFlowHub.WatchPresence:output_type -> myflowhub.v1.PresenceEvent
+	23, // [23:46] is the sub-list for method output_type
+	0,  // [0:23] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_myflowhub_service_proto_init() }
+func file_myflowhub_service_proto_init() {
+	if File_myflowhub_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_myflowhub_service_proto_rawDesc), len(file_myflowhub_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_myflowhub_service_proto_goTypes,
+		DependencyIndexes: file_myflowhub_service_proto_depIdxs,
+		MessageInfos:      file_myflowhub_service_proto_msgTypes,
+	}.Build()
+	File_myflowhub_service_proto = out.File
+	file_myflowhub_service_proto_goTypes = nil
+	file_myflowhub_service_proto_depIdxs = nil
+}
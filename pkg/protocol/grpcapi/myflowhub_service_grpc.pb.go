@@ -0,0 +1,999 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.20.3
+// source: myflowhub_service.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	pb "myflowhub/pkg/protocol/pb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MyFlowHub_QueryNodes_FullMethodName      = "/myflowhub.v1.MyFlowHub/QueryNodes"
+	MyFlowHub_CreateDevice_FullMethodName    = "/myflowhub.v1.MyFlowHub/CreateDevice"
+	MyFlowHub_UpdateDevice_FullMethodName    = "/myflowhub.v1.MyFlowHub/UpdateDevice"
+	MyFlowHub_DeleteDevice_FullMethodName    = "/myflowhub.v1.MyFlowHub/DeleteDevice"
+	MyFlowHub_ListVariables_FullMethodName   = "/myflowhub.v1.MyFlowHub/ListVariables"
+	MyFlowHub_UpdateVariables_FullMethodName = "/myflowhub.v1.MyFlowHub/UpdateVariables"
+	MyFlowHub_DeleteVariables_FullMethodName = "/myflowhub.v1.MyFlowHub/DeleteVariables"
+	MyFlowHub_Me_FullMethodName              = "/myflowhub.v1.MyFlowHub/Me"
+	MyFlowHub_ListUsers_FullMethodName       = "/myflowhub.v1.MyFlowHub/ListUsers"
+	MyFlowHub_CreateUser_FullMethodName      = "/myflowhub.v1.MyFlowHub/CreateUser"
+	MyFlowHub_UpdateUser_FullMethodName      = "/myflowhub.v1.MyFlowHub/UpdateUser"
+	MyFlowHub_DeleteUser_FullMethodName      = "/myflowhub.v1.MyFlowHub/DeleteUser"
+	MyFlowHub_ListUserPerms_FullMethodName   = "/myflowhub.v1.MyFlowHub/ListUserPerms"
+	MyFlowHub_AddUserPerm_FullMethodName     = "/myflowhub.v1.MyFlowHub/AddUserPerm"
+	MyFlowHub_RemoveUserPerm_FullMethodName  = "/myflowhub.v1.MyFlowHub/RemoveUserPerm"
+	MyFlowHub_ListKeys_FullMethodName        = "/myflowhub.v1.MyFlowHub/ListKeys"
+	MyFlowHub_CreateKey_FullMethodName       = "/myflowhub.v1.MyFlowHub/CreateKey"
+	MyFlowHub_UpdateKey_FullMethodName       = "/myflowhub.v1.MyFlowHub/UpdateKey"
+	MyFlowHub_DeleteKey_FullMethodName       = "/myflowhub.v1.MyFlowHub/DeleteKey"
+	MyFlowHub_KeyDevices_FullMethodName      = "/myflowhub.v1.MyFlowHub/KeyDevices"
+	MyFlowHub_ListSystemLogs_FullMethodName  = "/myflowhub.v1.MyFlowHub/ListSystemLogs"
+	MyFlowHub_WatchVariables_FullMethodName  = "/myflowhub.v1.MyFlowHub/WatchVariables"
+	MyFlowHub_WatchPresence_FullMethodName   = "/myflowhub.v1.MyFlowHub/WatchPresence"
+)
+
+// MyFlowHubClient is the client API for MyFlowHub service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// =============================================================
+// gRPC 接口（与 /ws 共用 Server.ListenAddr，HTTP/2）
+// 说明：复用 myflowhub.proto 中的请求/应答消息，语义与对应 TypeID 一致；
+//
+//	认证以 metadata `x-user-key`（或 `authorization: Bearer <userKey>`）携带 userKey，
+//	存在时覆盖请求体中的 user_key。调用方不具备设备身份，权限完全取决于 userKey。
+//	仅返回 OK_RESP 的接口以 OKResp 作为应答；ERR_RESP 映射为 gRPC 状态码。
+//
+// =============================================================
+type MyFlowHubClient interface {
+	// 设备
+	QueryNodes(ctx context.Context, in *pb.QueryNodesReq, opts ...grpc.CallOption) (*pb.QueryNodesResp, error)
+	CreateDevice(ctx context.Context, in *pb.CreateDeviceReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	UpdateDevice(ctx context.Context, in *pb.UpdateDeviceReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	DeleteDevice(ctx context.Context, in *pb.DeleteDeviceReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	// 变量
+	ListVariables(ctx context.Context, in *pb.VarListReq, opts ...grpc.CallOption) (*pb.VarListResp, error)
+	UpdateVariables(ctx context.Context, in *pb.VarUpdateReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	DeleteVariables(ctx context.Context, in *pb.VarDeleteReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	// 用户与权限节点
+	Me(ctx context.Context, in *pb.UserMeReq, opts ...grpc.CallOption) (*pb.UserMeResp, error)
+	ListUsers(ctx context.Context, in *pb.UserListReq, opts ...grpc.CallOption) (*pb.UserListResp, error)
+	CreateUser(ctx context.Context, in *pb.UserCreateReq, opts ...grpc.CallOption) (*pb.UserCreateResp, error)
+	UpdateUser(ctx context.Context, in *pb.UserUpdateReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	DeleteUser(ctx context.Context, in *pb.UserDeleteReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	ListUserPerms(ctx context.Context, in *pb.UserPermListReq, opts ...grpc.CallOption) (*pb.UserPermListResp, error)
+	AddUserPerm(ctx context.Context, in *pb.UserPermAddReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	RemoveUserPerm(ctx context.Context, in *pb.UserPermRemoveReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	// 密钥
+	ListKeys(ctx context.Context, in *pb.KeyListReq, opts ...grpc.CallOption) (*pb.KeyListResp, error)
+	CreateKey(ctx context.Context, in *pb.KeyCreateReq, opts ...grpc.CallOption) (*pb.KeyCreateResp, error)
+	UpdateKey(ctx context.Context, in *pb.KeyUpdateReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	DeleteKey(ctx context.Context, in *pb.KeyDeleteReq, opts ...grpc.CallOption) (*pb.OKResp, error)
+	KeyDevices(ctx context.Context, in *pb.KeyDevicesReq, opts ...grpc.CallOption) (*pb.KeyDevicesResp, error)
+	// 系统日志
+	ListSystemLogs(ctx context.Context, in *pb.SystemLogListReq, opts ...grpc.CallOption) (*pb.SystemLogListResp, error)
+	// 变量变更流：任何来源（WS/TCP/MQTT/CoAP/HTTP/适配器）写入变量后推送，仅含有权限设备的条目
+	WatchVariables(ctx context.Context, in *WatchVariablesReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.VarChangedNotify], error)
+	// 在线状态流：首条为可见设备的快照（snapshot=true），之后为上线/下线变化
+	WatchPresence(ctx context.Context, in *WatchPresenceReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.PresenceEvent], error)
+}
+
+type myFlowHubClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMyFlowHubClient(cc grpc.ClientConnInterface) MyFlowHubClient {
+	return &myFlowHubClient{cc}
+}
+
+func (c *myFlowHubClient) QueryNodes(ctx context.Context, in *pb.QueryNodesReq, opts ...grpc.CallOption) (*pb.QueryNodesResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.QueryNodesResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_QueryNodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) CreateDevice(ctx context.Context, in *pb.CreateDeviceReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_CreateDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) UpdateDevice(ctx context.Context, in *pb.UpdateDeviceReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_UpdateDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) DeleteDevice(ctx context.Context, in *pb.DeleteDeviceReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_DeleteDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) ListVariables(ctx context.Context, in *pb.VarListReq, opts ...grpc.CallOption) (*pb.VarListResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.VarListResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_ListVariables_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) UpdateVariables(ctx context.Context, in *pb.VarUpdateReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_UpdateVariables_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) DeleteVariables(ctx context.Context, in *pb.VarDeleteReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_DeleteVariables_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) Me(ctx context.Context, in *pb.UserMeReq, opts ...grpc.CallOption) (*pb.UserMeResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.UserMeResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_Me_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) ListUsers(ctx context.Context, in *pb.UserListReq, opts ...grpc.CallOption) (*pb.UserListResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.UserListResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_ListUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) CreateUser(ctx context.Context, in *pb.UserCreateReq, opts ...grpc.CallOption) (*pb.UserCreateResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.UserCreateResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_CreateUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) UpdateUser(ctx context.Context, in *pb.UserUpdateReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_UpdateUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) DeleteUser(ctx context.Context, in *pb.UserDeleteReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_DeleteUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) ListUserPerms(ctx context.Context, in *pb.UserPermListReq, opts ...grpc.CallOption) (*pb.UserPermListResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.UserPermListResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_ListUserPerms_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) AddUserPerm(ctx context.Context, in *pb.UserPermAddReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_AddUserPerm_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) RemoveUserPerm(ctx context.Context, in *pb.UserPermRemoveReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_RemoveUserPerm_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) ListKeys(ctx context.Context, in *pb.KeyListReq, opts ...grpc.CallOption) (*pb.KeyListResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.KeyListResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_ListKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) CreateKey(ctx context.Context, in *pb.KeyCreateReq, opts ...grpc.CallOption) (*pb.KeyCreateResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.KeyCreateResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_CreateKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) UpdateKey(ctx context.Context, in *pb.KeyUpdateReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_UpdateKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) DeleteKey(ctx context.Context, in *pb.KeyDeleteReq, opts ...grpc.CallOption) (*pb.OKResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.OKResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_DeleteKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) KeyDevices(ctx context.Context, in *pb.KeyDevicesReq, opts ...grpc.CallOption) (*pb.KeyDevicesResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.KeyDevicesResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_KeyDevices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) ListSystemLogs(ctx context.Context, in *pb.SystemLogListReq, opts ...grpc.CallOption) (*pb.SystemLogListResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(pb.SystemLogListResp)
+	err := c.cc.Invoke(ctx, MyFlowHub_ListSystemLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *myFlowHubClient) WatchVariables(ctx context.Context, in *WatchVariablesReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.VarChangedNotify], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MyFlowHub_ServiceDesc.Streams[0], MyFlowHub_WatchVariables_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchVariablesReq, pb.VarChangedNotify]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MyFlowHub_WatchVariablesClient = grpc.ServerStreamingClient[pb.VarChangedNotify]
+
+func (c *myFlowHubClient) WatchPresence(ctx context.Context, in *WatchPresenceReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.PresenceEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MyFlowHub_ServiceDesc.Streams[1], MyFlowHub_WatchPresence_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchPresenceReq, pb.PresenceEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MyFlowHub_WatchPresenceClient = grpc.ServerStreamingClient[pb.PresenceEvent]
+
+// MyFlowHubServer is the server API for MyFlowHub service.
+// All implementations must embed UnimplementedMyFlowHubServer
+// for forward compatibility.
+//
+// =============================================================
+// gRPC 接口（与 /ws 共用 Server.ListenAddr，HTTP/2）
+// 说明：复用 myflowhub.proto 中的请求/应答消息，语义与对应 TypeID 一致；
+//
+//	认证以 metadata `x-user-key`（或 `authorization: Bearer <userKey>`）携带 userKey，
+//	存在时覆盖请求体中的 user_key。调用方不具备设备身份，权限完全取决于 userKey。
+//	仅返回 OK_RESP 的接口以 OKResp 作为应答；ERR_RESP 映射为 gRPC 状态码。
+//
+// =============================================================
+type MyFlowHubServer interface {
+	// 设备
+	QueryNodes(context.Context, *pb.QueryNodesReq) (*pb.QueryNodesResp, error)
+	CreateDevice(context.Context, *pb.CreateDeviceReq) (*pb.OKResp, error)
+	UpdateDevice(context.Context, *pb.UpdateDeviceReq) (*pb.OKResp, error)
+	DeleteDevice(context.Context, *pb.DeleteDeviceReq) (*pb.OKResp, error)
+	// 变量
+	ListVariables(context.Context, *pb.VarListReq) (*pb.VarListResp, error)
+	UpdateVariables(context.Context, *pb.VarUpdateReq) (*pb.OKResp, error)
+	DeleteVariables(context.Context, *pb.VarDeleteReq) (*pb.OKResp, error)
+	// 用户与权限节点
+	Me(context.Context, *pb.UserMeReq) (*pb.UserMeResp, error)
+	ListUsers(context.Context, *pb.UserListReq) (*pb.UserListResp, error)
+	CreateUser(context.Context, *pb.UserCreateReq) (*pb.UserCreateResp, error)
+	UpdateUser(context.Context, *pb.UserUpdateReq) (*pb.OKResp, error)
+	DeleteUser(context.Context, *pb.UserDeleteReq) (*pb.OKResp, error)
+	ListUserPerms(context.Context, *pb.UserPermListReq) (*pb.UserPermListResp, error)
+	AddUserPerm(context.Context, *pb.UserPermAddReq) (*pb.OKResp, error)
+	RemoveUserPerm(context.Context, *pb.UserPermRemoveReq) (*pb.OKResp, error)
+	// 密钥
+	ListKeys(context.Context, *pb.KeyListReq) (*pb.KeyListResp, error)
+	CreateKey(context.Context, *pb.KeyCreateReq) (*pb.KeyCreateResp, error)
+	UpdateKey(context.Context, *pb.KeyUpdateReq) (*pb.OKResp, error)
+	DeleteKey(context.Context, *pb.KeyDeleteReq) (*pb.OKResp, error)
+	KeyDevices(context.Context, *pb.KeyDevicesReq) (*pb.KeyDevicesResp, error)
+	// 系统日志
+	ListSystemLogs(context.Context, *pb.SystemLogListReq) (*pb.SystemLogListResp, error)
+	// 变量变更流：任何来源（WS/TCP/MQTT/CoAP/HTTP/适配器）写入变量后推送，仅含有权限设备的条目
+	WatchVariables(*WatchVariablesReq, grpc.ServerStreamingServer[pb.VarChangedNotify]) error
+	// 在线状态流：首条为可见设备的快照（snapshot=true），之后为上线/下线变化
+	WatchPresence(*WatchPresenceReq, grpc.ServerStreamingServer[pb.PresenceEvent]) error
+	mustEmbedUnimplementedMyFlowHubServer()
+}
+
+// UnimplementedMyFlowHubServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMyFlowHubServer struct{}
+
+func (UnimplementedMyFlowHubServer) QueryNodes(context.Context, *pb.QueryNodesReq) (*pb.QueryNodesResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryNodes not implemented")
+}
+func (UnimplementedMyFlowHubServer) CreateDevice(context.Context, *pb.CreateDeviceReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDevice not implemented")
+}
+func (UnimplementedMyFlowHubServer) UpdateDevice(context.Context, *pb.UpdateDeviceReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDevice not implemented")
+}
+func (UnimplementedMyFlowHubServer) DeleteDevice(context.Context, *pb.DeleteDeviceReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDevice not implemented")
+}
+func (UnimplementedMyFlowHubServer) ListVariables(context.Context, *pb.VarListReq) (*pb.VarListResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVariables not implemented")
+}
+func (UnimplementedMyFlowHubServer) UpdateVariables(context.Context, *pb.VarUpdateReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateVariables not implemented")
+}
+func (UnimplementedMyFlowHubServer) DeleteVariables(context.Context, *pb.VarDeleteReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteVariables not implemented")
+}
+func (UnimplementedMyFlowHubServer) Me(context.Context, *pb.UserMeReq) (*pb.UserMeResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Me not implemented")
+}
+func (UnimplementedMyFlowHubServer) ListUsers(context.Context, *pb.UserListReq) (*pb.UserListResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedMyFlowHubServer) CreateUser(context.Context, *pb.UserCreateReq) (*pb.UserCreateResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
+}
+func (UnimplementedMyFlowHubServer) UpdateUser(context.Context, *pb.UserUpdateReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (UnimplementedMyFlowHubServer) DeleteUser(context.Context, *pb.UserDeleteReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedMyFlowHubServer) ListUserPerms(context.Context, *pb.UserPermListReq) (*pb.UserPermListResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUserPerms not implemented")
+}
+func (UnimplementedMyFlowHubServer) AddUserPerm(context.Context, *pb.UserPermAddReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddUserPerm not implemented")
+}
+func (UnimplementedMyFlowHubServer) RemoveUserPerm(context.Context, *pb.UserPermRemoveReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveUserPerm not implemented")
+}
+func (UnimplementedMyFlowHubServer) ListKeys(context.Context, *pb.KeyListReq) (*pb.KeyListResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListKeys not implemented")
+}
+func (UnimplementedMyFlowHubServer) CreateKey(context.Context, *pb.KeyCreateReq) (*pb.KeyCreateResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateKey not implemented")
+}
+func (UnimplementedMyFlowHubServer) UpdateKey(context.Context, *pb.KeyUpdateReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateKey not implemented")
+}
+func (UnimplementedMyFlowHubServer) DeleteKey(context.Context, *pb.KeyDeleteReq) (*pb.OKResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteKey not implemented")
+}
+func (UnimplementedMyFlowHubServer) KeyDevices(context.Context, *pb.KeyDevicesReq) (*pb.KeyDevicesResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KeyDevices not implemented")
+}
+func (UnimplementedMyFlowHubServer) ListSystemLogs(context.Context, *pb.SystemLogListReq) (*pb.SystemLogListResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSystemLogs not implemented")
+}
+func (UnimplementedMyFlowHubServer) WatchVariables(*WatchVariablesReq, grpc.ServerStreamingServer[pb.VarChangedNotify]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchVariables not implemented")
+}
+func (UnimplementedMyFlowHubServer) WatchPresence(*WatchPresenceReq, grpc.ServerStreamingServer[pb.PresenceEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPresence not implemented")
+}
+func (UnimplementedMyFlowHubServer) mustEmbedUnimplementedMyFlowHubServer() {}
+func (UnimplementedMyFlowHubServer) testEmbeddedByValue()                   {}
+
+// UnsafeMyFlowHubServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MyFlowHubServer will
+// result in compilation errors.
+type UnsafeMyFlowHubServer interface {
+	mustEmbedUnimplementedMyFlowHubServer()
+}
+
+func RegisterMyFlowHubServer(s grpc.ServiceRegistrar, srv MyFlowHubServer) {
+	// If the following call pancis, it indicates UnimplementedMyFlowHubServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MyFlowHub_ServiceDesc, srv)
+}
+
+func _MyFlowHub_QueryNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.QueryNodesReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).QueryNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_QueryNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).QueryNodes(ctx, req.(*pb.QueryNodesReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_CreateDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.CreateDeviceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).CreateDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_CreateDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).CreateDevice(ctx, req.(*pb.CreateDeviceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_UpdateDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.UpdateDeviceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).UpdateDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_UpdateDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).UpdateDevice(ctx, req.(*pb.UpdateDeviceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_DeleteDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.DeleteDeviceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).DeleteDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_DeleteDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).DeleteDevice(ctx, req.(*pb.DeleteDeviceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_ListVariables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.VarListReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).ListVariables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_ListVariables_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).ListVariables(ctx, req.(*pb.VarListReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_UpdateVariables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.VarUpdateReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).UpdateVariables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_UpdateVariables_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).UpdateVariables(ctx, req.(*pb.VarUpdateReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_DeleteVariables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.VarDeleteReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).DeleteVariables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_DeleteVariables_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).DeleteVariables(ctx, req.(*pb.VarDeleteReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_Me_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.UserMeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).Me(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_Me_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).Me(ctx, req.(*pb.UserMeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.UserListReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_ListUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).ListUsers(ctx, req.(*pb.UserListReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.UserCreateReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_CreateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).CreateUser(ctx, req.(*pb.UserCreateReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.UserUpdateReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_UpdateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).UpdateUser(ctx, req.(*pb.UserUpdateReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.UserDeleteReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_DeleteUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).DeleteUser(ctx, req.(*pb.UserDeleteReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_ListUserPerms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.UserPermListReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).ListUserPerms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_ListUserPerms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).ListUserPerms(ctx, req.(*pb.UserPermListReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_AddUserPerm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.UserPermAddReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).AddUserPerm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_AddUserPerm_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).AddUserPerm(ctx, req.(*pb.UserPermAddReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_RemoveUserPerm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.UserPermRemoveReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).RemoveUserPerm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_RemoveUserPerm_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).RemoveUserPerm(ctx, req.(*pb.UserPermRemoveReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_ListKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.KeyListReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).ListKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_ListKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).ListKeys(ctx, req.(*pb.KeyListReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_CreateKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.KeyCreateReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).CreateKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_CreateKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).CreateKey(ctx, req.(*pb.KeyCreateReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_UpdateKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.KeyUpdateReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).UpdateKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_UpdateKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).UpdateKey(ctx, req.(*pb.KeyUpdateReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_DeleteKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.KeyDeleteReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).DeleteKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_DeleteKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).DeleteKey(ctx, req.(*pb.KeyDeleteReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_KeyDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.KeyDevicesReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).KeyDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_KeyDevices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).KeyDevices(ctx, req.(*pb.KeyDevicesReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_ListSystemLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.SystemLogListReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MyFlowHubServer).ListSystemLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MyFlowHub_ListSystemLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MyFlowHubServer).ListSystemLogs(ctx, req.(*pb.SystemLogListReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MyFlowHub_WatchVariables_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchVariablesReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MyFlowHubServer).WatchVariables(m, &grpc.GenericServerStream[WatchVariablesReq, pb.VarChangedNotify]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MyFlowHub_WatchVariablesServer = grpc.ServerStreamingServer[pb.VarChangedNotify]
+
+func _MyFlowHub_WatchPresence_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPresenceReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MyFlowHubServer).WatchPresence(m, &grpc.GenericServerStream[WatchPresenceReq, pb.PresenceEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MyFlowHub_WatchPresenceServer = grpc.ServerStreamingServer[pb.PresenceEvent]
+
+// MyFlowHub_ServiceDesc is the grpc.ServiceDesc for MyFlowHub service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MyFlowHub_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "myflowhub.v1.MyFlowHub",
+	HandlerType: (*MyFlowHubServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "QueryNodes",
+			Handler:    _MyFlowHub_QueryNodes_Handler,
+		},
+		{
+			MethodName: "CreateDevice",
+			Handler:    _MyFlowHub_CreateDevice_Handler,
+		},
+		{
+			MethodName: "UpdateDevice",
+			Handler:    _MyFlowHub_UpdateDevice_Handler,
+		},
+		{
+			MethodName: "DeleteDevice",
+			Handler:    _MyFlowHub_DeleteDevice_Handler,
+		},
+		{
+			MethodName: "ListVariables",
+			Handler:    _MyFlowHub_ListVariables_Handler,
+		},
+		{
+			MethodName: "UpdateVariables",
+			Handler:    _MyFlowHub_UpdateVariables_Handler,
+		},
+		{
+			MethodName: "DeleteVariables",
+			Handler:    _MyFlowHub_DeleteVariables_Handler,
+		},
+		{
+			MethodName: "Me",
+			Handler:    _MyFlowHub_Me_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _MyFlowHub_ListUsers_Handler,
+		},
+		{
+			MethodName: "CreateUser",
+			Handler:    _MyFlowHub_CreateUser_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _MyFlowHub_UpdateUser_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _MyFlowHub_DeleteUser_Handler,
+		},
+		{
+			MethodName: "ListUserPerms",
+			Handler:    _MyFlowHub_ListUserPerms_Handler,
+		},
+		{
+			MethodName: "AddUserPerm",
+			Handler:    _MyFlowHub_AddUserPerm_Handler,
+		},
+		{
+			MethodName: "RemoveUserPerm",
+			Handler:    _MyFlowHub_RemoveUserPerm_Handler,
+		},
+		{
+			MethodName: "ListKeys",
+			Handler:    _MyFlowHub_ListKeys_Handler,
+		},
+		{
+			MethodName: "CreateKey",
+			Handler:    _MyFlowHub_CreateKey_Handler,
+		},
+		{
+			MethodName: "UpdateKey",
+			Handler:    _MyFlowHub_UpdateKey_Handler,
+		},
+		{
+			MethodName: "DeleteKey",
+			Handler:    _MyFlowHub_DeleteKey_Handler,
+		},
+		{
+			MethodName: "KeyDevices",
+			Handler:    _MyFlowHub_KeyDevices_Handler,
+		},
+		{
+			MethodName: "ListSystemLogs",
+			Handler:    _MyFlowHub_ListSystemLogs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchVariables",
+			Handler:       _MyFlowHub_WatchVariables_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchPresence",
+			Handler:       _MyFlowHub_WatchPresence_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "myflowhub_service.proto",
+}
@@ -23,8 +23,11 @@ const (
 
 // =============================================================
 // 基础应答（通用）
-// TypeID: 0 OK_RESP → OKResp, 1 ERR_RESP → ErrResp
+// TypeID: 0 OK_RESP → OKResp, 1 ERR_RESP → ErrResp, 2 CANCEL_REQ → CancelReq（返回 OKResp）
 // 说明：用于承载通用成功/失败与消息文本，message 采用 bytes 以兼容任意编码。
+//
+//	CANCEL_REQ 取消同一连接上尚未完成的请求（msg_id 为原请求的 MsgID），原请求以 ErrResp(499) 结束。
+//
 // =============================================================
 type OKResp struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -146,6 +149,50 @@ func (x *ErrResp) GetMessage() []byte {
 	return nil
 }
 
+type CancelReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MsgId         uint64                 `protobuf:"varint,1,opt,name=msg_id,json=msgId,proto3" json:"msg_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelReq) Reset() {
+	*x = CancelReq{}
+	mi := &file_myflowhub_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelReq) ProtoMessage() {}
+
+func (x *CancelReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelReq.ProtoReflect.Descriptor instead.
+func (*CancelReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CancelReq) GetMsgId() uint64 {
+	if x != nil {
+		return x.MsgId
+	}
+	return 0
+}
+
 // =============================================================
 // 管理员/登录（用户自助）
 // TypeID: 110/111（登录），112/113（UserMe），114/115（登出）
@@ -154,13 +201,14 @@ func (x *ErrResp) GetMessage() []byte {
 type ManagerAuthReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	FrameMacPub   []byte                 `protobuf:"bytes,2,opt,name=frame_mac_pub,json=frameMacPub,proto3" json:"frame_mac_pub,omitempty"` // 可选：帧认证临时 X25519 公钥（32B），为空表示不支持
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ManagerAuthReq) Reset() {
 	*x = ManagerAuthReq{}
-	mi := &file_myflowhub_proto_msgTypes[2]
+	mi := &file_myflowhub_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -172,7 +220,7 @@ func (x *ManagerAuthReq) String() string {
 func (*ManagerAuthReq) ProtoMessage() {}
 
 func (x *ManagerAuthReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[2]
+	mi := &file_myflowhub_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -185,7 +233,7 @@ func (x *ManagerAuthReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ManagerAuthReq.ProtoReflect.Descriptor instead.
 func (*ManagerAuthReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{2}
+	return file_myflowhub_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *ManagerAuthReq) GetToken() string {
@@ -195,18 +243,26 @@ func (x *ManagerAuthReq) GetToken() string {
 	return ""
 }
 
+func (x *ManagerAuthReq) GetFrameMacPub() []byte {
+	if x != nil {
+		return x.FrameMacPub
+	}
+	return nil
+}
+
 type ManagerAuthResp struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
 	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
-	Role          string                 `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"` // 空串表示无
+	Role          string                 `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`                                    // 空串表示无
+	FrameMacPub   []byte                 `protobuf:"bytes,4,opt,name=frame_mac_pub,json=frameMacPub,proto3" json:"frame_mac_pub,omitempty"` // Hub 临时 X25519 公钥；非空表示此后链路启用帧认证
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ManagerAuthResp) Reset() {
 	*x = ManagerAuthResp{}
-	mi := &file_myflowhub_proto_msgTypes[3]
+	mi := &file_myflowhub_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -218,7 +274,7 @@ func (x *ManagerAuthResp) String() string {
 func (*ManagerAuthResp) ProtoMessage() {}
 
 func (x *ManagerAuthResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[3]
+	mi := &file_myflowhub_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -231,7 +287,7 @@ func (x *ManagerAuthResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ManagerAuthResp.ProtoReflect.Descriptor instead.
 func (*ManagerAuthResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{3}
+	return file_myflowhub_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ManagerAuthResp) GetRequestId() uint64 {
@@ -255,6 +311,13 @@ func (x *ManagerAuthResp) GetRole() string {
 	return ""
 }
 
+func (x *ManagerAuthResp) GetFrameMacPub() []byte {
+	if x != nil {
+		return x.FrameMacPub
+	}
+	return nil
+}
+
 type UserLoginReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
@@ -265,7 +328,7 @@ type UserLoginReq struct {
 
 func (x *UserLoginReq) Reset() {
 	*x = UserLoginReq{}
-	mi := &file_myflowhub_proto_msgTypes[4]
+	mi := &file_myflowhub_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -277,7 +340,7 @@ func (x *UserLoginReq) String() string {
 func (*UserLoginReq) ProtoMessage() {}
 
 func (x *UserLoginReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[4]
+	mi := &file_myflowhub_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -290,7 +353,7 @@ func (x *UserLoginReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserLoginReq.ProtoReflect.Descriptor instead.
 func (*UserLoginReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{4}
+	return file_myflowhub_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *UserLoginReq) GetUsername() string {
@@ -322,7 +385,7 @@ type UserLoginResp struct {
 
 func (x *UserLoginResp) Reset() {
 	*x = UserLoginResp{}
-	mi := &file_myflowhub_proto_msgTypes[5]
+	mi := &file_myflowhub_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -334,7 +397,7 @@ func (x *UserLoginResp) String() string {
 func (*UserLoginResp) ProtoMessage() {}
 
 func (x *UserLoginResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[5]
+	mi := &file_myflowhub_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -347,7 +410,7 @@ func (x *UserLoginResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserLoginResp.ProtoReflect.Descriptor instead.
 func (*UserLoginResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{5}
+	return file_myflowhub_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *UserLoginResp) GetRequestId() uint64 {
@@ -408,7 +471,7 @@ type UserMeReq struct {
 
 func (x *UserMeReq) Reset() {
 	*x = UserMeReq{}
-	mi := &file_myflowhub_proto_msgTypes[6]
+	mi := &file_myflowhub_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -420,7 +483,7 @@ func (x *UserMeReq) String() string {
 func (*UserMeReq) ProtoMessage() {}
 
 func (x *UserMeReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[6]
+	mi := &file_myflowhub_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -433,7 +496,7 @@ func (x *UserMeReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserMeReq.ProtoReflect.Descriptor instead.
 func (*UserMeReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{6}
+	return file_myflowhub_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *UserMeReq) GetUserKey() string {
@@ -456,7 +519,7 @@ type UserMeResp struct {
 
 func (x *UserMeResp) Reset() {
 	*x = UserMeResp{}
-	mi := &file_myflowhub_proto_msgTypes[7]
+	mi := &file_myflowhub_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -468,7 +531,7 @@ func (x *UserMeResp) String() string {
 func (*UserMeResp) ProtoMessage() {}
 
 func (x *UserMeResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[7]
+	mi := &file_myflowhub_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -481,7 +544,7 @@ func (x *UserMeResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserMeResp.ProtoReflect.Descriptor instead.
 func (*UserMeResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{7}
+	return file_myflowhub_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *UserMeResp) GetRequestId() uint64 {
@@ -528,7 +591,7 @@ type UserLogoutReq struct {
 
 func (x *UserLogoutReq) Reset() {
 	*x = UserLogoutReq{}
-	mi := &file_myflowhub_proto_msgTypes[8]
+	mi := &file_myflowhub_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -540,7 +603,7 @@ func (x *UserLogoutReq) String() string {
 func (*UserLogoutReq) ProtoMessage() {}
 
 func (x *UserLogoutReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[8]
+	mi := &file_myflowhub_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -553,7 +616,7 @@ func (x *UserLogoutReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserLogoutReq.ProtoReflect.Descriptor instead.
 func (*UserLogoutReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{8}
+	return file_myflowhub_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *UserLogoutReq) GetUserKey() string {
@@ -581,7 +644,7 @@ type UserItem struct {
 
 func (x *UserItem) Reset() {
 	*x = UserItem{}
-	mi := &file_myflowhub_proto_msgTypes[9]
+	mi := &file_myflowhub_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -593,7 +656,7 @@ func (x *UserItem) String() string {
 func (*UserItem) ProtoMessage() {}
 
 func (x *UserItem) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[9]
+	mi := &file_myflowhub_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -606,7 +669,7 @@ func (x *UserItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserItem.ProtoReflect.Descriptor instead.
 func (*UserItem) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{9}
+	return file_myflowhub_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *UserItem) GetId() uint64 {
@@ -660,7 +723,7 @@ type UserListReq struct {
 
 func (x *UserListReq) Reset() {
 	*x = UserListReq{}
-	mi := &file_myflowhub_proto_msgTypes[10]
+	mi := &file_myflowhub_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -672,7 +735,7 @@ func (x *UserListReq) String() string {
 func (*UserListReq) ProtoMessage() {}
 
 func (x *UserListReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[10]
+	mi := &file_myflowhub_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -685,7 +748,7 @@ func (x *UserListReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserListReq.ProtoReflect.Descriptor instead.
 func (*UserListReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{10}
+	return file_myflowhub_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *UserListReq) GetUserKey() string {
@@ -705,7 +768,7 @@ type UserListResp struct {
 
 func (x *UserListResp) Reset() {
 	*x = UserListResp{}
-	mi := &file_myflowhub_proto_msgTypes[11]
+	mi := &file_myflowhub_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -717,7 +780,7 @@ func (x *UserListResp) String() string {
 func (*UserListResp) ProtoMessage() {}
 
 func (x *UserListResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[11]
+	mi := &file_myflowhub_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -730,7 +793,7 @@ func (x *UserListResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserListResp.ProtoReflect.Descriptor instead.
 func (*UserListResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{11}
+	return file_myflowhub_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *UserListResp) GetRequestId() uint64 {
@@ -759,7 +822,7 @@ type UserCreateReq struct {
 
 func (x *UserCreateReq) Reset() {
 	*x = UserCreateReq{}
-	mi := &file_myflowhub_proto_msgTypes[12]
+	mi := &file_myflowhub_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -771,7 +834,7 @@ func (x *UserCreateReq) String() string {
 func (*UserCreateReq) ProtoMessage() {}
 
 func (x *UserCreateReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[12]
+	mi := &file_myflowhub_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -784,7 +847,7 @@ func (x *UserCreateReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserCreateReq.ProtoReflect.Descriptor instead.
 func (*UserCreateReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{12}
+	return file_myflowhub_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *UserCreateReq) GetUserKey() string {
@@ -825,7 +888,7 @@ type UserCreateResp struct {
 
 func (x *UserCreateResp) Reset() {
 	*x = UserCreateResp{}
-	mi := &file_myflowhub_proto_msgTypes[13]
+	mi := &file_myflowhub_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -837,7 +900,7 @@ func (x *UserCreateResp) String() string {
 func (*UserCreateResp) ProtoMessage() {}
 
 func (x *UserCreateResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[13]
+	mi := &file_myflowhub_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -850,7 +913,7 @@ func (x *UserCreateResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserCreateResp.ProtoReflect.Descriptor instead.
 func (*UserCreateResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{13}
+	return file_myflowhub_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *UserCreateResp) GetRequestId() uint64 {
@@ -880,7 +943,7 @@ type UserUpdateReq struct {
 
 func (x *UserUpdateReq) Reset() {
 	*x = UserUpdateReq{}
-	mi := &file_myflowhub_proto_msgTypes[14]
+	mi := &file_myflowhub_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -892,7 +955,7 @@ func (x *UserUpdateReq) String() string {
 func (*UserUpdateReq) ProtoMessage() {}
 
 func (x *UserUpdateReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[14]
+	mi := &file_myflowhub_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -905,7 +968,7 @@ func (x *UserUpdateReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserUpdateReq.ProtoReflect.Descriptor instead.
 func (*UserUpdateReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{14}
+	return file_myflowhub_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *UserUpdateReq) GetUserKey() string {
@@ -953,7 +1016,7 @@ type UserDeleteReq struct {
 
 func (x *UserDeleteReq) Reset() {
 	*x = UserDeleteReq{}
-	mi := &file_myflowhub_proto_msgTypes[15]
+	mi := &file_myflowhub_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -965,7 +1028,7 @@ func (x *UserDeleteReq) String() string {
 func (*UserDeleteReq) ProtoMessage() {}
 
 func (x *UserDeleteReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[15]
+	mi := &file_myflowhub_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -978,7 +1041,7 @@ func (x *UserDeleteReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserDeleteReq.ProtoReflect.Descriptor instead.
 func (*UserDeleteReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{15}
+	return file_myflowhub_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *UserDeleteReq) GetUserKey() string {
@@ -1009,7 +1072,7 @@ type UserPermListReq struct {
 
 func (x *UserPermListReq) Reset() {
 	*x = UserPermListReq{}
-	mi := &file_myflowhub_proto_msgTypes[16]
+	mi := &file_myflowhub_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1021,7 +1084,7 @@ func (x *UserPermListReq) String() string {
 func (*UserPermListReq) ProtoMessage() {}
 
 func (x *UserPermListReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[16]
+	mi := &file_myflowhub_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1034,7 +1097,7 @@ func (x *UserPermListReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserPermListReq.ProtoReflect.Descriptor instead.
 func (*UserPermListReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{16}
+	return file_myflowhub_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *UserPermListReq) GetUserKey() string {
@@ -1061,7 +1124,7 @@ type UserPermListResp struct {
 
 func (x *UserPermListResp) Reset() {
 	*x = UserPermListResp{}
-	mi := &file_myflowhub_proto_msgTypes[17]
+	mi := &file_myflowhub_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1073,7 +1136,7 @@ func (x *UserPermListResp) String() string {
 func (*UserPermListResp) ProtoMessage() {}
 
 func (x *UserPermListResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[17]
+	mi := &file_myflowhub_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1086,7 +1149,7 @@ func (x *UserPermListResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserPermListResp.ProtoReflect.Descriptor instead.
 func (*UserPermListResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{17}
+	return file_myflowhub_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *UserPermListResp) GetRequestId() uint64 {
@@ -1114,7 +1177,7 @@ type UserPermAddReq struct {
 
 func (x *UserPermAddReq) Reset() {
 	*x = UserPermAddReq{}
-	mi := &file_myflowhub_proto_msgTypes[18]
+	mi := &file_myflowhub_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1126,7 +1189,7 @@ func (x *UserPermAddReq) String() string {
 func (*UserPermAddReq) ProtoMessage() {}
 
 func (x *UserPermAddReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[18]
+	mi := &file_myflowhub_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1139,7 +1202,7 @@ func (x *UserPermAddReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserPermAddReq.ProtoReflect.Descriptor instead.
 func (*UserPermAddReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{18}
+	return file_myflowhub_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *UserPermAddReq) GetUserKey() string {
@@ -1174,7 +1237,7 @@ type UserPermRemoveReq struct {
 
 func (x *UserPermRemoveReq) Reset() {
 	*x = UserPermRemoveReq{}
-	mi := &file_myflowhub_proto_msgTypes[19]
+	mi := &file_myflowhub_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1186,7 +1249,7 @@ func (x *UserPermRemoveReq) String() string {
 func (*UserPermRemoveReq) ProtoMessage() {}
 
 func (x *UserPermRemoveReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[19]
+	mi := &file_myflowhub_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1199,7 +1262,7 @@ func (x *UserPermRemoveReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserPermRemoveReq.ProtoReflect.Descriptor instead.
 func (*UserPermRemoveReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{19}
+	return file_myflowhub_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *UserPermRemoveReq) GetUserKey() string {
@@ -1233,7 +1296,7 @@ type UserSelfUpdateReq struct {
 
 func (x *UserSelfUpdateReq) Reset() {
 	*x = UserSelfUpdateReq{}
-	mi := &file_myflowhub_proto_msgTypes[20]
+	mi := &file_myflowhub_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1245,7 +1308,7 @@ func (x *UserSelfUpdateReq) String() string {
 func (*UserSelfUpdateReq) ProtoMessage() {}
 
 func (x *UserSelfUpdateReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[20]
+	mi := &file_myflowhub_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1258,7 +1321,7 @@ func (x *UserSelfUpdateReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserSelfUpdateReq.ProtoReflect.Descriptor instead.
 func (*UserSelfUpdateReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{20}
+	return file_myflowhub_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *UserSelfUpdateReq) GetUserKey() string {
@@ -1286,7 +1349,7 @@ type UserSelfPasswordReq struct {
 
 func (x *UserSelfPasswordReq) Reset() {
 	*x = UserSelfPasswordReq{}
-	mi := &file_myflowhub_proto_msgTypes[21]
+	mi := &file_myflowhub_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1298,7 +1361,7 @@ func (x *UserSelfPasswordReq) String() string {
 func (*UserSelfPasswordReq) ProtoMessage() {}
 
 func (x *UserSelfPasswordReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[21]
+	mi := &file_myflowhub_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1311,7 +1374,7 @@ func (x *UserSelfPasswordReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserSelfPasswordReq.ProtoReflect.Descriptor instead.
 func (*UserSelfPasswordReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{21}
+	return file_myflowhub_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *UserSelfPasswordReq) GetUserKey() string {
@@ -1352,13 +1415,15 @@ type DeviceItem struct {
 	CreatedAtSec  int64                  `protobuf:"varint,9,opt,name=created_at_sec,json=createdAtSec,proto3" json:"created_at_sec,omitempty"`
 	UpdatedAtSec  int64                  `protobuf:"varint,10,opt,name=updated_at_sec,json=updatedAtSec,proto3" json:"updated_at_sec,omitempty"`
 	Approved      *bool                  `protobuf:"varint,11,opt,name=approved,proto3,oneof" json:"approved,omitempty"` // 新增：审批状态
+	Online        *bool                  `protobuf:"varint,12,opt,name=online,proto3,oneof" json:"online,omitempty"`     // 在线状态（Hub 内存视图，含经中继上报的下级）
+	Tags          *string                `protobuf:"bytes,13,opt,name=tags,proto3,oneof" json:"tags,omitempty"`          // 标签（逗号分隔）；UPDATE_DEVICE 时缺省表示不修改，需 user_key
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeviceItem) Reset() {
 	*x = DeviceItem{}
-	mi := &file_myflowhub_proto_msgTypes[22]
+	mi := &file_myflowhub_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1370,7 +1435,7 @@ func (x *DeviceItem) String() string {
 func (*DeviceItem) ProtoMessage() {}
 
 func (x *DeviceItem) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[22]
+	mi := &file_myflowhub_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1383,7 +1448,7 @@ func (x *DeviceItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeviceItem.ProtoReflect.Descriptor instead.
 func (*DeviceItem) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{22}
+	return file_myflowhub_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *DeviceItem) GetId() uint64 {
@@ -1463,6 +1528,20 @@ func (x *DeviceItem) GetApproved() bool {
 	return false
 }
 
+func (x *DeviceItem) GetOnline() bool {
+	if x != nil && x.Online != nil {
+		return *x.Online
+	}
+	return false
+}
+
+func (x *DeviceItem) GetTags() string {
+	if x != nil && x.Tags != nil {
+		return *x.Tags
+	}
+	return ""
+}
+
 type QueryNodesReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserKey       *string                `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3,oneof" json:"user_key,omitempty"`
@@ -1472,7 +1551,7 @@ type QueryNodesReq struct {
 
 func (x *QueryNodesReq) Reset() {
 	*x = QueryNodesReq{}
-	mi := &file_myflowhub_proto_msgTypes[23]
+	mi := &file_myflowhub_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1484,7 +1563,7 @@ func (x *QueryNodesReq) String() string {
 func (*QueryNodesReq) ProtoMessage() {}
 
 func (x *QueryNodesReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[23]
+	mi := &file_myflowhub_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1497,7 +1576,7 @@ func (x *QueryNodesReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryNodesReq.ProtoReflect.Descriptor instead.
 func (*QueryNodesReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{23}
+	return file_myflowhub_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *QueryNodesReq) GetUserKey() string {
@@ -1517,7 +1596,7 @@ type QueryNodesResp struct {
 
 func (x *QueryNodesResp) Reset() {
 	*x = QueryNodesResp{}
-	mi := &file_myflowhub_proto_msgTypes[24]
+	mi := &file_myflowhub_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1529,7 +1608,7 @@ func (x *QueryNodesResp) String() string {
 func (*QueryNodesResp) ProtoMessage() {}
 
 func (x *QueryNodesResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[24]
+	mi := &file_myflowhub_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1542,7 +1621,7 @@ func (x *QueryNodesResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryNodesResp.ProtoReflect.Descriptor instead.
 func (*QueryNodesResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{24}
+	return file_myflowhub_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *QueryNodesResp) GetRequestId() uint64 {
@@ -1569,7 +1648,7 @@ type CreateDeviceReq struct {
 
 func (x *CreateDeviceReq) Reset() {
 	*x = CreateDeviceReq{}
-	mi := &file_myflowhub_proto_msgTypes[25]
+	mi := &file_myflowhub_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1581,7 +1660,7 @@ func (x *CreateDeviceReq) String() string {
 func (*CreateDeviceReq) ProtoMessage() {}
 
 func (x *CreateDeviceReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[25]
+	mi := &file_myflowhub_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1594,7 +1673,7 @@ func (x *CreateDeviceReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateDeviceReq.ProtoReflect.Descriptor instead.
 func (*CreateDeviceReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{25}
+	return file_myflowhub_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *CreateDeviceReq) GetUserKey() string {
@@ -1621,7 +1700,7 @@ type UpdateDeviceReq struct {
 
 func (x *UpdateDeviceReq) Reset() {
 	*x = UpdateDeviceReq{}
-	mi := &file_myflowhub_proto_msgTypes[26]
+	mi := &file_myflowhub_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1633,7 +1712,7 @@ func (x *UpdateDeviceReq) String() string {
 func (*UpdateDeviceReq) ProtoMessage() {}
 
 func (x *UpdateDeviceReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[26]
+	mi := &file_myflowhub_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1646,7 +1725,7 @@ func (x *UpdateDeviceReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateDeviceReq.ProtoReflect.Descriptor instead.
 func (*UpdateDeviceReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{26}
+	return file_myflowhub_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *UpdateDeviceReq) GetUserKey() string {
@@ -1673,7 +1752,7 @@ type DeleteDeviceReq struct {
 
 func (x *DeleteDeviceReq) Reset() {
 	*x = DeleteDeviceReq{}
-	mi := &file_myflowhub_proto_msgTypes[27]
+	mi := &file_myflowhub_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1685,7 +1764,7 @@ func (x *DeleteDeviceReq) String() string {
 func (*DeleteDeviceReq) ProtoMessage() {}
 
 func (x *DeleteDeviceReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[27]
+	mi := &file_myflowhub_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1698,7 +1777,7 @@ func (x *DeleteDeviceReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteDeviceReq.ProtoReflect.Descriptor instead.
 func (*DeleteDeviceReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{27}
+	return file_myflowhub_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *DeleteDeviceReq) GetUserKey() string {
@@ -1717,7 +1796,7 @@ func (x *DeleteDeviceReq) GetId() uint64 {
 
 // =============================================================
 // 变量（Variables）
-// 说明：VarList/VarUpdate/VarDelete
+// 说明：VarList/VarUpdate/VarDelete；VarChangedNotify(164) 由 Hub 推送给变量所属设备（变更来自其他请求方时）
 // =============================================================
 type VarListReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -1729,7 +1808,7 @@ type VarListReq struct {
 
 func (x *VarListReq) Reset() {
 	*x = VarListReq{}
-	mi := &file_myflowhub_proto_msgTypes[28]
+	mi := &file_myflowhub_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1741,7 +1820,7 @@ func (x *VarListReq) String() string {
 func (*VarListReq) ProtoMessage() {}
 
 func (x *VarListReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[28]
+	mi := &file_myflowhub_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1754,7 +1833,7 @@ func (x *VarListReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VarListReq.ProtoReflect.Descriptor instead.
 func (*VarListReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{28}
+	return file_myflowhub_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *VarListReq) GetUserKey() string {
@@ -1786,7 +1865,7 @@ type VarListItem struct {
 
 func (x *VarListItem) Reset() {
 	*x = VarListItem{}
-	mi := &file_myflowhub_proto_msgTypes[29]
+	mi := &file_myflowhub_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1798,7 +1877,7 @@ func (x *VarListItem) String() string {
 func (*VarListItem) ProtoMessage() {}
 
 func (x *VarListItem) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[29]
+	mi := &file_myflowhub_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1811,7 +1890,7 @@ func (x *VarListItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VarListItem.ProtoReflect.Descriptor instead.
 func (*VarListItem) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{29}
+	return file_myflowhub_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *VarListItem) GetId() uint64 {
@@ -1873,7 +1952,7 @@ type VarListResp struct {
 
 func (x *VarListResp) Reset() {
 	*x = VarListResp{}
-	mi := &file_myflowhub_proto_msgTypes[30]
+	mi := &file_myflowhub_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1885,7 +1964,7 @@ func (x *VarListResp) String() string {
 func (*VarListResp) ProtoMessage() {}
 
 func (x *VarListResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[30]
+	mi := &file_myflowhub_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1898,7 +1977,7 @@ func (x *VarListResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VarListResp.ProtoReflect.Descriptor instead.
 func (*VarListResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{30}
+	return file_myflowhub_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *VarListResp) GetRequestId() uint64 {
@@ -1926,7 +2005,7 @@ type VarUpdateItem struct {
 
 func (x *VarUpdateItem) Reset() {
 	*x = VarUpdateItem{}
-	mi := &file_myflowhub_proto_msgTypes[31]
+	mi := &file_myflowhub_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1938,7 +2017,7 @@ func (x *VarUpdateItem) String() string {
 func (*VarUpdateItem) ProtoMessage() {}
 
 func (x *VarUpdateItem) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[31]
+	mi := &file_myflowhub_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1951,7 +2030,7 @@ func (x *VarUpdateItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VarUpdateItem.ProtoReflect.Descriptor instead.
 func (*VarUpdateItem) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{31}
+	return file_myflowhub_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *VarUpdateItem) GetDeviceUid() uint64 {
@@ -1985,7 +2064,7 @@ type VarUpdateReq struct {
 
 func (x *VarUpdateReq) Reset() {
 	*x = VarUpdateReq{}
-	mi := &file_myflowhub_proto_msgTypes[32]
+	mi := &file_myflowhub_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1997,7 +2076,7 @@ func (x *VarUpdateReq) String() string {
 func (*VarUpdateReq) ProtoMessage() {}
 
 func (x *VarUpdateReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[32]
+	mi := &file_myflowhub_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2010,7 +2089,7 @@ func (x *VarUpdateReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VarUpdateReq.ProtoReflect.Descriptor instead.
 func (*VarUpdateReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{32}
+	return file_myflowhub_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *VarUpdateReq) GetUserKey() string {
@@ -2037,7 +2116,7 @@ type VarDeleteItem struct {
 
 func (x *VarDeleteItem) Reset() {
 	*x = VarDeleteItem{}
-	mi := &file_myflowhub_proto_msgTypes[33]
+	mi := &file_myflowhub_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2049,7 +2128,7 @@ func (x *VarDeleteItem) String() string {
 func (*VarDeleteItem) ProtoMessage() {}
 
 func (x *VarDeleteItem) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[33]
+	mi := &file_myflowhub_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2062,7 +2141,7 @@ func (x *VarDeleteItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VarDeleteItem.ProtoReflect.Descriptor instead.
 func (*VarDeleteItem) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{33}
+	return file_myflowhub_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *VarDeleteItem) GetDeviceUid() uint64 {
@@ -2089,7 +2168,7 @@ type VarDeleteReq struct {
 
 func (x *VarDeleteReq) Reset() {
 	*x = VarDeleteReq{}
-	mi := &file_myflowhub_proto_msgTypes[34]
+	mi := &file_myflowhub_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2101,7 +2180,7 @@ func (x *VarDeleteReq) String() string {
 func (*VarDeleteReq) ProtoMessage() {}
 
 func (x *VarDeleteReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[34]
+	mi := &file_myflowhub_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2114,7 +2193,7 @@ func (x *VarDeleteReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VarDeleteReq.ProtoReflect.Descriptor instead.
 func (*VarDeleteReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{34}
+	return file_myflowhub_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *VarDeleteReq) GetUserKey() string {
@@ -2131,14 +2210,66 @@ func (x *VarDeleteReq) GetItems() []*VarDeleteItem {
 	return nil
 }
 
-// =============================================================
-// Key 管理（发放与查询）
-// 说明：包含绑定主体、到期与次数限制、节点范围等；nodes 为权限节点/设备路径（服务端定义）。
-// =============================================================
-type KeyItem struct {
+type VarChangedNotify struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
-	Id              uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	OwnerUserId     *uint64                `protobuf:"varint,2,opt,name=owner_user_id,json=ownerUserId,proto3,oneof" json:"owner_user_id,omitempty"`
+	SourceDeviceUid uint64                 `protobuf:"varint,1,opt,name=source_device_uid,json=sourceDeviceUid,proto3" json:"source_device_uid,omitempty"`
+	Items           []*VarUpdateItem       `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *VarChangedNotify) Reset() {
+	*x = VarChangedNotify{}
+	mi := &file_myflowhub_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VarChangedNotify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VarChangedNotify) ProtoMessage() {}
+
+func (x *VarChangedNotify) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VarChangedNotify.ProtoReflect.Descriptor instead.
+func (*VarChangedNotify) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *VarChangedNotify) GetSourceDeviceUid() uint64 {
+	if x != nil {
+		return x.SourceDeviceUid
+	}
+	return 0
+}
+
+func (x *VarChangedNotify) GetItems() []*VarUpdateItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// =============================================================
+// Key 管理（发放与查询）
+// 说明：包含绑定主体、到期与次数限制、节点范围等；nodes 为权限节点/设备路径（服务端定义）。
+// =============================================================
+type KeyItem struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	OwnerUserId     *uint64                `protobuf:"varint,2,opt,name=owner_user_id,json=ownerUserId,proto3,oneof" json:"owner_user_id,omitempty"`
 	BindSubjectType *string                `protobuf:"bytes,3,opt,name=bind_subject_type,json=bindSubjectType,proto3,oneof" json:"bind_subject_type,omitempty"`
 	BindSubjectId   *uint64                `protobuf:"varint,4,opt,name=bind_subject_id,json=bindSubjectId,proto3,oneof" json:"bind_subject_id,omitempty"`
 	SecretHash      string                 `protobuf:"bytes,5,opt,name=secret_hash,json=secretHash,proto3" json:"secret_hash,omitempty"`
@@ -2155,7 +2286,7 @@ type KeyItem struct {
 
 func (x *KeyItem) Reset() {
 	*x = KeyItem{}
-	mi := &file_myflowhub_proto_msgTypes[35]
+	mi := &file_myflowhub_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2167,7 +2298,7 @@ func (x *KeyItem) String() string {
 func (*KeyItem) ProtoMessage() {}
 
 func (x *KeyItem) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[35]
+	mi := &file_myflowhub_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2180,7 +2311,7 @@ func (x *KeyItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyItem.ProtoReflect.Descriptor instead.
 func (*KeyItem) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{35}
+	return file_myflowhub_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *KeyItem) GetId() uint64 {
@@ -2276,7 +2407,7 @@ type KeyListReq struct {
 
 func (x *KeyListReq) Reset() {
 	*x = KeyListReq{}
-	mi := &file_myflowhub_proto_msgTypes[36]
+	mi := &file_myflowhub_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2288,7 +2419,7 @@ func (x *KeyListReq) String() string {
 func (*KeyListReq) ProtoMessage() {}
 
 func (x *KeyListReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[36]
+	mi := &file_myflowhub_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2301,7 +2432,7 @@ func (x *KeyListReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyListReq.ProtoReflect.Descriptor instead.
 func (*KeyListReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{36}
+	return file_myflowhub_proto_rawDescGZIP(), []int{38}
 }
 
 func (x *KeyListReq) GetUserKey() string {
@@ -2321,7 +2452,7 @@ type KeyListResp struct {
 
 func (x *KeyListResp) Reset() {
 	*x = KeyListResp{}
-	mi := &file_myflowhub_proto_msgTypes[37]
+	mi := &file_myflowhub_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2333,7 +2464,7 @@ func (x *KeyListResp) String() string {
 func (*KeyListResp) ProtoMessage() {}
 
 func (x *KeyListResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[37]
+	mi := &file_myflowhub_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2346,7 +2477,7 @@ func (x *KeyListResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyListResp.ProtoReflect.Descriptor instead.
 func (*KeyListResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{37}
+	return file_myflowhub_proto_rawDescGZIP(), []int{39}
 }
 
 func (x *KeyListResp) GetRequestId() uint64 {
@@ -2378,7 +2509,7 @@ type KeyCreateReq struct {
 
 func (x *KeyCreateReq) Reset() {
 	*x = KeyCreateReq{}
-	mi := &file_myflowhub_proto_msgTypes[38]
+	mi := &file_myflowhub_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2390,7 +2521,7 @@ func (x *KeyCreateReq) String() string {
 func (*KeyCreateReq) ProtoMessage() {}
 
 func (x *KeyCreateReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[38]
+	mi := &file_myflowhub_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2403,7 +2534,7 @@ func (x *KeyCreateReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyCreateReq.ProtoReflect.Descriptor instead.
 func (*KeyCreateReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{38}
+	return file_myflowhub_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *KeyCreateReq) GetUserKey() string {
@@ -2467,7 +2598,7 @@ type KeyCreateResp struct {
 
 func (x *KeyCreateResp) Reset() {
 	*x = KeyCreateResp{}
-	mi := &file_myflowhub_proto_msgTypes[39]
+	mi := &file_myflowhub_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2479,7 +2610,7 @@ func (x *KeyCreateResp) String() string {
 func (*KeyCreateResp) ProtoMessage() {}
 
 func (x *KeyCreateResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[39]
+	mi := &file_myflowhub_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2492,7 +2623,7 @@ func (x *KeyCreateResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyCreateResp.ProtoReflect.Descriptor instead.
 func (*KeyCreateResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{39}
+	return file_myflowhub_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *KeyCreateResp) GetRequestId() uint64 {
@@ -2533,7 +2664,7 @@ type KeyUpdateReq struct {
 
 func (x *KeyUpdateReq) Reset() {
 	*x = KeyUpdateReq{}
-	mi := &file_myflowhub_proto_msgTypes[40]
+	mi := &file_myflowhub_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2545,7 +2676,7 @@ func (x *KeyUpdateReq) String() string {
 func (*KeyUpdateReq) ProtoMessage() {}
 
 func (x *KeyUpdateReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[40]
+	mi := &file_myflowhub_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2558,7 +2689,7 @@ func (x *KeyUpdateReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyUpdateReq.ProtoReflect.Descriptor instead.
 func (*KeyUpdateReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{40}
+	return file_myflowhub_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *KeyUpdateReq) GetUserKey() string {
@@ -2585,7 +2716,7 @@ type KeyDeleteReq struct {
 
 func (x *KeyDeleteReq) Reset() {
 	*x = KeyDeleteReq{}
-	mi := &file_myflowhub_proto_msgTypes[41]
+	mi := &file_myflowhub_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2597,7 +2728,7 @@ func (x *KeyDeleteReq) String() string {
 func (*KeyDeleteReq) ProtoMessage() {}
 
 func (x *KeyDeleteReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[41]
+	mi := &file_myflowhub_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2610,7 +2741,7 @@ func (x *KeyDeleteReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyDeleteReq.ProtoReflect.Descriptor instead.
 func (*KeyDeleteReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{41}
+	return file_myflowhub_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *KeyDeleteReq) GetUserKey() string {
@@ -2636,7 +2767,7 @@ type KeyDevicesReq struct {
 
 func (x *KeyDevicesReq) Reset() {
 	*x = KeyDevicesReq{}
-	mi := &file_myflowhub_proto_msgTypes[42]
+	mi := &file_myflowhub_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2648,7 +2779,7 @@ func (x *KeyDevicesReq) String() string {
 func (*KeyDevicesReq) ProtoMessage() {}
 
 func (x *KeyDevicesReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[42]
+	mi := &file_myflowhub_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2661,7 +2792,7 @@ func (x *KeyDevicesReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyDevicesReq.ProtoReflect.Descriptor instead.
 func (*KeyDevicesReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{42}
+	return file_myflowhub_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *KeyDevicesReq) GetUserKey() string {
@@ -2681,7 +2812,7 @@ type KeyDevicesResp struct {
 
 func (x *KeyDevicesResp) Reset() {
 	*x = KeyDevicesResp{}
-	mi := &file_myflowhub_proto_msgTypes[43]
+	mi := &file_myflowhub_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2693,7 +2824,7 @@ func (x *KeyDevicesResp) String() string {
 func (*KeyDevicesResp) ProtoMessage() {}
 
 func (x *KeyDevicesResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[43]
+	mi := &file_myflowhub_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2706,7 +2837,7 @@ func (x *KeyDevicesResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyDevicesResp.ProtoReflect.Descriptor instead.
 func (*KeyDevicesResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{43}
+	return file_myflowhub_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *KeyDevicesResp) GetRequestId() uint64 {
@@ -2741,7 +2872,7 @@ type SystemLogItem struct {
 
 func (x *SystemLogItem) Reset() {
 	*x = SystemLogItem{}
-	mi := &file_myflowhub_proto_msgTypes[44]
+	mi := &file_myflowhub_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2753,7 +2884,7 @@ func (x *SystemLogItem) String() string {
 func (*SystemLogItem) ProtoMessage() {}
 
 func (x *SystemLogItem) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[44]
+	mi := &file_myflowhub_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2766,7 +2897,7 @@ func (x *SystemLogItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SystemLogItem.ProtoReflect.Descriptor instead.
 func (*SystemLogItem) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{44}
+	return file_myflowhub_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *SystemLogItem) GetLevel() string {
@@ -2820,7 +2951,7 @@ type SystemLogListReq struct {
 
 func (x *SystemLogListReq) Reset() {
 	*x = SystemLogListReq{}
-	mi := &file_myflowhub_proto_msgTypes[45]
+	mi := &file_myflowhub_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2832,7 +2963,7 @@ func (x *SystemLogListReq) String() string {
 func (*SystemLogListReq) ProtoMessage() {}
 
 func (x *SystemLogListReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[45]
+	mi := &file_myflowhub_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2845,7 +2976,7 @@ func (x *SystemLogListReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SystemLogListReq.ProtoReflect.Descriptor instead.
 func (*SystemLogListReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{45}
+	return file_myflowhub_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *SystemLogListReq) GetUserKey() string {
@@ -2917,7 +3048,7 @@ type SystemLogListResp struct {
 
 func (x *SystemLogListResp) Reset() {
 	*x = SystemLogListResp{}
-	mi := &file_myflowhub_proto_msgTypes[46]
+	mi := &file_myflowhub_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2929,7 +3060,7 @@ func (x *SystemLogListResp) String() string {
 func (*SystemLogListResp) ProtoMessage() {}
 
 func (x *SystemLogListResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[46]
+	mi := &file_myflowhub_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2942,7 +3073,7 @@ func (x *SystemLogListResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SystemLogListResp.ProtoReflect.Descriptor instead.
 func (*SystemLogListResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{46}
+	return file_myflowhub_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *SystemLogListResp) GetRequestId() uint64 {
@@ -2992,14 +3123,15 @@ type ParentAuthReq struct {
 	Nonce         []byte                 `protobuf:"bytes,3,opt,name=nonce,proto3" json:"nonce,omitempty"` // 16B
 	HardwareId    string                 `protobuf:"bytes,4,opt,name=hardware_id,json=hardwareId,proto3" json:"hardware_id,omitempty"`
 	Caps          string                 `protobuf:"bytes,5,opt,name=caps,proto3" json:"caps,omitempty"`
-	Sig           []byte                 `protobuf:"bytes,6,opt,name=sig,proto3" json:"sig,omitempty"` // 32B
+	Sig           []byte                 `protobuf:"bytes,6,opt,name=sig,proto3" json:"sig,omitempty"`                                      // 32B
+	FrameMacPub   []byte                 `protobuf:"bytes,7,opt,name=frame_mac_pub,json=frameMacPub,proto3" json:"frame_mac_pub,omitempty"` // 可选：帧认证临时 X25519 公钥（32B）
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ParentAuthReq) Reset() {
 	*x = ParentAuthReq{}
-	mi := &file_myflowhub_proto_msgTypes[47]
+	mi := &file_myflowhub_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3011,7 +3143,7 @@ func (x *ParentAuthReq) String() string {
 func (*ParentAuthReq) ProtoMessage() {}
 
 func (x *ParentAuthReq) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[47]
+	mi := &file_myflowhub_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3024,7 +3156,7 @@ func (x *ParentAuthReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ParentAuthReq.ProtoReflect.Descriptor instead.
 func (*ParentAuthReq) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{47}
+	return file_myflowhub_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *ParentAuthReq) GetVersion() uint32 {
@@ -3069,6 +3201,13 @@ func (x *ParentAuthReq) GetSig() []byte {
 	return nil
 }
 
+func (x *ParentAuthReq) GetFrameMacPub() []byte {
+	if x != nil {
+		return x.FrameMacPub
+	}
+	return nil
+}
+
 type ParentAuthResp struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
@@ -3077,14 +3216,15 @@ type ParentAuthResp struct {
 	HeartbeatSec  uint32                 `protobuf:"varint,4,opt,name=heartbeat_sec,json=heartbeatSec,proto3" json:"heartbeat_sec,omitempty"` // 低 16 位有效
 	Perms         []string               `protobuf:"bytes,5,rep,name=perms,proto3" json:"perms,omitempty"`
 	Exp           int64                  `protobuf:"varint,6,opt,name=exp,proto3" json:"exp,omitempty"`
-	Sig           []byte                 `protobuf:"bytes,7,opt,name=sig,proto3" json:"sig,omitempty"` // 32B
+	Sig           []byte                 `protobuf:"bytes,7,opt,name=sig,proto3" json:"sig,omitempty"`                                      // 32B
+	FrameMacPub   []byte                 `protobuf:"bytes,8,opt,name=frame_mac_pub,json=frameMacPub,proto3" json:"frame_mac_pub,omitempty"` // 上级临时 X25519 公钥；非空时纳入 sig
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ParentAuthResp) Reset() {
 	*x = ParentAuthResp{}
-	mi := &file_myflowhub_proto_msgTypes[48]
+	mi := &file_myflowhub_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3096,7 +3236,7 @@ func (x *ParentAuthResp) String() string {
 func (*ParentAuthResp) ProtoMessage() {}
 
 func (x *ParentAuthResp) ProtoReflect() protoreflect.Message {
-	mi := &file_myflowhub_proto_msgTypes[48]
+	mi := &file_myflowhub_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3109,7 +3249,7 @@ func (x *ParentAuthResp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ParentAuthResp.ProtoReflect.Descriptor instead.
 func (*ParentAuthResp) Descriptor() ([]byte, []int) {
-	return file_myflowhub_proto_rawDescGZIP(), []int{48}
+	return file_myflowhub_proto_rawDescGZIP(), []int{50}
 }
 
 func (x *ParentAuthResp) GetRequestId() uint64 {
@@ -3161,136 +3301,4177 @@ func (x *ParentAuthResp) GetSig() []byte {
 	return nil
 }
 
-var File_myflowhub_proto protoreflect.FileDescriptor
+func (x *ParentAuthResp) GetFrameMacPub() []byte {
+	if x != nil {
+		return x.FrameMacPub
+	}
+	return nil
+}
 
-const file_myflowhub_proto_rawDesc = "" +
-	"\n" +
-	"\x0fmyflowhub.proto\x12\fmyflowhub.v1\"U\n" +
-	"\x06OKResp\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x12\n" +
-	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\fR\amessage\"V\n" +
-	"\aErrResp\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x12\n" +
-	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\fR\amessage\"&\n" +
-	"\x0eManagerAuthReq\x12\x14\n" +
-	"\x05token\x18\x01 \x01(\tR\x05token\"c\n" +
-	"\x0fManagerAuthResp\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1d\n" +
-	"\n" +
-	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x12\n" +
-	"\x04role\x18\x03 \x01(\tR\x04role\"F\n" +
-	"\fUserLoginReq\x12\x1a\n" +
-	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"\xd7\x01\n" +
-	"\rUserLoginResp\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x15\n" +
-	"\x06key_id\x18\x02 \x01(\x04R\x05keyId\x12\x17\n" +
-	"\auser_id\x18\x03 \x01(\x04R\x06userId\x12\x16\n" +
-	"\x06secret\x18\x04 \x01(\tR\x06secret\x12\x1a\n" +
-	"\busername\x18\x05 \x01(\tR\busername\x12!\n" +
-	"\fdisplay_name\x18\x06 \x01(\tR\vdisplayName\x12 \n" +
-	"\vpermissions\x18\a \x03(\tR\vpermissions\"&\n" +
-	"\tUserMeReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\"\xa5\x01\n" +
-	"\n" +
-	"UserMeResp\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x1a\n" +
-	"\busername\x18\x03 \x01(\tR\busername\x12!\n" +
-	"\fdisplay_name\x18\x04 \x01(\tR\vdisplayName\x12 \n" +
-	"\vpermissions\x18\x05 \x03(\tR\vpermissions\"*\n" +
-	"\rUserLogoutReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\"\xc1\x01\n" +
-	"\bUserItem\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1a\n" +
-	"\busername\x18\x02 \x01(\tR\busername\x12!\n" +
-	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12\x1a\n" +
-	"\bdisabled\x18\x04 \x01(\bR\bdisabled\x12$\n" +
-	"\x0ecreated_at_sec\x18\x05 \x01(\x03R\fcreatedAtSec\x12$\n" +
-	"\x0eupdated_at_sec\x18\x06 \x01(\x03R\fupdatedAtSec\"(\n" +
-	"\vUserListReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\"[\n" +
-	"\fUserListResp\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x01 \x01(\x04R\trequestId\x12,\n" +
-	"\x05users\x18\x02 \x03(\v2\x16.myflowhub.v1.UserItemR\x05users\"\x85\x01\n" +
-	"\rUserCreateReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1a\n" +
-	"\busername\x18\x02 \x01(\tR\busername\x12!\n" +
-	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12\x1a\n" +
-	"\bpassword\x18\x04 \x01(\tR\bpassword\"H\n" +
-	"\x0eUserCreateResp\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\x04R\x06userId\"\xcf\x01\n" +
-	"\rUserUpdateReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x0e\n" +
-	"\x02id\x18\x02 \x01(\x04R\x02id\x12&\n" +
-	"\fdisplay_name\x18\x03 \x01(\tH\x00R\vdisplayName\x88\x01\x01\x12\x1f\n" +
-	"\bpassword\x18\x04 \x01(\tH\x01R\bpassword\x88\x01\x01\x12\x1f\n" +
-	"\bdisabled\x18\x05 \x01(\bH\x02R\bdisabled\x88\x01\x01B\x0f\n" +
-	"\r_display_nameB\v\n" +
-	"\t_passwordB\v\n" +
-	"\t_disabled\":\n" +
-	"\rUserDeleteReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x0e\n" +
-	"\x02id\x18\x02 \x01(\x04R\x02id\"E\n" +
-	"\x0fUserPermListReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\x04R\x06userId\"G\n" +
-	"\x10UserPermListResp\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x14\n" +
-	"\x05nodes\x18\x02 \x03(\tR\x05nodes\"X\n" +
-	"\x0eUserPermAddReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x12\n" +
-	"\x04node\x18\x03 \x01(\tR\x04node\"[\n" +
-	"\x11UserPermRemoveReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x12\n" +
-	"\x04node\x18\x03 \x01(\tR\x04node\"Q\n" +
-	"\x11UserSelfUpdateReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\x12!\n" +
-	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\"v\n" +
-	"\x13UserSelfPasswordReq\x12\x19\n" +
-	"\buser_key\x18\x01 \x01(\tR\auserKey\x12!\n" +
-	"\fold_password\x18\x02 \x01(\tR\voldPassword\x12!\n" +
-	"\fnew_password\x18\x03 \x01(\tR\vnewPassword\"\xa4\x03\n" +
-	"\n" +
-	"DeviceItem\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
-	"\n" +
-	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x1f\n" +
-	"\vhardware_id\x18\x03 \x01(\tR\n" +
-	"hardwareId\x12\x12\n" +
-	"\x04role\x18\x04 \x01(\tR\x04role\x12\x12\n" +
-	"\x04name\x18\x05 \x01(\tR\x04name\x12 \n" +
-	"\tparent_id\x18\x06 \x01(\x04H\x00R\bparentId\x88\x01\x01\x12'\n" +
-	"\rowner_user_id\x18\a \x01(\x04H\x01R\vownerUserId\x88\x01\x01\x12'\n" +
-	"\rlast_seen_sec\x18\b \x01(\x03H\x02R\vlastSeenSec\x88\x01\x01\x12$\n" +
-	"\x0ecreated_at_sec\x18\t \x01(\x03R\fcreatedAtSec\x12$\n" +
-	"\x0eupdated_at_sec\x18\n" +
-	" \x01(\x03R\fupdatedAtSec\x12\x1f\n" +
-	"\bapproved\x18\v \x01(\bH\x03R\bapproved\x88\x01\x01B\f\n" +
-	"\n" +
-	"_parent_idB\x10\n" +
-	"\x0e_owner_user_idB\x10\n" +
-	"\x0e_last_seen_secB\v\n" +
-	"\t_approved\"<\n" +
-	"\rQueryNodesReq\x12\x1e\n" +
-	"\buser_key\x18\x01 \x01(\tH\x00R\auserKey\x88\x01\x01B\v\n" +
-	"\t_user_key\"c\n" +
-	"\x0eQueryNodesResp\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x01 \x01(\x04R\trequestId\x122\n" +
+// =============================================================
+// 文件分片传输（File Transfer）
+// TypeID: 300 FILE_INIT_REQ, 301 FILE_INIT_RESP, 302 FILE_CHUNK,
+//
+//	303 FILE_COMPLETE_REQ, 304 FILE_COMPLETE_RESP, 305 FILE_CANCEL, 306 FILE_CHUNK_ACK
+//
+// 说明：Target=Hub（或 0）时由 Hub 落盘保存；Target 为其他设备时沿树透传，由目标设备自行应答。
+//
+//	file_hash 为整文件 SHA-256（32B）；crc32 为 IEEE 多项式。
+//
+// =============================================================
+type FileInitReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransferId    uint64                 `protobuf:"varint,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	TotalSize     uint64                 `protobuf:"varint,2,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	Mime          string                 `protobuf:"bytes,3,opt,name=mime,proto3" json:"mime,omitempty"`
+	Filename      string                 `protobuf:"bytes,4,opt,name=filename,proto3" json:"filename,omitempty"`
+	FileHash      []byte                 `protobuf:"bytes,5,opt,name=file_hash,json=fileHash,proto3" json:"file_hash,omitempty"` // 32B
+	Thumbnail     []byte                 `protobuf:"bytes,6,opt,name=thumbnail,proto3,oneof" json:"thumbnail,omitempty"`
+	ChunkSize     uint32                 `protobuf:"varint,7,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"` // 发送端期望的分片大小，0 表示由接收端决定
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileInitReq) Reset() {
+	*x = FileInitReq{}
+	mi := &file_myflowhub_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileInitReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileInitReq) ProtoMessage() {}
+
+func (x *FileInitReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileInitReq.ProtoReflect.Descriptor instead.
+func (*FileInitReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *FileInitReq) GetTransferId() uint64 {
+	if x != nil {
+		return x.TransferId
+	}
+	return 0
+}
+
+func (x *FileInitReq) GetTotalSize() uint64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *FileInitReq) GetMime() string {
+	if x != nil {
+		return x.Mime
+	}
+	return ""
+}
+
+func (x *FileInitReq) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *FileInitReq) GetFileHash() []byte {
+	if x != nil {
+		return x.FileHash
+	}
+	return nil
+}
+
+func (x *FileInitReq) GetThumbnail() []byte {
+	if x != nil {
+		return x.Thumbnail
+	}
+	return nil
+}
+
+func (x *FileInitReq) GetChunkSize() uint32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+type FileInitResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	TransferId    uint64                 `protobuf:"varint,2,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	Ok            bool                   `protobuf:"varint,3,opt,name=ok,proto3" json:"ok,omitempty"`
+	ResumeOffset  *uint64                `protobuf:"varint,4,opt,name=resume_offset,json=resumeOffset,proto3,oneof" json:"resume_offset,omitempty"` // 断点续传：接收端已连续持有的字节数
+	ChunkSize     uint32                 `protobuf:"varint,5,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`                // 接收端确认的分片大小
+	Window        uint32                 `protobuf:"varint,6,opt,name=window,proto3" json:"window,omitempty"`                                       // 允许在途（未确认）的分片数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileInitResp) Reset() {
+	*x = FileInitResp{}
+	mi := &file_myflowhub_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileInitResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileInitResp) ProtoMessage() {}
+
+func (x *FileInitResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileInitResp.ProtoReflect.Descriptor instead.
+func (*FileInitResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *FileInitResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *FileInitResp) GetTransferId() uint64 {
+	if x != nil {
+		return x.TransferId
+	}
+	return 0
+}
+
+func (x *FileInitResp) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *FileInitResp) GetResumeOffset() uint64 {
+	if x != nil && x.ResumeOffset != nil {
+		return *x.ResumeOffset
+	}
+	return 0
+}
+
+func (x *FileInitResp) GetChunkSize() uint32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *FileInitResp) GetWindow() uint32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
+type FileChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransferId    uint64                 `protobuf:"varint,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Chunk         []byte                 `protobuf:"bytes,3,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	Crc32         *uint32                `protobuf:"varint,4,opt,name=crc32,proto3,oneof" json:"crc32,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileChunk) Reset() {
+	*x = FileChunk{}
+	mi := &file_myflowhub_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChunk) ProtoMessage() {}
+
+func (x *FileChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
+func (*FileChunk) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *FileChunk) GetTransferId() uint64 {
+	if x != nil {
+		return x.TransferId
+	}
+	return 0
+}
+
+func (x *FileChunk) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *FileChunk) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+func (x *FileChunk) GetCrc32() uint32 {
+	if x != nil && x.Crc32 != nil {
+		return *x.Crc32
+	}
+	return 0
+}
+
+type FileChunkAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransferId    uint64                 `protobuf:"varint,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	NextOffset    uint64                 `protobuf:"varint,2,opt,name=next_offset,json=nextOffset,proto3" json:"next_offset,omitempty"` // 接收端期望的下一个偏移（累计确认）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileChunkAck) Reset() {
+	*x = FileChunkAck{}
+	mi := &file_myflowhub_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileChunkAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChunkAck) ProtoMessage() {}
+
+func (x *FileChunkAck) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChunkAck.ProtoReflect.Descriptor instead.
+func (*FileChunkAck) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *FileChunkAck) GetTransferId() uint64 {
+	if x != nil {
+		return x.TransferId
+	}
+	return 0
+}
+
+func (x *FileChunkAck) GetNextOffset() uint64 {
+	if x != nil {
+		return x.NextOffset
+	}
+	return 0
+}
+
+type FileCompleteReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransferId    uint64                 `protobuf:"varint,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileCompleteReq) Reset() {
+	*x = FileCompleteReq{}
+	mi := &file_myflowhub_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileCompleteReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileCompleteReq) ProtoMessage() {}
+
+func (x *FileCompleteReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileCompleteReq.ProtoReflect.Descriptor instead.
+func (*FileCompleteReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *FileCompleteReq) GetTransferId() uint64 {
+	if x != nil {
+		return x.TransferId
+	}
+	return 0
+}
+
+type FileCompleteResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	TransferId    uint64                 `protobuf:"varint,2,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	Ok            bool                   `protobuf:"varint,3,opt,name=ok,proto3" json:"ok,omitempty"`
+	FileId        uint64                 `protobuf:"varint,4,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"` // Hub 侧存储记录 ID（设备间传输为 0）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileCompleteResp) Reset() {
+	*x = FileCompleteResp{}
+	mi := &file_myflowhub_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileCompleteResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileCompleteResp) ProtoMessage() {}
+
+func (x *FileCompleteResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileCompleteResp.ProtoReflect.Descriptor instead.
+func (*FileCompleteResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *FileCompleteResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *FileCompleteResp) GetTransferId() uint64 {
+	if x != nil {
+		return x.TransferId
+	}
+	return 0
+}
+
+func (x *FileCompleteResp) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *FileCompleteResp) GetFileId() uint64 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+type FileCancel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransferId    uint64                 `protobuf:"varint,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileCancel) Reset() {
+	*x = FileCancel{}
+	mi := &file_myflowhub_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileCancel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileCancel) ProtoMessage() {}
+
+func (x *FileCancel) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileCancel.ProtoReflect.Descriptor instead.
+func (*FileCancel) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *FileCancel) GetTransferId() uint64 {
+	if x != nil {
+		return x.TransferId
+	}
+	return 0
+}
+
+func (x *FileCancel) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// =============================================================
+// 固件 OTA（Firmware OTA）
+// TypeID: 320/321 ARTIFACT_CREATE, 322/323 ARTIFACT_LIST, 324/325 CAMPAIGN_CREATE,
+//
+//	326/327 CAMPAIGN_LIST, 328 CAMPAIGN_CONTROL(→OKResp), 329/330 CAMPAIGN_STATUS
+//
+// 说明：固件文件先经文件分片协议上传到 Hub（得到 file_id），再登记为制品；
+//
+//	活动按 stages 百分比分批下发，设备通过变量 ota_state/ota_progress/ota_error/fw_version 上报状态。
+//
+// =============================================================
+type OtaArtifactItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	HardwareModel string                 `protobuf:"bytes,3,opt,name=hardware_model,json=hardwareModel,proto3" json:"hardware_model,omitempty"` // 空表示不限型号
+	FileId        uint64                 `protobuf:"varint,4,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Size          uint64                 `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	Sha256        string                 `protobuf:"bytes,6,opt,name=sha256,proto3" json:"sha256,omitempty"`                         // hex
+	CreatedAt     int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // 秒
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaArtifactItem) Reset() {
+	*x = OtaArtifactItem{}
+	mi := &file_myflowhub_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaArtifactItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaArtifactItem) ProtoMessage() {}
+
+func (x *OtaArtifactItem) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaArtifactItem.ProtoReflect.Descriptor instead.
+func (*OtaArtifactItem) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *OtaArtifactItem) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *OtaArtifactItem) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *OtaArtifactItem) GetHardwareModel() string {
+	if x != nil {
+		return x.HardwareModel
+	}
+	return ""
+}
+
+func (x *OtaArtifactItem) GetFileId() uint64 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *OtaArtifactItem) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *OtaArtifactItem) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *OtaArtifactItem) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type OtaArtifactCreateReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	HardwareModel string                 `protobuf:"bytes,3,opt,name=hardware_model,json=hardwareModel,proto3" json:"hardware_model,omitempty"`
+	FileId        uint64                 `protobuf:"varint,4,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Sha256        string                 `protobuf:"bytes,5,opt,name=sha256,proto3" json:"sha256,omitempty"` // hex，需与已上传文件一致
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaArtifactCreateReq) Reset() {
+	*x = OtaArtifactCreateReq{}
+	mi := &file_myflowhub_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaArtifactCreateReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaArtifactCreateReq) ProtoMessage() {}
+
+func (x *OtaArtifactCreateReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaArtifactCreateReq.ProtoReflect.Descriptor instead.
+func (*OtaArtifactCreateReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *OtaArtifactCreateReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *OtaArtifactCreateReq) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *OtaArtifactCreateReq) GetHardwareModel() string {
+	if x != nil {
+		return x.HardwareModel
+	}
+	return ""
+}
+
+func (x *OtaArtifactCreateReq) GetFileId() uint64 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *OtaArtifactCreateReq) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type OtaArtifactCreateResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Item          *OtaArtifactItem       `protobuf:"bytes,2,opt,name=item,proto3" json:"item,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaArtifactCreateResp) Reset() {
+	*x = OtaArtifactCreateResp{}
+	mi := &file_myflowhub_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaArtifactCreateResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaArtifactCreateResp) ProtoMessage() {}
+
+func (x *OtaArtifactCreateResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaArtifactCreateResp.ProtoReflect.Descriptor instead.
+func (*OtaArtifactCreateResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *OtaArtifactCreateResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *OtaArtifactCreateResp) GetItem() *OtaArtifactItem {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+type OtaArtifactListReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaArtifactListReq) Reset() {
+	*x = OtaArtifactListReq{}
+	mi := &file_myflowhub_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaArtifactListReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaArtifactListReq) ProtoMessage() {}
+
+func (x *OtaArtifactListReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaArtifactListReq.ProtoReflect.Descriptor instead.
+func (*OtaArtifactListReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *OtaArtifactListReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+type OtaArtifactListResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Items         []*OtaArtifactItem     `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaArtifactListResp) Reset() {
+	*x = OtaArtifactListResp{}
+	mi := &file_myflowhub_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaArtifactListResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaArtifactListResp) ProtoMessage() {}
+
+func (x *OtaArtifactListResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaArtifactListResp.ProtoReflect.Descriptor instead.
+func (*OtaArtifactListResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *OtaArtifactListResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *OtaArtifactListResp) GetItems() []*OtaArtifactItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type OtaCampaignItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ArtifactId    uint64                 `protobuf:"varint,3,opt,name=artifact_id,json=artifactId,proto3" json:"artifact_id,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"` // draft/running/paused/aborted/completed
+	OwnerUserId   *uint64                `protobuf:"varint,5,opt,name=owner_user_id,json=ownerUserId,proto3,oneof" json:"owner_user_id,omitempty"`
+	ParentId      *uint64                `protobuf:"varint,6,opt,name=parent_id,json=parentId,proto3,oneof" json:"parent_id,omitempty"` // 目标为该节点的整棵子树
+	Tag           *string                `protobuf:"bytes,7,opt,name=tag,proto3,oneof" json:"tag,omitempty"`
+	Stages        []uint32               `protobuf:"varint,8,rep,packed,name=stages,proto3" json:"stages,omitempty"` // 累计百分比，如 [10,50,100]
+	CurrentStage  uint32                 `protobuf:"varint,9,opt,name=current_stage,json=currentStage,proto3" json:"current_stage,omitempty"`
+	MaxFailurePct uint32                 `protobuf:"varint,10,opt,name=max_failure_pct,json=maxFailurePct,proto3" json:"max_failure_pct,omitempty"` // 单批失败率超过该值自动暂停，0 表示不限
+	Total         uint32                 `protobuf:"varint,11,opt,name=total,proto3" json:"total,omitempty"`
+	Succeeded     uint32                 `protobuf:"varint,12,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Failed        uint32                 `protobuf:"varint,13,opt,name=failed,proto3" json:"failed,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,14,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // 秒
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaCampaignItem) Reset() {
+	*x = OtaCampaignItem{}
+	mi := &file_myflowhub_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaCampaignItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaCampaignItem) ProtoMessage() {}
+
+func (x *OtaCampaignItem) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaCampaignItem.ProtoReflect.Descriptor instead.
+func (*OtaCampaignItem) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *OtaCampaignItem) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *OtaCampaignItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OtaCampaignItem) GetArtifactId() uint64 {
+	if x != nil {
+		return x.ArtifactId
+	}
+	return 0
+}
+
+func (x *OtaCampaignItem) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *OtaCampaignItem) GetOwnerUserId() uint64 {
+	if x != nil && x.OwnerUserId != nil {
+		return *x.OwnerUserId
+	}
+	return 0
+}
+
+func (x *OtaCampaignItem) GetParentId() uint64 {
+	if x != nil && x.ParentId != nil {
+		return *x.ParentId
+	}
+	return 0
+}
+
+func (x *OtaCampaignItem) GetTag() string {
+	if x != nil && x.Tag != nil {
+		return *x.Tag
+	}
+	return ""
+}
+
+func (x *OtaCampaignItem) GetStages() []uint32 {
+	if x != nil {
+		return x.Stages
+	}
+	return nil
+}
+
+func (x *OtaCampaignItem) GetCurrentStage() uint32 {
+	if x != nil {
+		return x.CurrentStage
+	}
+	return 0
+}
+
+func (x *OtaCampaignItem) GetMaxFailurePct() uint32 {
+	if x != nil {
+		return x.MaxFailurePct
+	}
+	return 0
+}
+
+func (x *OtaCampaignItem) GetTotal() uint32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *OtaCampaignItem) GetSucceeded() uint32 {
+	if x != nil {
+		return x.Succeeded
+	}
+	return 0
+}
+
+func (x *OtaCampaignItem) GetFailed() uint32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *OtaCampaignItem) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type OtaCampaignCreateReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ArtifactId    uint64                 `protobuf:"varint,3,opt,name=artifact_id,json=artifactId,proto3" json:"artifact_id,omitempty"`
+	OwnerUserId   *uint64                `protobuf:"varint,4,opt,name=owner_user_id,json=ownerUserId,proto3,oneof" json:"owner_user_id,omitempty"`
+	ParentId      *uint64                `protobuf:"varint,5,opt,name=parent_id,json=parentId,proto3,oneof" json:"parent_id,omitempty"`
+	Tag           *string                `protobuf:"bytes,6,opt,name=tag,proto3,oneof" json:"tag,omitempty"`
+	Stages        []uint32               `protobuf:"varint,7,rep,packed,name=stages,proto3" json:"stages,omitempty"`
+	MaxFailurePct uint32                 `protobuf:"varint,8,opt,name=max_failure_pct,json=maxFailurePct,proto3" json:"max_failure_pct,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaCampaignCreateReq) Reset() {
+	*x = OtaCampaignCreateReq{}
+	mi := &file_myflowhub_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaCampaignCreateReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaCampaignCreateReq) ProtoMessage() {}
+
+func (x *OtaCampaignCreateReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaCampaignCreateReq.ProtoReflect.Descriptor instead.
+func (*OtaCampaignCreateReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *OtaCampaignCreateReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *OtaCampaignCreateReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OtaCampaignCreateReq) GetArtifactId() uint64 {
+	if x != nil {
+		return x.ArtifactId
+	}
+	return 0
+}
+
+func (x *OtaCampaignCreateReq) GetOwnerUserId() uint64 {
+	if x != nil && x.OwnerUserId != nil {
+		return *x.OwnerUserId
+	}
+	return 0
+}
+
+func (x *OtaCampaignCreateReq) GetParentId() uint64 {
+	if x != nil && x.ParentId != nil {
+		return *x.ParentId
+	}
+	return 0
+}
+
+func (x *OtaCampaignCreateReq) GetTag() string {
+	if x != nil && x.Tag != nil {
+		return *x.Tag
+	}
+	return ""
+}
+
+func (x *OtaCampaignCreateReq) GetStages() []uint32 {
+	if x != nil {
+		return x.Stages
+	}
+	return nil
+}
+
+func (x *OtaCampaignCreateReq) GetMaxFailurePct() uint32 {
+	if x != nil {
+		return x.MaxFailurePct
+	}
+	return 0
+}
+
+type OtaCampaignCreateResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Item          *OtaCampaignItem       `protobuf:"bytes,2,opt,name=item,proto3" json:"item,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaCampaignCreateResp) Reset() {
+	*x = OtaCampaignCreateResp{}
+	mi := &file_myflowhub_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaCampaignCreateResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaCampaignCreateResp) ProtoMessage() {}
+
+func (x *OtaCampaignCreateResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaCampaignCreateResp.ProtoReflect.Descriptor instead.
+func (*OtaCampaignCreateResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *OtaCampaignCreateResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *OtaCampaignCreateResp) GetItem() *OtaCampaignItem {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+type OtaCampaignListReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaCampaignListReq) Reset() {
+	*x = OtaCampaignListReq{}
+	mi := &file_myflowhub_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaCampaignListReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaCampaignListReq) ProtoMessage() {}
+
+func (x *OtaCampaignListReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaCampaignListReq.ProtoReflect.Descriptor instead.
+func (*OtaCampaignListReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *OtaCampaignListReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+type OtaCampaignListResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Items         []*OtaCampaignItem     `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaCampaignListResp) Reset() {
+	*x = OtaCampaignListResp{}
+	mi := &file_myflowhub_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaCampaignListResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaCampaignListResp) ProtoMessage() {}
+
+func (x *OtaCampaignListResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaCampaignListResp.ProtoReflect.Descriptor instead.
+func (*OtaCampaignListResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *OtaCampaignListResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *OtaCampaignListResp) GetItems() []*OtaCampaignItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type OtaCampaignControlReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	CampaignId    uint64                 `protobuf:"varint,2,opt,name=campaign_id,json=campaignId,proto3" json:"campaign_id,omitempty"`
+	Action        string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"` // start/pause/resume/abort
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaCampaignControlReq) Reset() {
+	*x = OtaCampaignControlReq{}
+	mi := &file_myflowhub_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaCampaignControlReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaCampaignControlReq) ProtoMessage() {}
+
+func (x *OtaCampaignControlReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaCampaignControlReq.ProtoReflect.Descriptor instead.
+func (*OtaCampaignControlReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *OtaCampaignControlReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *OtaCampaignControlReq) GetCampaignId() uint64 {
+	if x != nil {
+		return x.CampaignId
+	}
+	return 0
+}
+
+func (x *OtaCampaignControlReq) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+type OtaDeviceStateItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceUid     uint64                 `protobuf:"varint,1,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Stage         uint32                 `protobuf:"varint,2,opt,name=stage,proto3" json:"stage,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`        // pending/downloading/installing/succeeded/failed
+	Progress      uint32                 `protobuf:"varint,4,opt,name=progress,proto3" json:"progress,omitempty"` // 0~100
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // 秒
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaDeviceStateItem) Reset() {
+	*x = OtaDeviceStateItem{}
+	mi := &file_myflowhub_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaDeviceStateItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaDeviceStateItem) ProtoMessage() {}
+
+func (x *OtaDeviceStateItem) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaDeviceStateItem.ProtoReflect.Descriptor instead.
+func (*OtaDeviceStateItem) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *OtaDeviceStateItem) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *OtaDeviceStateItem) GetStage() uint32 {
+	if x != nil {
+		return x.Stage
+	}
+	return 0
+}
+
+func (x *OtaDeviceStateItem) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *OtaDeviceStateItem) GetProgress() uint32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *OtaDeviceStateItem) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *OtaDeviceStateItem) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type OtaCampaignStatusReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	CampaignId    uint64                 `protobuf:"varint,2,opt,name=campaign_id,json=campaignId,proto3" json:"campaign_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaCampaignStatusReq) Reset() {
+	*x = OtaCampaignStatusReq{}
+	mi := &file_myflowhub_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaCampaignStatusReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaCampaignStatusReq) ProtoMessage() {}
+
+func (x *OtaCampaignStatusReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaCampaignStatusReq.ProtoReflect.Descriptor instead.
+func (*OtaCampaignStatusReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *OtaCampaignStatusReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *OtaCampaignStatusReq) GetCampaignId() uint64 {
+	if x != nil {
+		return x.CampaignId
+	}
+	return 0
+}
+
+type OtaCampaignStatusResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Campaign      *OtaCampaignItem       `protobuf:"bytes,2,opt,name=campaign,proto3" json:"campaign,omitempty"`
+	Devices       []*OtaDeviceStateItem  `protobuf:"bytes,3,rep,name=devices,proto3" json:"devices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OtaCampaignStatusResp) Reset() {
+	*x = OtaCampaignStatusResp{}
+	mi := &file_myflowhub_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OtaCampaignStatusResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OtaCampaignStatusResp) ProtoMessage() {}
+
+func (x *OtaCampaignStatusResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OtaCampaignStatusResp.ProtoReflect.Descriptor instead.
+func (*OtaCampaignStatusResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *OtaCampaignStatusResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *OtaCampaignStatusResp) GetCampaign() *OtaCampaignItem {
+	if x != nil {
+		return x.Campaign
+	}
+	return nil
+}
+
+func (x *OtaCampaignStatusResp) GetDevices() []*OtaDeviceStateItem {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+// =============================================================
+// 设备孪生（Device Twin）
+// TypeID: 340/341 TWIN_GET, 342 TWIN_DESIRED_UPDATE_REQ, 343 TWIN_REPORTED_UPDATE_REQ,
+//
+//	344 TWIN_UPDATE_RESP, 345 TWIN_DELTA_REQ, 346 TWIN_DELTA（响应或 Hub 主动推送）
+//
+// 说明：desired/reported/patch/delta 均为 JSON 对象（UTF-8 字节）；patch 采用 JSON Merge Patch（null 删除键）。
+//
+//	device_uid=0 表示请求方自身；expected_version 不匹配时返回 ErrResp(409)。
+//
+// =============================================================
+type TwinGetReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TwinGetReq) Reset() {
+	*x = TwinGetReq{}
+	mi := &file_myflowhub_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinGetReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinGetReq) ProtoMessage() {}
+
+func (x *TwinGetReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinGetReq.ProtoReflect.Descriptor instead.
+func (*TwinGetReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *TwinGetReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *TwinGetReq) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+type TwinGetResp struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	RequestId       uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	DeviceUid       uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Desired         []byte                 `protobuf:"bytes,3,opt,name=desired,proto3" json:"desired,omitempty"`
+	DesiredVersion  uint64                 `protobuf:"varint,4,opt,name=desired_version,json=desiredVersion,proto3" json:"desired_version,omitempty"`
+	Reported        []byte                 `protobuf:"bytes,5,opt,name=reported,proto3" json:"reported,omitempty"`
+	ReportedVersion uint64                 `protobuf:"varint,6,opt,name=reported_version,json=reportedVersion,proto3" json:"reported_version,omitempty"`
+	Delta           []byte                 `protobuf:"bytes,7,opt,name=delta,proto3" json:"delta,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TwinGetResp) Reset() {
+	*x = TwinGetResp{}
+	mi := &file_myflowhub_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinGetResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinGetResp) ProtoMessage() {}
+
+func (x *TwinGetResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinGetResp.ProtoReflect.Descriptor instead.
+func (*TwinGetResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{73}
+}
+
+func (x *TwinGetResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *TwinGetResp) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *TwinGetResp) GetDesired() []byte {
+	if x != nil {
+		return x.Desired
+	}
+	return nil
+}
+
+func (x *TwinGetResp) GetDesiredVersion() uint64 {
+	if x != nil {
+		return x.DesiredVersion
+	}
+	return 0
+}
+
+func (x *TwinGetResp) GetReported() []byte {
+	if x != nil {
+		return x.Reported
+	}
+	return nil
+}
+
+func (x *TwinGetResp) GetReportedVersion() uint64 {
+	if x != nil {
+		return x.ReportedVersion
+	}
+	return 0
+}
+
+func (x *TwinGetResp) GetDelta() []byte {
+	if x != nil {
+		return x.Delta
+	}
+	return nil
+}
+
+type TwinDesiredUpdateReq struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserKey         string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	DeviceUid       uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Patch           []byte                 `protobuf:"bytes,3,opt,name=patch,proto3" json:"patch,omitempty"`
+	ExpectedVersion *uint64                `protobuf:"varint,4,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TwinDesiredUpdateReq) Reset() {
+	*x = TwinDesiredUpdateReq{}
+	mi := &file_myflowhub_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinDesiredUpdateReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinDesiredUpdateReq) ProtoMessage() {}
+
+func (x *TwinDesiredUpdateReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinDesiredUpdateReq.ProtoReflect.Descriptor instead.
+func (*TwinDesiredUpdateReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{74}
+}
+
+func (x *TwinDesiredUpdateReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *TwinDesiredUpdateReq) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *TwinDesiredUpdateReq) GetPatch() []byte {
+	if x != nil {
+		return x.Patch
+	}
+	return nil
+}
+
+func (x *TwinDesiredUpdateReq) GetExpectedVersion() uint64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type TwinReportedUpdateReq struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Patch           []byte                 `protobuf:"bytes,1,opt,name=patch,proto3" json:"patch,omitempty"`
+	ExpectedVersion *uint64                `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TwinReportedUpdateReq) Reset() {
+	*x = TwinReportedUpdateReq{}
+	mi := &file_myflowhub_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinReportedUpdateReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinReportedUpdateReq) ProtoMessage() {}
+
+func (x *TwinReportedUpdateReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinReportedUpdateReq.ProtoReflect.Descriptor instead.
+func (*TwinReportedUpdateReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *TwinReportedUpdateReq) GetPatch() []byte {
+	if x != nil {
+		return x.Patch
+	}
+	return nil
+}
+
+func (x *TwinReportedUpdateReq) GetExpectedVersion() uint64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type TwinUpdateResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Version       uint64                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TwinUpdateResp) Reset() {
+	*x = TwinUpdateResp{}
+	mi := &file_myflowhub_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinUpdateResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinUpdateResp) ProtoMessage() {}
+
+func (x *TwinUpdateResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[76]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinUpdateResp.ProtoReflect.Descriptor instead.
+func (*TwinUpdateResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *TwinUpdateResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *TwinUpdateResp) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *TwinUpdateResp) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type TwinDeltaReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TwinDeltaReq) Reset() {
+	*x = TwinDeltaReq{}
+	mi := &file_myflowhub_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinDeltaReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinDeltaReq) ProtoMessage() {}
+
+func (x *TwinDeltaReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinDeltaReq.ProtoReflect.Descriptor instead.
+func (*TwinDeltaReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *TwinDeltaReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *TwinDeltaReq) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+type TwinDelta struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	RequestId       uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"` // 主动推送时为 0
+	DeviceUid       uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Delta           []byte                 `protobuf:"bytes,3,opt,name=delta,proto3" json:"delta,omitempty"`
+	DesiredVersion  uint64                 `protobuf:"varint,4,opt,name=desired_version,json=desiredVersion,proto3" json:"desired_version,omitempty"`
+	ReportedVersion uint64                 `protobuf:"varint,5,opt,name=reported_version,json=reportedVersion,proto3" json:"reported_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TwinDelta) Reset() {
+	*x = TwinDelta{}
+	mi := &file_myflowhub_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinDelta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinDelta) ProtoMessage() {}
+
+func (x *TwinDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinDelta.ProtoReflect.Descriptor instead.
+func (*TwinDelta) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{78}
+}
+
+func (x *TwinDelta) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *TwinDelta) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *TwinDelta) GetDelta() []byte {
+	if x != nil {
+		return x.Delta
+	}
+	return nil
+}
+
+func (x *TwinDelta) GetDesiredVersion() uint64 {
+	if x != nil {
+		return x.DesiredVersion
+	}
+	return 0
+}
+
+func (x *TwinDelta) GetReportedVersion() uint64 {
+	if x != nil {
+		return x.ReportedVersion
+	}
+	return 0
+}
+
+// =============================================================
+// 心跳与在线状态（Presence）
+// TypeID: 350 HEARTBEAT（设备→Hub，Hub 原样回送），351 PRESENCE_EVENT（中继→上级），
+//
+//	352/353 PRESENCE_QUERY
+//
+// 说明：心跳周期取 ParentAuthResp.heartbeat_sec；连续未收到心跳（含 WS Pong 与任意帧）超过上限即判定离线并断开。
+//
+//	PRESENCE_EVENT 由中继在下级上线/下线时发往上级，上级据此更新视图并继续向上转发；中继重连上级后发送全量快照（snapshot=true）。
+//
+// =============================================================
+type Heartbeat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TsMs          int64                  `protobuf:"varint,1,opt,name=ts_ms,json=tsMs,proto3" json:"ts_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Heartbeat) Reset() {
+	*x = Heartbeat{}
+	mi := &file_myflowhub_proto_msgTypes[79]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Heartbeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Heartbeat) ProtoMessage() {}
+
+func (x *Heartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[79]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
+func (*Heartbeat) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{79}
+}
+
+func (x *Heartbeat) GetTsMs() int64 {
+	if x != nil {
+		return x.TsMs
+	}
+	return 0
+}
+
+type PresenceItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceUid     uint64                 `protobuf:"varint,1,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Online        bool                   `protobuf:"varint,2,opt,name=online,proto3" json:"online,omitempty"`
+	LastSeenSec   int64                  `protobuf:"varint,3,opt,name=last_seen_sec,json=lastSeenSec,proto3" json:"last_seen_sec,omitempty"`
+	Via           uint64                 `protobuf:"varint,4,opt,name=via,proto3" json:"via,omitempty"` // 经由的直连中继 UID；0 表示直连本 Hub
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceItem) Reset() {
+	*x = PresenceItem{}
+	mi := &file_myflowhub_proto_msgTypes[80]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceItem) ProtoMessage() {}
+
+func (x *PresenceItem) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[80]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceItem.ProtoReflect.Descriptor instead.
+func (*PresenceItem) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{80}
+}
+
+func (x *PresenceItem) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *PresenceItem) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+func (x *PresenceItem) GetLastSeenSec() int64 {
+	if x != nil {
+		return x.LastSeenSec
+	}
+	return 0
+}
+
+func (x *PresenceItem) GetVia() uint64 {
+	if x != nil {
+		return x.Via
+	}
+	return 0
+}
+
+type PresenceEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*PresenceItem        `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	Snapshot      bool                   `protobuf:"varint,2,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceEvent) Reset() {
+	*x = PresenceEvent{}
+	mi := &file_myflowhub_proto_msgTypes[81]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceEvent) ProtoMessage() {}
+
+func (x *PresenceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[81]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceEvent.ProtoReflect.Descriptor instead.
+func (*PresenceEvent) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{81}
+}
+
+func (x *PresenceEvent) GetItems() []*PresenceItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *PresenceEvent) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+type PresenceQueryReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	DeviceUids    []uint64               `protobuf:"varint,2,rep,packed,name=device_uids,json=deviceUids,proto3" json:"device_uids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceQueryReq) Reset() {
+	*x = PresenceQueryReq{}
+	mi := &file_myflowhub_proto_msgTypes[82]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceQueryReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceQueryReq) ProtoMessage() {}
+
+func (x *PresenceQueryReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[82]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceQueryReq.ProtoReflect.Descriptor instead.
+func (*PresenceQueryReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{82}
+}
+
+func (x *PresenceQueryReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *PresenceQueryReq) GetDeviceUids() []uint64 {
+	if x != nil {
+		return x.DeviceUids
+	}
+	return nil
+}
+
+type PresenceQueryResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Items         []*PresenceItem        `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceQueryResp) Reset() {
+	*x = PresenceQueryResp{}
+	mi := &file_myflowhub_proto_msgTypes[83]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceQueryResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceQueryResp) ProtoMessage() {}
+
+func (x *PresenceQueryResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[83]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceQueryResp.ProtoReflect.Descriptor instead.
+func (*PresenceQueryResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{83}
+}
+
+func (x *PresenceQueryResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *PresenceQueryResp) GetItems() []*PresenceItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// =============================================================
+// 连接会话历史（Device Session）
+// TypeID: 360/361 DEVICE_SESSION_LIST
+// 说明：每条会话对应一次连接（建立→认证→断开）；未认证连接 device_uid=0；ended_at=0 表示仍在连接。
+//
+//	时间均为 epoch 秒；bytes/frames 为业务帧统计（不含 WS 控制帧）；
+//	启用压缩时 bytes 为压缩后字节（permessage-deflate 取套接字读写字节），raw_bytes 为未压缩字节。
+//
+// =============================================================
+type DeviceSessionItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Hub           string                 `protobuf:"bytes,3,opt,name=hub,proto3" json:"hub,omitempty"`                                 // 接入节点 HardwareID
+	ParentPath    string                 `protobuf:"bytes,4,opt,name=parent_path,json=parentPath,proto3" json:"parent_path,omitempty"` // 接入路径："/" 为直连中枢，"/<中继UID>/…" 为经由的中继链（自上而下）
+	RemoteAddr    string                 `protobuf:"bytes,5,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	UserAgent     string                 `protobuf:"bytes,6,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	Protocol      string                 `protobuf:"bytes,7,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	StartedAt     int64                  `protobuf:"varint,8,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	AuthedAt      int64                  `protobuf:"varint,9,opt,name=authed_at,json=authedAt,proto3" json:"authed_at,omitempty"`
+	EndedAt       int64                  `protobuf:"varint,10,opt,name=ended_at,json=endedAt,proto3" json:"ended_at,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,11,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	BytesIn       uint64                 `protobuf:"varint,12,opt,name=bytes_in,json=bytesIn,proto3" json:"bytes_in,omitempty"`
+	BytesOut      uint64                 `protobuf:"varint,13,opt,name=bytes_out,json=bytesOut,proto3" json:"bytes_out,omitempty"`
+	FramesIn      uint64                 `protobuf:"varint,14,opt,name=frames_in,json=framesIn,proto3" json:"frames_in,omitempty"`
+	FramesOut     uint64                 `protobuf:"varint,15,opt,name=frames_out,json=framesOut,proto3" json:"frames_out,omitempty"`
+	CloseReason   string                 `protobuf:"bytes,16,opt,name=close_reason,json=closeReason,proto3" json:"close_reason,omitempty"`
+	RawBytesIn    uint64                 `protobuf:"varint,17,opt,name=raw_bytes_in,json=rawBytesIn,proto3" json:"raw_bytes_in,omitempty"` // 未压缩的二进制帧字节数；bytes_* 为实际传输字节，raw/bytes 即压缩率
+	RawBytesOut   uint64                 `protobuf:"varint,18,opt,name=raw_bytes_out,json=rawBytesOut,proto3" json:"raw_bytes_out,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceSessionItem) Reset() {
+	*x = DeviceSessionItem{}
+	mi := &file_myflowhub_proto_msgTypes[84]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceSessionItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceSessionItem) ProtoMessage() {}
+
+func (x *DeviceSessionItem) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[84]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceSessionItem.ProtoReflect.Descriptor instead.
+func (*DeviceSessionItem) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{84}
+}
+
+func (x *DeviceSessionItem) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetHub() string {
+	if x != nil {
+		return x.Hub
+	}
+	return ""
+}
+
+func (x *DeviceSessionItem) GetParentPath() string {
+	if x != nil {
+		return x.ParentPath
+	}
+	return ""
+}
+
+func (x *DeviceSessionItem) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+func (x *DeviceSessionItem) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *DeviceSessionItem) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *DeviceSessionItem) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetAuthedAt() int64 {
+	if x != nil {
+		return x.AuthedAt
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetEndedAt() int64 {
+	if x != nil {
+		return x.EndedAt
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetBytesIn() uint64 {
+	if x != nil {
+		return x.BytesIn
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetBytesOut() uint64 {
+	if x != nil {
+		return x.BytesOut
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetFramesIn() uint64 {
+	if x != nil {
+		return x.FramesIn
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetFramesOut() uint64 {
+	if x != nil {
+		return x.FramesOut
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetCloseReason() string {
+	if x != nil {
+		return x.CloseReason
+	}
+	return ""
+}
+
+func (x *DeviceSessionItem) GetRawBytesIn() uint64 {
+	if x != nil {
+		return x.RawBytesIn
+	}
+	return 0
+}
+
+func (x *DeviceSessionItem) GetRawBytesOut() uint64 {
+	if x != nil {
+		return x.RawBytesOut
+	}
+	return 0
+}
+
+type DeviceSessionListReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserKey       string                 `protobuf:"bytes,1,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`
+	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceSessionListReq) Reset() {
+	*x = DeviceSessionListReq{}
+	mi := &file_myflowhub_proto_msgTypes[85]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceSessionListReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceSessionListReq) ProtoMessage() {}
+
+func (x *DeviceSessionListReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[85]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceSessionListReq.ProtoReflect.Descriptor instead.
+func (*DeviceSessionListReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{85}
+}
+
+func (x *DeviceSessionListReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *DeviceSessionListReq) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *DeviceSessionListReq) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *DeviceSessionListReq) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type DeviceSessionListResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Items         []*DeviceSessionItem   `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceSessionListResp) Reset() {
+	*x = DeviceSessionListResp{}
+	mi := &file_myflowhub_proto_msgTypes[86]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceSessionListResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceSessionListResp) ProtoMessage() {}
+
+func (x *DeviceSessionListResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[86]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceSessionListResp.ProtoReflect.Descriptor instead.
+func (*DeviceSessionListResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{86}
+}
+
+func (x *DeviceSessionListResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *DeviceSessionListResp) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *DeviceSessionListResp) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *DeviceSessionListResp) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *DeviceSessionListResp) GetItems() []*DeviceSessionItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// =============================================================
+// 设备证书（内置 CA）
+// TypeID: 370/371 CERT_CSR（已认证且已审批的连接提交 CSR，Hub 签发短期客户端证书），
+//
+//	372/373 CERT_CRL（吊销列表，中继周期同步）
+//
+// 说明：签发证书的 Subject CN 固定为请求连接对应设备的 HardwareID（忽略 CSR 中的 Subject）；
+//
+//	证书与 CA 链均为 DER；serial 为十六进制小写字符串。吊销列表仅包含尚未过期的证书。
+//
+// =============================================================
+type CertCsrReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Csr           []byte                 `protobuf:"bytes,1,opt,name=csr,proto3" json:"csr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CertCsrReq) Reset() {
+	*x = CertCsrReq{}
+	mi := &file_myflowhub_proto_msgTypes[87]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CertCsrReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertCsrReq) ProtoMessage() {}
+
+func (x *CertCsrReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[87]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertCsrReq.ProtoReflect.Descriptor instead.
+func (*CertCsrReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{87}
+}
+
+func (x *CertCsrReq) GetCsr() []byte {
+	if x != nil {
+		return x.Csr
+	}
+	return nil
+}
+
+type CertCsrResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Cert          []byte                 `protobuf:"bytes,2,opt,name=cert,proto3" json:"cert,omitempty"`   // 签发的客户端证书（DER）
+	Chain         [][]byte               `protobuf:"bytes,3,rep,name=chain,proto3" json:"chain,omitempty"` // 签发 CA 及其上级（DER，由近及远）
+	Serial        string                 `protobuf:"bytes,4,opt,name=serial,proto3" json:"serial,omitempty"`
+	NotAfter      int64                  `protobuf:"varint,5,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"` // epoch 秒
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CertCsrResp) Reset() {
+	*x = CertCsrResp{}
+	mi := &file_myflowhub_proto_msgTypes[88]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CertCsrResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertCsrResp) ProtoMessage() {}
+
+func (x *CertCsrResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[88]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertCsrResp.ProtoReflect.Descriptor instead.
+func (*CertCsrResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{88}
+}
+
+func (x *CertCsrResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *CertCsrResp) GetCert() []byte {
+	if x != nil {
+		return x.Cert
+	}
+	return nil
+}
+
+func (x *CertCsrResp) GetChain() [][]byte {
+	if x != nil {
+		return x.Chain
+	}
+	return nil
+}
+
+func (x *CertCsrResp) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *CertCsrResp) GetNotAfter() int64 {
+	if x != nil {
+		return x.NotAfter
+	}
+	return 0
+}
+
+type CertRevokedItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Serial        string                 `protobuf:"bytes,1,opt,name=serial,proto3" json:"serial,omitempty"`
+	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	RevokedAt     int64                  `protobuf:"varint,3,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"`
+	NotAfter      int64                  `protobuf:"varint,4,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CertRevokedItem) Reset() {
+	*x = CertRevokedItem{}
+	mi := &file_myflowhub_proto_msgTypes[89]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CertRevokedItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertRevokedItem) ProtoMessage() {}
+
+func (x *CertRevokedItem) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[89]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertRevokedItem.ProtoReflect.Descriptor instead.
+func (*CertRevokedItem) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{89}
+}
+
+func (x *CertRevokedItem) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *CertRevokedItem) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *CertRevokedItem) GetRevokedAt() int64 {
+	if x != nil {
+		return x.RevokedAt
+	}
+	return 0
+}
+
+func (x *CertRevokedItem) GetNotAfter() int64 {
+	if x != nil {
+		return x.NotAfter
+	}
+	return 0
+}
+
+type CertCrlReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CertCrlReq) Reset() {
+	*x = CertCrlReq{}
+	mi := &file_myflowhub_proto_msgTypes[90]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CertCrlReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertCrlReq) ProtoMessage() {}
+
+func (x *CertCrlReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[90]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertCrlReq.ProtoReflect.Descriptor instead.
+func (*CertCrlReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{90}
+}
+
+type CertCrlResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Items         []*CertRevokedItem     `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	GeneratedAt   int64                  `protobuf:"varint,3,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CertCrlResp) Reset() {
+	*x = CertCrlResp{}
+	mi := &file_myflowhub_proto_msgTypes[91]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CertCrlResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertCrlResp) ProtoMessage() {}
+
+func (x *CertCrlResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[91]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertCrlResp.ProtoReflect.Descriptor instead.
+func (*CertCrlResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{91}
+}
+
+func (x *CertCrlResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *CertCrlResp) GetItems() []*CertRevokedItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *CertCrlResp) GetGeneratedAt() int64 {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return 0
+}
+
+// =============================================================
+// 设备公钥认证（Ed25519 挑战-应答）
+// TypeID: 380/381 DEVICE_CHALLENGE（未认证连接按 hardware_id 申请一次性 nonce），
+//
+//	382/383 DEVICE_AUTH（对 nonce 签名完成认证），
+//	384 DEVICE_KEY_SET（登记/轮换公钥，返回 OK_RESP）
+//
+// 签名内容："myflowhub-device-auth-v1\0" + nonce + hardware_id；
+// 轮换签名："myflowhub-key-rotate-v1\0" + device_uid(u64 LE) + new public_key（由旧私钥签名）。
+// =============================================================
+type DeviceChallengeReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HardwareId    string                 `protobuf:"bytes,1,opt,name=hardware_id,json=hardwareId,proto3" json:"hardware_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceChallengeReq) Reset() {
+	*x = DeviceChallengeReq{}
+	mi := &file_myflowhub_proto_msgTypes[92]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceChallengeReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceChallengeReq) ProtoMessage() {}
+
+func (x *DeviceChallengeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[92]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceChallengeReq.ProtoReflect.Descriptor instead.
+func (*DeviceChallengeReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{92}
+}
+
+func (x *DeviceChallengeReq) GetHardwareId() string {
+	if x != nil {
+		return x.HardwareId
+	}
+	return ""
+}
+
+type DeviceChallengeResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Nonce         []byte                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	FrameMac      bool                   `protobuf:"varint,4,opt,name=frame_mac,json=frameMac,proto3" json:"frame_mac,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceChallengeResp) Reset() {
+	*x = DeviceChallengeResp{}
+	mi := &file_myflowhub_proto_msgTypes[93]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceChallengeResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceChallengeResp) ProtoMessage() {}
+
+func (x *DeviceChallengeResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[93]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceChallengeResp.ProtoReflect.Descriptor instead.
+func (*DeviceChallengeResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{93}
+}
+
+func (x *DeviceChallengeResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *DeviceChallengeResp) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *DeviceChallengeResp) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *DeviceChallengeResp) GetFrameMac() bool {
+	if x != nil {
+		return x.FrameMac
+	}
+	return false
+}
+
+type DeviceAuthReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HardwareId    string                 `protobuf:"bytes,1,opt,name=hardware_id,json=hardwareId,proto3" json:"hardware_id,omitempty"`
+	Signature     []byte                 `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	FrameMacPub   []byte                 `protobuf:"bytes,3,opt,name=frame_mac_pub,json=frameMacPub,proto3" json:"frame_mac_pub,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceAuthReq) Reset() {
+	*x = DeviceAuthReq{}
+	mi := &file_myflowhub_proto_msgTypes[94]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceAuthReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceAuthReq) ProtoMessage() {}
+
+func (x *DeviceAuthReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[94]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceAuthReq.ProtoReflect.Descriptor instead.
+func (*DeviceAuthReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{94}
+}
+
+func (x *DeviceAuthReq) GetHardwareId() string {
+	if x != nil {
+		return x.HardwareId
+	}
+	return ""
+}
+
+func (x *DeviceAuthReq) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *DeviceAuthReq) GetFrameMacPub() []byte {
+	if x != nil {
+		return x.FrameMacPub
+	}
+	return nil
+}
+
+type DeviceAuthResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	HeartbeatSec  uint32                 `protobuf:"varint,3,opt,name=heartbeat_sec,json=heartbeatSec,proto3" json:"heartbeat_sec,omitempty"`
+	FrameMacPub   []byte                 `protobuf:"bytes,4,opt,name=frame_mac_pub,json=frameMacPub,proto3" json:"frame_mac_pub,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceAuthResp) Reset() {
+	*x = DeviceAuthResp{}
+	mi := &file_myflowhub_proto_msgTypes[95]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceAuthResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceAuthResp) ProtoMessage() {}
+
+func (x *DeviceAuthResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[95]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceAuthResp.ProtoReflect.Descriptor instead.
+func (*DeviceAuthResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{95}
+}
+
+func (x *DeviceAuthResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *DeviceAuthResp) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *DeviceAuthResp) GetHeartbeatSec() uint32 {
+	if x != nil {
+		return x.HeartbeatSec
+	}
+	return 0
+}
+
+func (x *DeviceAuthResp) GetFrameMacPub() []byte {
+	if x != nil {
+		return x.FrameMacPub
+	}
+	return nil
+}
+
+type DeviceKeySetReq struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	DeviceUid uint64                 `protobuf:"varint,1,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	PublicKey []byte                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"` // Ed25519 公钥（32 字节）
+	// 以下凭据任选其一
+	OldKeySig     []byte `protobuf:"bytes,3,opt,name=old_key_sig,json=oldKeySig,proto3" json:"old_key_sig,omitempty"` // 轮换：旧私钥对轮换内容的签名
+	Secret        string `protobuf:"bytes,4,opt,name=secret,proto3" json:"secret,omitempty"`                          // 迁移：设备尚未登记公钥时以旧设备密钥证明身份（需允许密钥认证）
+	UserKey       string `protobuf:"bytes,5,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`         // 管理：对该设备有控制权的用户
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceKeySetReq) Reset() {
+	*x = DeviceKeySetReq{}
+	mi := &file_myflowhub_proto_msgTypes[96]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceKeySetReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceKeySetReq) ProtoMessage() {}
+
+func (x *DeviceKeySetReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[96]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceKeySetReq.ProtoReflect.Descriptor instead.
+func (*DeviceKeySetReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{96}
+}
+
+func (x *DeviceKeySetReq) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *DeviceKeySetReq) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *DeviceKeySetReq) GetOldKeySig() []byte {
+	if x != nil {
+		return x.OldKeySig
+	}
+	return nil
+}
+
+func (x *DeviceKeySetReq) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *DeviceKeySetReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+// =============================================================
+// 设备密钥轮换
+// TypeID: 390 DEVICE_ROTATE_SECRET_REQ（已认证设备轮换自身密钥，或以 user_key 由有控制权的用户轮换），
+//
+//	391 DEVICE_ROTATE_SECRET_RESP（返回新密钥明文，仅此一次）
+//
+// 说明：宽限期内新旧密钥均可认证；force=true 仅管理员可用，旧密钥立即失效并断开设备当前连接。
+// =============================================================
+type DeviceRotateSecretReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceUid     uint64                 `protobuf:"varint,1,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	UserKey       string                 `protobuf:"bytes,2,opt,name=user_key,json=userKey,proto3" json:"user_key,omitempty"`     // 为空时仅能轮换请求连接自身
+	GraceSec      uint32                 `protobuf:"varint,3,opt,name=grace_sec,json=graceSec,proto3" json:"grace_sec,omitempty"` // 旧密钥继续有效的秒数；0 使用服务端默认值
+	Force         bool                   `protobuf:"varint,4,opt,name=force,proto3" json:"force,omitempty"`                       // 强制轮换（管理员）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceRotateSecretReq) Reset() {
+	*x = DeviceRotateSecretReq{}
+	mi := &file_myflowhub_proto_msgTypes[97]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceRotateSecretReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceRotateSecretReq) ProtoMessage() {}
+
+func (x *DeviceRotateSecretReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[97]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceRotateSecretReq.ProtoReflect.Descriptor instead.
+func (*DeviceRotateSecretReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{97}
+}
+
+func (x *DeviceRotateSecretReq) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *DeviceRotateSecretReq) GetUserKey() string {
+	if x != nil {
+		return x.UserKey
+	}
+	return ""
+}
+
+func (x *DeviceRotateSecretReq) GetGraceSec() uint32 {
+	if x != nil {
+		return x.GraceSec
+	}
+	return 0
+}
+
+func (x *DeviceRotateSecretReq) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type DeviceRotateSecretResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Secret        string                 `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+	GraceUntil    int64                  `protobuf:"varint,4,opt,name=grace_until,json=graceUntil,proto3" json:"grace_until,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceRotateSecretResp) Reset() {
+	*x = DeviceRotateSecretResp{}
+	mi := &file_myflowhub_proto_msgTypes[98]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceRotateSecretResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceRotateSecretResp) ProtoMessage() {}
+
+func (x *DeviceRotateSecretResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[98]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceRotateSecretResp.ProtoReflect.Descriptor instead.
+func (*DeviceRotateSecretResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{98}
+}
+
+func (x *DeviceRotateSecretResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *DeviceRotateSecretResp) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *DeviceRotateSecretResp) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *DeviceRotateSecretResp) GetGraceUntil() int64 {
+	if x != nil {
+		return x.GraceUntil
+	}
+	return 0
+}
+
+// =============================================================
+// 端到端加密（E2E）公钥目录
+// TypeID: 400 E2E_KEY_PUBLISH_REQ（已认证设备发布自身 X25519 公钥，返回 OK_RESP），
+//
+//	401 E2E_KEY_GET_REQ / 402 E2E_KEY_GET_RESP（按设备 UID 查询公钥），
+//	403 E2E_KEY_CHANGED_NOTIFY（公钥变化时推送给查询过该公钥的设备）
+//
+// 说明：MSG_SEND 置 Header.Flags 的 E2E 位时负载按 binproto.SealE2E 加密，Hub 与中继仅透传。
+// =============================================================
+type E2EKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceUid     uint64                 `protobuf:"varint,1,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	PublicKey     []byte                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`  // X25519 公钥（32 字节）
+	UpdatedAt     int64                  `protobuf:"varint,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // epoch ms
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *E2EKey) Reset() {
+	*x = E2EKey{}
+	mi := &file_myflowhub_proto_msgTypes[99]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *E2EKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*E2EKey) ProtoMessage() {}
+
+func (x *E2EKey) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[99]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use E2EKey.ProtoReflect.Descriptor instead.
+func (*E2EKey) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{99}
+}
+
+func (x *E2EKey) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *E2EKey) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *E2EKey) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type E2EKeyPublishReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PublicKey     []byte                 `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *E2EKeyPublishReq) Reset() {
+	*x = E2EKeyPublishReq{}
+	mi := &file_myflowhub_proto_msgTypes[100]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *E2EKeyPublishReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*E2EKeyPublishReq) ProtoMessage() {}
+
+func (x *E2EKeyPublishReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[100]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use E2EKeyPublishReq.ProtoReflect.Descriptor instead.
+func (*E2EKeyPublishReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{100}
+}
+
+func (x *E2EKeyPublishReq) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+type E2EKeyGetReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceUids    []uint64               `protobuf:"varint,1,rep,packed,name=device_uids,json=deviceUids,proto3" json:"device_uids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *E2EKeyGetReq) Reset() {
+	*x = E2EKeyGetReq{}
+	mi := &file_myflowhub_proto_msgTypes[101]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *E2EKeyGetReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*E2EKeyGetReq) ProtoMessage() {}
+
+func (x *E2EKeyGetReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[101]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use E2EKeyGetReq.ProtoReflect.Descriptor instead.
+func (*E2EKeyGetReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{101}
+}
+
+func (x *E2EKeyGetReq) GetDeviceUids() []uint64 {
+	if x != nil {
+		return x.DeviceUids
+	}
+	return nil
+}
+
+type E2EKeyGetResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Keys          []*E2EKey              `protobuf:"bytes,2,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *E2EKeyGetResp) Reset() {
+	*x = E2EKeyGetResp{}
+	mi := &file_myflowhub_proto_msgTypes[102]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *E2EKeyGetResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*E2EKeyGetResp) ProtoMessage() {}
+
+func (x *E2EKeyGetResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[102]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use E2EKeyGetResp.ProtoReflect.Descriptor instead.
+func (*E2EKeyGetResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{102}
+}
+
+func (x *E2EKeyGetResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *E2EKeyGetResp) GetKeys() []*E2EKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type E2EKeyChangedNotify struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*E2EKey              `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *E2EKeyChangedNotify) Reset() {
+	*x = E2EKeyChangedNotify{}
+	mi := &file_myflowhub_proto_msgTypes[103]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *E2EKeyChangedNotify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*E2EKeyChangedNotify) ProtoMessage() {}
+
+func (x *E2EKeyChangedNotify) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[103]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use E2EKeyChangedNotify.ProtoReflect.Descriptor instead.
+func (*E2EKeyChangedNotify) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{103}
+}
+
+func (x *E2EKeyChangedNotify) GetKeys() []*E2EKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+// =============================================================
+// 连接握手（HELLO）
+// TypeID: 410 HELLO_REQ / 411 HELLO_RESP
+// 说明：连接建立后、认证之前可选发送一次；服务端回复双方能力的交集、自身标识与限制。
+//
+//	未发送 HELLO 的旧客户端按 v1 且支持全部可选特性处理（帧级压缩除外，须经 HELLO 协商）。
+//
+// =============================================================
+type HelloLimits struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	MaxFrameBytes   uint32                 `protobuf:"varint,1,opt,name=max_frame_bytes,json=maxFrameBytes,proto3" json:"max_frame_bytes,omitempty"`       // 单个线上帧上限（含帧头），0 表示未声明
+	MaxMessageBytes uint32                 `protobuf:"varint,2,opt,name=max_message_bytes,json=maxMessageBytes,proto3" json:"max_message_bytes,omitempty"` // 单条消息负载上限（v2 分片重组后），0 表示未声明
+	MaxPending      uint32                 `protobuf:"varint,3,opt,name=max_pending,json=maxPending,proto3" json:"max_pending,omitempty"`                  // 同时重组中的分片消息数上限，0 表示未声明
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HelloLimits) Reset() {
+	*x = HelloLimits{}
+	mi := &file_myflowhub_proto_msgTypes[104]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloLimits) ProtoMessage() {}
+
+func (x *HelloLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[104]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloLimits.ProtoReflect.Descriptor instead.
+func (*HelloLimits) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{104}
+}
+
+func (x *HelloLimits) GetMaxFrameBytes() uint32 {
+	if x != nil {
+		return x.MaxFrameBytes
+	}
+	return 0
+}
+
+func (x *HelloLimits) GetMaxMessageBytes() uint32 {
+	if x != nil {
+		return x.MaxMessageBytes
+	}
+	return 0
+}
+
+func (x *HelloLimits) GetMaxPending() uint32 {
+	if x != nil {
+		return x.MaxPending
+	}
+	return 0
+}
+
+type HelloReq struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Versions         []uint32               `protobuf:"varint,1,rep,packed,name=versions,proto3" json:"versions,omitempty"`              // 支持的协议版本（如 1、2）
+	Features         []string               `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty"`                      // 可选特性：relay、compression、ack、pubsub、file、e2e、resume、deadline
+	TypeIds          []uint32               `protobuf:"varint,3,rep,packed,name=type_ids,json=typeIds,proto3" json:"type_ids,omitempty"` // 能处理的下行 TypeID，为空表示不限制
+	Limits           *HelloLimits           `protobuf:"bytes,4,opt,name=limits,proto3" json:"limits,omitempty"`
+	ClientName       string                 `protobuf:"bytes,5,opt,name=client_name,json=clientName,proto3" json:"client_name,omitempty"`                           // 客户端名称与版本（仅用于日志与诊断）
+	CompressionDicts []uint32               `protobuf:"varint,6,rep,packed,name=compression_dicts,json=compressionDicts,proto3" json:"compression_dicts,omitempty"` // 持有的帧级压缩预置字典 ID（见 binproto.CompressionDict）
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *HelloReq) Reset() {
+	*x = HelloReq{}
+	mi := &file_myflowhub_proto_msgTypes[105]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloReq) ProtoMessage() {}
+
+func (x *HelloReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[105]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloReq.ProtoReflect.Descriptor instead.
+func (*HelloReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{105}
+}
+
+func (x *HelloReq) GetVersions() []uint32 {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+func (x *HelloReq) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *HelloReq) GetTypeIds() []uint32 {
+	if x != nil {
+		return x.TypeIds
+	}
+	return nil
+}
+
+func (x *HelloReq) GetLimits() *HelloLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *HelloReq) GetClientName() string {
+	if x != nil {
+		return x.ClientName
+	}
+	return ""
+}
+
+func (x *HelloReq) GetCompressionDicts() []uint32 {
+	if x != nil {
+		return x.CompressionDicts
+	}
+	return nil
+}
+
+type HelloResp struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	RequestId        uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Version          uint32                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`                                                  // 选定的协议版本（双方共同支持的最高版本）
+	Features         []string               `protobuf:"bytes,3,rep,name=features,proto3" json:"features,omitempty"`                                                 // 双方共同支持的特性
+	TypeIds          []uint32               `protobuf:"varint,4,rep,packed,name=type_ids,json=typeIds,proto3" json:"type_ids,omitempty"`                            // 双方共同支持的 TypeID（请求未列出时为空）
+	Limits           *HelloLimits           `protobuf:"bytes,5,opt,name=limits,proto3" json:"limits,omitempty"`                                                     // 服务端限制
+	HardwareId       string                 `protobuf:"bytes,6,opt,name=hardware_id,json=hardwareId,proto3" json:"hardware_id,omitempty"`                           // 服务端硬件标识
+	DeviceUid        uint64                 `protobuf:"varint,7,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`                             // 服务端设备 UID
+	HeartbeatSec     uint32                 `protobuf:"varint,8,opt,name=heartbeat_sec,json=heartbeatSec,proto3" json:"heartbeat_sec,omitempty"`                    // 建议心跳间隔（秒）
+	CompressionDicts []uint32               `protobuf:"varint,9,rep,packed,name=compression_dicts,json=compressionDicts,proto3" json:"compression_dicts,omitempty"` // 帧级压缩选用的预置字典 ID（至多一个，未协商 compression 时为空）
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *HelloResp) Reset() {
+	*x = HelloResp{}
+	mi := &file_myflowhub_proto_msgTypes[106]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloResp) ProtoMessage() {}
+
+func (x *HelloResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[106]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloResp.ProtoReflect.Descriptor instead.
+func (*HelloResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{106}
+}
+
+func (x *HelloResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *HelloResp) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *HelloResp) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *HelloResp) GetTypeIds() []uint32 {
+	if x != nil {
+		return x.TypeIds
+	}
+	return nil
+}
+
+func (x *HelloResp) GetLimits() *HelloLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *HelloResp) GetHardwareId() string {
+	if x != nil {
+		return x.HardwareId
+	}
+	return ""
+}
+
+func (x *HelloResp) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *HelloResp) GetHeartbeatSec() uint32 {
+	if x != nil {
+		return x.HeartbeatSec
+	}
+	return 0
+}
+
+func (x *HelloResp) GetCompressionDicts() []uint32 {
+	if x != nil {
+		return x.CompressionDicts
+	}
+	return nil
+}
+
+// =============================================================
+// 会话恢复（RESUME）
+// TypeID: 412 RESUME_TOKEN_NOTIFY / 413 RESUME_REQ / 414 RESUME_RESP
+// 说明：HELLO 协商 resume 特性的连接认证后，Hub 下发恢复令牌，并按序号缓存发往该会话的帧（含断线宽限期内到达的帧）。
+//
+//	序号：收到 RESUME_TOKEN_NOTIFY / RESUME_RESP 后客户端将计数置为其中的 seq，此后每收到一条下行消息（v2 分片重组后）计数加一。
+//	断线重连后在认证前发送 RESUME_REQ（可先 HELLO），成功则恢复设备身份与订阅并按序重放 last_seq 之后的帧；
+//	令牌无效或已过期返回 ERR 401，客户端应改为完整认证。
+//
+// =============================================================
+type ResumeTokenNotify struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`                        // 恢复令牌（每次恢复后更换）
+	Seq           uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`                           // 当前序号，此后的下行消息从 seq+1 编号
+	GraceSec      uint32                 `protobuf:"varint,3,opt,name=grace_sec,json=graceSec,proto3" json:"grace_sec,omitempty"` // 断线后会话保留的时长（秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeTokenNotify) Reset() {
+	*x = ResumeTokenNotify{}
+	mi := &file_myflowhub_proto_msgTypes[107]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeTokenNotify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeTokenNotify) ProtoMessage() {}
+
+func (x *ResumeTokenNotify) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[107]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeTokenNotify.ProtoReflect.Descriptor instead.
+func (*ResumeTokenNotify) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{107}
+}
+
+func (x *ResumeTokenNotify) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ResumeTokenNotify) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ResumeTokenNotify) GetGraceSec() uint32 {
+	if x != nil {
+		return x.GraceSec
+	}
+	return 0
+}
+
+type ResumeReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	LastSeq       uint64                 `protobuf:"varint,2,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`              // 已收到的最后一条下行消息的序号
+	FrameMacPub   []byte                 `protobuf:"bytes,3,opt,name=frame_mac_pub,json=frameMacPub,proto3" json:"frame_mac_pub,omitempty"` // 帧认证临时公钥（X25519，可选；以令牌为绑定值）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeReq) Reset() {
+	*x = ResumeReq{}
+	mi := &file_myflowhub_proto_msgTypes[108]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeReq) ProtoMessage() {}
+
+func (x *ResumeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[108]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeReq.ProtoReflect.Descriptor instead.
+func (*ResumeReq) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{108}
+}
+
+func (x *ResumeReq) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ResumeReq) GetLastSeq() uint64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+func (x *ResumeReq) GetFrameMacPub() []byte {
+	if x != nil {
+		return x.FrameMacPub
+	}
+	return nil
+}
+
+type ResumeResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	DeviceUid     uint64                 `protobuf:"varint,2,opt,name=device_uid,json=deviceUid,proto3" json:"device_uid,omitempty"`
+	Token         string                 `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`        // 新令牌（原令牌随即失效）
+	Seq           uint64                 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`           // 重放起点：随后依次重放 seq+1 起的帧；大于 last_seq 时其间的帧已超出缓存而丢失
+	Replayed      uint32                 `protobuf:"varint,5,opt,name=replayed,proto3" json:"replayed,omitempty"` // 随后重放的帧数
+	GraceSec      uint32                 `protobuf:"varint,6,opt,name=grace_sec,json=graceSec,proto3" json:"grace_sec,omitempty"`
+	FrameMacPub   []byte                 `protobuf:"bytes,7,opt,name=frame_mac_pub,json=frameMacPub,proto3" json:"frame_mac_pub,omitempty"` // 服务端帧认证临时公钥（未启用时为空）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeResp) Reset() {
+	*x = ResumeResp{}
+	mi := &file_myflowhub_proto_msgTypes[109]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeResp) ProtoMessage() {}
+
+func (x *ResumeResp) ProtoReflect() protoreflect.Message {
+	mi := &file_myflowhub_proto_msgTypes[109]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeResp.ProtoReflect.Descriptor instead.
+func (*ResumeResp) Descriptor() ([]byte, []int) {
+	return file_myflowhub_proto_rawDescGZIP(), []int{109}
+}
+
+func (x *ResumeResp) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *ResumeResp) GetDeviceUid() uint64 {
+	if x != nil {
+		return x.DeviceUid
+	}
+	return 0
+}
+
+func (x *ResumeResp) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ResumeResp) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ResumeResp) GetReplayed() uint32 {
+	if x != nil {
+		return x.Replayed
+	}
+	return 0
+}
+
+func (x *ResumeResp) GetGraceSec() uint32 {
+	if x != nil {
+		return x.GraceSec
+	}
+	return 0
+}
+
+func (x *ResumeResp) GetFrameMacPub() []byte {
+	if x != nil {
+		return x.FrameMacPub
+	}
+	return nil
+}
+
+var File_myflowhub_proto protoreflect.FileDescriptor
+
+const file_myflowhub_proto_rawDesc = "" +
+	"\n" +
+	"\x0fmyflowhub.proto\x12\fmyflowhub.v1\"U\n" +
+	"\x06OKResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\fR\amessage\"V\n" +
+	"\aErrResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\fR\amessage\"\"\n" +
+	"\tCancelReq\x12\x15\n" +
+	"\x06msg_id\x18\x01 \x01(\x04R\x05msgId\"J\n" +
+	"\x0eManagerAuthReq\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\"\n" +
+	"\rframe_mac_pub\x18\x02 \x01(\fR\vframeMacPub\"\x87\x01\n" +
+	"\x0fManagerAuthResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\x12\"\n" +
+	"\rframe_mac_pub\x18\x04 \x01(\fR\vframeMacPub\"F\n" +
+	"\fUserLoginReq\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"\xd7\x01\n" +
+	"\rUserLoginResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x15\n" +
+	"\x06key_id\x18\x02 \x01(\x04R\x05keyId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x04R\x06userId\x12\x16\n" +
+	"\x06secret\x18\x04 \x01(\tR\x06secret\x12\x1a\n" +
+	"\busername\x18\x05 \x01(\tR\busername\x12!\n" +
+	"\fdisplay_name\x18\x06 \x01(\tR\vdisplayName\x12 \n" +
+	"\vpermissions\x18\a \x03(\tR\vpermissions\"&\n" +
+	"\tUserMeReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\"\xa5\x01\n" +
+	"\n" +
+	"UserMeResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x12!\n" +
+	"\fdisplay_name\x18\x04 \x01(\tR\vdisplayName\x12 \n" +
+	"\vpermissions\x18\x05 \x03(\tR\vpermissions\"*\n" +
+	"\rUserLogoutReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\"\xc1\x01\n" +
+	"\bUserItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12!\n" +
+	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12\x1a\n" +
+	"\bdisabled\x18\x04 \x01(\bR\bdisabled\x12$\n" +
+	"\x0ecreated_at_sec\x18\x05 \x01(\x03R\fcreatedAtSec\x12$\n" +
+	"\x0eupdated_at_sec\x18\x06 \x01(\x03R\fupdatedAtSec\"(\n" +
+	"\vUserListReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\"[\n" +
+	"\fUserListResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12,\n" +
+	"\x05users\x18\x02 \x03(\v2\x16.myflowhub.v1.UserItemR\x05users\"\x85\x01\n" +
+	"\rUserCreateReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12!\n" +
+	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12\x1a\n" +
+	"\bpassword\x18\x04 \x01(\tR\bpassword\"H\n" +
+	"\x0eUserCreateResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\"\xcf\x01\n" +
+	"\rUserUpdateReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x04R\x02id\x12&\n" +
+	"\fdisplay_name\x18\x03 \x01(\tH\x00R\vdisplayName\x88\x01\x01\x12\x1f\n" +
+	"\bpassword\x18\x04 \x01(\tH\x01R\bpassword\x88\x01\x01\x12\x1f\n" +
+	"\bdisabled\x18\x05 \x01(\bH\x02R\bdisabled\x88\x01\x01B\x0f\n" +
+	"\r_display_nameB\v\n" +
+	"\t_passwordB\v\n" +
+	"\t_disabled\":\n" +
+	"\rUserDeleteReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x04R\x02id\"E\n" +
+	"\x0fUserPermListReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\"G\n" +
+	"\x10UserPermListResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x14\n" +
+	"\x05nodes\x18\x02 \x03(\tR\x05nodes\"X\n" +
+	"\x0eUserPermAddReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x12\n" +
+	"\x04node\x18\x03 \x01(\tR\x04node\"[\n" +
+	"\x11UserPermRemoveReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x12\n" +
+	"\x04node\x18\x03 \x01(\tR\x04node\"Q\n" +
+	"\x11UserSelfUpdateReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\"v\n" +
+	"\x13UserSelfPasswordReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12!\n" +
+	"\fold_password\x18\x02 \x01(\tR\voldPassword\x12!\n" +
+	"\fnew_password\x18\x03 \x01(\tR\vnewPassword\"\xee\x03\n" +
+	"\n" +
+	"DeviceItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x1f\n" +
+	"\vhardware_id\x18\x03 \x01(\tR\n" +
+	"hardwareId\x12\x12\n" +
+	"\x04role\x18\x04 \x01(\tR\x04role\x12\x12\n" +
+	"\x04name\x18\x05 \x01(\tR\x04name\x12 \n" +
+	"\tparent_id\x18\x06 \x01(\x04H\x00R\bparentId\x88\x01\x01\x12'\n" +
+	"\rowner_user_id\x18\a \x01(\x04H\x01R\vownerUserId\x88\x01\x01\x12'\n" +
+	"\rlast_seen_sec\x18\b \x01(\x03H\x02R\vlastSeenSec\x88\x01\x01\x12$\n" +
+	"\x0ecreated_at_sec\x18\t \x01(\x03R\fcreatedAtSec\x12$\n" +
+	"\x0eupdated_at_sec\x18\n" +
+	" \x01(\x03R\fupdatedAtSec\x12\x1f\n" +
+	"\bapproved\x18\v \x01(\bH\x03R\bapproved\x88\x01\x01\x12\x1b\n" +
+	"\x06online\x18\f \x01(\bH\x04R\x06online\x88\x01\x01\x12\x17\n" +
+	"\x04tags\x18\r \x01(\tH\x05R\x04tags\x88\x01\x01B\f\n" +
+	"\n" +
+	"_parent_idB\x10\n" +
+	"\x0e_owner_user_idB\x10\n" +
+	"\x0e_last_seen_secB\v\n" +
+	"\t_approvedB\t\n" +
+	"\a_onlineB\a\n" +
+	"\x05_tags\"<\n" +
+	"\rQueryNodesReq\x12\x1e\n" +
+	"\buser_key\x18\x01 \x01(\tH\x00R\auserKey\x88\x01\x01B\v\n" +
+	"\t_user_key\"c\n" +
+	"\x0eQueryNodesResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x122\n" +
 	"\adevices\x18\x02 \x03(\v2\x18.myflowhub.v1.DeviceItemR\adevices\"p\n" +
 	"\x0fCreateDeviceReq\x12\x1e\n" +
 	"\buser_key\x18\x01 \x01(\tH\x00R\auserKey\x88\x01\x01\x120\n" +
@@ -3339,7 +7520,10 @@ const file_myflowhub_proto_rawDesc = "" +
 	"\fVarDeleteReq\x12\x1e\n" +
 	"\buser_key\x18\x01 \x01(\tH\x00R\auserKey\x88\x01\x01\x121\n" +
 	"\x05items\x18\x02 \x03(\v2\x1b.myflowhub.v1.VarDeleteItemR\x05itemsB\v\n" +
-	"\t_user_key\"\xa9\x04\n" +
+	"\t_user_key\"q\n" +
+	"\x10VarChangedNotify\x12*\n" +
+	"\x11source_device_uid\x18\x01 \x01(\x04R\x0fsourceDeviceUid\x121\n" +
+	"\x05items\x18\x02 \x03(\v2\x1b.myflowhub.v1.VarUpdateItemR\x05items\"\xa9\x04\n" +
 	"\aKeyItem\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x04R\x02id\x12'\n" +
 	"\rowner_user_id\x18\x02 \x01(\x04H\x00R\vownerUserId\x88\x01\x01\x12/\n" +
@@ -3425,7 +7609,7 @@ const file_myflowhub_proto_rawDesc = "" +
 	"\x05total\x18\x02 \x01(\x03R\x05total\x12\x12\n" +
 	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
 	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12/\n" +
-	"\x04logs\x18\x05 \x03(\v2\x1b.myflowhub.v1.SystemLogItemR\x04logs\"\x9b\x01\n" +
+	"\x04logs\x18\x05 \x03(\v2\x1b.myflowhub.v1.SystemLogItemR\x04logs\"\xbf\x01\n" +
 	"\rParentAuthReq\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\rR\aversion\x12\x13\n" +
 	"\x05ts_ms\x18\x02 \x01(\x03R\x04tsMs\x12\x14\n" +
@@ -3433,7 +7617,8 @@ const file_myflowhub_proto_rawDesc = "" +
 	"\vhardware_id\x18\x04 \x01(\tR\n" +
 	"hardwareId\x12\x12\n" +
 	"\x04caps\x18\x05 \x01(\tR\x04caps\x12\x10\n" +
-	"\x03sig\x18\x06 \x01(\fR\x03sig\"\xcc\x01\n" +
+	"\x03sig\x18\x06 \x01(\fR\x03sig\x12\"\n" +
+	"\rframe_mac_pub\x18\a \x01(\fR\vframeMacPub\"\xf0\x01\n" +
 	"\x0eParentAuthResp\x12\x1d\n" +
 	"\n" +
 	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1d\n" +
@@ -3444,7 +7629,389 @@ const file_myflowhub_proto_rawDesc = "" +
 	"\rheartbeat_sec\x18\x04 \x01(\rR\fheartbeatSec\x12\x14\n" +
 	"\x05perms\x18\x05 \x03(\tR\x05perms\x12\x10\n" +
 	"\x03exp\x18\x06 \x01(\x03R\x03exp\x12\x10\n" +
-	"\x03sig\x18\a \x01(\fR\x03sigB\x1eZ\x1cmyflowhub/pkg/protocol/pb;pbb\x06proto3"
+	"\x03sig\x18\a \x01(\fR\x03sig\x12\"\n" +
+	"\rframe_mac_pub\x18\b \x01(\fR\vframeMacPub\"\xea\x01\n" +
+	"\vFileInitReq\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\x04R\n" +
+	"transferId\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x02 \x01(\x04R\ttotalSize\x12\x12\n" +
+	"\x04mime\x18\x03 \x01(\tR\x04mime\x12\x1a\n" +
+	"\bfilename\x18\x04 \x01(\tR\bfilename\x12\x1b\n" +
+	"\tfile_hash\x18\x05 \x01(\fR\bfileHash\x12!\n" +
+	"\tthumbnail\x18\x06 \x01(\fH\x00R\tthumbnail\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\a \x01(\rR\tchunkSizeB\f\n" +
+	"\n" +
+	"_thumbnail\"\xd1\x01\n" +
+	"\fFileInitResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1f\n" +
+	"\vtransfer_id\x18\x02 \x01(\x04R\n" +
+	"transferId\x12\x0e\n" +
+	"\x02ok\x18\x03 \x01(\bR\x02ok\x12(\n" +
+	"\rresume_offset\x18\x04 \x01(\x04H\x00R\fresumeOffset\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x05 \x01(\rR\tchunkSize\x12\x16\n" +
+	"\x06window\x18\x06 \x01(\rR\x06windowB\x10\n" +
+	"\x0e_resume_offset\"\x7f\n" +
+	"\tFileChunk\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\x04R\n" +
+	"transferId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05chunk\x18\x03 \x01(\fR\x05chunk\x12\x19\n" +
+	"\x05crc32\x18\x04 \x01(\rH\x00R\x05crc32\x88\x01\x01B\b\n" +
+	"\x06_crc32\"P\n" +
+	"\fFileChunkAck\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\x04R\n" +
+	"transferId\x12\x1f\n" +
+	"\vnext_offset\x18\x02 \x01(\x04R\n" +
+	"nextOffset\"2\n" +
+	"\x0fFileCompleteReq\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\x04R\n" +
+	"transferId\"{\n" +
+	"\x10FileCompleteResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1f\n" +
+	"\vtransfer_id\x18\x02 \x01(\x04R\n" +
+	"transferId\x12\x0e\n" +
+	"\x02ok\x18\x03 \x01(\bR\x02ok\x12\x17\n" +
+	"\afile_id\x18\x04 \x01(\x04R\x06fileId\"E\n" +
+	"\n" +
+	"FileCancel\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\x04R\n" +
+	"transferId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\xc6\x01\n" +
+	"\x0fOtaArtifactItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12%\n" +
+	"\x0ehardware_model\x18\x03 \x01(\tR\rhardwareModel\x12\x17\n" +
+	"\afile_id\x18\x04 \x01(\x04R\x06fileId\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x04R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x06 \x01(\tR\x06sha256\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\"\xa3\x01\n" +
+	"\x14OtaArtifactCreateReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12%\n" +
+	"\x0ehardware_model\x18\x03 \x01(\tR\rhardwareModel\x12\x17\n" +
+	"\afile_id\x18\x04 \x01(\x04R\x06fileId\x12\x16\n" +
+	"\x06sha256\x18\x05 \x01(\tR\x06sha256\"i\n" +
+	"\x15OtaArtifactCreateResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x121\n" +
+	"\x04item\x18\x02 \x01(\v2\x1d.myflowhub.v1.OtaArtifactItemR\x04item\"/\n" +
+	"\x12OtaArtifactListReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\"i\n" +
+	"\x13OtaArtifactListResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x123\n" +
+	"\x05items\x18\x02 \x03(\v2\x1d.myflowhub.v1.OtaArtifactItemR\x05items\"\xc8\x03\n" +
+	"\x0fOtaCampaignItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1f\n" +
+	"\vartifact_id\x18\x03 \x01(\x04R\n" +
+	"artifactId\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12'\n" +
+	"\rowner_user_id\x18\x05 \x01(\x04H\x00R\vownerUserId\x88\x01\x01\x12 \n" +
+	"\tparent_id\x18\x06 \x01(\x04H\x01R\bparentId\x88\x01\x01\x12\x15\n" +
+	"\x03tag\x18\a \x01(\tH\x02R\x03tag\x88\x01\x01\x12\x16\n" +
+	"\x06stages\x18\b \x03(\rR\x06stages\x12#\n" +
+	"\rcurrent_stage\x18\t \x01(\rR\fcurrentStage\x12&\n" +
+	"\x0fmax_failure_pct\x18\n" +
+	" \x01(\rR\rmaxFailurePct\x12\x14\n" +
+	"\x05total\x18\v \x01(\rR\x05total\x12\x1c\n" +
+	"\tsucceeded\x18\f \x01(\rR\tsucceeded\x12\x16\n" +
+	"\x06failed\x18\r \x01(\rR\x06failed\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x0e \x01(\x03R\tcreatedAtB\x10\n" +
+	"\x0e_owner_user_idB\f\n" +
+	"\n" +
+	"_parent_idB\x06\n" +
+	"\x04_tag\"\xb0\x02\n" +
+	"\x14OtaCampaignCreateReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1f\n" +
+	"\vartifact_id\x18\x03 \x01(\x04R\n" +
+	"artifactId\x12'\n" +
+	"\rowner_user_id\x18\x04 \x01(\x04H\x00R\vownerUserId\x88\x01\x01\x12 \n" +
+	"\tparent_id\x18\x05 \x01(\x04H\x01R\bparentId\x88\x01\x01\x12\x15\n" +
+	"\x03tag\x18\x06 \x01(\tH\x02R\x03tag\x88\x01\x01\x12\x16\n" +
+	"\x06stages\x18\a \x03(\rR\x06stages\x12&\n" +
+	"\x0fmax_failure_pct\x18\b \x01(\rR\rmaxFailurePctB\x10\n" +
+	"\x0e_owner_user_idB\f\n" +
+	"\n" +
+	"_parent_idB\x06\n" +
+	"\x04_tag\"i\n" +
+	"\x15OtaCampaignCreateResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x121\n" +
+	"\x04item\x18\x02 \x01(\v2\x1d.myflowhub.v1.OtaCampaignItemR\x04item\"/\n" +
+	"\x12OtaCampaignListReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\"i\n" +
+	"\x13OtaCampaignListResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x123\n" +
+	"\x05items\x18\x02 \x03(\v2\x1d.myflowhub.v1.OtaCampaignItemR\x05items\"k\n" +
+	"\x15OtaCampaignControlReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1f\n" +
+	"\vcampaign_id\x18\x02 \x01(\x04R\n" +
+	"campaignId\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\"\xb0\x01\n" +
+	"\x12OtaDeviceStateItem\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x01 \x01(\x04R\tdeviceUid\x12\x14\n" +
+	"\x05stage\x18\x02 \x01(\rR\x05stage\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12\x1a\n" +
+	"\bprogress\x18\x04 \x01(\rR\bprogress\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\x03R\tupdatedAt\"R\n" +
+	"\x14OtaCampaignStatusReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1f\n" +
+	"\vcampaign_id\x18\x02 \x01(\x04R\n" +
+	"campaignId\"\xad\x01\n" +
+	"\x15OtaCampaignStatusResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x129\n" +
+	"\bcampaign\x18\x02 \x01(\v2\x1d.myflowhub.v1.OtaCampaignItemR\bcampaign\x12:\n" +
+	"\adevices\x18\x03 \x03(\v2 .myflowhub.v1.OtaDeviceStateItemR\adevices\"F\n" +
+	"\n" +
+	"TwinGetReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\"\xeb\x01\n" +
+	"\vTwinGetResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x18\n" +
+	"\adesired\x18\x03 \x01(\fR\adesired\x12'\n" +
+	"\x0fdesired_version\x18\x04 \x01(\x04R\x0edesiredVersion\x12\x1a\n" +
+	"\breported\x18\x05 \x01(\fR\breported\x12)\n" +
+	"\x10reported_version\x18\x06 \x01(\x04R\x0freportedVersion\x12\x14\n" +
+	"\x05delta\x18\a \x01(\fR\x05delta\"\xab\x01\n" +
+	"\x14TwinDesiredUpdateReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x14\n" +
+	"\x05patch\x18\x03 \x01(\fR\x05patch\x12.\n" +
+	"\x10expected_version\x18\x04 \x01(\x04H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"r\n" +
+	"\x15TwinReportedUpdateReq\x12\x14\n" +
+	"\x05patch\x18\x01 \x01(\fR\x05patch\x12.\n" +
+	"\x10expected_version\x18\x02 \x01(\x04H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"h\n" +
+	"\x0eTwinUpdateResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\"H\n" +
+	"\fTwinDeltaReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\"\xb3\x01\n" +
+	"\tTwinDelta\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x14\n" +
+	"\x05delta\x18\x03 \x01(\fR\x05delta\x12'\n" +
+	"\x0fdesired_version\x18\x04 \x01(\x04R\x0edesiredVersion\x12)\n" +
+	"\x10reported_version\x18\x05 \x01(\x04R\x0freportedVersion\" \n" +
+	"\tHeartbeat\x12\x13\n" +
+	"\x05ts_ms\x18\x01 \x01(\x03R\x04tsMs\"{\n" +
+	"\fPresenceItem\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x01 \x01(\x04R\tdeviceUid\x12\x16\n" +
+	"\x06online\x18\x02 \x01(\bR\x06online\x12\"\n" +
+	"\rlast_seen_sec\x18\x03 \x01(\x03R\vlastSeenSec\x12\x10\n" +
+	"\x03via\x18\x04 \x01(\x04R\x03via\"]\n" +
+	"\rPresenceEvent\x120\n" +
+	"\x05items\x18\x01 \x03(\v2\x1a.myflowhub.v1.PresenceItemR\x05items\x12\x1a\n" +
+	"\bsnapshot\x18\x02 \x01(\bR\bsnapshot\"N\n" +
+	"\x10PresenceQueryReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1f\n" +
+	"\vdevice_uids\x18\x02 \x03(\x04R\n" +
+	"deviceUids\"d\n" +
+	"\x11PresenceQueryResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x120\n" +
+	"\x05items\x18\x02 \x03(\v2\x1a.myflowhub.v1.PresenceItemR\x05items\"\xa6\x04\n" +
+	"\x11DeviceSessionItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x10\n" +
+	"\x03hub\x18\x03 \x01(\tR\x03hub\x12\x1f\n" +
+	"\vparent_path\x18\x04 \x01(\tR\n" +
+	"parentPath\x12\x1f\n" +
+	"\vremote_addr\x18\x05 \x01(\tR\n" +
+	"remoteAddr\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x06 \x01(\tR\tuserAgent\x12\x1a\n" +
+	"\bprotocol\x18\a \x01(\tR\bprotocol\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\b \x01(\x03R\tstartedAt\x12\x1b\n" +
+	"\tauthed_at\x18\t \x01(\x03R\bauthedAt\x12\x19\n" +
+	"\bended_at\x18\n" +
+	" \x01(\x03R\aendedAt\x12\x1f\n" +
+	"\vduration_ms\x18\v \x01(\x03R\n" +
+	"durationMs\x12\x19\n" +
+	"\bbytes_in\x18\f \x01(\x04R\abytesIn\x12\x1b\n" +
+	"\tbytes_out\x18\r \x01(\x04R\bbytesOut\x12\x1b\n" +
+	"\tframes_in\x18\x0e \x01(\x04R\bframesIn\x12\x1d\n" +
+	"\n" +
+	"frames_out\x18\x0f \x01(\x04R\tframesOut\x12!\n" +
+	"\fclose_reason\x18\x10 \x01(\tR\vcloseReason\x12 \n" +
+	"\fraw_bytes_in\x18\x11 \x01(\x04R\n" +
+	"rawBytesIn\x12\"\n" +
+	"\rraw_bytes_out\x18\x12 \x01(\x04R\vrawBytesOut\"\x81\x01\n" +
+	"\x14DeviceSessionListReq\x12\x19\n" +
+	"\buser_key\x18\x01 \x01(\tR\auserKey\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\"\xb4\x01\n" +
+	"\x15DeviceSessionListResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x125\n" +
+	"\x05items\x18\x05 \x03(\v2\x1f.myflowhub.v1.DeviceSessionItemR\x05items\"\x1e\n" +
+	"\n" +
+	"CertCsrReq\x12\x10\n" +
+	"\x03csr\x18\x01 \x01(\fR\x03csr\"\x8b\x01\n" +
+	"\vCertCsrResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x12\n" +
+	"\x04cert\x18\x02 \x01(\fR\x04cert\x12\x14\n" +
+	"\x05chain\x18\x03 \x03(\fR\x05chain\x12\x16\n" +
+	"\x06serial\x18\x04 \x01(\tR\x06serial\x12\x1b\n" +
+	"\tnot_after\x18\x05 \x01(\x03R\bnotAfter\"\x84\x01\n" +
+	"\x0fCertRevokedItem\x12\x16\n" +
+	"\x06serial\x18\x01 \x01(\tR\x06serial\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x1d\n" +
+	"\n" +
+	"revoked_at\x18\x03 \x01(\x03R\trevokedAt\x12\x1b\n" +
+	"\tnot_after\x18\x04 \x01(\x03R\bnotAfter\"\f\n" +
+	"\n" +
+	"CertCrlReq\"\x84\x01\n" +
+	"\vCertCrlResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x123\n" +
+	"\x05items\x18\x02 \x03(\v2\x1d.myflowhub.v1.CertRevokedItemR\x05items\x12!\n" +
+	"\fgenerated_at\x18\x03 \x01(\x03R\vgeneratedAt\"5\n" +
+	"\x12DeviceChallengeReq\x12\x1f\n" +
+	"\vhardware_id\x18\x01 \x01(\tR\n" +
+	"hardwareId\"\x86\x01\n" +
+	"\x13DeviceChallengeResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\fR\x05nonce\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\x12\x1b\n" +
+	"\tframe_mac\x18\x04 \x01(\bR\bframeMac\"r\n" +
+	"\rDeviceAuthReq\x12\x1f\n" +
+	"\vhardware_id\x18\x01 \x01(\tR\n" +
+	"hardwareId\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignature\x12\"\n" +
+	"\rframe_mac_pub\x18\x03 \x01(\fR\vframeMacPub\"\x97\x01\n" +
+	"\x0eDeviceAuthResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12#\n" +
+	"\rheartbeat_sec\x18\x03 \x01(\rR\fheartbeatSec\x12\"\n" +
+	"\rframe_mac_pub\x18\x04 \x01(\fR\vframeMacPub\"\xa2\x01\n" +
+	"\x0fDeviceKeySetReq\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x01 \x01(\x04R\tdeviceUid\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\fR\tpublicKey\x12\x1e\n" +
+	"\vold_key_sig\x18\x03 \x01(\fR\toldKeySig\x12\x16\n" +
+	"\x06secret\x18\x04 \x01(\tR\x06secret\x12\x19\n" +
+	"\buser_key\x18\x05 \x01(\tR\auserKey\"\x84\x01\n" +
+	"\x15DeviceRotateSecretReq\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x01 \x01(\x04R\tdeviceUid\x12\x19\n" +
+	"\buser_key\x18\x02 \x01(\tR\auserKey\x12\x1b\n" +
+	"\tgrace_sec\x18\x03 \x01(\rR\bgraceSec\x12\x14\n" +
+	"\x05force\x18\x04 \x01(\bR\x05force\"\x8f\x01\n" +
+	"\x16DeviceRotateSecretResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x16\n" +
+	"\x06secret\x18\x03 \x01(\tR\x06secret\x12\x1f\n" +
+	"\vgrace_until\x18\x04 \x01(\x03R\n" +
+	"graceUntil\"e\n" +
+	"\x06E2EKey\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x01 \x01(\x04R\tdeviceUid\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\fR\tpublicKey\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\x03R\tupdatedAt\"1\n" +
+	"\x10E2EKeyPublishReq\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x01 \x01(\fR\tpublicKey\"/\n" +
+	"\fE2EKeyGetReq\x12\x1f\n" +
+	"\vdevice_uids\x18\x01 \x03(\x04R\n" +
+	"deviceUids\"X\n" +
+	"\rE2EKeyGetResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12(\n" +
+	"\x04keys\x18\x02 \x03(\v2\x14.myflowhub.v1.E2EKeyR\x04keys\"?\n" +
+	"\x13E2EKeyChangedNotify\x12(\n" +
+	"\x04keys\x18\x01 \x03(\v2\x14.myflowhub.v1.E2EKeyR\x04keys\"\x82\x01\n" +
+	"\vHelloLimits\x12&\n" +
+	"\x0fmax_frame_bytes\x18\x01 \x01(\rR\rmaxFrameBytes\x12*\n" +
+	"\x11max_message_bytes\x18\x02 \x01(\rR\x0fmaxMessageBytes\x12\x1f\n" +
+	"\vmax_pending\x18\x03 \x01(\rR\n" +
+	"maxPending\"\xde\x01\n" +
+	"\bHelloReq\x12\x1a\n" +
+	"\bversions\x18\x01 \x03(\rR\bversions\x12\x1a\n" +
+	"\bfeatures\x18\x02 \x03(\tR\bfeatures\x12\x19\n" +
+	"\btype_ids\x18\x03 \x03(\rR\atypeIds\x121\n" +
+	"\x06limits\x18\x04 \x01(\v2\x19.myflowhub.v1.HelloLimitsR\x06limits\x12\x1f\n" +
+	"\vclient_name\x18\x05 \x01(\tR\n" +
+	"clientName\x12+\n" +
+	"\x11compression_dicts\x18\x06 \x03(\rR\x10compressionDicts\"\xc0\x02\n" +
+	"\tHelloResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\rR\aversion\x12\x1a\n" +
+	"\bfeatures\x18\x03 \x03(\tR\bfeatures\x12\x19\n" +
+	"\btype_ids\x18\x04 \x03(\rR\atypeIds\x121\n" +
+	"\x06limits\x18\x05 \x01(\v2\x19.myflowhub.v1.HelloLimitsR\x06limits\x12\x1f\n" +
+	"\vhardware_id\x18\x06 \x01(\tR\n" +
+	"hardwareId\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\a \x01(\x04R\tdeviceUid\x12#\n" +
+	"\rheartbeat_sec\x18\b \x01(\rR\fheartbeatSec\x12+\n" +
+	"\x11compression_dicts\x18\t \x03(\rR\x10compressionDicts\"X\n" +
+	"\x11ResumeTokenNotify\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x04R\x03seq\x12\x1b\n" +
+	"\tgrace_sec\x18\x03 \x01(\rR\bgraceSec\"`\n" +
+	"\tResumeReq\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x19\n" +
+	"\blast_seq\x18\x02 \x01(\x04R\alastSeq\x12\"\n" +
+	"\rframe_mac_pub\x18\x03 \x01(\fR\vframeMacPub\"\xcf\x01\n" +
+	"\n" +
+	"ResumeResp\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x1d\n" +
+	"\n" +
+	"device_uid\x18\x02 \x01(\x04R\tdeviceUid\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\x12\x1a\n" +
+	"\breplayed\x18\x05 \x01(\rR\breplayed\x12\x1b\n" +
+	"\tgrace_sec\x18\x06 \x01(\rR\bgraceSec\x12\"\n" +
+	"\rframe_mac_pub\x18\a \x01(\fR\vframeMacPubB\x1eZ\x1cmyflowhub/pkg/protocol/pb;pbb\x06proto3"
 
 var (
 	file_myflowhub_proto_rawDescOnce sync.Once
@@ -3458,76 +8025,152 @@ func file_myflowhub_proto_rawDescGZIP() []byte {
 	return file_myflowhub_proto_rawDescData
 }
 
-var file_myflowhub_proto_msgTypes = make([]protoimpl.MessageInfo, 49)
+var file_myflowhub_proto_msgTypes = make([]protoimpl.MessageInfo, 110)
 var file_myflowhub_proto_goTypes = []any{
-	(*OKResp)(nil),              // 0: myflowhub.v1.OKResp
-	(*ErrResp)(nil),             // 1: myflowhub.v1.ErrResp
-	(*ManagerAuthReq)(nil),      // 2: myflowhub.v1.ManagerAuthReq
-	(*ManagerAuthResp)(nil),     // 3: myflowhub.v1.ManagerAuthResp
-	(*UserLoginReq)(nil),        // 4: myflowhub.v1.UserLoginReq
-	(*UserLoginResp)(nil),       // 5: myflowhub.v1.UserLoginResp
-	(*UserMeReq)(nil),           // 6: myflowhub.v1.UserMeReq
-	(*UserMeResp)(nil),          // 7: myflowhub.v1.UserMeResp
-	(*UserLogoutReq)(nil),       // 8: myflowhub.v1.UserLogoutReq
-	(*UserItem)(nil),            // 9: myflowhub.v1.UserItem
-	(*UserListReq)(nil),         // 10: myflowhub.v1.UserListReq
-	(*UserListResp)(nil),        // 11: myflowhub.v1.UserListResp
-	(*UserCreateReq)(nil),       // 12: myflowhub.v1.UserCreateReq
-	(*UserCreateResp)(nil),      // 13: myflowhub.v1.UserCreateResp
-	(*UserUpdateReq)(nil),       // 14: myflowhub.v1.UserUpdateReq
-	(*UserDeleteReq)(nil),       // 15: myflowhub.v1.UserDeleteReq
-	(*UserPermListReq)(nil),     // 16: myflowhub.v1.UserPermListReq
-	(*UserPermListResp)(nil),    // 17: myflowhub.v1.UserPermListResp
-	(*UserPermAddReq)(nil),      // 18: myflowhub.v1.UserPermAddReq
-	(*UserPermRemoveReq)(nil),   // 19: myflowhub.v1.UserPermRemoveReq
-	(*UserSelfUpdateReq)(nil),   // 20: myflowhub.v1.UserSelfUpdateReq
-	(*UserSelfPasswordReq)(nil), // 21: myflowhub.v1.UserSelfPasswordReq
-	(*DeviceItem)(nil),          // 22: myflowhub.v1.DeviceItem
-	(*QueryNodesReq)(nil),       // 23: myflowhub.v1.QueryNodesReq
-	(*QueryNodesResp)(nil),      // 24: myflowhub.v1.QueryNodesResp
-	(*CreateDeviceReq)(nil),     // 25: myflowhub.v1.CreateDeviceReq
-	(*UpdateDeviceReq)(nil),     // 26: myflowhub.v1.UpdateDeviceReq
-	(*DeleteDeviceReq)(nil),     // 27: myflowhub.v1.DeleteDeviceReq
-	(*VarListReq)(nil),          // 28: myflowhub.v1.VarListReq
-	(*VarListItem)(nil),         // 29: myflowhub.v1.VarListItem
-	(*VarListResp)(nil),         // 30: myflowhub.v1.VarListResp
-	(*VarUpdateItem)(nil),       // 31: myflowhub.v1.VarUpdateItem
-	(*VarUpdateReq)(nil),        // 32: myflowhub.v1.VarUpdateReq
-	(*VarDeleteItem)(nil),       // 33: myflowhub.v1.VarDeleteItem
-	(*VarDeleteReq)(nil),        // 34: myflowhub.v1.VarDeleteReq
-	(*KeyItem)(nil),             // 35: myflowhub.v1.KeyItem
-	(*KeyListReq)(nil),          // 36: myflowhub.v1.KeyListReq
-	(*KeyListResp)(nil),         // 37: myflowhub.v1.KeyListResp
-	(*KeyCreateReq)(nil),        // 38: myflowhub.v1.KeyCreateReq
-	(*KeyCreateResp)(nil),       // 39: myflowhub.v1.KeyCreateResp
-	(*KeyUpdateReq)(nil),        // 40: myflowhub.v1.KeyUpdateReq
-	(*KeyDeleteReq)(nil),        // 41: myflowhub.v1.KeyDeleteReq
-	(*KeyDevicesReq)(nil),       // 42: myflowhub.v1.KeyDevicesReq
-	(*KeyDevicesResp)(nil),      // 43: myflowhub.v1.KeyDevicesResp
-	(*SystemLogItem)(nil),       // 44: myflowhub.v1.SystemLogItem
-	(*SystemLogListReq)(nil),    // 45: myflowhub.v1.SystemLogListReq
-	(*SystemLogListResp)(nil),   // 46: myflowhub.v1.SystemLogListResp
-	(*ParentAuthReq)(nil),       // 47: myflowhub.v1.ParentAuthReq
-	(*ParentAuthResp)(nil),      // 48: myflowhub.v1.ParentAuthResp
+	(*OKResp)(nil),                 // 0: myflowhub.v1.OKResp
+	(*ErrResp)(nil),                // 1: myflowhub.v1.ErrResp
+	(*CancelReq)(nil),              // 2: myflowhub.v1.CancelReq
+	(*ManagerAuthReq)(nil),         // 3: myflowhub.v1.ManagerAuthReq
+	(*ManagerAuthResp)(nil),        // 4: myflowhub.v1.ManagerAuthResp
+	(*UserLoginReq)(nil),           // 5: myflowhub.v1.UserLoginReq
+	(*UserLoginResp)(nil),          // 6: myflowhub.v1.UserLoginResp
+	(*UserMeReq)(nil),              // 7: myflowhub.v1.UserMeReq
+	(*UserMeResp)(nil),             // 8: myflowhub.v1.UserMeResp
+	(*UserLogoutReq)(nil),          // 9: myflowhub.v1.UserLogoutReq
+	(*UserItem)(nil),               // 10: myflowhub.v1.UserItem
+	(*UserListReq)(nil),            // 11: myflowhub.v1.UserListReq
+	(*UserListResp)(nil),           // 12: myflowhub.v1.UserListResp
+	(*UserCreateReq)(nil),          // 13: myflowhub.v1.UserCreateReq
+	(*UserCreateResp)(nil),         // 14: myflowhub.v1.UserCreateResp
+	(*UserUpdateReq)(nil),          // 15: myflowhub.v1.UserUpdateReq
+	(*UserDeleteReq)(nil),          // 16: myflowhub.v1.UserDeleteReq
+	(*UserPermListReq)(nil),        // 17: myflowhub.v1.UserPermListReq
+	(*UserPermListResp)(nil),       // 18: myflowhub.v1.UserPermListResp
+	(*UserPermAddReq)(nil),         // 19: myflowhub.v1.UserPermAddReq
+	(*UserPermRemoveReq)(nil),      // 20: myflowhub.v1.UserPermRemoveReq
+	(*UserSelfUpdateReq)(nil),      // 21: myflowhub.v1.UserSelfUpdateReq
+	(*UserSelfPasswordReq)(nil),    // 22: myflowhub.v1.UserSelfPasswordReq
+	(*DeviceItem)(nil),             // 23: myflowhub.v1.DeviceItem
+	(*QueryNodesReq)(nil),          // 24: myflowhub.v1.QueryNodesReq
+	(*QueryNodesResp)(nil),         // 25: myflowhub.v1.QueryNodesResp
+	(*CreateDeviceReq)(nil),        // 26: myflowhub.v1.CreateDeviceReq
+	(*UpdateDeviceReq)(nil),        // 27: myflowhub.v1.UpdateDeviceReq
+	(*DeleteDeviceReq)(nil),        // 28: myflowhub.v1.DeleteDeviceReq
+	(*VarListReq)(nil),             // 29: myflowhub.v1.VarListReq
+	(*VarListItem)(nil),            // 30: myflowhub.v1.VarListItem
+	(*VarListResp)(nil),            // 31: myflowhub.v1.VarListResp
+	(*VarUpdateItem)(nil),          // 32: myflowhub.v1.VarUpdateItem
+	(*VarUpdateReq)(nil),           // 33: myflowhub.v1.VarUpdateReq
+	(*VarDeleteItem)(nil),          // 34: myflowhub.v1.VarDeleteItem
+	(*VarDeleteReq)(nil),           // 35: myflowhub.v1.VarDeleteReq
+	(*VarChangedNotify)(nil),       // 36: myflowhub.v1.VarChangedNotify
+	(*KeyItem)(nil),                // 37: myflowhub.v1.KeyItem
+	(*KeyListReq)(nil),             // 38: myflowhub.v1.KeyListReq
+	(*KeyListResp)(nil),            // 39: myflowhub.v1.KeyListResp
+	(*KeyCreateReq)(nil),           // 40: myflowhub.v1.KeyCreateReq
+	(*KeyCreateResp)(nil),          // 41: myflowhub.v1.KeyCreateResp
+	(*KeyUpdateReq)(nil),           // 42: myflowhub.v1.KeyUpdateReq
+	(*KeyDeleteReq)(nil),           // 43: myflowhub.v1.KeyDeleteReq
+	(*KeyDevicesReq)(nil),          // 44: myflowhub.v1.KeyDevicesReq
+	(*KeyDevicesResp)(nil),         // 45: myflowhub.v1.KeyDevicesResp
+	(*SystemLogItem)(nil),          // 46: myflowhub.v1.SystemLogItem
+	(*SystemLogListReq)(nil),       // 47: myflowhub.v1.SystemLogListReq
+	(*SystemLogListResp)(nil),      // 48: myflowhub.v1.SystemLogListResp
+	(*ParentAuthReq)(nil),          // 49: myflowhub.v1.ParentAuthReq
+	(*ParentAuthResp)(nil),         // 50: myflowhub.v1.ParentAuthResp
+	(*FileInitReq)(nil),            // 51: myflowhub.v1.FileInitReq
+	(*FileInitResp)(nil),           // 52: myflowhub.v1.FileInitResp
+	(*FileChunk)(nil),              // 53: myflowhub.v1.FileChunk
+	(*FileChunkAck)(nil),           // 54: myflowhub.v1.FileChunkAck
+	(*FileCompleteReq)(nil),        // 55: myflowhub.v1.FileCompleteReq
+	(*FileCompleteResp)(nil),       // 56: myflowhub.v1.FileCompleteResp
+	(*FileCancel)(nil),             // 57: myflowhub.v1.FileCancel
+	(*OtaArtifactItem)(nil),        // 58: myflowhub.v1.OtaArtifactItem
+	(*OtaArtifactCreateReq)(nil),   // 59: myflowhub.v1.OtaArtifactCreateReq
+	(*OtaArtifactCreateResp)(nil),  // 60: myflowhub.v1.OtaArtifactCreateResp
+	(*OtaArtifactListReq)(nil),     // 61: myflowhub.v1.OtaArtifactListReq
+	(*OtaArtifactListResp)(nil),    // 62: myflowhub.v1.OtaArtifactListResp
+	(*OtaCampaignItem)(nil),        // 63: myflowhub.v1.OtaCampaignItem
+	(*OtaCampaignCreateReq)(nil),   // 64: myflowhub.v1.OtaCampaignCreateReq
+	(*OtaCampaignCreateResp)(nil),  // 65: myflowhub.v1.OtaCampaignCreateResp
+	(*OtaCampaignListReq)(nil),     // 66: myflowhub.v1.OtaCampaignListReq
+	(*OtaCampaignListResp)(nil),    // 67: myflowhub.v1.OtaCampaignListResp
+	(*OtaCampaignControlReq)(nil),  // 68: myflowhub.v1.OtaCampaignControlReq
+	(*OtaDeviceStateItem)(nil),     // 69: myflowhub.v1.OtaDeviceStateItem
+	(*OtaCampaignStatusReq)(nil),   // 70: myflowhub.v1.OtaCampaignStatusReq
+	(*OtaCampaignStatusResp)(nil),  // 71: myflowhub.v1.OtaCampaignStatusResp
+	(*TwinGetReq)(nil),             // 72: myflowhub.v1.TwinGetReq
+	(*TwinGetResp)(nil),            // 73: myflowhub.v1.TwinGetResp
+	(*TwinDesiredUpdateReq)(nil),   // 74: myflowhub.v1.TwinDesiredUpdateReq
+	(*TwinReportedUpdateReq)(nil),  // 75: myflowhub.v1.TwinReportedUpdateReq
+	(*TwinUpdateResp)(nil),         // 76: myflowhub.v1.TwinUpdateResp
+	(*TwinDeltaReq)(nil),           // 77: myflowhub.v1.TwinDeltaReq
+	(*TwinDelta)(nil),              // 78: myflowhub.v1.TwinDelta
+	(*Heartbeat)(nil),              // 79: myflowhub.v1.Heartbeat
+	(*PresenceItem)(nil),           // 80: myflowhub.v1.PresenceItem
+	(*PresenceEvent)(nil),          // 81: myflowhub.v1.PresenceEvent
+	(*PresenceQueryReq)(nil),       // 82: myflowhub.v1.PresenceQueryReq
+	(*PresenceQueryResp)(nil),      // 83: myflowhub.v1.PresenceQueryResp
+	(*DeviceSessionItem)(nil),      // 84: myflowhub.v1.DeviceSessionItem
+	(*DeviceSessionListReq)(nil),   // 85: myflowhub.v1.DeviceSessionListReq
+	(*DeviceSessionListResp)(nil),  // 86: myflowhub.v1.DeviceSessionListResp
+	(*CertCsrReq)(nil),             // 87: myflowhub.v1.CertCsrReq
+	(*CertCsrResp)(nil),            // 88: myflowhub.v1.CertCsrResp
+	(*CertRevokedItem)(nil),        // 89: myflowhub.v1.CertRevokedItem
+	(*CertCrlReq)(nil),             // 90: myflowhub.v1.CertCrlReq
+	(*CertCrlResp)(nil),            // 91: myflowhub.v1.CertCrlResp
+	(*DeviceChallengeReq)(nil),     // 92: myflowhub.v1.DeviceChallengeReq
+	(*DeviceChallengeResp)(nil),    // 93: myflowhub.v1.DeviceChallengeResp
+	(*DeviceAuthReq)(nil),          // 94: myflowhub.v1.DeviceAuthReq
+	(*DeviceAuthResp)(nil),         // 95: myflowhub.v1.DeviceAuthResp
+	(*DeviceKeySetReq)(nil),        // 96: myflowhub.v1.DeviceKeySetReq
+	(*DeviceRotateSecretReq)(nil),  // 97: myflowhub.v1.DeviceRotateSecretReq
+	(*DeviceRotateSecretResp)(nil), // 98: myflowhub.v1.DeviceRotateSecretResp
+	(*E2EKey)(nil),                 // 99: myflowhub.v1.E2EKey
+	(*E2EKeyPublishReq)(nil),       // 100: myflowhub.v1.E2EKeyPublishReq
+	(*E2EKeyGetReq)(nil),           // 101: myflowhub.v1.E2EKeyGetReq
+	(*E2EKeyGetResp)(nil),          // 102: myflowhub.v1.E2EKeyGetResp
+	(*E2EKeyChangedNotify)(nil),    // 103: myflowhub.v1.E2EKeyChangedNotify
+	(*HelloLimits)(nil),            // 104: myflowhub.v1.HelloLimits
+	(*HelloReq)(nil),               // 105: myflowhub.v1.HelloReq
+	(*HelloResp)(nil),              // 106: myflowhub.v1.HelloResp
+	(*ResumeTokenNotify)(nil),      // 107: myflowhub.v1.ResumeTokenNotify
+	(*ResumeReq)(nil),              // 108: myflowhub.v1.ResumeReq
+	(*ResumeResp)(nil),             // 109: myflowhub.v1.ResumeResp
 }
 var file_myflowhub_proto_depIdxs = []int32{
-	9,  // 0: myflowhub.v1.UserListResp.users:type_name -> myflowhub.v1.UserItem
-	22, // 1: myflowhub.v1.QueryNodesResp.devices:type_name -> myflowhub.v1.DeviceItem
-	22, // 2: myflowhub.v1.CreateDeviceReq.device:type_name -> myflowhub.v1.DeviceItem
-	22, // 3: myflowhub.v1.UpdateDeviceReq.device:type_name -> myflowhub.v1.DeviceItem
-	29, // 4: myflowhub.v1.VarListResp.items:type_name -> myflowhub.v1.VarListItem
-	31, // 5: myflowhub.v1.VarUpdateReq.items:type_name -> myflowhub.v1.VarUpdateItem
-	33, // 6: myflowhub.v1.VarDeleteReq.items:type_name -> myflowhub.v1.VarDeleteItem
-	35, // 7: myflowhub.v1.KeyListResp.items:type_name -> myflowhub.v1.KeyItem
-	35, // 8: myflowhub.v1.KeyCreateResp.item:type_name -> myflowhub.v1.KeyItem
-	35, // 9: myflowhub.v1.KeyUpdateReq.item:type_name -> myflowhub.v1.KeyItem
-	22, // 10: myflowhub.v1.KeyDevicesResp.devices:type_name -> myflowhub.v1.DeviceItem
-	44, // 11: myflowhub.v1.SystemLogListResp.logs:type_name -> myflowhub.v1.SystemLogItem
-	12, // [12:12] is the sub-list for method output_type
-	12, // [12:12] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+	10,  // 0: myflowhub.v1.UserListResp.users:type_name -> myflowhub.v1.UserItem
+	23,  // 1: myflowhub.v1.QueryNodesResp.devices:type_name -> myflowhub.v1.DeviceItem
+	23,  // 2: myflowhub.v1.CreateDeviceReq.device:type_name -> myflowhub.v1.DeviceItem
+	23,  // 3: myflowhub.v1.UpdateDeviceReq.device:type_name -> myflowhub.v1.DeviceItem
+	30,  // 4: myflowhub.v1.VarListResp.items:type_name -> myflowhub.v1.VarListItem
+	32,  // 5: myflowhub.v1.VarUpdateReq.items:type_name -> myflowhub.v1.VarUpdateItem
+	34,  // 6: myflowhub.v1.VarDeleteReq.items:type_name -> myflowhub.v1.VarDeleteItem
+	32,  // 7: myflowhub.v1.VarChangedNotify.items:type_name -> myflowhub.v1.VarUpdateItem
+	37,  // 8: myflowhub.v1.KeyListResp.items:type_name -> myflowhub.v1.KeyItem
+	37,  // 9: myflowhub.v1.KeyCreateResp.item:type_name -> myflowhub.v1.KeyItem
+	37,  // 10: myflowhub.v1.KeyUpdateReq.item:type_name -> myflowhub.v1.KeyItem
+	23,  // 11: myflowhub.v1.KeyDevicesResp.devices:type_name -> myflowhub.v1.DeviceItem
+	46,  // 12: myflowhub.v1.SystemLogListResp.logs:type_name -> myflowhub.v1.SystemLogItem
+	58,  // 13: myflowhub.v1.OtaArtifactCreateResp.item:type_name -> myflowhub.v1.OtaArtifactItem
+	58,  // 14: myflowhub.v1.OtaArtifactListResp.items:type_name -> myflowhub.v1.OtaArtifactItem
+	63,  // 15: myflowhub.v1.OtaCampaignCreateResp.item:type_name -> myflowhub.v1.OtaCampaignItem
+	63,  // 16: myflowhub.v1.OtaCampaignListResp.items:type_name -> myflowhub.v1.OtaCampaignItem
+	63,  // 17: myflowhub.v1.OtaCampaignStatusResp.campaign:type_name -> myflowhub.v1.OtaCampaignItem
+	69,  // 18: myflowhub.v1.OtaCampaignStatusResp.devices:type_name -> myflowhub.v1.OtaDeviceStateItem
+	80,  // 19: myflowhub.v1.PresenceEvent.items:type_name -> myflowhub.v1.PresenceItem
+	80,  // 20: myflowhub.v1.PresenceQueryResp.items:type_name -> myflowhub.v1.PresenceItem
+	84,  // 21: myflowhub.v1.DeviceSessionListResp.items:type_name -> myflowhub.v1.DeviceSessionItem
+	89,  // 22: myflowhub.v1.CertCrlResp.items:type_name -> myflowhub.v1.CertRevokedItem
+	99,  // 23: myflowhub.v1.E2EKeyGetResp.keys:type_name -> myflowhub.v1.E2EKey
+	99,  // 24: myflowhub.v1.E2EKeyChangedNotify.keys:type_name -> myflowhub.v1.E2EKey
+	104, // 25: myflowhub.v1.HelloReq.limits:type_name -> myflowhub.v1.HelloLimits
+	104, // 26: myflowhub.v1.HelloResp.limits:type_name -> myflowhub.v1.HelloLimits
+	27,  // [27:27] is the sub-list for method output_type
+	27,  // [27:27] is the sub-list for method input_type
+	27,  // [27:27] is the sub-list for extension type_name
+	27,  // [27:27] is the sub-list for extension extendee
+	0,   // [0:27] is the sub-list for field type_name
 }
 
 func init() { file_myflowhub_proto_init() }
@@ -3535,25 +8178,32 @@ func file_myflowhub_proto_init() {
 	if File_myflowhub_proto != nil {
 		return
 	}
-	file_myflowhub_proto_msgTypes[14].OneofWrappers = []any{}
-	file_myflowhub_proto_msgTypes[22].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[15].OneofWrappers = []any{}
 	file_myflowhub_proto_msgTypes[23].OneofWrappers = []any{}
-	file_myflowhub_proto_msgTypes[25].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[24].OneofWrappers = []any{}
 	file_myflowhub_proto_msgTypes[26].OneofWrappers = []any{}
 	file_myflowhub_proto_msgTypes[27].OneofWrappers = []any{}
 	file_myflowhub_proto_msgTypes[28].OneofWrappers = []any{}
-	file_myflowhub_proto_msgTypes[32].OneofWrappers = []any{}
-	file_myflowhub_proto_msgTypes[34].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[29].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[33].OneofWrappers = []any{}
 	file_myflowhub_proto_msgTypes[35].OneofWrappers = []any{}
-	file_myflowhub_proto_msgTypes[38].OneofWrappers = []any{}
-	file_myflowhub_proto_msgTypes[45].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[37].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[40].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[47].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[51].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[52].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[53].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[63].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[64].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[74].OneofWrappers = []any{}
+	file_myflowhub_proto_msgTypes[75].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_myflowhub_proto_rawDesc), len(file_myflowhub_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   49,
+			NumMessages:   110,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"myflowhub/pkg/config"
 	"myflowhub/pkg/database"
+	"myflowhub/pkg/protocol/grpcapi"
+	"myflowhub/server/internal/adapter"
 	"myflowhub/server/internal/controller"
 	"myflowhub/server/internal/hub"
 	"myflowhub/server/internal/repository"
@@ -13,6 +17,7 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -37,6 +42,11 @@ func main() {
 	keyRepo := repository.NewKeyRepository(database.DB)
 	auditRepo := repository.NewAuditLogRepository(database.DB)
 	systemLogRepo := repository.NewSystemLogRepository(database.DB)
+	fileRepo := repository.NewFileRepository(database.DB)
+	otaRepo := repository.NewOTARepository(database.DB)
+	twinRepo := repository.NewTwinRepository(database.DB)
+	sessionRepo := repository.NewDeviceSessionRepository(database.DB)
+	certRepo := repository.NewCertRepository(database.DB)
 
 	// 初始化 service
 	deviceService := service.NewDeviceService(deviceRepo, variableRepo, database.DB)
@@ -48,6 +58,11 @@ func main() {
 	auditService := service.NewAuditService(auditRepo, keyService)
 	systemLogService := service.NewSystemLogService(systemLogRepo)
 	authzService := service.NewAuthzService(keyService, deviceRepo, permRepo)
+	fileService := service.NewFileService(fileRepo)
+	presenceService := service.NewPresenceService(deviceRepo)
+	otaService := service.NewOTAService(otaRepo, deviceRepo, variableRepo, fileService, presenceService)
+	twinService := service.NewTwinService(twinRepo)
+	sessionService := service.NewDeviceSessionService(sessionRepo)
 
 	// 初始化 controller
 	deviceController := controller.NewDeviceController(deviceService, permService, authzService, systemLogService)
@@ -66,6 +81,11 @@ func main() {
 	keyController := controller.NewKeyController(keyService)
 	logController := controller.NewLogController(auditService)
 	systemLogController := controller.NewSystemLogController(systemLogService)
+	fileController := controller.NewFileController(fileService)
+	otaController := controller.NewOTAController(otaService, authzService)
+	twinController := controller.NewTwinController(twinService, permService, authzService)
+	presenceController := controller.NewPresenceController(presenceService, deviceController)
+	sessionController := controller.NewDeviceSessionController(sessionService, permService, authzService)
 	// 将统一授权服务注入设备与变量控制器
 	userController.SetAuthzService(authzService)
 	userController.SetAuditService(auditService)
@@ -89,18 +109,96 @@ func main() {
 
 	// 注入系统日志服务到 hub（用于连接/断开等事件记录）
 	server.Syslog = systemLogService
+	// 设备断开时挂起其在途文件上传，等待重连续传
+	server.OnDisconnect = fileController.Suspend
+	// OTA 通过 hub 下发固件分片并周期推进活动
+	otaService.SetTransport(server)
+	go otaService.Run(5 * time.Second)
+	// 设备上线时推送待同步的孪生差量
+	twinService.SetTransport(server)
+	server.OnConnect = twinController.PushPending
+	// 帧认证失败写入审计（异步，不阻塞 Run 协程）
+	server.OnFrameMACFailure = func(deviceUID uint64, remoteAddr, reason string) {
+		var subjectID *uint64
+		if deviceUID != 0 {
+			subjectID = &deviceUID
+		}
+		go func() {
+			extra, _ := json.Marshal(map[string]string{"reason": reason})
+			_ = auditService.Write(context.Background(), "device", subjectID, "link.frame_mac.reject", "link:"+remoteAddr, "deny", remoteAddr, "", extra)
+		}()
+	}
+	// 连接协商的协议版本与特性写回 Device（异步，不阻塞 Run 协程）
+	server.OnCapabilities = func(deviceUID uint64, version uint32, features []string) {
+		go func() {
+			if err := deviceService.UpdateCapabilities(context.Background(), deviceUID, version, features); err != nil {
+				log.Warn().Err(err).Uint64("deviceUID", deviceUID).Msg("记录设备协商能力失败")
+			}
+		}()
+	}
+	// 在线状态：心跳看门狗更新内存视图，周期写回 Device.LastSeen；下级中继只能上报其子树内的设备
+	server.Presence = presenceService
+	server.Descendants = func(ctx context.Context, deviceUID uint64) ([]uint64, error) {
+		devices, err := deviceRepo.ListDescendantsOfUID(ctx, deviceUID)
+		if err != nil {
+			return nil, err
+		}
+		uids := make([]uint64, 0, len(devices))
+		for _, d := range devices {
+			uids = append(uids, d.DeviceUID)
+		}
+		return uids, nil
+	}
+	go presenceService.Run(time.Duration(hub.HeartbeatSec()) * time.Second)
+	// 连接会话历史：先关闭上次运行遗留的会话，再由 Run 协程在建立/认证/断开时写入
+	sessionService.CloseDangling(server.HardwareID)
+	server.Sessions = sessionService
+	// MQTT 网桥 / CoAP 网关：以设备密钥或 userKey 认证，接入后与普通下级一致
+	credentialController := controller.NewDeviceCredentialController(authService, authzService, deviceRepo)
+	server.MQTTAuth = credentialController
+	server.CoAPAuth = credentialController
+	go sessionService.Run()
+	// 内置 CA（仅中枢）：签发设备证书并维护吊销列表；未单独配置 ClientCAFile 时以该 CA 校验下级客户端证书
+	var certBin *controller.CertBin
+	if config.AppConfig.CA.Enabled && !config.AppConfig.Relay.Enabled {
+		certService := service.NewCertService(certRepo, auditService)
+		if err := certService.Init(); err != nil {
+			log.Fatal().Err(err).Msg("内置 CA 初始化失败")
+		}
+		certService.OnRevoked = server.SetRevokedCerts
+		certService.PublishRevoked()
+		deviceController.SetCertService(certService)
+		if config.AppConfig.Server.TLS.ClientCAFile == "" {
+			config.AppConfig.Server.TLS.ClientCAFile = service.CAFile()
+		}
+		certBin = &controller.CertBin{C: controller.NewCertController(certService, deviceService)}
+	}
 
 	// 启动前：按策略初始化默认管理员
 	seedDefaultAdmin(userService, permRepo)
 
+	// 签名请求防重放：Nonce.Persist 时写入数据库，重启后及多个 Hub 进程间保持唯一
+	var nonceRepo *repository.NonceRepository
+	if config.AppConfig.Nonce.Persist {
+		nonceRepo = repository.NewNonceRepository(database.DB)
+	}
+	nonceStore := service.NewNonceStore(nonceRepo)
+
 	// 创建各域的 Bin 适配器实例
 	ab := &controller.AuthBin{C: authController}
-	db := &controller.DeviceBin{C: deviceController}
+	db := &controller.DeviceBin{C: deviceController, P: presenceService}
 	vb := &controller.VariableBin{C: variableController}
 	kb := &controller.KeyBin{C: keyController}
 	slb := &controller.SystemLogBin{C: systemLogController}
 	ub := &controller.UserBin{Users: userController}
-	pb := &controller.ParentAuthBin{C: controller.NewParentAuthController()}
+	pb := &controller.ParentAuthBin{C: controller.NewParentAuthController(nonceStore)}
+	fb := &controller.FileBin{C: fileController}
+	ob := &controller.OTABin{C: otaController}
+	tb := &controller.TwinBin{C: twinController}
+	prb := &controller.PresenceBin{C: presenceController}
+	dsb := &controller.DeviceSessionBin{C: sessionController}
+	dkb := &controller.DeviceKeyBin{C: controller.NewDeviceKeyController(authService, authzService, deviceRepo, auditService, nonceStore)}
+	e2b := &controller.E2EKeyBin{C: controller.NewE2EKeyController(deviceRepo, auditService)}
 
 	// 在 hub 包内注册 TypeID，传入具体处理器以避免循环依赖
 	hub.RegisterAuthRoutes(server, ab.ManagerAuth, ab.UserLogin, ab.UserMe, ab.UserLogout)
@@ -111,6 +209,29 @@ func main() {
 	hub.RegisterKeyDevicesRoute(server, kb.Devices)
 	hub.RegisterUserRoutes(server, ub.List, ub.Create, ub.Update, ub.Delete, ub.PermList, ub.PermAdd, ub.PermRemove, ub.SelfUpdate, ub.SelfPassword)
 	hub.RegisterParentAuth(server, pb.Handle)
+	hub.RegisterDeviceKeyRoutes(server, dkb.Challenge, dkb.Auth, dkb.KeySet, dkb.RotateSecret)
+	hub.RegisterE2EKeyRoutes(server, e2b.Publish, e2b.Get)
+	hub.RegisterFileRoutes(server, fb.Init, fb.Chunk, fb.Complete, fb.Cancel)
+	hub.RegisterFilePeerRoutes(server, fb.PeerResponse)
+	hub.RegisterOTARoutes(server, ob.ArtifactCreate, ob.ArtifactList, ob.CampaignCreate, ob.CampaignList, ob.CampaignControl, ob.CampaignStatus)
+	hub.RegisterTwinRoutes(server, tb.Get, tb.UpdateDesired, tb.UpdateReported, tb.Delta)
+	hub.RegisterPresenceRoutes(server, prb.Query)
+	hub.RegisterDeviceSessionRoutes(server, dsb.List)
+	if certBin != nil {
+		hub.RegisterCertRoutes(server, certBin.CSR, certBin.CRL)
+	}
+
+	// 设备 HTTP 上报：与二进制路由共用变量授权与审批检查
+	dh := &controller.DeviceHTTP{Cred: credentialController, Variables: variableController, Presence: presenceService, Hub: server}
+	hub.RegisterDeviceHTTPRoutes(dh.Vars)
+
+	// gRPC：与 /ws 共用监听端口，一元接口复用上方注册的二进制路由
+	grpcServer := grpc.NewServer()
+	grpcapi.RegisterMyFlowHubServer(grpcServer, &controller.GRPCAPI{Hub: server, Authz: authzService, Presence: presenceController})
+	server.GRPC = grpcServer
+
+	// Modbus 适配器：虚拟设备挂在本 Hub 下，需等待 Hub 自身设备登记完成（内部重试）
+	adapter.StartModbusFromConfig(server, deviceService, variableService)
 
 	server.Start() // 阻塞式启动
 }
@@ -130,14 +251,15 @@ func seedDefaultAdmin(userSvc *service.UserService, permRepo *repository.Permiss
 	}
 
 	// 全新安装场景：创建或修复默认管理员权限
-	if u, err := userSvc.GetByUsername(username); err == nil {
-		_ = permRepo.AddUserNode(u.ID, "admin.manage", nil)
-		_ = permRepo.AddUserNode(u.ID, "**", nil)
+	ctx := context.Background()
+	if u, err := userSvc.GetByUsername(ctx, username); err == nil {
+		_ = permRepo.AddUserNode(ctx, u.ID, "admin.manage", nil)
+		_ = permRepo.AddUserNode(ctx, u.ID, "**", nil)
 		return
 	}
-	if u, err := userSvc.Create(username, "System Administrator", password); err == nil {
+	if u, err := userSvc.Create(ctx, username, "System Administrator", password); err == nil {
 		log.Info().Str("username", username).Msg("默认管理员已创建（新建用户表/数据库）")
-		_ = permRepo.AddUserNode(u.ID, "admin.manage", nil)
-		_ = permRepo.AddUserNode(u.ID, "**", nil)
+		_ = permRepo.AddUserNode(ctx, u.ID, "admin.manage", nil)
+		_ = permRepo.AddUserNode(ctx, u.ID, "**", nil)
 	}
 }
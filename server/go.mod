@@ -1,20 +1,22 @@
 module myflowhub/server
 
-go 1.23
-
-toolchain go1.24.5
+go 1.25.0
 
 require (
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.3
 	github.com/rs/zerolog v1.34.0
-	golang.org/x/crypto v0.31.0
+	golang.org/x/crypto v0.50.0
+	google.golang.org/grpc v1.82.1
+	google.golang.org/protobuf v1.36.11
 	gorm.io/datatypes v1.2.6
+	gorm.io/driver/sqlite v1.6.0
 	gorm.io/gorm v1.30.1
 	myflowhub/pkg/config v0.0.0
 	myflowhub/pkg/database v0.0.0
 	myflowhub/pkg/protocol v0.0.0
 	myflowhub/pkg/protocol/binproto v0.0.0
+	myflowhub/pkg/protocol/grpcapi v0.0.0
 )
 
 require (
@@ -28,10 +30,12 @@ require (
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
-	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
-	google.golang.org/protobuf v1.36.9 // indirect
+	github.com/mattn/go-sqlite3 v1.14.22 // indirect
+	golang.org/x/net v0.53.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478 // indirect
 	gorm.io/driver/mysql v1.5.6 // indirect
 	gorm.io/driver/postgres v1.6.0 // indirect
 )
@@ -43,3 +47,5 @@ replace myflowhub/pkg/database => ../pkg/database
 replace myflowhub/pkg/protocol/binproto => ../pkg/protocol/binproto
 
 replace myflowhub/pkg/protocol => ../pkg/protocol
+
+replace myflowhub/pkg/protocol/grpcapi => ../pkg/protocol/grpcapi
@@ -0,0 +1,349 @@
+// Package adapter 运行服务端侧的协议适配器：将现场设备映射为设备树中的虚拟设备及其变量。
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/hub"
+	"myflowhub/server/internal/modbus"
+	"myflowhub/server/internal/service"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/datatypes"
+)
+
+// ModbusErrorVar 适配器最近一次轮询错误（正常时为空字符串）
+const ModbusErrorVar = "modbus_error"
+
+// ModbusAdapter 轮询一个 Modbus TCP 端点，将寄存器值写入虚拟设备变量，并将变量写入回写至寄存器。
+// 端点可达时虚拟设备在线，不可达时离线并在 modbus_error 中记录原因。
+type ModbusAdapter struct {
+	conf    config.ModbusAdapter
+	hub     *hub.Server
+	devices *service.DeviceService
+	vars    *service.VariableService
+	client  *modbus.Client
+
+	device *database.Device
+	conn   *hub.Client       // 在线时的虚拟连接
+	last   map[string]string // 最近写入的变量值（JSON），仅在变化时落库
+	regs   map[string]config.ModbusRegister
+}
+
+// NewModbusAdapter 创建一个新的 ModbusAdapter
+func NewModbusAdapter(conf config.ModbusAdapter, s *hub.Server, devices *service.DeviceService, vars *service.VariableService) *ModbusAdapter {
+	if conf.UnitID == 0 {
+		conf.UnitID = 1
+	}
+	if conf.PollMs <= 0 {
+		conf.PollMs = 1000
+	}
+	if conf.TimeoutMs <= 0 {
+		conf.TimeoutMs = 2000
+	}
+	regs := make(map[string]config.ModbusRegister, len(conf.Registers))
+	for i := range conf.Registers {
+		r := &conf.Registers[i]
+		if r.Kind == "" {
+			r.Kind = "holding"
+		}
+		if r.Type == "" {
+			r.Type = "uint16"
+		}
+		if r.Kind == "coil" || r.Kind == "discrete" {
+			r.Type = "bool"
+		}
+		if r.Scale == 0 {
+			r.Scale = 1
+		}
+		regs[r.Name] = *r
+	}
+	return &ModbusAdapter{
+		conf:    conf,
+		hub:     s,
+		devices: devices,
+		vars:    vars,
+		client:  modbus.NewClient(conf.Endpoint, conf.UnitID, time.Duration(conf.TimeoutMs)*time.Millisecond),
+		last:    make(map[string]string),
+		regs:    regs,
+	}
+}
+
+// StartModbusFromConfig 按配置启动全部 Modbus 适配器
+func StartModbusFromConfig(s *hub.Server, devices *service.DeviceService, vars *service.VariableService) {
+	for _, conf := range config.AppConfig.Modbus.Adapters {
+		if conf.Name == "" || conf.Endpoint == "" {
+			log.Warn().Str("name", conf.Name).Msg("Modbus 适配器缺少 Name 或 Endpoint，已忽略")
+			continue
+		}
+		go NewModbusAdapter(conf, s, devices, vars).Run()
+	}
+}
+
+// Run 轮询直至进程退出（阻塞）
+func (a *ModbusAdapter) Run() {
+	interval := time.Duration(a.conf.PollMs) * time.Millisecond
+	for a.device == nil {
+		dev, err := a.ensureDevice()
+		if err != nil {
+			log.Warn().Err(err).Str("adapter", a.conf.Name).Msg("Modbus 适配器虚拟设备登记失败，稍后重试")
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		a.device = dev
+	}
+	log.Info().Str("adapter", a.conf.Name).Uint64("deviceUID", a.device.DeviceUID).Str("endpoint", a.conf.Endpoint).Msg("Modbus 适配器已启动")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		a.poll()
+		<-ticker.C
+	}
+}
+
+// ensureDevice 以 HardwareID modbus:<Name> 登记虚拟设备（挂在本 Hub 下，由配置创建因此默认已审批）
+func (a *ModbusAdapter) ensureDevice() (*database.Device, error) {
+	hid := "modbus:" + a.conf.Name
+	if dev, err := a.devices.GetDeviceByHardwareID(context.Background(), hid); err == nil {
+		return dev, nil
+	}
+	parent, err := a.devices.GetDeviceByHardwareID(context.Background(), a.hub.HardwareID)
+	if err != nil {
+		return nil, fmt.Errorf("hub device not ready: %w", err)
+	}
+	// 虚拟设备不以密钥接入，写入不可用的随机哈希
+	hash, _ := bcrypt.GenerateFromPassword([]byte(fmt.Sprintf("%s-%d", hid, time.Now().UnixNano())), bcrypt.DefaultCost)
+	dev := &database.Device{HardwareID: hid, SecretKeyHash: string(hash), Role: database.RoleNode, Name: a.conf.Name, ParentID: &parent.ID, Approved: true}
+	if err := a.devices.CreateDevice(context.Background(), dev); err != nil {
+		return nil, err
+	}
+	if dev.DeviceUID == 0 {
+		dev.DeviceUID = dev.ID
+		if err := a.devices.UpdateDevice(context.Background(), dev); err != nil {
+			return nil, err
+		}
+	}
+	return dev, nil
+}
+
+// poll 读取全部映射寄存器；任一失败即判定端点异常
+func (a *ModbusAdapter) poll() {
+	values := make(map[string]any, len(a.conf.Registers))
+	var pollErr error
+	for _, r := range a.conf.Registers {
+		v, err := a.read(r)
+		if err != nil {
+			pollErr = fmt.Errorf("%s: %w", r.Name, err)
+			var ex *modbus.ExceptionError
+			if errors.As(err, &ex) {
+				continue // 从站异常仅影响该寄存器
+			}
+			break
+		}
+		values[r.Name] = v
+	}
+	var ex *modbus.ExceptionError
+	reachable := pollErr == nil || errors.As(pollErr, &ex)
+	if reachable {
+		a.online()
+	} else {
+		a.offline(pollErr)
+	}
+	for name, v := range values {
+		a.store(name, v)
+	}
+	errText := ""
+	if pollErr != nil {
+		errText = pollErr.Error()
+	}
+	if prev, ok := a.last[ModbusErrorVar]; !ok || prev != mustJSON(errText) {
+		if pollErr != nil {
+			log.Warn().Err(pollErr).Str("adapter", a.conf.Name).Msg("Modbus 轮询失败")
+		} else if ok {
+			log.Info().Str("adapter", a.conf.Name).Msg("Modbus 轮询已恢复")
+		}
+	}
+	a.store(ModbusErrorVar, errText)
+}
+
+// store 仅在值变化时写入变量
+func (a *ModbusAdapter) store(name string, v any) {
+	js := mustJSON(v)
+	if a.last[name] == js {
+		return
+	}
+	if err := a.vars.UpsertVariable(context.Background(), &database.DeviceVariable{OwnerDeviceID: a.device.ID, VariableName: name, Value: datatypes.JSON(js)}); err != nil {
+		log.Warn().Err(err).Str("adapter", a.conf.Name).Str("var", name).Msg("Modbus 变量写入失败")
+		return
+	}
+	a.last[name] = js
+	a.hub.PublishVarChanged(a.device.DeviceUID, []bin.VarUpdateItem{{DeviceUID: a.device.DeviceUID, Name: name, Value: []byte(js)}})
+}
+
+// online 端点可达：登记虚拟连接并开始消费变量写入通知
+func (a *ModbusAdapter) online() {
+	if a.conn != nil {
+		a.conn.Touch()
+		return
+	}
+	idle := 3 * time.Duration(a.conf.PollMs+a.conf.TimeoutMs*len(a.conf.Registers)) * time.Millisecond
+	a.conn = a.hub.ConnectVirtual(a.device.DeviceUID, "modbus", idle)
+	go a.consume(a.conn)
+}
+
+func (a *ModbusAdapter) offline(err error) {
+	if a.conn == nil {
+		return
+	}
+	a.conn.Disconnect("modbus: " + err.Error())
+	a.conn = nil
+}
+
+// consume 处理下行帧：本设备变量被写入时回写可写寄存器
+func (a *ModbusAdapter) consume(c *hub.Client) {
+	for frame := range c.Send {
+		h, pl, err := bin.DecodeFrame(frame)
+		if err != nil || h.TypeID != bin.TypeVarChangedNotify {
+			continue
+		}
+		source, items, err := bin.DecodeVarChangedNotify(pl)
+		if err != nil {
+			continue
+		}
+		for _, it := range items {
+			r, ok := a.regs[it.Name]
+			if !ok || it.DeviceUID != a.device.DeviceUID {
+				continue
+			}
+			if !r.Writable || (r.Kind != "holding" && r.Kind != "coil") {
+				log.Warn().Str("adapter", a.conf.Name).Str("var", it.Name).Uint64("source", source).Msg("Modbus 变量不可回写，已忽略")
+				continue
+			}
+			if err := a.write(r, it.Value); err != nil {
+				log.Warn().Err(err).Str("adapter", a.conf.Name).Str("var", it.Name).Msg("Modbus 回写失败")
+				continue
+			}
+			log.Info().Str("adapter", a.conf.Name).Str("var", it.Name).Uint64("source", source).Msg("Modbus 已回写")
+		}
+	}
+}
+
+func (a *ModbusAdapter) read(r config.ModbusRegister) (any, error) {
+	switch r.Kind {
+	case "coil", "discrete":
+		read := a.client.ReadCoils
+		if r.Kind == "discrete" {
+			read = a.client.ReadDiscreteInputs
+		}
+		bits, err := read(r.Address, 1)
+		if err != nil {
+			return nil, err
+		}
+		return bits[0], nil
+	case "holding", "input":
+		read := a.client.ReadHoldingRegisters
+		if r.Kind == "input" {
+			read = a.client.ReadInputRegisters
+		}
+		regs, err := read(r.Address, registerWidth(r.Type))
+		if err != nil {
+			return nil, err
+		}
+		return decodeRegisters(r, regs)
+	}
+	return nil, fmt.Errorf("unknown register kind %q", r.Kind)
+}
+
+func (a *ModbusAdapter) write(r config.ModbusRegister, value []byte) error {
+	if r.Kind == "coil" {
+		var b bool
+		if err := json.Unmarshal(value, &b); err != nil {
+			return fmt.Errorf("coil value must be boolean")
+		}
+		return a.client.WriteSingleCoil(r.Address, b)
+	}
+	var f float64
+	if err := json.Unmarshal(value, &f); err != nil {
+		return fmt.Errorf("register value must be a number")
+	}
+	regs, err := encodeRegisters(r, f)
+	if err != nil {
+		return err
+	}
+	return a.client.WriteRegisters(r.Address, regs)
+}
+
+func registerWidth(typ string) uint16 {
+	switch typ {
+	case "uint32", "int32", "float32":
+		return 2
+	}
+	return 1
+}
+
+// decodeRegisters 原始寄存器 → 变量值（32 位高字在前）
+func decodeRegisters(r config.ModbusRegister, regs []uint16) (any, error) {
+	var raw float64
+	switch r.Type {
+	case "uint16":
+		raw = float64(regs[0])
+	case "int16":
+		raw = float64(int16(regs[0]))
+	case "uint32":
+		raw = float64(uint32(regs[0])<<16 | uint32(regs[1]))
+	case "int32":
+		raw = float64(int32(uint32(regs[0])<<16 | uint32(regs[1])))
+	case "float32":
+		raw = float64(math.Float32frombits(uint32(regs[0])<<16 | uint32(regs[1])))
+	default:
+		return nil, fmt.Errorf("unknown register type %q", r.Type)
+	}
+	v := raw * r.Scale
+	if r.Scale == 1 && r.Type != "float32" {
+		return int64(v), nil
+	}
+	return v, nil
+}
+
+// encodeRegisters 变量值 → 原始寄存器（按 Scale 反算并取整，越界报错）
+func encodeRegisters(r config.ModbusRegister, v float64) ([]uint16, error) {
+	raw := v / r.Scale
+	if r.Type == "float32" {
+		bits := math.Float32bits(float32(raw))
+		return []uint16{uint16(bits >> 16), uint16(bits)}, nil
+	}
+	n := math.Round(raw)
+	limits := map[string][2]float64{
+		"uint16": {0, math.MaxUint16},
+		"int16":  {math.MinInt16, math.MaxInt16},
+		"uint32": {0, math.MaxUint32},
+		"int32":  {math.MinInt32, math.MaxInt32},
+	}
+	lim, ok := limits[r.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown register type %q", r.Type)
+	}
+	if n < lim[0] || n > lim[1] {
+		return nil, fmt.Errorf("value %v out of range for %s", v, r.Type)
+	}
+	switch r.Type {
+	case "uint16", "int16":
+		return []uint16{uint16(int64(n))}, nil
+	}
+	u := uint32(int64(n))
+	return []uint16{uint16(u >> 16), uint16(u)}, nil
+}
+
+func mustJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,140 @@
+package adapter
+
+import (
+	"math"
+	"net"
+	"testing"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/hub"
+	"myflowhub/server/internal/modbus"
+)
+
+// startSimulator 在本机随机端口启动 Modbus 模拟从站，返回其地址
+func startSimulator(t *testing.T) (*modbus.Simulator, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := modbus.NewSimulator()
+	go sim.Serve(ln)
+	t.Cleanup(func() { ln.Close() })
+	return sim, ln.Addr().String()
+}
+
+func TestModbusReadMapping(t *testing.T) {
+	sim, addr := startSimulator(t)
+	bits := math.Float32bits(-12.5)
+	sim.SetHolding(0, 0xFFFE)                         // int16 -2
+	sim.SetHolding(1, 0x0001, 0x0002)                 // uint32 高字在前 0x00010002
+	sim.SetHolding(3, uint16(bits>>16), uint16(bits)) // float32 高字在前
+	sim.SetInput(5, 0x1234)                           // uint16，Scale 0.1
+	sim.SetHolding(6, 0xFFFF, 0xFFFB)                 // int32 -5
+	sim.SetCoil(8, true)
+
+	a := NewModbusAdapter(config.ModbusAdapter{Name: "sim", Endpoint: addr, Registers: []config.ModbusRegister{
+		{Name: "i16", Address: 0, Type: "int16"},
+		{Name: "u32", Address: 1, Type: "uint32"},
+		{Name: "f32", Address: 3, Type: "float32"},
+		{Name: "scaled", Address: 5, Kind: "input", Scale: 0.1},
+		{Name: "i32", Address: 6, Type: "int32"},
+		{Name: "flag", Address: 8, Kind: "coil"},
+	}}, nil, nil, nil)
+
+	cases := []struct {
+		name string
+		want any
+	}{
+		{"i16", int64(-2)},
+		{"u32", int64(0x00010002)},
+		{"f32", float64(-12.5)},
+		{"scaled", float64(0x1234) * 0.1},
+		{"i32", int64(-5)},
+		{"flag", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := a.read(a.regs[tc.name])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %v (%T), want %v (%T)", got, got, tc.want, tc.want)
+			}
+		})
+	}
+}
+
+func TestModbusWriteBack(t *testing.T) {
+	sim, addr := startSimulator(t)
+	a := NewModbusAdapter(config.ModbusAdapter{Name: "sim", Endpoint: addr, Registers: []config.ModbusRegister{
+		{Name: "i16", Address: 0, Type: "int16", Writable: true},
+		{Name: "u32", Address: 1, Type: "uint32", Writable: true},
+		{Name: "f32", Address: 3, Type: "float32", Writable: true},
+		{Name: "flag", Address: 8, Kind: "coil", Writable: true},
+		{Name: "ro", Address: 9},
+	}}, nil, nil, nil)
+	a.device = &database.Device{ID: 1, DeviceUID: 10001}
+
+	// 变量写入通知经虚拟连接下发，consume 回写寄存器
+	c := &hub.Client{Send: make(chan []byte, 1)}
+	notify := bin.EncodeVarChangedNotify(42, []bin.VarUpdateItem{
+		{DeviceUID: 10001, Name: "i16", Value: []byte("-300")},
+		{DeviceUID: 10001, Name: "u32", Value: []byte("70000")},
+		{DeviceUID: 10001, Name: "f32", Value: []byte("1.5")},
+		{DeviceUID: 10001, Name: "flag", Value: []byte("true")},
+		{DeviceUID: 10001, Name: "ro", Value: []byte("7")},
+		{DeviceUID: 10002, Name: "i16", Value: []byte("1")}, // 其他设备的变量
+	})
+	frame, err := bin.EncodeFrame(bin.HeaderV1{TypeID: bin.TypeVarChangedNotify, Target: 10001}, notify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Send <- frame
+	close(c.Send)
+	a.consume(c)
+
+	if got := sim.Holding(0); got != uint16(0xFED4) { // int16 -300
+		t.Fatalf("i16 register %#04x", got)
+	}
+	if hi, lo := sim.Holding(1), sim.Holding(2); hi != 0x0001 || lo != 0x1170 { // 70000 = 0x00011170
+		t.Fatalf("u32 registers %#04x %#04x", hi, lo)
+	}
+	bits := math.Float32bits(1.5)
+	if hi, lo := sim.Holding(3), sim.Holding(4); hi != uint16(bits>>16) || lo != uint16(bits) {
+		t.Fatalf("f32 registers %#04x %#04x", hi, lo)
+	}
+	if got := sim.Holding(9); got != 0 {
+		t.Fatalf("read-only register written: %d", got)
+	}
+	flag, err := a.read(a.regs["flag"])
+	if err != nil || flag != true {
+		t.Fatalf("coil %v %v", flag, err)
+	}
+	// 回写后轮询读回的值与写入一致
+	for name, want := range map[string]any{"i16": int64(-300), "u32": int64(70000), "f32": float64(1.5)} {
+		if got, err := a.read(a.regs[name]); err != nil || got != want {
+			t.Fatalf("%s read back %v %v", name, got, err)
+		}
+	}
+}
+
+func TestModbusEncodeOutOfRange(t *testing.T) {
+	cases := []struct {
+		typ string
+		v   float64
+	}{
+		{"int16", 40000},
+		{"uint16", -1},
+		{"uint32", math.MaxUint32 + 1},
+		{"int32", math.MinInt32 - 1},
+	}
+	for _, tc := range cases {
+		if _, err := encodeRegisters(config.ModbusRegister{Type: tc.typ, Scale: 1}, tc.v); err == nil {
+			t.Fatalf("%s %v: expected range error", tc.typ, tc.v)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Package coap 实现网关所需的 CoAP（RFC 7252）消息编解码与 Observe（RFC 7641）选项子集。
+package coap
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// 消息类型
+const (
+	Confirmable     byte = 0
+	NonConfirmable  byte = 1
+	Acknowledgement byte = 2
+	Reset           byte = 3
+)
+
+// 方法与响应码（class<<5 | detail）
+const (
+	Empty byte = 0
+	GET   byte = 1
+	POST  byte = 2
+	PUT   byte = 3
+
+	Created    byte = 2<<5 | 1
+	Changed    byte = 2<<5 | 4
+	Content    byte = 2<<5 | 5
+	BadRequest byte = 4<<5 | 0
+
+	Unauthorized     byte = 4<<5 | 1
+	Forbidden        byte = 4<<5 | 3
+	NotFound         byte = 4<<5 | 4
+	MethodNotAllowed byte = 4<<5 | 5
+	InternalError    byte = 5<<5 | 0
+	GatewayTimeout   byte = 5<<5 | 4
+)
+
+// 选项编号
+const (
+	OptObserve       uint16 = 6
+	OptURIPath       uint16 = 11
+	OptContentFormat uint16 = 12
+	OptURIQuery      uint16 = 15
+)
+
+// 内容格式
+const (
+	FormatText        uint32 = 0
+	FormatOctetStream uint32 = 42
+	FormatJSON        uint32 = 50
+)
+
+var ErrMalformed = errors.New("coap: malformed message")
+
+// Option 单个选项
+type Option struct {
+	Number uint16
+	Value  []byte
+}
+
+// Message CoAP 消息
+type Message struct {
+	Type      byte
+	Code      byte
+	MessageID uint16
+	Token     []byte
+	Options   []Option
+	Payload   []byte
+}
+
+// Parse 解析一个 UDP 数据报
+func Parse(b []byte) (*Message, error) {
+	if len(b) < 4 || b[0]>>6 != 1 {
+		return nil, ErrMalformed
+	}
+	tkl := int(b[0] & 0x0f)
+	if tkl > 8 || len(b) < 4+tkl {
+		return nil, ErrMalformed
+	}
+	m := &Message{Type: (b[0] >> 4) & 0x03, Code: b[1], MessageID: binary.BigEndian.Uint16(b[2:4])}
+	m.Token = append([]byte(nil), b[4:4+tkl]...)
+	b = b[4+tkl:]
+	var num uint16
+	for len(b) > 0 {
+		if b[0] == 0xff {
+			if len(b) == 1 {
+				return nil, ErrMalformed
+			}
+			m.Payload = append([]byte(nil), b[1:]...)
+			break
+		}
+		delta, length := int(b[0]>>4), int(b[0]&0x0f)
+		b = b[1:]
+		var ok bool
+		if delta, b, ok = extend(delta, b); !ok {
+			return nil, ErrMalformed
+		}
+		if length, b, ok = extend(length, b); !ok || len(b) < length {
+			return nil, ErrMalformed
+		}
+		num += uint16(delta)
+		m.Options = append(m.Options, Option{Number: num, Value: append([]byte(nil), b[:length]...)})
+		b = b[length:]
+	}
+	return m, nil
+}
+
+// extend 处理 13/14 扩展长度；15 保留
+func extend(v int, b []byte) (int, []byte, bool) {
+	switch v {
+	case 13:
+		if len(b) < 1 {
+			return 0, nil, false
+		}
+		return int(b[0]) + 13, b[1:], true
+	case 14:
+		if len(b) < 2 {
+			return 0, nil, false
+		}
+		return int(binary.BigEndian.Uint16(b)) + 269, b[2:], true
+	case 15:
+		return 0, nil, false
+	}
+	return v, b, true
+}
+
+// Marshal 编码消息（选项按编号排序）
+func (m *Message) Marshal() []byte {
+	out := []byte{1<<6 | m.Type<<4 | byte(len(m.Token)), m.Code, byte(m.MessageID >> 8), byte(m.MessageID)}
+	out = append(out, m.Token...)
+	opts := append([]Option(nil), m.Options...)
+	sort.SliceStable(opts, func(i, j int) bool { return opts[i].Number < opts[j].Number })
+	var prev uint16
+	for _, o := range opts {
+		dn, dx := nibble(int(o.Number - prev))
+		ln, lx := nibble(len(o.Value))
+		out = append(out, dn<<4|ln)
+		out = append(out, dx...)
+		out = append(out, lx...)
+		out = append(out, o.Value...)
+		prev = o.Number
+	}
+	if len(m.Payload) > 0 {
+		out = append(out, 0xff)
+		out = append(out, m.Payload...)
+	}
+	return out
+}
+
+func nibble(v int) (byte, []byte) {
+	switch {
+	case v < 13:
+		return byte(v), nil
+	case v < 269:
+		return 13, []byte{byte(v - 13)}
+	default:
+		return 14, []byte{byte((v - 269) >> 8), byte(v - 269)}
+	}
+}
+
+// Path 返回 Uri-Path 各段
+func (m *Message) Path() []string {
+	var segs []string
+	for _, o := range m.Options {
+		if o.Number == OptURIPath {
+			segs = append(segs, string(o.Value))
+		}
+	}
+	return segs
+}
+
+// Query 返回 Uri-Query 中 key 对应的值
+func (m *Message) Query(key string) string {
+	for _, o := range m.Options {
+		if o.Number == OptURIQuery {
+			if k, v, ok := strings.Cut(string(o.Value), "="); ok && k == key {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// Observe 返回 Observe 选项值及是否存在
+func (m *Message) Observe() (uint32, bool) {
+	for _, o := range m.Options {
+		if o.Number == OptObserve {
+			return DecodeUint(o.Value), true
+		}
+	}
+	return 0, false
+}
+
+// SetUint 追加一个无符号整数选项（最短编码）
+func (m *Message) SetUint(num uint16, v uint32) {
+	m.Options = append(m.Options, Option{Number: num, Value: EncodeUint(v)})
+}
+
+// EncodeUint 按 CoAP uint 规则编码（去除前导零）
+func EncodeUint(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+// DecodeUint 解码 CoAP uint 选项值
+func DecodeUint(b []byte) uint32 {
+	var v uint32
+	for _, x := range b {
+		v = v<<8 | uint32(x)
+	}
+	return v
+}
@@ -0,0 +1,146 @@
+package coap
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// sameMessage 比较两条消息（空切片与 nil 视为相同）
+func sameMessage(a, b *Message) bool {
+	if a.Type != b.Type || a.Code != b.Code || a.MessageID != b.MessageID || !bytes.Equal(a.Token, b.Token) ||
+		!bytes.Equal(a.Payload, b.Payload) || len(a.Options) != len(b.Options) {
+		return false
+	}
+	for i := range a.Options {
+		if a.Options[i].Number != b.Options[i].Number || !bytes.Equal(a.Options[i].Value, b.Options[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	long := bytes.Repeat([]byte{'x'}, 300)
+	cases := []struct {
+		name string
+		msg  Message
+	}{
+		{"empty ack", Message{Type: Acknowledgement, Code: Empty, MessageID: 1}},
+		{"get path query", Message{Type: Confirmable, Code: GET, MessageID: 0x1234, Token: []byte{1, 2, 3, 4}, Options: []Option{
+			{Number: OptURIPath, Value: []byte("v")}, {Number: OptURIPath, Value: []byte("temp")}, {Number: OptURIQuery, Value: []byte("k=1")},
+		}}},
+		{"observe register", Message{Type: NonConfirmable, Code: GET, MessageID: 2, Token: []byte{9}, Options: []Option{
+			{Number: OptObserve, Value: nil}, {Number: OptURIPath, Value: []byte("v")},
+		}}},
+		{"put json payload", Message{Type: Confirmable, Code: PUT, MessageID: 3, Token: make([]byte, 8), Options: []Option{
+			{Number: OptURIPath, Value: []byte("v")}, {Number: OptContentFormat, Value: EncodeUint(FormatJSON)},
+		}, Payload: []byte(`{"a":1}`)}},
+		// 选项增量 13~268 以 1 字节扩展，≥269 以 2 字节扩展
+		{"delta 1-byte ext", Message{Code: Content, MessageID: 4, Options: []Option{{Number: 13, Value: []byte{1}}, {Number: 268, Value: []byte{2}}}}},
+		{"delta 2-byte ext", Message{Code: Content, MessageID: 5, Options: []Option{{Number: 2048, Value: []byte{3}}, {Number: 65000, Value: nil}}}},
+		// 选项长度 13 与 300 分别使用 1 字节、2 字节扩展
+		{"length ext", Message{Code: Content, MessageID: 6, Options: []Option{{Number: OptURIPath, Value: long[:13]}, {Number: OptURIQuery, Value: long}}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.msg.Marshal())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameMessage(got, &tc.msg) {
+				t.Fatalf("got %+v\nwant %+v", *got, tc.msg)
+			}
+		})
+	}
+}
+
+func TestMarshalSortsOptions(t *testing.T) {
+	m := Message{Code: GET, Options: []Option{{Number: OptURIQuery, Value: []byte("a=1")}, {Number: OptURIPath, Value: []byte("p")}}}
+	got, err := Parse(m.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Options[0].Number != OptURIPath || got.Options[1].Number != OptURIQuery {
+		t.Fatalf("options %+v", got.Options)
+	}
+}
+
+func TestOptionExtensionEncoding(t *testing.T) {
+	cases := []struct {
+		name string
+		opt  Option
+		want []byte // 首个选项的编码（选项头、扩展字节与值）
+	}{
+		{"delta 12 inline", Option{Number: 12, Value: []byte{7}}, []byte{0xC1, 7}},
+		{"delta 13", Option{Number: 13, Value: nil}, []byte{0xD0, 0x00}},
+		{"delta 268", Option{Number: 268, Value: nil}, []byte{0xD0, 0xFF}},
+		{"delta 269", Option{Number: 269, Value: nil}, []byte{0xE0, 0x00, 0x00}},
+		{"length 13", Option{Number: 1, Value: make([]byte, 13)}, append([]byte{0x1D, 0x00}, make([]byte, 13)...)},
+		{"length 269", Option{Number: 1, Value: make([]byte, 269)}, append([]byte{0x1E, 0x00, 0x00}, make([]byte, 269)...)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := (&Message{Options: []Option{tc.opt}}).Marshal()
+			if !bytes.Equal(b[4:], tc.want) {
+				t.Fatalf("% x, want % x", b[4:], tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	cases := []struct {
+		name string
+		in   []byte
+	}{
+		{"short header", []byte{0x40, 0x01, 0x00}},
+		{"bad version", []byte{0x80, 0x01, 0x00, 0x01}},
+		{"token length 9", append([]byte{0x49, 0x01, 0x00, 0x01}, make([]byte, 9)...)},
+		{"truncated token", []byte{0x44, 0x01, 0x00, 0x01, 1, 2}},
+		{"payload marker without payload", []byte{0x40, 0x01, 0x00, 0x01, 0xff}},
+		{"option value past end", []byte{0x40, 0x01, 0x00, 0x01, 0xB3, 'a'}},
+		{"delta ext missing", []byte{0x40, 0x01, 0x00, 0x01, 0xD0}},
+		{"delta 2-byte ext truncated", []byte{0x40, 0x01, 0x00, 0x01, 0xE0, 0x01}},
+		{"length ext missing", []byte{0x40, 0x01, 0x00, 0x01, 0x1D}},
+		{"length 2-byte ext truncated", []byte{0x40, 0x01, 0x00, 0x01, 0x1E, 0x00}},
+		{"delta 15 reserved", []byte{0x40, 0x01, 0x00, 0x01, 0xF1, 0x00}},
+		{"length 15 reserved", []byte{0x40, 0x01, 0x00, 0x01, 0x1F}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse(tc.in); !errors.Is(err, ErrMalformed) {
+				t.Fatalf("err %v", err)
+			}
+		})
+	}
+}
+
+func TestMessageAccessors(t *testing.T) {
+	m := Message{Options: []Option{
+		{Number: OptURIPath, Value: []byte("v")},
+		{Number: OptURIPath, Value: []byte("temp")},
+		{Number: OptURIQuery, Value: []byte("uid=10001")},
+		{Number: OptURIQuery, Value: []byte("flag")},
+	}}
+	m.SetUint(OptObserve, 0)
+	if got := strings.Join(m.Path(), "/"); got != "v/temp" {
+		t.Fatalf("path %q", got)
+	}
+	if got := m.Query("uid"); got != "10001" {
+		t.Fatalf("query %q", got)
+	}
+	if got := m.Query("flag"); got != "" {
+		t.Fatalf("query without value %q", got)
+	}
+	if v, ok := m.Observe(); !ok || v != 0 {
+		t.Fatalf("observe %d %v", v, ok)
+	}
+	for _, v := range []uint32{0, 1, 255, 256, 1 << 24, 1<<32 - 1} {
+		enc := EncodeUint(v)
+		if DecodeUint(enc) != v || (len(enc) > 0 && enc[0] == 0) {
+			t.Fatalf("uint %d: % x", v, enc)
+		}
+	}
+}
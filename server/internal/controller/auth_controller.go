@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
@@ -44,8 +45,8 @@ func NewAuthController(
 }
 
 // AuthenticateManagerToken: 供二进制路由调用的纯业务方法
-func (c *AuthController) AuthenticateManagerToken(token string) (deviceUID uint64, role string, err error) {
-	device, ok := c.authService.AuthenticateManager(token)
+func (c *AuthController) AuthenticateManagerToken(ctx context.Context, token string) (deviceUID uint64, role string, err error) {
+	device, ok := c.authService.AuthenticateManager(ctx, token)
 	if !ok {
 		return 0, "", fmt.Errorf("unauthorized")
 	}
@@ -57,8 +58,8 @@ func (c *AuthController) AuthenticateManagerToken(token string) (deviceUID uint6
 }
 
 // Login: 用户登录，返回一次性 userKey 与权限
-func (c *AuthController) Login(username, password string) (keyID, userID uint64, secret, uname, displayName string, perms []string, err error) {
-	user, e := c.userRepo.FindByUsername(username)
+func (c *AuthController) Login(ctx context.Context, username, password string) (keyID, userID uint64, secret, uname, displayName string, perms []string, err error) {
+	user, e := c.userRepo.FindByUsername(ctx, username)
 	if e != nil {
 		return 0, 0, "", "", "", nil, fmt.Errorf("invalid credentials")
 	}
@@ -76,13 +77,13 @@ func (c *AuthController) Login(username, password string) (keyID, userID uint64,
 	maxExp := time.Now().Add(30 * 24 * time.Hour)
 	bind := "user"
 	uid := user.ID
-	keyObj, e2 := c.keyService.CreateKey(user.ID, &bind, &uid, secret, &maxExp, nil, nil)
+	keyObj, e2 := c.keyService.CreateKey(ctx, user.ID, &bind, &uid, secret, &maxExp, nil, nil)
 	if e2 != nil {
 		return 0, 0, "", "", "", nil, fmt.Errorf("issue failed")
 	}
 	var permNames []string
 	if c.permRepo != nil {
-		if list, _ := c.permRepo.ListByUserID(user.ID); len(list) > 0 {
+		if list, _ := c.permRepo.ListByUserID(ctx, user.ID); len(list) > 0 {
 			permNames = make([]string, 0, len(list))
 			for _, p := range list {
 				permNames = append(permNames, p.Node)
@@ -94,24 +95,24 @@ func (c *AuthController) Login(username, password string) (keyID, userID uint64,
 	}
 	if c.audit != nil {
 		uid2 := user.ID
-		_ = c.audit.Write("user", &uid2, "user.login", user.Username, "allow", "", "", nil)
+		_ = c.audit.Write(ctx, "user", &uid2, "user.login", user.Username, "allow", "", "", nil)
 	}
 	return keyObj.ID, user.ID, secret, user.Username, user.DisplayName, permNames, nil
 }
 
 // Me: 根据 userKey 返回用户与权限
-func (c *AuthController) Me(userKey string) (userID uint64, username, displayName string, perms []string, err error) {
-	uid, _, e := c.keyService.PeekUserKey(userKey)
+func (c *AuthController) Me(ctx context.Context, userKey string) (userID uint64, username, displayName string, perms []string, err error) {
+	uid, _, e := c.keyService.PeekUserKey(ctx, userKey)
 	if e != nil || uid == 0 {
 		return 0, "", "", nil, fmt.Errorf("invalid key")
 	}
-	u, e := c.userRepo.FindByID(uid)
+	u, e := c.userRepo.FindByID(ctx, uid)
 	if e != nil {
 		return 0, "", "", nil, fmt.Errorf("not found")
 	}
 	var permNames []string
 	if u != nil {
-		if list, _ := c.permRepo.ListByUserID(u.ID); len(list) > 0 {
+		if list, _ := c.permRepo.ListByUserID(ctx, u.ID); len(list) > 0 {
 			permNames = make([]string, 0, len(list))
 			for _, p := range list {
 				permNames = append(permNames, p.Node)
@@ -122,11 +123,11 @@ func (c *AuthController) Me(userKey string) (userID uint64, username, displayNam
 }
 
 // Logout: 撤销 userKey
-func (c *AuthController) Logout(userKey string) error {
+func (c *AuthController) Logout(ctx context.Context, userKey string) error {
 	if userKey == "" {
 		return fmt.Errorf("invalid key")
 	}
-	return c.keyService.DeleteBySecret(userKey)
+	return c.keyService.DeleteBySecret(ctx, userKey)
 }
 
 // 所有 JSON 兼容 Handler 已移除，二进制专用
@@ -1,96 +1,112 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"myflowhub/pkg/database"
 	binproto "myflowhub/pkg/protocol/binproto"
+	"myflowhub/pkg/protocol/binproto/filexfer"
 	"myflowhub/server/internal/hub"
+	"myflowhub/server/internal/service"
+
+	"github.com/rs/zerolog/log"
 )
 
 // ========== Auth ==========
 type AuthBin struct{ C *AuthController }
 
-func (a *AuthBin) ManagerAuth(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
-	token, err := binproto.DecodeManagerAuthReq(payload)
+func (a *AuthBin) ManagerAuth(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	token, macPub, err := binproto.DecodeManagerAuthReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	deviceUID, role, err := a.C.AuthenticateManagerToken(ctx, token)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 401, "unauthorized")
 		return
 	}
-	deviceUID, role, err := a.C.AuthenticateManagerToken(token)
+	if rejectIdentityChange(ctx, s, c, h, deviceUID) {
+		return
+	}
+	serverMACPub, err := c.NegotiateFrameMAC(macPub, []byte(token), binproto.TypeManagerAuthResp, h.MsgID)
 	if err != nil {
-		sendErr(s, c, h, 401, "unauthorized")
+		sendFrameMACErr(ctx, s, c, h, err)
 		return
 	}
 	// Manager 免审批：若记录未审批则自动审批
 	var dev database.Device
-	if e := database.DB.Where("device_uid = ? OR id = ?", deviceUID, deviceUID).First(&dev).Error; e == nil {
+	if e := database.DB.WithContext(ctx).Where("device_uid = ? OR id = ?", deviceUID, deviceUID).First(&dev).Error; e == nil {
 		if !dev.Approved {
 			dev.Approved = true
-			_ = database.DB.Model(&dev).Update("approved", true).Error
+			_ = database.DB.WithContext(ctx).Model(&dev).Update("approved", true).Error
 		}
 	}
-	c.DeviceID = deviceUID
-	s.Clients[c.DeviceID] = c
-	pl := binproto.EncodeManagerAuthResp(h.MsgID, deviceUID, role)
+	s.Attach(c, deviceUID)
+	pl := binproto.EncodeManagerAuthResp(h.MsgID, deviceUID, role, serverMACPub)
 	sendFrame(s, c, h, binproto.TypeManagerAuthResp, pl)
 }
 
-func (a *AuthBin) UserLogin(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (a *AuthBin) UserLogin(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	username, password, err := binproto.DecodeUserLoginReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	keyID, userID, secret, uname, displayName, perms, err := a.C.Login(username, password)
+	keyID, userID, secret, uname, displayName, perms, err := a.C.Login(ctx, username, password)
 	if err != nil {
-		sendErr(s, c, h, 401, "invalid credentials")
+		sendErr(ctx, s, c, h, 401, "invalid credentials")
 		return
 	}
 	pl := binproto.EncodeUserLoginResp(h.MsgID, keyID, userID, secret, uname, displayName, perms)
 	sendFrame(s, c, h, binproto.TypeUserLoginResp, pl)
 }
 
-func (a *AuthBin) UserMe(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (a *AuthBin) UserMe(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, err := binproto.DecodeUserMeReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	uid, uname, displayName, perms, err := a.C.Me(userKey)
+	uid, uname, displayName, perms, err := a.C.Me(ctx, userKey)
 	if err != nil {
-		sendErr(s, c, h, 401, "invalid key")
+		sendErr(ctx, s, c, h, 401, "invalid key")
 		return
 	}
 	pl := binproto.EncodeUserMeResp(h.MsgID, uid, uname, displayName, perms)
 	sendFrame(s, c, h, binproto.TypeUserMeResp, pl)
 }
 
-func (a *AuthBin) UserLogout(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (a *AuthBin) UserLogout(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, err := binproto.DecodeUserLogoutReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	if e := a.C.Logout(userKey); e != nil {
-		sendErr(s, c, h, 400, "logout failed")
+	if e := a.C.Logout(ctx, userKey); e != nil {
+		sendErr(ctx, s, c, h, 400, "logout failed")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
 
 // ========== Devices ==========
-type DeviceBin struct{ C *DeviceController }
+type DeviceBin struct {
+	C *DeviceController
+	P *service.PresenceService // 可空：为空时不返回在线状态
+}
 
-func (d *DeviceBin) QueryNodes(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (d *DeviceBin) QueryNodes(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, err := binproto.DecodeQueryNodesReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	list, err := d.C.QueryVisibleDevices(userKey, c.DeviceID)
+	list, err := d.C.QueryVisibleDevices(ctx, userKey, c.DeviceID)
 	if err != nil {
-		sendErr(s, c, h, 401, "unauthorized")
+		sendErr(ctx, s, c, h, 401, "unauthorized")
 		return
 	}
 	items := make([]binproto.DeviceItem, 0, len(list))
@@ -108,16 +124,22 @@ func (d *DeviceBin) QueryNodes(s *hub.Server, c *hub.Client, h binproto.HeaderV1
 			last = &v
 		}
 		appr := dv.Approved
-		items = append(items, binproto.DeviceItem{ID: dv.ID, DeviceUID: dv.DeviceUID, HardwareID: dv.HardwareID, Role: string(dv.Role), Name: dv.Name, ParentID: parentID, OwnerUserID: ownerID, LastSeenSec: last, CreatedAtSec: dv.CreatedAt.Unix(), UpdatedAtSec: dv.UpdatedAt.Unix(), Approved: &appr})
+		var online *bool
+		if d.P != nil {
+			v := d.P.IsOnline(dv.DeviceUID)
+			online = &v
+		}
+		tags := dv.Tags
+		items = append(items, binproto.DeviceItem{ID: dv.ID, DeviceUID: dv.DeviceUID, HardwareID: dv.HardwareID, Role: string(dv.Role), Name: dv.Name, ParentID: parentID, OwnerUserID: ownerID, LastSeenSec: last, CreatedAtSec: dv.CreatedAt.Unix(), UpdatedAtSec: dv.UpdatedAt.Unix(), Approved: &appr, Online: online, Tags: &tags})
 	}
 	pl := binproto.EncodeQueryNodesResp(h.MsgID, items)
 	sendFrame(s, c, h, binproto.TypeQueryNodesResp, pl)
 }
 
-func (d *DeviceBin) Create(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (d *DeviceBin) Create(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	uk, item, err := binproto.DecodeCreateDeviceReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
 	dev := &database.Device{HardwareID: item.HardwareID, Role: database.DeviceRole(item.Role), Name: item.Name}
@@ -127,17 +149,17 @@ func (d *DeviceBin) Create(s *hub.Server, c *hub.Client, h binproto.HeaderV1, pa
 	if item.OwnerUserID != nil {
 		dev.OwnerUserID = item.OwnerUserID
 	}
-	if e := d.C.CreateDevice(uk, *dev, c.DeviceID); e != nil {
-		sendErr(s, c, h, 403, "permission denied")
+	if e := d.C.CreateDevice(ctx, uk, *dev, c.DeviceID); e != nil {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (d *DeviceBin) Update(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (d *DeviceBin) Update(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	uk, item, err := binproto.DecodeUpdateDeviceReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
 	dev := database.Device{ID: item.ID}
@@ -159,73 +181,115 @@ func (d *DeviceBin) Update(s *hub.Server, c *hub.Client, h binproto.HeaderV1, pa
 	if item.Approved != nil {
 		dev.Approved = *item.Approved
 	}
-	if e := d.C.UpdateDevice(uk, dev, c.DeviceID); e != nil {
-		sendErr(s, c, h, 403, "permission denied")
+	if item.Tags != nil {
+		if e := d.C.SetTags(ctx, uk, item.ID, *item.Tags, c.DeviceID); e != nil {
+			sendErr(ctx, s, c, h, 403, "permission denied")
+			return
+		}
+	}
+	if e := d.C.UpdateDevice(ctx, uk, dev, c.DeviceID); e != nil {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
+	if item.Approved != nil && !*item.Approved {
+		// 驳回：吊销证书，断开设备当前连接并使其可恢复会话失效
+		if uid := d.C.RevokeCertificates(ctx, item.ID, "device rejected"); uid != 0 {
+			s.Kick(uid, "device rejected")
+		}
+	}
 }
 
-func (d *DeviceBin) Delete(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (d *DeviceBin) Delete(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	id, uk, err := binproto.DecodeDeleteDeviceReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	if e := d.C.DeleteDevice(uk, id, c.DeviceID); e != nil {
-		sendErr(s, c, h, 403, "permission denied")
+	uid, e := d.C.DeleteDevice(ctx, uk, id, c.DeviceID)
+	if e != nil {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
+	// 已删除的设备不得继续在线或恢复会话
+	s.Kick(uid, "device deleted")
 }
 
 // ========== Variables ==========
 type VariableBin struct{ C *VariableController }
 
-func (v *VariableBin) Update(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (v *VariableBin) Update(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, items, err := binproto.DecodeVarUpdateReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
 	conv := make([]VarKV, 0, len(items))
 	for _, it := range items {
 		conv = append(conv, VarKV{DeviceUID: it.DeviceUID, Name: it.Name, Value: it.Value})
 	}
-	_, _ = v.C.Update(userKey, conv, c.DeviceID)
+	updated, err := v.C.Update(ctx, userKey, conv, c.DeviceID)
+	if len(updated) > 0 {
+		notifyVarChanged(s, c.DeviceID, updated)
+	}
+	if err != nil {
+		log.Error().Err(err).Uint64("device", c.DeviceID).Msg("写入变量失败")
+		sendErr(ctx, s, c, h, 500, "internal error")
+		return
+	}
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (v *VariableBin) Delete(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+// notifyVarChanged 通知变量所属设备（写入方自身的变量不通知），并向进程内订阅者发布全部条目
+func notifyVarChanged(s *hub.Server, source uint64, updated []VarKV) {
+	all := make([]binproto.VarUpdateItem, 0, len(updated))
+	byOwner := make(map[uint64][]binproto.VarUpdateItem)
+	for _, it := range updated {
+		item := binproto.VarUpdateItem{DeviceUID: it.DeviceUID, Name: it.Name, Value: it.Value}
+		all = append(all, item)
+		if it.DeviceUID != source {
+			byOwner[it.DeviceUID] = append(byOwner[it.DeviceUID], item)
+		}
+	}
+	s.PublishVarChanged(source, all)
+	for owner, list := range byOwner {
+		if err := s.SendTo(owner, binproto.TypeVarChangedNotify, 0, binproto.EncodeVarChangedNotify(source, list)); err != nil {
+			log.Warn().Err(err).Uint64("device", owner).Msg("推送变量变更通知失败")
+		}
+	}
+}
+
+func (v *VariableBin) Delete(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, items, err := binproto.DecodeVarDeleteReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
 	conv := make([]VarKey, 0, len(items))
 	for _, it := range items {
 		conv = append(conv, VarKey{DeviceUID: it.DeviceUID, Name: it.Name})
 	}
-	_, _ = v.C.Delete(userKey, conv, c.DeviceID)
+	_, _ = v.C.Delete(ctx, userKey, conv, c.DeviceID)
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (v *VariableBin) List(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (v *VariableBin) List(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, deviceUIDPtr, err := binproto.DecodeVarListReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	list, e := v.C.List(userKey, deviceUIDPtr, c.DeviceID)
+	list, e := v.C.List(ctx, userKey, deviceUIDPtr, c.DeviceID)
 	if e != nil {
-		sendErr(s, c, h, 403, "permission denied")
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
 	items := make([]binproto.VarListItem, 0, len(list))
 	for _, it := range list {
 		// enrich OwnerDeviceUID by querying device service
 		ownerUID := uint64(0)
-		if owner, derr := v.C.deviceService.GetDeviceByID(it.OwnerDeviceID); derr == nil && owner != nil {
+		if owner, derr := v.C.deviceService.GetDeviceByID(ctx, it.OwnerDeviceID); derr == nil && owner != nil {
 			ownerUID = owner.DeviceUID
 		}
 		items = append(items, binproto.VarListItem{ID: it.ID, OwnerDeviceID: it.OwnerDeviceID, OwnerDeviceUID: ownerUID, Name: it.VariableName, Value: []byte(it.Value), CreatedAtSec: it.CreatedAt.Unix(), UpdatedAtSec: it.UpdatedAt.Unix()})
@@ -237,15 +301,15 @@ func (v *VariableBin) List(s *hub.Server, c *hub.Client, h binproto.HeaderV1, pa
 // ========== Keys ==========
 type KeyBin struct{ C *KeyController }
 
-func (k *KeyBin) List(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (k *KeyBin) List(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, err := binproto.DecodeKeyListReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	list, err := k.C.List(userKey)
+	list, err := k.C.List(ctx, userKey)
 	if err != nil {
-		sendErr(s, c, h, 401, "unauthorized")
+		sendErr(ctx, s, c, h, 401, "unauthorized")
 		return
 	}
 	items := make([]binproto.KeyItem, 0, len(list))
@@ -285,10 +349,10 @@ func (k *KeyBin) List(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload
 	sendFrame(s, c, h, binproto.TypeKeyListResp, pl)
 }
 
-func (k *KeyBin) Create(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (k *KeyBin) Create(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, bindType, bindID, expiresAtSec, maxUses, meta, nodes, err := binproto.DecodeKeyCreateReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
 	var expPtr *time.Time
@@ -301,9 +365,9 @@ func (k *KeyBin) Create(s *hub.Server, c *hub.Client, h binproto.HeaderV1, paylo
 		v := int32(*maxUses)
 		maxPtr = &v
 	}
-	secret, key, nodesOut, e := k.C.Create(userKey, bindType, bindID, expPtr, maxPtr, meta, nodes)
+	secret, key, nodesOut, e := k.C.Create(ctx, userKey, bindType, bindID, expPtr, maxPtr, meta, nodes)
 	if e != nil {
-		sendErr(s, c, h, 401, "unauthorized")
+		sendErr(ctx, s, c, h, 401, "unauthorized")
 		return
 	}
 	var owner, bid *uint64
@@ -340,10 +404,10 @@ func (k *KeyBin) Create(s *hub.Server, c *hub.Client, h binproto.HeaderV1, paylo
 	sendFrame(s, c, h, binproto.TypeKeyCreateResp, pl)
 }
 
-func (k *KeyBin) Update(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (k *KeyBin) Update(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, item, err := binproto.DecodeKeyUpdateReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
 	kdb := &database.Key{ID: item.ID, SecretHash: item.SecretHash, Revoked: item.Revoked}
@@ -374,35 +438,35 @@ func (k *KeyBin) Update(s *hub.Server, c *hub.Client, h binproto.HeaderV1, paylo
 	if len(item.Meta) > 0 {
 		kdb.Meta = item.Meta
 	}
-	if err := k.C.Update(userKey, kdb); err != nil {
-		sendErr(s, c, h, 401, "unauthorized")
+	if err := k.C.Update(ctx, userKey, kdb); err != nil {
+		sendErr(ctx, s, c, h, 401, "unauthorized")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (k *KeyBin) Delete(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (k *KeyBin) Delete(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, id, err := binproto.DecodeKeyDeleteReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	if err := k.C.Delete(userKey, id); err != nil {
-		sendErr(s, c, h, 401, "unauthorized")
+	if err := k.C.Delete(ctx, userKey, id); err != nil {
+		sendErr(ctx, s, c, h, 401, "unauthorized")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (k *KeyBin) Devices(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (k *KeyBin) Devices(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, err := binproto.DecodeKeyDevicesReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	list, e := k.C.VisibleDevices(userKey)
+	list, e := k.C.VisibleDevices(ctx, userKey)
 	if e != nil {
-		sendErr(s, c, h, 401, "unauthorized")
+		sendErr(ctx, s, c, h, 401, "unauthorized")
 		return
 	}
 	items := make([]binproto.DeviceItem, 0, len(list))
@@ -428,10 +492,10 @@ func (k *KeyBin) Devices(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payl
 // ========== SystemLog ==========
 type SystemLogBin struct{ C *SystemLogController }
 
-func (slog *SystemLogBin) List(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (slog *SystemLogBin) List(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, level, source, keyword, startAt, endAt, page, pageSize, err := binproto.DecodeSystemLogListReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
 	var startPtr, endPtr *int64
@@ -441,9 +505,9 @@ func (slog *SystemLogBin) List(s *hub.Server, c *hub.Client, h binproto.HeaderV1
 	if endAt > 0 {
 		endPtr = &endAt
 	}
-	out, e := slog.C.List(userKey, SystemLogListRequest{Level: level, Source: source, Keyword: keyword, StartAt: startPtr, EndAt: endPtr, Page: int(page), PageSize: int(pageSize)})
+	out, e := slog.C.List(ctx, userKey, SystemLogListRequest{Level: level, Source: source, Keyword: keyword, StartAt: startPtr, EndAt: endPtr, Page: int(page), PageSize: int(pageSize)})
 	if e != nil {
-		sendErr(s, c, h, 500, "query failed")
+		sendErr(ctx, s, c, h, 500, "query failed")
 		return
 	}
 	items := make([]binproto.SystemLogItem, 0, len(out.Items))
@@ -457,17 +521,17 @@ func (slog *SystemLogBin) List(s *hub.Server, c *hub.Client, h binproto.HeaderV1
 // ========== Users Management ==========
 type UserBin struct{ Users *UserController }
 
-func (ub *UserBin) List(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (ub *UserBin) List(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	// 要求管理员权限：通过用户密钥判断
 	userKey, _ := binproto.DecodeUserMeReq(payload) // 复用 user_key 解码
-	uid, ok := ub.Users.authz.ResolveUserIDFromKey(userKey)
-	if !ok || !ub.Users.authz.HasUserPermission(uid, "admin.manage") {
-		sendErr(s, c, h, 403, "permission denied")
+	uid, ok := ub.Users.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok || !ub.Users.authz.HasUserPermission(ctx, uid, "admin.manage") {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
-	list, err := ub.Users.users.List()
+	list, err := ub.Users.users.List(ctx)
 	if err != nil {
-		sendErr(s, c, h, 500, "list failed")
+		sendErr(ctx, s, c, h, 500, "list failed")
 		return
 	}
 	items := make([]binproto.UserItem, 0, len(list))
@@ -478,76 +542,76 @@ func (ub *UserBin) List(s *hub.Server, c *hub.Client, h binproto.HeaderV1, paylo
 	sendFrame(s, c, h, binproto.TypeUserListResp, pl)
 }
 
-func (ub *UserBin) Create(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (ub *UserBin) Create(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, username, displayName, password, err := binproto.DecodeUserCreateReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	uid, ok := ub.Users.authz.ResolveUserIDFromKey(userKey)
-	if !ok || !ub.Users.authz.HasUserPermission(uid, "admin.manage") {
-		sendErr(s, c, h, 403, "permission denied")
+	uid, ok := ub.Users.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok || !ub.Users.authz.HasUserPermission(ctx, uid, "admin.manage") {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
-	u, e := ub.Users.users.Create(username, displayName, password)
+	u, e := ub.Users.users.Create(ctx, username, displayName, password)
 	if e != nil {
-		sendErr(s, c, h, 500, "create failed")
+		sendErr(ctx, s, c, h, 500, "create failed")
 		return
 	}
 	pl := binproto.EncodeUserCreateResp(h.MsgID, u.ID)
 	sendFrame(s, c, h, binproto.TypeUserCreateResp, pl)
 }
 
-func (ub *UserBin) Update(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (ub *UserBin) Update(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, id, displayName, password, disabled, err := binproto.DecodeUserUpdateReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	uid, ok := ub.Users.authz.ResolveUserIDFromKey(userKey)
-	if !ok || !ub.Users.authz.HasUserPermission(uid, "admin.manage") {
-		sendErr(s, c, h, 403, "permission denied")
+	uid, ok := ub.Users.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok || !ub.Users.authz.HasUserPermission(ctx, uid, "admin.manage") {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
-	if e := ub.Users.users.Update(id, displayName, password, disabled); e != nil {
-		sendErr(s, c, h, 500, "update failed")
+	if e := ub.Users.users.Update(ctx, id, displayName, password, disabled); e != nil {
+		sendErr(ctx, s, c, h, 500, "update failed")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (ub *UserBin) Delete(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (ub *UserBin) Delete(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, id, err := binproto.DecodeUserDeleteReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	uid, ok := ub.Users.authz.ResolveUserIDFromKey(userKey)
-	if !ok || !ub.Users.authz.HasUserPermission(uid, "admin.manage") {
-		sendErr(s, c, h, 403, "permission denied")
+	uid, ok := ub.Users.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok || !ub.Users.authz.HasUserPermission(ctx, uid, "admin.manage") {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
-	if e := ub.Users.users.Delete(id); e != nil {
-		sendErr(s, c, h, 500, "delete failed")
+	if e := ub.Users.users.Delete(ctx, id); e != nil {
+		sendErr(ctx, s, c, h, 500, "delete failed")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (ub *UserBin) PermList(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (ub *UserBin) PermList(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, targetID, err := binproto.DecodeUserPermListReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	uid, ok := ub.Users.authz.ResolveUserIDFromKey(userKey)
-	if !ok || !ub.Users.authz.HasUserPermission(uid, "admin.manage") {
-		sendErr(s, c, h, 403, "permission denied")
+	uid, ok := ub.Users.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok || !ub.Users.authz.HasUserPermission(ctx, uid, "admin.manage") {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
-	ps, e := ub.Users.permsRepo.ListByUserID(targetID)
+	ps, e := ub.Users.permsRepo.ListByUserID(ctx, targetID)
 	if e != nil {
-		sendErr(s, c, h, 500, "query failed")
+		sendErr(ctx, s, c, h, 500, "query failed")
 		return
 	}
 	items := make([]binproto.PermissionItem, 0, len(ps))
@@ -558,74 +622,535 @@ func (ub *UserBin) PermList(s *hub.Server, c *hub.Client, h binproto.HeaderV1, p
 	sendFrame(s, c, h, binproto.TypeUserPermListResp, pl)
 }
 
-func (ub *UserBin) PermAdd(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (ub *UserBin) PermAdd(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, targetID, node, err := binproto.DecodeUserPermAddReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	uid, ok := ub.Users.authz.ResolveUserIDFromKey(userKey)
-	if !ok || !ub.Users.authz.HasUserPermission(uid, "admin.manage") {
-		sendErr(s, c, h, 403, "permission denied")
+	uid, ok := ub.Users.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok || !ub.Users.authz.HasUserPermission(ctx, uid, "admin.manage") {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
-	if e := ub.Users.permsRepo.AddUserNode(targetID, node, &uid); e != nil {
-		sendErr(s, c, h, 500, "add failed")
+	if e := ub.Users.permsRepo.AddUserNode(ctx, targetID, node, &uid); e != nil {
+		sendErr(ctx, s, c, h, 500, "add failed")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (ub *UserBin) PermRemove(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (ub *UserBin) PermRemove(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, targetID, node, err := binproto.DecodeUserPermRemoveReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	uid, ok := ub.Users.authz.ResolveUserIDFromKey(userKey)
-	if !ok || !ub.Users.authz.HasUserPermission(uid, "admin.manage") {
-		sendErr(s, c, h, 403, "permission denied")
+	uid, ok := ub.Users.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok || !ub.Users.authz.HasUserPermission(ctx, uid, "admin.manage") {
+		sendErr(ctx, s, c, h, 403, "permission denied")
 		return
 	}
-	if e := ub.Users.permsRepo.RemoveUserNode(targetID, node); e != nil {
-		sendErr(s, c, h, 500, "remove failed")
+	if e := ub.Users.permsRepo.RemoveUserNode(ctx, targetID, node); e != nil {
+		sendErr(ctx, s, c, h, 500, "remove failed")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (ub *UserBin) SelfUpdate(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (ub *UserBin) SelfUpdate(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, displayName, err := binproto.DecodeUserSelfUpdateReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	uid, ok := ub.Users.authz.ResolveUserIDFromKey(userKey)
+	uid, ok := ub.Users.authz.ResolveUserIDFromKey(ctx, userKey)
 	if !ok {
-		sendErr(s, c, h, 401, "unauthorized")
+		sendErr(ctx, s, c, h, 401, "unauthorized")
 		return
 	}
-	if e := ub.Users.users.UpdateDisplayName(uid, displayName); e != nil {
-		sendErr(s, c, h, 500, "update failed")
+	if e := ub.Users.users.UpdateDisplayName(ctx, uid, displayName); e != nil {
+		sendErr(ctx, s, c, h, 500, "update failed")
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
 
-func (ub *UserBin) SelfPassword(s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+func (ub *UserBin) SelfPassword(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
 	userKey, oldPassword, newPassword, err := binproto.DecodeUserSelfPasswordReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
-	uid, ok := ub.Users.authz.ResolveUserIDFromKey(userKey)
+	uid, ok := ub.Users.authz.ResolveUserIDFromKey(ctx, userKey)
 	if !ok {
-		sendErr(s, c, h, 401, "unauthorized")
+		sendErr(ctx, s, c, h, 401, "unauthorized")
+		return
+	}
+	if e := ub.Users.users.ChangePasswordWithVerify(ctx, uid, oldPassword, newPassword); e != nil {
+		sendErr(ctx, s, c, h, 400, "change failed")
+		return
+	}
+	sendOK(s, c, h, 0, "ok")
+}
+
+// ========== File ==========
+type FileBin struct{ C *FileController }
+
+func (f *FileBin) Init(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	init, err := binproto.DecodeFileInitReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	resume, chunkSize, window, err := f.C.Init(ctx, c.DeviceID, init)
+	if err != nil {
+		log.Warn().Err(err).Uint64("transferID", init.TransferID).Msg("FILE_INIT 被拒绝")
+		sendFrame(s, c, h, binproto.TypeFileInitResp, binproto.EncodeFileInitResp(h.MsgID, init.TransferID, false, nil, 0, 0))
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeFileInitResp, binproto.EncodeFileInitResp(h.MsgID, init.TransferID, true, &resume, chunkSize, window))
+}
+
+func (f *FileBin) Chunk(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	transferID, offset, chunk, crc, err := binproto.DecodeFileChunk(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	next, err := f.C.Chunk(ctx, c.DeviceID, transferID, offset, chunk, crc)
+	switch {
+	case err == nil, errors.Is(err, filexfer.ErrCRCMismatch):
+		// 校验失败的分片不写入，以当前偏移确认促使发送端重传
+		sendFrame(s, c, h, binproto.TypeFileChunkAck, binproto.EncodeFileChunkAck(transferID, next))
+	default:
+		sendFrame(s, c, h, binproto.TypeFileCancel, binproto.EncodeFileCancel(transferID, err.Error()))
+	}
+}
+
+func (f *FileBin) Complete(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	transferID, err := binproto.DecodeFileCompleteReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	fileID, err := f.C.Complete(ctx, c.DeviceID, transferID)
+	if err != nil {
+		log.Warn().Err(err).Uint64("transferID", transferID).Msg("FILE_COMPLETE 校验失败")
+	}
+	sendFrame(s, c, h, binproto.TypeFileCompleteResp, binproto.EncodeFileCompleteResp(h.MsgID, transferID, err == nil, fileID))
+}
+
+func (f *FileBin) Cancel(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	transferID, reason, err := binproto.DecodeFileCancel(payload)
+	if err != nil {
+		return
+	}
+	// 先匹配 Hub 发出的传输（接收方取消）
+	if handled, _ := f.C.HandlePeerResponse(c.DeviceID, h.TypeID, payload); handled {
+		log.Info().Uint64("transferID", transferID).Str("reason", reason).Msg("接收方取消了文件下发")
+		return
+	}
+	if e := f.C.Cancel(ctx, c.DeviceID, transferID); e != nil {
+		log.Debug().Err(e).Uint64("transferID", transferID).Msg("FILE_CANCEL 未找到传输")
+		return
+	}
+	log.Info().Uint64("transferID", transferID).Str("reason", reason).Msg("文件上传已取消")
+}
+
+// PeerResponse 处理设备对 Hub 下发传输的应答（FILE_INIT_RESP/FILE_CHUNK_ACK/FILE_COMPLETE_RESP）
+func (f *FileBin) PeerResponse(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	handled, err := f.C.HandlePeerResponse(c.DeviceID, h.TypeID, payload)
+	if err != nil {
+		log.Debug().Err(err).Uint16("typeID", h.TypeID).Uint64("peer", c.DeviceID).Msg("处理文件下发应答失败")
 		return
 	}
-	if e := ub.Users.users.ChangePasswordWithVerify(uid, oldPassword, newPassword); e != nil {
-		sendErr(s, c, h, 400, "change failed")
+	if !handled {
+		log.Debug().Uint16("typeID", h.TypeID).Uint64("peer", c.DeviceID).Msg("文件下发应答无对应传输，已忽略")
+	}
+}
+
+// ========== OTA ==========
+type OTABin struct{ C *OTAController }
+
+// otaErrCode 权限错误返回 403，其余按请求错误处理
+func otaErrCode(err error) int32 {
+	if errors.Is(err, errOTAPermission) {
+		return 403
+	}
+	return 400
+}
+
+func (o *OTABin) ArtifactCreate(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, version, model, fileID, sha, err := binproto.DecodeOTAArtifactCreateReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	item, err := o.C.CreateArtifact(ctx, userKey, version, model, fileID, sha)
+	if err != nil {
+		sendErr(ctx, s, c, h, otaErrCode(err), err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeOTAArtifactCreateResp, binproto.EncodeOTAArtifactCreateResp(h.MsgID, item))
+}
+
+func (o *OTABin) ArtifactList(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, err := binproto.DecodeOTAArtifactListReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	items, err := o.C.ListArtifacts(ctx, userKey)
+	if err != nil {
+		sendErr(ctx, s, c, h, otaErrCode(err), err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeOTAArtifactListResp, binproto.EncodeOTAArtifactListResp(h.MsgID, items))
+}
+
+func (o *OTABin) CampaignCreate(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, spec, err := binproto.DecodeOTACampaignCreateReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	item, err := o.C.CreateCampaign(ctx, userKey, spec)
+	if err != nil {
+		sendErr(ctx, s, c, h, otaErrCode(err), err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeOTACampaignCreateResp, binproto.EncodeOTACampaignCreateResp(h.MsgID, item))
+}
+
+func (o *OTABin) CampaignList(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, err := binproto.DecodeOTACampaignListReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	items, err := o.C.ListCampaigns(ctx, userKey)
+	if err != nil {
+		sendErr(ctx, s, c, h, otaErrCode(err), err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeOTACampaignListResp, binproto.EncodeOTACampaignListResp(h.MsgID, items))
+}
+
+func (o *OTABin) CampaignControl(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, id, action, err := binproto.DecodeOTACampaignControlReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	if err := o.C.Control(ctx, userKey, id, action); err != nil {
+		sendErr(ctx, s, c, h, otaErrCode(err), err.Error())
 		return
 	}
 	sendOK(s, c, h, 0, "ok")
 }
+
+func (o *OTABin) CampaignStatus(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, id, err := binproto.DecodeOTACampaignStatusReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	item, devices, err := o.C.Status(ctx, userKey, id)
+	if err != nil {
+		sendErr(ctx, s, c, h, otaErrCode(err), err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeOTACampaignStatusResp, binproto.EncodeOTACampaignStatusResp(h.MsgID, item, devices))
+}
+
+// ========== Twin ==========
+type TwinBin struct{ C *TwinController }
+
+func twinErrCode(err error) int32 {
+	switch {
+	case errors.Is(err, errTwinPermission):
+		return 403
+	case errors.Is(err, service.ErrTwinVersionConflict):
+		return 409
+	case errors.Is(err, service.ErrTwinBadPatch):
+		return 400
+	}
+	return 500
+}
+
+func (t *TwinBin) Get(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, deviceUID, err := binproto.DecodeTwinGetReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	doc, err := t.C.Get(ctx, userKey, deviceUID, c.DeviceID)
+	if err != nil {
+		sendErr(ctx, s, c, h, twinErrCode(err), err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeTwinGetResp, binproto.EncodeTwinGetResp(h.MsgID, doc))
+}
+
+func (t *TwinBin) Delta(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, deviceUID, err := binproto.DecodeTwinDeltaReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	doc, err := t.C.Get(ctx, userKey, deviceUID, c.DeviceID)
+	if err != nil {
+		sendErr(ctx, s, c, h, twinErrCode(err), err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeTwinDelta, binproto.EncodeTwinDelta(h.MsgID, doc.DeviceUID, doc.Delta, doc.DesiredVersion, doc.ReportedVersion))
+}
+
+func (t *TwinBin) UpdateDesired(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, deviceUID, patch, expected, err := binproto.DecodeTwinDesiredUpdateReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	target, version, err := t.C.UpdateDesired(ctx, userKey, deviceUID, patch, expected, c.DeviceID)
+	if err != nil {
+		sendErr(ctx, s, c, h, twinErrCode(err), err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeTwinUpdateResp, binproto.EncodeTwinUpdateResp(h.MsgID, target, version))
+}
+
+func (t *TwinBin) UpdateReported(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	patch, expected, err := binproto.DecodeTwinReportedUpdateReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	version, err := t.C.UpdateReported(ctx, patch, expected, c.DeviceID)
+	if err != nil {
+		sendErr(ctx, s, c, h, twinErrCode(err), err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeTwinUpdateResp, binproto.EncodeTwinUpdateResp(h.MsgID, c.DeviceID, version))
+}
+
+// ========== Presence ==========
+type PresenceBin struct{ C *PresenceController }
+
+func (p *PresenceBin) Query(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, uids, err := binproto.DecodePresenceQueryReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	items, err := p.C.Query(ctx, userKey, uids, c.DeviceID)
+	if err != nil {
+		sendErr(ctx, s, c, h, 401, "unauthorized")
+		return
+	}
+	sendFrame(s, c, h, binproto.TypePresenceQueryResp, binproto.EncodePresenceQueryResp(h.MsgID, items))
+}
+
+// ========== Device Sessions ==========
+type DeviceSessionBin struct{ C *DeviceSessionController }
+
+func (d *DeviceSessionBin) List(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	userKey, deviceUID, page, pageSize, err := binproto.DecodeDeviceSessionListReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	items, total, page, pageSize, err := d.C.List(ctx, userKey, deviceUID, page, pageSize, c.DeviceID)
+	if err != nil {
+		code := int32(500)
+		if errors.Is(err, errSessionPermission) {
+			code = 403
+		}
+		sendErr(ctx, s, c, h, code, err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeDeviceSessionListResp, binproto.EncodeDeviceSessionListResp(h.MsgID, total, page, pageSize, items))
+}
+
+// ========== Device Certificates ==========
+type CertBin struct{ C *CertController }
+
+func (cb *CertBin) CSR(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	csr, err := binproto.DecodeCertCSRReq(payload)
+	if err != nil || len(csr) == 0 {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	issued, err := cb.C.Issue(ctx, c.DeviceID, csr, c.RemoteAddr, c.UserAgent)
+	if err != nil {
+		code := int32(400)
+		switch {
+		case errors.Is(err, errCertNoDevice):
+			code = 404
+		case errors.Is(err, errCertNotApproved):
+			code = 403
+		}
+		sendErr(ctx, s, c, h, code, err.Error())
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeCertCSRResp, binproto.EncodeCertCSRResp(h.MsgID, issued.Cert, issued.Chain, issued.Serial, issued.NotAfter.Unix()))
+}
+
+func (cb *CertBin) CRL(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	items, at, err := cb.C.Revoked(ctx)
+	if err != nil {
+		sendErr(ctx, s, c, h, 500, "internal error")
+		return
+	}
+	sendFrame(s, c, h, binproto.TypeCertCRLResp, binproto.EncodeCertCRLResp(h.MsgID, items, at))
+}
+
+// ========== Device Key Auth ==========
+type DeviceKeyBin struct{ C *DeviceKeyController }
+
+func (d *DeviceKeyBin) Challenge(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	hardwareID, err := binproto.DecodeDeviceChallengeReq(payload)
+	if err != nil || hardwareID == "" {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	nonce, exp, err := d.C.Challenge()
+	if err != nil {
+		sendErr(ctx, s, c, h, 500, "internal error")
+		return
+	}
+	c.SetChallenge(hardwareID, nonce, exp)
+	sendFrame(s, c, h, binproto.TypeDeviceChallengeResp, binproto.EncodeDeviceChallengeResp(h.MsgID, nonce, exp.UnixMilli(), c.FrameMACOffered()))
+}
+
+func (d *DeviceKeyBin) Auth(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	hardwareID, sig, macPub, err := binproto.DecodeDeviceAuthReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	nonce, ok := c.TakeChallenge(hardwareID)
+	if !ok {
+		sendErr(ctx, s, c, h, 401, "no valid challenge")
+		return
+	}
+	dev, err := d.C.Authenticate(ctx, hardwareID, nonce, sig, macPub, c.RemoteAddr, c.UserAgent)
+	if err != nil {
+		code := int32(401)
+		if errors.Is(err, errNotApproved) {
+			code = 403
+		}
+		sendErr(ctx, s, c, h, code, err.Error())
+		return
+	}
+	if rejectIdentityChange(ctx, s, c, h, dev.DeviceUID) {
+		return
+	}
+	serverMACPub, err := c.NegotiateFrameMAC(macPub, nonce, binproto.TypeDeviceAuthResp, h.MsgID)
+	if err != nil {
+		sendFrameMACErr(ctx, s, c, h, err)
+		return
+	}
+	s.Attach(c, dev.DeviceUID)
+	sendFrame(s, c, h, binproto.TypeDeviceAuthResp, binproto.EncodeDeviceAuthResp(h.MsgID, dev.DeviceUID, uint32(hub.HeartbeatSec()), serverMACPub))
+}
+
+func (d *DeviceKeyBin) KeySet(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	req, err := binproto.DecodeDeviceKeySetReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	if err := d.C.SetKey(ctx, req, c.DeviceID, c.RemoteAddr, c.UserAgent); err != nil {
+		code := int32(500)
+		switch {
+		case errors.Is(err, errBadPublicKey):
+			code = 400
+		case errors.Is(err, errBadCredentials):
+			code = 401
+		}
+		sendErr(ctx, s, c, h, code, err.Error())
+		return
+	}
+	sendOK(s, c, h, 0, "ok")
+}
+
+func (d *DeviceKeyBin) RotateSecret(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	uid, userKey, graceSec, force, err := binproto.DecodeDeviceRotateSecretReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	if uid == 0 {
+		uid = c.DeviceID
+	}
+	secret, graceUntil, err := d.C.RotateSecret(ctx, uid, userKey, graceSec, force, c.DeviceID, c.RemoteAddr, c.UserAgent)
+	if err != nil {
+		code := int32(500)
+		switch {
+		case errors.Is(err, errBadCredentials):
+			code = 401
+		case errors.Is(err, errKeyPermission):
+			code = 403
+		}
+		sendErr(ctx, s, c, h, code, err.Error())
+		return
+	}
+	var until int64
+	if !graceUntil.IsZero() {
+		until = graceUntil.Unix()
+	}
+	sendFrame(s, c, h, binproto.TypeDeviceRotateSecretResp, binproto.EncodeDeviceRotateSecretResp(h.MsgID, uid, secret, until))
+	if force {
+		// 旧密钥已失效：断开设备当前连接，迫使其以新凭据重连
+		s.Kick(uid, "credential rotated")
+	}
+}
+
+// E2EKeyBin 端到端加密公钥目录
+type E2EKeyBin struct{ C *E2EKeyController }
+
+func (e *E2EKeyBin) Publish(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	key, err := binproto.DecodeE2EKeyPublishReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	entry, changed, err := e.C.Publish(ctx, c.DeviceID, key, c.RemoteAddr, c.UserAgent)
+	if err != nil {
+		code := int32(500)
+		switch {
+		case errors.Is(err, errBadE2EKey):
+			code = 400
+		case errors.Is(err, errBadCredentials):
+			code = 401
+		}
+		sendErr(ctx, s, c, h, code, err.Error())
+		return
+	}
+	sendOK(s, c, h, 0, "ok")
+	if changed {
+		s.AnnounceE2EKey(entry)
+	}
+}
+
+func (e *E2EKeyBin) Get(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	uids, err := binproto.DecodeE2EKeyGetReq(payload)
+	if err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	keys, err := e.C.Get(ctx, uids)
+	if err != nil {
+		code := int32(500)
+		if errors.Is(err, errE2EQueryTooBig) {
+			code = 400
+		}
+		sendErr(ctx, s, c, h, code, err.Error())
+		return
+	}
+	// 记录查询关系：之后这些公钥变化时向本连接推送 E2E_KEY_CHANGED_NOTIFY
+	c.WatchE2EKeys(uids)
+	sendFrame(s, c, h, binproto.TypeE2EKeyGetResp, binproto.EncodeE2EKeyGetResp(h.MsgID, keys))
+}
@@ -1,6 +1,9 @@
 package controller
 
 import (
+	"context"
+	"errors"
+
 	binproto "myflowhub/pkg/protocol/binproto"
 	"myflowhub/server/internal/hub"
 
@@ -20,7 +23,30 @@ func sendOK(s *hub.Server, c *hub.Client, h binproto.HeaderV1, code int32, msg s
 	sendFrame(s, c, h, binproto.TypeOKResp, pl)
 }
 
-func sendErr(s *hub.Server, c *hub.Client, h binproto.HeaderV1, code int32, msg string) {
+// sendErr 请求已被取消或超过截止时间时以 499/504 代替处理器给出的错误（此时的错误多由 ctx 导致）
+func sendErr(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, code int32, msg string) {
+	if cc, m, ok := hub.RequestErr(ctx); ok {
+		code, msg = cc, m
+	}
 	pl := binproto.EncodeErrResp(h.MsgID, code, []byte(msg))
 	sendFrame(s, c, h, binproto.TypeErrResp, pl)
 }
+
+// sendFrameMACErr 帧认证协商失败：require 模式下未提供公钥返回 426，公钥无效返回 400
+func sendFrameMACErr(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, err error) {
+	if errors.Is(err, hub.ErrFrameMACRequired) {
+		sendErr(ctx, s, c, h, 426, err.Error())
+		return
+	}
+	sendErr(ctx, s, c, h, 400, "invalid frame mac key")
+}
+
+// rejectIdentityChange 已认证的连接以另一设备身份重新认证时返回 409（续期须沿用原身份，连接保持原身份），返回是否已拒绝
+func rejectIdentityChange(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, uid uint64) bool {
+	if c.DeviceID == 0 || c.DeviceID == uid {
+		return false
+	}
+	log.Warn().Uint64("deviceUID", c.DeviceID).Uint64("newUID", uid).Msg("已认证连接尝试以其他设备身份重新认证，已拒绝")
+	sendErr(ctx, s, c, h, 409, "already authenticated as another device")
+	return true
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/service"
+)
+
+var (
+	errCertNoDevice    = errors.New("device not found")
+	errCertNotApproved = errors.New("device not approved")
+)
+
+// CertController 内置 CA：设备以自身连接身份申请证书，吊销列表供下级中继同步
+type CertController struct {
+	svc     *service.CertService
+	devices *service.DeviceService
+}
+
+// NewCertController 创建一个新的 CertController
+func NewCertController(svc *service.CertService, devices *service.DeviceService) *CertController {
+	return &CertController{svc: svc, devices: devices}
+}
+
+// Issue 为请求连接所代表的设备签发证书（仅能为自身申请，CN 取自设备记录）
+func (c *CertController) Issue(ctx context.Context, requesterDeviceUID uint64, csr []byte, ip, ua string) (*service.CertIssued, error) {
+	dev, err := c.devices.GetDeviceByUID(ctx, requesterDeviceUID)
+	if err != nil {
+		return nil, errCertNoDevice
+	}
+	if !dev.Approved {
+		return nil, errCertNotApproved
+	}
+	return c.svc.Issue(ctx, dev, csr, ip, ua)
+}
+
+// Revoked 返回当前吊销列表及生成时间
+func (c *CertController) Revoked(ctx context.Context) ([]bin.CertRevokedItem, int64, error) {
+	items, err := c.svc.Revoked(ctx)
+	return items, time.Now().Unix(), err
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"myflowhub/pkg/database"
 	"myflowhub/server/internal/service"
@@ -12,6 +13,7 @@ type DeviceController struct {
 	perm    *service.PermissionService
 	authz   *service.AuthzService
 	syslog  *service.SystemLogService
+	certs   *service.CertService // 可空：启用内置 CA 时删除/驳回设备吊销其证书
 }
 
 // NewDeviceController 创建一个新的 DeviceController
@@ -24,12 +26,33 @@ func NewDeviceController(service *service.DeviceService, perm *service.Permissio
 	}
 }
 
+// SetCertService 注入内置 CA（可选）
+func (c *DeviceController) SetCertService(certs *service.CertService) { c.certs = certs }
+
+// RevokeCertificates 吊销设备（按主键）名下的全部证书（未启用内置 CA 时跳过），返回设备 UID；设备不存在时返回 0
+func (c *DeviceController) RevokeCertificates(ctx context.Context, id uint64, reason string) uint64 {
+	d, err := c.service.GetDeviceByID(ctx, id)
+	if err != nil {
+		return 0
+	}
+	if c.certs != nil {
+		c.revokeByUID(ctx, d.DeviceUID, reason)
+	}
+	return d.DeviceUID
+}
+
+func (c *DeviceController) revokeByUID(ctx context.Context, uid uint64, reason string) {
+	if err := c.certs.RevokeDevice(ctx, uid, reason); err != nil && c.syslog != nil {
+		_ = c.syslog.Error("cert", "revoke failed", map[string]any{"deviceUID": uid, "error": err.Error()})
+	}
+}
+
 // Business methods for binary routes (transport-agnostic)
-func (c *DeviceController) QueryVisibleDevices(userKey string, requesterDeviceUID uint64) ([]database.Device, error) {
+func (c *DeviceController) QueryVisibleDevices(ctx context.Context, userKey string, requesterDeviceUID uint64) ([]database.Device, error) {
 	// 三来源优先
 	if c.authz != nil && userKey != "" {
-		if uid, ok := c.authz.ResolveUserIDFromKey(userKey); ok {
-			if ds, err := c.authz.VisibleDevices(uid, requesterDeviceUID); err == nil {
+		if uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey); ok {
+			if ds, err := c.authz.VisibleDevices(ctx, uid, requesterDeviceUID); err == nil {
 				return ds, nil
 			} else {
 				return nil, err
@@ -38,16 +61,16 @@ func (c *DeviceController) QueryVisibleDevices(userKey string, requesterDeviceUI
 		return nil, fmt.Errorf("unauthorized")
 	}
 	// 旧逻辑：管理员设备可见全部，否则空
-	if !c.perm.CanAccessAllDevices(requesterDeviceUID) {
+	if !c.perm.CanAccessAllDevices(ctx, requesterDeviceUID) {
 		return []database.Device{}, nil
 	}
-	return c.service.GetAllDevices()
+	return c.service.GetAllDevices(ctx)
 }
 
-func (c *DeviceController) CreateDevice(userKey string, item database.Device, requesterDeviceUID uint64) error {
+func (c *DeviceController) CreateDevice(ctx context.Context, userKey string, item database.Device, requesterDeviceUID uint64) error {
 	if c.authz != nil && userKey != "" {
-		if uid, ok := c.authz.ResolveUserIDFromKey(userKey); ok {
-			isAdmin := c.authz.HasUserPermission(uid, "admin.manage")
+		if uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey); ok {
+			isAdmin := c.authz.HasUserPermission(ctx, uid, "admin.manage")
 			if !isAdmin {
 				if item.OwnerUserID != nil {
 					if *item.OwnerUserID != uid {
@@ -59,29 +82,29 @@ func (c *DeviceController) CreateDevice(userKey string, item database.Device, re
 				if item.ParentID == nil {
 					return fmt.Errorf("parent required")
 				}
-				parent, err := c.service.GetDeviceByID(*item.ParentID)
+				parent, err := c.service.GetDeviceByID(ctx, *item.ParentID)
 				if err != nil {
 					return fmt.Errorf("parent not found")
 				}
-				if !c.authz.CanControlDevice(requesterDeviceUID, parent.DeviceUID, uid) {
+				if !c.authz.CanControlDevice(ctx, requesterDeviceUID, parent.DeviceUID, uid) {
 					return fmt.Errorf("permission denied")
 				}
 			}
-			return c.service.CreateDevice(&item)
+			return c.service.CreateDevice(ctx, &item)
 		}
 		return fmt.Errorf("unauthorized")
 	}
-	if !c.perm.CanManageDevice(requesterDeviceUID, item.DeviceUID) {
+	if !c.perm.CanManageDevice(ctx, requesterDeviceUID, item.DeviceUID) {
 		return fmt.Errorf("permission denied")
 	}
-	return c.service.CreateDevice(&item)
+	return c.service.CreateDevice(ctx, &item)
 }
 
-func (c *DeviceController) UpdateDevice(userKey string, item database.Device, requesterDeviceUID uint64) error {
+func (c *DeviceController) UpdateDevice(ctx context.Context, userKey string, item database.Device, requesterDeviceUID uint64) error {
 	if c.authz != nil && userKey != "" {
-		if uid, ok := c.authz.ResolveUserIDFromKey(userKey); ok {
-			isAdmin := c.authz.HasUserPermission(uid, "admin.manage")
-			if !c.authz.CanControlDevice(requesterDeviceUID, item.DeviceUID, uid) {
+		if uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey); ok {
+			isAdmin := c.authz.HasUserPermission(ctx, uid, "admin.manage")
+			if !c.authz.CanControlDevice(ctx, requesterDeviceUID, item.DeviceUID, uid) {
 				return fmt.Errorf("permission denied")
 			}
 			if !isAdmin {
@@ -89,49 +112,84 @@ func (c *DeviceController) UpdateDevice(userKey string, item database.Device, re
 					return fmt.Errorf("permission denied")
 				}
 				if item.ParentID != nil {
-					parent, err := c.service.GetDeviceByID(*item.ParentID)
+					parent, err := c.service.GetDeviceByID(ctx, *item.ParentID)
 					if err != nil {
 						return fmt.Errorf("parent not found")
 					}
-					if !c.authz.CanControlDevice(requesterDeviceUID, parent.DeviceUID, uid) {
+					if !c.authz.CanControlDevice(ctx, requesterDeviceUID, parent.DeviceUID, uid) {
 						return fmt.Errorf("permission denied")
 					}
 				}
 			}
-			return c.service.UpdateDevice(&item)
+			return c.service.UpdateDevice(ctx, &item)
 		}
 		return fmt.Errorf("unauthorized")
 	}
-	if !c.perm.CanManageDevice(requesterDeviceUID, item.DeviceUID) {
+	if !c.perm.CanManageDevice(ctx, requesterDeviceUID, item.DeviceUID) {
+		return fmt.Errorf("permission denied")
+	}
+	return c.service.UpdateDevice(ctx, &item)
+}
+
+// SetTags 设置设备标签（OTA 按标签选择设备）：仅以用户密钥认证、可控制该设备的用户或管理员可设置，设备自身不可修改
+func (c *DeviceController) SetTags(ctx context.Context, userKey string, id uint64, tags string, requesterDeviceUID uint64) error {
+	if c.authz == nil || userKey == "" {
+		return fmt.Errorf("permission denied")
+	}
+	uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok {
+		return fmt.Errorf("unauthorized")
+	}
+	target, err := c.service.GetDeviceByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("not found")
+	}
+	if !c.authz.HasUserPermission(ctx, uid, "admin.manage") && !c.authz.CanControlDevice(ctx, requesterDeviceUID, target.DeviceUID, uid) {
 		return fmt.Errorf("permission denied")
 	}
-	return c.service.UpdateDevice(&item)
+	return c.service.UpdateTags(ctx, id, tags)
+}
+
+// DeleteDevice 删除设备并吊销其证书，返回被删除设备的 UID（供断开其连接）
+func (c *DeviceController) DeleteDevice(ctx context.Context, userKey string, id uint64, requesterDeviceUID uint64) (uint64, error) {
+	// 删除后无法再按主键查到设备，先取 UID
+	d, err := c.service.GetDeviceByID(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("not found")
+	}
+	if err := c.deleteDevice(ctx, userKey, id, requesterDeviceUID); err != nil {
+		return 0, err
+	}
+	if c.certs != nil {
+		c.revokeByUID(ctx, d.DeviceUID, "device deleted")
+	}
+	return d.DeviceUID, nil
 }
 
-func (c *DeviceController) DeleteDevice(userKey string, id uint64, requesterDeviceUID uint64) error {
+func (c *DeviceController) deleteDevice(ctx context.Context, userKey string, id uint64, requesterDeviceUID uint64) error {
 	if c.authz != nil && userKey != "" {
-		if uid, ok := c.authz.ResolveUserIDFromKey(userKey); ok {
-			target, err := c.service.GetDeviceByID(id)
+		if uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey); ok {
+			target, err := c.service.GetDeviceByID(ctx, id)
 			if err != nil {
 				return fmt.Errorf("not found")
 			}
-			if c.authz.HasUserPermission(uid, "admin.manage") {
-				return c.service.DeleteDevice(id)
+			if c.authz.HasUserPermission(ctx, uid, "admin.manage") {
+				return c.service.DeleteDevice(ctx, id)
 			}
 			if target.OwnerUserID != nil && *target.OwnerUserID == uid {
-				return c.service.DeleteDevice(id)
+				return c.service.DeleteDevice(ctx, id)
 			}
-			if c.authz.CanControlDevice(requesterDeviceUID, target.DeviceUID, uid) && target.OwnerUserID != nil && *target.OwnerUserID == uid {
-				return c.service.DeleteDevice(id)
+			if c.authz.CanControlDevice(ctx, requesterDeviceUID, target.DeviceUID, uid) && target.OwnerUserID != nil && *target.OwnerUserID == uid {
+				return c.service.DeleteDevice(ctx, id)
 			}
 			return fmt.Errorf("permission denied")
 		}
 		return fmt.Errorf("unauthorized")
 	}
-	if !c.perm.IsAdminDevice(requesterDeviceUID) {
+	if !c.perm.IsAdminDevice(ctx, requesterDeviceUID) {
 		return fmt.Errorf("permission denied")
 	}
-	return c.service.DeleteDevice(id)
+	return c.service.DeleteDevice(ctx, id)
 }
 
 // HandleQueryNodes 处理节点查询请求
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/service"
+)
+
+var (
+	errBadCredentials = errors.New("bad credentials")
+	errNotApproved    = errors.New("device not approved")
+)
+
+// DeviceCredentialController 认证非二进制协议接入（MQTT/CoAP/HTTP）的设备：凭据为设备密钥，
+// 或对该设备有控制权的用户 userKey
+type DeviceCredentialController struct {
+	auth    *service.AuthService
+	authz   *service.AuthzService
+	devices *repository.DeviceRepository
+}
+
+// NewDeviceCredentialController 创建一个新的 DeviceCredentialController
+func NewDeviceCredentialController(auth *service.AuthService, authz *service.AuthzService, devices *repository.DeviceRepository) *DeviceCredentialController {
+	return &DeviceCredentialController{auth: auth, authz: authz, devices: devices}
+}
+
+// AuthenticateMQTT username 为设备 UID（缺省取 ClientID），password 为凭据
+func (c *DeviceCredentialController) AuthenticateMQTT(clientID, username string, password []byte) (uint64, error) {
+	id := username
+	if id == "" {
+		id = clientID
+	}
+	uid, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, errBadCredentials
+	}
+	uid, _, err = c.authenticate(context.Background(), uid, password)
+	return uid, err
+}
+
+// AuthenticateCoAP 认证 CoAP /auth 请求
+func (c *DeviceCredentialController) AuthenticateCoAP(deviceUID uint64, credential []byte) (uint64, error) {
+	uid, _, err := c.authenticate(context.Background(), deviceUID, credential)
+	return uid, err
+}
+
+// authenticate 校验凭据；viaKey 表示凭据为 userKey（后续操作应按该 Key 的用户授权）
+func (c *DeviceCredentialController) authenticate(ctx context.Context, uid uint64, credential []byte) (deviceUID uint64, viaKey bool, err error) {
+	if uid == 0 || len(credential) == 0 {
+		return 0, false, errBadCredentials
+	}
+	dev, ok := c.auth.AuthenticateDevice(ctx, uid, string(credential))
+	if !ok {
+		userID, ok := c.authz.ResolveUserIDFromKey(ctx, string(credential))
+		if !ok || !c.authz.CanControlDevice(ctx, 0, uid, userID) {
+			return 0, false, errBadCredentials
+		}
+		if dev, err = c.devices.FindByUID(ctx, uid); err != nil {
+			return 0, false, errBadCredentials
+		}
+		viaKey = true
+	}
+	if !dev.Approved {
+		return 0, false, errNotApproved
+	}
+	return dev.DeviceUID, viaKey, nil
+}
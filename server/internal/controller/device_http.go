@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"myflowhub/server/internal/hub"
+	"myflowhub/server/internal/service"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	deviceHTTPMaxBody  = 1 << 20
+	deviceHTTPMaxItems = 500
+)
+
+// DeviceHTTP 设备 HTTP 上报接口：无需常驻连接即可读写变量。
+// 认证：请求头 X-Device-UID 为设备 UID，Authorization: Bearer <设备密钥或绑定该设备的 userKey>。
+type DeviceHTTP struct {
+	Cred      *DeviceCredentialController
+	Variables *VariableController
+	Presence  *service.PresenceService
+	Hub       *hub.Server
+}
+
+type deviceHTTPVar struct {
+	DeviceUID uint64          `json:"deviceUid,omitempty"`
+	Name      string          `json:"name"`
+	Value     json.RawMessage `json:"value"`
+}
+
+type deviceHTTPResult struct {
+	DeviceUID uint64 `json:"deviceUid"`
+	Name      string `json:"name"`
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Vars 处理 /device/v1/vars：
+//
+//	GET  ?names=a,b                          读取本设备变量（names 为空时返回全部）
+//	POST {"vars":[{"name","value","deviceUid"?}]} 批量写入（deviceUid 缺省为本设备），逐项返回结果
+func (d *DeviceHTTP) Vars(w http.ResponseWriter, r *http.Request) {
+	uid, userKey, status, err := d.authenticate(r)
+	if err != nil {
+		writeDeviceHTTP(w, status, map[string]any{"success": false, "message": err.Error()})
+		return
+	}
+	if d.Presence != nil {
+		d.Presence.Touch(uid, time.Now())
+	}
+	switch r.Method {
+	case http.MethodGet:
+		d.list(w, r, uid, userKey)
+	case http.MethodPost:
+		d.update(w, r, uid, userKey)
+	default:
+		writeDeviceHTTP(w, http.StatusMethodNotAllowed, map[string]any{"success": false, "message": "method not allowed"})
+	}
+}
+
+// authenticate 返回设备 UID；凭据为 userKey 时一并返回，以便按该 Key 的用户授权
+func (d *DeviceHTTP) authenticate(r *http.Request) (uint64, string, int, error) {
+	uid, err := strconv.ParseUint(strings.TrimSpace(r.Header.Get("X-Device-UID")), 10, 64)
+	if err != nil {
+		return 0, "", http.StatusUnauthorized, errors.New("missing or invalid X-Device-UID")
+	}
+	cred := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	uid, viaKey, err := d.Cred.authenticate(r.Context(), uid, []byte(cred))
+	switch {
+	case errors.Is(err, errNotApproved):
+		return 0, "", http.StatusForbidden, err
+	case err != nil:
+		return 0, "", http.StatusUnauthorized, err
+	}
+	if viaKey {
+		return uid, cred, 0, nil
+	}
+	return uid, "", 0, nil
+}
+
+func (d *DeviceHTTP) list(w http.ResponseWriter, r *http.Request, uid uint64, userKey string) {
+	list, err := d.Variables.List(r.Context(), userKey, &uid, uid)
+	if err != nil {
+		writeDeviceHTTP(w, http.StatusForbidden, map[string]any{"success": false, "message": err.Error()})
+		return
+	}
+	want := make(map[string]bool)
+	for _, n := range strings.Split(r.URL.Query().Get("names"), ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			want[n] = true
+		}
+	}
+	items := make([]map[string]any, 0, len(list))
+	for _, v := range list {
+		if len(want) > 0 && !want[v.VariableName] {
+			continue
+		}
+		items = append(items, map[string]any{"name": v.VariableName, "value": json.RawMessage(v.Value), "updatedAt": v.UpdatedAt.Unix()})
+	}
+	writeDeviceHTTP(w, http.StatusOK, map[string]any{"success": true, "deviceUid": uid, "data": items})
+}
+
+func (d *DeviceHTTP) update(w http.ResponseWriter, r *http.Request, uid uint64, userKey string) {
+	var body struct {
+		Vars []deviceHTTPVar `json:"vars"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, deviceHTTPMaxBody)).Decode(&body); err != nil || len(body.Vars) == 0 {
+		writeDeviceHTTP(w, http.StatusBadRequest, map[string]any{"success": false, "message": "bad request"})
+		return
+	}
+	if len(body.Vars) > deviceHTTPMaxItems {
+		writeDeviceHTTP(w, http.StatusRequestEntityTooLarge, map[string]any{"success": false, "message": "too many vars"})
+		return
+	}
+	results := make([]deviceHTTPResult, len(body.Vars))
+	items := make([]VarKV, 0, len(body.Vars))
+	for i, v := range body.Vars {
+		if v.DeviceUID == 0 {
+			v.DeviceUID = uid
+		}
+		results[i] = deviceHTTPResult{DeviceUID: v.DeviceUID, Name: v.Name}
+		switch {
+		case !hub.IsValidVarName(v.Name):
+			results[i].Error = "invalid name"
+		case len(bytes.TrimSpace(v.Value)) == 0:
+			results[i].Error = "missing value"
+		default:
+			items = append(items, VarKV{DeviceUID: v.DeviceUID, Name: v.Name, Value: v.Value})
+		}
+	}
+	updated, err := d.Variables.Update(r.Context(), userKey, items, uid)
+	var failed VarWriteErrors
+	if err != nil && !errors.As(err, &failed) {
+		log.Error().Err(err).Uint64("device", uid).Msg("HTTP 写入变量失败")
+		writeDeviceHTTP(w, http.StatusInternalServerError, map[string]any{"success": false, "message": "internal error"})
+		return
+	}
+	applied := make(map[VarKey]bool, len(updated))
+	for _, it := range updated {
+		applied[VarKey{DeviceUID: it.DeviceUID, Name: it.Name}] = true
+	}
+	okCount := 0
+	for i := range results {
+		if results[i].Error != "" {
+			continue
+		}
+		key := VarKey{DeviceUID: results[i].DeviceUID, Name: results[i].Name}
+		switch {
+		case applied[key]:
+			results[i].OK = true
+			okCount++
+		case failed[key] != nil:
+			results[i].Error = "internal error"
+		default:
+			results[i].Error = "permission denied or device not found"
+		}
+	}
+	if d.Hub != nil && len(updated) > 0 {
+		notifyVarChanged(d.Hub, uid, updated)
+	}
+	status := http.StatusOK
+	if len(failed) > 0 {
+		// 存储故障：逐项结果中标明失败项，整体以 5xx 提示可重试（写入幂等）
+		log.Error().Err(err).Uint64("device", uid).Msg("HTTP 写入变量失败")
+		status = http.StatusInternalServerError
+	}
+	writeDeviceHTTP(w, status, map[string]any{"success": okCount == len(results), "data": results})
+}
+
+func writeDeviceHTTP(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
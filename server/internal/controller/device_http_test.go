@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/database"
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/service"
+	"myflowhub/server/internal/testdb"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type deviceHTTPFixture struct {
+	t        *testing.T
+	db       *gorm.DB
+	http     *DeviceHTTP
+	presence *service.PresenceService
+}
+
+func newDeviceHTTPFixture(t *testing.T) *deviceHTTPFixture {
+	t.Helper()
+	ctx := context.Background()
+	db := testdb.Open(t)
+	devices := repository.NewDeviceRepository(db)
+	vars := repository.NewVariableRepository(db)
+	keys := service.NewKeyService(repository.NewKeyRepository(db), repository.NewPermissionRepository(db), devices)
+	authz := service.NewAuthzService(keys, devices, repository.NewPermissionRepository(db))
+	perm := service.NewPermissionService(devices)
+	if _, err := keys.CreateKey(ctx, 5, nil, nil, "owner-key", nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	presence := service.NewPresenceService(devices)
+	return &deviceHTTPFixture{
+		t:  t,
+		db: db,
+		http: &DeviceHTTP{
+			Cred:      NewDeviceCredentialController(service.NewAuthService(devices, vars), authz, devices),
+			Variables: NewVariableController(service.NewVariableService(vars), service.NewDeviceService(devices, vars, db), perm, authz),
+			Presence:  presence,
+		},
+		presence: presence,
+	}
+}
+
+// addDevice 创建设备（所有者为用户 5），secret 为其设备密钥
+func (f *deviceHTTPFixture) addDevice(hw, secret string, approved bool) *database.Device {
+	f.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.MinCost)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	owner := uint64(5)
+	d := &database.Device{HardwareID: hw, Role: database.RoleNode, Approved: approved, SecretKeyHash: string(hash), OwnerUserID: &owner}
+	if err := f.db.Create(d).Error; err != nil {
+		f.t.Fatal(err)
+	}
+	return d
+}
+
+func (f *deviceHTTPFixture) do(method string, uid uint64, cred, body string) (int, map[string]json.RawMessage) {
+	f.t.Helper()
+	r := httptest.NewRequest(method, "/device/v1/vars", strings.NewReader(body))
+	r.Header.Set("X-Device-UID", strconv.FormatUint(uid, 10))
+	if cred != "" {
+		r.Header.Set("Authorization", "Bearer "+cred)
+	}
+	w := httptest.NewRecorder()
+	f.http.Vars(w, r)
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		f.t.Fatalf("response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, out
+}
+
+func TestDeviceHTTPAuthentication(t *testing.T) {
+	f := newDeviceHTTPFixture(t)
+	dev := f.addDevice("node", "dev-secret", true)
+	pending := f.addDevice("pending", "pending-secret", false)
+
+	cases := []struct {
+		name   string
+		uid    uint64
+		cred   string
+		status int
+	}{
+		{"device secret", dev.DeviceUID, "dev-secret", http.StatusOK},
+		{"owner user key", dev.DeviceUID, "owner-key", http.StatusOK},
+		{"wrong secret", dev.DeviceUID, "pending-secret", http.StatusUnauthorized},
+		{"unknown key", dev.DeviceUID, "nope", http.StatusUnauthorized},
+		{"missing credential", dev.DeviceUID, "", http.StatusUnauthorized},
+		{"missing uid", 0, "dev-secret", http.StatusUnauthorized},
+		{"not approved", pending.DeviceUID, "pending-secret", http.StatusForbidden},
+		{"not approved via key", pending.DeviceUID, "owner-key", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if status, out := f.do(http.MethodGet, tc.uid, tc.cred, ""); status != tc.status {
+				t.Fatalf("status %d, want %d (%s)", status, tc.status, out["message"])
+			}
+		})
+	}
+	// 认证失败不刷新活跃时间
+	if _, ok := f.presence.Get(pending.DeviceUID); ok {
+		t.Fatal("rejected device touched presence")
+	}
+}
+
+func TestDeviceHTTPUpdate(t *testing.T) {
+	f := newDeviceHTTPFixture(t)
+	dev := f.addDevice("node", "dev-secret", true)
+	other := f.addDevice("other", "other-secret", true)
+	unowned := &database.Device{HardwareID: "unowned", Role: database.RoleNode, Approved: true}
+	if err := f.db.Create(unowned).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now().Unix()
+	body := `{"vars":[
+		{"name":"temp","value":21.5},
+		{"name":"bad name!","value":1},
+		{"name":"empty"},
+		{"deviceUid":` + strconv.FormatUint(other.DeviceUID, 10) + `,"name":"temp","value":1}
+	]}`
+	status, out := f.do(http.MethodPost, dev.DeviceUID, "dev-secret", body)
+	if status != http.StatusOK || string(out["success"]) != "false" {
+		t.Fatalf("status %d success %s", status, out["success"])
+	}
+	var results []deviceHTTPResult
+	if err := json.Unmarshal(out["data"], &results); err != nil {
+		t.Fatal(err)
+	}
+	want := []deviceHTTPResult{
+		{DeviceUID: dev.DeviceUID, Name: "temp", OK: true},
+		{DeviceUID: dev.DeviceUID, Name: "bad name!", Error: "invalid name"},
+		{DeviceUID: dev.DeviceUID, Name: "empty", Error: "missing value"},
+		// 设备密钥只能写本设备
+		{DeviceUID: other.DeviceUID, Name: "temp", Error: "permission denied or device not found"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("results %+v", results)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Fatalf("result %d = %+v, want %+v", i, results[i], want[i])
+		}
+	}
+
+	// 请求刷新活跃时间，但 HTTP 接入不视为在线
+	it, ok := f.presence.Get(dev.DeviceUID)
+	if !ok || it.Online || it.LastSeenSec < before {
+		t.Fatalf("presence %+v ok=%v", it, ok)
+	}
+
+	// userKey 按其用户授权：可写该用户拥有的其他设备
+	body = `{"vars":[
+		{"deviceUid":` + strconv.FormatUint(other.DeviceUID, 10) + `,"name":"temp","value":2},
+		{"deviceUid":` + strconv.FormatUint(unowned.DeviceUID, 10) + `,"name":"temp","value":3}
+	]}`
+	if status, out = f.do(http.MethodPost, dev.DeviceUID, "owner-key", body); status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	if err := json.Unmarshal(out["data"], &results); err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].OK || results[1].OK || results[1].Error == "" {
+		t.Fatalf("results %+v", results)
+	}
+
+	status, out = f.do(http.MethodGet, dev.DeviceUID, "dev-secret", "")
+	var list []struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(out["data"], &list); status != http.StatusOK || err != nil || len(list) != 1 || list[0].Name != "temp" || string(list[0].Value) != "21.5" {
+		t.Fatalf("status %d list %s", status, out["data"])
+	}
+}
+
+func TestDeviceHTTPStorageError(t *testing.T) {
+	f := newDeviceHTTPFixture(t)
+	dev := f.addDevice("node", "dev-secret", true)
+	// 模拟存储故障：名为 broken 的变量写入失败
+	err := f.db.Callback().Create().Before("gorm:create").Register("test:fail_var", func(tx *gorm.DB) {
+		if v, ok := tx.Statement.Dest.(*database.DeviceVariable); ok && v.VariableName == "broken" {
+			_ = tx.AddError(errors.New("disk full"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, out := f.do(http.MethodPost, dev.DeviceUID, "dev-secret", `{"vars":[{"name":"broken","value":1},{"name":"temp","value":2}]}`)
+	if status != http.StatusInternalServerError || string(out["success"]) != "false" {
+		t.Fatalf("status %d success %s", status, out["success"])
+	}
+	var results []deviceHTTPResult
+	if err := json.Unmarshal(out["data"], &results); err != nil {
+		t.Fatal(err)
+	}
+	// 其余条目照常写入，失败项单独标明
+	if len(results) != 2 || results[0].OK || results[0].Error != "internal error" || !results[1].OK {
+		t.Fatalf("results %+v", results)
+	}
+
+	_, err = f.http.Variables.Update(context.Background(), "", []VarKV{{DeviceUID: dev.DeviceUID, Name: "broken", Value: []byte("1")}}, dev.DeviceUID)
+	var failed VarWriteErrors
+	if !errors.As(err, &failed) || failed[VarKey{DeviceUID: dev.DeviceUID, Name: "broken"}] == nil {
+		t.Fatalf("err = %v", err)
+	}
+}
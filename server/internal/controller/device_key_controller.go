@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/service"
+)
+
+var (
+	errBadPublicKey   = errors.New("invalid public key")
+	errKeyPermission  = errors.New("permission denied")
+	maxRotateGraceSec = uint32(7 * 24 * 3600)
+)
+
+// DeviceKeyController Ed25519 设备认证：签发挑战、校验签名，以及公钥的登记与轮换
+type DeviceKeyController struct {
+	auth    *service.AuthService
+	authz   *service.AuthzService
+	devices *repository.DeviceRepository
+	audit   *service.AuditService
+	nonces  *service.NonceStore
+}
+
+// NewDeviceKeyController 创建一个新的 DeviceKeyController
+func NewDeviceKeyController(auth *service.AuthService, authz *service.AuthzService, devices *repository.DeviceRepository, audit *service.AuditService, nonces *service.NonceStore) *DeviceKeyController {
+	return &DeviceKeyController{auth: auth, authz: authz, devices: devices, audit: audit, nonces: nonces}
+}
+
+// Challenge 生成一次性 nonce 及其过期时间；不论设备是否存在均签发，避免探测已登记的 HardwareID
+func (c *DeviceKeyController) Challenge() ([]byte, time.Time, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, time.Time{}, err
+	}
+	ttl := config.AppConfig.DeviceAuth.ChallengeTTLSec
+	if ttl <= 0 {
+		ttl = 30
+	}
+	return nonce, time.Now().Add(time.Duration(ttl) * time.Second), nil
+}
+
+// Authenticate 校验签名；通过后还须已审批
+func (c *DeviceKeyController) Authenticate(ctx context.Context, hardwareID string, nonce, sig, frameMACPub []byte, ip, ua string) (*database.Device, error) {
+	dev, err := c.auth.AuthenticateDeviceKey(ctx, hardwareID, nonce, sig, frameMACPub)
+	if err != nil {
+		c.writeAudit(ctx, nil, "device.auth", "device:"+hardwareID, "deny", ip, ua, nil)
+		return nil, errBadCredentials
+	}
+	uid := dev.DeviceUID
+	if !dev.Approved {
+		c.writeAudit(ctx, &uid, "device.auth", "device:"+hardwareID, "deny", ip, ua, map[string]any{"reason": "not approved"})
+		return nil, errNotApproved
+	}
+	c.writeAudit(ctx, &uid, "device.auth", "device:"+hardwareID, "allow", ip, ua, nil)
+	return dev, nil
+}
+
+// SetKey 登记或轮换设备公钥。凭据按以下顺序之一成立即可：
+// 对该设备有控制权的 userKey；旧私钥对新公钥的签名；设备尚未登记公钥时，
+// 请求连接已认证为该设备本身或提供正确的旧设备密钥（允许密钥认证时）。
+func (c *DeviceKeyController) SetKey(ctx context.Context, req bin.DeviceKeySet, requesterDeviceUID uint64, ip, ua string) error {
+	if len(req.PublicKey) != ed25519.PublicKeySize {
+		return errBadPublicKey
+	}
+	dev, err := c.devices.FindByUID(ctx, req.DeviceUID)
+	if err != nil {
+		return errBadCredentials
+	}
+	var method string
+	switch {
+	case req.UserKey != "":
+		if userID, ok := c.authz.ResolveUserIDFromKey(ctx, req.UserKey); ok && c.authz.CanControlDevice(ctx, requesterDeviceUID, dev.DeviceUID, userID) {
+			method = "user_key"
+		}
+	case len(req.OldKeySig) > 0:
+		// 同一轮换签名只能使用一次，防止截获后在公钥回退等场景下重放
+		if service.VerifyKeyRotation(dev, req.PublicKey, req.OldKeySig) && c.nonces.Use(ctx, nonceScopeKeyRotate, req.OldKeySig) == nil {
+			method = "rotate"
+		}
+	case len(dev.PublicKey) == 0 && requesterDeviceUID != 0 && requesterDeviceUID == dev.DeviceUID:
+		method = "session"
+	case len(dev.PublicKey) == 0 && req.Secret != "":
+		if service.CheckDeviceSecret(dev, req.Secret) {
+			method = "secret"
+		}
+	}
+	uid := dev.DeviceUID
+	resource := fmt.Sprintf("device:%d", uid)
+	if method == "" {
+		c.writeAudit(ctx, &uid, "device.key.set", resource, "deny", ip, ua, nil)
+		return errBadCredentials
+	}
+	if err := c.auth.SetDevicePublicKey(ctx, dev, req.PublicKey); err != nil {
+		return err
+	}
+	c.writeAudit(ctx, &uid, "device.key.set", resource, "allow", ip, ua, map[string]any{"method": method})
+	return nil
+}
+
+// RotateSecret 轮换设备密钥。无 userKey 时仅能轮换请求连接自身；userKey 须对设备有控制权，
+// force 还须为管理员（旧密钥立即失效，由调用方断开设备连接）。返回新密钥明文与宽限截止时间（无宽限为零值）
+func (c *DeviceKeyController) RotateSecret(ctx context.Context, deviceUID uint64, userKey string, graceSec uint32, force bool, requesterDeviceUID uint64, ip, ua string) (string, time.Time, error) {
+	dev, err := c.devices.FindByUID(ctx, deviceUID)
+	if err != nil {
+		return "", time.Time{}, errBadCredentials
+	}
+	by := "device"
+	if userKey != "" {
+		userID, ok := c.authz.ResolveUserIDFromKey(ctx, userKey)
+		if !ok {
+			return "", time.Time{}, errBadCredentials
+		}
+		if !c.authz.CanControlDevice(ctx, requesterDeviceUID, dev.DeviceUID, userID) || (force && !c.authz.HasUserPermission(ctx, userID, "admin.manage")) {
+			uid := dev.DeviceUID
+			c.writeAudit(ctx, &uid, "device.secret.rotate", fmt.Sprintf("device:%d", uid), "deny", ip, ua, map[string]any{"force": force})
+			return "", time.Time{}, errKeyPermission
+		}
+		by = fmt.Sprintf("user:%d", userID)
+	} else if requesterDeviceUID == 0 || requesterDeviceUID != dev.DeviceUID || force {
+		return "", time.Time{}, errKeyPermission
+	}
+	var grace time.Duration
+	if !force {
+		if graceSec == 0 {
+			if d := config.AppConfig.DeviceAuth.RotateGraceSec; d > 0 {
+				graceSec = uint32(d)
+			} else {
+				graceSec = 3600
+			}
+		}
+		grace = time.Duration(min(graceSec, maxRotateGraceSec)) * time.Second
+	}
+	secret, until, err := c.auth.RotateDeviceSecret(ctx, dev, grace)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var graceUntil time.Time
+	if until != nil {
+		graceUntil = *until
+	}
+	uid := dev.DeviceUID
+	c.writeAudit(ctx, &uid, "device.secret.rotate", fmt.Sprintf("device:%d", uid), "allow", ip, ua, map[string]any{"by": by, "force": force, "graceUntil": graceUntil.Unix()})
+	return secret, graceUntil, nil
+}
+
+func (c *DeviceKeyController) writeAudit(ctx context.Context, uid *uint64, action, resource, decision, ip, ua string, extra map[string]any) {
+	if c.audit == nil {
+		return
+	}
+	var b []byte
+	if extra != nil {
+		b, _ = json.Marshal(extra)
+	}
+	_ = c.audit.Write(ctx, "device", uid, action, resource, decision, ip, ua, b)
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/service"
+)
+
+var errSessionPermission = errors.New("permission denied")
+
+// DeviceSessionController 查询设备连接会话历史
+type DeviceSessionController struct {
+	svc   *service.DeviceSessionService
+	perm  *service.PermissionService
+	authz *service.AuthzService
+}
+
+// NewDeviceSessionController 创建一个新的 DeviceSessionController
+func NewDeviceSessionController(svc *service.DeviceSessionService, perm *service.PermissionService, authz *service.AuthzService) *DeviceSessionController {
+	return &DeviceSessionController{svc: svc, perm: perm, authz: authz}
+}
+
+// List 返回设备会话分页；用户需能控制该设备，设备仅可查询自身或有读权限的设备
+func (c *DeviceSessionController) List(ctx context.Context, userKey string, deviceUID uint64, page, pageSize int32, requesterDeviceUID uint64) (items []bin.DeviceSessionItem, total int64, outPage, outSize int32, err error) {
+	if deviceUID == 0 {
+		deviceUID = requesterDeviceUID
+	}
+	if !c.allowed(ctx, userKey, requesterDeviceUID, deviceUID) {
+		return nil, 0, 0, 0, errSessionPermission
+	}
+	res, err := c.svc.List(ctx, deviceUID, int(page), int(pageSize))
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	items = make([]bin.DeviceSessionItem, 0, len(res.Items))
+	for _, s := range res.Items {
+		it := bin.DeviceSessionItem{
+			ID: s.ID, DeviceUID: s.DeviceUID, Hub: s.Hub, ParentPath: s.ParentPath,
+			RemoteAddr: s.RemoteAddr, UserAgent: s.UserAgent, Protocol: s.Protocol,
+			StartedAt: s.StartedAt.Unix(), DurationMs: s.DurationMs,
+			BytesIn: s.BytesIn, BytesOut: s.BytesOut, FramesIn: s.FramesIn, FramesOut: s.FramesOut,
+			RawBytesIn: s.RawBytesIn, RawBytesOut: s.RawBytesOut, CloseReason: s.CloseReason,
+		}
+		if s.AuthedAt != nil {
+			it.AuthedAt = s.AuthedAt.Unix()
+		}
+		if s.EndedAt != nil {
+			it.EndedAt = s.EndedAt.Unix()
+		}
+		items = append(items, it)
+	}
+	return items, res.Total, int32(res.Page), int32(res.Size), nil
+}
+
+func (c *DeviceSessionController) allowed(ctx context.Context, userKey string, requesterDeviceUID, targetUID uint64) bool {
+	if c.authz != nil && userKey != "" {
+		if uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey); ok {
+			return c.authz.HasUserPermission(ctx, uid, "admin.manage") || c.authz.CanControlDevice(ctx, requesterDeviceUID, targetUID, uid)
+		}
+		return false
+	}
+	return targetUID == requesterDeviceUID || c.perm.CanReadVarsForDevice(ctx, requesterDeviceUID, targetUID)
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/service"
+)
+
+var (
+	errBadE2EKey      = errors.New("invalid x25519 public key")
+	errE2EQueryTooBig = errors.New("too many device uids")
+)
+
+// maxE2EKeyQuery 单次 E2E_KEY_GET_REQ 可查询的设备数
+const maxE2EKeyQuery = 100
+
+// E2EKeyController 端到端加密公钥目录：设备发布自身 X25519 公钥，其他设备按 UID 查询后自行加密 MSG_SEND 负载
+type E2EKeyController struct {
+	devices *repository.DeviceRepository
+	audit   *service.AuditService
+}
+
+// NewE2EKeyController 创建一个新的 E2EKeyController
+func NewE2EKeyController(devices *repository.DeviceRepository, audit *service.AuditService) *E2EKeyController {
+	return &E2EKeyController{devices: devices, audit: audit}
+}
+
+// Publish 登记请求连接所代表设备的公钥；返回目录条目及公钥是否发生变化（相同公钥重复发布不视为变化）
+func (c *E2EKeyController) Publish(ctx context.Context, deviceUID uint64, key []byte, ip, ua string) (bin.E2EKey, bool, error) {
+	if _, err := ecdh.X25519().NewPublicKey(key); err != nil {
+		return bin.E2EKey{}, false, errBadE2EKey
+	}
+	dev, err := c.devices.FindByUID(ctx, deviceUID)
+	if err != nil {
+		return bin.E2EKey{}, false, errBadCredentials
+	}
+	if bytes.Equal(dev.E2EPublicKey, key) && dev.E2EKeyUpdatedAt != nil {
+		return bin.E2EKey{DeviceUID: deviceUID, PublicKey: key, UpdatedAt: dev.E2EKeyUpdatedAt.UnixMilli()}, false, nil
+	}
+	now := time.Now()
+	if err := c.devices.UpdateE2EKey(ctx, dev.ID, key, now); err != nil {
+		return bin.E2EKey{}, false, err
+	}
+	if c.audit != nil {
+		kid := bin.E2EKeyID(key)
+		extra, _ := json.Marshal(map[string]any{"keyId": hex.EncodeToString(kid[:])})
+		_ = c.audit.Write(ctx, "device", &deviceUID, "device.e2e_key.publish", fmt.Sprintf("device:%d", deviceUID), "allow", ip, ua, extra)
+	}
+	return bin.E2EKey{DeviceUID: deviceUID, PublicKey: key, UpdatedAt: now.UnixMilli()}, true, nil
+}
+
+// Get 返回指定设备已发布的公钥；未发布的设备不出现在结果中
+func (c *E2EKeyController) Get(ctx context.Context, deviceUIDs []uint64) ([]bin.E2EKey, error) {
+	if len(deviceUIDs) > maxE2EKeyQuery {
+		return nil, errE2EQueryTooBig
+	}
+	if len(deviceUIDs) == 0 {
+		return nil, nil
+	}
+	devs, err := c.devices.FindE2EKeys(ctx, deviceUIDs)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]bin.E2EKey, 0, len(devs))
+	for _, d := range devs {
+		k := bin.E2EKey{DeviceUID: d.DeviceUID, PublicKey: d.E2EPublicKey}
+		if d.E2EKeyUpdatedAt != nil {
+			k.UpdatedAt = d.E2EKeyUpdatedAt.UnixMilli()
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/database"
+	binproto "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/hub"
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/testdb"
+)
+
+// recvFrame 等待 c 的下一帧并按类型核对
+func recvFrame(t *testing.T, c *hub.Client, typeID uint16) []byte {
+	t.Helper()
+	select {
+	case frame := <-c.Send:
+		h, pl, err := binproto.DecodeFrame(frame)
+		if err != nil || h.TypeID != typeID {
+			t.Fatalf("got type %d, want %d: %v", h.TypeID, typeID, err)
+		}
+		return pl
+	case <-time.After(5 * time.Second):
+		t.Fatalf("device %d received nothing", c.DeviceID)
+		return nil
+	}
+}
+
+func TestE2EKeyPublishAndLookup(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.Open(t)
+	var devs [3]database.Device
+	for i := range devs {
+		devs[i] = database.Device{HardwareID: string(rune('a' + i)), Role: database.RoleNode, Approved: true}
+		if err := db.Create(&devs[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob, carol := devs[0].DeviceUID, devs[1].DeviceUID, devs[2].DeviceUID
+	s := hub.NewServer("", "", "hub-test")
+	go s.Run()
+	e2b := &E2EKeyBin{C: NewE2EKeyController(repository.NewDeviceRepository(db), nil)}
+	a, b := s.ConnectVirtual(alice, "test", 0), s.ConnectVirtual(bob, "test", 0)
+	msgID := uint64(0)
+	call := func(c *hub.Client, handler hub.BinHandler, typeID uint16, payload []byte) {
+		msgID++
+		handler(ctx, s, c, binproto.HeaderV1{TypeID: typeID, MsgID: msgID, Source: c.DeviceID}, payload)
+	}
+	publish := func(c *hub.Client, key []byte) {
+		call(c, e2b.Publish, binproto.TypeE2EKeyPublishReq, binproto.EncodeE2EKeyPublishReq(key))
+	}
+	get := func(c *hub.Client, uids ...uint64) []binproto.E2EKey {
+		call(c, e2b.Get, binproto.TypeE2EKeyGetReq, binproto.EncodeE2EKeyGetReq(uids))
+		_, keys, err := binproto.DecodeE2EKeyGetResp(recvFrame(t, c, binproto.TypeE2EKeyGetResp))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return keys
+	}
+	newKey := func() []byte {
+		k, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k.PublicKey().Bytes()
+	}
+
+	// 发布：以连接的设备身份登记，非法公钥被拒绝
+	key1 := newKey()
+	publish(a, key1)
+	recvFrame(t, a, binproto.TypeOKResp)
+	publish(a, []byte("short"))
+	recvFrame(t, a, binproto.TypeErrResp)
+
+	// 查询：未发布的设备不出现在结果中
+	keys := get(b, alice, carol)
+	if len(keys) != 1 || keys[0].DeviceUID != alice || !bytes.Equal(keys[0].PublicKey, key1) || keys[0].UpdatedAt == 0 {
+		t.Fatalf("lookup %+v", keys)
+	}
+	tooMany := make([]uint64, maxE2EKeyQuery+1)
+	call(b, e2b.Get, binproto.TypeE2EKeyGetReq, binproto.EncodeE2EKeyGetReq(tooMany))
+	recvFrame(t, b, binproto.TypeErrResp)
+
+	// 重复发布相同公钥不通知；轮换后向查询过的连接推送新公钥
+	publish(a, key1)
+	recvFrame(t, a, binproto.TypeOKResp)
+	if len(b.Send) != 0 {
+		t.Fatal("unchanged key announced")
+	}
+	key2 := newKey()
+	publish(a, key2)
+	recvFrame(t, a, binproto.TypeOKResp)
+	changed, err := binproto.DecodeE2EKeyChangedNotify(recvFrame(t, b, binproto.TypeE2EKeyChangedNotify))
+	if err != nil || len(changed) != 1 || changed[0].DeviceUID != alice || !bytes.Equal(changed[0].PublicKey, key2) {
+		t.Fatalf("notify %+v: %v", changed, err)
+	}
+	if keys = get(b, alice); len(keys) != 1 || !bytes.Equal(keys[0].PublicKey, key2) {
+		t.Fatalf("lookup after rotation %+v", keys)
+	}
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/service"
+)
+
+// FileController 负责处理文件分片上传（TypeID 300~306）
+type FileController struct {
+	svc *service.FileService
+}
+
+// NewFileController 创建一个新的 FileController
+func NewFileController(svc *service.FileService) *FileController {
+	return &FileController{svc: svc}
+}
+
+// Init 开始或恢复一次上传，返回断点偏移与协商参数
+func (c *FileController) Init(ctx context.Context, ownerUID uint64, init bin.FileInit) (resume uint64, chunkSize, window uint32, err error) {
+	resume, err = c.svc.Begin(ctx, ownerUID, init)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	chunkSize, window = c.svc.Params(init.ChunkSize)
+	return resume, chunkSize, window, nil
+}
+
+// Chunk 写入分片，返回累计确认偏移
+func (c *FileController) Chunk(ctx context.Context, ownerUID, transferID, offset uint64, chunk []byte, crc *uint32) (uint64, error) {
+	return c.svc.Chunk(ctx, ownerUID, transferID, offset, chunk, crc)
+}
+
+// Complete 校验并落盘，返回文件 ID
+func (c *FileController) Complete(ctx context.Context, ownerUID, transferID uint64) (uint64, error) {
+	return c.svc.Complete(ctx, ownerUID, transferID)
+}
+
+// Cancel 取消上传
+func (c *FileController) Cancel(ctx context.Context, ownerUID, transferID uint64) error {
+	return c.svc.Cancel(ctx, ownerUID, transferID)
+}
+
+// HandlePeerResponse 处理设备对 Hub 下发传输的应答
+func (c *FileController) HandlePeerResponse(peerUID uint64, typeID uint16, payload []byte) (bool, error) {
+	return c.svc.HandlePeerResponse(peerUID, typeID, payload)
+}
+
+// Suspend 设备断开时挂起其在途上传
+func (c *FileController) Suspend(ownerUID uint64) {
+	c.svc.Suspend(ownerUID)
+}
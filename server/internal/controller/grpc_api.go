@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	binproto "myflowhub/pkg/protocol/binproto"
+	"myflowhub/pkg/protocol/grpcapi"
+	"myflowhub/pkg/protocol/pb"
+	"myflowhub/server/internal/hub"
+	"myflowhub/server/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// GRPCAPI 实现 grpcapi.MyFlowHubServer：一元接口经 hub.Invoke 复用二进制路由（授权与业务逻辑一致），
+// 流接口订阅 Hub 的变量变更与在线状态事件
+type GRPCAPI struct {
+	grpcapi.UnimplementedMyFlowHubServer
+	Hub      *hub.Server
+	Authz    *service.AuthzService
+	Presence *PresenceController
+}
+
+// grpcUserKey 从 metadata 读取 userKey：x-user-key 优先，其次 authorization: Bearer
+func grpcUserKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get("x-user-key"); len(v) > 0 && v[0] != "" {
+		return v[0]
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		if key, ok := strings.CutPrefix(v[0], "Bearer "); ok {
+			return strings.TrimSpace(key)
+		}
+	}
+	return ""
+}
+
+// grpcCode 将 ERR_RESP 的 code 映射为 gRPC 状态码
+func grpcCode(code int32) codes.Code {
+	switch code {
+	case 400:
+		return codes.InvalidArgument
+	case 401:
+		return codes.Unauthenticated
+	case 403:
+		return codes.PermissionDenied
+	case 404:
+		return codes.NotFound
+	case 409:
+		return codes.AlreadyExists
+	case 429:
+		return codes.ResourceExhausted
+	case 503:
+		return codes.Unavailable
+	}
+	return codes.Unknown
+}
+
+// invoke 以 metadata 中的 userKey 覆盖请求的 user_key 字段，执行对应 TypeID 的路由并解码应答
+func (g *GRPCAPI) invoke(ctx context.Context, typeID uint16, req, resp proto.Message) error {
+	if key := grpcUserKey(ctx); key != "" {
+		m := req.ProtoReflect()
+		if fd := m.Descriptor().Fields().ByName("user_key"); fd != nil {
+			m.Set(fd, protoreflect.ValueOfString(key))
+		}
+	}
+	payload, err := proto.Marshal(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	h, pl, err := g.Hub.Invoke(ctx, typeID, payload)
+	if err != nil {
+		if errors.Is(err, hub.ErrNoRoute) {
+			return status.Error(codes.Unimplemented, "route not registered")
+		}
+		return status.FromContextError(err).Err()
+	}
+	if h.TypeID == binproto.TypeErrResp {
+		var e pb.ErrResp
+		if err := proto.Unmarshal(pl, &e); err != nil {
+			return status.Error(codes.Internal, "bad error response")
+		}
+		return status.Error(grpcCode(e.GetCode()), string(e.GetMessage()))
+	}
+	if err := proto.Unmarshal(pl, resp); err != nil {
+		return status.Error(codes.Internal, "bad response")
+	}
+	return nil
+}
+
+// ========== 设备 ==========
+func (g *GRPCAPI) QueryNodes(ctx context.Context, req *pb.QueryNodesReq) (*pb.QueryNodesResp, error) {
+	resp := &pb.QueryNodesResp{}
+	return resp, g.invoke(ctx, binproto.TypeQueryNodesReq, req, resp)
+}
+
+func (g *GRPCAPI) CreateDevice(ctx context.Context, req *pb.CreateDeviceReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeCreateDeviceReq, req, resp)
+}
+
+func (g *GRPCAPI) UpdateDevice(ctx context.Context, req *pb.UpdateDeviceReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeUpdateDeviceReq, req, resp)
+}
+
+func (g *GRPCAPI) DeleteDevice(ctx context.Context, req *pb.DeleteDeviceReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeDeleteDeviceReq, req, resp)
+}
+
+// ========== 变量 ==========
+func (g *GRPCAPI) ListVariables(ctx context.Context, req *pb.VarListReq) (*pb.VarListResp, error) {
+	resp := &pb.VarListResp{}
+	return resp, g.invoke(ctx, binproto.TypeVarListReq, req, resp)
+}
+
+func (g *GRPCAPI) UpdateVariables(ctx context.Context, req *pb.VarUpdateReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeVarUpdateReq, req, resp)
+}
+
+func (g *GRPCAPI) DeleteVariables(ctx context.Context, req *pb.VarDeleteReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeVarDeleteReq, req, resp)
+}
+
+// ========== 用户 ==========
+func (g *GRPCAPI) Me(ctx context.Context, req *pb.UserMeReq) (*pb.UserMeResp, error) {
+	resp := &pb.UserMeResp{}
+	return resp, g.invoke(ctx, binproto.TypeUserMeReq, req, resp)
+}
+
+func (g *GRPCAPI) ListUsers(ctx context.Context, req *pb.UserListReq) (*pb.UserListResp, error) {
+	resp := &pb.UserListResp{}
+	return resp, g.invoke(ctx, binproto.TypeUserListReq, req, resp)
+}
+
+func (g *GRPCAPI) CreateUser(ctx context.Context, req *pb.UserCreateReq) (*pb.UserCreateResp, error) {
+	resp := &pb.UserCreateResp{}
+	return resp, g.invoke(ctx, binproto.TypeUserCreateReq, req, resp)
+}
+
+func (g *GRPCAPI) UpdateUser(ctx context.Context, req *pb.UserUpdateReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeUserUpdateReq, req, resp)
+}
+
+func (g *GRPCAPI) DeleteUser(ctx context.Context, req *pb.UserDeleteReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeUserDeleteReq, req, resp)
+}
+
+func (g *GRPCAPI) ListUserPerms(ctx context.Context, req *pb.UserPermListReq) (*pb.UserPermListResp, error) {
+	resp := &pb.UserPermListResp{}
+	return resp, g.invoke(ctx, binproto.TypeUserPermListReq, req, resp)
+}
+
+func (g *GRPCAPI) AddUserPerm(ctx context.Context, req *pb.UserPermAddReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeUserPermAddReq, req, resp)
+}
+
+func (g *GRPCAPI) RemoveUserPerm(ctx context.Context, req *pb.UserPermRemoveReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeUserPermRemoveReq, req, resp)
+}
+
+// ========== 密钥 ==========
+func (g *GRPCAPI) ListKeys(ctx context.Context, req *pb.KeyListReq) (*pb.KeyListResp, error) {
+	resp := &pb.KeyListResp{}
+	return resp, g.invoke(ctx, binproto.TypeKeyListReq, req, resp)
+}
+
+func (g *GRPCAPI) CreateKey(ctx context.Context, req *pb.KeyCreateReq) (*pb.KeyCreateResp, error) {
+	resp := &pb.KeyCreateResp{}
+	return resp, g.invoke(ctx, binproto.TypeKeyCreateReq, req, resp)
+}
+
+func (g *GRPCAPI) UpdateKey(ctx context.Context, req *pb.KeyUpdateReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeKeyUpdateReq, req, resp)
+}
+
+func (g *GRPCAPI) DeleteKey(ctx context.Context, req *pb.KeyDeleteReq) (*pb.OKResp, error) {
+	resp := &pb.OKResp{}
+	return resp, g.invoke(ctx, binproto.TypeKeyDeleteReq, req, resp)
+}
+
+func (g *GRPCAPI) KeyDevices(ctx context.Context, req *pb.KeyDevicesReq) (*pb.KeyDevicesResp, error) {
+	resp := &pb.KeyDevicesResp{}
+	return resp, g.invoke(ctx, binproto.TypeKeyDevicesReq, req, resp)
+}
+
+// ========== 系统日志 ==========
+func (g *GRPCAPI) ListSystemLogs(ctx context.Context, req *pb.SystemLogListReq) (*pb.SystemLogListResp, error) {
+	resp := &pb.SystemLogListResp{}
+	return resp, g.invoke(ctx, binproto.TypeSystemLogListReq, req, resp)
+}
+
+// ========== 流 ==========
+
+// watchFilter 解析 userKey 并返回设备过滤器（与变量读取一致：管理员或设备所有者）。
+// 指定 deviceUIDs 时须全部有权限；否则按需判定并在流期间缓存结果。
+func (g *GRPCAPI) watchFilter(ctx context.Context, bodyKey string, deviceUIDs []uint64) (string, func(uint64) bool, error) {
+	key := grpcUserKey(ctx)
+	if key == "" {
+		key = bodyKey
+	}
+	uid, ok := g.Authz.ResolveUserIDFromKey(ctx, key)
+	if !ok {
+		return "", nil, status.Error(codes.Unauthenticated, "invalid user key")
+	}
+	allowed := make(map[uint64]bool)
+	if len(deviceUIDs) > 0 {
+		for _, d := range deviceUIDs {
+			if !g.Authz.CanControlDevice(ctx, 0, d, uid) {
+				return "", nil, status.Errorf(codes.PermissionDenied, "device %d not permitted", d)
+			}
+			allowed[d] = true
+		}
+		return key, func(d uint64) bool { return allowed[d] }, nil
+	}
+	return key, func(d uint64) bool {
+		v, ok := allowed[d]
+		if !ok {
+			v = g.Authz.CanControlDevice(ctx, 0, d, uid)
+			allowed[d] = v
+		}
+		return v
+	}, nil
+}
+
+func (g *GRPCAPI) WatchVariables(req *grpcapi.WatchVariablesReq, stream grpc.ServerStreamingServer[pb.VarChangedNotify]) error {
+	ctx := stream.Context()
+	_, allow, err := g.watchFilter(ctx, req.GetUserKey(), req.GetDeviceUids())
+	if err != nil {
+		return err
+	}
+	events, cancel := g.Hub.WatchVars(64)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-events:
+			items := make([]*pb.VarUpdateItem, 0, len(ev.Items))
+			for _, it := range ev.Items {
+				if allow(it.DeviceUID) {
+					items = append(items, &pb.VarUpdateItem{DeviceUid: it.DeviceUID, Name: it.Name, Value: it.Value})
+				}
+			}
+			if len(items) == 0 {
+				continue
+			}
+			if err := stream.Send(&pb.VarChangedNotify{SourceDeviceUid: ev.Source, Items: items}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (g *GRPCAPI) WatchPresence(req *grpcapi.WatchPresenceReq, stream grpc.ServerStreamingServer[pb.PresenceEvent]) error {
+	ctx := stream.Context()
+	key, allow, err := g.watchFilter(ctx, req.GetUserKey(), req.GetDeviceUids())
+	if err != nil {
+		return err
+	}
+	// 先订阅再取快照，避免两者之间的变化丢失
+	events, cancel := g.Hub.WatchPresence(64)
+	defer cancel()
+	snapshot, err := g.Presence.Query(ctx, key, req.GetDeviceUids(), 0)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err := stream.Send(&pb.PresenceEvent{Items: presenceToPB(snapshot, allow), Snapshot: true}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case changed := <-events:
+			items := presenceToPB(changed, allow)
+			if len(items) == 0 {
+				continue
+			}
+			if err := stream.Send(&pb.PresenceEvent{Items: items}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func presenceToPB(items []binproto.PresenceItem, allow func(uint64) bool) []*pb.PresenceItem {
+	out := make([]*pb.PresenceItem, 0, len(items))
+	for _, it := range items {
+		if allow(it.DeviceUID) {
+			out = append(out, &pb.PresenceItem{DeviceUid: it.DeviceUID, Online: it.Online, LastSeenSec: it.LastSeenSec, Via: it.Via})
+		}
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	binproto "myflowhub/pkg/protocol/binproto"
+	"myflowhub/pkg/protocol/grpcapi"
+	"myflowhub/pkg/protocol/pb"
+	"myflowhub/server/internal/hub"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// startGRPC 以内存连接启动 gRPC 服务，返回客户端；s 上注册的路由即 gRPC 接口的后端
+func startGRPC(t *testing.T, s *hub.Server) grpcapi.MyFlowHubClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	grpcapi.RegisterMyFlowHubServer(srv, &GRPCAPI{Hub: s})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///hub",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return grpcapi.NewMyFlowHubClient(conn)
+}
+
+// echoMe USER_ME 测试路由：以请求中的 user_key 作为用户名应答，"denied" 返回 403
+func echoMe(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+	var req pb.UserMeReq
+	if err := proto.Unmarshal(payload, &req); err != nil {
+		sendErr(ctx, s, c, h, 400, "bad request")
+		return
+	}
+	if req.GetUserKey() == "denied" {
+		sendErr(ctx, s, c, h, 403, "forbidden")
+		return
+	}
+	pl, _ := proto.Marshal(&pb.UserMeResp{RequestId: h.MsgID, Username: req.GetUserKey()})
+	sendFrame(s, c, h, binproto.TypeUserMeResp, pl)
+}
+
+func TestGRPCUserKeyMetadata(t *testing.T) {
+	s := hub.NewServer("", "", "hub-test")
+	s.RegisterBinRoute(binproto.TypeUserMeReq, echoMe)
+	client := startGRPC(t, s)
+	cases := []struct {
+		name string
+		md   []string
+		body string
+		want string
+	}{
+		{"body key without metadata", nil, "body-key", "body-key"},
+		{"x-user-key overrides body", []string{"x-user-key", "md-key"}, "body-key", "md-key"},
+		{"bearer token", []string{"authorization", "Bearer  bearer-key "}, "", "bearer-key"},
+		{"x-user-key preferred over bearer", []string{"authorization", "Bearer b", "x-user-key", "x"}, "", "x"},
+		{"non-bearer authorization ignored", []string{"authorization", "Basic abc"}, "body-key", "body-key"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(tc.md...))
+			resp, err := client.Me(ctx, &pb.UserMeReq{UserKey: tc.body})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.GetUsername() != tc.want {
+				t.Fatalf("user key %q, want %q", resp.GetUsername(), tc.want)
+			}
+		})
+	}
+}
+
+func TestGRPCErrorMapping(t *testing.T) {
+	s := hub.NewServer("", "", "hub-test")
+	s.RegisterBinRoute(binproto.TypeUserMeReq, echoMe)
+	client := startGRPC(t, s)
+
+	_, err := client.Me(context.Background(), &pb.UserMeReq{UserKey: "denied"})
+	if st, _ := status.FromError(err); st.Code() != codes.PermissionDenied || st.Message() != "forbidden" {
+		t.Fatalf("ERR_RESP 403 mapped to %v", err)
+	}
+	// 未注册路由的 RPC
+	_, err = client.ListKeys(context.Background(), &pb.KeyListReq{})
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("unregistered route: %v", err)
+	}
+	for code, want := range map[int32]codes.Code{400: codes.InvalidArgument, 401: codes.Unauthenticated, 404: codes.NotFound,
+		409: codes.AlreadyExists, 429: codes.ResourceExhausted, 503: codes.Unavailable, 500: codes.Unknown} {
+		if got := grpcCode(code); got != want {
+			t.Fatalf("grpcCode(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestGRPCDeadlinePropagates(t *testing.T) {
+	s := hub.NewServer("", "", "hub-test")
+	cancelled := make(chan error, 1)
+	// 处理器阻塞至 context 结束：客户端的截止时间须传递到处理器
+	s.RegisterBinRoute(binproto.TypeUserMeReq, func(ctx context.Context, s *hub.Server, c *hub.Client, h binproto.HeaderV1, payload []byte) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+	})
+	client := startGRPC(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := client.Me(ctx, &pb.UserMeReq{}); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("err %v, want DeadlineExceeded", err)
+	}
+	select {
+	case err := <-cancelled:
+		if err == nil {
+			t.Fatal("handler context not cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler still running after client deadline")
+	}
+}
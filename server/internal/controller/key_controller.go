@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
@@ -25,23 +26,23 @@ func (c *KeyController) SetAuditService(a *service.AuditService) { c.audit = a }
 // --- Business methods for binroutes ---
 
 // List returns keys visible to the user represented by userKey.
-func (c *KeyController) List(userKey string) ([]database.Key, error) {
+func (c *KeyController) List(ctx context.Context, userKey string) ([]database.Key, error) {
 	if userKey == "" {
 		return nil, fmt.Errorf("unauthorized")
 	}
-	uid, _, err := c.keys.PeekUserKey(userKey)
+	uid, _, err := c.keys.PeekUserKey(ctx, userKey)
 	if err != nil || uid == 0 {
 		return nil, fmt.Errorf("unauthorized")
 	}
-	return c.keys.ListKeys(uid)
+	return c.keys.ListKeys(ctx, uid)
 }
 
 // Create issues a new key bound optionally and returns the generated secret with created key and attached nodes.
-func (c *KeyController) Create(userKey string, bindType *string, bindID *uint64, expiresAt *time.Time, maxUses *int32, meta []byte, nodes []string) (secret string, key *database.Key, outNodes []string, err error) {
+func (c *KeyController) Create(ctx context.Context, userKey string, bindType *string, bindID *uint64, expiresAt *time.Time, maxUses *int32, meta []byte, nodes []string) (secret string, key *database.Key, outNodes []string, err error) {
 	if userKey == "" {
 		return "", nil, nil, fmt.Errorf("unauthorized")
 	}
-	uid, _, e := c.keys.PeekUserKey(userKey)
+	uid, _, e := c.keys.PeekUserKey(ctx, userKey)
 	if e != nil || uid == 0 {
 		return "", nil, nil, fmt.Errorf("unauthorized")
 	}
@@ -61,12 +62,12 @@ func (c *KeyController) Create(userKey string, bindType *string, bindID *uint64,
 		v := int(*maxUses)
 		maxPtr = &v
 	}
-	k, e := c.keys.CreateKey(uid, bindType, bindID, secret, expPtr, maxPtr, meta)
+	k, e := c.keys.CreateKey(ctx, uid, bindType, bindID, secret, expPtr, maxPtr, meta)
 	if e != nil {
 		return "", nil, nil, fmt.Errorf("create failed")
 	}
 	if len(nodes) > 0 {
-		if err := c.keys.AttachKeyPermissions(uid, k.ID, nodes); err != nil {
+		if err := c.keys.AttachKeyPermissions(ctx, uid, k.ID, nodes); err != nil {
 			return "", nil, nil, fmt.Errorf("invalid key permission nodes")
 		}
 	}
@@ -74,40 +75,40 @@ func (c *KeyController) Create(userKey string, bindType *string, bindID *uint64,
 }
 
 // Update updates a key record, with permissions checked by userKey holder.
-func (c *KeyController) Update(userKey string, item *database.Key) error {
+func (c *KeyController) Update(ctx context.Context, userKey string, item *database.Key) error {
 	if userKey == "" {
 		return fmt.Errorf("unauthorized")
 	}
-	uid, _, err := c.keys.PeekUserKey(userKey)
+	uid, _, err := c.keys.PeekUserKey(ctx, userKey)
 	if err != nil || uid == 0 {
 		return fmt.Errorf("unauthorized")
 	}
-	return c.keys.UpdateKey(uid, item)
+	return c.keys.UpdateKey(ctx, uid, item)
 }
 
 // Delete deletes a key by id with permission checks.
-func (c *KeyController) Delete(userKey string, id uint64) error {
+func (c *KeyController) Delete(ctx context.Context, userKey string, id uint64) error {
 	// Placeholder for future implementations
 	if userKey == "" {
 		return fmt.Errorf("unauthorized")
 	}
-	uid, _, err := c.keys.PeekUserKey(userKey)
+	uid, _, err := c.keys.PeekUserKey(ctx, userKey)
 	if err != nil || uid == 0 {
 		return fmt.Errorf("unauthorized")
 	}
-	return c.keys.DeleteKey(uid, id)
+	return c.keys.DeleteKey(ctx, uid, id)
 }
 
 // VisibleDevices lists devices the user can select when creating a key.
-func (c *KeyController) VisibleDevices(userKey string) ([]database.Device, error) {
+func (c *KeyController) VisibleDevices(ctx context.Context, userKey string) ([]database.Device, error) {
 	if userKey == "" {
 		return nil, fmt.Errorf("unauthorized")
 	}
-	uid, _, err := c.keys.PeekUserKey(userKey)
+	uid, _, err := c.keys.PeekUserKey(ctx, userKey)
 	if err != nil || uid == 0 {
 		return nil, fmt.Errorf("unauthorized")
 	}
-	return c.keys.ListVisibleDevicesForKey(uid)
+	return c.keys.ListVisibleDevicesForKey(ctx, uid)
 }
 
 // HandleKeyList 按规则返回可见密钥列表
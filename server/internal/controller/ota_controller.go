@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/service"
+)
+
+var errOTAPermission = errors.New("permission denied")
+
+// OTAController 负责固件制品与 OTA 活动管理（需要 ota.manage 或 admin.manage）
+type OTAController struct {
+	svc   *service.OTAService
+	authz *service.AuthzService
+}
+
+// NewOTAController 创建一个新的 OTAController
+func NewOTAController(svc *service.OTAService, authz *service.AuthzService) *OTAController {
+	return &OTAController{svc: svc, authz: authz}
+}
+
+// authorize 校验 userKey 并返回用户 ID
+func (c *OTAController) authorize(ctx context.Context, userKey string) (uint64, error) {
+	if c.authz == nil {
+		return 0, fmt.Errorf("not configured")
+	}
+	uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok || !(c.authz.HasUserPermission(ctx, uid, "ota.manage") || c.authz.HasUserPermission(ctx, uid, "admin.manage")) {
+		return 0, errOTAPermission
+	}
+	return uid, nil
+}
+
+func (c *OTAController) CreateArtifact(ctx context.Context, userKey, version, hardwareModel string, fileID uint64, sha256Hex string) (bin.OTAArtifactItem, error) {
+	uid, err := c.authorize(ctx, userKey)
+	if err != nil {
+		return bin.OTAArtifactItem{}, err
+	}
+	a, err := c.svc.CreateArtifact(ctx, version, hardwareModel, fileID, sha256Hex, &uid)
+	if err != nil {
+		return bin.OTAArtifactItem{}, err
+	}
+	return toOTAArtifactItem(a), nil
+}
+
+func (c *OTAController) ListArtifacts(ctx context.Context, userKey string) ([]bin.OTAArtifactItem, error) {
+	if _, err := c.authorize(ctx, userKey); err != nil {
+		return nil, err
+	}
+	list, err := c.svc.ListArtifacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]bin.OTAArtifactItem, 0, len(list))
+	for i := range list {
+		items = append(items, toOTAArtifactItem(&list[i]))
+	}
+	return items, nil
+}
+
+func (c *OTAController) CreateCampaign(ctx context.Context, userKey string, spec bin.OTACampaignSpec) (bin.OTACampaignItem, error) {
+	uid, err := c.authorize(ctx, userKey)
+	if err != nil {
+		return bin.OTACampaignItem{}, err
+	}
+	cp, err := c.svc.CreateCampaign(ctx, spec, &uid)
+	if err != nil {
+		return bin.OTACampaignItem{}, err
+	}
+	return c.toOTACampaignItem(ctx, cp), nil
+}
+
+func (c *OTAController) ListCampaigns(ctx context.Context, userKey string) ([]bin.OTACampaignItem, error) {
+	if _, err := c.authorize(ctx, userKey); err != nil {
+		return nil, err
+	}
+	list, err := c.svc.ListCampaigns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]bin.OTACampaignItem, 0, len(list))
+	for i := range list {
+		items = append(items, c.toOTACampaignItem(ctx, &list[i]))
+	}
+	return items, nil
+}
+
+func (c *OTAController) Control(ctx context.Context, userKey string, campaignID uint64, action string) error {
+	if _, err := c.authorize(ctx, userKey); err != nil {
+		return err
+	}
+	return c.svc.Control(ctx, campaignID, action)
+}
+
+func (c *OTAController) Status(ctx context.Context, userKey string, campaignID uint64) (bin.OTACampaignItem, []bin.OTADeviceStateItem, error) {
+	if _, err := c.authorize(ctx, userKey); err != nil {
+		return bin.OTACampaignItem{}, nil, err
+	}
+	cp, states, err := c.svc.CampaignStatus(ctx, campaignID)
+	if err != nil {
+		return bin.OTACampaignItem{}, nil, err
+	}
+	devices := make([]bin.OTADeviceStateItem, 0, len(states))
+	for _, st := range states {
+		devices = append(devices, bin.OTADeviceStateItem{DeviceUID: st.DeviceUID, Stage: st.Stage, State: st.State, Progress: st.Progress, Error: st.Error, UpdatedAt: st.UpdatedAt.Unix()})
+	}
+	return c.toOTACampaignItem(ctx, cp), devices, nil
+}
+
+func toOTAArtifactItem(a *database.FirmwareArtifact) bin.OTAArtifactItem {
+	return bin.OTAArtifactItem{ID: a.ID, Version: a.Version, HardwareModel: a.HardwareModel, FileID: a.FileID, Size: a.Size, SHA256: a.SHA256, CreatedAt: a.CreatedAt.Unix()}
+}
+
+func (c *OTAController) toOTACampaignItem(ctx context.Context, cp *database.OTACampaign) bin.OTACampaignItem {
+	var stages []uint32
+	_ = json.Unmarshal(cp.Stages, &stages)
+	total, succeeded, failed := c.svc.Counts(ctx, cp.ID)
+	return bin.OTACampaignItem{
+		OTACampaignSpec: bin.OTACampaignSpec{
+			Name:          cp.Name,
+			ArtifactID:    cp.ArtifactID,
+			OwnerUserID:   cp.TargetOwnerUserID,
+			ParentID:      cp.TargetParentID,
+			Tag:           cp.TargetTag,
+			Stages:        stages,
+			MaxFailurePct: cp.MaxFailurePct,
+		},
+		ID:           cp.ID,
+		Status:       cp.Status,
+		CurrentStage: cp.CurrentStage,
+		Total:        total,
+		Succeeded:    succeeded,
+		Failed:       failed,
+		CreatedAt:    cp.CreatedAt.Unix(),
+	}
+}
@@ -1,30 +1,34 @@
 package controller
 
 import (
+	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/binary"
-	"strings"
+	"errors"
 	"time"
 
 	"myflowhub/pkg/config"
 	"myflowhub/pkg/database"
 	bin "myflowhub/pkg/protocol/binproto"
 	"myflowhub/server/internal/hub"
+	"myflowhub/server/internal/service"
+
+	"github.com/rs/zerolog/log"
 )
 
 // ParentAuthController 处理父链路二进制认证
 type ParentAuthController struct {
-	Nonces    map[string]int64
-	NoncesTTL time.Duration
+	Nonces *service.NonceStore
 }
 
-func NewParentAuthController() *ParentAuthController {
-	return &ParentAuthController{Nonces: make(map[string]int64), NoncesTTL: 10 * time.Minute}
+func NewParentAuthController(nonces *service.NonceStore) *ParentAuthController {
+	return &ParentAuthController{Nonces: nonces}
 }
 
-// VerifyAndAssign 校验请求并返回分配的设备 UID
-func (p *ParentAuthController) VerifyAndAssign(reqTsMs int64, nonce [16]byte, hardwareID, caps string) (uint64, error) {
+// VerifyAndAssign 校验请求并返回分配的设备 UID；relay 为新登记设备是否以中继角色创建
+func (p *ParentAuthController) VerifyAndAssign(ctx context.Context, reqTsMs int64, nonce [16]byte, hardwareID string, relay bool) (uint64, error) {
 	nowMs := time.Now().UnixMilli()
 	if d := nowMs - reqTsMs; d > int64(5*time.Minute/time.Millisecond) || d < -int64(5*time.Minute/time.Millisecond) {
 		return 0, ErrBadTimeWindow
@@ -32,46 +36,43 @@ func (p *ParentAuthController) VerifyAndAssign(reqTsMs int64, nonce [16]byte, ha
 	if hardwareID == "" {
 		return 0, ErrBadRequest
 	}
-	// nonce 去重
-	for k, v := range p.Nonces {
-		if nowMs-v > int64(p.NoncesTTL/time.Millisecond) {
-			delete(p.Nonces, k)
+	// nonce 去重（签名已在 Bin 适配器中校验，未认证的请求不会占用存储）
+	if err := p.Nonces.Use(ctx, nonceScopeParentAuth, nonce[:]); err != nil {
+		if errors.Is(err, service.ErrNonceReplay) {
+			return 0, ErrReplay
 		}
+		log.Error().Err(err).Str("hardwareID", hardwareID).Msg("ParentAuth nonce 登记失败")
+		return 0, ErrNonceUnavailable
 	}
-	key := string(nonce[:])
-	if _, exists := p.Nonces[key]; exists {
-		return 0, ErrReplay
-	}
-	p.Nonces[key] = nowMs
 
 	// HMAC 校验：实际签名比对在 Bin 适配器中完成；此处仅进行设备登记
-	return p.ensureDevice(hardwareID, caps)
+	return p.ensureDevice(ctx, hardwareID, relay)
 }
 
-func (p *ParentAuthController) ensureDevice(hardwareID, caps string) (uint64, error) {
+func (p *ParentAuthController) ensureDevice(ctx context.Context, hardwareID string, relay bool) (uint64, error) {
 	var dev database.Device
-	if err := database.DB.Where("hardware_id = ?", hardwareID).First(&dev).Error; err != nil {
+	if err := database.DB.WithContext(ctx).Where("hardware_id = ?", hardwareID).First(&dev).Error; err != nil {
 		// 新登记的设备默认未审批，等待 Manager 审批后才能正常使用
-		// 角色：若 caps 中包含 "relay" 则为中继，否则按普通节点处理
+		// 角色：连接协商了 relay 特性（HELLO 或 caps）则为中继，否则按普通节点处理
 		role := database.RoleNode
-		if strings.Contains(strings.ToLower(caps), "relay") {
+		if relay {
 			role = database.RoleRelay
 		}
 		dev = database.Device{HardwareID: hardwareID, Role: role, Name: hardwareID, Approved: false}
-		if e2 := database.DB.Create(&dev).Error; e2 != nil {
+		if e2 := database.DB.WithContext(ctx).Create(&dev).Error; e2 != nil {
 			return 0, e2
 		}
 		// 确保分配稳定的 DeviceUID（避免 0 导致 Hub 审批查询失配）
 		if dev.DeviceUID == 0 {
 			dev.DeviceUID = dev.ID
-			_ = database.DB.Model(&dev).Update("device_uid", dev.DeviceUID).Error
+			_ = database.DB.WithContext(ctx).Model(&dev).Update("device_uid", dev.DeviceUID).Error
 		}
 		return dev.DeviceUID, nil
 	}
 	// 兼容旧记录：若 DeviceUID 仍为 0，则回填为主键 ID
 	if dev.DeviceUID == 0 {
 		dev.DeviceUID = dev.ID
-		_ = database.DB.Model(&dev).Update("device_uid", dev.DeviceUID).Error
+		_ = database.DB.WithContext(ctx).Model(&dev).Update("device_uid", dev.DeviceUID).Error
 	}
 	return dev.DeviceUID, nil
 }
@@ -81,6 +82,14 @@ var (
 	ErrBadTimeWindow = &binErr{"time window exceeded"}
 	ErrBadRequest    = &binErr{"bad request"}
 	ErrReplay        = &binErr{"replay detected"}
+	// nonce 存储已满或数据库不可用时拒绝（不放行可能的重放）
+	ErrNonceUnavailable = &binErr{"nonce store unavailable"}
+)
+
+// NonceStore 中各类签名请求的 scope
+const (
+	nonceScopeParentAuth = "parent_auth"
+	nonceScopeKeyRotate  = "key_rotate"
 )
 
 type binErr struct{ s string }
@@ -90,47 +99,119 @@ func (e *binErr) Error() string { return e.s }
 // Adapter 层：解析/封包
 type ParentAuthBin struct{ C *ParentAuthController }
 
-func (p *ParentAuthBin) Handle(s *hub.Server, c *hub.Client, h bin.HeaderV1, payload []byte) {
-	version, tsMs, nonce, hardwareID, caps, sig, err := bin.DecodeParentAuthReq(payload)
+func (p *ParentAuthBin) Handle(ctx context.Context, s *hub.Server, c *hub.Client, h bin.HeaderV1, payload []byte) {
+	version, tsMs, nonce, hardwareID, caps, sig, macPub, err := bin.DecodeParentAuthReq(payload)
 	if err != nil {
-		sendErr(s, c, h, 400, "bad request")
+		sendErr(ctx, s, c, h, 400, "bad request")
 		return
 	}
 	_ = version
-	// 计算 HMAC 并比对
-	var tsBuf [8]byte
-	binary.LittleEndian.PutUint64(tsBuf[:], uint64(tsMs))
-	// 优先使用专用 RelayToken，其次回退 ManagerToken 以兼容
-	key := config.AppConfig.Server.RelayToken
-	if key == "" {
-		key = config.AppConfig.Server.ManagerToken
+	// 签名 ParentAuthResp 所用的令牌（证书认证时为空，由 TLS 保证上级身份）
+	var signKey string
+	if c.CertHardwareID != "" {
+		// mTLS：已校验的客户端证书替代共享密钥签名，但 CN 必须与声明的 HardwareID 一致
+		if c.CertHardwareID != hardwareID {
+			sendErr(ctx, s, c, h, 401, "certificate subject mismatch")
+			return
+		}
+	} else {
+		// 计算 HMAC 并比对
+		var tsBuf [8]byte
+		binary.LittleEndian.PutUint64(tsBuf[:], uint64(tsMs))
+		keys := relayTokens(time.Now())
+		if len(keys) == 0 {
+			sendErr(ctx, s, c, h, 401, "shared token not configured")
+			return
+		}
+		matched := -1
+		for i, key := range keys {
+			mac := computeHMACSHA256([]byte(key), tsBuf[:], nonce[:], []byte(hardwareID), []byte(caps))
+			if hmac.Equal(sig[:], mac[:]) {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			sendErr(ctx, s, c, h, 401, "invalid signature")
+			return
+		}
+		signKey = keys[matched]
+		if matched > 0 {
+			log.Warn().Str("hardwareID", hardwareID).Str("until", config.AppConfig.Server.RelayTokenPrevUntil).Msg("下级仍在使用轮换前的 RelayToken，请尽快更新其 SharedToken")
+		}
 	}
-	mac := computeHMACSHA256([]byte(key), tsBuf[:], nonce[:], []byte(hardwareID), []byte(caps))
-	if !hmac.Equal(sig[:], mac[:]) {
-		sendErr(s, c, h, 401, "invalid signature")
+	relay := c.ResolveRelay(caps)
+	uid, e := p.C.VerifyAndAssign(ctx, tsMs, nonce, hardwareID, relay)
+	if e != nil {
+		sendErr(ctx, s, c, h, 400, e.Error())
 		return
 	}
-	uid, e := p.C.VerifyAndAssign(tsMs, nonce, hardwareID, caps)
-	if e != nil {
-		sendErr(s, c, h, 400, e.Error())
+	if rejectIdentityChange(ctx, s, c, h, uid) {
 		return
 	}
 	// 未审批设备：禁止加入网络与消息发送
 	var dev database.Device
-	if err := database.DB.Where("device_uid = ? OR id = ?", uid, uid).First(&dev).Error; err == nil {
+	if err := database.DB.WithContext(ctx).Where("device_uid = ? OR id = ?", uid, uid).First(&dev).Error; err == nil {
 		if !dev.Approved {
-			sendErr(s, c, h, 403, "device not approved")
+			sendErr(ctx, s, c, h, 403, "device not approved")
 			return
 		}
 	}
 	var sid [16]byte
-	// 成功后将连接标记为该设备，加入 Hub 客户端表
-	c.DeviceID = uid
-	s.Clients[c.DeviceID] = c
-	pl := bin.EncodeParentAuthResp(h.MsgID, uid, sid, 30, nil, 0, [32]byte{})
+	if _, err := rand.Read(sid[:]); err != nil {
+		sendErr(ctx, s, c, h, 500, "internal error")
+		return
+	}
+	hb := uint16(hub.HeartbeatSec())
+	// 中继会话带有效期与权限，到期前须在同一连接上重新认证；普通设备不过期
+	var perms []string
+	var exp time.Time
+	var expMs int64
+	if relay {
+		perms = hub.RelayPerms()
+		exp = time.Now().Add(hub.ParentSessionTTL())
+		expMs = exp.UnixMilli()
+	}
+	// 帧认证以签名令牌为绑定值（证书认证时为空，链路已由 TLS 保护）；上级公钥纳入响应签名
+	serverMACPub, err := c.NegotiateFrameMAC(macPub, []byte(signKey), bin.TypeParentAuthResp, h.MsgID)
+	if err != nil {
+		sendFrameMACErr(ctx, s, c, h, err)
+		return
+	}
+	var respSig [32]byte
+	if signKey != "" {
+		respSig = computeHMACSHA256([]byte(signKey), bin.ParentAuthRespSignedData(nonce, uid, sid, hb, perms, expMs, serverMACPub))
+	}
+	// 成功后将连接标记为该设备，加入 Hub 客户端表；同一连接上的续期认证只刷新会话
+	if c.DeviceID != uid {
+		s.Attach(c, uid)
+	}
+	c.SetSessionExpiry(exp)
+	c.SetRelay(relay)
+	pl := bin.EncodeParentAuthResp(h.MsgID, uid, sid, hb, perms, expMs, respSig, serverMACPub)
 	sendFrame(s, c, h, bin.TypeParentAuthResp, pl)
 }
 
+// relayTokens 返回 ParentAuth 可接受的共享令牌：当前令牌（优先 RelayToken，其次回退 ManagerToken 以兼容），
+// 以及 RelayTokenPrevUntil 之前仍有效的旧令牌
+func relayTokens(now time.Time) []string {
+	var keys []string
+	key := config.AppConfig.Server.RelayToken
+	if key == "" {
+		key = config.AppConfig.Server.ManagerToken
+	}
+	if key != "" {
+		keys = append(keys, key)
+	}
+	if prev := config.AppConfig.Server.RelayTokenPrev; prev != "" && prev != key {
+		until, err := time.Parse(time.RFC3339, config.AppConfig.Server.RelayTokenPrevUntil)
+		if err == nil && now.Before(until) {
+			keys = append(keys, prev)
+		}
+	}
+	return keys
+}
+
 // 使用本地计算逻辑的 HMAC-SHA256
 func computeHMACSHA256(key []byte, data ...[]byte) [32]byte {
 	h := hmac.New(sha256.New, key)
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/config"
+)
+
+func TestRelayTokensRotationGrace(t *testing.T) {
+	prev := config.AppConfig.Server
+	t.Cleanup(func() { config.AppConfig.Server = prev })
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Hour).Format(time.RFC3339)
+	cases := []struct {
+		name                               string
+		relay, manager, prevToken, prevEnd string
+		want                               []string
+	}{
+		{"none configured", "", "", "", "", nil},
+		{"relay token", "r1", "m", "", "", []string{"r1"}},
+		{"manager token fallback", "", "m", "", "", []string{"m"}},
+		{"previous within grace", "r2", "", "r1", until, []string{"r2", "r1"}},
+		{"previous after grace", "r2", "", "r1", now.Add(-time.Second).Format(time.RFC3339), []string{"r2"}},
+		{"previous without deadline", "r2", "", "r1", "", []string{"r2"}},
+		{"previous equals current", "r1", "", "r1", until, []string{"r1"}},
+		{"previous only", "", "", "r1", until, []string{"r1"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config.AppConfig.Server.RelayToken = tc.relay
+			config.AppConfig.Server.ManagerToken = tc.manager
+			config.AppConfig.Server.RelayTokenPrev = tc.prevToken
+			config.AppConfig.Server.RelayTokenPrevUntil = tc.prevEnd
+			if got := relayTokens(now); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
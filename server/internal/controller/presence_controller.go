@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/service"
+)
+
+// PresenceController 查询设备在线状态；可见范围与节点查询一致
+type PresenceController struct {
+	svc     *service.PresenceService
+	devices *DeviceController
+}
+
+// NewPresenceController 创建一个新的 PresenceController
+func NewPresenceController(svc *service.PresenceService, devices *DeviceController) *PresenceController {
+	return &PresenceController{svc: svc, devices: devices}
+}
+
+// Query 返回请求方可见设备的在线状态；deviceUIDs 为空时返回全部可见设备
+func (c *PresenceController) Query(ctx context.Context, userKey string, deviceUIDs []uint64, requesterDeviceUID uint64) ([]bin.PresenceItem, error) {
+	visible, err := c.devices.QueryVisibleDevices(ctx, userKey, requesterDeviceUID)
+	if err != nil {
+		return nil, err
+	}
+	var want map[uint64]struct{}
+	if len(deviceUIDs) > 0 {
+		want = make(map[uint64]struct{}, len(deviceUIDs))
+		for _, uid := range deviceUIDs {
+			want[uid] = struct{}{}
+		}
+	}
+	items := make([]bin.PresenceItem, 0, len(visible))
+	for _, d := range visible {
+		if want != nil {
+			if _, ok := want[d.DeviceUID]; !ok {
+				continue
+			}
+		}
+		it, ok := c.svc.Get(d.DeviceUID)
+		if !ok && d.LastSeen != nil {
+			it.LastSeenSec = d.LastSeen.Unix()
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"myflowhub/server/internal/service"
 )
@@ -27,15 +28,15 @@ type SystemLogListRequest struct {
 
 // HandleSystemLogList 通过 hub 路由处理系统日志列表查询（需要 log.read 或 admin.manage）
 // List is transport-agnostic method to list system logs with userKey authorization.
-func (c *SystemLogController) List(userKey string, req SystemLogListRequest) (*service.SystemLogListOutput, error) {
+func (c *SystemLogController) List(ctx context.Context, userKey string, req SystemLogListRequest) (*service.SystemLogListOutput, error) {
 	if c.authz == nil {
 		return nil, fmt.Errorf("not configured")
 	}
-	uid, ok := c.authz.ResolveUserIDFromKey(userKey)
-	if !ok || !(c.authz.HasUserPermission(uid, "log.read") || c.authz.HasUserPermission(uid, "admin.manage")) {
+	uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey)
+	if !ok || !(c.authz.HasUserPermission(ctx, uid, "log.read") || c.authz.HasUserPermission(ctx, uid, "admin.manage")) {
 		return nil, fmt.Errorf("permission denied")
 	}
-	return c.svc.List(service.SystemLogListInput{
+	return c.svc.List(ctx, service.SystemLogListInput{
 		Level:    req.Level,
 		Source:   req.Source,
 		Keyword:  req.Keyword,
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/service"
+)
+
+var errTwinPermission = errors.New("permission denied")
+
+// TwinController 负责设备孪生的读写；设备只写 reported，用户（或有权设备）只写 desired
+type TwinController struct {
+	svc   *service.TwinService
+	perm  *service.PermissionService
+	authz *service.AuthzService
+}
+
+// NewTwinController 创建一个新的 TwinController
+func NewTwinController(svc *service.TwinService, perm *service.PermissionService, authz *service.AuthzService) *TwinController {
+	return &TwinController{svc: svc, perm: perm, authz: authz}
+}
+
+// allowed 判断请求方能否访问目标设备孪生：设备自身总是允许；携带 userKey 时按用户控制权，否则按设备读/写权限
+func (c *TwinController) allowed(ctx context.Context, userKey string, requesterDeviceUID, targetUID uint64, write bool) bool {
+	if targetUID == requesterDeviceUID && !write {
+		return true
+	}
+	if c.authz != nil && userKey != "" {
+		if uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey); ok {
+			return c.authz.CanControlDevice(ctx, requesterDeviceUID, targetUID, uid)
+		}
+		return false
+	}
+	if write {
+		return c.perm.CanWriteVarsForDevice(ctx, requesterDeviceUID, targetUID)
+	}
+	return c.perm.CanReadVarsForDevice(ctx, requesterDeviceUID, targetUID)
+}
+
+// userControls 判断 userKey 对应的用户能否控制目标设备（管理员或所有者），不计请求设备自身的默认权限
+func (c *TwinController) userControls(ctx context.Context, userKey string, targetUID uint64) bool {
+	if c.authz == nil || userKey == "" {
+		return false
+	}
+	uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey)
+	return ok && c.authz.CanControlDevice(ctx, 0, targetUID, uid)
+}
+
+func resolveTwinTarget(deviceUID, requesterDeviceUID uint64) uint64 {
+	if deviceUID == 0 {
+		return requesterDeviceUID
+	}
+	return deviceUID
+}
+
+func (c *TwinController) Get(ctx context.Context, userKey string, deviceUID, requesterDeviceUID uint64) (bin.TwinDoc, error) {
+	target := resolveTwinTarget(deviceUID, requesterDeviceUID)
+	if !c.allowed(ctx, userKey, requesterDeviceUID, target, false) {
+		return bin.TwinDoc{}, errTwinPermission
+	}
+	t, delta, err := c.svc.Get(ctx, target)
+	if err != nil {
+		return bin.TwinDoc{}, err
+	}
+	return bin.TwinDoc{DeviceUID: target, Desired: t.Desired, DesiredVersion: t.DesiredVersion, Reported: t.Reported, ReportedVersion: t.ReportedVersion, Delta: delta}, nil
+}
+
+func (c *TwinController) UpdateDesired(ctx context.Context, userKey string, deviceUID uint64, patch []byte, expectedVersion *uint64, requesterDeviceUID uint64) (target, version uint64, err error) {
+	target = resolveTwinTarget(deviceUID, requesterDeviceUID)
+	if target == requesterDeviceUID {
+		// desired 由用户下发：设备写自身 desired 须经用户密钥授权，设备自身身份不足以授权
+		if !c.userControls(ctx, userKey, target) {
+			return target, 0, errTwinPermission
+		}
+	} else if !c.allowed(ctx, userKey, requesterDeviceUID, target, true) {
+		return target, 0, errTwinPermission
+	}
+	version, err = c.svc.UpdateDesired(ctx, target, patch, expectedVersion)
+	return target, version, err
+}
+
+// UpdateReported 仅允许设备更新自身的 reported
+func (c *TwinController) UpdateReported(ctx context.Context, patch []byte, expectedVersion *uint64, requesterDeviceUID uint64) (uint64, error) {
+	return c.svc.UpdateReported(ctx, requesterDeviceUID, patch, expectedVersion)
+}
+
+// PushPending 设备上线时推送待同步差量；由 Run 协程回调，查库放到独立协程
+func (c *TwinController) PushPending(deviceUID uint64) {
+	go c.svc.PushPending(context.Background(), deviceUID)
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"myflowhub/pkg/database"
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/service"
+	"myflowhub/server/internal/testdb"
+)
+
+func TestTwinDesiredSelfWrite(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.Open(t)
+	devices := repository.NewDeviceRepository(db)
+	keys := service.NewKeyService(repository.NewKeyRepository(db), repository.NewPermissionRepository(db), devices)
+	owner, stranger := uint64(5), uint64(6)
+	dev := database.Device{HardwareID: "node", Role: database.RoleNode, Approved: true, OwnerUserID: &owner}
+	mgr := database.Device{HardwareID: "manager", Role: database.RoleManager, Approved: true}
+	for _, d := range []*database.Device{&dev, &mgr} {
+		if err := db.Create(d).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for user, secret := range map[uint64]string{owner: "owner-key", stranger: "stranger-key"} {
+		if _, err := keys.CreateKey(ctx, user, nil, nil, secret, nil, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewTwinController(service.NewTwinService(repository.NewTwinRepository(db)), service.NewPermissionService(devices), service.NewAuthzService(keys, devices, repository.NewPermissionRepository(db)))
+
+	patch := []byte(`{"led":"on"}`)
+	cases := []struct {
+		name      string
+		userKey   string
+		deviceUID uint64
+		requester uint64
+		ok        bool
+	}{
+		{"self without key", "", 0, dev.DeviceUID, false},
+		{"self by explicit uid", "", dev.DeviceUID, dev.DeviceUID, false},
+		{"self with unrelated user key", "stranger-key", 0, dev.DeviceUID, false},
+		{"self with unknown key", "nope", 0, dev.DeviceUID, false},
+		{"self with owner key", "owner-key", 0, dev.DeviceUID, true},
+		{"manager device", "", dev.DeviceUID, mgr.DeviceUID, true},
+		{"owner key from manager connection", "owner-key", dev.DeviceUID, mgr.DeviceUID, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target, _, err := c.UpdateDesired(ctx, tc.userKey, tc.deviceUID, patch, nil, tc.requester)
+			if target != dev.DeviceUID {
+				t.Fatalf("target %d", target)
+			}
+			if (err == nil) != tc.ok {
+				t.Fatalf("err = %v, want ok=%v", err, tc.ok)
+			}
+		})
+	}
+
+	// 设备仍可读取自身孪生并上报 reported
+	if _, err := c.Get(ctx, "", 0, dev.DeviceUID); err != nil {
+		t.Fatalf("read own twin: %v", err)
+	}
+	if _, err := c.UpdateReported(ctx, []byte(`{"led":"on"}`), nil, dev.DeviceUID); err != nil {
+		t.Fatalf("report own state: %v", err)
+	}
+}
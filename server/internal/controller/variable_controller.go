@@ -1,11 +1,14 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"myflowhub/pkg/database"
 	"myflowhub/server/internal/service"
 
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // VariableController 负责处理变量相关的消息
@@ -27,11 +30,11 @@ func NewVariableController(service *service.VariableService, deviceService *serv
 }
 
 // authzVisibleAsAdmin: 基于用户权限判断是否具备 admin.manage（或 ** 由 HasPermission 内部处理）
-func (c *VariableController) authzVisibleAsAdmin(userID uint64) bool {
+func (c *VariableController) authzVisibleAsAdmin(ctx context.Context, userID uint64) bool {
 	if c.authz == nil || userID == 0 {
 		return false
 	}
-	return c.authz.HasUserPermission(userID, "admin.manage")
+	return c.authz.HasUserPermission(ctx, userID, "admin.manage")
 }
 
 // HandleVarsQuery 处理来自直接客户端的变量查询请求
@@ -48,82 +51,102 @@ type VarKey struct {
 	Name      string
 }
 
-func (c *VariableController) List(userKey string, deviceUID *uint64, requesterDeviceUID uint64) ([]database.DeviceVariable, error) {
+// VarWriteErrors 变量写入的存储错误（逐项）；权限不足或设备不存在而跳过的条目不在其中
+type VarWriteErrors map[VarKey]error
+
+func (e VarWriteErrors) Error() string {
+	for k, err := range e {
+		return fmt.Sprintf("%d variable writes failed (device %d %q: %v)", len(e), k.DeviceUID, k.Name, err)
+	}
+	return "variable writes failed"
+}
+
+func (c *VariableController) List(ctx context.Context, userKey string, deviceUID *uint64, requesterDeviceUID uint64) ([]database.DeviceVariable, error) {
 	var requesterUID uint64
 	if c.authz != nil && userKey != "" {
-		if uid, ok := c.authz.ResolveUserIDFromKey(userKey); ok {
+		if uid, ok := c.authz.ResolveUserIDFromKey(ctx, userKey); ok {
 			requesterUID = uid
 		}
 	}
 	if deviceUID != nil {
 		if requesterUID != 0 {
-			if !c.authz.CanControlDevice(requesterDeviceUID, *deviceUID, requesterUID) {
+			if !c.authz.CanControlDevice(ctx, requesterDeviceUID, *deviceUID, requesterUID) {
 				return nil, fmt.Errorf("permission denied")
 			}
-		} else if !c.perm.CanReadVarsForDevice(requesterDeviceUID, *deviceUID) {
+		} else if !c.perm.CanReadVarsForDevice(ctx, requesterDeviceUID, *deviceUID) {
 			return nil, fmt.Errorf("permission denied")
 		}
-		dev, e := c.deviceService.GetDeviceByUID(*deviceUID)
+		dev, e := c.deviceService.GetDeviceByUID(ctx, *deviceUID)
 		if e != nil {
 			return nil, fmt.Errorf("device not found")
 		}
-		return c.service.GetVariablesByDeviceID(dev.ID)
+		return c.service.GetVariablesByDeviceID(ctx, dev.ID)
 	}
-	if requesterUID == 0 || !c.authz.HasUserPermission(requesterUID, "admin.manage") {
+	if requesterUID == 0 || !c.authz.HasUserPermission(ctx, requesterUID, "admin.manage") {
 		return nil, fmt.Errorf("permission denied")
 	}
-	return c.service.GetAllVariables()
+	return c.service.GetAllVariables(ctx)
 }
 
-func (c *VariableController) Update(userKey string, items []VarKV, requesterDeviceUID uint64) (int, error) {
+// Update 写入有权限的变量，返回实际写入的条目；存在存储错误时其余条目照常写入，并以 VarWriteErrors 返回失败项
+func (c *VariableController) Update(ctx context.Context, userKey string, items []VarKV, requesterDeviceUID uint64) ([]VarKV, error) {
 	var uid uint64
 	if c.authz != nil && userKey != "" {
-		if u, ok := c.authz.ResolveUserIDFromKey(userKey); ok {
+		if u, ok := c.authz.ResolveUserIDFromKey(ctx, userKey); ok {
 			uid = u
 		}
 	}
-	updated := 0
+	var updated []VarKV
+	failed := VarWriteErrors{}
 	for _, it := range items {
 		if uid != 0 {
-			if !c.authz.CanControlDevice(requesterDeviceUID, it.DeviceUID, uid) {
+			if !c.authz.CanControlDevice(ctx, requesterDeviceUID, it.DeviceUID, uid) {
 				continue
 			}
-		} else if !c.perm.CanWriteVarsForDevice(requesterDeviceUID, it.DeviceUID) {
+		} else if !c.perm.CanWriteVarsForDevice(ctx, requesterDeviceUID, it.DeviceUID) {
 			continue
 		}
-		dev, e := c.deviceService.GetDeviceByUID(it.DeviceUID)
-		if e != nil {
+		dev, e := c.deviceService.GetDeviceByUID(ctx, it.DeviceUID)
+		if errors.Is(e, gorm.ErrRecordNotFound) {
 			continue
 		}
-		v := &database.DeviceVariable{OwnerDeviceID: dev.ID, VariableName: it.Name, Value: datatypes.JSON(it.Value)}
-		if c.service.UpsertVariable(v) == nil {
-			updated++
+		if e == nil {
+			v := &database.DeviceVariable{OwnerDeviceID: dev.ID, VariableName: it.Name, Value: datatypes.JSON(it.Value)}
+			e = c.service.UpsertVariable(ctx, v)
 		}
+		if e != nil {
+			failed[VarKey{DeviceUID: it.DeviceUID, Name: it.Name}] = e
+			continue
+		}
+		updated = append(updated, it)
+	}
+	if len(failed) > 0 {
+		return updated, failed
 	}
 	return updated, nil
 }
 
-func (c *VariableController) Delete(userKey string, items []VarKey, requesterDeviceUID uint64) (int, error) {
+func (c *VariableController) Delete(ctx context.Context, userKey string, items []VarKey, requesterDeviceUID uint64) (int, error) {
 	var uid uint64
 	if c.authz != nil && userKey != "" {
-		if u, ok := c.authz.ResolveUserIDFromKey(userKey); ok {
+		if u, ok := c.authz.ResolveUserIDFromKey(ctx, userKey); ok {
 			uid = u
 		}
 	}
 	deleted := 0
 	for _, it := range items {
 		if uid != 0 {
-			if !c.authz.CanControlDevice(requesterDeviceUID, it.DeviceUID, uid) {
+			if !c.authz.CanControlDevice(ctx, requesterDeviceUID, it.DeviceUID, uid) {
 				continue
 			}
-		} else if !c.perm.CanWriteVarsForDevice(requesterDeviceUID, it.DeviceUID) {
+		} else if !c.perm.CanWriteVarsForDevice(ctx, requesterDeviceUID, it.DeviceUID) {
 			continue
 		}
-		dev, e := c.deviceService.GetDeviceByUID(it.DeviceUID)
+		dev, e := c.deviceService.GetDeviceByUID(ctx, it.DeviceUID)
 		if e != nil {
 			continue
 		}
-		if c.service.DeleteVariable(dev.ID, it.Name) == nil {
+		if c.service.DeleteVariable(ctx, dev.ID, it.Name) == nil {
 			deleted++
 		}
 	}
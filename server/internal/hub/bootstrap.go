@@ -1,6 +1,9 @@
 package hub
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+
 	"myflowhub/pkg/database"
 
 	"github.com/rs/zerolog/log"
@@ -8,6 +11,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// legacyBootstrapSecret 早期版本写入本节点记录的固定密钥（已公开，不可再接受）
+const legacyBootstrapSecret = "a-very-secure-secret-for-the-server"
+
+// randomSecretHash 本节点不以设备密钥认证，仅为满足非空约束写入随机值的哈希
+func randomSecretHash() string {
+	var secret [24]byte
+	_, _ = rand.Read(secret[:])
+	hashed, _ := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret[:])), bcrypt.DefaultCost)
+	return string(hashed)
+}
+
 // Bootstrap ensures the server has a persistent identity in the database.
 func (s *Server) Bootstrap() {
 	var device database.Device
@@ -20,9 +34,6 @@ func (s *Server) Bootstrap() {
 	if err == gorm.ErrRecordNotFound {
 		log.Info().Str("hardwareID", s.HardwareID).Msg("Server device record not found, creating a new one...")
 
-		tempSecret := "a-very-secure-secret-for-the-server"
-		hashedSecret, _ := bcrypt.GenerateFromPassword([]byte(tempSecret), bcrypt.DefaultCost)
-
 		role := database.RoleRelay
 		if s.ParentAddr == "" {
 			role = database.RoleHub
@@ -30,7 +41,7 @@ func (s *Server) Bootstrap() {
 
 		newDevice := database.Device{
 			HardwareID:    s.HardwareID,
-			SecretKeyHash: string(hashedSecret),
+			SecretKeyHash: randomSecretHash(),
 			Role:          role,
 			Name:          s.HardwareID,
 		}
@@ -40,12 +51,18 @@ func (s *Server) Bootstrap() {
 		}
 
 		device = newDevice
-		s.SecretKey = tempSecret
 	} else {
 		log.Info().Msg("Server device record found.")
-		s.SecretKey = "a-very-secure-secret-for-the-server"
+		// 早期版本以固定密钥登记本节点，发现时替换为随机密钥
+		if bcrypt.CompareHashAndPassword([]byte(device.SecretKeyHash), []byte(legacyBootstrapSecret)) == nil {
+			if err := database.DB.Model(&device).Update("secret_key_hash", randomSecretHash()).Error; err != nil {
+				log.Warn().Err(err).Msg("轮换本节点固定密钥失败")
+			} else {
+				log.Info().Msg("已将本节点的固定密钥轮换为随机密钥")
+			}
+		}
 	}
 
-	s.DeviceID = device.DeviceUID
-	log.Info().Uint64("deviceID", s.DeviceID).Msg("Server identity bootstrapped successfully")
+	s.deviceID.Store(device.DeviceUID)
+	log.Info().Uint64("deviceID", s.DeviceID()).Msg("Server identity bootstrapped successfully")
 }
@@ -0,0 +1,29 @@
+package hub
+
+import (
+	"crypto/subtle"
+	"time"
+)
+
+// challenge 未认证连接申请的一次性认证 nonce（仅由该连接的工作协程访问）
+type challenge struct {
+	hardwareID string
+	nonce      []byte
+	expires    time.Time
+}
+
+// SetChallenge 为连接记录待签名的 nonce，覆盖之前未使用的挑战
+func (c *Client) SetChallenge(hardwareID string, nonce []byte, expires time.Time) {
+	c.challenge = &challenge{hardwareID: hardwareID, nonce: nonce, expires: expires}
+}
+
+// TakeChallenge 取出并清除连接的挑战；HardwareID 不符或已过期时返回 false
+func (c *Client) TakeChallenge(hardwareID string) ([]byte, bool) {
+	ch := c.challenge
+	c.challenge = nil
+	if ch == nil || time.Now().After(ch.expires) ||
+		subtle.ConstantTimeCompare([]byte(ch.hardwareID), []byte(hardwareID)) != 1 {
+		return nil, false
+	}
+	return ch.nonce, true
+}
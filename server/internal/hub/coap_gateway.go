@@ -0,0 +1,544 @@
+package hub
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/coap"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// coapExchangeLifetime CON 请求去重窗口（RFC 7252 EXCHANGE_LIFETIME）
+	coapExchangeLifetime = 247 * time.Second
+	// coapHubTimeout 等待 Hub 应答的上限（需小于客户端重传总时长）
+	coapHubTimeout = 5 * time.Second
+)
+
+var errCoAPTimeout = errors.New("coap: hub response timeout")
+
+// CoAPAuthenticator 认证 CoAP 设备并返回其设备 UID（由 controller 实现，可访问数据库）
+type CoAPAuthenticator interface {
+	AuthenticateCoAP(deviceUID uint64, credential []byte) (uint64, error)
+}
+
+// coapGateway CoAP 网关：每个已认证设备对应一个会话与一个 Client
+type coapGateway struct {
+	s    *Server
+	conn net.PacketConn
+	mid  atomic.Uint32
+
+	mu        sync.Mutex
+	sessions  map[string]*coapSession // 会话令牌 → 会话
+	byDevice  map[uint64]*coapSession
+	exchanges map[string]*coapExchange // "地址/MessageID" → CON 请求的应答（去重）
+}
+
+type coapExchange struct {
+	resp []byte // nil 表示仍在处理
+	at   time.Time
+}
+
+// coapSession 一个已认证设备的网关会话
+type coapSession struct {
+	g        *coapGateway
+	token    string
+	client   *Client
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu        sync.Mutex
+	addr      net.Addr
+	pending   map[uint64]chan []byte   // Hub 请求 MsgID → 应答帧
+	observers map[string]*coapObserver // 变量名 → 观察者
+}
+
+type coapObserver struct {
+	addr    net.Addr
+	token   []byte
+	seq     uint32
+	lastMID uint16
+}
+
+// ListenCoAP 在 conn 上运行 CoAP 网关，直至 conn 关闭。
+// 本仓库不内置 DTLS：如需 DTLS-PSK，可由前置的 DTLS 终结代理转发明文 CoAP 至本地端口。
+func (s *Server) ListenCoAP(conn net.PacketConn) error {
+	g := &coapGateway{s: s, conn: conn, sessions: make(map[string]*coapSession), byDevice: make(map[uint64]*coapSession), exchanges: make(map[string]*coapExchange)}
+	log.Info().Str("address", conn.LocalAddr().String()).Msg("CoAP 网关已启动")
+	go g.janitor()
+	buf := make([]byte, 64*1024)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		m, err := coap.Parse(buf[:n])
+		if err != nil {
+			continue
+		}
+		g.receive(addr, m)
+	}
+}
+
+// startCoAPFromConfig 按配置启动 CoAP 网关（未配置 ListenAddr 时不启用）
+func (s *Server) startCoAPFromConfig() {
+	addr := config.AppConfig.CoAP.ListenAddr
+	if addr == "" {
+		return
+	}
+	if s.CoAPAuth == nil {
+		log.Warn().Msg("未注入 CoAP 认证器，CoAP 网关不启用")
+		return
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("无法启动 CoAP 网关")
+	}
+	go func() {
+		if err := s.ListenCoAP(conn); err != nil {
+			log.Error().Err(err).Msg("CoAP 网关已停止")
+		}
+	}()
+}
+
+// receive 在读协程内完成去重与分类，请求本身交由独立协程处理（可能等待 Hub 应答）
+func (g *coapGateway) receive(addr net.Addr, m *coap.Message) {
+	switch {
+	case m.Type == coap.Reset:
+		g.dropObserver(addr, m.MessageID)
+		return
+	case m.Type == coap.Acknowledgement || m.Code>>5 != 0:
+		return
+	case m.Code == coap.Empty:
+		// CoAP ping：以 RST 应答
+		if m.Type == coap.Confirmable {
+			g.write(addr, (&coap.Message{Type: coap.Reset, MessageID: m.MessageID}).Marshal())
+		}
+		return
+	}
+	key := ""
+	if m.Type == coap.Confirmable {
+		key = addr.String() + "/" + strconv.Itoa(int(m.MessageID))
+		g.mu.Lock()
+		if ex, ok := g.exchanges[key]; ok {
+			g.mu.Unlock()
+			if ex.resp != nil {
+				g.write(addr, ex.resp)
+			}
+			return
+		}
+		g.exchanges[key] = &coapExchange{at: time.Now()}
+		g.mu.Unlock()
+	}
+	go g.handle(addr, m, key)
+}
+
+func (g *coapGateway) handle(addr net.Addr, req *coap.Message, key string) {
+	resp := g.dispatch(addr, req)
+	resp.Token = req.Token
+	if req.Type == coap.Confirmable {
+		resp.Type, resp.MessageID = coap.Acknowledgement, req.MessageID
+	} else {
+		resp.Type, resp.MessageID = coap.NonConfirmable, g.nextMID()
+	}
+	b := resp.Marshal()
+	if key != "" {
+		g.mu.Lock()
+		if ex, ok := g.exchanges[key]; ok {
+			ex.resp = b
+		}
+		g.mu.Unlock()
+	}
+	g.write(addr, b)
+}
+
+// dispatch 资源映射：
+//
+//	POST /auth             负载 "<uid>:<设备密钥或 userKey>"，返回会话令牌
+//	GET  /vars/<name>?s=…  读取本设备变量（Observe=0 订阅变更，Observe=1 取消）
+//	PUT  /vars/<name>?s=…  写入本设备变量（负载为 JSON；非 JSON 按字符串处理）
+//	POST /msg/<uid>?s=…    以 MSG_SEND 投递负载
+func (g *coapGateway) dispatch(addr net.Addr, req *coap.Message) *coap.Message {
+	path := req.Path()
+	if len(path) == 1 && path[0] == "auth" {
+		if req.Code != coap.POST {
+			return &coap.Message{Code: coap.MethodNotAllowed}
+		}
+		return g.auth(addr, req.Payload)
+	}
+	sess := g.session(req.Query("s"), addr)
+	if sess == nil {
+		return &coap.Message{Code: coap.Unauthorized}
+	}
+	if len(path) != 2 {
+		return &coap.Message{Code: coap.NotFound}
+	}
+	switch path[0] {
+	case "vars":
+		if !IsValidVarName(path[1]) {
+			return &coap.Message{Code: coap.BadRequest}
+		}
+		switch req.Code {
+		case coap.GET:
+			return sess.readVar(addr, req, path[1])
+		case coap.PUT:
+			return sess.writeVar(path[1], req.Payload)
+		}
+		return &coap.Message{Code: coap.MethodNotAllowed}
+	case "msg":
+		if req.Code != coap.POST {
+			return &coap.Message{Code: coap.MethodNotAllowed}
+		}
+		target, err := strconv.ParseUint(path[1], 10, 64)
+		if err != nil || target == 0 {
+			return &coap.Message{Code: coap.BadRequest}
+		}
+		return sess.sendMsg(target, req.Payload)
+	}
+	return &coap.Message{Code: coap.NotFound}
+}
+
+// auth 认证设备并建立会话；同一设备重复认证时替换旧会话
+func (g *coapGateway) auth(addr net.Addr, payload []byte) *coap.Message {
+	uidStr, cred, ok := strings.Cut(string(payload), ":")
+	uid, err := strconv.ParseUint(uidStr, 10, 64)
+	if !ok || err != nil {
+		return &coap.Message{Code: coap.BadRequest}
+	}
+	if uid, err = g.s.CoAPAuth.AuthenticateCoAP(uid, []byte(cred)); err != nil {
+		log.Warn().Err(err).Str("remote", addr.String()).Msg("CoAP 设备认证失败")
+		return &coap.Message{Code: coap.Unauthorized}
+	}
+	tok := make([]byte, 8)
+	if _, err := rand.Read(tok); err != nil {
+		return &coap.Message{Code: coap.InternalError}
+	}
+
+	qsize := config.AppConfig.WS.SendQueueSize
+	if qsize <= 0 {
+		qsize = 256
+	}
+	idle := time.Duration(config.AppConfig.CoAP.SessionIdleSec) * time.Second
+	if idle <= 0 {
+		idle = 600 * time.Second
+	}
+	client := &Client{Hub: g.s, Send: make(chan []byte, qsize), RemoteAddr: addr.String(), UserAgent: "coap", Binary: true, Protocol: "coap", idleTimeout: idle}
+	client.lastActive.Store(time.Now().UnixNano())
+	sess := &coapSession{g: g, token: hex.EncodeToString(tok), client: client, stop: make(chan struct{}), addr: addr, pending: make(map[uint64]chan []byte), observers: make(map[string]*coapObserver)}
+	client.closeFn = sess.shutdown
+
+	g.s.Register <- client
+	g.s.Attach(client, uid)
+	go sess.run()
+
+	g.mu.Lock()
+	old := g.byDevice[uid]
+	if old != nil {
+		delete(g.sessions, old.token)
+	}
+	g.sessions[sess.token] = sess
+	g.byDevice[uid] = sess
+	g.mu.Unlock()
+	if old != nil {
+		old.shutdown()
+	}
+	log.Info().Uint64("deviceUID", uid).Str("remote", addr.String()).Msg("CoAP 设备已接入")
+
+	resp := &coap.Message{Code: coap.Created, Payload: []byte(sess.token)}
+	resp.SetUint(coap.OptContentFormat, coap.FormatText)
+	return resp
+}
+
+// session 查找会话并刷新活跃时间与对端地址（NAT 重绑定）
+func (g *coapGateway) session(token string, addr net.Addr) *coapSession {
+	g.mu.Lock()
+	sess := g.sessions[token]
+	g.mu.Unlock()
+	if sess == nil {
+		return nil
+	}
+	sess.client.lastActive.Store(time.Now().UnixNano())
+	sess.mu.Lock()
+	sess.addr = addr
+	sess.mu.Unlock()
+	return sess
+}
+
+func (g *coapGateway) remove(sess *coapSession) {
+	g.mu.Lock()
+	if g.sessions[sess.token] == sess {
+		delete(g.sessions, sess.token)
+	}
+	if g.byDevice[sess.client.DeviceID] == sess {
+		delete(g.byDevice, sess.client.DeviceID)
+	}
+	g.mu.Unlock()
+}
+
+// dropObserver 客户端以 RST 拒收通知时取消对应观察
+func (g *coapGateway) dropObserver(addr net.Addr, mid uint16) {
+	g.mu.Lock()
+	list := make([]*coapSession, 0, len(g.sessions))
+	for _, sess := range g.sessions {
+		list = append(list, sess)
+	}
+	g.mu.Unlock()
+	for _, sess := range list {
+		sess.mu.Lock()
+		for name, o := range sess.observers {
+			if o.lastMID == mid && o.addr.String() == addr.String() {
+				delete(sess.observers, name)
+			}
+		}
+		sess.mu.Unlock()
+	}
+}
+
+// janitor 清理过期的去重记录
+func (g *coapGateway) janitor() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		g.mu.Lock()
+		for k, ex := range g.exchanges {
+			if now.Sub(ex.at) > coapExchangeLifetime {
+				delete(g.exchanges, k)
+			}
+		}
+		g.mu.Unlock()
+	}
+}
+
+func (g *coapGateway) nextMID() uint16 { return uint16(g.mid.Add(1)) }
+
+func (g *coapGateway) write(addr net.Addr, b []byte) {
+	if _, err := g.conn.WriteTo(b, addr); err != nil {
+		log.Warn().Err(err).Str("remote", addr.String()).Msg("CoAP 写入失败")
+	}
+}
+
+// shutdown 结束会话（幂等）：由 run 协程向 Hub 注销
+func (cs *coapSession) shutdown() {
+	cs.stopOnce.Do(func() { close(cs.stop) })
+}
+
+// run 消费 Hub 下发的帧：应答交给等待中的请求，变量变更推送给观察者
+func (cs *coapSession) run() {
+	stop := cs.stop
+	for {
+		select {
+		case frame, ok := <-cs.client.Send:
+			if !ok {
+				cs.g.remove(cs)
+				return
+			}
+			cs.client.framesOut.Add(1)
+			cs.client.bytesOut.Add(uint64(len(frame)))
+			cs.client.rawBytesOut.Add(uint64(len(frame)))
+			cs.deliver(frame)
+		case <-stop:
+			// 注销后 Hub 关闭 Send，循环随之退出
+			stop = nil
+			cs.client.setCloseReason("coap session closed")
+			cs.g.s.Unregister <- cs.client
+		}
+	}
+}
+
+func (cs *coapSession) deliver(frame []byte) {
+	h, pl, err := bin.DecodeFrame(frame)
+	if err != nil {
+		return
+	}
+	var reqID uint64
+	switch h.TypeID {
+	case bin.TypeOKResp:
+		reqID, _, _, err = bin.DecodeOKResp(pl)
+	case bin.TypeErrResp:
+		reqID, _, _, err = bin.DecodeErrResp(pl)
+	case bin.TypeVarListResp:
+		reqID, _, err = bin.DecodeVarListResp(pl)
+	case bin.TypeVarChangedNotify:
+		if _, items, err := bin.DecodeVarChangedNotify(pl); err == nil {
+			for _, it := range items {
+				if it.DeviceUID == cs.client.DeviceID {
+					cs.notify(it.Name, it.Value)
+				}
+			}
+		}
+		return
+	default:
+		log.Debug().Uint16("typeID", h.TypeID).Uint64("deviceUID", cs.client.DeviceID).Msg("CoAP 会话忽略下行帧")
+		return
+	}
+	if err != nil {
+		return
+	}
+	cs.mu.Lock()
+	ch := cs.pending[reqID]
+	delete(cs.pending, reqID)
+	cs.mu.Unlock()
+	if ch != nil {
+		ch <- frame
+	}
+}
+
+// submit 以本设备身份向 Hub 提交一帧；wait 为真时等待同 MsgID 的应答
+func (cs *coapSession) submit(typeID uint16, target uint64, payload []byte, wait bool) (bin.HeaderV1, []byte, error) {
+	h := bin.HeaderV1{TypeID: typeID, MsgID: uint64(time.Now().UnixNano()), Source: cs.client.DeviceID, Target: target, Timestamp: time.Now().UnixMilli()}
+	frame, err := bin.EncodeFrame(h, payload)
+	if err != nil {
+		return bin.HeaderV1{}, nil, err
+	}
+	ch := make(chan []byte, 1)
+	if wait {
+		cs.mu.Lock()
+		cs.pending[h.MsgID] = ch
+		cs.mu.Unlock()
+		defer func() {
+			cs.mu.Lock()
+			delete(cs.pending, h.MsgID)
+			cs.mu.Unlock()
+		}()
+	}
+	cs.client.framesIn.Add(1)
+	cs.client.bytesIn.Add(uint64(len(frame)))
+	timer := time.NewTimer(coapHubTimeout)
+	defer timer.Stop()
+	select {
+	case cs.g.s.Broadcast <- &HubMessage{Client: cs.client, Message: frame, IsBinary: true}:
+	case <-timer.C:
+		return bin.HeaderV1{}, nil, errCoAPTimeout
+	}
+	if !wait {
+		return bin.HeaderV1{}, nil, nil
+	}
+	select {
+	case resp := <-ch:
+		return bin.DecodeFrame(resp)
+	case <-timer.C:
+		return bin.HeaderV1{}, nil, errCoAPTimeout
+	}
+}
+
+func (cs *coapSession) readVar(addr net.Addr, req *coap.Message, name string) *coap.Message {
+	obs, hasObs := req.Observe()
+	if hasObs && obs == 1 {
+		cs.mu.Lock()
+		delete(cs.observers, name)
+		cs.mu.Unlock()
+		hasObs = false
+	}
+	uid := cs.client.DeviceID
+	h, pl, err := cs.submit(bin.TypeVarListReq, 0, bin.EncodeVarListReq("", &uid), true)
+	if err != nil {
+		return &coap.Message{Code: coap.GatewayTimeout}
+	}
+	if h.TypeID != bin.TypeVarListResp {
+		return coapErrResp(h, pl)
+	}
+	_, items, err := bin.DecodeVarListResp(pl)
+	if err != nil {
+		return &coap.Message{Code: coap.InternalError}
+	}
+	for _, it := range items {
+		if it.Name != name {
+			continue
+		}
+		resp := &coap.Message{Code: coap.Content, Payload: it.Value}
+		resp.SetUint(coap.OptContentFormat, coap.FormatJSON)
+		if hasObs && obs == 0 {
+			cs.mu.Lock()
+			o := &coapObserver{addr: addr, token: req.Token}
+			cs.observers[name] = o
+			resp.SetUint(coap.OptObserve, o.seq)
+			cs.mu.Unlock()
+		}
+		return resp
+	}
+	return &coap.Message{Code: coap.NotFound}
+}
+
+func (cs *coapSession) writeVar(name string, payload []byte) *coap.Message {
+	value := payload
+	if !json.Valid(bytes.TrimSpace(value)) {
+		value, _ = json.Marshal(string(payload))
+	}
+	item := bin.VarUpdateItem{DeviceUID: cs.client.DeviceID, Name: name, Value: value}
+	h, pl, err := cs.submit(bin.TypeVarUpdateReq, 0, bin.EncodeVarUpdateReq("", []bin.VarUpdateItem{item}), true)
+	if err != nil {
+		return &coap.Message{Code: coap.GatewayTimeout}
+	}
+	if h.TypeID != bin.TypeOKResp {
+		return coapErrResp(h, pl)
+	}
+	// 本设备写入不会收到 VAR_CHANGED_NOTIFY，直接通知其观察者
+	cs.notify(name, value)
+	return &coap.Message{Code: coap.Changed}
+}
+
+func (cs *coapSession) sendMsg(target uint64, payload []byte) *coap.Message {
+	if _, _, err := cs.submit(bin.TypeMsgSend, target, payload, false); err != nil {
+		return &coap.Message{Code: coap.GatewayTimeout}
+	}
+	return &coap.Message{Code: coap.Changed}
+}
+
+// notify 向变量观察者发送 NON 通知
+func (cs *coapSession) notify(name string, value []byte) {
+	cs.mu.Lock()
+	o := cs.observers[name]
+	if o == nil {
+		cs.mu.Unlock()
+		return
+	}
+	o.seq = (o.seq + 1) & 0xffffff
+	o.lastMID = cs.g.nextMID()
+	msg := &coap.Message{Type: coap.NonConfirmable, Code: coap.Content, MessageID: o.lastMID, Token: o.token, Payload: value}
+	msg.SetUint(coap.OptObserve, o.seq)
+	msg.SetUint(coap.OptContentFormat, coap.FormatJSON)
+	addr := o.addr
+	cs.mu.Unlock()
+	cs.g.write(addr, msg.Marshal())
+}
+
+// coapErrResp 将 Hub 的 ERR_RESP 映射为 CoAP 响应码
+func coapErrResp(h bin.HeaderV1, pl []byte) *coap.Message {
+	if h.TypeID != bin.TypeErrResp {
+		return &coap.Message{Code: coap.InternalError}
+	}
+	_, code, msg, err := bin.DecodeErrResp(pl)
+	if err != nil {
+		return &coap.Message{Code: coap.InternalError}
+	}
+	resp := &coap.Message{Payload: msg}
+	switch code {
+	case 400:
+		resp.Code = coap.BadRequest
+	case 401:
+		resp.Code = coap.Unauthorized
+	case 403:
+		resp.Code = coap.Forbidden
+	case 404:
+		resp.Code = coap.NotFound
+	default:
+		resp.Code = coap.InternalError
+	}
+	return resp
+}
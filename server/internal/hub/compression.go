@@ -0,0 +1,197 @@
+package hub
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// 压缩：WS 链路（下级接入与上级链路）由 WebSocket 库以 permessage-deflate 透明压缩，小于 Compression.MinBytes 的消息不压缩；
+// 其他二进制链路（原始 TCP，或未启用 permessage-deflate 的 WS）经 HELLO 协商 compression 后使用帧级压缩（Flags bit2）。
+// 入站帧在重组后、路由前解压，出站帧在分片前压缩，Hub 内部统一处理未压缩的 v1 帧。
+
+// compressionEnabled 是否启用压缩（Compression.Disabled 为 false）
+func compressionEnabled() bool {
+	return !config.AppConfig.Compression.Disabled
+}
+
+// compressionMinBytes 负载不小于该值才压缩，默认 256
+func compressionMinBytes() int {
+	if v := config.AppConfig.Compression.MinBytes; v > 0 {
+		return v
+	}
+	return 256
+}
+
+// compressionLevel deflate 压缩级别（1-9），默认 6
+func compressionLevel() int {
+	if v := config.AppConfig.Compression.Level; v >= 1 && v <= 9 {
+		return v
+	}
+	return 6
+}
+
+var localDictOnce struct {
+	sync.Once
+	dict *bin.CompressionDict
+}
+
+// localDict 返回 Compression.DictFile 配置的预置字典（首次调用时加载，失败时不使用字典）
+func localDict() *bin.CompressionDict {
+	localDictOnce.Do(func() {
+		path := config.AppConfig.Compression.DictFile
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Error().Err(err).Str("file", path).Msg("读取压缩字典失败，帧级压缩不使用字典")
+			return
+		}
+		localDictOnce.dict = bin.NewCompressionDict(data)
+		log.Info().Str("file", path).Uint32("dictID", localDictOnce.dict.ID).Msg("已加载压缩字典")
+	})
+	return localDictOnce.dict
+}
+
+// lookupDict 按 ID 查找本地字典（解压入站帧时使用）
+func lookupDict(id uint32) *bin.CompressionDict {
+	if d := localDict(); d != nil && d.ID == id {
+		return d
+	}
+	return nil
+}
+
+// localDictIDs 返回 HELLO 中声明持有的字典 ID
+func localDictIDs() []uint32 {
+	if d := localDict(); d != nil {
+		return []uint32{d.ID}
+	}
+	return nil
+}
+
+// newCompressor 按配置创建帧压缩器；对端持有本地字典时使用该字典，返回选用的字典 ID
+func newCompressor(peerDicts []uint32) (*bin.Compressor, []uint32) {
+	var dict *bin.CompressionDict
+	if d := localDict(); d != nil {
+		for _, id := range peerDicts {
+			if id == d.ID {
+				dict = d
+				break
+			}
+		}
+	}
+	var chosen []uint32
+	if dict != nil {
+		chosen = []uint32{dict.ID}
+	}
+	return bin.NewCompressor(compressionLevel(), compressionMinBytes(), dict), chosen
+}
+
+// wsDeflateOffered 握手头部是否带有 permessage-deflate 扩展（请求头为对端提议，响应头为协商结果）
+func wsDeflateOffered(h http.Header) bool {
+	for _, v := range h.Values("Sec-WebSocket-Extensions") {
+		for _, ext := range strings.Split(v, ",") {
+			name, _, _ := strings.Cut(ext, ";")
+			if strings.EqualFold(strings.TrimSpace(name), "permessage-deflate") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeWS 写出一条 WS 消息；启用 permessage-deflate 时仅压缩不小于 MinBytes 的消息
+func writeWS(conn *websocket.Conn, deflate bool, mt int, data []byte) error {
+	if deflate {
+		conn.EnableWriteCompression(len(data) >= compressionMinBytes())
+	}
+	return conn.WriteMessage(mt, data)
+}
+
+// compressFrame 协商了帧级压缩时压缩出站帧；HELLO 应答本身不压缩（仅写协程调用）
+func (c *Client) compressFrame(frame []byte) []byte {
+	comp := c.compressor.Load()
+	if comp == nil {
+		return frame
+	}
+	var h bin.HeaderV1
+	if h.Decode(frame) != nil || h.TypeID == bin.TypeHelloResp {
+		return frame
+	}
+	out, _ := comp.CompressFrame(frame)
+	return out
+}
+
+// decompress 解压入站的帧级压缩帧；格式错误或字典未知时丢弃并返回 ERR 400（在 Run 协程内调用）
+func (s *Server) decompress(c *Client, frame []byte) ([]byte, bool) {
+	var h bin.HeaderV1
+	if h.Decode(frame) != nil || h.Flags&bin.FlagCompressed == 0 {
+		return frame, true
+	}
+	maxBytes, _, _ := reassemblyLimits()
+	out, err := bin.DecompressFrame(frame, lookupDict, maxBytes)
+	if err != nil {
+		log.Warn().Err(err).Uint64("clientID", c.DeviceID).Uint16("typeID", h.TypeID).Uint64("msgID", h.MsgID).Msg("解压帧失败，丢弃")
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, c.DeviceID, bin.EncodeErrResp(h.MsgID, 400, []byte(err.Error())))
+		return nil, false
+	}
+	return out, true
+}
+
+// decompressParent 解压上级下发的帧级压缩帧（父链路读协程与握手调用）
+func (s *Server) decompressParent(frame []byte) ([]byte, error) {
+	var h bin.HeaderV1
+	if h.Decode(frame) != nil || h.Flags&bin.FlagCompressed == 0 {
+		return frame, nil
+	}
+	maxBytes, _, _ := reassemblyLimits()
+	return bin.DecompressFrame(frame, lookupDict, maxBytes)
+}
+
+// meteredListener 统计每个接入连接在套接字上实际读写的字节数，用于计算 permessage-deflate 的压缩率
+type meteredListener struct{ net.Listener }
+
+func (l meteredListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &meteredConn{Conn: conn}, nil
+}
+
+type meteredConn struct {
+	net.Conn
+	in, out atomic.Uint64
+}
+
+func (c *meteredConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.in.Add(uint64(n))
+	return n, err
+}
+
+func (c *meteredConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.out.Add(uint64(n))
+	return n, err
+}
+
+// connMeter 返回 WS 连接底层的计量连接（TLS 时取其下层连接）；非经 meteredListener 接入时为 nil
+func connMeter(conn net.Conn) *meteredConn {
+	if tc, ok := conn.(*tls.Conn); ok {
+		conn = tc.NetConn()
+	}
+	mc, _ := conn.(*meteredConn)
+	return mc
+}
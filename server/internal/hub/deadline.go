@@ -0,0 +1,206 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// 请求时限与取消：二进制路由处理器在连接的工作协程内执行（见 worker.go），每个请求携带 context，截止时间取
+// Deadline 配置（按 TypeID）与请求附带的截止时刻（FlagDeadline）中较早者；CANCEL_REQ 取消同一连接上排队或执行中的请求。
+// 处理器将 ctx 传入 controller、service 与 GORM，超时或取消后数据库查询随之中止。
+
+// 请求被取消或超过截止时间时的应答码
+const (
+	ErrCodeCancelled int32 = 499
+	ErrCodeDeadline  int32 = 504
+)
+
+// requestTimeout 返回 TypeID 的处理时限：Deadline.PerType（按名称或十进制 TypeID）覆盖 Deadline.DefaultMs，默认 5 秒
+func requestTimeout(typeID uint16) time.Duration {
+	cfg := config.AppConfig.Deadline
+	ms := cfg.DefaultMs
+	if len(cfg.PerType) > 0 {
+		if t, ok := bin.LookupType(typeID); ok && cfg.PerType[t.Name] > 0 {
+			ms = cfg.PerType[t.Name]
+		}
+		if v := cfg.PerType[strconv.Itoa(int(typeID))]; v > 0 {
+			ms = v
+		}
+	}
+	if ms <= 0 {
+		ms = 5000
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// requestContext 为请求派生 context：截止时间取配置时限与请求附带的截止时刻（零值表示未附带）中较早者
+func requestContext(parent context.Context, typeID uint16, requested time.Time) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(requestTimeout(typeID))
+	if !requested.IsZero() && requested.Before(deadline) {
+		deadline = requested
+	}
+	return context.WithDeadline(parent, deadline)
+}
+
+// RequestErr 请求的 context 已取消或超过截止时间时返回应答码与说明（499 request cancelled / 504 deadline exceeded）
+func RequestErr(ctx context.Context) (code int32, msg string, ok bool) {
+	switch err := ctx.Err(); {
+	case err == nil:
+		return 0, "", false
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeDeadline, "deadline exceeded", true
+	default:
+		return ErrCodeCancelled, "request cancelled", true
+	}
+}
+
+const (
+	// maxEarlyCancels 每个连接记录的、目标请求尚未开始执行的取消数上限
+	maxEarlyCancels = 64
+	earlyCancelTTL  = time.Minute
+)
+
+// requestTracker 连接上正在执行的请求与提前到达的取消：执行由工作协程登记，取消来自读协程或注销
+type requestTracker struct {
+	mu     sync.Mutex
+	msgID  uint64
+	cancel context.CancelFunc
+	early  map[uint64]time.Time
+}
+
+// begin 登记开始执行的请求；该请求已被提前取消时返回 true
+func (t *requestTracker) begin(msgID uint64, cancel context.CancelFunc) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.early[msgID]; ok {
+		delete(t.early, msgID)
+		return true
+	}
+	t.msgID, t.cancel = msgID, cancel
+	return false
+}
+
+// end 请求执行结束
+func (t *requestTracker) end(msgID uint64) {
+	t.mu.Lock()
+	if t.cancel != nil && t.msgID == msgID {
+		t.cancel = nil
+	}
+	t.mu.Unlock()
+}
+
+// cancelRequest 取消正在执行的请求；目标尚未开始执行（仍在队列中）时记下，轮到时直接以 499 应答
+func (t *requestTracker) cancelRequest(msgID uint64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cancel != nil && t.msgID == msgID {
+		t.cancel()
+		t.cancel = nil
+		return
+	}
+	for id, at := range t.early {
+		if now.Sub(at) > earlyCancelTTL {
+			delete(t.early, id)
+		}
+	}
+	if len(t.early) >= maxEarlyCancels {
+		return
+	}
+	if t.early == nil {
+		t.early = make(map[uint64]time.Time)
+	}
+	t.early[msgID] = now
+}
+
+// abort 取消正在执行的请求（连接已注销）
+func (t *requestTracker) abort() {
+	t.mu.Lock()
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+	t.mu.Unlock()
+}
+
+// forget 清除提前记录的取消（工作协程处理 CANCEL_REQ 时，排在其前面的目标请求均已执行完毕）
+func (t *requestTracker) forget(msgID uint64) {
+	t.mu.Lock()
+	delete(t.early, msgID)
+	t.mu.Unlock()
+}
+
+// peekCancel 在读协程内识别 CANCEL_REQ，使其不必排在工作队列后面即可生效。
+// 此时帧尚未经帧认证校验：能注入帧的攻击者同样能丢弃原请求，提前取消不会扩大其能力；应答仍在校验后按序发出
+func (c *Client) peekCancel(frame []byte) {
+	var (
+		h       bin.HeaderV1
+		payload []byte
+	)
+	if bin.FrameVersion(frame) == bin.Version2 {
+		h2, pl, err := bin.DecodeFrameV2(frame)
+		if err != nil {
+			return
+		}
+		h, payload = h2.HeaderV1, pl
+	} else {
+		h1, pl, err := bin.DecodeFrame(frame)
+		if err != nil {
+			return
+		}
+		h, payload = h1, pl
+	}
+	if h.TypeID != bin.TypeCancelReq || h.Flags&(bin.FlagCompressed|bin.FlagFragment) != 0 {
+		return
+	}
+	if h.Flags&bin.FlagMAC != 0 {
+		if len(payload) < bin.FrameMACSize {
+			return
+		}
+		payload = payload[:len(payload)-bin.FrameMACSize]
+	}
+	if _, pl, err := bin.SplitDeadline(&h, payload); err == nil {
+		payload = pl
+	}
+	if msgID, err := bin.DecodeCancelReq(payload); err == nil {
+		c.requests.cancelRequest(msgID, time.Now())
+	}
+}
+
+// handleCancel 处理 CANCEL_REQ：目标请求的结果以其自身的应答（被取消时为 ERR 499）为准，此处仅确认受理
+func (s *Server) handleCancel(c *Client, h bin.HeaderV1, payload []byte) {
+	msgID, err := bin.DecodeCancelReq(payload)
+	if err != nil {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, c.DeviceID, bin.EncodeErrResp(h.MsgID, 400, []byte("bad request")))
+		return
+	}
+	c.requests.forget(msgID)
+	s.SendBin(c, bin.TypeOKResp, h.MsgID, c.DeviceID, bin.EncodeOKResp(h.MsgID, 0, nil))
+}
+
+// dispatch 以带截止时间的 context 执行路由处理器；请求在执行前已被取消或已过截止时间时直接应答
+// （在连接的工作协程或 Invoke 的调用方协程内调用）
+func (s *Server) dispatch(parent context.Context, c *Client, h bin.HeaderV1, payload []byte, deadline time.Time, handler BinHandler) {
+	ctx, cancel := requestContext(parent, h.TypeID, deadline)
+	defer cancel()
+	if c.requests.begin(h.MsgID, cancel) {
+		cancel()
+	}
+	defer c.requests.end(h.MsgID)
+	if code, msg, ok := RequestErr(ctx); ok {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, c.DeviceID, bin.EncodeErrResp(h.MsgID, code, []byte(msg)))
+		return
+	}
+	start := time.Now()
+	handler(ctx, s, c, h, payload)
+	if err := ctx.Err(); err != nil {
+		log.Warn().Err(err).Uint16("typeID", h.TypeID).Uint64("msgID", h.MsgID).Uint64("clientID", c.DeviceID).Dur("elapsed", time.Since(start)).Msg("请求已取消或超过截止时间")
+	}
+}
@@ -0,0 +1,34 @@
+package hub
+
+import (
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WatchE2EKeys 记录连接查询过的公钥，之后公钥变化时向其推送 E2E_KEY_CHANGED_NOTIFY（e2eWatch 由 Run 协程访问，不可在 Run 协程内调用）
+func (c *Client) WatchE2EKeys(deviceUIDs []uint64) {
+	c.Hub.runSync(func() {
+		if c.e2eWatch == nil {
+			c.e2eWatch = make(map[uint64]struct{}, len(deviceUIDs))
+		}
+		for _, uid := range deviceUIDs {
+			c.e2eWatch[uid] = struct{}{}
+		}
+	})
+}
+
+// AnnounceE2EKey 向本节点查询过该设备公钥的连接推送新公钥（不可在 Run 协程内调用）
+func (s *Server) AnnounceE2EKey(k bin.E2EKey) {
+	pl := bin.EncodeE2EKeyChangedNotify([]bin.E2EKey{k})
+	n := 0
+	s.runSync(func() {
+		for id, c := range s.Clients {
+			if _, ok := c.e2eWatch[k.DeviceUID]; ok && id != k.DeviceUID && c.accepts(bin.TypeE2EKeyChangedNotify) {
+				s.SendBin(c, bin.TypeE2EKeyChangedNotify, invokeSeq.Add(1), id, pl)
+				n++
+			}
+		}
+	})
+	log.Info().Uint64("deviceUID", k.DeviceUID).Int("peers", n).Msg("E2E 公钥已更新并通知对端")
+}
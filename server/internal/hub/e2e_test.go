@@ -0,0 +1,116 @@
+package hub
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+// expectErrResp 等待 c 收到针对 msgID 的 ERR_RESP，并核对错误码
+func expectErrResp(t *testing.T, c *Client, msgID uint64, code int32) {
+	t.Helper()
+	select {
+	case frame := <-c.Send:
+		h, pl, err := bin.DecodeFrame(frame)
+		if err != nil || h.TypeID != bin.TypeErrResp {
+			t.Fatalf("got type %d, want ERR_RESP: %v", h.TypeID, err)
+		}
+		if id, got, msg, _ := bin.DecodeErrResp(pl); id != msgID || got != code {
+			t.Fatalf("err %d for msg %d (%s), want %d for msg %d", got, id, msg, code, msgID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("device %d received nothing", c.DeviceID)
+	}
+}
+
+func mustX25519(t *testing.T) *ecdh.PrivateKey {
+	t.Helper()
+	k, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestE2EMessageForwarding(t *testing.T) {
+	const alice, bob = 10001, 10002
+	approveDevices(t, alice, bob)
+	s := newTestServer(t)
+	a, b := attachTestClient(s, alice), attachTestClient(s, bob)
+	s.runSync(func() {
+		a.caps = &Capabilities{Version: 1, Features: []string{bin.FeatureE2E}}
+		b.caps = &Capabilities{Version: 1, Features: []string{bin.FeatureE2E}}
+	})
+	aKey, bKey := mustX25519(t), mustX25519(t)
+
+	// 密文帧原样交给目标：Hub 不解密也不改写负载，接收方可按帧头解密
+	h := bin.HeaderV1{TypeID: bin.TypeMsgSend, MsgID: 1, Source: alice, Target: bob, Timestamp: time.Now().UnixMilli()}
+	sealed, err := bin.SealE2E(aKey, &h, bKey.PublicKey().Bytes(), []byte("open sesame"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := submitFrame(t, s, a, h, sealed)
+	expectFrame(t, b, frame)
+	gh, pl, err := bin.DecodeFrame(frame)
+	if err != nil || gh.Flags&bin.FlagE2E == 0 || !bytes.Equal(pl, sealed) {
+		t.Fatalf("forwarded header %+v: %v", gh, err)
+	}
+	if pt, err := bin.OpenE2E(bKey, gh, aKey.PublicKey().Bytes(), pl); err != nil || string(pt) != "open sesame" {
+		t.Fatalf("open: %q %v", pt, err)
+	}
+
+	// 密文只能单播：广播或发往 Hub 自身被拒绝
+	submitFrame(t, s, a, bin.HeaderV1{TypeID: bin.TypeMsgSend, MsgID: 2, Flags: bin.FlagE2E, Source: alice, Target: 0}, sealed)
+	expectErrResp(t, a, 2, 400)
+
+	// 未协商 e2e 的连接不可发送密文，也不可使用公钥目录
+	s.runSync(func() { a.caps = &Capabilities{Version: 1} })
+	submitFrame(t, s, a, bin.HeaderV1{TypeID: bin.TypeMsgSend, MsgID: 3, Flags: bin.FlagE2E, Source: alice, Target: bob}, sealed)
+	expectErrResp(t, a, 3, 412)
+	submitFrame(t, s, a, bin.HeaderV1{TypeID: bin.TypeE2EKeyGetReq, MsgID: 4, Source: alice}, bin.EncodeE2EKeyGetReq([]uint64{bob}))
+	expectErrResp(t, a, 4, 412)
+	if len(b.Send) != 0 {
+		t.Fatalf("bob received %d rejected frames", len(b.Send))
+	}
+}
+
+func TestE2EKeyChangedNotify(t *testing.T) {
+	const owner = 10001
+	s := newTestServer(t)
+	watcher, other, legacy, self := attachTestClient(s, 10002), attachTestClient(s, 10003), attachTestClient(s, 10004), attachTestClient(s, owner)
+	pubsub := &Capabilities{Version: 1, Features: []string{bin.FeatureE2E, bin.FeaturePubSub}}
+	s.runSync(func() {
+		watcher.caps, other.caps, self.caps = pubsub, pubsub, pubsub
+		// 未协商 pubsub 的连接不接收主动推送
+		legacy.caps = &Capabilities{Version: 1, Features: []string{bin.FeatureE2E}}
+	})
+	for _, c := range []*Client{watcher, legacy, self} {
+		c.WatchE2EKeys([]uint64{owner})
+	}
+	other.WatchE2EKeys([]uint64{10099})
+
+	key := bin.E2EKey{DeviceUID: owner, PublicKey: mustX25519(t).PublicKey().Bytes(), UpdatedAt: 1}
+	s.AnnounceE2EKey(key)
+	select {
+	case frame := <-watcher.Send:
+		h, pl, err := bin.DecodeFrame(frame)
+		if err != nil || h.TypeID != bin.TypeE2EKeyChangedNotify || h.Target != 10002 {
+			t.Fatalf("got type %d target %d: %v", h.TypeID, h.Target, err)
+		}
+		keys, err := bin.DecodeE2EKeyChangedNotify(pl)
+		if err != nil || len(keys) != 1 || keys[0].DeviceUID != owner || !bytes.Equal(keys[0].PublicKey, key.PublicKey) {
+			t.Fatalf("notify %+v: %v", keys, err)
+		}
+	default:
+		t.Fatal("watcher not notified")
+	}
+	for _, c := range []*Client{other, legacy, self} {
+		if len(c.Send) != 0 {
+			t.Fatalf("device %d notified", c.DeviceID)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package hub
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/testdb"
+)
+
+// approveDevices 在测试数据库中登记已审批的设备（工作协程的准入检查据此放行）
+func approveDevices(t *testing.T, uids ...uint64) {
+	t.Helper()
+	db := testdb.Global(t)
+	for _, uid := range uids {
+		d := database.Device{DeviceUID: uid, HardwareID: fmt.Sprintf("hw-%d", uid), Role: database.RoleNode, Approved: true}
+		if err := db.Create(&d).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// submitFrame 以 c 的身份提交一帧，如同从其连接读到
+func submitFrame(t *testing.T, s *Server, c *Client, h bin.HeaderV1, payload []byte) []byte {
+	t.Helper()
+	frame, err := bin.EncodeFrame(h, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.runSync(func() { s.submit(c, request{h: h, payload: payload, frame: frame}) })
+	return frame
+}
+
+// expectFrame 等待 c 的发送队列收到 want
+func expectFrame(t *testing.T, c *Client, want []byte) {
+	t.Helper()
+	select {
+	case got := <-c.Send:
+		if !bytes.Equal(got, want) {
+			h, _, _ := bin.DecodeFrame(got)
+			t.Fatalf("device %d got frame type %d target %d", c.DeviceID, h.TypeID, h.Target)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("device %d received nothing", c.DeviceID)
+	}
+}
+
+func TestFileTransferThroughRelay(t *testing.T) {
+	const sender, relayUID, leaf = 10001, 10500, 10600
+	approveDevices(t, sender, relayUID)
+	s := newTestServer(t)
+	s.Presence = &fakePresence{relayed: map[uint64]uint64{leaf: relayUID}}
+	src := attachTestClient(s, sender)
+	relay := attachTestClient(s, relayUID)
+	relay.SetRelay(true)
+
+	// 下行：目标经中继在线，帧原样交给该中继
+	init := bin.FileInit{TransferID: 77, TotalSize: 4, FileHash: make([]byte, 32), ChunkSize: 4}
+	frame := submitFrame(t, s, src, bin.HeaderV1{TypeID: bin.TypeFileInitReq, MsgID: 1, Source: sender, Target: leaf}, bin.EncodeFileInitReq(init))
+	expectFrame(t, relay, frame)
+	frame = submitFrame(t, s, src, bin.HeaderV1{TypeID: bin.TypeFileChunk, MsgID: 2, Source: sender, Target: leaf}, bin.EncodeFileChunk(77, 0, []byte("data"), nil))
+	expectFrame(t, relay, frame)
+
+	// 上行：中继转来的应答直接投递给直连的发送方
+	frame = submitFrame(t, s, relay, bin.HeaderV1{TypeID: bin.TypeFileInitResp, MsgID: 1, Source: leaf, Target: sender}, bin.EncodeFileInitResp(1, 77, true, nil, 4, 1))
+	expectFrame(t, src, frame)
+
+	// 上级下发给该设备的帧同样经中继向下投递
+	frame, _ = bin.EncodeFrame(bin.HeaderV1{TypeID: bin.TypeMsgSend, MsgID: 3, Target: leaf}, []byte("from parent"))
+	s.FromParent <- frame
+	expectFrame(t, relay, frame)
+}
+
+func TestRelayLookupRequiresRelayConnection(t *testing.T) {
+	const other, leaf = 10500, 10600
+	s := newTestServer(t)
+	s.Presence = &fakePresence{relayed: map[uint64]uint64{leaf: other}}
+	// via 指向的连接不是以中继身份认证的：不向其投递
+	notRelay := attachTestClient(s, other)
+	frame, _ := bin.EncodeFrame(bin.HeaderV1{TypeID: bin.TypeMsgSend, MsgID: 1, Target: leaf}, []byte("hi"))
+	s.runSync(func() { s.forward(leaf, frame) })
+	if len(notRelay.Send) != 0 {
+		t.Fatal("frame delivered to a connection that is not a relay")
+	}
+}
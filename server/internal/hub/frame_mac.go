@@ -0,0 +1,141 @@
+package hub
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"strings"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// 帧认证模式（config.FrameMAC.Mode）
+const (
+	FrameMACOff       = "off"
+	FrameMACNegotiate = "negotiate"
+	FrameMACRequire   = "require"
+)
+
+// maxFrameMACFailures 单个连接累计校验失败达到该次数即断开
+const maxFrameMACFailures = 10
+
+// ErrFrameMACRequired require 模式下二进制链路的认证请求未携带帧认证公钥
+var ErrFrameMACRequired = errors.New("frame mac required")
+
+// FrameMACMode 返回帧认证模式，未配置或无法识别时为 negotiate
+func FrameMACMode() string {
+	switch m := strings.ToLower(strings.TrimSpace(config.AppConfig.FrameMAC.Mode)); m {
+	case FrameMACOff, FrameMACRequire:
+		return m
+	default:
+		return FrameMACNegotiate
+	}
+}
+
+// pendingFrameMAC 已协商、待认证响应写出后启用的下行认证状态
+type pendingFrameMAC struct {
+	mac    *bin.FrameMAC
+	typeID uint16
+	msgID  uint64
+}
+
+// binaryLink 是否为直接收发二进制帧的链路（WS 二进制与原始 TCP）；JSON、MQTT、CoAP 等接入经过转码
+func (c *Client) binaryLink() bool {
+	if c.Conn != nil {
+		return !c.JSON
+	}
+	return c.tcp != nil && strings.HasPrefix(c.Protocol, "tcp")
+}
+
+// frameMACCapable 是否为可启用帧认证的链路（仅二进制链路）
+func (c *Client) frameMACCapable() bool {
+	return c.binaryLink()
+}
+
+// FrameMACOffered 是否愿意在该连接上协商帧认证（设备挑战响应据此告知设备）
+func (c *Client) FrameMACOffered() bool {
+	return c.rxMAC.Load() == nil && FrameMACMode() != FrameMACOff && c.frameMACCapable()
+}
+
+// NegotiateFrameMAC 以对端临时公钥与认证绑定值派生链路密钥，返回写入认证响应的本端公钥。
+// 返回 nil 公钥表示不启用（模式为 off、链路不适用、对端未提供公钥，或同一连接上已启用时的重新认证）。
+// 上行帧立即要求认证；下行在 respTypeID/respMsgID 对应的认证响应写出之后才开始附带标签
+// （在该连接的工作协程或 Run 协程内调用，同一连接上不会并发）。
+func (c *Client) NegotiateFrameMAC(clientPub, binding []byte, respTypeID uint16, respMsgID uint64) ([]byte, error) {
+	if c.rxMAC.Load() != nil || FrameMACMode() == FrameMACOff || !c.frameMACCapable() {
+		return nil, nil
+	}
+	if len(clientPub) == 0 {
+		if FrameMACMode() == FrameMACRequire {
+			return nil, ErrFrameMACRequired
+		}
+		return nil, nil
+	}
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serverPub := priv.PublicKey().Bytes()
+	key, err := bin.DeriveFrameMACKey(priv, clientPub, clientPub, serverPub, binding)
+	if err != nil {
+		return nil, err
+	}
+	c.rxMAC.Store(bin.NewFrameMAC(key, bin.FrameMACClientToServer))
+	c.txMACPending.Store(&pendingFrameMAC{mac: bin.NewFrameMAC(key, bin.FrameMACServerToClient), typeID: respTypeID, msgID: respMsgID})
+	log.Info().Str("remoteAddr", c.RemoteAddr).Str("protocol", c.Protocol).Msg("链路已协商帧认证")
+	return serverPub, nil
+}
+
+// sealFrame 下行启用帧认证后为帧附带标签（仅写协程调用）
+func (c *Client) sealFrame(frame []byte) []byte {
+	if c.txMAC == nil {
+		return frame
+	}
+	return c.txMAC.Seal(frame)
+}
+
+// frameWritten 写出协商时登记的认证响应后启用下行帧认证（仅写协程调用）
+func (c *Client) frameWritten(frame []byte) {
+	p := c.txMACPending.Load()
+	if p == nil {
+		return
+	}
+	var h bin.HeaderV1
+	if h.Decode(frame) != nil || h.TypeID != p.typeID || h.MsgID != p.msgID {
+		return
+	}
+	c.txMAC = p.mac
+	c.txMACPending.Store(nil)
+}
+
+// openFrame 校验上行帧标签并返回去除标签的帧；未启用帧认证的连接拒绝置 FlagMAC 的帧。
+// 失败时丢弃该帧并上报，累计过多即断开（在 Run 协程内调用）。
+func (s *Server) openFrame(c *Client, frame []byte) ([]byte, bool) {
+	var err error
+	if rx := c.rxMAC.Load(); rx != nil {
+		var out []byte
+		if out, err = rx.Open(frame); err == nil {
+			return out, true
+		}
+	} else {
+		var h bin.HeaderV1
+		if h.Decode(frame) != nil || h.Flags&bin.FlagMAC == 0 {
+			return frame, true
+		}
+		err = errors.New("frame mac not negotiated")
+	}
+	c.macFailures++
+	log.Warn().Err(err).Uint64("deviceUID", c.DeviceID).Str("remoteAddr", c.RemoteAddr).Int("failures", c.macFailures).Msg("帧认证失败，丢弃")
+	if s.OnFrameMACFailure != nil {
+		s.OnFrameMACFailure(c.DeviceID, c.RemoteAddr, err.Error())
+	}
+	if c.macFailures >= maxFrameMACFailures {
+		c.setCloseReason("frame mac failures")
+		s.dropResume(c.DeviceID)
+		c.close()
+	}
+	return nil, false
+}
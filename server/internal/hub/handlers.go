@@ -1,19 +1,31 @@
 package hub
 
 import (
+	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
 
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
 
+// WebSocket 子协议：二进制帧，或 JSON 文本帧（经 TypeID 注册表与 protojson 转码）
 const (
-	writeWait  = 30 * time.Second
-	pongWait   = 60 * time.Second
-	pingPeriod = (pongWait * 9) / 10
+	SubprotocolBinary   = "myflowhub.bin.v1"
+	SubprotocolBinaryV2 = "myflowhub.bin.v2"
+	SubprotocolJSON     = "myflowhub.json.v1"
+)
+
+const (
+	writeWait = 30 * time.Second
 	// 提高最大消息大小，避免较大二进制帧导致 read limit exceeded → 1006 异常断开
 	maxMessageSize = 4 * 1024 * 1024 // 4MB
 )
@@ -21,16 +33,90 @@ const (
 // Client is a middleman between the websocket connection and the hub.
 type Client struct {
 	Hub        *Server
-	Conn       *websocket.Conn
+	Conn       *websocket.Conn // WebSocket 接入；TCP 接入时为空
+	tcp        net.Conn        // 原始 TCP 接入
+	closeFn    func()          // 无底层连接的接入（CoAP 会话）的关闭方式
 	Send       chan []byte
 	DeviceID   uint64
 	RemoteAddr string
 	UserAgent  string
 	Binary     bool
+	Protocol   string // 接入协议（WS 子协议等），用于会话记录
+	JSON       bool   // 下行以 JSON 文本帧发送（myflowhub.json.v1）
+	// CertHardwareID mTLS：已校验客户端证书的 Subject CN，ParentAuth 时可替代共享密钥
+	CertHardwareID string
+	CertSerial     string // 客户端证书序列号（十六进制），用于吊销后断开
+	// challenge Ed25519 设备认证的待签名 nonce（仅由该连接的工作协程访问）
+	challenge *challenge
+	// sessionExpires 认证会话到期时间（下级中继 ParentAuth），零值表示不过期
+	sessionExpires time.Time
+	// relay 以中继身份完成 ParentAuth（仅由 Run 协程修改，经 SetRelay）
+	relay bool
+	// e2eWatch 该连接查询过的 E2E 公钥（设备 UID），公钥变化时推送通知
+	e2eWatch map[uint64]struct{}
+	// 帧认证：rxMAC 由工作协程在认证时设置、此后仅 Run 协程使用，macFailures 仅在 Run 协程内访问；
+	// txMAC 仅由写协程访问；txMACPending 由认证处理器登记、写协程启用
+	rxMAC        atomic.Pointer[bin.FrameMAC]
+	txMAC        *bin.FrameMAC
+	txMACPending atomic.Pointer[pendingFrameMAC]
+	macFailures  int
+	// 协议版本：wireV2 为 true 时下行转换为 v2 帧（写协程读取）；reasm 为 v2 分片重组状态（仅 Run 协程访问）
+	wireV2 atomic.Bool
+	reasm  *bin.Reassembler
+	// HELLO 协商结果：caps 与 legacyFeatures（未发送 HELLO 的中继在 ParentAuth 中声明的 caps）仅由 Run 协程修改，
+	// 该连接的工作协程可读取（修改均经 runSync 进行，期间工作协程等待）；
+	// 对端声明的收发上限由写协程读取
+	caps           *Capabilities
+	legacyFeatures []string
+	peerMaxFrame   atomic.Uint32
+	peerMaxMessage atomic.Uint32
+	// 压缩：wsDeflate 该 WS 连接协商了 permessage-deflate（建立时设置）；compressor 为 HELLO 协商的帧级压缩器（写协程读取）；
+	// meter 统计套接字实际收发字节，rawBytesIn/rawBytesOut 为未压缩的帧字节数，二者用于计算压缩率
+	wsDeflate   bool
+	compressor  atomic.Pointer[bin.Compressor]
+	meter       *meteredConn
+	rawBytesIn  atomic.Uint64
+	rawBytesOut atomic.Uint64
+	// 会话恢复：resume 为绑定的可恢复会话；sendMu 串行化入队与编号，使序号与写出顺序一致，
+	// 并保护 sendClosed（Send 已关闭，之后的入队一律丢弃）
+	resume     atomic.Pointer[resumeSession]
+	sendMu     sync.Mutex
+	sendClosed bool
+	// credEpoch 认证时的凭据指纹，新建的可恢复会话据此在恢复前核对（仅在 Run 协程内访问）
+	credEpoch string
+	// requests 正在执行的请求与提前到达的取消（CANCEL_REQ）；inbox 为工作协程的请求队列（仅 Run 协程访问），
+	// gone 在注销时置位，此后队列中的请求不再执行
+	requests requestTracker
+	inbox    chan request
+	gone     atomic.Bool
 	// 控制帧：通过写协程发送 Pong，避免与业务写并发
 	pongCh chan string
 	// 诊断：记录最近一次成功读取
 	lastReadAt time.Time
+	// 最近一次活跃（任意帧或 Pong，UnixNano），供心跳看门狗跨协程读取
+	lastActive atomic.Int64
+	// 空闲超时（0 使用全局心跳超时；MQTT 取 1.5 倍 keepalive，CoAP 取会话空闲超时）
+	idleTimeout time.Duration
+	// 会话统计（业务帧，不含控制帧）与关闭原因
+	sessionSeq  uint64
+	bytesIn     atomic.Uint64
+	bytesOut    atomic.Uint64
+	framesIn    atomic.Uint64
+	framesOut   atomic.Uint64
+	closeReason atomic.Pointer[string]
+}
+
+// close 关闭底层连接（读协程随之退出并注销）
+func (c *Client) close() {
+	if c.Conn != nil {
+		_ = c.Conn.Close()
+	}
+	if c.tcp != nil {
+		_ = c.tcp.Close()
+	}
+	if c.closeFn != nil {
+		c.closeFn()
+	}
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -39,11 +125,14 @@ func (c *Client) readPump() {
 		c.Hub.Unregister <- c
 		c.Conn.Close()
 	}()
+	readTimeout := heartbeatTimeout()
 	c.Conn.SetReadLimit(maxMessageSize)
-	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.Conn.SetReadDeadline(time.Now().Add(readTimeout))
 	c.Conn.SetPongHandler(func(string) error {
 		log.Debug().Uint64("clientID", c.DeviceID).Msg("readPump: 收到 Pong，刷新读超时")
-		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+		now := time.Now()
+		c.lastActive.Store(now.UnixNano())
+		c.Conn.SetReadDeadline(now.Add(readTimeout))
 		return nil
 	})
 	for {
@@ -52,22 +141,48 @@ func (c *Client) readPump() {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Error().Err(err).Msg("Unexpected websocket close")
 			}
+			if ce, ok := err.(*websocket.CloseError); ok {
+				c.setCloseReason(fmt.Sprintf("peer closed: %d %s", ce.Code, ce.Text))
+			} else {
+				c.setCloseReason("read error: " + err.Error())
+			}
 			break
 		}
+		c.framesIn.Add(1)
+		c.bytesIn.Add(uint64(len(message)))
 		// 任何成功读取都刷新读超时，提升稳健性
 		c.lastReadAt = time.Now()
-		c.Conn.SetReadDeadline(c.lastReadAt.Add(pongWait))
+		c.lastActive.Store(c.lastReadAt.UnixNano())
+		c.Conn.SetReadDeadline(c.lastReadAt.Add(readTimeout))
 		if mt != websocket.BinaryMessage {
-			// 二进制专用：拒绝非二进制帧
-			continue
+			if !c.JSON {
+				// 未协商 JSON：拒绝非二进制帧
+				continue
+			}
+			frame, err := bin.FrameFromJSON(message)
+			if err != nil {
+				log.Warn().Err(err).Uint64("clientID", c.DeviceID).Msg("readPump: JSON 帧转码失败")
+				c.sendTranscodeError(message, err)
+				continue
+			}
+			message = frame
 		}
+		c.peekCancel(message)
 		c.Hub.Broadcast <- &HubMessage{Client: c, Message: message, IsBinary: true}
 	}
 }
 
+// sendTranscodeError 以 ERR_RESP(400) 告知 JSON 帧无法转码（尽量沿用信封中的 msgId）
+func (c *Client) sendTranscodeError(message []byte, err error) {
+	var env bin.JSONEnvelope
+	_ = json.Unmarshal(message, &env)
+	c.Hub.SendBin(c, bin.TypeErrResp, uint64(env.MsgID), c.DeviceID, bin.EncodeErrResp(uint64(env.MsgID), 400, []byte(err.Error())))
+}
+
 // writePump pumps messages from the hub to the websocket connection.
 func (c *Client) writePump() {
-	ticker := time.NewTicker(pingPeriod)
+	// Ping 周期与心跳周期一致，Pong 即视为一次心跳
+	ticker := time.NewTicker(time.Duration(HeartbeatSec()) * time.Second)
 	defer func() {
 		ticker.Stop()
 		c.Conn.Close()
@@ -79,15 +194,38 @@ func (c *Client) writePump() {
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// The hub closed the channel.
+				c.setCloseReason("closed by hub")
 				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
 				log.Info().Uint64("clientID", c.DeviceID).Msg("writePump: channel 已关闭，正常退出")
 				return
 			}
-			// 发送队列仅用于透传二进制帧
-			if err := c.Conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
-				log.Error().Err(err).Uint64("clientID", c.DeviceID).Msg("writePump: 写入二进制消息失败")
-				return
+			// 发送队列统一为 v1 二进制帧；JSON 连接在写出前转码，二进制连接按协商版本转换并附带帧认证标签
+			c.rawBytesOut.Add(uint64(len(message)))
+			if c.JSON {
+				if text, err := bin.FrameToJSON(message); err == nil {
+					if err := writeWS(c.Conn, c.wsDeflate, websocket.TextMessage, text); err != nil {
+						log.Error().Err(err).Uint64("clientID", c.DeviceID).Msg("writePump: 写入 JSON 消息失败")
+						c.setCloseReason("write error: " + err.Error())
+						return
+					}
+					c.framesOut.Add(1)
+					c.bytesOut.Add(uint64(len(text)))
+					continue
+				}
+				log.Warn().Uint64("clientID", c.DeviceID).Msg("writePump: JSON 转码失败，按二进制发送")
 			}
+			for _, f := range c.wireFrames(c.compressFrame(message)) {
+				f = c.sealFrame(f)
+				c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+				if err := writeWS(c.Conn, c.wsDeflate, websocket.BinaryMessage, f); err != nil {
+					log.Error().Err(err).Uint64("clientID", c.DeviceID).Msg("writePump: 写入二进制消息失败")
+					c.setCloseReason("write error: " + err.Error())
+					return
+				}
+				c.bytesOut.Add(uint64(len(f)))
+				c.frameWritten(f)
+			}
+			c.framesOut.Add(1)
 			log.Debug().Uint64("clientID", c.DeviceID).Int("bytes", len(message)).Msg("writePump: 成功写入消息")
 		case appData := <-c.pongCh:
 			// 通过单写协程发送 Pong 控制帧
@@ -98,6 +236,7 @@ func (c *Client) writePump() {
 					Time("lastReadAt", c.lastReadAt).
 					Dur("sinceLastRead", time.Since(c.lastReadAt)).
 					Msg("writePump: 发送 Pong 失败")
+				c.setCloseReason("write error: " + err.Error())
 				return
 			}
 		case <-ticker.C:
@@ -108,6 +247,7 @@ func (c *Client) writePump() {
 					Time("lastReadAt", c.lastReadAt).
 					Dur("sinceLastRead", time.Since(c.lastReadAt)).
 					Msg("writePump: 发送 Ping 失败")
+				c.setCloseReason("write error: " + err.Error())
 				return
 			}
 		}
@@ -116,12 +256,13 @@ func (c *Client) writePump() {
 
 // HandleSubordinateConnection handles websocket requests from the peer.
 func (s *Server) HandleSubordinateConnection(w http.ResponseWriter, r *http.Request) {
-	// 协商：优先使用 Sec-WebSocket-Protocol: myflowhub.bin.v1，否则通过 ?bin=1 开启
+	// 协商：按客户端顺序选用 Sec-WebSocket-Protocol 中首个支持的编码（myflowhub.bin.v2 / myflowhub.bin.v1 / myflowhub.json.v1），
+	// 否则通过 ?bin=1 / ?enc=json 指定
 	var respHdr http.Header
 	if r.Header.Get("Sec-WebSocket-Protocol") != "" {
 		respHdr = http.Header{}
 		for _, p := range websocket.Subprotocols(r) {
-			if p == "myflowhub.bin.v1" {
+			if p == SubprotocolBinaryV2 || p == SubprotocolBinary || p == SubprotocolJSON {
 				respHdr.Set("Sec-WebSocket-Protocol", p)
 				break
 			}
@@ -132,13 +273,33 @@ func (s *Server) HandleSubordinateConnection(w http.ResponseWriter, r *http.Requ
 		log.Error().Err(err).Msg("Failed to upgrade connection")
 		return
 	}
-	binary := conn.Subprotocol() == "myflowhub.bin.v1" || r.URL.Query().Get("bin") == "1"
+	deflate := s.Upgrader.EnableCompression && wsDeflateOffered(r.Header)
+	if deflate {
+		_ = conn.SetCompressionLevel(compressionLevel())
+	}
+	binaryV2 := conn.Subprotocol() == SubprotocolBinaryV2
+	binary := binaryV2 || conn.Subprotocol() == SubprotocolBinary || r.URL.Query().Get("bin") == "1"
+	jsonEnc := conn.Subprotocol() == SubprotocolJSON || (conn.Subprotocol() == "" && r.URL.Query().Get("enc") == "json")
+	protocol := "ws"
+	if sp := conn.Subprotocol(); sp != "" {
+		protocol = "ws/" + sp
+	} else if jsonEnc {
+		protocol = "ws?enc=json"
+	} else if binary {
+		protocol = "ws?bin=1"
+	}
+	if r.TLS != nil {
+		protocol = "wss" + strings.TrimPrefix(protocol, "ws")
+	}
 	// 发送队列容量从配置读取，默认 256
 	qsize := config.AppConfig.WS.SendQueueSize
 	if qsize <= 0 {
 		qsize = 256
 	}
-	client := &Client{Hub: s, Conn: conn, Send: make(chan []byte, qsize), DeviceID: 0, RemoteAddr: r.RemoteAddr, UserAgent: r.UserAgent(), Binary: binary, pongCh: make(chan string, 8)}
+	client := &Client{Hub: s, Conn: conn, Send: make(chan []byte, qsize), DeviceID: 0, RemoteAddr: r.RemoteAddr, UserAgent: r.UserAgent(), Binary: binary && !jsonEnc, JSON: jsonEnc, Protocol: protocol, CertHardwareID: peerHardwareID(r.TLS), CertSerial: peerCertSerial(r.TLS), pongCh: make(chan string, 8)}
+	client.wsDeflate, client.meter = deflate, connMeter(conn.NetConn())
+	client.lastActive.Store(time.Now().UnixNano())
+	client.wireV2.Store(binaryV2)
 	s.Register <- client
 
 	go client.writePump()
@@ -0,0 +1,246 @@
+package hub
+
+import (
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// 连接握手（HELLO）：连接建立后、认证之前客户端可发送一次 HELLO_REQ，声明支持的协议版本、可选特性、
+// 能处理的下行 TypeID 与收发限制；Hub 回复交集、自身标识与限制，协商结果记录在 Client 上，认证后写入 Device。
+// 未发送 HELLO 的旧客户端按子协议协商的版本且支持全部特性处理。
+
+// hubFeatures Hub 支持的可选特性（ack 暂不支持，协商结果中不会出现）；compression 与 resume 按配置与连接另行判断，见 helloFeatures
+var hubFeatures = []string{bin.FeatureRelay, bin.FeaturePubSub, bin.FeatureFile, bin.FeatureE2E, bin.FeatureDeadline}
+
+// helloFeatures 该连接可协商的特性：帧级压缩仅用于未启用 permessage-deflate 的二进制链路；会话恢复按 Resume 配置
+func (c *Client) helloFeatures() []string {
+	features := append([]string(nil), hubFeatures...)
+	if compressionEnabled() && c.binaryLink() && !c.wsDeflate {
+		features = append(features, bin.FeatureCompression)
+	}
+	if resumeEnabled() {
+		features = append(features, bin.FeatureResume)
+	}
+	return features
+}
+
+// Capabilities 连接经 HELLO 协商的能力（协商后只读）
+type Capabilities struct {
+	Version    uint32
+	Features   []string
+	TypeIDs    map[uint16]struct{} // 客户端能处理的下行 TypeID，为 nil 表示不限制
+	Limits     bin.HelloLimits
+	ClientName string
+}
+
+// hubVersions 该连接可用的协议版本：v2 仅适用于 WS 二进制与原始 TCP 链路
+func (c *Client) hubVersions() []uint32 {
+	if c.binaryLink() {
+		return []uint32{uint32(bin.Version1), uint32(bin.Version2)}
+	}
+	return []uint32{uint32(bin.Version1)}
+}
+
+// Capabilities 返回 HELLO 协商结果；未发送 HELLO 时为 nil（在 Run 协程或该连接的工作协程内调用）
+func (c *Client) Capabilities() *Capabilities {
+	return c.caps
+}
+
+// HasFeature 连接是否可使用可选特性；未发送 HELLO 的旧客户端视为全部支持（在 Run 协程或该连接的工作协程内调用）
+func (c *Client) HasFeature(f string) bool {
+	if c.caps == nil {
+		return true
+	}
+	for _, v := range c.caps.Features {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsType 客户端是否声明可处理该下行 TypeID（在 Run 协程内调用）
+func (c *Client) AcceptsType(typeID uint16) bool {
+	if c.caps == nil || c.caps.TypeIDs == nil {
+		return true
+	}
+	_, ok := c.caps.TypeIDs[typeID]
+	return ok
+}
+
+// ResolveRelay 判断连接是否以中继身份认证：协商过 HELLO 时以 relay 特性为准，否则按 ParentAuthReq.caps 判定，
+// 并将 caps 记为该连接的特性（写入 Device.Capabilities；在该连接的工作协程内调用）
+func (c *Client) ResolveRelay(caps string) bool {
+	if c.caps != nil {
+		return c.HasFeature(bin.FeatureRelay)
+	}
+	features := bin.ParseFeatures(caps)
+	c.Hub.runSync(func() { c.legacyFeatures = features })
+	for _, f := range features {
+		if f == bin.FeatureRelay {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiated 返回写入 Device 的协议版本与特性
+func (c *Client) negotiated() (uint32, []string) {
+	if c.caps != nil {
+		return c.caps.Version, c.caps.Features
+	}
+	if c.wireV2.Load() {
+		return uint32(bin.Version2), c.legacyFeatures
+	}
+	return uint32(bin.Version1), c.legacyFeatures
+}
+
+// requiredFeature 返回使用该 TypeID 需协商的特性（收发双向），无要求时为空
+func requiredFeature(typeID uint16) string {
+	switch {
+	case bin.IsFileTransferType(typeID):
+		return bin.FeatureFile
+	case typeID >= bin.TypeE2EKeyPublishReq && typeID <= bin.TypeE2EKeyChangedNotify:
+		return bin.FeatureE2E
+	}
+	return ""
+}
+
+// accepts 是否向该连接下发此类帧：客户端须声明可处理该 TypeID，且已协商其所需特性；服务端主动推送的通知另需 pubsub
+func (c *Client) accepts(typeID uint16) bool {
+	if !c.AcceptsType(typeID) {
+		return false
+	}
+	if f := requiredFeature(typeID); f != "" && !c.HasFeature(f) {
+		return false
+	}
+	switch typeID {
+	case bin.TypeVarChangedNotify, bin.TypeTwinDelta, bin.TypeE2EKeyChangedNotify:
+		return c.HasFeature(bin.FeaturePubSub)
+	}
+	return true
+}
+
+// hubLimits Hub 在 HELLO_RESP 中声明的限制
+func hubLimits(version uint32) bin.HelloLimits {
+	if version < uint32(bin.Version2) {
+		return bin.HelloLimits{MaxFrameBytes: maxMessageSize, MaxMessageBytes: maxMessageSize - bin.HeaderSizeV1}
+	}
+	maxBytes, maxPending, _ := reassemblyLimits()
+	return bin.HelloLimits{MaxFrameBytes: maxMessageSize, MaxMessageBytes: uint32(maxBytes), MaxPending: uint32(maxPending)}
+}
+
+// handleHello 处理 HELLO_REQ：每个连接仅在认证前接受一次。选定版本后立即切换该连接的下行版本，
+// 应答即按选定版本发送（在 Run 协程内调用）
+func (s *Server) handleHello(c *Client, h bin.HeaderV1, payload []byte) {
+	if c.caps != nil || c.DeviceID != 0 {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, c.DeviceID, bin.EncodeErrResp(h.MsgID, 409, []byte("hello must be sent once before authentication")))
+		return
+	}
+	req, err := bin.DecodeHelloReq(payload)
+	if err != nil {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 400, []byte("bad request")))
+		return
+	}
+	version := bin.NegotiateVersion(c.hubVersions(), req.Versions)
+	if version == 0 {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 400, []byte("no common protocol version")))
+		return
+	}
+	caps := &Capabilities{Version: version, Features: bin.IntersectFeatures(c.helloFeatures(), req.Features), Limits: req.Limits, ClientName: req.ClientName}
+	var typeIDs []uint16
+	if len(req.TypeIDs) > 0 {
+		caps.TypeIDs = make(map[uint16]struct{}, len(req.TypeIDs))
+		for _, id := range req.TypeIDs {
+			if _, ok := bin.LookupType(id); ok {
+				if _, dup := caps.TypeIDs[id]; !dup {
+					caps.TypeIDs[id] = struct{}{}
+					typeIDs = append(typeIDs, id)
+				}
+			}
+		}
+	}
+	c.caps = caps
+	c.peerMaxFrame.Store(req.Limits.MaxFrameBytes)
+	c.peerMaxMessage.Store(req.Limits.MaxMessageBytes)
+	c.wireV2.Store(version == uint32(bin.Version2))
+	var dicts []uint32
+	if c.HasFeature(bin.FeatureCompression) {
+		var comp *bin.Compressor
+		comp, dicts = newCompressor(req.CompressionDicts)
+		c.compressor.Store(comp)
+	}
+
+	resp := bin.HelloResult{
+		Version:          version,
+		Features:         caps.Features,
+		TypeIDs:          typeIDs,
+		Limits:           hubLimits(version),
+		HardwareID:       s.HardwareID,
+		DeviceUID:        s.DeviceID(),
+		HeartbeatSec:     uint32(HeartbeatSec()),
+		CompressionDicts: dicts,
+	}
+	s.SendBin(c, bin.TypeHelloResp, h.MsgID, 0, bin.EncodeHelloResp(h.MsgID, resp))
+	log.Info().Str("remoteAddr", c.RemoteAddr).Str("client", req.ClientName).Uint32("version", version).Strs("features", caps.Features).Msg("连接握手完成")
+}
+
+// writeParentHello 中继在认证前向上级声明支持的版本与特性（含 relay），不等待应答：
+// 应答由 readParentReply 在读取认证响应时一并处理，旧版上级忽略或拒绝 HELLO 均不影响认证
+func (s *Server) writeParentHello(conn *websocket.Conn, msgID uint64) error {
+	hello := bin.Hello{
+		Versions:   []uint32{uint32(bin.Version1), uint32(bin.Version2)},
+		Features:   hubFeatures,
+		Limits:     hubLimits(uint32(bin.Version2)),
+		ClientName: "myflowhub-relay",
+	}
+	// 父链路未启用 permessage-deflate 时请求帧级压缩
+	if compressionEnabled() && !s.parentDeflate {
+		hello.Features = append(append([]string(nil), hubFeatures...), bin.FeatureCompression)
+		hello.CompressionDicts = localDictIDs()
+	}
+	frame, err := bin.EncodeFrame(bin.HeaderV1{TypeID: bin.TypeHelloReq, MsgID: msgID, Source: s.DeviceID(), Timestamp: time.Now().UnixMilli()}, bin.EncodeHelloReq(hello))
+	if err != nil {
+		return err
+	}
+	return s.writeParentHandshake(conn, frame)
+}
+
+// readParentReply 读取上级的握手应答；HELLO 应答在此应用（切换父链路版本与分片上限），
+// 旧版上级对 HELLO 的错误响应被跳过
+func (s *Server) readParentReply(conn *websocket.Conn, helloID uint64) ([]byte, error) {
+	for {
+		msg, err := s.readParentFrame(conn)
+		if err != nil {
+			return nil, err
+		}
+		h, pl, err := bin.DecodeFrame(msg)
+		if err != nil || h.MsgID != helloID {
+			return msg, nil
+		}
+		switch h.TypeID {
+		case bin.TypeHelloResp:
+			_, r, err := bin.DecodeHelloResp(pl)
+			if err != nil {
+				log.Warn().Err(err).Msg("解析上级 HELLO 应答失败，按子协议协商结果通信")
+				continue
+			}
+			s.parentV2 = r.Version == uint32(bin.Version2)
+			s.parentMaxFrame = int(r.Limits.MaxFrameBytes)
+			for _, f := range r.Features {
+				if f == bin.FeatureCompression {
+					s.parentCompressor, _ = newCompressor(r.CompressionDicts)
+				}
+			}
+			log.Info().Str("parent", r.HardwareID).Uint32("version", r.Version).Strs("features", r.Features).Msg("父链路握手完成")
+		case bin.TypeErrResp:
+			log.Info().Msg("上级不支持 HELLO，按子协议协商结果通信")
+		default:
+			return msg, nil
+		}
+	}
+}
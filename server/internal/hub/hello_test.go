@@ -0,0 +1,147 @@
+package hub
+
+import (
+	"reflect"
+	"testing"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+// hello 发送 HELLO_REQ，返回应答的原始帧版本与协商结果
+func (p *tcpPeer) hello(msgID uint64, hello bin.Hello) (uint8, bin.HelloResult) {
+	p.t.Helper()
+	p.send(bin.TypeHelloReq, msgID, bin.EncodeHelloReq(hello))
+	frame, err := p.recvRaw()
+	if err != nil {
+		p.t.Fatalf("read: %v", err)
+	}
+	v1, err := p.reasm.Add(frame)
+	if err != nil || v1 == nil {
+		p.t.Fatalf("reassemble: %v", err)
+	}
+	h, pl, err := bin.DecodeFrame(v1)
+	if err != nil || h.TypeID != bin.TypeHelloResp || h.MsgID != msgID {
+		p.t.Fatalf("got type %d msg %d %v, want HELLO_RESP", h.TypeID, h.MsgID, err)
+	}
+	_, r, err := bin.DecodeHelloResp(pl)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return bin.FrameVersion(frame), r
+}
+
+func TestHelloVersionNegotiation(t *testing.T) {
+	cases := []struct {
+		name     string
+		offer    []uint32
+		want     uint32
+		wireVers uint8 // 应答帧的版本：选定版本后立即切换
+	}{
+		{"prefers v2", []uint32{1, 2}, 2, bin.Version2},
+		{"v1 only", []uint32{1}, 1, bin.Version1},
+		{"unknown versions ignored", []uint32{9, 1}, 1, bin.Version1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTCPPeer(t, newTestServer(t))
+			wire, r := p.hello(1, bin.Hello{Versions: tc.offer})
+			if r.Version != tc.want || wire != tc.wireVers {
+				t.Fatalf("version %d on v%d frame, want %d on v%d", r.Version, wire, tc.want, tc.wireVers)
+			}
+		})
+	}
+}
+
+func TestHelloNoCommonVersion(t *testing.T) {
+	p := newTCPPeer(t, newTestServer(t))
+	p.send(bin.TypeHelloReq, 1, bin.EncodeHelloReq(bin.Hello{Versions: []uint32{3}}))
+	p.expectErr(1, 400)
+	// 协商失败不占用本连接唯一一次 HELLO
+	if _, r := p.hello(2, bin.Hello{Versions: []uint32{1}}); r.Version != 1 {
+		t.Fatalf("retry version %d", r.Version)
+	}
+}
+
+func TestHelloOnlyOnce(t *testing.T) {
+	p := newTCPPeer(t, newTestServer(t))
+	p.hello(1, bin.Hello{Versions: []uint32{1, 2}})
+	p.send(bin.TypeHelloReq, 2, bin.EncodeHelloReq(bin.Hello{Versions: []uint32{1}}))
+	p.expectErr(2, 409)
+}
+
+func TestHelloIntersection(t *testing.T) {
+	p := newTCPPeer(t, newTestServer(t))
+	_, r := p.hello(1, bin.Hello{
+		Versions: []uint32{1},
+		// ack 为 Hub 不支持的特性；大小写、空白与重复均被规整
+		Features: []string{"relay", "PubSub", " file ", bin.FeatureAck, "bogus", "relay"},
+		// 未注册与重复的 TypeID 被丢弃
+		TypeIDs: []uint16{bin.TypeMsgSend, 0xFFFE, bin.TypeMsgSend, bin.TypeVarChangedNotify},
+	})
+	if want := []string{bin.FeatureFile, bin.FeaturePubSub, bin.FeatureRelay}; !reflect.DeepEqual(r.Features, want) {
+		t.Fatalf("features %v, want %v", r.Features, want)
+	}
+	if want := []uint16{bin.TypeMsgSend, bin.TypeVarChangedNotify}; !reflect.DeepEqual(r.TypeIDs, want) {
+		t.Fatalf("type ids %v, want %v", r.TypeIDs, want)
+	}
+	if r.HardwareID != "hub-test" || r.Limits.MaxFrameBytes != maxMessageSize {
+		t.Fatalf("hub identity %+v", r)
+	}
+}
+
+func TestLegacyClientFallback(t *testing.T) {
+	s := newTestServer(t)
+	// 未发送 HELLO：全部特性可用、下行不限制，ParentAuth 的 caps 决定中继身份
+	c := &Client{Hub: s}
+	if !c.HasFeature(bin.FeatureFile) || !c.accepts(bin.TypeVarChangedNotify) || !c.accepts(bin.TypeFileInitReq) {
+		t.Fatal("legacy client should accept every feature")
+	}
+	if c.ResolveRelay("pubsub") {
+		t.Fatal("caps without relay resolved as relay")
+	}
+	if !c.ResolveRelay(" Relay ,pubsub") {
+		t.Fatal("caps with relay not resolved as relay")
+	}
+	if v, f := c.negotiated(); v != 1 || !reflect.DeepEqual(f, []string{bin.FeatureRelay, bin.FeaturePubSub}) {
+		t.Fatalf("negotiated %d %v", v, f)
+	}
+	c.wireV2.Store(true)
+	if v, _ := c.negotiated(); v != 2 {
+		t.Fatalf("negotiated version over v2 subprotocol %d", v)
+	}
+}
+
+func TestNegotiatedClientGating(t *testing.T) {
+	s := newTestServer(t)
+	c := &Client{Hub: s, caps: &Capabilities{
+		Version:  2,
+		Features: []string{bin.FeatureFile},
+		TypeIDs:  map[uint16]struct{}{bin.TypeMsgSend: {}, bin.TypeVarChangedNotify: {}, bin.TypeFileInitReq: {}},
+	}}
+	// 协商过 HELLO：忽略 caps，以 relay 特性为准
+	if c.ResolveRelay("relay") {
+		t.Fatal("relay resolved from caps despite HELLO")
+	}
+	cases := []struct {
+		typeID uint16
+		want   bool
+	}{
+		{bin.TypeMsgSend, true},
+		{bin.TypeFileInitReq, true},
+		{bin.TypeVarChangedNotify, false}, // 通知另需 pubsub
+		{bin.TypeTwinDelta, false},        // 未声明该 TypeID
+	}
+	for _, tc := range cases {
+		if got := c.accepts(tc.typeID); got != tc.want {
+			t.Fatalf("accepts(%d) = %v, want %v", tc.typeID, got, tc.want)
+		}
+	}
+	c.caps.Features = append(c.caps.Features, bin.FeaturePubSub)
+	if !c.accepts(bin.TypeVarChangedNotify) {
+		t.Fatal("pubsub client rejected notification")
+	}
+	c.caps.TypeIDs = nil
+	if c.accepts(bin.TypeE2EKeyChangedNotify) {
+		t.Fatal("E2E notification delivered without e2e feature")
+	}
+}
@@ -1,11 +1,15 @@
 package hub
 
 import (
+	"context"
 	"fmt"
-	"myflowhub/pkg/database"
+	"myflowhub/pkg/config"
 	bin "myflowhub/pkg/protocol/binproto"
+	"net"
 	"net/http"
 	"regexp"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -25,20 +29,69 @@ type Server struct {
 	ParentAddr string
 	ListenAddr string
 	HardwareID string
-	DeviceID   uint64
-	SecretKey  string
+	// deviceID 本节点的设备 UID：启动时由 bootstrap 设置，父链路认证成功后改为上级分配的 UID（可跨协程读取，见 DeviceID）
+	deviceID atomic.Uint64
 
 	Clients    map[uint64]*Client
 	ParentSend chan []byte
+	FromParent chan []byte   // 上级下发的帧，由 Run 投递给下级
+	Outbound   chan []byte   // 业务协程主动下发的帧，由 Run 按 Target 路由
+	exec       chan func()   // 需在 Run 协程内执行的查询与修改（访问 Clients 等，见 runSync）
+	workers    chan struct{} // 同时执行的请求数上限（见 worker.go）
 	Broadcast  chan *HubMessage
 	Register   chan *Client
 	Unregister chan *Client
-	binRoutes  map[uint16]func(s *Server, c *Client, h bin.HeaderV1, payload []byte)
+	binRoutes  map[uint16]BinHandler
 	Syslog     interface {
 		Info(source, message string, details any) error
 		Error(source, message string, details any) error
 	} // updated interface to include Error method
 
+	// Presence 在线状态视图（可空）
+	Presence PresenceTracker
+	// MQTTAuth MQTT 网桥的客户端认证（为空时不启用网桥）
+	MQTTAuth MQTTAuthenticator
+	// CoAPAuth CoAP 网关的设备认证（为空时不启用网关）
+	CoAPAuth CoAPAuthenticator
+	// GRPC gRPC 服务（可空）；非空时监听端口同时接受明文 HTTP/2，按 Content-Type 分流
+	GRPC http.Handler
+	// Sessions 连接会话历史（可空）；sessionSeq 与 relayedSessions 仅由 Run 协程访问
+	Sessions        SessionRecorder
+	sessionSeq      uint64
+	relayedSessions map[uint64]relayedSession // 经中继在线的设备 UID → 会话
+
+	// Descendants 返回设备树中某设备 UID 的全部后代 UID（可空，为空时不接受下级的在线状态上报；不在 Run 协程内调用）
+	Descendants func(ctx context.Context, deviceUID uint64) ([]uint64, error)
+
+	// OnConnect 在客户端认证通过并登记后调用；OnDisconnect 在已认证客户端注销后调用（均在 Run 协程内执行，不可阻塞）
+	OnConnect    func(deviceUID uint64)
+	OnDisconnect func(deviceUID uint64)
+	// OnFrameMACFailure 在上行帧认证失败时调用（在 Run 协程内执行，不可阻塞）
+	OnFrameMACFailure func(deviceUID uint64, remoteAddr, reason string)
+	// OnCapabilities 在客户端认证登记后以协商的协议版本与特性调用（在 Run 协程内执行，不可阻塞）
+	OnCapabilities func(deviceUID uint64, version uint32, features []string)
+
+	watch watchers
+	// revoked 内置 CA 吊销的证书序列号集合（握手时校验）
+	revoked atomic.Pointer[map[string]struct{}]
+	// parentWait 发往上级的请求（requestParent）按 MsgID 等待应答
+	parentWait sync.Map
+	// parentRxMAC/parentTxMAC 父链路帧认证状态，由重连循环在启动收发协程前设置，之后分别仅由读、写协程使用
+	parentRxMAC *bin.FrameMAC
+	parentTxMAC *bin.FrameMAC
+	// parentV2 父链路协商为 myflowhub.bin.v2；parentReasm 父链路的分片重组状态（握手与读协程使用），均由重连循环在握手前重置
+	parentV2    bool
+	parentReasm *bin.Reassembler
+	// parentMaxFrame 上级在 HELLO 应答中声明的单帧上限（0 表示未声明），由握手设置
+	parentMaxFrame int
+	// parentDeflate 父链路协商了 permessage-deflate；parentCompressor 上级在 HELLO 中同意的帧级压缩器（均由握手设置，之后仅写协程读取）
+	parentDeflate    bool
+	parentCompressor *bin.Compressor
+	// 可恢复会话：按令牌与设备 UID 索引（仅 Run 协程访问）
+	resumeTokens  map[string]*resumeSession
+	resumeDevices map[uint64]*resumeSession
+	// parentSess 中继模式下与上级的已校验会话（授予的权限、心跳周期与到期时间）
+	parentSess atomic.Pointer[parentSession]
 }
 
 // isValidVarName 检查变量名是否有效
@@ -51,40 +104,78 @@ func NewServer(parentAddr, listenAddr, hardwareID string) *Server {
 		ListenAddr: listenAddr,
 		HardwareID: hardwareID,
 		Upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool { return true },
+			CheckOrigin:       func(r *http.Request) bool { return true },
+			EnableCompression: compressionEnabled(),
 		},
 		Clients:    make(map[uint64]*Client),
 		ParentSend: make(chan []byte, 256),
+		FromParent: make(chan []byte, 256),
+		Outbound:   make(chan []byte, 1024),
+		exec:       make(chan func()),
+		workers:    make(chan struct{}, maxWorkers()),
 		Broadcast:  make(chan *HubMessage, 256),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
-		binRoutes:  make(map[uint16]func(*Server, *Client, bin.HeaderV1, []byte)),
+		binRoutes:  make(map[uint16]BinHandler),
+
+		resumeTokens:  make(map[string]*resumeSession),
+		resumeDevices: make(map[uint64]*resumeSession),
+
+		relayedSessions: make(map[uint64]relayedSession),
 	}
 	return s
 }
 
 // Run 启动 hub 的主循环
 func (s *Server) Run() {
+	heartbeat := time.NewTicker(time.Duration(HeartbeatSec()) * time.Second)
+	defer heartbeat.Stop()
 	for {
 		select {
 		case c := <-s.Register:
 			log.Info().Msg("一个新客户端已连接，等待认证...")
+			s.sessionOpened(c)
 			if s.Syslog != nil {
 				_ = s.Syslog.Info("hub", "client connected", map[string]any{"ip": c.RemoteAddr, "ua": c.UserAgent})
 			}
 		case client := <-s.Unregister:
+			s.stopWorker(client)
+			s.sessionClosed(client)
 			if client.DeviceID != 0 {
-				if _, ok := s.Clients[client.DeviceID]; ok {
+				if cur, ok := s.Clients[client.DeviceID]; ok && cur != client {
+					// 同一设备已重连，旧连接仅释放发送队列，不影响新连接与在线状态
+					client.closeSend()
+				} else if ok {
 					delete(s.Clients, client.DeviceID)
-					close(client.Send)
+					s.detachResume(client)
+					client.closeSend()
 					log.Info().Uint64("clientID", client.DeviceID).Int("total_clients", len(s.Clients)).Msg("客户端已从 Hub 注销")
 					if s.Syslog != nil {
 						_ = s.Syslog.Info("hub", "client disconnected", map[string]any{"deviceUID": client.DeviceID, "ip": client.RemoteAddr, "ua": client.UserAgent})
 					}
+					s.applyPresence(0, []bin.PresenceItem{{DeviceUID: client.DeviceID, Online: false, LastSeenSec: time.Now().Unix()}}, false)
+					if s.OnDisconnect != nil {
+						s.OnDisconnect(client.DeviceID)
+					}
 				}
 			}
 		case hubMessage := <-s.Broadcast:
 			s.routeMessage(hubMessage)
+		case frame := <-s.FromParent:
+			s.deliverFromParent(frame)
+		case frame := <-s.Outbound:
+			if h, _, err := bin.DecodeFrame(frame); err == nil {
+				if c, ok := s.Clients[h.Target]; ok && !c.accepts(h.TypeID) {
+					log.Debug().Uint64("target", h.Target).Uint16("typeID", h.TypeID).Msg("目标未协商该类消息，已忽略")
+					continue
+				}
+				s.forward(h.Target, frame)
+			}
+		case fn := <-s.exec:
+			fn()
+		case now := <-heartbeat.C:
+			s.checkHeartbeats(now)
+			s.expireResume(now)
 		}
 	}
 }
@@ -93,7 +184,20 @@ func (s *Server) Run() {
 func (s *Server) routeMessage(hubMessage *HubMessage) {
 	sourceClient := hubMessage.Client
 	if hubMessage.IsBinary {
-		// 二进制路径
+		// 二进制路径：先校验并去除链路帧认证标签
+		msg, ok := s.openFrame(sourceClient, hubMessage.Message)
+		if !ok {
+			return
+		}
+		// v2 帧重组并转换为 v1、解压帧级压缩，此后按未压缩的 v1 帧路由
+		if msg, ok = s.reassemble(sourceClient, msg); !ok {
+			return
+		}
+		if msg, ok = s.decompress(sourceClient, msg); !ok {
+			return
+		}
+		sourceClient.rawBytesIn.Add(uint64(len(msg)))
+		hubMessage.Message = msg
 		h, payload, err := bin.DecodeFrame(hubMessage.Message)
 		if err != nil {
 			// 打印十六进制预览与长度，便于定位协议问题
@@ -110,140 +214,259 @@ func (s *Server) routeMessage(hubMessage *HubMessage) {
 			return
 		}
 		log.Debug().Uint16("typeID", h.TypeID).Uint64("msgID", h.MsgID).Uint64("source", h.Source).Uint64("target", h.Target).Msg("收到二进制帧")
-		// 审批门控：认证类请求除外，未审批的连接拒绝后续操作
-		switch h.TypeID {
-		case bin.TypeManagerAuthReq, bin.TypeParentAuthReq, bin.TypeUserLoginReq, bin.TypeUserMeReq, bin.TypeUserLogoutReq:
-			// 认证与自助接口放行
-		default:
-			if sourceClient.DeviceID != 0 {
-				var approved bool
-				// 查询一次数据库；也可考虑加入缓存
-				var cnt int64
-				if err := database.DB.Model(&database.Device{}).
-					Where("device_uid = ? AND approved = ?", sourceClient.DeviceID, true).
-					Count(&cnt).Error; err == nil {
-					approved = cnt > 0
-				}
-				if !approved {
-					// 直接返回 ErrResp（禁止使用任何网络功能，也不能向其他节点发送消息）
-					pl := bin.EncodeErrResp(h.MsgID, 403, []byte("device not approved"))
-					frame, _ := bin.EncodeFrame(bin.HeaderV1{TypeID: bin.TypeErrResp, MsgID: h.MsgID, Source: s.DeviceID, Target: sourceClient.DeviceID, Timestamp: time.Now().UnixMilli()}, pl)
-					select {
-					case sourceClient.Send <- frame:
-					default:
-					}
-					return
-				}
-			} else {
-				// 未认证的连接亦禁止访问非认证接口
-				pl := bin.EncodeErrResp(h.MsgID, 401, []byte("unauthorized"))
-				frame, _ := bin.EncodeFrame(bin.HeaderV1{TypeID: bin.TypeErrResp, MsgID: h.MsgID, Source: s.DeviceID, Target: 0, Timestamp: time.Now().UnixMilli()}, pl)
-				select {
-				case sourceClient.Send <- frame:
-				default:
-				}
-				return
-			}
+		// 请求附带的截止时刻逐跳有效：取出后以不含截止时刻的帧继续处理与转发
+		deadline, payload, err := bin.SplitDeadline(&h, payload)
+		if err != nil {
+			s.SendBin(sourceClient, bin.TypeErrResp, h.MsgID, sourceClient.DeviceID, bin.EncodeErrResp(h.MsgID, 400, []byte(err.Error())))
+			return
+		}
+		if !deadline.IsZero() {
+			hubMessage.Message, _ = bin.EncodeFrame(h, payload)
+		}
+		s.submit(sourceClient, request{h: h, payload: payload, frame: hubMessage.Message, deadline: deadline})
+		return
+	}
+
+	// JSON 路径已移除
+}
+
+// routeFrame 处理内建消息与转发：心跳、在线状态、HELLO 与 MSG_SEND 等（由工作协程经 runSync 交给 Run 协程执行）
+func (s *Server) routeFrame(sourceClient *Client, h bin.HeaderV1, payload, frame []byte) {
+	if sourceClient.gone.Load() {
+		return
+	}
+	switch h.TypeID {
+	case bin.TypeHeartbeat:
+		s.handleHeartbeat(sourceClient, h)
+	case bin.TypeHelloReq:
+		s.handleHello(sourceClient, h, payload)
+	case bin.TypeManagerAuthReq:
+		log.Warn().Msg("未注册 ManagerAuth 二进制处理器")
+	case bin.TypeParentAuthReq:
+		log.Warn().Msg("未注册 ParentAuth 二进制处理器（应由 binroutes 注册）")
+	case bin.TypeOKResp, bin.TypeErrResp:
+		// 某些客户端可能会向 Hub 回传通用响应帧；Hub 端无需处理，静默丢弃以减少噪音
+		// 附加诊断：打印头部十六进制，便于对齐问题排查
+		if len(frame) >= bin.HeaderSizeV1 {
+			hb := frame[:bin.HeaderSizeV1]
+			log.Debug().Uint16("typeID", h.TypeID).Uint64("msgID", h.MsgID).Str("hex", fmt.Sprintf("% x", hb)).Msg("收到通用响应帧，已忽略")
+		} else {
+			log.Debug().Uint16("typeID", h.TypeID).Uint64("msgID", h.MsgID).Int("len", len(frame)).Msg("收到通用响应帧，已忽略")
 		}
-		if handler, ok := s.binRoutes[h.TypeID]; ok {
-			handler(s, sourceClient, h, payload)
+	case bin.TypeMsgSend:
+		// 端到端加密负载按设备对派生密钥，只能单播给其他设备；Hub 不解密
+		if h.Flags&bin.FlagE2E != 0 && !sourceClient.HasFeature(bin.FeatureE2E) {
+			s.SendBin(sourceClient, bin.TypeErrResp, h.MsgID, sourceClient.DeviceID, bin.EncodeErrResp(h.MsgID, 412, []byte("feature not negotiated: "+bin.FeatureE2E)))
 			return
 		}
-		switch h.TypeID {
-		case bin.TypeManagerAuthReq:
-			log.Warn().Msg("未注册 ManagerAuth 二进制处理器")
-		case bin.TypeParentAuthReq:
-			log.Warn().Msg("未注册 ParentAuth 二进制处理器（应由 binroutes 注册）")
-		case bin.TypeOKResp, bin.TypeErrResp:
-			// 某些客户端可能会向 Hub 回传通用响应帧；Hub 端无需处理，静默丢弃以减少噪音
-			// 附加诊断：打印头部十六进制，便于对齐问题排查
-			if len(hubMessage.Message) >= bin.HeaderSizeV1 {
-				hb := hubMessage.Message[:bin.HeaderSizeV1]
-				log.Debug().Uint16("typeID", h.TypeID).Uint64("msgID", h.MsgID).Str("hex", fmt.Sprintf("% x", hb)).Msg("收到通用响应帧，已忽略")
+		if h.Flags&bin.FlagE2E != 0 && (h.Target == 0 || h.Target == s.DeviceID()) {
+			s.SendBin(sourceClient, bin.TypeErrResp, h.MsgID, sourceClient.DeviceID, bin.EncodeErrResp(h.MsgID, 400, []byte("e2e payload requires a device target")))
+			return
+		}
+		// 自回环：当目标就是当前客户端自身 UID，直接回送一份，便于本地回环测试
+		if sourceClient.DeviceID != 0 && h.Target == sourceClient.DeviceID {
+			if sourceClient.enqueue(frame) {
+				log.Debug().Uint64("target", h.Target).Msg("MSG_SEND 自回环回送给自身")
 			} else {
-				log.Debug().Uint16("typeID", h.TypeID).Uint64("msgID", h.MsgID).Int("len", len(hubMessage.Message)).Msg("收到通用响应帧，已忽略")
-			}
-		case bin.TypeMsgSend:
-			// 自回环：当目标就是当前客户端自身 UID，直接回送一份，便于本地回环测试
-			if sourceClient.DeviceID != 0 && h.Target == sourceClient.DeviceID {
-				select {
-				case sourceClient.Send <- hubMessage.Message:
-					log.Debug().Uint64("target", h.Target).Msg("MSG_SEND 自回环回送给自身")
-				default:
-					log.Warn().Uint64("target", h.Target).Msg("自回环回送失败：channel 已满")
-				}
-				return
+				log.Warn().Uint64("target", h.Target).Msg("自回环回送失败：channel 已满")
 			}
-			// 透传：当 Target ≠ Hub（自身设备）且 ≠ 广播
-			if h.Target != s.DeviceID && h.Target != 0 {
-				// 发往目标或上级，不解析 payload
-				if client, ok := s.Clients[h.Target]; ok {
-					// 直接转发原始帧
-					select {
-					case client.Send <- hubMessage.Message:
-						log.Debug().Uint64("target", h.Target).Msg("消息已放入目标客户端 channel")
-					default:
-						log.Warn().Uint64("target", h.Target).Msg("目标客户端 channel 已满，消息被丢弃")
-					}
-				} else if s.ParentAddr != "" {
-					s.ParentSend <- hubMessage.Message
-				} else {
-					log.Warn().Uint64("target", h.Target).Msg("目标未找到，且无上级可转发")
-				}
-			} else if h.Target == 0 {
-				// 广播
-				for id, c := range s.Clients {
-					if id != sourceClient.DeviceID {
-						select {
-						case c.Send <- hubMessage.Message:
-							log.Debug().Uint64("target", id).Msg("广播消息已放入目标客户端 channel")
-						default:
-							log.Warn().Uint64("target", id).Msg("目标客户端 channel 已满，广播消息被丢弃")
-						}
+			return
+		}
+		// 透传：当 Target ≠ Hub（自身设备）且 ≠ 广播
+		if h.Target != s.DeviceID() && h.Target != 0 {
+			// 发往目标或上级，不解析 payload
+			s.forward(h.Target, frame)
+		} else if h.Target == 0 {
+			// 广播
+			for id, c := range s.Clients {
+				if id != sourceClient.DeviceID {
+					if c.enqueue(frame) {
+						log.Debug().Uint64("target", id).Msg("广播消息已放入目标客户端 channel")
+					} else {
+						log.Warn().Uint64("target", id).Msg("目标客户端 channel 已满，广播消息被丢弃")
 					}
 				}
-				if s.ParentAddr != "" {
-					s.ParentSend <- hubMessage.Message
-				}
-			} else {
-				log.Info().Msg("MSG_SEND 发往 Hub，自行处理 payload（后续实现）")
 			}
-		default:
-			if len(hubMessage.Message) >= bin.HeaderSizeV1 {
-				hb := hubMessage.Message[:bin.HeaderSizeV1]
-				log.Warn().Uint16("typeID", h.TypeID).Str("hex", fmt.Sprintf("% x", hb)).Msg("未知 TypeID")
-			} else {
-				log.Warn().Uint16("typeID", h.TypeID).Int("len", len(hubMessage.Message)).Msg("未知 TypeID")
+			if s.ParentAddr != "" && s.parentAllowed(PermForward) {
+				s.ParentSend <- frame
 			}
+		} else {
+			log.Info().Msg("MSG_SEND 发往 Hub，自行处理 payload（后续实现）")
+		}
+	default:
+		if len(frame) >= bin.HeaderSizeV1 {
+			hb := frame[:bin.HeaderSizeV1]
+			log.Warn().Uint16("typeID", h.TypeID).Str("hex", fmt.Sprintf("% x", hb)).Msg("未知 TypeID")
+		} else {
+			log.Warn().Uint16("typeID", h.TypeID).Int("len", len(frame)).Msg("未知 TypeID")
 		}
-		return
 	}
+}
 
-	// JSON 路径已移除
+// DeviceID 本节点的设备 UID（可在任意协程调用）
+func (s *Server) DeviceID() uint64 {
+	return s.deviceID.Load()
+}
+
+// Attach 将连接以 deviceUID 登记为已认证并触发 OnConnect，等待 Run 协程完成（供认证处理器在工作协程内调用）；
+// 连接已注销或已以其他设备身份认证时不登记
+func (s *Server) Attach(c *Client, deviceUID uint64) {
+	epoch := resumeEpoch(c, deviceUID)
+	s.runSync(func() {
+		if c.gone.Load() {
+			return
+		}
+		if c.DeviceID != 0 && c.DeviceID != deviceUID {
+			// 客户端表以 DeviceID 为键，更换身份会留下指向同一连接的旧条目；认证处理器应事先拒绝
+			log.Warn().Uint64("deviceUID", c.DeviceID).Uint64("newUID", deviceUID).Msg("已认证连接不可更换设备身份，忽略登记")
+			return
+		}
+		c.DeviceID = deviceUID
+		c.credEpoch = epoch
+		s.attach(c)
+	})
+}
+
+// attach 将已认证的连接登记到客户端表并触发 OnConnect（在 Run 协程内调用）
+func (s *Server) attach(c *Client) {
+	if old, ok := s.Clients[c.DeviceID]; ok && old != c {
+		old.setCloseReason("replaced by new connection")
+	}
+	s.Clients[c.DeviceID] = c
+	s.startResume(c)
+	s.sessionAuthenticated(c)
+	if s.OnCapabilities != nil {
+		version, features := c.negotiated()
+		s.OnCapabilities(c.DeviceID, version, features)
+	}
+	c.lastActive.Store(time.Now().UnixNano())
+	s.applyPresence(0, []bin.PresenceItem{{DeviceUID: c.DeviceID, Online: true, LastSeenSec: time.Now().Unix()}}, false)
+	if s.OnConnect != nil {
+		s.OnConnect(c.DeviceID)
+	}
+}
+
+// forward 将原始帧转发给直连目标；目标已断线但会话可恢复时缓存，否则交由上级
+func (s *Server) forward(target uint64, frame []byte) {
+	if client, ok := s.Clients[target]; ok {
+		// 直接转发原始帧
+		if client.enqueue(frame) {
+			log.Debug().Uint64("target", target).Msg("消息已放入目标客户端 channel")
+		} else {
+			log.Warn().Uint64("target", target).Msg("目标客户端 channel 已满，消息被丢弃")
+		}
+	} else if relay, ok := s.relayFor(target); ok {
+		// 目标经下级中继在线：交给该中继向下投递
+		if relay.enqueue(frame) {
+			log.Debug().Uint64("target", target).Uint64("relay", relay.DeviceID).Msg("消息已交给目标所在的下级中继")
+		} else {
+			log.Warn().Uint64("target", target).Uint64("relay", relay.DeviceID).Msg("下级中继 channel 已满，消息被丢弃")
+		}
+	} else if s.bufferForResume(target, frame) {
+		log.Debug().Uint64("target", target).Msg("目标已断线，消息缓存待会话恢复")
+	} else if s.ParentAddr != "" {
+		if !s.parentAllowed(PermForward) {
+			log.Warn().Uint64("target", target).Msg("上级未授予 forward 权限，目标不在本地的帧被丢弃")
+			return
+		}
+		s.ParentSend <- frame
+	} else {
+		log.Warn().Uint64("target", target).Msg("目标未找到，且无上级可转发")
+	}
+}
+
+// relayFor 返回目标设备经由的直连下级中继（依据在线视图的 via；仅在 Run 协程内调用）
+func (s *Server) relayFor(target uint64) (*Client, bool) {
+	if s.Presence == nil {
+		return nil, false
+	}
+	it, ok := s.Presence.Get(target)
+	if !ok || !it.Online || it.Via == 0 {
+		return nil, false
+	}
+	relay, ok := s.Clients[it.Via]
+	if !ok || !relay.IsRelay() {
+		return nil, false
+	}
+	return relay, true
+}
+
+// deliverFromParent 将上级下发的帧投递给本地下级（目标为 0 时广播）
+func (s *Server) deliverFromParent(frame []byte) {
+	h, _, err := bin.DecodeFrame(frame)
+	if err != nil {
+		log.Warn().Err(err).Int("len", len(frame)).Msg("无法解析上级下发的帧")
+		return
+	}
+	if h.Target == 0 {
+		for id, c := range s.Clients {
+			if !c.enqueue(frame) {
+				log.Warn().Uint64("target", id).Msg("目标客户端 channel 已满，上级广播被丢弃")
+			}
+		}
+		return
+	}
+	if c, ok := s.Clients[h.Target]; ok {
+		if c.enqueue(frame) {
+			log.Debug().Uint64("target", h.Target).Uint16("typeID", h.TypeID).Msg("上级下发帧已投递")
+		} else {
+			log.Warn().Uint64("target", h.Target).Msg("目标客户端 channel 已满，上级下发帧被丢弃")
+		}
+		return
+	}
+	if relay, ok := s.relayFor(h.Target); ok {
+		if !relay.enqueue(frame) {
+			log.Warn().Uint64("target", h.Target).Uint64("relay", relay.DeviceID).Msg("下级中继 channel 已满，上级下发帧被丢弃")
+		}
+		return
+	}
+	if s.bufferForResume(h.Target, frame) {
+		log.Debug().Uint64("target", h.Target).Uint16("typeID", h.TypeID).Msg("目标已断线，上级下发帧缓存待会话恢复")
+		return
+	}
+	log.Debug().Uint64("target", h.Target).Uint16("typeID", h.TypeID).Msg("上级下发帧的目标不在本地，已忽略")
 }
 
 // RegisterBinRoute registers a binary TypeID handler
-func (s *Server) RegisterBinRoute(typeID uint16, handler func(s *Server, c *Client, h bin.HeaderV1, payload []byte)) {
+func (s *Server) RegisterBinRoute(typeID uint16, handler BinHandler) {
 	s.binRoutes[typeID] = handler
 }
 
 // SendBin sends a binary frame to client
 func (s *Server) SendBin(c *Client, typeID uint16, msgID uint64, target uint64, payload []byte) {
-	h := bin.HeaderV1{TypeID: typeID, MsgID: msgID, Source: s.DeviceID, Target: target, Timestamp: time.Now().UnixMilli()}
-	frame, err := bin.EncodeFrame(h, payload)
+	frame, err := s.encodeFrame(typeID, msgID, target, payload)
 	if err != nil {
 		log.Error().Err(err).Msg("EncodeFrame failed")
 		return
 	}
-	select {
-	case c.Send <- frame:
+	if c.enqueue(frame) {
 		log.Debug().Uint64("msgID", msgID).Uint16("typeID", typeID).Uint64("target", target).Msg("frame enqueued to client.Send")
-	default:
+	} else {
 		// 队列已满，丢弃并记录
 		log.Warn().Uint64("msgID", msgID).Uint16("typeID", typeID).Uint64("target", target).Int("queueCap", cap(c.Send)).Msg("client.Send full, drop frame")
 	}
 }
 
+// encodeFrame 以本 Hub 为 Source 编码一帧
+func (s *Server) encodeFrame(typeID uint16, msgID uint64, target uint64, payload []byte) ([]byte, error) {
+	return bin.EncodeFrame(bin.HeaderV1{TypeID: typeID, MsgID: msgID, Source: s.DeviceID(), Target: target, Timestamp: time.Now().UnixMilli()}, payload)
+}
+
+// SendTo 由业务协程（或 Run 内处理器）向任意设备发送一帧；队列满时丢弃并返回错误，不阻塞
+func (s *Server) SendTo(target uint64, typeID uint16, msgID uint64, payload []byte) error {
+	h := bin.HeaderV1{TypeID: typeID, MsgID: msgID, Source: s.DeviceID(), Target: target, Timestamp: time.Now().UnixMilli()}
+	frame, err := bin.EncodeFrame(h, payload)
+	if err != nil {
+		return err
+	}
+	select {
+	case s.Outbound <- frame:
+		return nil
+	default:
+		return fmt.Errorf("outbound queue full")
+	}
+}
+
 // JSON 路由与兼容占位符已彻底移除
 
 // Start 启动服务
@@ -255,9 +478,38 @@ func (s *Server) Start() {
 		go s.connectToParent()
 	}
 
+	s.startTCPFromConfig()
+	s.startMQTTFromConfig()
+	s.startCoAPFromConfig()
+
 	http.HandleFunc("/ws", s.HandleSubordinateConnection)
-	log.Info().Str("address", s.ListenAddr).Msg("服务端启动，监听下级连接")
-	if err := http.ListenAndServe(s.ListenAddr, nil); err != nil {
+	log.Info().Str("address", s.ListenAddr).Bool("tls", config.AppConfig.Server.TLS.CertFile != "").Msg("服务端启动，监听下级连接")
+	tlsConf, err := ListenerTLSConfig(config.AppConfig.Server.TLS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("加载监听 TLS 证书失败")
+	}
+	if tlsConf != nil && tlsConf.ClientCAs != nil {
+		tlsConf.VerifyPeerCertificate = s.verifyNotRevoked
+	}
+	srv := &http.Server{Addr: s.ListenAddr, Handler: s.httpHandler(), TLSConfig: tlsConf}
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	if s.GRPC != nil && tlsConf == nil {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	srv.Protocols = &protocols
+	// 接入连接经计量监听器，统计套接字实际收发字节（permessage-deflate 压缩率）
+	ln, err := net.Listen("tcp", s.ListenAddr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("无法启动监听服务")
+	}
+	if tlsConf != nil {
+		err = srv.ServeTLS(meteredListener{ln}, "", "")
+	} else {
+		err = srv.Serve(meteredListener{ln})
+	}
+	if err != nil {
 		log.Fatal().Err(err).Msg("无法启动监听服务")
 	}
 }
@@ -0,0 +1,61 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+// ErrNoRoute Invoke 的 TypeID 未注册处理器
+var ErrNoRoute = errors.New("no route for type")
+
+var invokeSeq atomic.Uint64
+
+// Invoke 以进程内调用方执行已注册的二进制路由并等待应答帧，供 gRPC 等非帧传输复用同一套授权与业务逻辑。
+// 调用方不具备设备身份（DeviceID=0），授权完全取决于请求中的 user_key；不经过审批门控。处理器在调用方协程内执行，
+// 与连接的请求共用 Deadline.Workers 并发上限，context 派生自 ctx。
+func (s *Server) Invoke(ctx context.Context, typeID uint16, payload []byte) (bin.HeaderV1, []byte, error) {
+	handler, ok := s.binRoutes[typeID]
+	if !ok {
+		return bin.HeaderV1{}, nil, ErrNoRoute
+	}
+	c := &Client{Hub: s, Send: make(chan []byte, 8), RemoteAddr: "local", Binary: true, Protocol: "grpc"}
+	h := bin.HeaderV1{TypeID: typeID, MsgID: invokeSeq.Add(1), Source: 0, Target: s.DeviceID(), Timestamp: time.Now().UnixMilli()}
+	select {
+	case s.workers <- struct{}{}:
+	case <-ctx.Done():
+		return bin.HeaderV1{}, nil, ctx.Err()
+	}
+	s.dispatch(ctx, c, h, payload, time.Time{}, handler)
+	<-s.workers
+	for {
+		select {
+		case frame := <-c.Send:
+			rh, pl, err := bin.DecodeFrame(frame)
+			if err == nil && rh.MsgID == h.MsgID {
+				return rh, pl, nil
+			}
+		case <-ctx.Done():
+			return bin.HeaderV1{}, nil, ctx.Err()
+		}
+	}
+}
+
+// httpHandler 将 HTTP/2 gRPC 请求交给 s.GRPC，其余交给默认 mux（/ws 与设备 HTTP 接口）
+func (s *Server) httpHandler() http.Handler {
+	if s.GRPC == nil {
+		return http.DefaultServeMux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			s.GRPC.ServeHTTP(w, r)
+			return
+		}
+		http.DefaultServeMux.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,369 @@
+package hub
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/mqtt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// MQTTAuthenticator 认证 MQTT 客户端并返回其代表的设备 UID（由 controller 实现，可访问数据库）
+type MQTTAuthenticator interface {
+	AuthenticateMQTT(clientID, username string, password []byte) (deviceUID uint64, err error)
+}
+
+// mqttSession 单个 MQTT 连接的协议状态
+type mqttSession struct {
+	clientID string
+	ctrl     chan []byte   // 由读协程产生、写协程写出的 MQTT 报文（CONNACK/PUBACK/SUBACK/PINGRESP）
+	done     chan struct{} // 写协程退出
+	mu       sync.Mutex
+	subs     map[string]struct{}
+}
+
+func (m *mqttSession) subscribed(topic string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for f := range m.subs {
+		if mqtt.Match(f, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListenMQTT 启动内置 MQTT 3.1.1 网桥监听（tlsConf 非空时为 TLS）。每个 MQTT 连接认证后作为
+// 普通 Client 登记到 Clients，发布被转换为二进制帧进入 Broadcast，与其他接入共用审批与授权。
+func (s *Server) ListenMQTT(addr string, tlsConf *tls.Config) error {
+	var (
+		ln  net.Listener
+		err error
+	)
+	if tlsConf != nil {
+		ln, err = tls.Listen("tcp", addr, tlsConf)
+	} else {
+		ln, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	log.Info().Str("address", addr).Bool("tls", tlsConf != nil).Msg("MQTT 网桥已启动")
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		go s.handleMQTTConnection(conn, tlsConf != nil)
+	}
+}
+
+// startMQTTFromConfig 按配置启动 MQTT 网桥（未配置 ListenAddr 时不启用）
+func (s *Server) startMQTTFromConfig() {
+	mc := config.AppConfig.MQTT
+	if mc.ListenAddr == "" {
+		return
+	}
+	if s.MQTTAuth == nil {
+		log.Warn().Msg("未注入 MQTT 认证器，MQTT 网桥不启用")
+		return
+	}
+	tlsConf, err := ListenerTLSConfig(config.ListenerTLS{CertFile: mc.CertFile, KeyFile: mc.KeyFile})
+	if err != nil {
+		log.Fatal().Err(err).Msg("加载 MQTT TLS 证书失败")
+	}
+	go func() {
+		if err := s.ListenMQTT(mc.ListenAddr, tlsConf); err != nil {
+			log.Fatal().Err(err).Msg("无法启动 MQTT 网桥")
+		}
+	}()
+}
+
+// handleMQTTConnection 完成 CONNECT 认证后登记为 Client 并启动读写协程
+func (s *Server) handleMQTTConnection(conn net.Conn, secure bool) {
+	r := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	p, err := mqtt.ReadPacket(r, maxMessageSize)
+	if err != nil || p.Type != mqtt.CONNECT {
+		_ = conn.Close()
+		return
+	}
+	refuse := func(code byte) {
+		_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+		_, _ = conn.Write(mqtt.EncodeConnack(false, code))
+		_ = conn.Close()
+	}
+	if p.ProtocolName != "MQTT" || p.ProtocolLevel != 4 {
+		refuse(mqtt.ConnRefusedProtocol)
+		return
+	}
+	if s.MQTTAuth == nil {
+		refuse(mqtt.ConnRefusedNotAuth)
+		return
+	}
+	uid, err := s.MQTTAuth.AuthenticateMQTT(p.ClientID, p.Username, p.Password)
+	if err != nil {
+		log.Warn().Err(err).Str("clientID", p.ClientID).Str("remote", conn.RemoteAddr().String()).Msg("MQTT 客户端认证失败")
+		refuse(mqtt.ConnRefusedBadAuth)
+		return
+	}
+
+	qsize := config.AppConfig.WS.SendQueueSize
+	if qsize <= 0 {
+		qsize = 256
+	}
+	protocol := "mqtt"
+	if secure {
+		protocol = "mqtt+tls"
+	}
+	client := &Client{Hub: s, tcp: conn, Send: make(chan []byte, qsize), RemoteAddr: conn.RemoteAddr().String(), UserAgent: "mqtt/" + p.ClientID, Binary: true, Protocol: protocol}
+	// MQTT 保活由客户端声明：1.5 倍 keepalive 内无报文即断开
+	if p.KeepAlive > 0 {
+		client.idleTimeout = time.Duration(p.KeepAlive) * time.Second * 3 / 2
+	}
+	client.lastActive.Store(time.Now().UnixNano())
+	sess := &mqttSession{clientID: p.ClientID, ctrl: make(chan []byte, 64), done: make(chan struct{}), subs: make(map[string]struct{})}
+	sess.ctrl <- mqtt.EncodeConnack(false, mqtt.ConnAccepted)
+
+	s.Register <- client
+	s.Attach(client, uid)
+	log.Info().Uint64("deviceUID", uid).Str("clientID", p.ClientID).Msg("MQTT 客户端已接入")
+
+	go client.mqttWritePump(sess)
+	go client.mqttReadPump(sess, r)
+}
+
+// mqttReadPump 处理客户端报文：PUBLISH 转换为二进制帧进入 Broadcast，其余在此应答
+func (c *Client) mqttReadPump(sess *mqttSession, r *bufio.Reader) {
+	defer func() {
+		c.Hub.Unregister <- c
+		c.tcp.Close()
+	}()
+	timeout := c.idleTimeout
+	if timeout <= 0 {
+		timeout = heartbeatTimeout()
+	}
+	reply := func(pkt []byte) bool {
+		select {
+		case sess.ctrl <- pkt:
+			return true
+		case <-sess.done:
+			return false
+		}
+	}
+	for {
+		_ = c.tcp.SetReadDeadline(time.Now().Add(timeout))
+		p, err := mqtt.ReadPacket(r, maxMessageSize)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				c.setCloseReason("peer closed")
+			} else {
+				c.setCloseReason("read error: " + err.Error())
+			}
+			return
+		}
+		c.lastReadAt = time.Now()
+		c.lastActive.Store(c.lastReadAt.UnixNano())
+		switch p.Type {
+		case mqtt.PUBLISH:
+			if p.QoS > 1 {
+				c.setCloseReason("mqtt: qos 2 not supported")
+				return
+			}
+			c.framesIn.Add(1)
+			c.bytesIn.Add(uint64(len(p.Payload)))
+			if frame, err := c.mqttInbound(p.Topic, p.Payload); err != nil {
+				log.Warn().Err(err).Uint64("deviceUID", c.DeviceID).Str("topic", p.Topic).Msg("MQTT 发布无法映射，已忽略")
+			} else {
+				c.Hub.Broadcast <- &HubMessage{Client: c, Message: frame, IsBinary: true}
+			}
+			if p.QoS == 1 && !reply(mqtt.EncodePuback(p.PacketID)) {
+				return
+			}
+		case mqtt.SUBSCRIBE:
+			codes := make([]byte, len(p.Filters))
+			sess.mu.Lock()
+			for i, f := range p.Filters {
+				if !mqtt.ValidFilter(f) {
+					codes[i] = mqtt.SubackFailure
+					continue
+				}
+				sess.subs[f] = struct{}{}
+				codes[i] = 0 // 网桥下行统一 QoS 0
+			}
+			sess.mu.Unlock()
+			if !reply(mqtt.EncodeSuback(p.PacketID, codes)) {
+				return
+			}
+		case mqtt.UNSUBSCRIBE:
+			sess.mu.Lock()
+			for _, f := range p.Filters {
+				delete(sess.subs, f)
+			}
+			sess.mu.Unlock()
+			if !reply(mqtt.EncodeUnsuback(p.PacketID)) {
+				return
+			}
+		case mqtt.PINGREQ:
+			if !reply(mqtt.EncodePingresp()) {
+				return
+			}
+		case mqtt.DISCONNECT:
+			c.setCloseReason("mqtt disconnect")
+			return
+		case mqtt.PUBACK:
+			// 下行仅使用 QoS 0，忽略
+		default:
+			c.setCloseReason(fmt.Sprintf("mqtt: unexpected packet type %d", p.Type))
+			return
+		}
+	}
+}
+
+// mqttWritePump 单写协程：写出控制报文，并将下行二进制帧转换为 PUBLISH（仅投递已订阅主题）
+func (c *Client) mqttWritePump(sess *mqttSession) {
+	defer func() {
+		close(sess.done)
+		c.tcp.Close()
+	}()
+	write := func(pkt []byte) bool {
+		_ = c.tcp.SetWriteDeadline(time.Now().Add(writeWait))
+		if _, err := c.tcp.Write(pkt); err != nil {
+			log.Error().Err(err).Uint64("clientID", c.DeviceID).Msg("mqttWritePump: 写入失败")
+			c.setCloseReason("write error: " + err.Error())
+			return false
+		}
+		return true
+	}
+	for {
+		select {
+		case pkt := <-sess.ctrl:
+			if !write(pkt) {
+				return
+			}
+		case frame, ok := <-c.Send:
+			if !ok {
+				c.setCloseReason("closed by hub")
+				return
+			}
+			c.framesOut.Add(1)
+			c.bytesOut.Add(uint64(len(frame)))
+			c.rawBytesOut.Add(uint64(len(frame)))
+			for _, pub := range c.mqttOutbound(frame) {
+				if !sess.subscribed(pub.topic) {
+					continue
+				}
+				if !write(mqtt.EncodePublish(pub.topic, pub.payload, 0, 0, false)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// mqttInbound 主题映射（上行）：
+//
+//	devices/<uid>/vars/<name> → VAR_UPDATE_REQ（负载为 JSON；非 JSON 按字符串处理）
+//	devices/<uid>/msg         → MSG_SEND（Target=uid，负载原样透传）
+//	devices/<uid>/e2e         → MSG_SEND 且置 FlagE2E（负载为 binproto.SealE2E 密文）
+func (c *Client) mqttInbound(topic string, payload []byte) ([]byte, error) {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 3 || parts[0] != "devices" {
+		return nil, fmt.Errorf("unsupported topic")
+	}
+	uid, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || uid == 0 {
+		return nil, fmt.Errorf("invalid device uid")
+	}
+	h := bin.HeaderV1{MsgID: uint64(time.Now().UnixNano()), Source: c.DeviceID, Timestamp: time.Now().UnixMilli()}
+	switch {
+	case len(parts) == 4 && parts[2] == "vars":
+		if !IsValidVarName(parts[3]) {
+			return nil, fmt.Errorf("invalid variable name")
+		}
+		value := payload
+		if !json.Valid(value) {
+			value, _ = json.Marshal(string(payload))
+		}
+		h.TypeID = bin.TypeVarUpdateReq
+		return bin.EncodeFrame(h, bin.EncodeVarUpdateReq("", []bin.VarUpdateItem{{DeviceUID: uid, Name: parts[3], Value: value}}))
+	case len(parts) == 3 && parts[2] == "msg":
+		h.TypeID = bin.TypeMsgSend
+		h.Target = uid
+		return bin.EncodeFrame(h, payload)
+	case len(parts) == 3 && parts[2] == "e2e":
+		h.TypeID = bin.TypeMsgSend
+		h.Flags = bin.FlagE2E
+		h.Target = uid
+		return bin.EncodeFrame(h, payload)
+	}
+	return nil, fmt.Errorf("unsupported topic")
+}
+
+type mqttPublish struct {
+	topic   string
+	payload []byte
+}
+
+// mqttOutbound 主题映射（下行）：
+//
+//	VAR_CHANGED_NOTIFY → devices/<uid>/vars/<name>（JSON 值）
+//	MSG_SEND           → devices/<本设备>/msg/<来源UID>（负载原样；置 FlagE2E 时为 devices/<本设备>/e2e/<来源UID>）
+//	TWIN_DELTA         → devices/<uid>/twin/delta（JSON）
+//	ERR_RESP           → devices/<本设备>/errors（{"msgId","code","message"}）
+//
+// 其余帧（如 OK_RESP）不转发
+func (c *Client) mqttOutbound(frame []byte) []mqttPublish {
+	h, pl, err := bin.DecodeFrame(frame)
+	if err != nil {
+		return nil
+	}
+	switch h.TypeID {
+	case bin.TypeVarChangedNotify:
+		_, items, err := bin.DecodeVarChangedNotify(pl)
+		if err != nil {
+			return nil
+		}
+		out := make([]mqttPublish, 0, len(items))
+		for _, it := range items {
+			out = append(out, mqttPublish{topic: fmt.Sprintf("devices/%d/vars/%s", it.DeviceUID, it.Name), payload: it.Value})
+		}
+		return out
+	case bin.TypeMsgSend:
+		if h.Flags&bin.FlagE2E != 0 {
+			return []mqttPublish{{topic: fmt.Sprintf("devices/%d/e2e/%d", c.DeviceID, h.Source), payload: pl}}
+		}
+		return []mqttPublish{{topic: fmt.Sprintf("devices/%d/msg/%d", c.DeviceID, h.Source), payload: pl}}
+	case bin.TypeTwinDelta:
+		_, uid, delta, _, _, err := bin.DecodeTwinDelta(pl)
+		if err != nil {
+			return nil
+		}
+		return []mqttPublish{{topic: fmt.Sprintf("devices/%d/twin/delta", uid), payload: delta}}
+	case bin.TypeErrResp:
+		reqID, code, msg, err := bin.DecodeErrResp(pl)
+		if err != nil {
+			return nil
+		}
+		b, _ := json.Marshal(map[string]any{"msgId": reqID, "code": code, "message": string(msg)})
+		return []mqttPublish{{topic: fmt.Sprintf("devices/%d/errors", c.DeviceID), payload: b}}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package hub
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// parentRequestTimeout 发往上级的请求等待应答的超时
+const parentRequestTimeout = 10 * time.Second
+
+// requestParent 以本节点身份向上级发送请求并等待同 MsgID 的应答（应答在 readPumpFromParent 中截获，不再下发）
+func (s *Server) requestParent(typeID uint16, payload []byte) (bin.HeaderV1, []byte, error) {
+	h := bin.HeaderV1{TypeID: typeID, MsgID: invokeSeq.Add(1), Source: s.DeviceID(), Target: 0, Timestamp: time.Now().UnixMilli()}
+	frame, err := bin.EncodeFrame(h, payload)
+	if err != nil {
+		return bin.HeaderV1{}, nil, err
+	}
+	ch := make(chan []byte, 1)
+	s.parentWait.Store(h.MsgID, ch)
+	defer s.parentWait.Delete(h.MsgID)
+	timer := time.NewTimer(parentRequestTimeout)
+	defer timer.Stop()
+	select {
+	case s.ParentSend <- frame:
+	case <-timer.C:
+		return bin.HeaderV1{}, nil, errors.New("parent send queue full")
+	}
+	select {
+	case resp := <-ch:
+		rh, pl, err := bin.DecodeFrame(resp)
+		if err != nil {
+			return bin.HeaderV1{}, nil, err
+		}
+		if rh.TypeID == bin.TypeErrResp {
+			_, code, msg, _ := bin.DecodeErrResp(pl)
+			return rh, nil, fmt.Errorf("parent error %d: %s", code, msg)
+		}
+		return rh, pl, nil
+	case <-timer.C:
+		return bin.HeaderV1{}, nil, errors.New("parent request timeout")
+	}
+}
+
+// takeParentReply 若帧是 requestParent 等待中的应答则交付并返回 true
+func (s *Server) takeParentReply(frame []byte) bool {
+	h, _, err := bin.DecodeFrame(frame)
+	if err != nil || h.Target != s.DeviceID() {
+		return false
+	}
+	ch, ok := s.parentWait.LoadAndDelete(h.MsgID)
+	if !ok {
+		return false
+	}
+	ch.(chan []byte) <- frame
+	return true
+}
+
+// maintainParentCerts 中继与上级连接期间：本地监听启用 mTLS 时同步上级吊销列表；
+// Relay.TLS.AutoEnroll 时在客户端证书缺失或剩余有效期不足 1/3 时向上级 CA 申请新证书（下次重连生效）
+func (s *Server) maintainParentCerts(done <-chan struct{}) {
+	rt := config.AppConfig.Relay.TLS
+	syncCRL := config.AppConfig.Server.TLS.ClientCAFile != ""
+	if !syncCRL && !rt.AutoEnroll {
+		return
+	}
+	interval := time.Duration(config.AppConfig.Relay.CRLSyncSec) * time.Second
+	if interval <= 0 {
+		interval = 300 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if !s.parentAllowed(PermCert) {
+			log.Warn().Msg("上级未授予 cert 权限，跳过吊销列表同步与证书申请")
+			return
+		}
+		if syncCRL {
+			s.syncParentCRL()
+		}
+		if rt.AutoEnroll && rt.CertFile != "" && rt.KeyFile != "" && certNeedsRenewal(rt.CertFile, s.HardwareID, time.Now()) {
+			if err := s.enrollWithParent(rt.CertFile, rt.KeyFile); err != nil {
+				log.Warn().Err(err).Msg("向上级申请客户端证书失败")
+			}
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) syncParentCRL() {
+	_, pl, err := s.requestParent(bin.TypeCertCRLReq, bin.EncodeCertCRLReq())
+	if err != nil {
+		log.Warn().Err(err).Msg("同步上级吊销列表失败")
+		return
+	}
+	_, items, _, err := bin.DecodeCertCRLResp(pl)
+	if err != nil {
+		log.Warn().Err(err).Msg("解码上级吊销列表失败")
+		return
+	}
+	s.SetRevokedCerts(items)
+	log.Debug().Int("count", len(items)).Msg("已同步上级吊销列表")
+}
+
+// certNeedsRenewal 证书文件缺失、无法解析、CN 不符或剩余有效期不足 1/3 时返回 true
+func certNeedsRenewal(certFile, hardwareID string, now time.Time) bool {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return true
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return true
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || cert.Subject.CommonName != hardwareID {
+		return true
+	}
+	return cert.NotAfter.Sub(now) < cert.NotAfter.Sub(cert.NotBefore)/3
+}
+
+// enrollWithParent 生成新密钥与 CSR，经上级签发后写入证书（叶子 + 链）与私钥文件
+func (s *Server) enrollWithParent(certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: s.HardwareID}}, key)
+	if err != nil {
+		return err
+	}
+	_, pl, err := s.requestParent(bin.TypeCertCSRReq, bin.EncodeCertCSRReq(csr))
+	if err != nil {
+		return err
+	}
+	_, cert, chain, serial, notAfter, err := bin.DecodeCertCSRResp(pl)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	var certPEM []byte
+	for _, der := range append([][]byte{cert}, chain...) {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	if err := writeFileAtomic(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(certFile, certPEM, 0o644); err != nil {
+		return err
+	}
+	log.Info().Str("serial", serial).Time("notAfter", time.Unix(notAfter, 0)).Msg("已从上级获取客户端证书")
+	return nil
+}
+
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -1,8 +1,11 @@
 package hub
 
 import (
+	"crypto/ecdh"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"myflowhub/pkg/config"
 	bin "myflowhub/pkg/protocol/binproto"
 	"net/url"
@@ -18,18 +21,32 @@ func (s *Server) connectToParent() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("无效的上级服务器地址")
 	}
+	tlsConf, err := DialTLSConfig(config.AppConfig.Relay.TLS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("加载上级链路 TLS 配置失败")
+	}
+	if rt := config.AppConfig.Relay.TLS; u.Scheme == "ws" && (rt.CAFile != "" || rt.CertFile != "") {
+		log.Warn().Msg("已配置 Relay.TLS 但 ParentAddr 为 ws://，上级链路仍为明文")
+	}
 
 	for { // Main reconnect loop
 		log.Info().Str("address", u.String()).Msg("正在连接到上级服务器...")
 		dialer := *websocket.DefaultDialer
-		// 请求协商二进制子协议
-		dialer.Subprotocols = []string{"myflowhub.bin.v1"}
-		conn, _, err := dialer.Dial(u.String(), nil)
+		// 请求协商二进制子协议，优先 v2（上级仅支持 v1 时选用 v1）
+		dialer.Subprotocols = []string{SubprotocolBinaryV2, SubprotocolBinary}
+		dialer.TLSClientConfig = tlsConf
+		// 请求 permessage-deflate；上级接受时父链路不再协商帧级压缩
+		dialer.EnableCompression = compressionEnabled()
+		conn, resp, err := dialer.Dial(u.String(), nil)
 		if err != nil {
 			log.Error().Err(err).Msg("连接上级失败，将在5秒后重试")
 			time.Sleep(5 * time.Second)
 			continue
 		}
+		s.parentDeflate = dialer.EnableCompression && wsDeflateOffered(resp.Header)
+		if s.parentDeflate {
+			_ = conn.SetCompressionLevel(compressionLevel())
+		}
 
 		// Authenticate with the parent (binary ManagerAuth as MVP)
 		if !s.authenticateWithParent(conn) {
@@ -43,6 +60,10 @@ func (s *Server) connectToParent() {
 		done := make(chan struct{})
 		go s.writePumpToParent(conn, done)
 		go s.readPumpFromParent(conn, done)
+		// 上报全量在线快照，使上级视图与本地一致
+		s.reportPresenceSnapshot()
+		go s.maintainParentCerts(done)
+		go s.parentSessionLoop(conn, done)
 
 		<-done // Wait until a pump fails
 		log.Warn().Msg("与上级的连接已断开，准备重连...")
@@ -54,19 +75,51 @@ func (s *Server) connectToParent() {
 // readPumpFromParent handles reading messages from the parent.
 func (s *Server) readPumpFromParent(conn *websocket.Conn, done chan struct{}) {
 	defer close(done)
+	// 清除认证阶段设置的读超时
+	_ = conn.SetReadDeadline(time.Time{})
 	for {
-		if _, _, err := conn.ReadMessage(); err != nil {
+		mt, msg, err := conn.ReadMessage()
+		if err != nil {
 			log.Error().Err(err).Msg("从上级读取消息失败")
 			return
 		}
-		log.Info().Msg("收到来自上级的消息(二进制未实现，忽略)")
-		// Here we could handle messages from parent, e.g., route them to our children
+		if mt != websocket.BinaryMessage {
+			continue
+		}
+		// 帧认证：启用后每帧须校验通过，失败即断开由重连循环重新握手；未启用时拒绝置 FlagMAC 的帧
+		if s.parentRxMAC != nil {
+			if msg, err = s.parentRxMAC.Open(msg); err != nil {
+				log.Error().Err(err).Msg("上级帧认证失败，断开连接")
+				return
+			}
+		} else if len(msg) >= bin.HeaderSizeV1 && binary.LittleEndian.Uint16(msg[2:4])&bin.FlagMAC != 0 {
+			log.Warn().Msg("上级链路未协商帧认证，丢弃带 FlagMAC 的帧")
+			continue
+		}
+		// v2 帧重组并转换为 v1
+		if msg, err = s.parentReasm.Add(msg); err != nil {
+			log.Warn().Err(err).Msg("上级 v2 帧重组失败，丢弃")
+			continue
+		}
+		if msg == nil {
+			continue
+		}
+		if msg, err = s.decompressParent(msg); err != nil {
+			log.Warn().Err(err).Msg("解压上级帧失败，丢弃")
+			continue
+		}
+		if s.takeParentReply(msg) {
+			continue
+		}
+		// 交由 Run 协程投递给下级（Clients 仅由 Run 协程访问）
+		s.FromParent <- msg
 	}
 }
 
 // writePumpToParent handles writing messages to the parent.
 func (s *Server) writePumpToParent(conn *websocket.Conn, done chan struct{}) {
-	ticker := time.NewTicker(30 * time.Second)
+	// 心跳周期取自上级 ParentAuthResp.heartbeat_sec
+	ticker := time.NewTicker(s.parentHeartbeat())
 	defer ticker.Stop()
 
 	for {
@@ -76,9 +129,14 @@ func (s *Server) writePumpToParent(conn *websocket.Conn, done chan struct{}) {
 				conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
-			if err := conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
-				log.Error().Err(err).Msg("向上级写入消息失败")
-				return
+			for _, f := range s.parentWireFrames(message) {
+				if s.parentTxMAC != nil {
+					f = s.parentTxMAC.Seal(f)
+				}
+				if err := writeWS(conn, s.parentDeflate, websocket.BinaryMessage, f); err != nil {
+					log.Error().Err(err).Msg("向上级写入消息失败")
+					return
+				}
 			}
 		case <-ticker.C:
 			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
@@ -91,57 +149,180 @@ func (s *Server) writePumpToParent(conn *websocket.Conn, done chan struct{}) {
 	}
 }
 
-// authenticateWithParent sends an authentication request to the parent.
-func (s *Server) authenticateWithParent(conn *websocket.Conn) bool {
-	// 优先使用 ParentAuth（二进制 HMAC 握手）；失败时回退到 ManagerAuth
-	// 取 token 优先级：Relay.SharedToken > Server.RelayToken > Server.ManagerToken（兼容旧配置）
-	token := config.AppConfig.Relay.SharedToken
+// parentAuthToken 取 ParentAuth 共享密钥，优先级：Relay.SharedToken > Server.RelayToken > Server.ManagerToken（兼容旧配置）；
+// 未配置密钥但出示客户端证书时 certAuth 为 true，由上级按证书 CN 认证
+func parentAuthToken() (token string, certAuth bool) {
+	token = config.AppConfig.Relay.SharedToken
 	if token == "" {
 		token = config.AppConfig.Server.RelayToken
 	}
 	if token == "" {
 		token = config.AppConfig.Server.ManagerToken
 	}
-	if token == "" {
-		log.Error().Msg("父链路认证失败：未配置 Relay/Shared/Manager Token")
-		return false
-	}
+	return token, token == "" && config.AppConfig.Relay.TLS.CertFile != ""
+}
 
-	// 构造 ParentAuthReq
+// buildParentAuthReq 构造 ParentAuthReq 负载，返回本次使用的 nonce（用于校验响应签名）；macPub 为帧认证临时公钥（可空）
+func (s *Server) buildParentAuthReq(token string, certAuth bool, macPub []byte) ([]byte, [16]byte, error) {
 	var nonce [16]byte
 	if _, err := rand.Read(nonce[:]); err != nil {
-		log.Error().Err(err).Msg("生成 nonce 失败")
-		return false
+		return nil, nonce, err
 	}
 	tsMs := time.Now().UnixMilli()
 	var tsBuf [8]byte
 	binary.LittleEndian.PutUint64(tsBuf[:], uint64(tsMs))
 	// caps 可根据需要扩展，这里传递一个简单标识
 	caps := "relay"
-	mac := computeHMACSHA256([]byte(token), tsBuf[:], nonce[:], []byte(s.HardwareID), []byte(caps))
+	var mac [32]byte
+	if !certAuth {
+		mac = computeHMACSHA256([]byte(token), tsBuf[:], nonce[:], []byte(s.HardwareID), []byte(caps))
+	}
+	return bin.EncodeParentAuthReq(1, tsMs, nonce, s.HardwareID, caps, mac, macPub), nonce, nil
+}
+
+// acceptParentAuthResp 校验 ParentAuthResp 的签名与有效期，并记录上级下发的会话（心跳、权限、到期时间）；
+// 返回上级的帧认证公钥（未启用时为空）
+func (s *Server) acceptParentAuthResp(pl []byte, nonce [16]byte, token string, certAuth bool) ([]byte, error) {
+	_, deviceUID, sid, hb, perms, exp, sig, macPub, err := bin.DecodeParentAuthResp(pl)
+	if err != nil {
+		return nil, err
+	}
+	// 证书认证时上级身份已由 TLS 校验，响应不带签名
+	if !certAuth {
+		want := computeHMACSHA256([]byte(token), bin.ParentAuthRespSignedData(nonce, deviceUID, sid, hb, perms, exp, macPub))
+		if !hmacEqual(sig[:], want) {
+			return nil, errors.New("invalid response signature")
+		}
+	}
+	var expires time.Time
+	if exp != 0 {
+		expires = time.UnixMilli(exp)
+		if !expires.After(time.Now()) {
+			return nil, errors.New("session already expired")
+		}
+	}
+	if old := s.parentSess.Load(); old != nil && deviceUID != s.DeviceID() {
+		return nil, fmt.Errorf("device uid changed on re-auth: %d -> %d", s.DeviceID(), deviceUID)
+	}
+	sess := &parentSession{id: sid, heartbeat: time.Duration(hb) * time.Second, perms: make(map[string]struct{}, len(perms)), expires: expires}
+	for _, p := range perms {
+		sess.perms[p] = struct{}{}
+	}
+	// 记录分配的 DeviceID，用于后续作为 Source 标识
+	if deviceUID != 0 {
+		s.deviceID.Store(deviceUID)
+	}
+	s.parentSess.Store(sess)
+	log.Info().Uint64("deviceUID", deviceUID).Strs("perms", perms).Time("expires", expires).Msg("父链路 ParentAuth 认证成功")
+	return macPub, nil
+}
+
+// parentSessionLoop 在上级会话到期前于同一连接上重新认证；续期失败则断开连接，由重连循环重新握手
+func (s *Server) parentSessionLoop(conn *websocket.Conn, done chan struct{}) {
+	for {
+		sess := s.parentSess.Load()
+		if sess == nil || sess.expires.IsZero() {
+			return
+		}
+		// 在剩余有效期的 80% 处续期，留出重试与时钟误差余量
+		wait := time.Until(sess.expires) * 4 / 5
+		if sess.legacy {
+			wait = time.Until(sess.expires)
+		}
+		select {
+		case <-done:
+			return
+		case <-time.After(wait):
+		}
+		if sess.legacy {
+			log.Warn().Msg("ManagerAuth 回退会话到期，断开连接重新握手")
+			_ = conn.Close()
+			return
+		}
+		if err := s.renewParentSession(); err != nil {
+			log.Error().Err(err).Msg("父链路会话续期失败，断开连接")
+			_ = conn.Close()
+			return
+		}
+	}
+}
+
+// renewParentSession 通过 requestParent 重新发送 ParentAuthReq 并校验签名响应；链路帧认证沿用握手时的密钥
+func (s *Server) renewParentSession() error {
+	token, certAuth := parentAuthToken()
+	pl, nonce, err := s.buildParentAuthReq(token, certAuth, nil)
+	if err != nil {
+		return err
+	}
+	rh, rpl, err := s.requestParent(bin.TypeParentAuthReq, pl)
+	if err != nil {
+		return err
+	}
+	if rh.TypeID != bin.TypeParentAuthResp {
+		return fmt.Errorf("unexpected response type %d", rh.TypeID)
+	}
+	_, err = s.acceptParentAuthResp(rpl, nonce, token, certAuth)
+	return err
+}
+
+// authenticateWithParent sends an authentication request to the parent.
+func (s *Server) authenticateWithParent(conn *websocket.Conn) bool {
+	// 优先使用 ParentAuth（二进制 HMAC 握手，响应由上级签名）；仅在允许时回退到未签名的 ManagerAuth
+	s.parentSess.Store(nil)
+	token, certAuth := parentAuthToken()
+	if token == "" && !certAuth {
+		log.Error().Msg("父链路认证失败：未配置 Relay/Shared/Manager Token 或客户端证书")
+		return false
+	}
+	allowManagerAuth := !certAuth && !config.AppConfig.Relay.DisableManagerAuth
+	// 帧认证：握手携带临时公钥，上级应答公钥后双方以 token 为绑定值派生链路密钥
+	s.parentRxMAC, s.parentTxMAC = nil, nil
+	// 协议版本先按子协议协商结果，收到 HELLO 应答后以其为准；握手帧同样按当前版本收发
+	s.parentV2 = conn.Subprotocol() == SubprotocolBinaryV2
+	s.parentReasm = newReassembler()
+	var macPriv *ecdh.PrivateKey
+	var macPub []byte
+	if FrameMACMode() != FrameMACOff {
+		k, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			log.Error().Err(err).Msg("生成帧认证密钥失败")
+			return false
+		}
+		macPriv, macPub = k, k.PublicKey().Bytes()
+	}
+
+	s.parentMaxFrame = 0
+	s.parentCompressor = nil
 	msgID := uint64(time.Now().UnixNano())
-	pl := bin.EncodeParentAuthReq(1, tsMs, nonce, s.HardwareID, caps, mac)
-	h := bin.HeaderV1{TypeID: bin.TypeParentAuthReq, MsgID: msgID, Source: s.DeviceID, Target: 0, Timestamp: time.Now().UnixMilli()}
+	// 连接握手：HELLO 与认证请求连续发送，应答在读取认证响应时处理
+	helloID := msgID - 1
+	if err := s.writeParentHello(conn, helloID); err != nil {
+		log.Error().Err(err).Msg("发送 HELLO 失败")
+		return false
+	}
+
+	pl, nonce, err := s.buildParentAuthReq(token, certAuth, macPub)
+	if err != nil {
+		log.Error().Err(err).Msg("生成 nonce 失败")
+		return false
+	}
+	h := bin.HeaderV1{TypeID: bin.TypeParentAuthReq, MsgID: msgID, Source: s.DeviceID(), Target: 0, Timestamp: time.Now().UnixMilli()}
 	frame, err := bin.EncodeFrame(h, pl)
 	if err != nil {
 		log.Error().Err(err).Msg("编码 ParentAuth 帧失败")
 		return false
 	}
-	if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+	if err := s.writeParentHandshake(conn, frame); err != nil {
 		log.Error().Err(err).Msg("发送 ParentAuth 请求失败")
 		return false
 	}
 	// 等待响应
 	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
-	mt, msg, err := conn.ReadMessage()
+	msg, err := s.readParentReply(conn, helloID)
 	if err != nil {
 		log.Error().Err(err).Msg("读取 ParentAuth 响应失败")
 		return false
 	}
-	if mt != websocket.BinaryMessage {
-		log.Error().Msg("ParentAuth 响应不是二进制帧")
-		return false
-	}
 	rh, rpl, err := bin.DecodeFrame(msg)
 	if err != nil {
 		log.Error().Err(err).Msg("解析 ParentAuth 响应帧失败")
@@ -149,17 +330,12 @@ func (s *Server) authenticateWithParent(conn *websocket.Conn) bool {
 	}
 	switch rh.TypeID {
 	case bin.TypeParentAuthResp:
-		_, deviceUID, _, _, _, _, _, derr := bin.DecodeParentAuthResp(rpl)
-		if derr != nil {
-			log.Error().Err(derr).Msg("解码 ParentAuth 响应失败")
+		peerPub, err := s.acceptParentAuthResp(rpl, nonce, token, certAuth)
+		if err != nil {
+			log.Error().Err(err).Msg("ParentAuth 响应校验失败")
 			return false
 		}
-		// 记录分配的 DeviceID，用于后续作为 Source 标识
-		if deviceUID != 0 {
-			s.DeviceID = deviceUID
-		}
-		log.Info().Uint64("deviceUID", deviceUID).Msg("父链路 ParentAuth 认证成功")
-		return true
+		return s.enableParentFrameMAC(macPriv, macPub, peerPub, token)
 	case bin.TypeErrResp:
 		_, code, msgb, e := bin.DecodeErrResp(rpl)
 		if e != nil {
@@ -169,60 +345,66 @@ func (s *Server) authenticateWithParent(conn *websocket.Conn) bool {
 		log.Error().Int32("code", code).Msgf("ParentAuth 被拒绝：%s", string(msgb))
 		return false
 	case bin.TypeManagerAuthResp:
-		// 兼容：如果上级仍返回旧的 ManagerAuthResp
-		_, deviceUID, role, derr := bin.DecodeManagerAuthResp(rpl)
+		// 兼容：如果上级仍返回旧的 ManagerAuthResp（未签名，需显式允许）
+		if !allowManagerAuth {
+			log.Error().Msg("上级返回未签名的 ManagerAuthResp，已禁用 ManagerAuth 回退")
+			return false
+		}
+		_, deviceUID, role, peerPub, derr := bin.DecodeManagerAuthResp(rpl)
 		if derr != nil {
 			log.Error().Err(derr).Msg("解码兼容的 ManagerAuth 响应失败")
 			return false
 		}
 		if deviceUID != 0 {
-			s.DeviceID = deviceUID
+			s.deviceID.Store(deviceUID)
 		}
-		log.Info().Uint64("deviceUID", deviceUID).Str("role", role).Msg("父链路使用兼容 ManagerAuth 认证成功")
-		return true
+		s.parentSess.Store(legacyParentSession())
+		log.Warn().Uint64("deviceUID", deviceUID).Str("role", role).Msg("父链路使用兼容 ManagerAuth 认证成功（响应未签名）")
+		return s.enableParentFrameMAC(macPriv, macPub, peerPub, token)
 	default:
+		if !allowManagerAuth {
+			log.Error().Uint16("typeID", rh.TypeID).Msg("ParentAuth 收到未知类型响应")
+			return false
+		}
 		// 回退到旧协议尝试一次
 		log.Warn().Uint16("typeID", rh.TypeID).Msg("ParentAuth 收到未知类型响应，尝试回退 ManagerAuth")
 	}
 
 	// 回退：ManagerAuth
-	payload := bin.EncodeManagerAuthReq(token)
-	header := bin.HeaderV1{TypeID: bin.TypeManagerAuthReq, MsgID: msgID + 1, Source: s.DeviceID, Target: 0, Timestamp: time.Now().UnixMilli()}
+	payload := bin.EncodeManagerAuthReq(token, macPub)
+	header := bin.HeaderV1{TypeID: bin.TypeManagerAuthReq, MsgID: msgID + 1, Source: s.DeviceID(), Target: 0, Timestamp: time.Now().UnixMilli()}
 	frame2, err := bin.EncodeFrame(header, payload)
 	if err != nil {
 		log.Error().Err(err).Msg("编码回退 ManagerAuth 帧失败")
 		return false
 	}
-	if err := conn.WriteMessage(websocket.BinaryMessage, frame2); err != nil {
+	if err := s.writeParentHandshake(conn, frame2); err != nil {
 		log.Error().Err(err).Msg("发送回退 ManagerAuth 请求失败")
 		return false
 	}
 	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
-	mt2, msg2, err := conn.ReadMessage()
+	msg2, err := s.readParentReply(conn, helloID)
 	if err != nil {
 		log.Error().Err(err).Msg("读取回退 ManagerAuth 响应失败")
 		return false
 	}
-	if mt2 != websocket.BinaryMessage {
-		log.Error().Msg("回退 ManagerAuth 响应不是二进制帧")
-		return false
-	}
 	h2, pl2, err := bin.DecodeFrame(msg2)
 	if err != nil {
 		log.Error().Err(err).Msg("解析回退 ManagerAuth 响应帧失败")
 		return false
 	}
 	if h2.TypeID == bin.TypeManagerAuthResp {
-		_, deviceUID, role, err := bin.DecodeManagerAuthResp(pl2)
+		_, deviceUID, role, peerPub, err := bin.DecodeManagerAuthResp(pl2)
 		if err != nil {
 			log.Error().Err(err).Msg("解码回退 ManagerAuth 负载失败")
 			return false
 		}
 		if deviceUID != 0 {
-			s.DeviceID = deviceUID
+			s.deviceID.Store(deviceUID)
 		}
-		log.Info().Uint64("deviceUID", deviceUID).Str("role", role).Msg("父链路回退 ManagerAuth 认证成功")
-		return true
+		s.parentSess.Store(legacyParentSession())
+		log.Warn().Uint64("deviceUID", deviceUID).Str("role", role).Msg("父链路回退 ManagerAuth 认证成功（响应未签名）")
+		return s.enableParentFrameMAC(macPriv, macPub, peerPub, token)
 	}
 	if h2.TypeID == bin.TypeErrResp {
 		_, code, msgb, e := bin.DecodeErrResp(pl2)
@@ -236,3 +418,23 @@ func (s *Server) authenticateWithParent(conn *websocket.Conn) bool {
 	log.Error().Uint16("typeID", h2.TypeID).Msg("回退 ManagerAuth 收到未知类型响应")
 	return false
 }
+
+// enableParentFrameMAC 按上级应答的公钥启用父链路帧认证；上级未应答公钥时 require 模式下认证失败
+func (s *Server) enableParentFrameMAC(priv *ecdh.PrivateKey, pub, peerPub []byte, token string) bool {
+	if priv == nil || len(peerPub) == 0 {
+		if FrameMACMode() == FrameMACRequire {
+			log.Error().Msg("上级未协商帧认证（FrameMAC.Mode=require），放弃连接")
+			return false
+		}
+		return true
+	}
+	key, err := bin.DeriveFrameMACKey(priv, peerPub, pub, peerPub, []byte(token))
+	if err != nil {
+		log.Error().Err(err).Msg("派生父链路帧认证密钥失败")
+		return false
+	}
+	s.parentTxMAC = bin.NewFrameMAC(key, bin.FrameMACClientToServer)
+	s.parentRxMAC = bin.NewFrameMAC(key, bin.FrameMACServerToClient)
+	log.Info().Msg("父链路已启用帧认证")
+	return true
+}
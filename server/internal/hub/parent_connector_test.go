@@ -0,0 +1,117 @@
+package hub
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/gorilla/websocket"
+)
+
+// legacyParent 启动一个只认 ManagerAuth 的旧版上级：HELLO 与 ParentAuth 均返回 ManagerAuthResp（未签名，不含权限）
+func legacyParent(t *testing.T) string {
+	t.Helper()
+	up := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := up.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			h, _, err := bin.DecodeFrame(msg)
+			if err != nil {
+				return
+			}
+			var reply []byte
+			switch h.TypeID {
+			case bin.TypeHelloReq:
+				reply, _ = bin.EncodeFrame(bin.HeaderV1{TypeID: bin.TypeErrResp, MsgID: h.MsgID}, bin.EncodeErrResp(h.MsgID, 404, []byte("unknown type")))
+			case bin.TypeParentAuthReq, bin.TypeManagerAuthReq:
+				reply, _ = bin.EncodeFrame(bin.HeaderV1{TypeID: bin.TypeManagerAuthResp, MsgID: h.MsgID}, bin.EncodeManagerAuthResp(h.MsgID, 20001, "manager", nil))
+			default:
+				continue
+			}
+			if err := conn.WriteMessage(websocket.BinaryMessage, reply); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dialParent(t *testing.T, addr string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func setRelayAuthConfig(t *testing.T, disableManagerAuth bool) {
+	t.Helper()
+	prev := config.AppConfig.Relay
+	t.Cleanup(func() { config.AppConfig.Relay = prev })
+	config.AppConfig.Relay.SharedToken = "relay-token"
+	config.AppConfig.Relay.DisableManagerAuth = disableManagerAuth
+}
+
+func TestManagerAuthFallbackSession(t *testing.T) {
+	setRelayAuthConfig(t, false)
+	addr := legacyParent(t)
+	s := NewServer(addr, "", "relay-test")
+	conn := dialParent(t, addr)
+	if !s.authenticateWithParent(conn) {
+		t.Fatal("fallback authentication failed")
+	}
+	if s.DeviceID() != 20001 {
+		t.Fatalf("device uid %d", s.DeviceID())
+	}
+	// 回退会话按旧版行为获得 forward/presence，不含 cert，且有明确的到期时间
+	if !s.parentAllowed(PermForward) || !s.parentAllowed(PermPresence) || s.parentAllowed(PermCert) {
+		t.Fatal("fallback session should grant forward and presence only")
+	}
+	sess := s.parentSess.Load()
+	if !sess.legacy || time.Until(sess.expires) > legacyParentSessionTTL || time.Until(sess.expires) < legacyParentSessionTTL-time.Minute {
+		t.Fatalf("fallback session %+v", sess)
+	}
+
+	// 到期后不尝试续期，直接断开由重连循环重新握手
+	s.parentSess.Store(&parentSession{perms: sess.perms, expires: time.Now().Add(50 * time.Millisecond), legacy: true})
+	done := make(chan struct{})
+	returned := make(chan struct{})
+	go func() { s.parentSessionLoop(conn, done); close(returned) }()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expired fallback session not closed")
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Fatal("connection still open after the fallback session expired")
+	}
+}
+
+func TestManagerAuthFallbackDisabled(t *testing.T) {
+	setRelayAuthConfig(t, true)
+	addr := legacyParent(t)
+	s := NewServer(addr, "", "relay-test")
+	if s.authenticateWithParent(dialParent(t, addr)) {
+		t.Fatal("unsigned ManagerAuth accepted with DisableManagerAuth")
+	}
+	if s.parentSess.Load() != nil || s.parentAllowed(PermForward) {
+		t.Fatal("session recorded for a refused fallback")
+	}
+}
@@ -0,0 +1,85 @@
+package hub
+
+import (
+	"time"
+
+	"myflowhub/pkg/config"
+)
+
+// 上级在 ParentAuthResp.perms 中授予中继的权限
+const (
+	PermForward  = "forward"  // 向上级转发下级帧（目标不在本地的 MSG_SEND、文件分片与广播）
+	PermPresence = "presence" // 向上级上报下级在线状态
+	PermCert     = "cert"     // 向上级内置 CA 申请证书、同步吊销列表
+)
+
+// RelayPerms 返回授予下级中继的权限（Server.RelayPerms，缺省为全部）
+func RelayPerms() []string {
+	if p := config.AppConfig.Server.RelayPerms; len(p) > 0 {
+		return p
+	}
+	return []string{PermForward, PermPresence, PermCert}
+}
+
+// legacyParentSessionTTL ManagerAuth 回退会话的有效期；旧版上级无法续期，到期即断开重连、重新握手
+const legacyParentSessionTTL = time.Hour
+
+// ParentSessionTTL 返回下级中继 ParentAuth 会话的有效期
+func ParentSessionTTL() time.Duration {
+	if v := config.AppConfig.Server.ParentSessionSec; v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return time.Hour
+}
+
+// SetSessionExpiry 记录连接认证会话的到期时间，到期未重新认证即由心跳看门狗断开（不可在 Run 协程内调用）
+func (c *Client) SetSessionExpiry(t time.Time) {
+	c.Hub.runSync(func() { c.sessionExpires = t })
+}
+
+// SetRelay 记录连接是否以中继身份认证；仅中继可上报下级在线状态（不可在 Run 协程内调用）
+func (c *Client) SetRelay(relay bool) {
+	c.Hub.runSync(func() { c.relay = relay })
+}
+
+// IsRelay 连接是否以中继身份认证（在 Run 协程或该连接的工作协程内调用）
+func (c *Client) IsRelay() bool {
+	return c.relay
+}
+
+// parentSession 中继与上级之间已校验的 ParentAuth 会话
+type parentSession struct {
+	id        [16]byte
+	heartbeat time.Duration
+	perms     map[string]struct{}
+	expires   time.Time
+	legacy    bool // 以 ManagerAuth 回退认证：上级未签名也未下发权限，到期不续期而是断开重连
+}
+
+// legacyParentSession 为 ManagerAuth 回退链路创建会话：按旧版中继的行为授予 forward/presence（旧版上级不签发证书），
+// 并设定明确的到期时间
+func legacyParentSession() *parentSession {
+	return &parentSession{
+		perms:   map[string]struct{}{PermForward: {}, PermPresence: {}},
+		expires: time.Now().Add(legacyParentSessionTTL),
+		legacy:  true,
+	}
+}
+
+// parentAllowed 中继是否获授予 perm；尚未与上级建立会话时一律拒绝，ManagerAuth 回退链路按 legacyParentSession 授权
+func (s *Server) parentAllowed(perm string) bool {
+	sess := s.parentSess.Load()
+	if sess == nil {
+		return false
+	}
+	_, ok := sess.perms[perm]
+	return ok
+}
+
+// parentHeartbeat 返回上级下发的心跳周期（未建立会话时为默认 30 秒）
+func (s *Server) parentHeartbeat() time.Duration {
+	if sess := s.parentSess.Load(); sess != nil && sess.heartbeat > 0 {
+		return sess.heartbeat
+	}
+	return 30 * time.Second
+}
@@ -0,0 +1,165 @@
+package hub
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PresenceTracker 维护设备在线视图（由 service.PresenceService 实现，方法均不阻塞）
+type PresenceTracker interface {
+	// Apply 应用一批上线/下线事件；via 为事件来源的直连中继（0 表示直连本 Hub）。
+	// snapshot=true 时 via 下未出现在 items 中的设备视为下线。返回实际发生变化的条目（含级联下线）。
+	Apply(via uint64, items []bin.PresenceItem, snapshot bool) []bin.PresenceItem
+	// Seen 刷新设备最近活跃时间
+	Seen(deviceUID uint64, at time.Time)
+	// Snapshot 返回当前在线设备
+	Snapshot() []bin.PresenceItem
+	// Get 返回设备的在线条目；Via 为其经由的直连中继（0 表示直连本 Hub）
+	Get(deviceUID uint64) (bin.PresenceItem, bool)
+}
+
+// HeartbeatSec 返回心跳周期（秒），随 ParentAuthResp 下发
+func HeartbeatSec() int {
+	if v := config.AppConfig.Presence.HeartbeatSec; v > 0 && v <= 0xffff {
+		return v
+	}
+	return 30
+}
+
+// heartbeatTimeout 连续错过 MissedHeartbeats 次心跳后判定离线
+func heartbeatTimeout() time.Duration {
+	missed := config.AppConfig.Presence.MissedHeartbeats
+	if missed <= 0 {
+		missed = 3
+	}
+	return time.Duration(HeartbeatSec()*missed) * time.Second
+}
+
+// checkHeartbeats 在 Run 协程内周期执行：刷新活跃时间，心跳超时的连接直接关闭（由 readPump 触发注销）
+func (s *Server) checkHeartbeats(now time.Time) {
+	timeout := heartbeatTimeout()
+	for id, c := range s.Clients {
+		last := time.Unix(0, c.lastActive.Load())
+		limit := timeout
+		if c.idleTimeout > 0 {
+			limit = c.idleTimeout
+		}
+		if now.Sub(last) > limit {
+			log.Warn().Uint64("clientID", id).Time("lastActive", last).Dur("timeout", limit).Msg("心跳超时，判定离线并断开连接")
+			c.setCloseReason("heartbeat timeout")
+			c.close()
+			continue
+		}
+		if !c.sessionExpires.IsZero() && now.After(c.sessionExpires) {
+			log.Warn().Uint64("clientID", id).Time("expires", c.sessionExpires).Msg("认证会话已过期且未重新认证，断开连接")
+			c.setCloseReason("session expired")
+			c.close()
+			continue
+		}
+		if s.Presence != nil {
+			s.Presence.Seen(id, last)
+		}
+	}
+	// 中继每个心跳周期向上级补发一次快照：刷新最近在线时间，并修复丢弃的事件
+	if s.Presence != nil && s.ParentAddr != "" {
+		s.reportPresence(s.Presence.Snapshot(), true)
+	}
+}
+
+// applyPresence 更新本地视图，并将变化上报给上级（中继模式）
+func (s *Server) applyPresence(via uint64, items []bin.PresenceItem, snapshot bool) {
+	if s.Presence == nil {
+		return
+	}
+	changed := s.Presence.Apply(via, items, snapshot)
+	s.recordRelayedSessions(changed)
+	if len(changed) > 0 {
+		s.publishPresence(changed)
+		s.reportPresence(changed, false)
+	}
+}
+
+// reportPresence 向上级发送 PRESENCE_EVENT；无上级时忽略，队列满时丢弃（重连后以快照补齐）
+func (s *Server) reportPresence(items []bin.PresenceItem, snapshot bool) {
+	if s.ParentAddr == "" || !s.parentAllowed(PermPresence) {
+		return
+	}
+	for i := range items {
+		items[i].Via = 0 // 上级以本中继作为 via
+	}
+	h := bin.HeaderV1{TypeID: bin.TypePresenceEvent, MsgID: uint64(time.Now().UnixNano()), Source: s.DeviceID(), Target: 0, Timestamp: time.Now().UnixMilli()}
+	frame, err := bin.EncodeFrame(h, bin.EncodePresenceEvent(items, snapshot))
+	if err != nil {
+		log.Error().Err(err).Msg("编码 PRESENCE_EVENT 失败")
+		return
+	}
+	select {
+	case s.ParentSend <- frame:
+	default:
+		log.Warn().Int("items", len(items)).Msg("上级发送队列已满，在线状态事件被丢弃")
+	}
+}
+
+// reportPresenceSnapshot 与上级（重新）建立连接后上报全量在线快照
+func (s *Server) reportPresenceSnapshot() {
+	if s.Presence == nil {
+		return
+	}
+	s.exec <- func() {
+		s.reportPresence(s.Presence.Snapshot(), true)
+	}
+}
+
+// handleHeartbeat 回送心跳（携带 Hub 时间），活跃时间已在 readPump 中刷新
+func (s *Server) handleHeartbeat(c *Client, h bin.HeaderV1) {
+	s.SendBin(c, bin.TypeHeartbeat, h.MsgID, c.DeviceID, bin.EncodeHeartbeat(time.Now().UnixMilli()))
+}
+
+// handlePresenceEvent 处理下级中继上报的在线状态（在工作协程内调用）：仅接受以中继身份认证且获授 presence 权限的连接，
+// 丢弃不属于该中继子树的设备，最近在线时间不晚于当前时间
+func (s *Server) handlePresenceEvent(c *Client, req request) {
+	items, snapshot, err := bin.DecodePresenceEvent(req.payload)
+	if err != nil {
+		log.Warn().Err(err).Uint64("from", c.DeviceID).Msg("解析 PRESENCE_EVENT 失败")
+		return
+	}
+	if !c.IsRelay() || !slices.Contains(RelayPerms(), PermPresence) {
+		log.Warn().Uint64("from", c.DeviceID).Msg("非中继连接上报在线状态，已忽略")
+		return
+	}
+	if s.Descendants == nil {
+		return
+	}
+	ctx, cancel := requestContext(context.Background(), req.h.TypeID, req.deadline)
+	uids, err := s.Descendants(ctx, c.DeviceID)
+	cancel()
+	if err != nil {
+		log.Warn().Err(err).Uint64("from", c.DeviceID).Msg("查询中继子树失败，在线状态上报已忽略")
+		return
+	}
+	subtree := make(map[uint64]struct{}, len(uids))
+	for _, uid := range uids {
+		subtree[uid] = struct{}{}
+	}
+	now := time.Now().Unix()
+	kept := items[:0]
+	for _, it := range items {
+		if _, ok := subtree[it.DeviceUID]; !ok {
+			log.Warn().Uint64("from", c.DeviceID).Uint64("deviceUID", it.DeviceUID).Msg("在线状态上报的设备不属于该中继，已丢弃")
+			continue
+		}
+		it.LastSeenSec = min(it.LastSeenSec, now)
+		kept = append(kept, it)
+	}
+	s.runSync(func() {
+		if !c.gone.Load() {
+			s.applyPresence(c.DeviceID, kept, snapshot)
+		}
+	})
+}
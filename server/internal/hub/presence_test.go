@@ -0,0 +1,97 @@
+package hub
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+// fakePresence 记录 Apply 调用的 PresenceTracker；relayed 为经中继在线的设备（UID -> via）
+type fakePresence struct {
+	via     []uint64
+	items   [][]bin.PresenceItem
+	relayed map[uint64]uint64
+}
+
+func (f *fakePresence) Apply(via uint64, items []bin.PresenceItem, snapshot bool) []bin.PresenceItem {
+	f.via = append(f.via, via)
+	f.items = append(f.items, append([]bin.PresenceItem(nil), items...))
+	return nil
+}
+func (f *fakePresence) Seen(uint64, time.Time)       {}
+func (f *fakePresence) Snapshot() []bin.PresenceItem { return nil }
+func (f *fakePresence) Get(uid uint64) (bin.PresenceItem, bool) {
+	via, ok := f.relayed[uid]
+	return bin.PresenceItem{DeviceUID: uid, Online: ok, Via: via}, ok
+}
+
+func presenceRequest(items []bin.PresenceItem) request {
+	return request{h: bin.HeaderV1{TypeID: bin.TypePresenceEvent}, payload: bin.EncodePresenceEvent(items, false)}
+}
+
+func TestPresenceEventFromRelay(t *testing.T) {
+	s := newTestServer(t)
+	p := &fakePresence{}
+	s.Presence = p
+	s.Descendants = func(_ context.Context, uid uint64) ([]uint64, error) {
+		if uid == 500 {
+			return []uint64{501, 502}, nil
+		}
+		return nil, nil
+	}
+	relay := attachTestClient(s, 500)
+	relay.SetRelay(true)
+
+	future := time.Now().Add(time.Hour).Unix()
+	s.handlePresenceEvent(relay, presenceRequest([]bin.PresenceItem{
+		{DeviceUID: 501, Online: true, LastSeenSec: future}, // 未来时间被截断为当前时间
+		{DeviceUID: 502, Online: false, LastSeenSec: 100},
+		{DeviceUID: 900, Online: true},  // 不在该中继子树
+		{DeviceUID: 500, Online: false}, // 中继自身
+	}))
+	s.runSync(func() {})
+	if len(p.items) != 1 || p.via[0] != 500 {
+		t.Fatalf("apply calls %v via %v", p.items, p.via)
+	}
+	got := p.items[0]
+	if len(got) != 2 || got[0].DeviceUID != 501 || got[1].DeviceUID != 502 {
+		t.Fatalf("kept %+v", got)
+	}
+	if now := time.Now().Unix(); got[0].LastSeenSec > now || got[0].LastSeenSec < now-5 {
+		t.Fatalf("last seen not clamped: %d", got[0].LastSeenSec)
+	}
+	if got[1].LastSeenSec != 100 {
+		t.Fatalf("past last seen changed: %d", got[1].LastSeenSec)
+	}
+}
+
+func TestPresenceEventRejected(t *testing.T) {
+	items := []bin.PresenceItem{{DeviceUID: 501, Online: true}}
+	cases := []struct {
+		name        string
+		relay       bool
+		descendants func(context.Context, uint64) ([]uint64, error)
+	}{
+		{"leaf device", false, func(context.Context, uint64) ([]uint64, error) { return []uint64{501}, nil }},
+		{"no device tree", true, nil},
+		{"tree lookup failed", true, func(context.Context, uint64) ([]uint64, error) { return nil, context.DeadlineExceeded }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t)
+			p := &fakePresence{}
+			s.Presence = p
+			s.Descendants = tc.descendants
+			c := attachTestClient(s, 500)
+			c.SetRelay(tc.relay)
+			s.handlePresenceEvent(c, presenceRequest(items))
+			s.runSync(func() {})
+			if !reflect.DeepEqual(p.items, [][]bin.PresenceItem(nil)) {
+				t.Fatalf("presence applied: %+v", p.items)
+			}
+		})
+	}
+}
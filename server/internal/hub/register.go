@@ -1,11 +1,14 @@
 package hub
 
 import (
+	"context"
+	"net/http"
+
 	bin "myflowhub/pkg/protocol/binproto"
 )
 
-// BinHandler 是二进制消息处理器签名。
-type BinHandler func(s *Server, c *Client, h bin.HeaderV1, payload []byte)
+// BinHandler 是二进制消息处理器签名；ctx 带请求的截止时间，请求被取消或超时后结束（见 dispatch）。
+type BinHandler func(ctx context.Context, s *Server, c *Client, h bin.HeaderV1, payload []byte)
 
 // RegisterAuthRoutes 注册认证与用户自服务相关路由（传入具体处理器函数）。
 func RegisterAuthRoutes(s *Server, managerAuth, userLogin, userMe, userLogout BinHandler) {
@@ -124,3 +127,123 @@ func RegisterParentAuth(s *Server, handle BinHandler) {
 		s.RegisterBinRoute(bin.TypeParentAuthReq, handle)
 	}
 }
+
+// RegisterFileRoutes 注册文件分片上传路由（仅处理发往本 Hub 的传输）。
+func RegisterFileRoutes(s *Server, initH, chunk, complete, cancel BinHandler) {
+	if initH != nil {
+		s.RegisterBinRoute(bin.TypeFileInitReq, initH)
+	}
+	if chunk != nil {
+		s.RegisterBinRoute(bin.TypeFileChunk, chunk)
+	}
+	if complete != nil {
+		s.RegisterBinRoute(bin.TypeFileCompleteReq, complete)
+	}
+	if cancel != nil {
+		s.RegisterBinRoute(bin.TypeFileCancel, cancel)
+	}
+}
+
+// RegisterFilePeerRoutes 注册设备对 Hub 下发传输的应答路由（INIT_RESP/CHUNK_ACK/COMPLETE_RESP 共用一个处理器）。
+func RegisterFilePeerRoutes(s *Server, peerResp BinHandler) {
+	if peerResp != nil {
+		s.RegisterBinRoute(bin.TypeFileInitResp, peerResp)
+		s.RegisterBinRoute(bin.TypeFileChunkAck, peerResp)
+		s.RegisterBinRoute(bin.TypeFileCompleteResp, peerResp)
+	}
+}
+
+// RegisterOTARoutes 注册固件制品与 OTA 活动管理路由。
+func RegisterOTARoutes(s *Server, artifactCreate, artifactList, campaignCreate, campaignList, campaignControl, campaignStatus BinHandler) {
+	if artifactCreate != nil {
+		s.RegisterBinRoute(bin.TypeOTAArtifactCreateReq, artifactCreate)
+	}
+	if artifactList != nil {
+		s.RegisterBinRoute(bin.TypeOTAArtifactListReq, artifactList)
+	}
+	if campaignCreate != nil {
+		s.RegisterBinRoute(bin.TypeOTACampaignCreateReq, campaignCreate)
+	}
+	if campaignList != nil {
+		s.RegisterBinRoute(bin.TypeOTACampaignListReq, campaignList)
+	}
+	if campaignControl != nil {
+		s.RegisterBinRoute(bin.TypeOTACampaignControlReq, campaignControl)
+	}
+	if campaignStatus != nil {
+		s.RegisterBinRoute(bin.TypeOTACampaignStatusReq, campaignStatus)
+	}
+}
+
+// RegisterTwinRoutes 注册设备孪生路由。
+func RegisterTwinRoutes(s *Server, get, updateDesired, updateReported, delta BinHandler) {
+	if get != nil {
+		s.RegisterBinRoute(bin.TypeTwinGetReq, get)
+	}
+	if updateDesired != nil {
+		s.RegisterBinRoute(bin.TypeTwinDesiredUpdateReq, updateDesired)
+	}
+	if updateReported != nil {
+		s.RegisterBinRoute(bin.TypeTwinReportedUpdateReq, updateReported)
+	}
+	if delta != nil {
+		s.RegisterBinRoute(bin.TypeTwinDeltaReq, delta)
+	}
+}
+
+// RegisterPresenceRoutes 注册在线状态查询路由（HEARTBEAT/PRESENCE_EVENT 由 Hub 内部处理）。
+func RegisterPresenceRoutes(s *Server, query BinHandler) {
+	if query != nil {
+		s.RegisterBinRoute(bin.TypePresenceQueryReq, query)
+	}
+}
+
+// RegisterDeviceSessionRoutes 注册连接会话历史查询路由。
+func RegisterDeviceSessionRoutes(s *Server, list BinHandler) {
+	if list != nil {
+		s.RegisterBinRoute(bin.TypeDeviceSessionListReq, list)
+	}
+}
+
+// RegisterDeviceHTTPRoutes 注册设备 HTTP 上报接口（与 /ws 共用监听地址）。
+func RegisterDeviceHTTPRoutes(vars http.HandlerFunc) {
+	if vars != nil {
+		http.HandleFunc("/device/v1/vars", vars)
+	}
+}
+
+// RegisterCertRoutes 注册内置 CA 路由（证书申请与吊销列表）。
+func RegisterCertRoutes(s *Server, csr, crl BinHandler) {
+	if csr != nil {
+		s.RegisterBinRoute(bin.TypeCertCSRReq, csr)
+	}
+	if crl != nil {
+		s.RegisterBinRoute(bin.TypeCertCRLReq, crl)
+	}
+}
+
+// RegisterDeviceKeyRoutes 注册设备凭据路由（Ed25519 挑战、认证、公钥登记与密钥轮换）。
+func RegisterDeviceKeyRoutes(s *Server, challenge, auth, keySet, rotateSecret BinHandler) {
+	if challenge != nil {
+		s.RegisterBinRoute(bin.TypeDeviceChallengeReq, challenge)
+	}
+	if auth != nil {
+		s.RegisterBinRoute(bin.TypeDeviceAuthReq, auth)
+	}
+	if keySet != nil {
+		s.RegisterBinRoute(bin.TypeDeviceKeySetReq, keySet)
+	}
+	if rotateSecret != nil {
+		s.RegisterBinRoute(bin.TypeDeviceRotateSecretReq, rotateSecret)
+	}
+}
+
+// RegisterE2EKeyRoutes 注册端到端加密公钥目录路由（发布与查询）。
+func RegisterE2EKeyRoutes(s *Server, publish, get BinHandler) {
+	if publish != nil {
+		s.RegisterBinRoute(bin.TypeE2EKeyPublishReq, publish)
+	}
+	if get != nil {
+		s.RegisterBinRoute(bin.TypeE2EKeyGetReq, get)
+	}
+}
@@ -0,0 +1,391 @@
+package hub
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// 会话恢复：HELLO 协商 resume 的连接认证后，Hub 下发恢复令牌并为该会话的下行帧按入队顺序编号（seq），
+// 在有界缓存中保留最近的帧；连接断开后会话保留 Resume.GraceSec，其间发往该设备的帧继续编号缓存。
+// 新连接以 RESUME_REQ{token, last_seq} 恢复设备身份与订阅，并按序重放 last_seq 之后仍在缓存中的帧。
+// 踢出、驳回、删除设备或吊销证书时会话随之失效；恢复前还会重新核对审批状态与凭据指纹。
+
+// resumeEnabled 是否启用会话恢复（Resume.Disabled 为 false）
+func resumeEnabled() bool {
+	return !config.AppConfig.Resume.Disabled
+}
+
+// resumeGrace 断线后会话保留时长，默认 60 秒
+func resumeGrace() time.Duration {
+	if v := config.AppConfig.Resume.GraceSec; v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return 60 * time.Second
+}
+
+// resumeBufferLimits 每个会话缓存的帧数与字节上限，默认 128 帧、1MB
+func resumeBufferLimits() (frames, bytes int) {
+	frames, bytes = config.AppConfig.Resume.BufferFrames, config.AppConfig.Resume.BufferBytes
+	if frames <= 0 {
+		frames = 128
+	}
+	if bytes <= 0 {
+		bytes = 1 << 20
+	}
+	return frames, bytes
+}
+
+type resumeFrame struct {
+	seq   uint64
+	frame []byte
+}
+
+// resumeSession 一个可恢复的设备会话。token、client、attached、expires 仅在 Run 协程内访问；
+// 序号与缓存由 mu 保护（SendBin 可能在业务协程调用，此时经连接的 sendMu 串行化）
+type resumeSession struct {
+	token    string
+	deviceID uint64
+	epoch    string  // 建立会话时的凭据指纹（见 credentialEpoch）
+	client   *Client // 当前或最近一次绑定的连接，其协商结果与订阅在恢复时沿用
+	attached bool
+	expires  time.Time
+
+	mu     sync.Mutex
+	seq    uint64
+	frames []resumeFrame
+	bytes  int
+}
+
+// record 为下行帧编号并加入缓存，超出上限时淘汰最早的帧（持有 mu 时调用）
+func (r *resumeSession) record(frame []byte) {
+	r.seq++
+	r.frames = append(r.frames, resumeFrame{seq: r.seq, frame: frame})
+	r.bytes += len(frame)
+	maxFrames, maxBytes := resumeBufferLimits()
+	n := 0
+	for len(r.frames)-n > maxFrames || (r.bytes > maxBytes && len(r.frames)-n > 1) {
+		r.bytes -= len(r.frames[n].frame)
+		n++
+	}
+	if n > 0 {
+		r.frames = append(r.frames[:0], r.frames[n:]...)
+	}
+}
+
+// buffer 为帧编号并缓存（断线期间由 Run 协程缓存发往该设备的帧）
+func (r *resumeSession) buffer(frame []byte) {
+	r.mu.Lock()
+	r.record(frame)
+	r.mu.Unlock()
+}
+
+// enqueue 将帧放入连接的发送队列（不阻塞）；绑定了可恢复会话时同时编号并缓存，队列已满丢弃的帧不占用序号
+func (c *Client) enqueue(frame []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sendClosed {
+		return false
+	}
+	select {
+	case c.Send <- frame:
+	default:
+		return false
+	}
+	if sess := c.resume.Load(); sess != nil {
+		sess.buffer(frame)
+	}
+	return true
+}
+
+// closeSend 关闭发送队列（连接注销时在 Run 协程内调用）；此后仍在执行的处理器的应答被丢弃，不再写入已关闭的队列
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.sendClosed {
+		c.sendClosed = true
+		close(c.Send)
+	}
+}
+
+// bindResume 绑定或解除连接的可恢复会话；frames 为绑定前须先入队、不编号的帧（令牌通知、恢复应答与重放帧）
+func (c *Client) bindResume(sess *resumeSession, frames ...[]byte) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sendClosed {
+		return
+	}
+	for _, f := range frames {
+		select {
+		case c.Send <- f:
+		default:
+			log.Warn().Uint64("clientID", c.DeviceID).Msg("发送队列已满，会话恢复帧被丢弃")
+		}
+	}
+	c.resume.Store(sess)
+}
+
+var errResumeRevoked = errors.New("device not approved or credentials changed")
+
+// credentialEpoch 设备当前凭据的指纹（密钥与公钥的摘要）；设备不存在或未审批时返回 errResumeRevoked（不在 Run 协程内调用）
+func credentialEpoch(ctx context.Context, deviceUID uint64) (string, error) {
+	var d database.Device
+	res := database.DB.WithContext(ctx).Select("secret_key_hash", "public_key", "approved").
+		Where("device_uid = ?", deviceUID).Limit(1).Find(&d)
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 0 || !d.Approved {
+		return "", errResumeRevoked
+	}
+	sum := sha256.New()
+	sum.Write([]byte(d.SecretKeyHash))
+	sum.Write([]byte{0})
+	sum.Write(d.PublicKey)
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// resumeEpoch 认证登记前为协商了 resume 的连接取凭据指纹；查询失败时返回空串，此次不下发令牌（不在 Run 协程内调用）
+func resumeEpoch(c *Client, deviceUID uint64) string {
+	if !resumeEnabled() || c.caps == nil || !c.HasFeature(bin.FeatureResume) {
+		return ""
+	}
+	ctx, cancel := requestContext(context.Background(), bin.TypeResumeReq, time.Time{})
+	defer cancel()
+	epoch, err := credentialEpoch(ctx, deviceUID)
+	if err != nil {
+		log.Warn().Err(err).Uint64("deviceUID", deviceUID).Msg("查询凭据指纹失败，不下发会话恢复令牌")
+		return ""
+	}
+	return epoch
+}
+
+func newResumeToken() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// startResume 认证登记时为协商了 resume 的连接建立可恢复会话并下发令牌；完整认证使该设备之前的会话失效（在 Run 协程内调用）
+func (s *Server) startResume(c *Client) {
+	if c.resume.Load() != nil {
+		return
+	}
+	s.dropResume(c.DeviceID)
+	if !resumeEnabled() || c.caps == nil || !c.HasFeature(bin.FeatureResume) || c.credEpoch == "" {
+		return
+	}
+	token, err := newResumeToken()
+	if err != nil {
+		log.Error().Err(err).Msg("生成会话恢复令牌失败")
+		return
+	}
+	notify, err := s.encodeFrame(bin.TypeResumeTokenNotify, 0, c.DeviceID, bin.EncodeResumeTokenNotify(token, 0, uint32(resumeGrace()/time.Second)))
+	if err != nil {
+		return
+	}
+	sess := &resumeSession{token: token, deviceID: c.DeviceID, epoch: c.credEpoch, client: c, attached: true}
+	s.resumeTokens[token] = sess
+	s.resumeDevices[c.DeviceID] = sess
+	// 令牌通知本身不编号，其后的下行帧从 1 开始
+	c.bindResume(sess, notify)
+}
+
+// detachResume 连接断开时保留其会话至宽限期结束（在 Run 协程内调用）
+func (s *Server) detachResume(c *Client) {
+	sess := c.resume.Load()
+	if sess == nil || sess.client != c || s.resumeDevices[sess.deviceID] != sess {
+		return
+	}
+	c.bindResume(nil)
+	sess.attached = false
+	sess.expires = time.Now().Add(resumeGrace())
+	log.Info().Uint64("deviceUID", sess.deviceID).Time("expires", sess.expires).Msg("连接断开，会话保留以待恢复")
+}
+
+// dropResume 使设备的可恢复会话失效（重新认证、踢出、证书吊销等；在 Run 协程内调用）
+func (s *Server) dropResume(deviceID uint64) {
+	sess, ok := s.resumeDevices[deviceID]
+	if !ok {
+		return
+	}
+	delete(s.resumeDevices, deviceID)
+	delete(s.resumeTokens, sess.token)
+	if sess.attached {
+		sess.client.bindResume(nil)
+	}
+}
+
+// expireResume 清理宽限期已过的会话，缓存的帧随之丢弃（在 Run 协程内调用）
+func (s *Server) expireResume(now time.Time) {
+	for id, sess := range s.resumeDevices {
+		if !sess.attached && now.After(sess.expires) {
+			log.Info().Uint64("deviceUID", id).Int("buffered", len(sess.frames)).Msg("会话恢复宽限期已过，丢弃会话")
+			s.dropResume(id)
+		}
+	}
+}
+
+// bufferForResume 目标设备已断线但会话在宽限期内时缓存该帧，返回是否已缓存（在 Run 协程内调用）
+func (s *Server) bufferForResume(target uint64, frame []byte) bool {
+	sess, ok := s.resumeDevices[target]
+	if !ok || sess.attached {
+		return false
+	}
+	var h bin.HeaderV1
+	if h.Decode(frame) == nil && sess.client.accepts(h.TypeID) {
+		sess.buffer(frame)
+	}
+	return true
+}
+
+var errResumeAhead = errors.New("last_seq ahead of session")
+
+// ahead 客户端声明的序号是否超出会话已编号的范围
+func (r *resumeSession) ahead(lastSeq uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return lastSeq > r.seq
+}
+
+// replay 返回 lastSeq 之后仍在缓存中的帧（至多 limit 条，超出时舍弃较早的帧）及重放起点序号
+func (r *resumeSession) replay(lastSeq uint64, limit int) (uint64, [][]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	base := lastSeq
+	var out [][]byte
+	for _, f := range r.frames {
+		if f.seq > lastSeq {
+			if len(out) == 0 {
+				base = f.seq - 1
+			}
+			out = append(out, f.frame)
+		}
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	if len(out) > limit {
+		base += uint64(len(out) - limit)
+		out = out[len(out)-limit:]
+	}
+	return base, out
+}
+
+// handleResume 处理 RESUME_REQ：仅未认证的连接可用。先核对设备仍已审批且凭据未变更（查询数据库，在工作协程内进行），
+// 不符时会话作废；随后交由 Run 协程沿用原会话的设备身份、协商结果与订阅（见 reattach）
+func (s *Server) handleResume(c *Client, req request) {
+	h := req.h
+	if c.DeviceID != 0 {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, c.DeviceID, bin.EncodeErrResp(h.MsgID, 409, []byte("already authenticated")))
+		return
+	}
+	token, lastSeq, macPub, err := bin.DecodeResumeReq(req.payload)
+	if err != nil {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 400, []byte("bad request")))
+		return
+	}
+	var sess *resumeSession
+	s.runSync(func() { sess = s.resumeTokens[token] })
+	if sess == nil || !resumeEnabled() {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 401, []byte("invalid or expired resume token")))
+		return
+	}
+	ctx, cancel := requestContext(context.Background(), h.TypeID, req.deadline)
+	epoch, err := credentialEpoch(ctx, sess.deviceID)
+	cancel()
+	if err != nil && !errors.Is(err, errResumeRevoked) {
+		log.Error().Err(err).Uint64("deviceUID", sess.deviceID).Msg("会话恢复时查询设备失败")
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 503, []byte("service unavailable")))
+		return
+	}
+	if err != nil || epoch != sess.epoch {
+		log.Warn().Uint64("deviceUID", sess.deviceID).Msg("设备已驳回或凭据已变更，会话恢复令牌作废")
+		s.runSync(func() {
+			if s.resumeDevices[sess.deviceID] == sess {
+				s.dropResume(sess.deviceID)
+			}
+		})
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 401, []byte(errResumeRevoked.Error())))
+		return
+	}
+	s.runSync(func() { s.reattach(c, h, token, lastSeq, macPub) })
+}
+
+// reattach 恢复令牌对应的会话：更换令牌后应答并按序重放，随后登记为在线（在 Run 协程内调用）
+func (s *Server) reattach(c *Client, h bin.HeaderV1, token string, lastSeq uint64, macPub []byte) {
+	// 核对期间会话可能已失效或被其他连接恢复
+	sess, ok := s.resumeTokens[token]
+	if !ok || c.gone.Load() || c.DeviceID != 0 {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 401, []byte("invalid or expired resume token")))
+		return
+	}
+	if sess.ahead(lastSeq) {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 400, []byte(errResumeAhead.Error())))
+		return
+	}
+	serverMACPub, err := c.NegotiateFrameMAC(macPub, []byte(token), bin.TypeResumeResp, h.MsgID)
+	if err != nil {
+		if errors.Is(err, ErrFrameMACRequired) {
+			s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 426, []byte(err.Error())))
+		} else {
+			s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 400, []byte("invalid frame mac key")))
+		}
+		return
+	}
+	next, err := newResumeToken()
+	if err != nil {
+		log.Error().Err(err).Msg("生成会话恢复令牌失败")
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 500, []byte("internal error")))
+		return
+	}
+
+	// 原连接仍在（对端已断开而 Hub 尚未察觉）时由新连接接管
+	old := sess.client
+	if sess.attached {
+		old.setCloseReason("resumed on new connection")
+		old.close()
+		old.bindResume(nil)
+	}
+	delete(s.resumeTokens, sess.token)
+	sess.token = next
+	s.resumeTokens[next] = sess
+
+	// 恢复设备身份与订阅；新连接未重新 HELLO 时沿用原连接协商的特性与 TypeID
+	c.DeviceID = sess.deviceID
+	c.credEpoch = sess.epoch
+	c.e2eWatch = old.e2eWatch
+	c.legacyFeatures = old.legacyFeatures
+	if c.caps == nil {
+		c.caps = old.caps
+	}
+
+	// 应答与重放均不编号；重放完成后绑定会话，此后的下行帧接续编号。
+	// 新连接尚未登记，仅本协程向其入队：重放量以队列剩余容量为限（预留应答一条）
+	base, frames := sess.replay(lastSeq, cap(c.Send)-len(c.Send)-1)
+	resp, _ := s.encodeFrame(bin.TypeResumeResp, h.MsgID, c.DeviceID, bin.EncodeResumeResp(h.MsgID, bin.ResumeResult{
+		DeviceUID:   c.DeviceID,
+		Token:       next,
+		Seq:         base,
+		Replayed:    uint32(len(frames)),
+		GraceSec:    uint32(resumeGrace() / time.Second),
+		FrameMACPub: serverMACPub,
+	}))
+	c.bindResume(sess, append([][]byte{resp}, frames...)...)
+	sess.client, sess.attached = c, true
+
+	s.attach(c)
+	log.Info().Uint64("deviceUID", c.DeviceID).Uint64("lastSeq", lastSeq).Uint64("seq", base).Int("replayed", len(frames)).Msg("会话已恢复")
+}
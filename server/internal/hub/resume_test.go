@@ -0,0 +1,276 @@
+package hub
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+// newResumeClient 返回一条未认证的测试连接；close 如同真实连接，触发异步注销
+func newResumeClient(s *Server) *Client {
+	c := &Client{Hub: s, Send: make(chan []byte, 64), RemoteAddr: "test",
+		caps: &Capabilities{Version: 1, Features: []string{bin.FeatureResume}}}
+	var once sync.Once
+	c.closeFn = func() { once.Do(func() { go func() { s.Unregister <- c }() }) }
+	return c
+}
+
+// recvType 等待 c 的下一帧并核对类型
+func recvType(t *testing.T, c *Client, typeID uint16) (bin.HeaderV1, []byte) {
+	t.Helper()
+	select {
+	case frame := <-c.Send:
+		h, pl, err := bin.DecodeFrame(frame)
+		if err != nil || h.TypeID != typeID {
+			t.Fatalf("got type %d, want %d: %v", h.TypeID, typeID, err)
+		}
+		return h, pl
+	case <-time.After(5 * time.Second):
+		t.Fatalf("device %d received nothing", c.DeviceID)
+		return bin.HeaderV1{}, nil
+	}
+}
+
+// connectResumable 以 uid 认证一条协商了 resume 的连接，返回连接与下发的令牌
+func connectResumable(t *testing.T, s *Server, uid uint64) (*Client, string) {
+	t.Helper()
+	c := newResumeClient(s)
+	s.Attach(c, uid)
+	_, pl := recvType(t, c, bin.TypeResumeTokenNotify)
+	token, seq, _, err := bin.DecodeResumeTokenNotify(pl)
+	if err != nil || token == "" || seq != 0 {
+		t.Fatalf("token notify %q seq %d: %v", token, seq, err)
+	}
+	return c, token
+}
+
+// deliver 经 Run 协程向 uid 发送一条 MSG_SEND，返回帧
+func deliver(t *testing.T, s *Server, uid, msgID uint64) []byte {
+	t.Helper()
+	frame, err := bin.EncodeFrame(bin.HeaderV1{TypeID: bin.TypeMsgSend, MsgID: msgID, Target: uid}, []byte("m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.runSync(func() { s.forward(uid, frame) })
+	return frame
+}
+
+func disconnect(s *Server, c *Client) {
+	c.setCloseReason("peer closed")
+	s.Unregister <- c
+	s.runSync(func() {})
+}
+
+func submitResume(t *testing.T, s *Server, c *Client, msgID uint64, token string, lastSeq uint64) {
+	t.Helper()
+	submitFrame(t, s, c, bin.HeaderV1{TypeID: bin.TypeResumeReq, MsgID: msgID}, bin.EncodeResumeReq(token, lastSeq, nil))
+}
+
+func expectResumed(t *testing.T, c *Client, msgID uint64) bin.ResumeResult {
+	t.Helper()
+	_, pl := recvType(t, c, bin.TypeResumeResp)
+	id, r, err := bin.DecodeResumeResp(pl)
+	if err != nil || id != msgID {
+		t.Fatalf("resume resp for %d: %v", id, err)
+	}
+	return r
+}
+
+func TestResumeReplaysInOrder(t *testing.T) {
+	const uid = 10001
+	approveDevices(t, uid)
+	s := newTestServer(t)
+	a, token := connectResumable(t, s, uid)
+
+	var frames [][]byte
+	for i := uint64(1); i <= 3; i++ {
+		frames = append(frames, deliver(t, s, uid, i))
+		expectFrame(t, a, frames[i-1])
+	}
+	// 断线期间发往该设备的帧继续编号缓存
+	disconnect(s, a)
+	for i := uint64(4); i <= 5; i++ {
+		frames = append(frames, deliver(t, s, uid, i))
+	}
+
+	// 客户端已收到 seq 2：应答 seq=2，随后按序重放 3..5
+	b := newResumeClient(s)
+	submitResume(t, s, b, 100, token, 2)
+	r := expectResumed(t, b, 100)
+	if r.DeviceUID != uid || r.Seq != 2 || r.Replayed != 3 || r.Token == "" || r.Token == token {
+		t.Fatalf("resume result %+v", r)
+	}
+	for _, f := range frames[2:] {
+		expectFrame(t, b, f)
+	}
+	s.runSync(func() {
+		if s.Clients[uid] != b || b.DeviceID != uid {
+			t.Errorf("resumed connection not registered")
+		}
+	})
+	// 此后的下行帧接续编号
+	expectFrame(t, b, deliver(t, s, uid, 6))
+	s.runSync(func() {
+		sess := s.resumeDevices[uid]
+		if sess == nil || !sess.attached || sess.seq != 6 {
+			t.Errorf("session %+v", sess)
+		}
+	})
+
+	// 旧令牌随恢复更换而失效；超出已编号范围的 last_seq 被拒绝
+	c := newResumeClient(s)
+	submitResume(t, s, c, 101, token, 0)
+	expectErrResp(t, c, 101, 401)
+	submitResume(t, s, c, 102, r.Token, 100)
+	expectErrResp(t, c, 102, 400)
+}
+
+func TestResumeBufferLimit(t *testing.T) {
+	const uid = 10001
+	approveDevices(t, uid)
+	s := newTestServer(t)
+	a, token := connectResumable(t, s, uid)
+	disconnect(s, a)
+	frames, _ := resumeBufferLimits()
+	var last []byte
+	for i := uint64(1); i <= uint64(frames)+2; i++ {
+		last = deliver(t, s, uid, i)
+	}
+	// 缓存只保留最近的帧：从 0 恢复时重放起点跳过被淘汰的两条
+	b := newResumeClient(s)
+	b.Send = make(chan []byte, frames+8)
+	submitResume(t, s, b, 1, token, 0)
+	if r := expectResumed(t, b, 1); r.Seq != 2 || int(r.Replayed) != frames {
+		t.Fatalf("resume result %+v", r)
+	}
+	for range frames - 1 {
+		<-b.Send
+	}
+	expectFrame(t, b, last)
+}
+
+func TestResumeTokenExpiry(t *testing.T) {
+	const uid = 10001
+	approveDevices(t, uid)
+	s := newTestServer(t)
+	a, token := connectResumable(t, s, uid)
+	disconnect(s, a)
+
+	// 宽限期内不清理
+	s.runSync(func() { s.expireResume(time.Now()) })
+	s.runSync(func() {
+		if s.resumeDevices[uid] == nil {
+			t.Error("session dropped within grace")
+		}
+	})
+	s.runSync(func() { s.expireResume(time.Now().Add(resumeGrace() + time.Second)) })
+	s.runSync(func() {
+		if len(s.resumeDevices) != 0 || len(s.resumeTokens) != 0 {
+			t.Errorf("expired session kept: %d %d", len(s.resumeDevices), len(s.resumeTokens))
+		}
+	})
+	b := newResumeClient(s)
+	submitResume(t, s, b, 1, token, 0)
+	expectErrResp(t, b, 1, 401)
+}
+
+func TestResumeInvalidation(t *testing.T) {
+	const uid = 10001
+	cases := []struct {
+		name       string
+		invalidate func(t *testing.T, s *Server, a *Client)
+	}{
+		{"kick", func(t *testing.T, s *Server, a *Client) {
+			s.Kick(uid, "device rejected")
+		}},
+		{"reject", func(t *testing.T, s *Server, a *Client) {
+			// 其他 Hub 上驳回：本节点未收到踢出，恢复前的核对使令牌作废
+			disconnect(s, a)
+			if err := database.DB.Model(&database.Device{}).Where("device_uid = ?", uid).Update("approved", false).Error; err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"delete", func(t *testing.T, s *Server, a *Client) {
+			disconnect(s, a)
+			if err := database.DB.Where("device_uid = ?", uid).Delete(&database.Device{}).Error; err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"credentials changed", func(t *testing.T, s *Server, a *Client) {
+			disconnect(s, a)
+			if err := database.DB.Model(&database.Device{}).Where("device_uid = ?", uid).Update("secret_key_hash", "rotated").Error; err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			approveDevices(t, uid)
+			s := newTestServer(t)
+			a, token := connectResumable(t, s, uid)
+			tc.invalidate(t, s, a)
+			b := newResumeClient(s)
+			submitResume(t, s, b, 1, token, 0)
+			expectErrResp(t, b, 1, 401)
+			s.runSync(func() {
+				if len(s.resumeDevices) != 0 || len(s.resumeTokens) != 0 {
+					t.Errorf("session kept: %d %d", len(s.resumeDevices), len(s.resumeTokens))
+				}
+				// 作废后发往该设备的帧不再缓存
+				if s.bufferForResume(uid, []byte{}) {
+					t.Error("frame buffered for an invalidated session")
+				}
+			})
+		})
+	}
+}
+
+func TestResumeRacesWithUnregister(t *testing.T) {
+	const uid = 10001
+	approveDevices(t, uid)
+	s := newTestServer(t)
+	a, token := connectResumable(t, s, uid)
+
+	// 原连接尚未注销时恢复：新连接接管，原连接随后到达的注销不影响新连接与会话
+	b := newResumeClient(s)
+	submitResume(t, s, b, 1, token, 0)
+	r := expectResumed(t, b, 1)
+	if a.CloseReason() != "resumed on new connection" {
+		t.Fatalf("old connection close reason %q", a.CloseReason())
+	}
+	// 等待原连接的注销完成（注销时关闭其发送队列）
+	for open := true; open; {
+		select {
+		case _, open = <-a.Send:
+		case <-time.After(5 * time.Second):
+			t.Fatal("old connection not unregistered")
+		}
+	}
+	s.runSync(func() {
+		sess := s.resumeDevices[uid]
+		if s.Clients[uid] != b || sess == nil || !sess.attached || sess.client != b {
+			t.Errorf("takeover lost after old unregister: session %+v", sess)
+		}
+	})
+	expectFrame(t, b, deliver(t, s, uid, 2))
+
+	// 新连接在核对期间注销：恢复被拒绝，会话保留给下一次恢复
+	disconnect(s, b)
+	c := newResumeClient(s)
+	s.Unregister <- c
+	s.handleResume(c, request{h: bin.HeaderV1{TypeID: bin.TypeResumeReq, MsgID: 2}, payload: bin.EncodeResumeReq(r.Token, 1, nil)})
+	expectErrResp(t, c, 2, 401)
+	s.runSync(func() {
+		if _, ok := s.Clients[uid]; ok {
+			t.Error("gone connection registered")
+		}
+	})
+	d := newResumeClient(s)
+	submitResume(t, s, d, 3, r.Token, 1)
+	if r = expectResumed(t, d, 3); r.Seq != 1 || r.Replayed != 0 {
+		t.Fatalf("resume after race %+v", r)
+	}
+}
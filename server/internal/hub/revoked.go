@@ -0,0 +1,65 @@
+package hub
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// errCertRevoked 客户端证书已被内置 CA 吊销
+var errCertRevoked = errors.New("client certificate revoked")
+
+// SetRevokedCerts 替换吊销序列号集合（中枢由 CertService 推送，中继由上级 CRL 同步），
+// 并断开仍以已吊销证书在线的连接（异步进行，可在 Run 启动前调用）
+func (s *Server) SetRevokedCerts(items []bin.CertRevokedItem) {
+	set := make(map[string]struct{}, len(items))
+	for _, it := range items {
+		set[it.Serial] = struct{}{}
+	}
+	s.revoked.Store(&set)
+	go func() {
+		s.exec <- func() {
+			for id, c := range s.Clients {
+				if _, ok := set[c.CertSerial]; ok && c.CertSerial != "" {
+					log.Warn().Uint64("deviceUID", id).Str("serial", c.CertSerial).Msg("客户端证书已吊销，断开连接")
+					c.setCloseReason("certificate revoked")
+					s.dropResume(id)
+					c.close()
+				}
+			}
+		}
+	}()
+}
+
+// IsCertRevoked 序列号（十六进制）是否在吊销集合中
+func (s *Server) IsCertRevoked(serial string) bool {
+	set := s.revoked.Load()
+	if set == nil {
+		return false
+	}
+	_, ok := (*set)[serial]
+	return ok
+}
+
+// verifyNotRevoked 作为 tls.Config.VerifyPeerCertificate，在链校验通过后拒绝已吊销的客户端证书
+func (s *Server) verifyNotRevoked(_ [][]byte, chains [][]*x509.Certificate) error {
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return nil
+	}
+	if s.IsCertRevoked(chains[0][0].SerialNumber.Text(16)) {
+		return errCertRevoked
+	}
+	return nil
+}
+
+// peerCertSerial 返回已校验客户端证书的序列号（十六进制），无证书时为空
+func peerCertSerial(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].SerialNumber.Text(16)
+}
@@ -0,0 +1,53 @@
+package hub
+
+import (
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+func TestRevokedCertsDisconnect(t *testing.T) {
+	s := newTestServer(t)
+	closed := make(chan uint64, 2)
+	for uid, serial := range map[uint64]string{10001: "abc", 10002: "def"} {
+		c := &Client{Hub: s, DeviceID: uid, CertSerial: serial}
+		c.closeFn = func() { closed <- c.DeviceID }
+		s.runSync(func() { s.Clients[uid] = c })
+	}
+
+	s.SetRevokedCerts([]bin.CertRevokedItem{{Serial: "abc", DeviceUID: 10001}})
+	select {
+	case uid := <-closed:
+		if uid != 10001 {
+			t.Fatalf("closed %d, want 10001", uid)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("revoked client not disconnected")
+	}
+	s.runSync(func() {}) // 等待吊销处理完成
+	select {
+	case uid := <-closed:
+		t.Fatalf("unrevoked client %d disconnected", uid)
+	default:
+	}
+	if !s.IsCertRevoked("abc") || s.IsCertRevoked("def") || s.IsCertRevoked("") {
+		t.Fatal("revocation set mismatch")
+	}
+
+	// 握手校验：吊销链首证书被拒绝，集合整体替换后恢复
+	chain := [][]*x509.Certificate{{{SerialNumber: big.NewInt(0xabc)}}}
+	if err := s.verifyNotRevoked(nil, chain); !errors.Is(err, errCertRevoked) {
+		t.Fatalf("verify revoked: %v", err)
+	}
+	s.SetRevokedCerts(nil)
+	if err := s.verifyNotRevoked(nil, chain); err != nil {
+		t.Fatalf("verify after unrevoke: %v", err)
+	}
+	if err := s.verifyNotRevoked(nil, nil); err != nil {
+		t.Fatalf("verify without chain: %v", err)
+	}
+}
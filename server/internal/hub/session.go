@@ -0,0 +1,122 @@
+package hub
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+// SessionRecorder 记录连接会话历史（由 service.DeviceSessionService 实现，方法均不阻塞）
+type SessionRecorder interface {
+	SessionOpened(seq uint64, hub, remoteAddr, userAgent, protocol string, at time.Time)
+	SessionAuthenticated(seq, deviceUID uint64, parentPath string, at time.Time)
+	// bytes 为实际传输字节（WS 启用 permessage-deflate 时取套接字读写字节），rawBytes 为未压缩的二进制帧字节
+	SessionClosed(seq, bytesIn, bytesOut, rawBytesIn, rawBytesOut, framesIn, framesOut uint64, reason string, at time.Time)
+}
+
+// setCloseReason 记录连接关闭原因；仅首次设置生效（后续的读写错误多为连锁结果）
+func (c *Client) setCloseReason(reason string) {
+	c.closeReason.CompareAndSwap(nil, &reason)
+}
+
+// CloseReason 返回连接关闭原因（未设置时为空）
+func (c *Client) CloseReason() string {
+	if p := c.closeReason.Load(); p != nil {
+		return *p
+	}
+	return ""
+}
+
+// maxRelayDepth 接入路径中最多追溯的中继层数（防止在线视图异常成环）
+const maxRelayDepth = 8
+
+// relayedSession 经中继在线的设备的会话：序号与其经由的直连中继
+type relayedSession struct {
+	seq uint64
+	via uint64
+}
+
+// parentPath 设备的接入路径：本节点为中继时以 "/<本中继UID>" 开头，其后为设备经由的中继链（自上而下，
+// 按在线视图的 via 逐级追溯）；直连中枢的设备为 "/"
+func (s *Server) parentPath(via uint64) string {
+	var chain []uint64
+	for v := via; v != 0 && len(chain) < maxRelayDepth; {
+		chain = append(chain, v)
+		if s.Presence == nil {
+			break
+		}
+		it, ok := s.Presence.Get(v)
+		if !ok || !it.Online {
+			break
+		}
+		v = it.Via
+	}
+	if s.ParentAddr != "" {
+		chain = append(chain, s.DeviceID())
+	}
+	if len(chain) == 0 {
+		return "/"
+	}
+	var b strings.Builder
+	for i := len(chain) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "/%d", chain[i])
+	}
+	return b.String()
+}
+
+// sessionOpened 在 Run 协程内为新连接分配会话序号并记录
+func (s *Server) sessionOpened(c *Client) {
+	s.sessionSeq++
+	c.sessionSeq = s.sessionSeq
+	if s.Sessions != nil {
+		s.Sessions.SessionOpened(c.sessionSeq, s.HardwareID, c.RemoteAddr, c.UserAgent, c.Protocol, time.Now())
+	}
+}
+
+func (s *Server) sessionAuthenticated(c *Client) {
+	if s.Sessions != nil && c.sessionSeq != 0 {
+		s.Sessions.SessionAuthenticated(c.sessionSeq, c.DeviceID, s.parentPath(0), time.Now())
+	}
+}
+
+func (s *Server) sessionClosed(c *Client) {
+	if s.Sessions != nil && c.sessionSeq != 0 {
+		reason := c.CloseReason()
+		if reason == "" {
+			reason = "unknown"
+		}
+		bytesIn, bytesOut := c.bytesIn.Load(), c.bytesOut.Load()
+		if c.wsDeflate && c.meter != nil {
+			bytesIn, bytesOut = c.meter.in.Load(), c.meter.out.Load()
+		}
+		s.Sessions.SessionClosed(c.sessionSeq, bytesIn, bytesOut, c.rawBytesIn.Load(), c.rawBytesOut.Load(), c.framesIn.Load(), c.framesOut.Load(), reason, time.Now())
+	}
+}
+
+// recordRelayedSessions 为经中继在线的设备记录会话（在 Run 协程内调用）：中继上报上线时开始，
+// 下线或改经其他链路上线时结束。连接与流量统计在中继侧，此处仅记录时间与接入路径
+func (s *Server) recordRelayedSessions(changed []bin.PresenceItem) {
+	if s.Sessions == nil {
+		return
+	}
+	now := time.Now()
+	for _, it := range changed {
+		if rs, ok := s.relayedSessions[it.DeviceUID]; ok && (!it.Online || it.Via != rs.via) {
+			delete(s.relayedSessions, it.DeviceUID)
+			reason := "offline via relay"
+			if it.Online {
+				reason = "moved to another link"
+			}
+			s.Sessions.SessionClosed(rs.seq, 0, 0, 0, 0, 0, 0, reason, now)
+		}
+		if !it.Online || it.Via == 0 {
+			continue
+		}
+		s.sessionSeq++
+		s.relayedSessions[it.DeviceUID] = relayedSession{seq: s.sessionSeq, via: it.Via}
+		s.Sessions.SessionOpened(s.sessionSeq, s.HardwareID, "", "", "relay", now)
+		s.Sessions.SessionAuthenticated(s.sessionSeq, it.DeviceUID, s.parentPath(it.Via), now)
+	}
+}
@@ -0,0 +1,204 @@
+package hub
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+type sessionEvent struct {
+	kind     string
+	seq      uint64
+	uid      uint64
+	path     string
+	protocol string
+	reason   string
+}
+
+// recordingSessions 记录会话回调的 SessionRecorder（仅由 Run 协程调用，测试经 runSync 读取）
+type recordingSessions struct{ events []sessionEvent }
+
+func (r *recordingSessions) SessionOpened(seq uint64, _, _, _, protocol string, _ time.Time) {
+	r.events = append(r.events, sessionEvent{kind: "open", seq: seq, protocol: protocol})
+}
+
+func (r *recordingSessions) SessionAuthenticated(seq, deviceUID uint64, parentPath string, _ time.Time) {
+	r.events = append(r.events, sessionEvent{kind: "auth", seq: seq, uid: deviceUID, path: parentPath})
+}
+
+func (r *recordingSessions) SessionClosed(seq, _, _, _, _, _, _ uint64, reason string, _ time.Time) {
+	r.events = append(r.events, sessionEvent{kind: "close", seq: seq, reason: reason})
+}
+
+// chainPresence 最小的在线视图：记录 via 并返回变化的条目，设备下线时经由它在线的设备一并下线
+type chainPresence struct{ entries map[uint64]bin.PresenceItem }
+
+func (p *chainPresence) Apply(via uint64, items []bin.PresenceItem, _ bool) []bin.PresenceItem {
+	var changed []bin.PresenceItem
+	for _, it := range items {
+		cur := p.entries[it.DeviceUID]
+		if it.Online {
+			if cur.Online && cur.Via == via {
+				continue
+			}
+			it.Via = via
+			p.entries[it.DeviceUID] = it
+			changed = append(changed, it)
+			continue
+		}
+		if cur.Online {
+			changed = append(changed, p.offline(it.DeviceUID)...)
+		}
+	}
+	return changed
+}
+
+func (p *chainPresence) offline(uid uint64) []bin.PresenceItem {
+	it := p.entries[uid]
+	it.Online = false
+	p.entries[uid] = it
+	out := []bin.PresenceItem{it}
+	for cuid, child := range p.entries {
+		if child.Online && child.Via == uid {
+			out = append(out, p.offline(cuid)...)
+		}
+	}
+	return out
+}
+func (p *chainPresence) Seen(uint64, time.Time)       {}
+func (p *chainPresence) Snapshot() []bin.PresenceItem { return nil }
+func (p *chainPresence) Get(uid uint64) (bin.PresenceItem, bool) {
+	it, ok := p.entries[uid]
+	return it, ok
+}
+
+func newSessionTestServer(t *testing.T) (*Server, *recordingSessions) {
+	t.Helper()
+	s := newTestServer(t)
+	rec := &recordingSessions{}
+	s.Sessions = rec
+	s.Presence = &chainPresence{entries: make(map[uint64]bin.PresenceItem)}
+	return s, rec
+}
+
+// takeSessionEvents 返回并清空已记录的会话事件
+func takeSessionEvents(s *Server, rec *recordingSessions) []sessionEvent {
+	var out []sessionEvent
+	s.runSync(func() { out, rec.events = rec.events, nil })
+	return out
+}
+
+// connect 模拟一条连接的注册与认证，返回已登记的连接
+func connect(s *Server, uid uint64) *Client {
+	c := &Client{Hub: s, Send: make(chan []byte, 64), RemoteAddr: "test", Protocol: "tcp"}
+	s.Register <- c
+	s.runSync(func() {
+		c.DeviceID = uid
+		s.attach(c)
+	})
+	return c
+}
+
+func TestSessionDirectConnection(t *testing.T) {
+	s, rec := newSessionTestServer(t)
+	c := connect(s, 10)
+	c.setCloseReason("peer closed: 1000")
+	s.Unregister <- c
+	want := []sessionEvent{
+		{kind: "open", seq: 1, protocol: "tcp"},
+		{kind: "auth", seq: 1, uid: 10, path: "/"},
+		{kind: "close", seq: 1, reason: "peer closed: 1000"},
+	}
+	if got := takeSessionEvents(s, rec); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events %+v, want %+v", got, want)
+	}
+
+	// 未认证即断开的连接同样记录，原因缺省为 unknown
+	s.Unregister <- &Client{Hub: s, Send: make(chan []byte, 1)}
+	c = &Client{Hub: s, Send: make(chan []byte, 1), Protocol: "ws"}
+	s.Register <- c
+	s.Unregister <- c
+	want = []sessionEvent{{kind: "open", seq: 2, protocol: "ws"}, {kind: "close", seq: 2, reason: "unknown"}}
+	if got := takeSessionEvents(s, rec); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unauthenticated events %+v", got)
+	}
+}
+
+func TestSessionRelayedDevices(t *testing.T) {
+	s, rec := newSessionTestServer(t)
+	relay := connect(s, 100)
+	takeSessionEvents(s, rec)
+
+	// 二级中继 200 经 100 在线，设备 300 经 200 在线：路径为自上而下的中继链
+	s.runSync(func() {
+		s.applyPresence(100, []bin.PresenceItem{{DeviceUID: 200, Online: true}}, false)
+		s.applyPresence(200, []bin.PresenceItem{{DeviceUID: 300, Online: true}}, false)
+	})
+	want := []sessionEvent{
+		{kind: "open", seq: 2, protocol: "relay"},
+		{kind: "auth", seq: 2, uid: 200, path: "/100"},
+		{kind: "open", seq: 3, protocol: "relay"},
+		{kind: "auth", seq: 3, uid: 300, path: "/100/200"},
+	}
+	if got := takeSessionEvents(s, rec); !reflect.DeepEqual(got, want) {
+		t.Fatalf("online events %+v, want %+v", got, want)
+	}
+
+	// 重复上报不重开会话；改为直连时结束经中继的会话
+	s.runSync(func() { s.applyPresence(200, []bin.PresenceItem{{DeviceUID: 300, Online: true}}, false) })
+	if got := takeSessionEvents(s, rec); len(got) != 0 {
+		t.Fatalf("repeated report: %+v", got)
+	}
+	direct := connect(s, 300)
+	want = []sessionEvent{
+		{kind: "open", seq: 4, protocol: "tcp"},
+		{kind: "auth", seq: 4, uid: 300, path: "/"},
+		{kind: "close", seq: 3, reason: "moved to another link"},
+	}
+	if got := takeSessionEvents(s, rec); !reflect.DeepEqual(got, want) {
+		t.Fatalf("moved events %+v, want %+v", got, want)
+	}
+	s.Unregister <- direct
+	takeSessionEvents(s, rec)
+
+	// 一级中继断开：其下（含二级中继下）在线的设备随之下线，会话结束
+	s.runSync(func() { s.applyPresence(200, []bin.PresenceItem{{DeviceUID: 300, Online: true}}, false) })
+	takeSessionEvents(s, rec)
+	s.Unregister <- relay
+	got := takeSessionEvents(s, rec)
+	closed := map[uint64]string{}
+	for _, e := range got {
+		if e.kind == "close" {
+			closed[e.seq] = e.reason
+		}
+	}
+	if len(closed) != 3 || closed[1] != "unknown" || closed[2] != "offline via relay" || closed[5] != "offline via relay" {
+		t.Fatalf("relay offline events %+v", got)
+	}
+	s.runSync(func() {
+		if len(s.relayedSessions) != 0 {
+			t.Errorf("relayed sessions %+v", s.relayedSessions)
+		}
+	})
+}
+
+func TestSessionParentPathOnRelay(t *testing.T) {
+	s, _ := newSessionTestServer(t)
+	s.deviceID.Store(900)
+	s.runSync(func() {
+		s.ParentAddr = "ws://parent"
+		s.Presence.Apply(0, []bin.PresenceItem{{DeviceUID: 100, Online: true}}, false)
+		if p := s.parentPath(0); p != "/900" {
+			t.Errorf("direct path %q", p)
+		}
+		if p := s.parentPath(100); p != "/900/100" {
+			t.Errorf("relayed path %q", p)
+		}
+		// 在线视图中找不到的中继仅记录其自身
+		if p := s.parentPath(555); p != "/900/555" {
+			t.Errorf("unknown relay path %q", p)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package hub
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ListenTCP 启动原始 TCP 接入（tlsConf 非空时为 TLS）。连接与 WebSocket 下级共用 Client、
+// Broadcast 与审批门控；帧以 4 字节小端长度前缀分隔，长度 0 为保活帧。阻塞直至监听失败。
+func (s *Server) ListenTCP(addr string, tlsConf *tls.Config) error {
+	var (
+		ln  net.Listener
+		err error
+	)
+	if tlsConf != nil {
+		ln, err = tls.Listen("tcp", addr, tlsConf)
+	} else {
+		ln, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	log.Info().Str("address", addr).Bool("tls", tlsConf != nil).Msg("TCP 接入已启动")
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		s.handleTCPConnection(conn, tlsConf != nil)
+	}
+}
+
+// startTCPFromConfig 按配置启动 TCP 接入（未配置 ListenAddr 时不启用）
+func (s *Server) startTCPFromConfig() {
+	tc := config.AppConfig.TCP
+	if tc.ListenAddr == "" {
+		return
+	}
+	tlsConf, err := ListenerTLSConfig(config.ListenerTLS{CertFile: tc.CertFile, KeyFile: tc.KeyFile})
+	if err != nil {
+		log.Fatal().Err(err).Msg("加载 TCP TLS 证书失败")
+	}
+	go func() {
+		if err := s.ListenTCP(tc.ListenAddr, tlsConf); err != nil {
+			log.Fatal().Err(err).Msg("无法启动 TCP 接入")
+		}
+	}()
+}
+
+func (s *Server) handleTCPConnection(conn net.Conn, secure bool) {
+	qsize := config.AppConfig.WS.SendQueueSize
+	if qsize <= 0 {
+		qsize = 256
+	}
+	protocol := "tcp"
+	if secure {
+		protocol = "tcp+tls"
+	}
+	client := &Client{Hub: s, tcp: conn, Send: make(chan []byte, qsize), RemoteAddr: conn.RemoteAddr().String(), Binary: true, Protocol: protocol}
+	client.lastActive.Store(time.Now().UnixNano())
+	s.Register <- client
+
+	go client.tcpWritePump()
+	go client.tcpReadPump()
+}
+
+// tcpReadPump 读取长度前缀帧并投递到 Broadcast；任意记录（含保活帧）刷新读超时
+func (c *Client) tcpReadPump() {
+	defer func() {
+		c.Hub.Unregister <- c
+		c.tcp.Close()
+	}()
+	readTimeout := heartbeatTimeout()
+	r := bufio.NewReader(c.tcp)
+	for {
+		_ = c.tcp.SetReadDeadline(time.Now().Add(readTimeout))
+		frame, err := bin.ReadStreamFrame(r, maxMessageSize)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				c.setCloseReason("peer closed")
+			} else {
+				c.setCloseReason("read error: " + err.Error())
+			}
+			return
+		}
+		c.lastReadAt = time.Now()
+		c.lastActive.Store(c.lastReadAt.UnixNano())
+		if len(frame) == 0 {
+			continue
+		}
+		c.framesIn.Add(1)
+		c.bytesIn.Add(uint64(len(frame)))
+		c.peekCancel(frame)
+		c.Hub.Broadcast <- &HubMessage{Client: c, Message: frame, IsBinary: true}
+	}
+}
+
+// tcpWritePump 单写协程：业务帧与周期保活帧均由此写出
+func (c *Client) tcpWritePump() {
+	ticker := time.NewTicker(time.Duration(HeartbeatSec()) * time.Second)
+	defer func() {
+		ticker.Stop()
+		c.tcp.Close()
+	}()
+	for {
+		select {
+		case message, ok := <-c.Send:
+			if !ok {
+				c.setCloseReason("closed by hub")
+				return
+			}
+			c.rawBytesOut.Add(uint64(len(message)))
+			for _, f := range c.wireFrames(c.compressFrame(message)) {
+				f = c.sealFrame(f)
+				_ = c.tcp.SetWriteDeadline(time.Now().Add(writeWait))
+				if err := bin.WriteStreamFrame(c.tcp, f); err != nil {
+					log.Error().Err(err).Uint64("clientID", c.DeviceID).Msg("tcpWritePump: 写入帧失败")
+					c.setCloseReason("write error: " + err.Error())
+					return
+				}
+				c.bytesOut.Add(uint64(len(f)))
+				c.frameWritten(f)
+			}
+			c.framesOut.Add(1)
+		case <-ticker.C:
+			_ = c.tcp.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := bin.WriteStreamFrame(c.tcp, nil); err != nil {
+				log.Error().Err(err).Uint64("clientID", c.DeviceID).Msg("tcpWritePump: 发送保活帧失败")
+				c.setCloseReason("write error: " + err.Error())
+				return
+			}
+		}
+	}
+}
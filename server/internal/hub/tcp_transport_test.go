@@ -0,0 +1,142 @@
+package hub
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+// newTestServer 启动一个不连接上级、不访问数据库的 Hub（Run 协程随测试进程结束）
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := NewServer("", "", "hub-test")
+	go s.Run()
+	return s
+}
+
+// tcpPeer 经 net.Pipe 接入 Hub 原始 TCP 传输的测试客户端
+type tcpPeer struct {
+	t     *testing.T
+	conn  net.Conn
+	r     *bufio.Reader
+	reasm *bin.Reassembler
+}
+
+func newTCPPeer(t *testing.T, s *Server) *tcpPeer {
+	t.Helper()
+	client, server := net.Pipe()
+	s.handleTCPConnection(server, false)
+	t.Cleanup(func() { client.Close() })
+	return &tcpPeer{t: t, conn: client, r: bufio.NewReader(client), reasm: bin.NewReassembler(1<<20, 8, time.Minute)}
+}
+
+func (p *tcpPeer) send(typeID uint16, msgID uint64, payload []byte) {
+	p.t.Helper()
+	frame, err := bin.EncodeFrame(bin.HeaderV1{TypeID: typeID, MsgID: msgID, Timestamp: time.Now().UnixMilli()}, payload)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	p.write(frame)
+}
+
+func (p *tcpPeer) write(frame []byte) {
+	p.t.Helper()
+	_ = p.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if err := bin.WriteStreamFrame(p.conn, frame); err != nil {
+		p.t.Fatalf("write: %v", err)
+	}
+}
+
+// recvRaw 读取下一条非保活帧的原始字节（未重组）
+func (p *tcpPeer) recvRaw() ([]byte, error) {
+	_ = p.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		frame, err := bin.ReadStreamFrame(p.r, 1<<20)
+		if err != nil || len(frame) > 0 {
+			return frame, err
+		}
+	}
+}
+
+// recv 读取下一条消息，v2 帧重组为 v1 后解码
+func (p *tcpPeer) recv() (bin.HeaderV1, []byte) {
+	p.t.Helper()
+	for {
+		frame, err := p.recvRaw()
+		if err != nil {
+			p.t.Fatalf("read: %v", err)
+		}
+		v1, err := p.reasm.Add(frame)
+		if err != nil {
+			p.t.Fatalf("reassemble: %v", err)
+		}
+		if v1 == nil {
+			continue
+		}
+		h, pl, err := bin.DecodeFrame(v1)
+		if err != nil {
+			p.t.Fatalf("decode: %v", err)
+		}
+		return h, pl
+	}
+}
+
+// expectErr 读取下一条消息并校验为指定错误码的 ERR_RESP
+func (p *tcpPeer) expectErr(msgID uint64, code int32) {
+	p.t.Helper()
+	h, pl := p.recv()
+	if h.TypeID != bin.TypeErrResp || h.MsgID != msgID {
+		p.t.Fatalf("got type %d msg %d, want ERR_RESP for %d", h.TypeID, h.MsgID, msgID)
+	}
+	_, got, msg, err := bin.DecodeErrResp(pl)
+	if err != nil || got != code {
+		p.t.Fatalf("err code %d (%s) %v, want %d", got, msg, err, code)
+	}
+}
+
+func TestTCPTransportRoundTrip(t *testing.T) {
+	s := newTestServer(t)
+	p := newTCPPeer(t, s)
+	// 保活帧被忽略，其后的帧照常处理
+	p.write(nil)
+	p.write(nil)
+	p.send(bin.TypeHelloReq, 7, bin.EncodeHelloReq(bin.Hello{Versions: []uint32{1}, ClientName: "mcu"}))
+	h, pl := p.recv()
+	if h.TypeID != bin.TypeHelloResp || h.MsgID != 7 {
+		t.Fatalf("got type %d msg %d", h.TypeID, h.MsgID)
+	}
+	if _, r, err := bin.DecodeHelloResp(pl); err != nil || r.Version != 1 || r.HardwareID != "hub-test" {
+		t.Fatalf("hello resp %+v %v", r, err)
+	}
+}
+
+func TestTCPTransportApprovalGate(t *testing.T) {
+	s := newTestServer(t)
+	p := newTCPPeer(t, s)
+	// 未认证的连接不可使用非认证接口，与 WebSocket 连接相同
+	p.send(bin.TypeQueryNodesReq, 1, bin.EncodeQueryNodesReq(""))
+	p.expectErr(1, 401)
+	p.send(bin.TypeMsgSend, 2, []byte("hi"))
+	p.expectErr(2, 401)
+}
+
+func TestTCPTransportRejectsOversizedFrame(t *testing.T) {
+	s := newTestServer(t)
+	p := newTCPPeer(t, s)
+	// 长度前缀超过上限：Hub 断开连接，不读取后续字节
+	var prefix [bin.StreamPrefixSize]byte
+	binary.LittleEndian.PutUint32(prefix[:], maxMessageSize+1)
+	_ = p.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := p.conn.Write(prefix[:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.recvRaw(); !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("expected connection closed, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package hub
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"myflowhub/pkg/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+// certReloadCheck 握手时检查证书文件修改时间的最小间隔
+const certReloadCheck = 10 * time.Second
+
+// certReloader 证书热加载：握手时若文件修改时间变化则重新读取，读取失败时沿用旧证书并在下次检查时重试
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(r.fileModTime()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) fileModTime() time.Time {
+	var latest time.Time
+	for _, f := range []string{r.certFile, r.keyFile} {
+		if st, err := os.Stat(f); err == nil && st.ModTime().After(latest) {
+			latest = st.ModTime()
+		}
+	}
+	return latest
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+func (r *certReloader) current() *tls.Certificate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now := time.Now(); now.Sub(r.checked) >= certReloadCheck {
+		r.checked = now
+		if mt := r.fileModTime(); mt.After(r.modTime) {
+			if err := r.load(mt); err != nil {
+				log.Warn().Err(err).Str("cert", r.certFile).Msg("证书重新加载失败，继续使用旧证书")
+			} else {
+				log.Info().Str("cert", r.certFile).Msg("证书已重新加载")
+			}
+		}
+	}
+	return r.cert
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.current(), nil
+}
+
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if cert := r.current(); cert != nil {
+		return cert, nil
+	}
+	// 尚未签发（AutoEnroll 首次连接）：不出示证书
+	return &tls.Certificate{}, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in %s", file)
+	}
+	return pool, nil
+}
+
+// ListenerTLSConfig 构造监听端 TLS 配置；未配置证书时返回 nil（明文）
+func ListenerTLSConfig(t config.ListenerTLS) (*tls.Config, error) {
+	if t.CertFile == "" || t.KeyFile == "" {
+		return nil, nil
+	}
+	r, err := newCertReloader(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	conf := &tls.Config{GetCertificate: r.GetCertificate, MinVersion: tls.VersionTLS12}
+	if t.ClientCAFile != "" {
+		pool, err := loadCertPool(t.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+		if t.RequireClientCert {
+			conf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return conf, nil
+}
+
+// DialTLSConfig 构造拨号端 TLS 配置：CAFile 非空时仅信任该 CA，CertFile/KeyFile 非空时出示客户端证书（AutoEnroll 时允许文件暂不存在）
+func DialTLSConfig(t config.DialTLS) (*tls.Config, error) {
+	conf := &tls.Config{ServerName: t.ServerName, MinVersion: tls.VersionTLS12}
+	if t.CAFile != "" {
+		pool, err := loadCertPool(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.RootCAs = pool
+	}
+	if t.CertFile != "" && t.KeyFile != "" {
+		r, err := newCertReloader(t.CertFile, t.KeyFile)
+		if err != nil {
+			if !t.AutoEnroll {
+				return nil, err
+			}
+			// 自动签发：证书文件稍后由 maintainParentCerts 写入，届时热加载
+			r = &certReloader{certFile: t.CertFile, keyFile: t.KeyFile}
+		}
+		conf.GetClientCertificate = r.GetClientCertificate
+	}
+	return conf, nil
+}
+
+// peerHardwareID 返回已校验客户端证书的 Subject CN（mTLS），无证书时为空
+func peerHardwareID(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].Subject.CommonName
+}
@@ -0,0 +1,49 @@
+package hub
+
+import (
+	"sync"
+	"time"
+
+	"myflowhub/pkg/config"
+)
+
+// ConnectVirtual 将进程内虚拟设备（如协议适配器）登记为已认证连接并返回其 Client。
+// 下行帧由调用方从 Send 消费，直至 Disconnect 后 Hub 关闭 Send；idle 内未 Touch 即由心跳看门狗判定离线。
+func (s *Server) ConnectVirtual(deviceUID uint64, protocol string, idle time.Duration) *Client {
+	qsize := config.AppConfig.WS.SendQueueSize
+	if qsize <= 0 {
+		qsize = 256
+	}
+	c := &Client{Hub: s, Send: make(chan []byte, qsize), RemoteAddr: "local", UserAgent: protocol, Binary: true, Protocol: protocol, idleTimeout: idle}
+	var once sync.Once
+	// close 可能在 Run 协程内被调用（心跳超时），注销需异步进行
+	c.closeFn = func() {
+		once.Do(func() { go func() { s.Unregister <- c }() })
+	}
+	c.lastActive.Store(time.Now().UnixNano())
+	s.Register <- c
+	s.Attach(c, deviceUID)
+	return c
+}
+
+// Touch 刷新虚拟设备的活跃时间
+func (c *Client) Touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+// Disconnect 注销虚拟设备（幂等）
+func (c *Client) Disconnect(reason string) {
+	c.setCloseReason(reason)
+	c.close()
+}
+
+// Kick 断开设备在本节点的当前连接并使其可恢复会话失效，等待 Run 协程完成（供路由处理器调用；不可在 Run 协程内调用）
+func (s *Server) Kick(deviceUID uint64, reason string) {
+	s.runSync(func() {
+		s.dropResume(deviceUID)
+		if c, ok := s.Clients[deviceUID]; ok {
+			c.setCloseReason(reason)
+			c.close()
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package hub
+
+import (
+	"sync"
+
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// VarChange 一次变量写入：Source 为写入方设备 UID（用户/内部写入为 0）
+type VarChange struct {
+	Source uint64
+	Items  []bin.VarUpdateItem
+}
+
+// watchers 进程内变量变更与在线状态的订阅者（gRPC 流等）；发布不阻塞，订阅者队列满时丢弃
+type watchers struct {
+	mu       sync.Mutex
+	vars     map[chan VarChange]struct{}
+	presence map[chan []bin.PresenceItem]struct{}
+}
+
+// WatchVars 订阅变量变更；调用返回的 cancel 后通道关闭
+func (s *Server) WatchVars(buf int) (<-chan VarChange, func()) {
+	ch := make(chan VarChange, buf)
+	s.watch.mu.Lock()
+	if s.watch.vars == nil {
+		s.watch.vars = make(map[chan VarChange]struct{})
+	}
+	s.watch.vars[ch] = struct{}{}
+	s.watch.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.watch.mu.Lock()
+			delete(s.watch.vars, ch)
+			s.watch.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// PublishVarChanged 向订阅者发布变量变更（可在任意协程调用）
+func (s *Server) PublishVarChanged(source uint64, items []bin.VarUpdateItem) {
+	if len(items) == 0 {
+		return
+	}
+	s.watch.mu.Lock()
+	defer s.watch.mu.Unlock()
+	for ch := range s.watch.vars {
+		select {
+		case ch <- VarChange{Source: source, Items: items}:
+		default:
+			log.Warn().Int("items", len(items)).Msg("变量变更订阅者队列已满，事件被丢弃")
+		}
+	}
+}
+
+// WatchPresence 订阅在线状态变化；调用返回的 cancel 后通道关闭
+func (s *Server) WatchPresence(buf int) (<-chan []bin.PresenceItem, func()) {
+	ch := make(chan []bin.PresenceItem, buf)
+	s.watch.mu.Lock()
+	if s.watch.presence == nil {
+		s.watch.presence = make(map[chan []bin.PresenceItem]struct{})
+	}
+	s.watch.presence[ch] = struct{}{}
+	s.watch.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.watch.mu.Lock()
+			delete(s.watch.presence, ch)
+			s.watch.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publishPresence 在 Run 协程内发布在线状态变化；复制一份，避免与 reportPresence 改写 Via 竞争
+func (s *Server) publishPresence(items []bin.PresenceItem) {
+	items = append([]bin.PresenceItem(nil), items...)
+	s.watch.mu.Lock()
+	defer s.watch.mu.Unlock()
+	for ch := range s.watch.presence {
+		select {
+		case ch <- items:
+		default:
+			log.Warn().Int("items", len(items)).Msg("在线状态订阅者队列已满，事件被丢弃")
+		}
+	}
+}
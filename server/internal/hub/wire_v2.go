@@ -0,0 +1,155 @@
+package hub
+
+import (
+	"errors"
+	"time"
+
+	"myflowhub/pkg/config"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// 二进制协议版本：Hub 内部统一以 v1 帧路由。v2 对端的入站帧（含分片）在 Run 协程内重组并转换为 v1，
+// 出站帧在写协程内转换为 v2 并按 Fragment.FragmentSize 分片；v1 对端收发不变，二者可接入同一 Hub。
+// 对端以 WS 子协议 myflowhub.bin.v2 协商，或发送过任一 v2 帧后，Hub 对该连接下行使用 v2。
+
+// fragmentSize 返回 v2 分片的负载上限，保证分片连同帧头与帧认证标签不超过单帧上限
+func fragmentSize() int {
+	max := maxMessageSize - bin.HeaderSizeV2 - bin.FrameMACSize
+	if v := config.AppConfig.Fragment.FragmentSize; v > 0 && v < max {
+		return v
+	}
+	if max > 1<<20 {
+		return 1 << 20
+	}
+	return max
+}
+
+// reassemblyLimits 返回 Fragment 配置的重组限制（未配置时取默认值）
+func reassemblyLimits() (maxBytes, maxPending int, timeout time.Duration) {
+	cfg := config.AppConfig.Fragment
+	maxBytes = 64 << 20
+	if cfg.MaxMessageBytes > 0 {
+		maxBytes = cfg.MaxMessageBytes
+	}
+	maxPending = 16
+	if cfg.MaxPending > 0 {
+		maxPending = cfg.MaxPending
+	}
+	timeout = 60 * time.Second
+	if cfg.TimeoutSec > 0 {
+		timeout = time.Duration(cfg.TimeoutSec) * time.Second
+	}
+	return maxBytes, maxPending, timeout
+}
+
+// newReassembler 按 Fragment 配置创建分片重组器
+func newReassembler() *bin.Reassembler {
+	return bin.NewReassembler(reassemblyLimits())
+}
+
+// fragmentSizeFor 在对端声明的单帧上限（0 表示未声明）内取分片负载上限
+func fragmentSizeFor(peerMaxFrame int) int {
+	size := fragmentSize()
+	if peerMaxFrame > 0 {
+		if max := peerMaxFrame - bin.HeaderSizeV2 - bin.FrameMACSize; max > 0 && max < size {
+			size = max
+		}
+	}
+	return size
+}
+
+// wireFrames 将待发送的 v1 帧转换为该连接的线上格式；超过对端 HELLO 声明的上限或 v1 单帧上限的消息被丢弃（仅写协程调用）
+func (c *Client) wireFrames(frame []byte) [][]byte {
+	if max := c.peerMaxMessage.Load(); max > 0 && len(frame)-bin.HeaderSizeV1 > int(max) {
+		log.Warn().Uint64("clientID", c.DeviceID).Int("bytes", len(frame)).Uint32("max", max).Msg("消息超过对端 HELLO 声明的上限，丢弃")
+		return nil
+	}
+	peerMaxFrame := int(c.peerMaxFrame.Load())
+	if !c.wireV2.Load() {
+		max := maxMessageSize
+		if peerMaxFrame > 0 && peerMaxFrame < max {
+			max = peerMaxFrame
+		}
+		if len(frame) > max {
+			log.Warn().Uint64("clientID", c.DeviceID).Int("bytes", len(frame)).Int("max", max).Msg("消息超过 v1 单帧上限，对端未协商 v2，丢弃")
+			return nil
+		}
+		return [][]byte{frame}
+	}
+	frames, err := bin.SplitFrameV2(frame, fragmentSizeFor(peerMaxFrame))
+	if err != nil {
+		log.Warn().Err(err).Uint64("clientID", c.DeviceID).Int("bytes", len(frame)).Msg("转换 v2 帧失败，丢弃")
+		return nil
+	}
+	return frames
+}
+
+// reassemble 将入站帧重组并转换为 v1 帧；分片未到齐时返回 false。收到 v2 帧即对该连接启用 v2 下行（在 Run 协程内调用）
+func (s *Server) reassemble(c *Client, frame []byte) ([]byte, bool) {
+	if bin.FrameVersion(frame) == bin.Version2 && !c.wireV2.Load() {
+		c.wireV2.Store(true)
+	}
+	if c.reasm == nil {
+		c.reasm = newReassembler()
+	}
+	out, err := c.reasm.Add(frame)
+	if err != nil {
+		var h bin.HeaderV1
+		_ = h.Decode(frame)
+		log.Warn().Err(err).Uint64("clientID", c.DeviceID).Uint16("typeID", h.TypeID).Uint64("msgID", h.MsgID).Msg("v2 帧重组失败，丢弃")
+		if errors.Is(err, bin.ErrReassemblyTooLarge) {
+			s.SendBin(c, bin.TypeErrResp, h.MsgID, c.DeviceID, bin.EncodeErrResp(h.MsgID, 413, []byte("message too large")))
+		}
+		return nil, false
+	}
+	return out, out != nil
+}
+
+// parentWireFrames 将发往上级的 v1 帧转换为父链路协商的线上格式：先按协商做帧级压缩，再按版本分片（仅父链路写协程与握手调用）
+func (s *Server) parentWireFrames(frame []byte) [][]byte {
+	if s.parentCompressor != nil {
+		frame, _ = s.parentCompressor.CompressFrame(frame)
+	}
+	if !s.parentV2 {
+		return [][]byte{frame}
+	}
+	frames, err := bin.SplitFrameV2(frame, fragmentSizeFor(s.parentMaxFrame))
+	if err != nil {
+		log.Warn().Err(err).Int("bytes", len(frame)).Msg("转换发往上级的 v2 帧失败，丢弃")
+		return nil
+	}
+	return frames
+}
+
+// writeParentHandshake 在认证握手阶段按父链路协商的版本写出一条消息（此时写协程尚未启动）
+func (s *Server) writeParentHandshake(conn *websocket.Conn, frame []byte) error {
+	for _, f := range s.parentWireFrames(frame) {
+		if err := writeWS(conn, s.parentDeflate, websocket.BinaryMessage, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readParentFrame 在认证握手阶段读取上级的一条完整消息（v2 分片重组后转换为 v1 帧，并解压帧级压缩）
+func (s *Server) readParentFrame(conn *websocket.Conn) ([]byte, error) {
+	for {
+		mt, msg, err := conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		if mt != websocket.BinaryMessage {
+			return nil, errors.New("non-binary message")
+		}
+		out, err := s.parentReasm.Add(msg)
+		if err != nil {
+			return nil, err
+		}
+		if out != nil {
+			return s.decompressParent(out)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package hub
+
+import (
+	"context"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+
+	"github.com/rs/zerolog/log"
+)
+
+// 请求执行：Run 协程完成帧认证、重组与解压后，将请求交给该连接的工作协程按到达顺序处理（审批门控、路由处理器），
+// 各连接之间互不阻塞，同时执行的请求数受 Deadline.Workers 限制。客户端表、可恢复会话等状态仍只由 Run 协程修改：
+// 工作协程经 runSync 交给 Run 执行（登记、踢出、HELLO、转发等），期间自身等待，因此可安全读取本连接的状态。
+
+// requestQueueSize 每个连接排队等待执行的请求数上限，超出时以 ERR 503 拒绝
+const requestQueueSize = 256
+
+// request 交给工作协程的一帧（已去除截止时刻的 v1 帧）
+type request struct {
+	h        bin.HeaderV1
+	payload  []byte
+	frame    []byte
+	deadline time.Time
+}
+
+// maxWorkers 同时执行的请求数上限，默认 64
+func maxWorkers() int {
+	if v := config.AppConfig.Deadline.Workers; v > 0 {
+		return v
+	}
+	return 64
+}
+
+// submit 将请求放入连接的工作队列，首次使用时启动工作协程（在 Run 协程内调用，不阻塞）
+func (s *Server) submit(c *Client, req request) {
+	if c.gone.Load() {
+		return
+	}
+	if c.inbox == nil {
+		c.inbox = make(chan request, requestQueueSize)
+		go s.serve(c, c.inbox)
+	}
+	select {
+	case c.inbox <- req:
+	default:
+		log.Warn().Uint64("clientID", c.DeviceID).Uint16("typeID", req.h.TypeID).Msg("请求队列已满，拒绝请求")
+		s.SendBin(c, bin.TypeErrResp, req.h.MsgID, c.DeviceID, bin.EncodeErrResp(req.h.MsgID, 503, []byte("server busy")))
+	}
+}
+
+// serve 连接的工作协程：按序处理请求，连接注销后退出
+func (s *Server) serve(c *Client, inbox <-chan request) {
+	for req := range inbox {
+		if c.gone.Load() {
+			continue
+		}
+		s.workers <- struct{}{}
+		s.handleRequest(c, req)
+		<-s.workers
+	}
+}
+
+// stopWorker 连接注销时停止其工作协程，并取消正在执行的请求（在 Run 协程内调用）
+func (s *Server) stopWorker(c *Client) {
+	c.gone.Store(true)
+	c.requests.abort()
+	if c.inbox != nil {
+		close(c.inbox)
+		c.inbox = nil
+	}
+}
+
+// runSync 在 Run 协程内执行 fn 并等待完成；不可在 Run 协程内调用
+func (s *Server) runSync(fn func()) {
+	done := make(chan struct{})
+	s.exec <- func() {
+		defer close(done)
+		fn()
+	}
+	<-done
+}
+
+// handleRequest 在工作协程内处理一帧：门控后执行路由处理器，内建消息与转发交给 Run 协程
+func (s *Server) handleRequest(c *Client, req request) {
+	h, payload := req.h, req.payload
+	if h.TypeID == bin.TypeCancelReq {
+		s.handleCancel(c, h, payload)
+		return
+	}
+	if !s.admit(c, req) {
+		return
+	}
+	// 可选特性门控：HELLO 中未协商的特性（文件传输、E2E）拒绝使用；未发送 HELLO 的旧客户端不受限
+	if f := requiredFeature(h.TypeID); f != "" && !c.HasFeature(f) {
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, c.DeviceID, bin.EncodeErrResp(h.MsgID, 412, []byte("feature not negotiated: "+f)))
+		return
+	}
+	// 文件分片帧：目标非本 Hub 时按 MSG_SEND 规则透传，不解析 payload
+	if bin.IsFileTransferType(h.TypeID) && h.Target != s.DeviceID() && h.Target != 0 {
+		s.runSync(func() { s.forward(h.Target, req.frame) })
+		return
+	}
+	if h.TypeID == bin.TypeResumeReq {
+		s.handleResume(c, req)
+		return
+	}
+	if h.TypeID == bin.TypePresenceEvent {
+		s.handlePresenceEvent(c, req)
+		return
+	}
+	if handler, ok := s.binRoutes[h.TypeID]; ok {
+		s.dispatch(context.Background(), c, h, payload, req.deadline, handler)
+		return
+	}
+	s.runSync(func() { s.routeFrame(c, h, payload, req.frame) })
+}
+
+// admit 审批门控：认证类请求除外，未认证或未审批的连接拒绝后续操作（在工作协程内调用）
+func (s *Server) admit(c *Client, req request) bool {
+	h := req.h
+	switch h.TypeID {
+	case bin.TypeManagerAuthReq, bin.TypeParentAuthReq, bin.TypeUserLoginReq, bin.TypeUserMeReq, bin.TypeUserLogoutReq,
+		bin.TypeDeviceChallengeReq, bin.TypeDeviceAuthReq, bin.TypeDeviceKeySetReq, bin.TypeHelloReq, bin.TypeResumeReq:
+		// 认证与自助接口放行
+		return true
+	}
+	if c.DeviceID == 0 {
+		// 未认证的连接亦禁止访问非认证接口
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, 0, bin.EncodeErrResp(h.MsgID, 401, []byte("unauthorized")))
+		return false
+	}
+	// 查询一次数据库；也可考虑加入缓存
+	var cnt int64
+	ctx, cancel := requestContext(context.Background(), h.TypeID, req.deadline)
+	err := database.DB.WithContext(ctx).Model(&database.Device{}).
+		Where("device_uid = ? AND approved = ?", c.DeviceID, true).
+		Count(&cnt).Error
+	cancel()
+	if err != nil || cnt == 0 {
+		// 直接返回 ErrResp（禁止使用任何网络功能，也不能向其他节点发送消息）
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, c.DeviceID, bin.EncodeErrResp(h.MsgID, 403, []byte("device not approved")))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+)
+
+// attachTestClient 将一个无底层连接的已认证客户端登记到 Hub（不访问数据库）
+func attachTestClient(s *Server, uid uint64) *Client {
+	c := &Client{Hub: s, DeviceID: uid, Send: make(chan []byte, 64), RemoteAddr: "test"}
+	s.runSync(func() { s.Clients[uid] = c })
+	return c
+}
+
+func TestUnregisterWithHandlerInFlight(t *testing.T) {
+	s := newTestServer(t)
+	started, finished := make(chan struct{}), make(chan struct{})
+	// 处理器阻塞至请求被取消后才应答：应答须被丢弃，而不是写入已关闭的发送队列
+	s.RegisterBinRoute(bin.TypeUserMeReq, func(ctx context.Context, s *Server, c *Client, h bin.HeaderV1, payload []byte) {
+		close(started)
+		<-ctx.Done()
+		code, msg, _ := RequestErr(ctx)
+		s.SendBin(c, bin.TypeErrResp, h.MsgID, c.DeviceID, bin.EncodeErrResp(h.MsgID, code, []byte(msg)))
+		close(finished)
+	})
+	c := attachTestClient(s, 10001)
+	h := bin.HeaderV1{TypeID: bin.TypeUserMeReq, MsgID: 1}
+	s.runSync(func() { s.submit(c, request{h: h}) })
+	<-started
+
+	s.Unregister <- c
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not cancelled on disconnect")
+	}
+	if c.enqueue([]byte("late")) {
+		t.Fatal("frame enqueued after the send queue was closed")
+	}
+	s.runSync(func() {
+		if _, ok := s.Clients[10001]; ok {
+			t.Error("client still registered")
+		}
+	})
+}
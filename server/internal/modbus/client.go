@@ -0,0 +1,225 @@
+// Package modbus 实现 Modbus TCP 客户端（功能码 1/2/3/4/5/6/16）与用于本地联调的从站模拟器。
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// 功能码
+const (
+	FuncReadCoils              byte = 1
+	FuncReadDiscreteInputs     byte = 2
+	FuncReadHoldingRegisters   byte = 3
+	FuncReadInputRegisters     byte = 4
+	FuncWriteSingleCoil        byte = 5
+	FuncWriteSingleRegister    byte = 6
+	FuncWriteMultipleRegisters byte = 16
+)
+
+// 异常码
+const (
+	ExIllegalFunction    byte = 1
+	ExIllegalDataAddress byte = 2
+	ExIllegalDataValue   byte = 3
+)
+
+const mbapSize = 7
+
+var ErrMalformed = errors.New("modbus: malformed response")
+
+// ExceptionError 从站返回的异常响应
+type ExceptionError struct {
+	Function byte
+	Code     byte
+}
+
+func (e *ExceptionError) Error() string {
+	return fmt.Sprintf("modbus: exception %d on function %d", e.Code, e.Function)
+}
+
+// Client Modbus TCP 客户端；按需建立连接，I/O 失败后关闭并在下次请求时重连。并发安全（请求串行执行）。
+type Client struct {
+	addr    string
+	unit    byte
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	tid  uint16
+}
+
+// NewClient 创建一个新的 Client
+func NewClient(addr string, unitID byte, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Client{addr: addr, unit: unitID, timeout: timeout}
+}
+
+// Close 关闭底层连接
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeLocked()
+}
+
+func (c *Client) closeLocked() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// ReadHoldingRegisters 读保持寄存器
+func (c *Client) ReadHoldingRegisters(addr, qty uint16) ([]uint16, error) {
+	return c.readRegisters(FuncReadHoldingRegisters, addr, qty)
+}
+
+// ReadInputRegisters 读输入寄存器
+func (c *Client) ReadInputRegisters(addr, qty uint16) ([]uint16, error) {
+	return c.readRegisters(FuncReadInputRegisters, addr, qty)
+}
+
+// ReadCoils 读线圈
+func (c *Client) ReadCoils(addr, qty uint16) ([]bool, error) {
+	return c.readBits(FuncReadCoils, addr, qty)
+}
+
+// ReadDiscreteInputs 读离散输入
+func (c *Client) ReadDiscreteInputs(addr, qty uint16) ([]bool, error) {
+	return c.readBits(FuncReadDiscreteInputs, addr, qty)
+}
+
+// WriteSingleCoil 写单个线圈
+func (c *Client) WriteSingleCoil(addr uint16, v bool) error {
+	val := uint16(0)
+	if v {
+		val = 0xff00
+	}
+	_, err := c.do(FuncWriteSingleCoil, be16(addr, val))
+	return err
+}
+
+// WriteRegisters 写保持寄存器：单个使用功能码 6，多个使用 16
+func (c *Client) WriteRegisters(addr uint16, vals []uint16) error {
+	if len(vals) == 1 {
+		_, err := c.do(FuncWriteSingleRegister, be16(addr, vals[0]))
+		return err
+	}
+	req := append(be16(addr, uint16(len(vals))), byte(2*len(vals)))
+	req = append(req, be16(vals...)...)
+	_, err := c.do(FuncWriteMultipleRegisters, req)
+	return err
+}
+
+func (c *Client) readRegisters(fc byte, addr, qty uint16) ([]uint16, error) {
+	resp, err := c.do(fc, be16(addr, qty))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 1 || int(resp[0]) != 2*int(qty) || len(resp) != 1+2*int(qty) {
+		return nil, ErrMalformed
+	}
+	out := make([]uint16, qty)
+	for i := range out {
+		out[i] = binary.BigEndian.Uint16(resp[1+2*i:])
+	}
+	return out, nil
+}
+
+func (c *Client) readBits(fc byte, addr, qty uint16) ([]bool, error) {
+	resp, err := c.do(fc, be16(addr, qty))
+	if err != nil {
+		return nil, err
+	}
+	n := (int(qty) + 7) / 8
+	if len(resp) != 1+n || int(resp[0]) != n {
+		return nil, ErrMalformed
+	}
+	out := make([]bool, qty)
+	for i := range out {
+		out[i] = resp[1+i/8]&(1<<(i%8)) != 0
+	}
+	return out, nil
+}
+
+// do 发送一个 PDU 并返回响应数据（不含功能码）
+func (c *Client) do(fc byte, data []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+	}
+	c.tid++
+	req := make([]byte, mbapSize+1+len(data))
+	binary.BigEndian.PutUint16(req[0:], c.tid)
+	binary.BigEndian.PutUint16(req[4:], uint16(2+len(data)))
+	req[6] = c.unit
+	req[7] = fc
+	copy(req[8:], data)
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := c.conn.Write(req); err != nil {
+		_ = c.closeLocked()
+		return nil, err
+	}
+	for {
+		tid, pdu, err := readADU(c.conn)
+		if err != nil {
+			_ = c.closeLocked()
+			return nil, err
+		}
+		if tid != c.tid {
+			continue // 迟到的旧响应
+		}
+		if len(pdu) < 1 {
+			return nil, ErrMalformed
+		}
+		if pdu[0] == fc|0x80 {
+			if len(pdu) < 2 {
+				return nil, ErrMalformed
+			}
+			return nil, &ExceptionError{Function: fc, Code: pdu[1]}
+		}
+		if pdu[0] != fc {
+			return nil, ErrMalformed
+		}
+		return pdu[1:], nil
+	}
+}
+
+// readADU 读取一个 Modbus TCP 应用数据单元，返回事务号与 PDU（含功能码）
+func readADU(r io.Reader) (tid uint16, pdu []byte, err error) {
+	var hdr [mbapSize]byte
+	if _, err = io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	n := int(binary.BigEndian.Uint16(hdr[4:]))
+	if binary.BigEndian.Uint16(hdr[2:]) != 0 || n < 2 || n > 254 {
+		return 0, nil, ErrMalformed
+	}
+	buf := make([]byte, n-1)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return 0, nil, err
+	}
+	return binary.BigEndian.Uint16(hdr[0:]), buf, nil
+}
+
+func be16(vals ...uint16) []byte {
+	b := make([]byte, 2*len(vals))
+	for i, v := range vals {
+		binary.BigEndian.PutUint16(b[2*i:], v)
+	}
+	return b
+}
@@ -0,0 +1,148 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+)
+
+// Simulator 内存 Modbus TCP 从站，忽略 UnitID，用于本地联调适配器
+type Simulator struct {
+	mu       sync.Mutex
+	holding  []uint16
+	input    []uint16
+	coils    []bool
+	discrete []bool
+}
+
+// NewSimulator 创建一个新的 Simulator（四类数据区各 65536 个地址）
+func NewSimulator() *Simulator {
+	return &Simulator{holding: make([]uint16, 65536), input: make([]uint16, 65536), coils: make([]bool, 65536), discrete: make([]bool, 65536)}
+}
+
+// SetHolding 设置保持寄存器
+func (s *Simulator) SetHolding(addr uint16, vals ...uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.holding[addr:], vals)
+}
+
+// Holding 读取保持寄存器
+func (s *Simulator) Holding(addr uint16) uint16 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.holding[addr]
+}
+
+// SetInput 设置输入寄存器
+func (s *Simulator) SetInput(addr uint16, vals ...uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.input[addr:], vals)
+}
+
+// SetCoil 设置线圈
+func (s *Simulator) SetCoil(addr uint16, v bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coils[addr] = v
+}
+
+// SetDiscrete 设置离散输入
+func (s *Simulator) SetDiscrete(addr uint16, v bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.discrete[addr] = v
+}
+
+// Serve 接受连接直至 ln 关闭
+func (s *Simulator) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Simulator) serveConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var hdr [mbapSize]byte
+		tid, pdu, err := readADU(conn)
+		if err != nil {
+			return
+		}
+		resp := s.handle(pdu)
+		binary.BigEndian.PutUint16(hdr[0:], tid)
+		binary.BigEndian.PutUint16(hdr[4:], uint16(1+len(resp)))
+		hdr[6] = 1
+		if _, err := conn.Write(append(hdr[:], resp...)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Simulator) handle(pdu []byte) []byte {
+	fc := pdu[0]
+	ex := func(code byte) []byte { return []byte{fc | 0x80, code} }
+	if len(pdu) < 5 {
+		return ex(ExIllegalDataValue)
+	}
+	addr := int(binary.BigEndian.Uint16(pdu[1:]))
+	arg := binary.BigEndian.Uint16(pdu[3:])
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch fc {
+	case FuncReadHoldingRegisters, FuncReadInputRegisters:
+		qty := int(arg)
+		if qty < 1 || qty > 125 || addr+qty > 65536 {
+			return ex(ExIllegalDataAddress)
+		}
+		area := s.holding
+		if fc == FuncReadInputRegisters {
+			area = s.input
+		}
+		return append([]byte{fc, byte(2 * qty)}, be16(area[addr:addr+qty]...)...)
+	case FuncReadCoils, FuncReadDiscreteInputs:
+		qty := int(arg)
+		if qty < 1 || qty > 2000 || addr+qty > 65536 {
+			return ex(ExIllegalDataAddress)
+		}
+		area := s.coils
+		if fc == FuncReadDiscreteInputs {
+			area = s.discrete
+		}
+		out := make([]byte, (qty+7)/8)
+		for i := 0; i < qty; i++ {
+			if area[addr+i] {
+				out[i/8] |= 1 << (i % 8)
+			}
+		}
+		return append([]byte{fc, byte(len(out))}, out...)
+	case FuncWriteSingleCoil:
+		if arg != 0 && arg != 0xff00 {
+			return ex(ExIllegalDataValue)
+		}
+		s.coils[addr] = arg == 0xff00
+		return pdu[:5]
+	case FuncWriteSingleRegister:
+		s.holding[addr] = arg
+		return pdu[:5]
+	case FuncWriteMultipleRegisters:
+		qty := int(arg)
+		if qty < 1 || qty > 123 || addr+qty > 65536 || len(pdu) != 6+2*qty || int(pdu[5]) != 2*qty {
+			return ex(ExIllegalDataValue)
+		}
+		for i := 0; i < qty; i++ {
+			s.holding[addr+i] = binary.BigEndian.Uint16(pdu[6+2*i:])
+		}
+		return pdu[:5]
+	}
+	return ex(ExIllegalFunction)
+}
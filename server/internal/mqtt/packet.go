@@ -0,0 +1,302 @@
+// Package mqtt 实现网桥所需的 MQTT 3.1.1 报文子集（服务端视角）：
+// CONNECT/CONNACK、PUBLISH(QoS 0/1)/PUBACK、SUBSCRIBE/SUBACK、UNSUBSCRIBE/UNSUBACK、PINGREQ/PINGRESP、DISCONNECT。
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 报文类型
+const (
+	CONNECT     byte = 1
+	CONNACK     byte = 2
+	PUBLISH     byte = 3
+	PUBACK      byte = 4
+	SUBSCRIBE   byte = 8
+	SUBACK      byte = 9
+	UNSUBSCRIBE byte = 10
+	UNSUBACK    byte = 11
+	PINGREQ     byte = 12
+	PINGRESP    byte = 13
+	DISCONNECT  byte = 14
+)
+
+// CONNACK 返回码
+const (
+	ConnAccepted          byte = 0
+	ConnRefusedProtocol   byte = 1
+	ConnRefusedIdentifier byte = 2
+	ConnRefusedBadAuth    byte = 4
+	ConnRefusedNotAuth    byte = 5
+)
+
+// SubackFailure SUBACK 中表示订阅被拒绝
+const SubackFailure byte = 0x80
+
+var (
+	ErrMalformed      = errors.New("mqtt: malformed packet")
+	ErrPacketTooLarge = errors.New("mqtt: packet too large")
+	ErrUnsupported    = errors.New("mqtt: unsupported packet")
+)
+
+// Packet 解码后的报文；按 Type 使用对应字段
+type Packet struct {
+	Type  byte
+	Flags byte
+
+	// CONNECT
+	ProtocolName  string
+	ProtocolLevel byte
+	CleanSession  bool
+	KeepAlive     uint16
+	ClientID      string
+	Username      string
+	Password      []byte
+
+	// PUBLISH / PUBACK / SUBSCRIBE / UNSUBSCRIBE
+	PacketID uint16
+	Topic    string
+	Payload  []byte
+	QoS      byte
+	Retain   bool
+	Dup      bool
+
+	// SUBSCRIBE / UNSUBSCRIBE
+	Filters []string
+	QoSs    []byte
+}
+
+// ReadPacket 读取一个报文；max>0 时限制剩余长度
+func ReadPacket(r *bufio.Reader, max int) (*Packet, error) {
+	b0, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	n, err := readRemainingLength(r)
+	if err != nil {
+		return nil, err
+	}
+	if max > 0 && n > max {
+		return nil, ErrPacketTooLarge
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	p := &Packet{Type: b0 >> 4, Flags: b0 & 0x0f}
+	d := decoder{b: body}
+	switch p.Type {
+	case CONNECT:
+		p.ProtocolName = d.str()
+		p.ProtocolLevel = d.byte()
+		flags := d.byte()
+		p.KeepAlive = d.u16()
+		p.ClientID = d.str()
+		p.CleanSession = flags&0x02 != 0
+		if flags&0x04 != 0 { // will：网桥不支持遗嘱，仅跳过
+			d.str()
+			d.bytes()
+		}
+		if flags&0x80 != 0 {
+			p.Username = d.str()
+		}
+		if flags&0x40 != 0 {
+			p.Password = d.bytes()
+		}
+	case PUBLISH:
+		p.Dup = p.Flags&0x08 != 0
+		p.QoS = (p.Flags >> 1) & 0x03
+		p.Retain = p.Flags&0x01 != 0
+		p.Topic = d.str()
+		if p.QoS > 0 {
+			p.PacketID = d.u16()
+		}
+		if d.err == nil {
+			p.Payload = d.b[d.off:]
+			d.off = len(d.b)
+		}
+	case PUBACK:
+		p.PacketID = d.u16()
+	case SUBSCRIBE:
+		p.PacketID = d.u16()
+		for d.err == nil && d.off < len(d.b) {
+			p.Filters = append(p.Filters, d.str())
+			p.QoSs = append(p.QoSs, d.byte())
+		}
+	case UNSUBSCRIBE:
+		p.PacketID = d.u16()
+		for d.err == nil && d.off < len(d.b) {
+			p.Filters = append(p.Filters, d.str())
+		}
+	case PINGREQ, DISCONNECT:
+	default:
+		return nil, fmt.Errorf("%w: type %d", ErrUnsupported, p.Type)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return p, nil
+}
+
+func readRemainingLength(r *bufio.Reader) (int, error) {
+	n, mul := 0, 1
+	for i := 0; i < 4; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n += int(b&0x7f) * mul
+		if b&0x80 == 0 {
+			return n, nil
+		}
+		mul *= 128
+	}
+	return 0, ErrMalformed
+}
+
+type decoder struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (d *decoder) need(n int) bool {
+	if d.err != nil || d.off+n > len(d.b) {
+		d.err = ErrMalformed
+		return false
+	}
+	return true
+}
+
+func (d *decoder) byte() byte {
+	if !d.need(1) {
+		return 0
+	}
+	v := d.b[d.off]
+	d.off++
+	return v
+}
+
+func (d *decoder) u16() uint16 {
+	if !d.need(2) {
+		return 0
+	}
+	v := binary.BigEndian.Uint16(d.b[d.off:])
+	d.off += 2
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := int(d.u16())
+	if !d.need(n) {
+		return nil
+	}
+	v := d.b[d.off : d.off+n]
+	d.off += n
+	return v
+}
+
+func (d *decoder) str() string { return string(d.bytes()) }
+
+func encode(typ, flags byte, body []byte) []byte {
+	out := []byte{typ<<4 | flags}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+func appendStr(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// EncodeConnack CONNACK
+func EncodeConnack(sessionPresent bool, code byte) []byte {
+	var sp byte
+	if sessionPresent {
+		sp = 1
+	}
+	return encode(CONNACK, 0, []byte{sp, code})
+}
+
+// EncodePublish PUBLISH；qos=0 时忽略 packetID
+func EncodePublish(topic string, payload []byte, qos byte, packetID uint16, retain bool) []byte {
+	body := appendStr(make([]byte, 0, 2+len(topic)+2+len(payload)), topic)
+	if qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, packetID)
+	}
+	body = append(body, payload...)
+	flags := qos << 1
+	if retain {
+		flags |= 0x01
+	}
+	return encode(PUBLISH, flags, body)
+}
+
+// EncodePuback PUBACK
+func EncodePuback(packetID uint16) []byte {
+	return encode(PUBACK, 0, binary.BigEndian.AppendUint16(nil, packetID))
+}
+
+// EncodeSuback SUBACK
+func EncodeSuback(packetID uint16, codes []byte) []byte {
+	return encode(SUBACK, 0, append(binary.BigEndian.AppendUint16(nil, packetID), codes...))
+}
+
+// EncodeUnsuback UNSUBACK
+func EncodeUnsuback(packetID uint16) []byte {
+	return encode(UNSUBACK, 0, binary.BigEndian.AppendUint16(nil, packetID))
+}
+
+// EncodePingresp PINGRESP
+func EncodePingresp() []byte { return encode(PINGRESP, 0, nil) }
+
+// Match 判断主题是否匹配订阅过滤器（支持 + 与 #）
+func Match(filter, topic string) bool {
+	fs := strings.Split(filter, "/")
+	ts := strings.Split(topic, "/")
+	for i, f := range fs {
+		if f == "#" {
+			return i == len(fs)-1
+		}
+		if i >= len(ts) {
+			return false
+		}
+		if f != "+" && f != ts[i] {
+			return false
+		}
+	}
+	return len(fs) == len(ts)
+}
+
+// ValidFilter 校验订阅过滤器的通配符用法
+func ValidFilter(filter string) bool {
+	if filter == "" {
+		return false
+	}
+	parts := strings.Split(filter, "/")
+	for i, p := range parts {
+		if strings.Contains(p, "#") && (p != "#" || i != len(parts)-1) {
+			return false
+		}
+		if strings.Contains(p, "+") && p != "+" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func read(b []byte, max int) (*Packet, error) {
+	return ReadPacket(bufio.NewReader(bytes.NewReader(b)), max)
+}
+
+// connectBody 按给定标志拼接 CONNECT 可变头与负载
+func connectBody(flags byte, keepAlive uint16, fields ...string) []byte {
+	b := appendStr(nil, "MQTT")
+	b = append(b, 4, flags)
+	b = binary.BigEndian.AppendUint16(b, keepAlive)
+	for _, f := range fields {
+		b = appendStr(b, f)
+	}
+	return b
+}
+
+func TestReadPacket(t *testing.T) {
+	cases := []struct {
+		name string
+		in   []byte
+		want Packet
+	}{
+		{"connect", encode(CONNECT, 0, connectBody(0xC2, 60, "dev-1", "user", "secret")),
+			Packet{Type: CONNECT, ProtocolName: "MQTT", ProtocolLevel: 4, CleanSession: true, KeepAlive: 60, ClientID: "dev-1", Username: "user", Password: []byte("secret")}},
+		{"connect will skipped", encode(CONNECT, 0, connectBody(0x06, 30, "dev-2", "will/topic", "bye")),
+			Packet{Type: CONNECT, ProtocolName: "MQTT", ProtocolLevel: 4, CleanSession: true, KeepAlive: 30, ClientID: "dev-2"}},
+		{"publish qos0", EncodePublish("a/b", []byte("hi"), 0, 0, true),
+			Packet{Type: PUBLISH, Flags: 0x01, Topic: "a/b", Payload: []byte("hi"), Retain: true}},
+		{"publish qos1 dup", encode(PUBLISH, 0x0A, append(binary.BigEndian.AppendUint16(appendStr(nil, "t"), 7), 'x')),
+			Packet{Type: PUBLISH, Flags: 0x0A, Topic: "t", PacketID: 7, Payload: []byte("x"), QoS: 1, Dup: true}},
+		{"publish empty payload", EncodePublish("t", nil, 1, 9, false),
+			Packet{Type: PUBLISH, Flags: 0x02, Topic: "t", PacketID: 9, Payload: []byte{}, QoS: 1}},
+		{"puback", EncodePuback(513), Packet{Type: PUBACK, PacketID: 513}},
+		{"subscribe", encode(SUBSCRIBE, 0x02, append(appendStr(append(appendStr(binary.BigEndian.AppendUint16(nil, 3), "a/+"), 1), "b/#"), 0)),
+			Packet{Type: SUBSCRIBE, Flags: 0x02, PacketID: 3, Filters: []string{"a/+", "b/#"}, QoSs: []byte{1, 0}}},
+		{"unsubscribe", encode(UNSUBSCRIBE, 0x02, appendStr(appendStr(binary.BigEndian.AppendUint16(nil, 4), "a/+"), "c")),
+			Packet{Type: UNSUBSCRIBE, Flags: 0x02, PacketID: 4, Filters: []string{"a/+", "c"}}},
+		{"pingreq", encode(PINGREQ, 0, nil), Packet{Type: PINGREQ}},
+		{"disconnect", encode(DISCONNECT, 0, nil), Packet{Type: DISCONNECT}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := read(tc.in, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tc.want) {
+				t.Fatalf("got %+v\nwant %+v", *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadPacketErrors(t *testing.T) {
+	publish := EncodePublish("a/b", []byte("payload"), 1, 1, false)
+	cases := []struct {
+		name string
+		in   []byte
+		max  int
+		want error
+	}{
+		{"empty", nil, 0, io.EOF},
+		{"missing length", []byte{PUBLISH << 4}, 0, io.EOF},
+		{"truncated length", []byte{PUBLISH << 4, 0x80}, 0, io.EOF},
+		{"length over 4 bytes", []byte{PUBLISH << 4, 0xff, 0xff, 0xff, 0xff, 0x01}, 0, ErrMalformed},
+		{"truncated body", publish[:len(publish)-3], 0, io.ErrUnexpectedEOF},
+		{"too large", publish, 8, ErrPacketTooLarge},
+		{"topic past body", encode(PUBLISH, 0, []byte{0, 9, 'a'}), 0, ErrMalformed},
+		{"missing packet id", encode(PUBLISH, 0x02, appendStr(nil, "t")), 0, ErrMalformed},
+		{"connect without client id", encode(CONNECT, 0, connectBody(0x02, 60)), 0, ErrMalformed},
+		{"connect password missing", encode(CONNECT, 0, connectBody(0x42, 60, "dev")), 0, ErrMalformed},
+		{"subscribe without qos", encode(SUBSCRIBE, 0x02, appendStr(binary.BigEndian.AppendUint16(nil, 1), "a")), 0, ErrMalformed},
+		{"puback short", encode(PUBACK, 0, []byte{1}), 0, ErrMalformed},
+		{"unsupported", encode(SUBACK, 0, []byte{0, 1, 0}), 0, ErrUnsupported},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := read(tc.in, tc.max); !errors.Is(err, tc.want) {
+				t.Fatalf("err %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestRemainingLength(t *testing.T) {
+	// MQTT 3.1.1 §2.2.3 给出的各字节数边界
+	cases := []struct {
+		n   int
+		enc []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{16383, []byte{0xff, 0x7f}},
+		{16384, []byte{0x80, 0x80, 0x01}},
+		{2097151, []byte{0xff, 0xff, 0x7f}},
+		{2097152, []byte{0x80, 0x80, 0x80, 0x01}},
+		{268435455, []byte{0xff, 0xff, 0xff, 0x7f}},
+	}
+	for _, tc := range cases {
+		n, err := readRemainingLength(bufio.NewReader(bytes.NewReader(tc.enc)))
+		if err != nil || n != tc.n {
+			t.Fatalf("decode % x: %d %v, want %d", tc.enc, n, err, tc.n)
+		}
+		if tc.n > 1<<16 {
+			continue // 编码需构造完整报文，大长度只校验解码
+		}
+		if got := encode(PINGREQ, 0, make([]byte, tc.n))[1 : 1+len(tc.enc)]; !bytes.Equal(got, tc.enc) {
+			t.Fatalf("encode %d: % x, want % x", tc.n, got, tc.enc)
+		}
+	}
+}
+
+func TestEncodeAcks(t *testing.T) {
+	cases := []struct {
+		name string
+		got  []byte
+		want []byte
+	}{
+		{"connack", EncodeConnack(true, ConnRefusedBadAuth), []byte{CONNACK << 4, 2, 1, 4}},
+		{"suback", EncodeSuback(258, []byte{1, SubackFailure}), []byte{SUBACK << 4, 4, 1, 2, 1, 0x80}},
+		{"unsuback", EncodeUnsuback(5), []byte{UNSUBACK << 4, 2, 0, 5}},
+		{"pingresp", EncodePingresp(), []byte{PINGRESP << 4, 0}},
+	}
+	for _, tc := range cases {
+		if !bytes.Equal(tc.got, tc.want) {
+			t.Fatalf("%s: % x, want % x", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		filter, topic string
+		want          bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/+", "a/b", true},
+		{"a/+", "a/b/c", false},
+		{"a/#", "a/b/c", true},
+		{"a/#", "a", true},
+		{"#", "a/b", true},
+		{"+/b", "a/c", false},
+		{"a/b", "a", false},
+	}
+	for _, tc := range cases {
+		if got := Match(tc.filter, tc.topic); got != tc.want {
+			t.Fatalf("Match(%q, %q) = %v", tc.filter, tc.topic, got)
+		}
+	}
+	for filter, want := range map[string]bool{"a/+/c": true, "a/#": true, "": false, "a/#/c": false, "a+/b": false, "a/b#": false} {
+		if got := ValidFilter(filter); got != want {
+			t.Fatalf("ValidFilter(%q) = %v", filter, got)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 
 	"gorm.io/gorm"
@@ -28,12 +29,12 @@ type PagedAuditLogs struct {
 	Size  int
 }
 
-func (r *AuditLogRepository) Create(l *database.AuditLog) error {
-	return r.db.Create(l).Error
+func (r *AuditLogRepository) Create(ctx context.Context, l *database.AuditLog) error {
+	return r.db.WithContext(ctx).Create(l).Error
 }
 
-func (r *AuditLogRepository) List(filter AuditListFilter) (*PagedAuditLogs, error) {
-	q := r.db.Model(&database.AuditLog{})
+func (r *AuditLogRepository) List(ctx context.Context, filter AuditListFilter) (*PagedAuditLogs, error) {
+	q := r.db.WithContext(ctx).Model(&database.AuditLog{})
 	if filter.Keyword != "" {
 		like := "%" + filter.Keyword + "%"
 		q = q.Where("action ILIKE ? OR resource ILIKE ? OR ip ILIKE ? OR ua ILIKE ? OR decision ILIKE ?", like, like, like, like, like)
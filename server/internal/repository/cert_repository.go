@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"myflowhub/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// CertRepository 内置 CA 签发记录的存取
+type CertRepository struct{ db *gorm.DB }
+
+// NewCertRepository 创建一个新的 CertRepository
+func NewCertRepository(db *gorm.DB) *CertRepository { return &CertRepository{db: db} }
+
+func (r *CertRepository) Create(ctx context.Context, c *database.DeviceCertificate) error {
+	return r.db.WithContext(ctx).Create(c).Error
+}
+
+// RevokeByDevice 吊销设备名下全部未过期且未吊销的证书，返回被吊销的序列号
+func (r *CertRepository) RevokeByDevice(ctx context.Context, deviceUID uint64, reason string, at time.Time) ([]string, error) {
+	var serials []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Model(&database.DeviceCertificate{}).
+			Where("device_uid = ? AND revoked_at IS NULL AND not_after > ?", deviceUID, at)
+		if err := q.Pluck("serial", &serials).Error; err != nil {
+			return err
+		}
+		if len(serials) == 0 {
+			return nil
+		}
+		return tx.Model(&database.DeviceCertificate{}).Where("serial IN ?", serials).
+			Updates(map[string]any{"revoked_at": at, "revoke_reason": reason}).Error
+	})
+	return serials, err
+}
+
+// ListRevoked 列出已吊销且尚未过期的证书（过期证书握手时本就会被拒绝，无需下发）
+func (r *CertRepository) ListRevoked(ctx context.Context, now time.Time) ([]database.DeviceCertificate, error) {
+	var items []database.DeviceCertificate
+	err := r.db.WithContext(ctx).Where("revoked_at IS NOT NULL AND not_after > ?", now).Order("revoked_at").Find(&items).Error
+	return items, err
+}
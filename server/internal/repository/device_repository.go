@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"myflowhub/pkg/database"
 
 	"gorm.io/gorm"
@@ -17,23 +20,23 @@ func NewDeviceRepository(db *gorm.DB) *DeviceRepository {
 }
 
 // FindAll 返回所有设备
-func (r *DeviceRepository) FindAll() ([]database.Device, error) {
+func (r *DeviceRepository) FindAll(ctx context.Context) ([]database.Device, error) {
 	var devices []database.Device
-	err := r.db.Preload("Parent").Find(&devices).Error
+	err := r.db.WithContext(ctx).Preload("Parent").Find(&devices).Error
 	return devices, err
 }
 
 // ListByOwner 返回指定用户拥有的设备
-func (r *DeviceRepository) ListByOwner(ownerUserID uint64) ([]database.Device, error) {
+func (r *DeviceRepository) ListByOwner(ctx context.Context, ownerUserID uint64) ([]database.Device, error) {
 	var devices []database.Device
-	err := r.db.Where("owner_user_id = ?", ownerUserID).Find(&devices).Error
+	err := r.db.WithContext(ctx).Where("owner_user_id = ?", ownerUserID).Find(&devices).Error
 	return devices, err
 }
 
 // FindByUID 根据 UID 查找设备
-func (r *DeviceRepository) FindByUID(uid uint64) (*database.Device, error) {
+func (r *DeviceRepository) FindByUID(ctx context.Context, uid uint64) (*database.Device, error) {
 	var device database.Device
-	err := r.db.Where("device_uid = ?", uid).First(&device).Error
+	err := r.db.WithContext(ctx).Where("device_uid = ?", uid).First(&device).Error
 	if err != nil {
 		return nil, err
 	}
@@ -41,18 +44,18 @@ func (r *DeviceRepository) FindByUID(uid uint64) (*database.Device, error) {
 }
 
 // FindByID 根据 ID 查找设备
-func (r *DeviceRepository) FindByID(id uint64) (*database.Device, error) {
+func (r *DeviceRepository) FindByID(ctx context.Context, id uint64) (*database.Device, error) {
 	var device database.Device
-	if err := r.db.First(&device, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&device, id).Error; err != nil {
 		return nil, err
 	}
 	return &device, nil
 }
 
 // FindByHardwareID 根据硬件 ID 查找设备
-func (r *DeviceRepository) FindByHardwareID(hid string) (*database.Device, error) {
+func (r *DeviceRepository) FindByHardwareID(ctx context.Context, hid string) (*database.Device, error) {
 	var device database.Device
-	err := r.db.Where("hardware_id = ?", hid).First(&device).Error
+	err := r.db.WithContext(ctx).Where("hardware_id = ?", hid).First(&device).Error
 	if err != nil {
 		return nil, err
 	}
@@ -60,28 +63,66 @@ func (r *DeviceRepository) FindByHardwareID(hid string) (*database.Device, error
 }
 
 // Create 创建一个新设备
-func (r *DeviceRepository) Create(device *database.Device) error {
-	return r.db.Create(device).Error
+func (r *DeviceRepository) Create(ctx context.Context, device *database.Device) error {
+	return r.db.WithContext(ctx).Create(device).Error
 }
 
 // UpdateParentID 更新设备的父级 ID
-func (r *DeviceRepository) UpdateParentID(deviceID, parentID uint64) error {
-	return r.db.Model(&database.Device{}).Where("id = ?", deviceID).Update("parent_id", parentID).Error
+func (r *DeviceRepository) UpdateParentID(ctx context.Context, deviceID, parentID uint64) error {
+	return r.db.WithContext(ctx).Model(&database.Device{}).Where("id = ?", deviceID).Update("parent_id", parentID).Error
 }
 
 // Update 更新设备信息
-func (r *DeviceRepository) Update(device *database.Device) error {
-	return r.db.Save(device).Error
+func (r *DeviceRepository) Update(ctx context.Context, device *database.Device) error {
+	// 标签只经 UpdateTags 修改
+	return r.db.WithContext(ctx).Omit("tags").Save(device).Error
+}
+
+// UpdateTags 设置设备标签（逗号分隔）
+func (r *DeviceRepository) UpdateTags(ctx context.Context, id uint64, tags string) error {
+	return r.db.WithContext(ctx).Model(&database.Device{}).Where("id = ?", id).Update("tags", tags).Error
+}
+
+// UpdatePublicKey 登记/轮换设备公钥
+func (r *DeviceRepository) UpdatePublicKey(ctx context.Context, id uint64, key []byte, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&database.Device{}).Where("id = ?", id).
+		Updates(map[string]any{"public_key": key, "key_updated_at": at}).Error
+}
+
+// UpdateE2EKey 更新设备的端到端加密公钥
+func (r *DeviceRepository) UpdateE2EKey(ctx context.Context, id uint64, key []byte, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&database.Device{}).Where("id = ?", id).
+		Updates(map[string]any{"e2e_public_key": key, "e2e_key_updated_at": at}).Error
+}
+
+// FindE2EKeys 返回指定设备中已发布端到端加密公钥的记录
+func (r *DeviceRepository) FindE2EKeys(ctx context.Context, uids []uint64) ([]database.Device, error) {
+	var devices []database.Device
+	err := r.db.WithContext(ctx).Select("device_uid", "e2e_public_key", "e2e_key_updated_at").
+		Where("device_uid IN ? AND e2e_public_key IS NOT NULL", uids).Find(&devices).Error
+	return devices, err
+}
+
+// UpdateCapabilities 记录设备最近一次连接协商的协议版本与特性
+func (r *DeviceRepository) UpdateCapabilities(ctx context.Context, uid uint64, version uint32, caps string) error {
+	return r.db.WithContext(ctx).Model(&database.Device{}).Where("device_uid = ?", uid).
+		Updates(map[string]any{"protocol_version": version, "capabilities": caps}).Error
+}
+
+// UpdateSecret 写入新密钥哈希及宽限期内的旧密钥（prevHash 为空表示旧密钥立即失效）
+func (r *DeviceRepository) UpdateSecret(ctx context.Context, id uint64, hash, prevHash string, prevUntil *time.Time) error {
+	return r.db.WithContext(ctx).Model(&database.Device{}).Where("id = ?", id).
+		Updates(map[string]any{"secret_key_hash": hash, "prev_secret_key_hash": prevHash, "prev_secret_expires_at": prevUntil}).Error
 }
 
 // Delete 删除设备
-func (r *DeviceRepository) Delete(id uint64) error {
-	return r.db.Delete(&database.Device{}, id).Error
+func (r *DeviceRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&database.Device{}, id).Error
 }
 
 // IsAncestorUID 判断 ancestorUID 是否为 descendantUID 的祖先（或相等时视为可控在上层处理）
-func (r *DeviceRepository) IsAncestorUID(ancestorUID, descendantUID uint64) (bool, error) {
-	desc, err := r.FindByUID(descendantUID)
+func (r *DeviceRepository) IsAncestorUID(ctx context.Context, ancestorUID, descendantUID uint64) (bool, error) {
+	desc, err := r.FindByUID(ctx, descendantUID)
 	if err != nil {
 		return false, err
 	}
@@ -90,7 +131,7 @@ func (r *DeviceRepository) IsAncestorUID(ancestorUID, descendantUID uint64) (boo
 	cur := desc
 	for cur != nil && cur.ParentID != nil && steps < maxSteps {
 		steps++
-		p, err := r.FindByID(*cur.ParentID)
+		p, err := r.FindByID(ctx, *cur.ParentID)
 		if err != nil {
 			break
 		}
@@ -103,8 +144,8 @@ func (r *DeviceRepository) IsAncestorUID(ancestorUID, descendantUID uint64) (boo
 }
 
 // ListDescendantsOfUID 返回某设备 UID 的所有后代（不含自身）
-func (r *DeviceRepository) ListDescendantsOfUID(uid uint64) ([]database.Device, error) {
-	root, err := r.FindByUID(uid)
+func (r *DeviceRepository) ListDescendantsOfUID(ctx context.Context, uid uint64) ([]database.Device, error) {
+	root, err := r.FindByUID(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +157,7 @@ func (r *DeviceRepository) ListDescendantsOfUID(uid uint64) ([]database.Device,
 	for len(frontier) > 0 && levels < maxLevels {
 		levels++
 		var children []database.Device
-		if err := r.db.Where("parent_id IN ?", frontier).Find(&children).Error; err != nil {
+		if err := r.db.WithContext(ctx).Where("parent_id IN ?", frontier).Find(&children).Error; err != nil {
 			return nil, err
 		}
 		if len(children) == 0 {
@@ -131,3 +172,8 @@ func (r *DeviceRepository) ListDescendantsOfUID(uid uint64) ([]database.Device,
 	}
 	return result, nil
 }
+
+// UpdateLastSeen 更新设备最近在线时间
+func (r *DeviceRepository) UpdateLastSeen(ctx context.Context, uid uint64, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&database.Device{}).Where("device_uid = ?", uid).Update("last_seen", at).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"myflowhub/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// DeviceSessionRepository 连接会话历史的存取
+type DeviceSessionRepository struct{ db *gorm.DB }
+
+// NewDeviceSessionRepository 创建一个新的 DeviceSessionRepository
+func NewDeviceSessionRepository(db *gorm.DB) *DeviceSessionRepository {
+	return &DeviceSessionRepository{db: db}
+}
+
+func (r *DeviceSessionRepository) Create(ctx context.Context, s *database.DeviceSession) error {
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+func (r *DeviceSessionRepository) Update(ctx context.Context, id uint64, fields map[string]any) error {
+	return r.db.WithContext(ctx).Model(&database.DeviceSession{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// CloseDangling 关闭指定节点遗留的未结束会话（进程重启前未能写入断开记录）
+func (r *DeviceSessionRepository) CloseDangling(ctx context.Context, hub, reason string, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&database.DeviceSession{}).
+		Where("hub = ? AND ended_at IS NULL", hub).
+		Updates(map[string]any{"ended_at": at, "close_reason": reason})
+	return res.RowsAffected, res.Error
+}
+
+type PagedDeviceSessions struct {
+	Items []database.DeviceSession
+	Total int64
+	Page  int
+	Size  int
+}
+
+// ListByDevice 按开始时间倒序分页返回设备的会话
+func (r *DeviceSessionRepository) ListByDevice(ctx context.Context, deviceUID uint64, page, size int) (*PagedDeviceSessions, error) {
+	q := r.db.WithContext(ctx).Model(&database.DeviceSession{}).Where("device_uid = ?", deviceUID)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 200 {
+		size = 20
+	}
+	var items []database.DeviceSession
+	if err := q.Order("started_at DESC, id DESC").Offset((page - 1) * size).Limit(size).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return &PagedDeviceSessions{Items: items, Total: total, Page: page, Size: size}, nil
+}
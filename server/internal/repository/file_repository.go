@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"myflowhub/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// FileRepository 提供 Hub 侧文件存储记录的访问方法
+type FileRepository struct {
+	db *gorm.DB
+}
+
+// NewFileRepository 创建一个新的 FileRepository
+func NewFileRepository(db *gorm.DB) *FileRepository {
+	return &FileRepository{db: db}
+}
+
+// FindByTransfer 根据上传方与传输 ID 查找记录
+func (r *FileRepository) FindByTransfer(ctx context.Context, ownerDeviceID, transferID uint64) (*database.StoredFile, error) {
+	var f database.StoredFile
+	err := r.db.WithContext(ctx).Where("owner_device_id = ? AND transfer_id = ?", ownerDeviceID, transferID).First(&f).Error
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// FindByID 根据 ID 查找记录
+func (r *FileRepository) FindByID(ctx context.Context, id uint64) (*database.StoredFile, error) {
+	var f database.StoredFile
+	if err := r.db.WithContext(ctx).First(&f, id).Error; err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Create 创建记录
+func (r *FileRepository) Create(ctx context.Context, f *database.StoredFile) error {
+	return r.db.WithContext(ctx).Create(f).Error
+}
+
+// Save 保存记录
+func (r *FileRepository) Save(ctx context.Context, f *database.StoredFile) error {
+	return r.db.WithContext(ctx).Save(f).Error
+}
+
+// UpdateReceived 更新已接收字节数
+func (r *FileRepository) UpdateReceived(ctx context.Context, id, received uint64) error {
+	return r.db.WithContext(ctx).Model(&database.StoredFile{}).Where("id = ?", id).Update("received", received).Error
+}
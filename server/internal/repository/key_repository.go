@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 
 	"gorm.io/gorm"
@@ -10,41 +11,41 @@ type KeyRepository struct{ db *gorm.DB }
 
 func NewKeyRepository(db *gorm.DB) *KeyRepository { return &KeyRepository{db: db} }
 
-func (r *KeyRepository) FindByID(id uint64) (*database.Key, error) {
+func (r *KeyRepository) FindByID(ctx context.Context, id uint64) (*database.Key, error) {
 	var k database.Key
-	if err := r.db.First(&k, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&k, id).Error; err != nil {
 		return nil, err
 	}
 	return &k, nil
 }
 
-func (r *KeyRepository) ListAll() ([]database.Key, error) {
+func (r *KeyRepository) ListAll(ctx context.Context) ([]database.Key, error) {
 	var ks []database.Key
-	err := r.db.Find(&ks).Error
+	err := r.db.WithContext(ctx).Find(&ks).Error
 	return ks, err
 }
 
-func (r *KeyRepository) ListByOwner(ownerID uint64) ([]database.Key, error) {
+func (r *KeyRepository) ListByOwner(ctx context.Context, ownerID uint64) ([]database.Key, error) {
 	var ks []database.Key
-	err := r.db.Where("owner_user_id = ?", ownerID).Find(&ks).Error
+	err := r.db.WithContext(ctx).Where("owner_user_id = ?", ownerID).Find(&ks).Error
 	return ks, err
 }
 
-func (r *KeyRepository) Create(k *database.Key) error {
-	return r.db.Create(k).Error
+func (r *KeyRepository) Create(ctx context.Context, k *database.Key) error {
+	return r.db.WithContext(ctx).Create(k).Error
 }
 
-func (r *KeyRepository) Update(k *database.Key) error {
-	return r.db.Save(k).Error
+func (r *KeyRepository) Update(ctx context.Context, k *database.Key) error {
+	return r.db.WithContext(ctx).Save(k).Error
 }
 
-func (r *KeyRepository) Delete(id uint64) error {
-	return r.db.Delete(&database.Key{}, id).Error
+func (r *KeyRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&database.Key{}, id).Error
 }
 
-func (r *KeyRepository) FindBySecretHash(hash string) (*database.Key, error) {
+func (r *KeyRepository) FindBySecretHash(ctx context.Context, hash string) (*database.Key, error) {
 	var k database.Key
-	if err := r.db.Where("secret_hash = ? AND revoked = FALSE", hash).First(&k).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("secret_hash = ? AND revoked = FALSE", hash).First(&k).Error; err != nil {
 		return nil, err
 	}
 	return &k, nil
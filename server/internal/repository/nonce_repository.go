@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"myflowhub/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NonceRepository 已使用 nonce 的存取（唯一索引保证多个 Hub 进程间只有一次插入成功）
+type NonceRepository struct{ db *gorm.DB }
+
+// NewNonceRepository 创建一个新的 NonceRepository
+func NewNonceRepository(db *gorm.DB) *NonceRepository { return &NonceRepository{db: db} }
+
+// Insert 记录 nonce；已存在（重放）时返回 false
+func (r *NonceRepository) Insert(ctx context.Context, scope, value string, expiresAt time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&database.UsedNonce{Scope: scope, Value: value, ExpiresAt: expiresAt})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// DeleteExpired 删除已过期的记录
+func (r *NonceRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&database.UsedNonce{})
+	return res.RowsAffected, res.Error
+}
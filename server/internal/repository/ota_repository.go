@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"myflowhub/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// OTARepository 提供固件制品、升级活动与设备升级状态的访问方法
+type OTARepository struct {
+	db *gorm.DB
+}
+
+// NewOTARepository 创建一个新的 OTARepository
+func NewOTARepository(db *gorm.DB) *OTARepository {
+	return &OTARepository{db: db}
+}
+
+// CreateArtifact 创建固件制品
+func (r *OTARepository) CreateArtifact(ctx context.Context, a *database.FirmwareArtifact) error {
+	return r.db.WithContext(ctx).Create(a).Error
+}
+
+// FindArtifact 根据 ID 查找固件制品
+func (r *OTARepository) FindArtifact(ctx context.Context, id uint64) (*database.FirmwareArtifact, error) {
+	var a database.FirmwareArtifact
+	if err := r.db.WithContext(ctx).First(&a, id).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListArtifacts 返回全部固件制品（新的在前）
+func (r *OTARepository) ListArtifacts(ctx context.Context) ([]database.FirmwareArtifact, error) {
+	var items []database.FirmwareArtifact
+	err := r.db.WithContext(ctx).Order("id DESC").Find(&items).Error
+	return items, err
+}
+
+// CreateCampaign 在事务中创建活动及其设备状态
+func (r *OTARepository) CreateCampaign(ctx context.Context, c *database.OTACampaign, states []database.OTADeviceState) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c).Error; err != nil {
+			return err
+		}
+		for i := range states {
+			states[i].CampaignID = c.ID
+		}
+		if len(states) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(states, 200).Error
+	})
+}
+
+// FindCampaign 根据 ID 查找活动
+func (r *OTARepository) FindCampaign(ctx context.Context, id uint64) (*database.OTACampaign, error) {
+	var c database.OTACampaign
+	if err := r.db.WithContext(ctx).First(&c, id).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCampaigns 返回全部活动（新的在前）
+func (r *OTARepository) ListCampaigns(ctx context.Context) ([]database.OTACampaign, error) {
+	var items []database.OTACampaign
+	err := r.db.WithContext(ctx).Order("id DESC").Find(&items).Error
+	return items, err
+}
+
+// ListCampaignsByStatus 返回指定状态的活动
+func (r *OTARepository) ListCampaignsByStatus(ctx context.Context, status string) ([]database.OTACampaign, error) {
+	var items []database.OTACampaign
+	err := r.db.WithContext(ctx).Where("status = ?", status).Find(&items).Error
+	return items, err
+}
+
+// SaveCampaign 保存活动
+func (r *OTARepository) SaveCampaign(ctx context.Context, c *database.OTACampaign) error {
+	return r.db.WithContext(ctx).Save(c).Error
+}
+
+// ListStates 返回活动内全部设备状态
+func (r *OTARepository) ListStates(ctx context.Context, campaignID uint64) ([]database.OTADeviceState, error) {
+	var items []database.OTADeviceState
+	err := r.db.WithContext(ctx).Where("campaign_id = ?", campaignID).Order("stage, device_uid").Find(&items).Error
+	return items, err
+}
+
+// SaveState 保存设备状态
+func (r *OTARepository) SaveState(ctx context.Context, s *database.OTADeviceState) error {
+	return r.db.WithContext(ctx).Save(s).Error
+}
+
+// CountStates 按状态统计活动内设备数
+func (r *OTARepository) CountStates(ctx context.Context, campaignID uint64) (map[string]int64, error) {
+	var rows []struct {
+		State string
+		N     int64
+	}
+	err := r.db.WithContext(ctx).Model(&database.OTADeviceState{}).Select("state, COUNT(*) AS n").
+		Where("campaign_id = ?", campaignID).Group("state").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		out[row.State] = row.N
+	}
+	return out, nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 
 	"gorm.io/gorm"
@@ -10,33 +11,33 @@ type PermissionRepository struct{ db *gorm.DB }
 
 func NewPermissionRepository(db *gorm.DB) *PermissionRepository { return &PermissionRepository{db: db} }
 
-func (r *PermissionRepository) ListByUserID(userID uint64) ([]database.Permission, error) {
+func (r *PermissionRepository) ListByUserID(ctx context.Context, userID uint64) ([]database.Permission, error) {
 	var ps []database.Permission
-	err := r.db.Where("subject_type = ? AND subject_id = ?", "user", userID).Find(&ps).Error
+	err := r.db.WithContext(ctx).Where("subject_type = ? AND subject_id = ?", "user", userID).Find(&ps).Error
 	return ps, err
 }
 
-func (r *PermissionRepository) AddUserNode(userID uint64, node string, createdBy *uint64) error {
+func (r *PermissionRepository) AddUserNode(ctx context.Context, userID uint64, node string, createdBy *uint64) error {
 	p := &database.Permission{SubjectType: "user", SubjectID: userID, Node: node, CreatedBy: createdBy}
-	return r.db.Create(p).Error
+	return r.db.WithContext(ctx).Create(p).Error
 }
 
-func (r *PermissionRepository) RemoveUserNode(userID uint64, node string) error {
-	return r.db.Where("subject_type = ? AND subject_id = ? AND node = ?", "user", userID, node).Delete(&database.Permission{}).Error
+func (r *PermissionRepository) RemoveUserNode(ctx context.Context, userID uint64, node string) error {
+	return r.db.WithContext(ctx).Where("subject_type = ? AND subject_id = ? AND node = ?", "user", userID, node).Delete(&database.Permission{}).Error
 }
 
 // Key-scoped permissions
-func (r *PermissionRepository) ListByKeyID(keyID uint64) ([]database.Permission, error) {
+func (r *PermissionRepository) ListByKeyID(ctx context.Context, keyID uint64) ([]database.Permission, error) {
 	var ps []database.Permission
-	err := r.db.Where("subject_type = ? AND subject_id = ?", "key", keyID).Find(&ps).Error
+	err := r.db.WithContext(ctx).Where("subject_type = ? AND subject_id = ?", "key", keyID).Find(&ps).Error
 	return ps, err
 }
 
-func (r *PermissionRepository) AddKeyNode(keyID uint64, node string, createdBy *uint64) error {
+func (r *PermissionRepository) AddKeyNode(ctx context.Context, keyID uint64, node string, createdBy *uint64) error {
 	p := &database.Permission{SubjectType: "key", SubjectID: keyID, Node: node, CreatedBy: createdBy}
-	return r.db.Create(p).Error
+	return r.db.WithContext(ctx).Create(p).Error
 }
 
-func (r *PermissionRepository) RemoveKeyNode(keyID uint64, node string) error {
-	return r.db.Where("subject_type = ? AND subject_id = ? AND node = ?", "key", keyID, node).Delete(&database.Permission{}).Error
+func (r *PermissionRepository) RemoveKeyNode(ctx context.Context, keyID uint64, node string) error {
+	return r.db.WithContext(ctx).Where("subject_type = ? AND subject_id = ? AND node = ?", "key", keyID, node).Delete(&database.Permission{}).Error
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 
 	"gorm.io/gorm"
@@ -27,12 +28,12 @@ type PagedSystemLogs struct {
 	Size  int
 }
 
-func (r *SystemLogRepository) Create(l *database.SystemLog) error {
-	return r.db.Create(l).Error
+func (r *SystemLogRepository) Create(ctx context.Context, l *database.SystemLog) error {
+	return r.db.WithContext(ctx).Create(l).Error
 }
 
-func (r *SystemLogRepository) List(filter SystemLogFilter) (*PagedSystemLogs, error) {
-	q := r.db.Model(&database.SystemLog{})
+func (r *SystemLogRepository) List(ctx context.Context, filter SystemLogFilter) (*PagedSystemLogs, error) {
+	q := r.db.WithContext(ctx).Model(&database.SystemLog{})
 	if filter.Level != "" {
 		q = q.Where("level = ?", filter.Level)
 	}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"myflowhub/pkg/database"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// TwinRepository 提供设备孪生的访问方法
+type TwinRepository struct {
+	db *gorm.DB
+}
+
+// NewTwinRepository 创建一个新的 TwinRepository
+func NewTwinRepository(db *gorm.DB) *TwinRepository {
+	return &TwinRepository{db: db}
+}
+
+// FindOrCreate 获取设备孪生，不存在时创建空文档
+func (r *TwinRepository) FindOrCreate(ctx context.Context, deviceUID uint64) (*database.DeviceTwin, error) {
+	var t database.DeviceTwin
+	err := r.db.WithContext(ctx).Where("device_uid = ?", deviceUID).First(&t).Error
+	if err == nil {
+		return &t, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	t = database.DeviceTwin{DeviceUID: deviceUID, Desired: datatypes.JSON("{}"), Reported: datatypes.JSON("{}")}
+	if err := r.db.WithContext(ctx).Create(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// UpdateDesired 以版本号做乐观并发控制写入 desired；版本不匹配时返回 false
+func (r *TwinRepository) UpdateDesired(ctx context.Context, deviceUID, fromVersion uint64, doc datatypes.JSON) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&database.DeviceTwin{}).
+		Where("device_uid = ? AND desired_version = ?", deviceUID, fromVersion).
+		Updates(map[string]any{"desired": doc, "desired_version": fromVersion + 1})
+	return res.RowsAffected == 1, res.Error
+}
+
+// UpdateReported 以版本号做乐观并发控制写入 reported；版本不匹配时返回 false
+func (r *TwinRepository) UpdateReported(ctx context.Context, deviceUID, fromVersion uint64, doc datatypes.JSON) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&database.DeviceTwin{}).
+		Where("device_uid = ? AND reported_version = ?", deviceUID, fromVersion).
+		Updates(map[string]any{"reported": doc, "reported_version": fromVersion + 1})
+	return res.RowsAffected == 1, res.Error
+}
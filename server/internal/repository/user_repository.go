@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 
 	"gorm.io/gorm"
@@ -14,36 +15,36 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) FindAll() ([]database.User, error) {
+func (r *UserRepository) FindAll(ctx context.Context) ([]database.User, error) {
 	var users []database.User
-	err := r.db.Find(&users).Error
+	err := r.db.WithContext(ctx).Find(&users).Error
 	return users, err
 }
 
-func (r *UserRepository) FindByID(id uint64) (*database.User, error) {
+func (r *UserRepository) FindByID(ctx context.Context, id uint64) (*database.User, error) {
 	var u database.User
-	if err := r.db.First(&u, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&u, id).Error; err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
-func (r *UserRepository) FindByUsername(username string) (*database.User, error) {
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*database.User, error) {
 	var u database.User
-	if err := r.db.Where("username = ?", username).First(&u).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&u).Error; err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
-func (r *UserRepository) Create(u *database.User) error {
-	return r.db.Create(u).Error
+func (r *UserRepository) Create(ctx context.Context, u *database.User) error {
+	return r.db.WithContext(ctx).Create(u).Error
 }
 
-func (r *UserRepository) Update(u *database.User) error {
-	return r.db.Save(u).Error
+func (r *UserRepository) Update(ctx context.Context, u *database.User) error {
+	return r.db.WithContext(ctx).Save(u).Error
 }
 
-func (r *UserRepository) Delete(id uint64) error {
-	return r.db.Delete(&database.User{}, id).Error
+func (r *UserRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&database.User{}, id).Error
 }
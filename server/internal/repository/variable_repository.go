@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 	"strings"
 
@@ -18,16 +19,16 @@ func NewVariableRepository(db *gorm.DB) *VariableRepository {
 }
 
 // FindByDeviceID 根据设备 ID 查找变量
-func (r *VariableRepository) FindByDeviceID(deviceID uint64) ([]database.DeviceVariable, error) {
+func (r *VariableRepository) FindByDeviceID(ctx context.Context, deviceID uint64) ([]database.DeviceVariable, error) {
 	var variables []database.DeviceVariable
-	err := r.db.Where("owner_device_id = ?", deviceID).Find(&variables).Error
+	err := r.db.WithContext(ctx).Where("owner_device_id = ?", deviceID).Find(&variables).Error
 	return variables, err
 }
 
 // FindByOwnerAndName 根据所有者和变量名查找变量
-func (r *VariableRepository) FindByOwnerAndName(ownerID uint64, name string) (*database.DeviceVariable, error) {
+func (r *VariableRepository) FindByOwnerAndName(ctx context.Context, ownerID uint64, name string) (*database.DeviceVariable, error) {
 	var variable database.DeviceVariable
-	err := r.db.Where("owner_device_id = ? AND variable_name = ?", ownerID, name).First(&variable).Error
+	err := r.db.WithContext(ctx).Where("owner_device_id = ? AND variable_name = ?", ownerID, name).First(&variable).Error
 	if err != nil {
 		return nil, err
 	}
@@ -35,37 +36,37 @@ func (r *VariableRepository) FindByOwnerAndName(ownerID uint64, name string) (*d
 }
 
 // Upsert 更新或创建变量
-func (r *VariableRepository) Upsert(variable *database.DeviceVariable) error {
-	return r.db.Where("owner_device_id = ? AND variable_name = ?", variable.OwnerDeviceID, variable.VariableName).
+func (r *VariableRepository) Upsert(ctx context.Context, variable *database.DeviceVariable) error {
+	return r.db.WithContext(ctx).Where("owner_device_id = ? AND variable_name = ?", variable.OwnerDeviceID, variable.VariableName).
 		Assign(database.DeviceVariable{Value: variable.Value}).
 		FirstOrCreate(variable).Error
 }
 
 // Delete 删除变量
-func (r *VariableRepository) Delete(ownerID uint64, name string) error {
-	return r.db.Where("owner_device_id = ? AND variable_name = ?", ownerID, name).Delete(&database.DeviceVariable{}).Error
+func (r *VariableRepository) Delete(ctx context.Context, ownerID uint64, name string) error {
+	return r.db.WithContext(ctx).Where("owner_device_id = ? AND variable_name = ?", ownerID, name).Delete(&database.DeviceVariable{}).Error
 }
 
 // FindAll 返回所有变量
-func (r *VariableRepository) FindAll() ([]database.DeviceVariable, error) {
+func (r *VariableRepository) FindAll(ctx context.Context) ([]database.DeviceVariable, error) {
 	var variables []database.DeviceVariable
-	err := r.db.Preload("Device").Find(&variables).Error
+	err := r.db.WithContext(ctx).Preload("Device").Find(&variables).Error
 	return variables, err
 }
 
 // FindByDeviceUIDAndVarName 根据设备 UID 和变量名查找
-func (r *VariableRepository) FindByDeviceUIDAndVarName(deviceUID, varName string) (*database.DeviceVariable, error) {
+func (r *VariableRepository) FindByDeviceUIDAndVarName(ctx context.Context, deviceUID, varName string) (*database.DeviceVariable, error) {
 	var targetDevice database.Device
 	var err error
 
 	if strings.HasPrefix(deviceUID, "[") && strings.HasSuffix(deviceUID, "]") {
-		err = r.db.Where("device_uid = ?", strings.Trim(deviceUID, "[]")).First(&targetDevice).Error
+		err = r.db.WithContext(ctx).Where("device_uid = ?", strings.Trim(deviceUID, "[]")).First(&targetDevice).Error
 	} else {
-		err = r.db.Where("name = ?", strings.Trim(deviceUID, "()")).First(&targetDevice).Error
+		err = r.db.WithContext(ctx).Where("name = ?", strings.Trim(deviceUID, "()")).First(&targetDevice).Error
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	return r.FindByOwnerAndName(targetDevice.ID, varName)
+	return r.FindByOwnerAndName(ctx, targetDevice.ID, varName)
 }
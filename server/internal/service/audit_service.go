@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"time"
 
 	"myflowhub/pkg/database"
@@ -16,15 +17,15 @@ func NewAuditService(repo *repository.AuditLogRepository, keySvc *KeyService) *A
 	return &AuditService{repo: repo, keySvc: keySvc}
 }
 
-func (s *AuditService) Write(subjectType string, subjectID *uint64, action, resource, decision, ip, ua string, extraJSON []byte) error {
+func (s *AuditService) Write(ctx context.Context, subjectType string, subjectID *uint64, action, resource, decision, ip, ua string, extraJSON []byte) error {
 	l := &database.AuditLog{SubjectType: subjectType, SubjectID: subjectID, Action: action, Resource: resource, Decision: decision, IP: ip, UA: ua, At: time.Now()}
 	if len(extraJSON) > 0 {
 		l.Extra = extraJSON
 	}
-	return s.repo.Create(l)
+	return s.repo.Create(ctx, l)
 }
 
 // List 返回分页日志；需要在调用方做权限控制
-func (s *AuditService) List(filter repository.AuditListFilter) (*repository.PagedAuditLogs, error) {
-	return s.repo.List(filter)
+func (s *AuditService) List(ctx context.Context, filter repository.AuditListFilter) (*repository.PagedAuditLogs, error) {
+	return s.repo.List(ctx, filter)
 }
@@ -1,10 +1,17 @@
 package service
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"myflowhub/pkg/config"
 	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
 	"myflowhub/server/internal/repository"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -24,27 +31,92 @@ func NewAuthService(deviceRepo *repository.DeviceRepository, variableRepo *repos
 	}
 }
 
-// AuthenticateDevice 认证一个常规设备
-func (s *AuthService) AuthenticateDevice(deviceID uint64, secretKey string) (*database.Device, bool) {
-	device, err := s.deviceRepo.FindByUID(deviceID)
+// ErrDeviceKey 公钥认证失败（设备不存在、未登记公钥或签名无效，对外不作区分）
+var ErrDeviceKey = errors.New("device key authentication failed")
+
+// AuthenticateDevice 以设备密钥认证一个常规设备（DeviceAuth.DisableSecret 时一律拒绝）
+func (s *AuthService) AuthenticateDevice(ctx context.Context, deviceID uint64, secretKey string) (*database.Device, bool) {
+	device, err := s.deviceRepo.FindByUID(ctx, deviceID)
 	if err != nil {
 		return nil, false
 	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(device.SecretKeyHash), []byte(secretKey)); err != nil {
+	if !CheckDeviceSecret(device, secretKey) {
 		return nil, false
 	}
-
 	return device, true
 }
 
+// CheckDeviceSecret 校验设备密钥（轮换宽限期内亦接受旧密钥）；DeviceAuth.DisableSecret 时一律返回 false
+func CheckDeviceSecret(device *database.Device, secretKey string) bool {
+	if config.AppConfig.DeviceAuth.DisableSecret || secretKey == "" {
+		return false
+	}
+	if device.SecretKeyHash != "" && bcrypt.CompareHashAndPassword([]byte(device.SecretKeyHash), []byte(secretKey)) == nil {
+		return true
+	}
+	return device.PrevSecretKeyHash != "" && device.PrevSecretExpiresAt != nil && time.Now().Before(*device.PrevSecretExpiresAt) &&
+		bcrypt.CompareHashAndPassword([]byte(device.PrevSecretKeyHash), []byte(secretKey)) == nil
+}
+
+// RotateDeviceSecret 为设备生成新密钥；grace > 0 时旧密钥在宽限期内继续有效，返回新密钥明文与宽限截止时间
+func (s *AuthService) RotateDeviceSecret(ctx context.Context, device *database.Device, grace time.Duration) (string, *time.Time, error) {
+	secret := RandomSecret()
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, err
+	}
+	var prevHash string
+	var until *time.Time
+	if grace > 0 && device.SecretKeyHash != "" {
+		t := time.Now().Add(grace)
+		prevHash, until = device.SecretKeyHash, &t
+	}
+	if err := s.deviceRepo.UpdateSecret(ctx, device.ID, string(hash), prevHash, until); err != nil {
+		return "", nil, err
+	}
+	return secret, until, nil
+}
+
+// AuthenticateDeviceKey 校验设备对挑战 nonce（及帧认证公钥，若提供）的 Ed25519 签名
+func (s *AuthService) AuthenticateDeviceKey(ctx context.Context, hardwareID string, nonce, sig, frameMACPub []byte) (*database.Device, error) {
+	device, err := s.deviceRepo.FindByHardwareID(ctx, hardwareID)
+	if err != nil || len(device.PublicKey) != ed25519.PublicKeySize {
+		return nil, ErrDeviceKey
+	}
+	if !ed25519.Verify(device.PublicKey, bin.DeviceAuthMessage(nonce, hardwareID, frameMACPub), sig) {
+		return nil, ErrDeviceKey
+	}
+	return device, nil
+}
+
+// VerifyKeyRotation 校验旧私钥对新公钥的签名
+func VerifyKeyRotation(device *database.Device, newKey, oldKeySig []byte) bool {
+	return len(device.PublicKey) == ed25519.PublicKeySize &&
+		ed25519.Verify(device.PublicKey, bin.DeviceKeyRotateMessage(device.DeviceUID, newKey), oldKeySig)
+}
+
+// SetDevicePublicKey 登记/轮换设备公钥（调用方负责校验凭据）
+func (s *AuthService) SetDevicePublicKey(ctx context.Context, device *database.Device, key []byte) error {
+	if len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid public key")
+	}
+	return s.deviceRepo.UpdatePublicKey(ctx, device.ID, key, time.Now())
+}
+
+// RandomSecret 生成随机设备密钥（十六进制）
+func RandomSecret() string {
+	var b [24]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
 // AuthenticateManager 认证一个管理员节点
-func (s *AuthService) AuthenticateManager(token string) (*database.Device, bool) {
+func (s *AuthService) AuthenticateManager(ctx context.Context, token string) (*database.Device, bool) {
 	if token != config.AppConfig.Server.ManagerToken {
 		return nil, false
 	}
 
-	managerDevice, err := s.deviceRepo.FindByHardwareID("manager")
+	managerDevice, err := s.deviceRepo.FindByHardwareID(ctx, "manager")
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			hashedSecret, _ := bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
@@ -55,13 +127,13 @@ func (s *AuthService) AuthenticateManager(token string) (*database.Device, bool)
 				Approved:      true,
 				Name:          "System Manager",
 			}
-			if err := s.deviceRepo.Create(newManagerDevice); err != nil {
+			if err := s.deviceRepo.Create(ctx, newManagerDevice); err != nil {
 				return nil, false
 			}
 			if newManagerDevice.DeviceUID == 0 {
 				// 确保有非零 UID（某些数据库/迁移环境可能未自动分配）
 				newManagerDevice.DeviceUID = 10001
-				_ = s.deviceRepo.Update(newManagerDevice)
+				_ = s.deviceRepo.Update(ctx, newManagerDevice)
 			}
 			return newManagerDevice, true
 		}
@@ -69,23 +141,23 @@ func (s *AuthService) AuthenticateManager(token string) (*database.Device, bool)
 	}
 	if managerDevice.DeviceUID == 0 {
 		managerDevice.DeviceUID = 10001
-		_ = s.deviceRepo.Update(managerDevice)
+		_ = s.deviceRepo.Update(ctx, managerDevice)
 	}
 	if !managerDevice.Approved {
 		managerDevice.Approved = true
-		_ = s.deviceRepo.Update(managerDevice)
+		_ = s.deviceRepo.Update(ctx, managerDevice)
 	}
 	return managerDevice, true
 }
 
 // RegisterDevice 注册一个新设备
-func (s *AuthService) RegisterDevice(hardwareID string) (*database.Device, string, bool) {
-	_, err := s.deviceRepo.FindByHardwareID(hardwareID)
+func (s *AuthService) RegisterDevice(ctx context.Context, hardwareID string) (*database.Device, string, bool) {
+	_, err := s.deviceRepo.FindByHardwareID(ctx, hardwareID)
 	if err == nil {
 		return nil, "", false // 设备已存在
 	}
 
-	secretKey := "default-secret" // 在实际应用中应随机生成
+	secretKey := RandomSecret()
 	hashedSecret, _ := bcrypt.GenerateFromPassword([]byte(secretKey), bcrypt.DefaultCost)
 	newDevice := &database.Device{
 		HardwareID:    hardwareID,
@@ -94,7 +166,7 @@ func (s *AuthService) RegisterDevice(hardwareID string) (*database.Device, strin
 		Name:          hardwareID,
 	}
 
-	if err := s.deviceRepo.Create(newDevice); err != nil {
+	if err := s.deviceRepo.Create(ctx, newDevice); err != nil {
 		return nil, "", false
 	}
 
@@ -102,13 +174,13 @@ func (s *AuthService) RegisterDevice(hardwareID string) (*database.Device, strin
 }
 
 // GetInitialVariablesForDevice 获取设备上线时的初始变量
-func (s *AuthService) GetInitialVariablesForDevice(deviceUID uint64) (map[string]interface{}, error) {
-	device, err := s.deviceRepo.FindByUID(deviceUID)
+func (s *AuthService) GetInitialVariablesForDevice(ctx context.Context, deviceUID uint64) (map[string]interface{}, error) {
+	device, err := s.deviceRepo.FindByUID(ctx, deviceUID)
 	if err != nil {
 		return nil, err
 	}
 
-	variables, err := s.variableRepo.FindByDeviceID(device.ID)
+	variables, err := s.variableRepo.FindByDeviceID(ctx, device.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	"myflowhub/server/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+func mustHash(t *testing.T, secret string) string {
+	t.Helper()
+	h, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(h)
+}
+
+func TestCheckDeviceSecretGrace(t *testing.T) {
+	cur, prev := mustHash(t, "new"), mustHash(t, "old")
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Second)
+	cases := []struct {
+		name   string
+		device database.Device
+		secret string
+		want   bool
+	}{
+		{"current", database.Device{SecretKeyHash: cur}, "new", true},
+		{"wrong", database.Device{SecretKeyHash: cur}, "nope", false},
+		{"empty", database.Device{SecretKeyHash: cur}, "", false},
+		{"old within grace", database.Device{SecretKeyHash: cur, PrevSecretKeyHash: prev, PrevSecretExpiresAt: &future}, "old", true},
+		{"new within grace", database.Device{SecretKeyHash: cur, PrevSecretKeyHash: prev, PrevSecretExpiresAt: &future}, "new", true},
+		{"old after grace", database.Device{SecretKeyHash: cur, PrevSecretKeyHash: prev, PrevSecretExpiresAt: &past}, "old", false},
+		{"old without expiry", database.Device{SecretKeyHash: cur, PrevSecretKeyHash: prev}, "old", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CheckDeviceSecret(&tc.device, tc.secret); got != tc.want {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	prevCfg := config.AppConfig.DeviceAuth.DisableSecret
+	t.Cleanup(func() { config.AppConfig.DeviceAuth.DisableSecret = prevCfg })
+	config.AppConfig.DeviceAuth.DisableSecret = true
+	if CheckDeviceSecret(&database.Device{SecretKeyHash: cur}, "new") {
+		t.Fatal("secret accepted with DisableSecret")
+	}
+}
+
+// rotateAndPersist 执行一次轮换，并按写入数据库的列（DryRun 捕获）还原设备凭据
+func rotateAndPersist(t *testing.T, device database.Device, grace time.Duration) (database.Device, string, *time.Time) {
+	t.Helper()
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cols map[string]any
+	_ = db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		cols, _ = tx.Statement.Dest.(map[string]any)
+	})
+	s := NewAuthService(repository.NewDeviceRepository(db), nil)
+	secret, until, err := s.RotateDeviceSecret(context.Background(), &device, grace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cols == nil {
+		t.Fatal("no update issued")
+	}
+	device.SecretKeyHash = cols["secret_key_hash"].(string)
+	device.PrevSecretKeyHash = cols["prev_secret_key_hash"].(string)
+	device.PrevSecretExpiresAt = cols["prev_secret_expires_at"].(*time.Time)
+	return device, secret, until
+}
+
+func TestRotateDeviceSecret(t *testing.T) {
+	old := database.Device{ID: 1, DeviceUID: 10001, SecretKeyHash: mustHash(t, "old")}
+
+	t.Run("grace keeps old secret", func(t *testing.T) {
+		dev, secret, until := rotateAndPersist(t, old, time.Hour)
+		if until == nil || time.Until(*until) > time.Hour || time.Until(*until) < time.Hour-time.Minute {
+			t.Fatalf("grace until %v", until)
+		}
+		if secret == "" || !CheckDeviceSecret(&dev, secret) || !CheckDeviceSecret(&dev, "old") {
+			t.Fatal("both secrets should work during grace")
+		}
+		expired := until.Add(-2 * time.Hour)
+		dev.PrevSecretExpiresAt = &expired
+		if CheckDeviceSecret(&dev, "old") || !CheckDeviceSecret(&dev, secret) {
+			t.Fatal("old secret accepted after grace")
+		}
+	})
+
+	t.Run("force revokes old secret", func(t *testing.T) {
+		dev, secret, until := rotateAndPersist(t, old, 0)
+		if until != nil || dev.PrevSecretKeyHash != "" {
+			t.Fatalf("forced rotation kept grace %v %q", until, dev.PrevSecretKeyHash)
+		}
+		if !CheckDeviceSecret(&dev, secret) || CheckDeviceSecret(&dev, "old") {
+			t.Fatal("forced rotation should accept only the new secret")
+		}
+	})
+
+	t.Run("no previous secret", func(t *testing.T) {
+		// 公钥认证的设备没有旧密钥，宽限无从保留
+		dev, secret, until := rotateAndPersist(t, database.Device{ID: 2, DeviceUID: 10002}, time.Hour)
+		if until != nil || !CheckDeviceSecret(&dev, secret) {
+			t.Fatalf("grace %v", until)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 	"myflowhub/server/internal/repository"
 )
@@ -17,12 +18,12 @@ func NewAuthzService(keySvc *KeyService, deviceRepo *repository.DeviceRepository
 }
 
 // ResolveUserIDFromKey 根据用户密钥解析用户ID（验证有效性与剩余次数）
-func (a *AuthzService) ResolveUserIDFromKey(userKey string) (uint64, bool) {
+func (a *AuthzService) ResolveUserIDFromKey(ctx context.Context, userKey string) (uint64, bool) {
 	if userKey == "" {
 		return 0, false
 	}
 	// 使用非消耗式校验，避免列表/查询等高频接口迅速耗尽可用次数
-	uid, _, err := a.keySvc.PeekUserKey(userKey)
+	uid, _, err := a.keySvc.PeekUserKey(ctx, userKey)
 	if err != nil {
 		return 0, false
 	}
@@ -34,14 +35,14 @@ func (a *AuthzService) ResolveUserIDFromKey(userKey string) (uint64, bool) {
 // 1) 用户权限：若用户具有 admin.manage 或 **，允许
 // 2) 所有权：若目标设备归该用户所有，允许
 // 3) 设备默认：请求设备为目标或其祖先（可控制自身与子设备），允许
-func (a *AuthzService) CanControlDevice(requesterDeviceUID, targetDeviceUID uint64, userID uint64) bool {
+func (a *AuthzService) CanControlDevice(ctx context.Context, requesterDeviceUID, targetDeviceUID uint64, userID uint64) bool {
 	// 用户权限：管理员
-	if userID != 0 && a.keySvc.IsKeyManagerUser(userID) {
+	if userID != 0 && a.keySvc.IsKeyManagerUser(ctx, userID) {
 		return true
 	}
 	// 所有权
 	if userID != 0 {
-		if d, err := a.deviceRepo.FindByUID(targetDeviceUID); err == nil {
+		if d, err := a.deviceRepo.FindByUID(ctx, targetDeviceUID); err == nil {
 			if d.OwnerUserID != nil && *d.OwnerUserID == userID {
 				return true
 			}
@@ -52,7 +53,7 @@ func (a *AuthzService) CanControlDevice(requesterDeviceUID, targetDeviceUID uint
 		if requesterDeviceUID == targetDeviceUID {
 			return true
 		}
-		ok, _ := a.deviceRepo.IsAncestorUID(requesterDeviceUID, targetDeviceUID)
+		ok, _ := a.deviceRepo.IsAncestorUID(ctx, requesterDeviceUID, targetDeviceUID)
 		if ok {
 			return true
 		}
@@ -62,23 +63,23 @@ func (a *AuthzService) CanControlDevice(requesterDeviceUID, targetDeviceUID uint
 
 // VisibleDevices 返回对请求方可见的设备集合
 // 用户为管理员：全部；否则：用户拥有的设备 + （如为设备请求）该设备及其子设备
-func (a *AuthzService) VisibleDevices(userID, requesterDeviceUID uint64) ([]database.Device, error) {
-	if userID != 0 && a.keySvc.IsKeyManagerUser(userID) {
-		return a.deviceRepo.FindAll()
+func (a *AuthzService) VisibleDevices(ctx context.Context, userID, requesterDeviceUID uint64) ([]database.Device, error) {
+	if userID != 0 && a.keySvc.IsKeyManagerUser(ctx, userID) {
+		return a.deviceRepo.FindAll(ctx)
 	}
 	result := make([]database.Device, 0)
 	// 加入用户拥有的设备
 	if userID != 0 {
-		if ds, err := a.deviceRepo.ListByOwner(userID); err == nil {
+		if ds, err := a.deviceRepo.ListByOwner(ctx, userID); err == nil {
 			result = append(result, ds...)
 		}
 	}
 	// 若为设备请求，加入该设备及其所有后代
 	if requesterDeviceUID != 0 {
-		if dev, err := a.deviceRepo.FindByUID(requesterDeviceUID); err == nil {
+		if dev, err := a.deviceRepo.FindByUID(ctx, requesterDeviceUID); err == nil {
 			result = append(result, *dev)
 		}
-		if ds, err := a.deviceRepo.ListDescendantsOfUID(requesterDeviceUID); err == nil {
+		if ds, err := a.deviceRepo.ListDescendantsOfUID(ctx, requesterDeviceUID); err == nil {
 			result = append(result, ds...)
 		}
 	}
@@ -96,6 +97,6 @@ func (a *AuthzService) VisibleDevices(userID, requesterDeviceUID uint64) ([]data
 }
 
 // HasUserPermission: 代理到 KeyService 的用户权限判定
-func (a *AuthzService) HasUserPermission(userID uint64, node string) bool {
-	return a.keySvc.HasPermission(userID, node)
+func (a *AuthzService) HasUserPermission(ctx context.Context, userID uint64, node string) bool {
+	return a.keySvc.HasPermission(ctx, userID, node)
 }
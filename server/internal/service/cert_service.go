@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultCACertFile   = "./data/ca/ca.crt"
+	defaultCAKeyFile    = "./data/ca/ca.key"
+	defaultCertTTLHours = 24
+)
+
+// CertIssued 一次签发的结果（DER）
+type CertIssued struct {
+	Cert     []byte
+	Chain    [][]byte // 签发 CA 及其上级，自下而上
+	Serial   string
+	NotAfter time.Time
+}
+
+// CertService 内置 CA：为已审批设备签发短期客户端证书并维护吊销列表。
+// 吊销集合变化时回调 OnRevoked（由 main 接到 hub.Server.SetRevokedCerts）。
+type CertService struct {
+	repo  *repository.CertRepository
+	audit *AuditService
+
+	caCert *x509.Certificate
+	caKey  crypto.Signer
+	chain  [][]byte
+	ttl    time.Duration
+
+	OnRevoked func(items []bin.CertRevokedItem)
+}
+
+// NewCertService 创建一个新的 CertService；需调用 Init 加载或生成 CA
+func NewCertService(repo *repository.CertRepository, audit *AuditService) *CertService {
+	return &CertService{repo: repo, audit: audit}
+}
+
+// CAFile 返回 CA 证书路径（未配置时为默认路径）
+func CAFile() string {
+	if f := config.AppConfig.CA.CertFile; f != "" {
+		return f
+	}
+	return defaultCACertFile
+}
+
+// Init 加载 CA 证书与私钥；两者均不存在时生成自签 ECDSA P-256 根 CA
+func (s *CertService) Init() error {
+	certFile, keyFile := CAFile(), config.AppConfig.CA.KeyFile
+	if keyFile == "" {
+		keyFile = defaultCAKeyFile
+	}
+	_, errC := os.Stat(certFile)
+	_, errK := os.Stat(keyFile)
+	if errors.Is(errC, os.ErrNotExist) && errors.Is(errK, os.ErrNotExist) {
+		if err := generateRootCA(certFile, keyFile, config.AppConfig.Server.HardwareID); err != nil {
+			return fmt.Errorf("generate CA: %w", err)
+		}
+		log.Info().Str("cert", certFile).Msg("已生成内置根 CA")
+	}
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return fmt.Errorf("no certificate in %s", certFile)
+	}
+	if s.caCert, err = x509.ParseCertificate(block.Bytes); err != nil {
+		return err
+	}
+	if !s.caCert.IsCA {
+		return fmt.Errorf("%s is not a CA certificate", certFile)
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	if s.caKey, err = parsePrivateKey(keyPEM); err != nil {
+		return fmt.Errorf("%s: %w", keyFile, err)
+	}
+	s.chain = [][]byte{s.caCert.Raw}
+	if f := config.AppConfig.CA.ChainFile; f != "" {
+		rest, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		for {
+			var b *pem.Block
+			if b, rest = pem.Decode(rest); b == nil {
+				break
+			}
+			if b.Type == "CERTIFICATE" {
+				s.chain = append(s.chain, b.Bytes)
+			}
+		}
+	}
+	hours := config.AppConfig.CA.CertTTLHours
+	if hours <= 0 {
+		hours = defaultCertTTLHours
+	}
+	s.ttl = time.Duration(hours) * time.Hour
+	return nil
+}
+
+// Issue 校验 CSR 并为设备签发客户端证书：Subject CN 固定为设备 HardwareID（忽略 CSR 中的主题），仅用于客户端认证
+func (s *CertService) Issue(ctx context.Context, device *database.Device, csrDER []byte, ip, ua string) (*CertIssued, error) {
+	if !device.Approved {
+		return nil, fmt.Errorf("device not approved")
+	}
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		return nil, fmt.Errorf("invalid csr: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid csr signature: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	notAfter := now.Add(s.ttl)
+	if notAfter.After(s.caCert.NotAfter) {
+		notAfter = s.caCert.NotAfter
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: device.HardwareID},
+		NotBefore:    now.Add(-5 * time.Minute), // 容忍设备时钟偏差
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, s.caCert, csr.PublicKey, s.caKey)
+	if err != nil {
+		return nil, err
+	}
+	rec := &database.DeviceCertificate{Serial: serial.Text(16), DeviceUID: device.DeviceUID, HardwareID: device.HardwareID, NotBefore: tmpl.NotBefore, NotAfter: notAfter}
+	if err := s.repo.Create(ctx, rec); err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		extra, _ := json.Marshal(map[string]any{"serial": rec.Serial, "notAfter": notAfter.Unix()})
+		uid := device.DeviceUID
+		_ = s.audit.Write(ctx, "device", &uid, "cert.issue", fmt.Sprintf("device:%d", uid), "allow", ip, ua, extra)
+	}
+	return &CertIssued{Cert: der, Chain: s.chain, Serial: rec.Serial, NotAfter: notAfter}, nil
+}
+
+// RevokeDevice 吊销设备名下全部有效证书（设备删除或驳回时调用），并推送新的吊销集合
+func (s *CertService) RevokeDevice(ctx context.Context, deviceUID uint64, reason string) error {
+	serials, err := s.repo.RevokeByDevice(ctx, deviceUID, reason, time.Now())
+	if err != nil || len(serials) == 0 {
+		return err
+	}
+	if s.audit != nil {
+		extra, _ := json.Marshal(map[string]any{"serials": serials, "reason": reason})
+		uid := deviceUID
+		_ = s.audit.Write(ctx, "device", &uid, "cert.revoke", fmt.Sprintf("device:%d", uid), "allow", "", "", extra)
+	}
+	log.Info().Uint64("deviceUID", deviceUID).Strs("serials", serials).Str("reason", reason).Msg("设备证书已吊销")
+	s.PublishRevoked()
+	return nil
+}
+
+// Revoked 返回已吊销且尚未过期的证书列表
+func (s *CertService) Revoked(ctx context.Context) ([]bin.CertRevokedItem, error) {
+	rows, err := s.repo.ListRevoked(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	items := make([]bin.CertRevokedItem, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, bin.CertRevokedItem{Serial: r.Serial, DeviceUID: r.DeviceUID, RevokedAt: r.RevokedAt.Unix(), NotAfter: r.NotAfter.Unix()})
+	}
+	return items, nil
+}
+
+// PublishRevoked 将当前吊销集合推送给 OnRevoked
+func (s *CertService) PublishRevoked() {
+	if s.OnRevoked == nil {
+		return
+	}
+	items, err := s.Revoked(context.Background())
+	if err != nil {
+		log.Warn().Err(err).Msg("读取吊销列表失败")
+		return
+	}
+	s.OnRevoked(items)
+}
+
+func generateRootCA(certFile, keyFile, hardwareID string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "MyFlowHub CA " + hardwareID, Organization: []string{"MyFlowHub"}},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.MkdirAll(filepath.Dir(f), 0o700); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+}
+
+// parsePrivateKey 支持 PKCS#8、SEC1（EC）与 PKCS#1（RSA）格式
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := k.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, errors.New("unsupported key type")
+	}
+	if k, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	"myflowhub/server/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+// newTestCA 在临时目录生成内置 CA；签发记录写入 DryRun 数据库（不落盘）
+func newTestCA(t *testing.T, ttlHours int) *CertService {
+	t.Helper()
+	dir := t.TempDir()
+	prev := config.AppConfig.CA
+	t.Cleanup(func() { config.AppConfig.CA = prev })
+	config.AppConfig.CA.CertFile = filepath.Join(dir, "ca.crt")
+	config.AppConfig.CA.KeyFile = filepath.Join(dir, "ca.key")
+	config.AppConfig.CA.CertTTLHours = ttlHours
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewCertService(repository.NewCertRepository(db), nil)
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newCSR(t *testing.T, cn string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestCertServiceInitReusesCA(t *testing.T) {
+	s := newTestCA(t, 0)
+	if !s.caCert.IsCA || s.ttl != defaultCertTTLHours*time.Hour {
+		t.Fatalf("ca %v ttl %v", s.caCert.IsCA, s.ttl)
+	}
+	// 再次加载读取已生成的 CA，不重新生成
+	again := NewCertService(nil, nil)
+	if err := again.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.caCert.Raw, s.caCert.Raw) {
+		t.Fatal("CA regenerated on second Init")
+	}
+}
+
+func TestCertIssue(t *testing.T) {
+	s := newTestCA(t, 2)
+	device := &database.Device{DeviceUID: 10001, HardwareID: "hw-10001", Approved: true}
+	issued, err := s.Issue(context.Background(), device, newCSR(t, "someone-else"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(issued.Cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 主题固定为设备 HardwareID，忽略 CSR 中的 CN
+	if cert.Subject.CommonName != "hw-10001" || cert.SerialNumber.Text(16) != issued.Serial {
+		t.Fatalf("subject %q serial %s/%s", cert.Subject.CommonName, cert.SerialNumber.Text(16), issued.Serial)
+	}
+	if d := time.Until(cert.NotAfter); d > 2*time.Hour || d < 2*time.Hour-time.Minute {
+		t.Fatalf("validity %v, want 2h", d)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(s.caCert)
+	// 仅可用于客户端认证
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Fatalf("client auth verify: %v", err)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}); err == nil {
+		t.Fatal("issued certificate valid for server auth")
+	}
+	if len(issued.Chain) != 1 || !bytes.Equal(issued.Chain[0], s.caCert.Raw) {
+		t.Fatal("chain does not carry the CA")
+	}
+	// 每次签发序列号不同
+	again, err := s.Issue(context.Background(), device, newCSR(t, ""), "", "")
+	if err != nil || again.Serial == issued.Serial {
+		t.Fatalf("reissue serial %v %v", again, err)
+	}
+}
+
+func TestCertIssueRejects(t *testing.T) {
+	s := newTestCA(t, 0)
+	approved := &database.Device{DeviceUID: 1, HardwareID: "hw", Approved: true}
+	tampered := newCSR(t, "hw")
+	tampered[len(tampered)-1] ^= 0xff
+	cases := []struct {
+		name   string
+		device *database.Device
+		csr    []byte
+	}{
+		{"not approved", &database.Device{DeviceUID: 1, HardwareID: "hw"}, newCSR(t, "hw")},
+		{"not a csr", approved, []byte("garbage")},
+		{"bad signature", approved, tampered},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.Issue(context.Background(), tc.device, tc.csr, "", ""); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestCertIssueClampsToCAExpiry(t *testing.T) {
+	s := newTestCA(t, 0)
+	s.ttl = 20 * 365 * 24 * time.Hour // 超过根 CA 的 10 年有效期
+	issued, err := s.Issue(context.Background(), &database.Device{DeviceUID: 1, HardwareID: "hw", Approved: true}, newCSR(t, "hw"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !issued.NotAfter.Equal(s.caCert.NotAfter) {
+		t.Fatalf("not after %v, want CA expiry %v", issued.NotAfter, s.caCert.NotAfter)
+	}
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 	"myflowhub/server/internal/repository"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -26,56 +28,72 @@ func NewDeviceService(deviceRepo *repository.DeviceRepository, variableRepo *rep
 }
 
 // GetAllDevices 获取所有设备
-func (s *DeviceService) GetAllDevices() ([]database.Device, error) {
-	return s.deviceRepo.FindAll()
+func (s *DeviceService) GetAllDevices(ctx context.Context) ([]database.Device, error) {
+	return s.deviceRepo.FindAll(ctx)
 }
 
 // GetDeviceByUID 根据 UID 获取设备
-func (s *DeviceService) GetDeviceByUID(uid uint64) (*database.Device, error) {
-	return s.deviceRepo.FindByUID(uid)
+func (s *DeviceService) GetDeviceByUID(ctx context.Context, uid uint64) (*database.Device, error) {
+	return s.deviceRepo.FindByUID(ctx, uid)
 }
 
 // GetDeviceByID 根据数据库 ID 获取设备
-func (s *DeviceService) GetDeviceByID(id uint64) (*database.Device, error) {
-	return s.deviceRepo.FindByID(id)
+func (s *DeviceService) GetDeviceByID(ctx context.Context, id uint64) (*database.Device, error) {
+	return s.deviceRepo.FindByID(ctx, id)
 }
 
 // GetDeviceByHardwareID 根据硬件 ID 获取设备
-func (s *DeviceService) GetDeviceByHardwareID(hid string) (*database.Device, error) {
-	return s.deviceRepo.FindByHardwareID(hid)
+func (s *DeviceService) GetDeviceByHardwareID(ctx context.Context, hid string) (*database.Device, error) {
+	return s.deviceRepo.FindByHardwareID(ctx, hid)
 }
 
 // CreateDevice 创建一个新设备
-func (s *DeviceService) CreateDevice(device *database.Device) error {
-	return s.deviceRepo.Create(device)
+func (s *DeviceService) CreateDevice(ctx context.Context, device *database.Device) error {
+	return s.deviceRepo.Create(ctx, device)
 }
 
 // UpdateDeviceParentID 更新设备的父级 ID
-func (s *DeviceService) UpdateDeviceParentID(deviceID, parentID uint64) error {
-	return s.deviceRepo.UpdateParentID(deviceID, parentID)
+func (s *DeviceService) UpdateDeviceParentID(ctx context.Context, deviceID, parentID uint64) error {
+	return s.deviceRepo.UpdateParentID(ctx, deviceID, parentID)
 }
 
 // GetDeviceByUIDOrName 根据 UID 或名称获取设备
-func (s *DeviceService) GetDeviceByUIDOrName(identifier string) (*database.Device, error) {
+func (s *DeviceService) GetDeviceByUIDOrName(ctx context.Context, identifier string) (*database.Device, error) {
 	if strings.HasPrefix(identifier, "[") && strings.HasSuffix(identifier, "]") {
 		uid, err := strconv.ParseUint(strings.Trim(identifier, "[]"), 10, 64)
 		if err != nil {
 			return nil, err
 		}
-		return s.deviceRepo.FindByUID(uid)
+		return s.deviceRepo.FindByUID(ctx, uid)
 	}
-	return s.deviceRepo.FindByHardwareID(strings.Trim(identifier, "()"))
+	return s.deviceRepo.FindByHardwareID(ctx, strings.Trim(identifier, "()"))
 }
 
 // UpdateDevice 更新设备信息
-func (s *DeviceService) UpdateDevice(device *database.Device) error {
-	return s.deviceRepo.Update(device)
+func (s *DeviceService) UpdateDevice(ctx context.Context, device *database.Device) error {
+	return s.deviceRepo.Update(ctx, device)
+}
+
+// UpdateTags 设置设备标签：去除空白与重复项后以逗号连接
+func (s *DeviceService) UpdateTags(ctx context.Context, id uint64, tags string) error {
+	var out []string
+	for _, t := range strings.Split(tags, ",") {
+		if t = strings.TrimSpace(t); t != "" && !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	return s.deviceRepo.UpdateTags(ctx, id, strings.Join(out, ","))
+}
+
+// UpdateCapabilities 记录设备连接协商的协议版本与特性
+func (s *DeviceService) UpdateCapabilities(ctx context.Context, uid uint64, version uint32, features []string) error {
+	return s.deviceRepo.UpdateCapabilities(ctx, uid, version, strings.Join(features, ","))
 }
 
 // DeleteDevice 删除设备及其关联的变量
-func (s *DeviceService) DeleteDevice(id uint64) error {
+func (s *DeviceService) DeleteDevice(ctx context.Context, id uint64) error {
 	// 启动数据库事务
-	tx := s.db.Begin()
+	tx := s.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return tx.Error
 	}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"myflowhub/pkg/database"
+	"myflowhub/server/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+type openSession struct {
+	id        uint64
+	startedAt time.Time
+}
+
+// DeviceSessionService 记录连接会话历史。hub.Server 在 Run 协程内回调，
+// 事件经缓冲队列交由单个写库协程顺序处理，回调本身不阻塞。
+type DeviceSessionService struct {
+	repo   *repository.DeviceSessionRepository
+	events chan func()
+	open   map[uint64]openSession // hub 会话序号 → 数据库记录（仅写库协程访问）
+}
+
+// NewDeviceSessionService 创建一个新的 DeviceSessionService
+func NewDeviceSessionService(repo *repository.DeviceSessionRepository) *DeviceSessionService {
+	return &DeviceSessionService{repo: repo, events: make(chan func(), 1024), open: make(map[uint64]openSession)}
+}
+
+// Run 顺序写入会话事件（阻塞，需在独立协程中运行）
+func (s *DeviceSessionService) Run() {
+	for fn := range s.events {
+		fn()
+	}
+}
+
+// CloseDangling 启动时关闭本节点上次运行遗留的未结束会话
+func (s *DeviceSessionService) CloseDangling(hub string) {
+	n, err := s.repo.CloseDangling(context.Background(), hub, "hub restart", time.Now())
+	if err != nil {
+		log.Warn().Err(err).Msg("关闭遗留连接会话失败")
+		return
+	}
+	if n > 0 {
+		log.Info().Int64("count", n).Msg("已关闭上次运行遗留的连接会话")
+	}
+}
+
+func (s *DeviceSessionService) enqueue(fn func()) {
+	select {
+	case s.events <- fn:
+	default:
+		log.Warn().Msg("会话事件队列已满，记录被丢弃")
+	}
+}
+
+// SessionOpened 连接建立
+func (s *DeviceSessionService) SessionOpened(seq uint64, hub, remoteAddr, userAgent, protocol string, at time.Time) {
+	s.enqueue(func() {
+		rec := &database.DeviceSession{Hub: hub, RemoteAddr: truncate(remoteAddr, 100), UserAgent: truncate(userAgent, 255), Protocol: truncate(protocol, 50), StartedAt: at}
+		if err := s.repo.Create(context.Background(), rec); err != nil {
+			log.Warn().Err(err).Msg("写入连接会话失败")
+			return
+		}
+		s.open[seq] = openSession{id: rec.ID, startedAt: at}
+	})
+}
+
+// SessionAuthenticated 连接认证通过
+func (s *DeviceSessionService) SessionAuthenticated(seq, deviceUID uint64, parentPath string, at time.Time) {
+	s.enqueue(func() {
+		o, ok := s.open[seq]
+		if !ok {
+			return
+		}
+		if err := s.repo.Update(context.Background(), o.id, map[string]any{"device_uid": deviceUID, "parent_path": parentPath, "authed_at": at}); err != nil {
+			log.Warn().Err(err).Uint64("device", deviceUID).Msg("更新连接会话失败")
+		}
+	})
+}
+
+// SessionClosed 连接断开，记录统计与原因
+func (s *DeviceSessionService) SessionClosed(seq, bytesIn, bytesOut, rawBytesIn, rawBytesOut, framesIn, framesOut uint64, reason string, at time.Time) {
+	s.enqueue(func() {
+		o, ok := s.open[seq]
+		if !ok {
+			return
+		}
+		delete(s.open, seq)
+		fields := map[string]any{
+			"ended_at":      at,
+			"duration_ms":   at.Sub(o.startedAt).Milliseconds(),
+			"bytes_in":      bytesIn,
+			"bytes_out":     bytesOut,
+			"raw_bytes_in":  rawBytesIn,
+			"raw_bytes_out": rawBytesOut,
+			"frames_in":     framesIn,
+			"frames_out":    framesOut,
+			"close_reason":  truncate(reason, 255),
+		}
+		if err := s.repo.Update(context.Background(), o.id, fields); err != nil {
+			log.Warn().Err(err).Msg("更新连接会话失败")
+		}
+	})
+}
+
+// List 分页查询设备的会话历史
+func (s *DeviceSessionService) List(ctx context.Context, deviceUID uint64, page, size int) (*repository.PagedDeviceSessions, error) {
+	return s.repo.ListByDevice(ctx, deviceUID, page, size)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/database"
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/testdb"
+
+	"gorm.io/gorm"
+)
+
+// flush 等待此前排队的会话事件全部写入
+func (s *DeviceSessionService) flush() {
+	done := make(chan struct{})
+	s.events <- func() { close(done) }
+	<-done
+}
+
+func newDeviceSessionService(t *testing.T) (*DeviceSessionService, *gorm.DB) {
+	t.Helper()
+	db := testdb.Open(t)
+	s := NewDeviceSessionService(repository.NewDeviceSessionRepository(db))
+	go s.Run()
+	t.Cleanup(func() { close(s.events) })
+	return s, db
+}
+
+func TestDeviceSessionLifecycle(t *testing.T) {
+	s, db := newDeviceSessionService(t)
+	ctx := context.Background()
+	start := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	s.SessionOpened(1, "hub-1", "10.0.0.1:5000", "ua", "ws/myflowhub.bin.v1", start)
+	s.SessionAuthenticated(1, 42, "/100/200", start.Add(time.Second))
+	// 未登记的序号被忽略
+	s.SessionAuthenticated(9, 42, "/", start)
+	s.SessionClosed(9, 1, 1, 1, 1, 1, 1, "ghost", start)
+	s.flush()
+
+	res, err := s.List(ctx, 42, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || len(res.Items) != 1 {
+		t.Fatalf("open sessions %+v", res)
+	}
+	got := res.Items[0]
+	if got.Hub != "hub-1" || got.RemoteAddr != "10.0.0.1:5000" || got.Protocol != "ws/myflowhub.bin.v1" || got.ParentPath != "/100/200" ||
+		got.AuthedAt == nil || got.EndedAt != nil || got.CloseReason != "" {
+		t.Fatalf("open session %+v", got)
+	}
+
+	s.SessionClosed(1, 100, 200, 300, 400, 5, 6, "peer closed: 1000", start.Add(90*time.Second))
+	// 已结束的会话不再更新
+	s.SessionClosed(1, 0, 0, 0, 0, 0, 0, "again", start.Add(2*time.Minute))
+	s.flush()
+	if res, err = s.List(ctx, 42, 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	got = res.Items[0]
+	if got.EndedAt == nil || got.DurationMs != 90_000 || got.BytesIn != 100 || got.BytesOut != 200 || got.RawBytesIn != 300 ||
+		got.RawBytesOut != 400 || got.FramesIn != 5 || got.FramesOut != 6 || got.CloseReason != "peer closed: 1000" {
+		t.Fatalf("closed session %+v", got)
+	}
+
+	// 进程重启：本节点遗留的未结束会话被关闭，其他节点不受影响
+	s.SessionOpened(2, "hub-1", "", "", "tcp", start)
+	s.SessionOpened(3, "hub-2", "", "", "tcp", start)
+	s.flush()
+	s.CloseDangling("hub-1")
+	var open []database.DeviceSession
+	if err := db.Where("ended_at IS NULL").Find(&open).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 || open[0].Hub != "hub-2" {
+		t.Fatalf("dangling %+v", open)
+	}
+}
+
+func TestDeviceSessionList(t *testing.T) {
+	s, _ := newDeviceSessionService(t)
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i := range 25 {
+		seq := uint64(i + 1)
+		uid := uint64(42)
+		if i%5 == 4 {
+			uid = 7
+		}
+		s.SessionOpened(seq, "hub", "", "", "tcp", base.Add(time.Duration(i)*time.Minute))
+		s.SessionAuthenticated(seq, uid, "/", base.Add(time.Duration(i)*time.Minute))
+	}
+	s.flush()
+
+	// 设备 42 共 20 条，按开始时间倒序分页
+	res, err := s.List(ctx, 42, 1, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 20 || res.Page != 1 || res.Size != 8 || len(res.Items) != 8 {
+		t.Fatalf("page 1: total %d page %d size %d items %d", res.Total, res.Page, res.Size, len(res.Items))
+	}
+	if !res.Items[0].StartedAt.Equal(base.Add(23 * time.Minute)) {
+		t.Fatalf("newest %v", res.Items[0].StartedAt)
+	}
+	for i := 1; i < len(res.Items); i++ {
+		if res.Items[i].StartedAt.After(res.Items[i-1].StartedAt) || res.Items[i].DeviceUID != 42 {
+			t.Fatalf("order at %d: %+v", i, res.Items)
+		}
+	}
+	if res, err = s.List(ctx, 42, 3, 8); err != nil || len(res.Items) != 4 || !res.Items[3].StartedAt.Equal(base) {
+		t.Fatalf("last page %+v %v", res, err)
+	}
+	if res, err = s.List(ctx, 42, 4, 8); err != nil || len(res.Items) != 0 || res.Total != 20 {
+		t.Fatalf("past the end %+v %v", res, err)
+	}
+	// 非法分页参数取默认值
+	if res, err = s.List(ctx, 42, 0, 1000); err != nil || res.Page != 1 || res.Size != 20 || len(res.Items) != 20 {
+		t.Fatalf("defaults %+v %v", res, err)
+	}
+	if res, err = s.List(ctx, 8, 1, 10); err != nil || res.Total != 0 || len(res.Items) != 0 {
+		t.Fatalf("unknown device %+v %v", res, err)
+	}
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/pkg/protocol/binproto/filexfer"
+	"myflowhub/server/internal/repository"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFileTooLarge    = errors.New("file too large")
+	ErrTransferUnknown = errors.New("unknown transfer")
+	ErrBadFilename     = errors.New("invalid filename")
+)
+
+// safeFilename 取文件名的最后一段（同时按 / 与 \ 切分），防止以 ../ 等路径写出存储目录
+func safeFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" || name == "." || name == ".." {
+		return "file"
+	}
+	return name
+}
+
+type transferKey struct{ owner, id uint64 }
+
+type activeTransfer struct {
+	rec         *database.StoredFile
+	file        *os.File
+	recv        *filexfer.Receiver
+	persistedAt time.Time
+}
+
+// outgoingTransfer Hub 作为发送端的传输（如 OTA 固件下发）
+type outgoingTransfer struct {
+	file   *os.File
+	sender *filexfer.Sender
+}
+
+// FileService 管理 Hub 侧的文件接收（落盘、断点续传与整文件校验）与已存储文件的下发
+type FileService struct {
+	repo       *repository.FileRepository
+	dir        string
+	chunkSize  uint32
+	window     uint32
+	maxSize    uint64
+	ackTimeout time.Duration
+
+	mu       sync.Mutex
+	active   map[transferKey]*activeTransfer
+	outgoing map[transferKey]*outgoingTransfer
+}
+
+// NewFileService 创建 FileService，参数取自 config.File
+func NewFileService(repo *repository.FileRepository) *FileService {
+	fc := config.AppConfig.File
+	dir := fc.StorageDir
+	if dir == "" {
+		dir = filepath.Join("data", "files")
+	}
+	maxSize := uint64(1 << 30)
+	if fc.MaxFileSize > 0 {
+		maxSize = uint64(fc.MaxFileSize)
+	}
+	ackTimeout := 10 * time.Second
+	if fc.AckTimeout > 0 {
+		ackTimeout = time.Duration(fc.AckTimeout) * time.Second
+	}
+	return &FileService{
+		repo:       repo,
+		dir:        dir,
+		chunkSize:  filexfer.NormalizeChunkSize(uint32(max(fc.ChunkSize, 0))),
+		window:     filexfer.NormalizeWindow(uint32(max(fc.Window, 0))),
+		maxSize:    maxSize,
+		ackTimeout: ackTimeout,
+		active:     make(map[transferKey]*activeTransfer),
+		outgoing:   make(map[transferKey]*outgoingTransfer),
+	}
+}
+
+// Params 返回协商给发送端的分片大小与窗口
+func (s *FileService) Params(requested uint32) (chunkSize, window uint32) {
+	chunkSize = s.chunkSize
+	if requested != 0 && filexfer.NormalizeChunkSize(requested) < chunkSize {
+		chunkSize = filexfer.NormalizeChunkSize(requested)
+	}
+	return chunkSize, s.window
+}
+
+// Begin 处理 FILE_INIT：新建或恢复传输，返回断点偏移
+func (s *FileService) Begin(ctx context.Context, ownerUID uint64, init bin.FileInit) (uint64, error) {
+	if init.TotalSize > s.maxSize {
+		return 0, ErrFileTooLarge
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := transferKey{ownerUID, init.TransferID}
+	hashHex := hex.EncodeToString(init.FileHash)
+	if at, ok := s.active[key]; ok {
+		if at.rec.SHA256 == hashHex && at.rec.Size == init.TotalSize {
+			return at.recv.Received(), nil
+		}
+		s.dropLocked(key, at)
+	}
+	rec, err := s.repo.FindByTransfer(ctx, ownerUID, init.TransferID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	if rec != nil && (rec.SHA256 != hashHex || rec.Size != init.TotalSize || rec.Status == "cancelled") {
+		// 同一传输 ID 被复用为不同文件：丢弃旧的部分数据重新开始
+		_ = os.Remove(rec.Path)
+		rec.Filename, rec.Mime, rec.Size, rec.SHA256 = safeFilename(init.Filename), init.Mime, init.TotalSize, hashHex
+		rec.Received, rec.Status, rec.CompletedAt = 0, "uploading", nil
+		if err := s.repo.Save(ctx, rec); err != nil {
+			return 0, err
+		}
+	}
+	if rec != nil && rec.Status == "completed" {
+		// 已完成：返回全部偏移，发送端直接进入 COMPLETE
+		return rec.Size, nil
+	}
+	if rec == nil {
+		rec = &database.StoredFile{
+			TransferID:    init.TransferID,
+			OwnerDeviceID: ownerUID,
+			Filename:      safeFilename(init.Filename),
+			Mime:          init.Mime,
+			Size:          init.TotalSize,
+			SHA256:        hashHex,
+			Status:        "uploading",
+		}
+		rec.Path = filepath.Join(s.dir, strconv.FormatUint(ownerUID, 10), strconv.FormatUint(init.TransferID, 10)+".part")
+		if err := s.repo.Create(ctx, rec); err != nil {
+			return 0, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(rec.Path), 0o755); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(rec.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	// 以磁盘实际长度与记录取较小值作为断点，防止记录超前于数据
+	received := rec.Received
+	if st, e := f.Stat(); e == nil && uint64(st.Size()) < received {
+		received = uint64(st.Size())
+	}
+	s.active[key] = &activeTransfer{rec: rec, file: f, recv: filexfer.NewReceiver(init, f, received), persistedAt: time.Now()}
+	return received, nil
+}
+
+// Chunk 处理 FILE_CHUNK，返回累计确认偏移
+func (s *FileService) Chunk(ctx context.Context, ownerUID, transferID, offset uint64, chunk []byte, crc *uint32) (uint64, error) {
+	s.mu.Lock()
+	at, ok := s.active[transferKey{ownerUID, transferID}]
+	s.mu.Unlock()
+	if !ok {
+		return 0, ErrTransferUnknown
+	}
+	next, err := at.recv.HandleChunk(offset, chunk, crc)
+	if err != nil {
+		return next, err
+	}
+	// 断点进度按时间节流落库，避免每个分片都写数据库；persistedAt 与 Begin/dropLocked 同受 s.mu 保护
+	s.mu.Lock()
+	persist := s.active[transferKey{ownerUID, transferID}] == at && time.Since(at.persistedAt) > 2*time.Second
+	if persist {
+		at.persistedAt = time.Now()
+	}
+	s.mu.Unlock()
+	if persist {
+		_ = s.repo.UpdateReceived(ctx, at.rec.ID, next)
+	}
+	return next, nil
+}
+
+// Complete 处理 FILE_COMPLETE_REQ：校验哈希并转为正式文件
+func (s *FileService) Complete(ctx context.Context, ownerUID, transferID uint64) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := transferKey{ownerUID, transferID}
+	at, ok := s.active[key]
+	if !ok {
+		if rec, err := s.repo.FindByTransfer(ctx, ownerUID, transferID); err == nil && rec.Status == "completed" {
+			return rec.ID, nil // 重复的 COMPLETE（如响应丢失后重传）
+		}
+		return 0, ErrTransferUnknown
+	}
+	if err := at.recv.Complete(); err != nil {
+		if errors.Is(err, filexfer.ErrHashMismatch) {
+			// 数据已损坏：丢弃后由发送端重新开始
+			s.dropLocked(key, at)
+			_ = os.Remove(at.rec.Path)
+			at.rec.Received = 0
+			_ = s.repo.Save(ctx, at.rec)
+		}
+		return 0, err
+	}
+	// 旧版本落库的文件名可能未经清理：含路径分隔符的拒绝转为正式文件
+	if at.rec.Filename != safeFilename(at.rec.Filename) {
+		return 0, ErrBadFilename
+	}
+	_ = at.file.Close()
+	delete(s.active, key)
+	final := filepath.Join(filepath.Dir(at.rec.Path), fmt.Sprintf("%d_%s", at.rec.ID, at.rec.Filename))
+	if err := os.Rename(at.rec.Path, final); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	at.rec.Path, at.rec.Received, at.rec.Status, at.rec.CompletedAt = final, at.rec.Size, "completed", &now
+	if err := s.repo.Save(ctx, at.rec); err != nil {
+		return 0, err
+	}
+	return at.rec.ID, nil
+}
+
+// Cancel 处理 FILE_CANCEL：删除部分数据
+func (s *FileService) Cancel(ctx context.Context, ownerUID, transferID uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := transferKey{ownerUID, transferID}
+	rec, err := s.repo.FindByTransfer(ctx, ownerUID, transferID)
+	if at, ok := s.active[key]; ok {
+		s.dropLocked(key, at)
+		rec, err = at.rec, nil
+	}
+	if err != nil {
+		return ErrTransferUnknown
+	}
+	if rec.Status == "completed" {
+		return nil
+	}
+	_ = os.Remove(rec.Path)
+	rec.Status, rec.Received = "cancelled", 0
+	return s.repo.Save(ctx, rec)
+}
+
+// Suspend 连接断开时关闭该设备的在途文件句柄并持久化进度，等待重连续传
+func (s *FileService) Suspend(ownerUID uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, at := range s.active {
+		if key.owner != ownerUID {
+			continue
+		}
+		_ = s.repo.UpdateReceived(context.Background(), at.rec.ID, at.recv.Received())
+		_ = at.file.Close()
+		delete(s.active, key)
+	}
+}
+
+// SendStored 将已完成存储的文件以分片协议发送给 peer；send 负责封帧下发。
+// 返回的 Sender 可用于查询进度（Acked）与结果（State）；超时重传由内部协程驱动。
+func (s *FileService) SendStored(ctx context.Context, fileID, peer uint64, send filexfer.SendFunc) (*filexfer.Sender, error) {
+	rec, err := s.repo.FindByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Status != "completed" {
+		return nil, fmt.Errorf("file %d not completed", fileID)
+	}
+	hash, err := hex.DecodeString(rec.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	var rnd [8]byte
+	if _, err := rand.Read(rnd[:]); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(rec.Path)
+	if err != nil {
+		return nil, err
+	}
+	init := bin.FileInit{
+		TransferID: binary.LittleEndian.Uint64(rnd[:]),
+		TotalSize:  rec.Size,
+		Mime:       rec.Mime,
+		Filename:   rec.Filename,
+		FileHash:   hash,
+		ChunkSize:  s.chunkSize,
+	}
+	ot := &outgoingTransfer{file: f, sender: filexfer.NewSender(f, init, send)}
+	key := transferKey{peer, init.TransferID}
+	s.mu.Lock()
+	s.outgoing[key] = ot
+	s.mu.Unlock()
+	// INIT 发送失败（如队列已满）由超时重传兜底
+	if err := ot.sender.Start(); err != nil {
+		log.Warn().Err(err).Uint64("peer", peer).Uint64("transferID", init.TransferID).Msg("发送 FILE_INIT 失败，等待重传")
+	}
+	go s.watchOutgoing(key, ot)
+	return ot.sender, nil
+}
+
+// watchOutgoing 驱动发送端超时重传，结束后释放资源
+func (s *FileService) watchOutgoing(key transferKey, ot *outgoingTransfer) {
+	ticker := time.NewTicker(s.ackTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-ot.sender.Done():
+			if err != nil {
+				log.Warn().Err(err).Uint64("peer", key.owner).Uint64("transferID", key.id).Msg("文件下发失败")
+			}
+			_ = ot.file.Close()
+			s.mu.Lock()
+			delete(s.outgoing, key)
+			s.mu.Unlock()
+			return
+		case <-ticker.C:
+			_ = ot.sender.CheckTimeout(s.ackTimeout)
+		}
+	}
+}
+
+// HandlePeerResponse 处理接收方对 Hub 发出传输的应答（301/304/305/306）；
+// 返回 false 表示不属于 Hub 发出的传输
+func (s *FileService) HandlePeerResponse(peer uint64, typeID uint16, payload []byte) (bool, error) {
+	var transferID uint64
+	var err error
+	switch typeID {
+	case bin.TypeFileInitResp:
+		_, transferID, _, _, _, _, err = bin.DecodeFileInitResp(payload)
+	case bin.TypeFileChunkAck:
+		transferID, _, err = bin.DecodeFileChunkAck(payload)
+	case bin.TypeFileCompleteResp:
+		_, transferID, _, _, err = bin.DecodeFileCompleteResp(payload)
+	case bin.TypeFileCancel:
+		transferID, _, err = bin.DecodeFileCancel(payload)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	ot, ok := s.outgoing[transferKey{peer, transferID}]
+	s.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	switch typeID {
+	case bin.TypeFileInitResp:
+		_, _, okResp, resume, chunkSize, window, _ := bin.DecodeFileInitResp(payload)
+		return true, ot.sender.HandleInitResp(okResp, resume, chunkSize, window)
+	case bin.TypeFileChunkAck:
+		_, next, _ := bin.DecodeFileChunkAck(payload)
+		return true, ot.sender.HandleAck(next)
+	case bin.TypeFileCompleteResp:
+		_, _, okResp, _, _ := bin.DecodeFileCompleteResp(payload)
+		ot.sender.HandleCompleteResp(okResp)
+	case bin.TypeFileCancel:
+		_, reason, _ := bin.DecodeFileCancel(payload)
+		ot.sender.HandleCancel(reason)
+	}
+	return true, nil
+}
+
+// GetFile 根据 ID 获取已存储的文件记录
+func (s *FileService) GetFile(ctx context.Context, id uint64) (*database.StoredFile, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *FileService) dropLocked(key transferKey, at *activeTransfer) {
+	at.recv.Cancel()
+	_ = at.file.Close()
+	delete(s.active, key)
+}
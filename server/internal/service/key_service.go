@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
@@ -21,8 +22,8 @@ func NewKeyService(keys *repository.KeyRepository, perms *repository.PermissionR
 }
 
 // IsKeyManagerUser 判断用户是否具有 key.manage 权限
-func (s *KeyService) IsKeyManagerUser(userID uint64) bool {
-	list, err := s.perms.ListByUserID(userID)
+func (s *KeyService) IsKeyManagerUser(ctx context.Context, userID uint64) bool {
+	list, err := s.perms.ListByUserID(ctx, userID)
 	if err != nil {
 		return false
 	}
@@ -35,24 +36,24 @@ func (s *KeyService) IsKeyManagerUser(userID uint64) bool {
 }
 
 // ListKeys 返回用户可见的密钥集合：普通用户仅看自己，admin.key 可看全部
-func (s *KeyService) ListKeys(requestUserID uint64) ([]database.Key, error) {
-	if s.IsKeyManagerUser(requestUserID) {
-		return s.keys.ListAll()
+func (s *KeyService) ListKeys(ctx context.Context, requestUserID uint64) ([]database.Key, error) {
+	if s.IsKeyManagerUser(ctx, requestUserID) {
+		return s.keys.ListAll(ctx)
 	}
-	return s.keys.ListByOwner(requestUserID)
+	return s.keys.ListByOwner(ctx, requestUserID)
 }
 
 // ValidateUserKey 校验用户密钥，返回请求者的用户ID
 // 策略：优先使用绑定的用户（BindSubjectType=user）；否则回退为签发者 OwnerUserID。
 // 要求：未撤销、未过期、剩余次数>0（如有限制）
-func (s *KeyService) ValidateUserKey(secret string) (uint64, *database.Key, error) {
+func (s *KeyService) ValidateUserKey(ctx context.Context, secret string) (uint64, *database.Key, error) {
 	if secret == "" {
 		return 0, nil, errors.New("empty key")
 	}
 	// 强制使用哈希匹配
 	sum := sha256.Sum256([]byte(secret))
 	hashed := hex.EncodeToString(sum[:])
-	k, err := s.keys.FindBySecretHash(hashed)
+	k, err := s.keys.FindBySecretHash(ctx, hashed)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -78,20 +79,20 @@ func (s *KeyService) ValidateUserKey(secret string) (uint64, *database.Key, erro
 	if k.RemainingUses != nil {
 		v := *k.RemainingUses - 1
 		k.RemainingUses = &v
-		_ = s.keys.Update(k)
+		_ = s.keys.Update(ctx, k)
 	}
 	return userID, k, nil
 }
 
 // PeekUserKey 校验密钥但不消耗剩余次数
-func (s *KeyService) PeekUserKey(secret string) (uint64, *database.Key, error) {
+func (s *KeyService) PeekUserKey(ctx context.Context, secret string) (uint64, *database.Key, error) {
 	if secret == "" {
 		return 0, nil, errors.New("empty key")
 	}
 	// 强制使用哈希匹配
 	sum := sha256.Sum256([]byte(secret))
 	hashed := hex.EncodeToString(sum[:])
-	k, err := s.keys.FindBySecretHash(hashed)
+	k, err := s.keys.FindBySecretHash(ctx, hashed)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -117,37 +118,37 @@ func (s *KeyService) PeekUserKey(secret string) (uint64, *database.Key, error) {
 }
 
 // RevokeBySecret 撤销密钥（支持明文或哈希传入）
-func (s *KeyService) RevokeBySecret(secret string) error {
+func (s *KeyService) RevokeBySecret(ctx context.Context, secret string) error {
 	if secret == "" {
 		return errors.New("empty key")
 	}
 	sum := sha256.Sum256([]byte(secret))
 	hashed := hex.EncodeToString(sum[:])
-	k, err := s.keys.FindBySecretHash(hashed)
+	k, err := s.keys.FindBySecretHash(ctx, hashed)
 	if err != nil {
 		return err
 	}
 	k.Revoked = true
-	return s.keys.Update(k)
+	return s.keys.Update(ctx, k)
 }
 
 // DeleteBySecret 通过明文或哈希删除密钥，避免积累
-func (s *KeyService) DeleteBySecret(secret string) error {
+func (s *KeyService) DeleteBySecret(ctx context.Context, secret string) error {
 	if secret == "" {
 		return errors.New("empty key")
 	}
 	sum := sha256.Sum256([]byte(secret))
 	hashed := hex.EncodeToString(sum[:])
-	k, err := s.keys.FindBySecretHash(hashed)
+	k, err := s.keys.FindBySecretHash(ctx, hashed)
 	if err != nil {
 		return err
 	}
-	return s.keys.Delete(k.ID)
+	return s.keys.Delete(ctx, k.ID)
 }
 
 // HasPermission 判断用户是否拥有指定权限节点（精确匹配；通配在上层处理或扩展）
-func (s *KeyService) HasPermission(userID uint64, node string) bool {
-	list, err := s.perms.ListByUserID(userID)
+func (s *KeyService) HasPermission(ctx context.Context, userID uint64, node string) bool {
+	list, err := s.perms.ListByUserID(ctx, userID)
 	if err != nil {
 		return false
 	}
@@ -159,7 +160,7 @@ func (s *KeyService) HasPermission(userID uint64, node string) bool {
 	return false
 }
 
-func (s *KeyService) CreateKey(ownerUserID uint64, bindType *string, bindID *uint64, secret string, expiresAt *time.Time, maxUses *int, metaJSON []byte) (*database.Key, error) {
+func (s *KeyService) CreateKey(ctx context.Context, ownerUserID uint64, bindType *string, bindID *uint64, secret string, expiresAt *time.Time, maxUses *int, metaJSON []byte) (*database.Key, error) {
 	// 为安全起见按 sha256 存储；同时 ValidateUserKey 兼容旧数据
 	sum := sha256.Sum256([]byte(secret))
 	secretHash := hex.EncodeToString(sum[:])
@@ -178,19 +179,19 @@ func (s *KeyService) CreateKey(ownerUserID uint64, bindType *string, bindID *uin
 	if len(metaJSON) > 0 {
 		k.Meta = metaJSON
 	}
-	if err := s.keys.Create(k); err != nil {
+	if err := s.keys.Create(ctx, k); err != nil {
 		return nil, err
 	}
 	return k, nil
 }
 
 // AttachKeyPermissions 为密钥设置权限，要求 nodes ⊆ 用户权限
-func (s *KeyService) AttachKeyPermissions(ownerUserID uint64, keyID uint64, nodes []string) error {
+func (s *KeyService) AttachKeyPermissions(ctx context.Context, ownerUserID uint64, keyID uint64, nodes []string) error {
 	if len(nodes) == 0 {
 		return nil
 	}
 	// 获取用户全部权限
-	ups, err := s.perms.ListByUserID(ownerUserID)
+	ups, err := s.perms.ListByUserID(ctx, ownerUserID)
 	if err != nil {
 		return err
 	}
@@ -204,40 +205,40 @@ func (s *KeyService) AttachKeyPermissions(ownerUserID uint64, keyID uint64, node
 		}
 	}
 	for _, n := range nodes {
-		if err := s.perms.AddKeyNode(keyID, n, &ownerUserID); err != nil {
+		if err := s.perms.AddKeyNode(ctx, keyID, n, &ownerUserID); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (s *KeyService) UpdateKey(requestUserID uint64, k *database.Key) error {
+func (s *KeyService) UpdateKey(ctx context.Context, requestUserID uint64, k *database.Key) error {
 	// 普通用户只能更新自己的密钥；admin.key 可更新任意
-	if !s.IsKeyManagerUser(requestUserID) {
+	if !s.IsKeyManagerUser(ctx, requestUserID) {
 		if k.OwnerUserID == nil || *k.OwnerUserID != requestUserID {
 			return errors.New("forbidden")
 		}
 	}
-	return s.keys.Update(k)
+	return s.keys.Update(ctx, k)
 }
 
-func (s *KeyService) DeleteKey(requestUserID uint64, id uint64) error {
-	existing, err := s.keys.FindByID(id)
+func (s *KeyService) DeleteKey(ctx context.Context, requestUserID uint64, id uint64) error {
+	existing, err := s.keys.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
-	if !s.IsKeyManagerUser(requestUserID) {
+	if !s.IsKeyManagerUser(ctx, requestUserID) {
 		if existing.OwnerUserID == nil || *existing.OwnerUserID != requestUserID {
 			return errors.New("forbidden")
 		}
 	}
-	return s.keys.Delete(id)
+	return s.keys.Delete(ctx, id)
 }
 
 // ListVisibleDevicesForKey 返回当前用户在创建密钥时可见的设备
-func (s *KeyService) ListVisibleDevicesForKey(userID uint64) ([]database.Device, error) {
-	if s.IsKeyManagerUser(userID) {
-		return s.deviceRepo.FindAll()
+func (s *KeyService) ListVisibleDevicesForKey(ctx context.Context, userID uint64) ([]database.Device, error) {
+	if s.IsKeyManagerUser(ctx, userID) {
+		return s.deviceRepo.FindAll(ctx)
 	}
-	return s.deviceRepo.ListByOwner(userID)
+	return s.deviceRepo.ListByOwner(ctx, userID)
 }
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/server/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrNonceReplay    = errors.New("nonce replay")
+	ErrNonceStoreFull = errors.New("nonce store full")
+)
+
+// NonceStore 签名请求的 nonce 防重放存储（并发安全）。内存中按接收时间分桶，整桶到期淘汰而不逐条扫描，
+// 条目总数有上限；配置了 repo 时同时写入数据库，由唯一索引保证重启后及多个 Hub 进程间仍只能使用一次。
+// 不同请求类型以 scope 区分。
+type NonceStore struct {
+	repo      *repository.NonceRepository // 为 nil 时仅保存在内存
+	ttl       time.Duration
+	bucketDur time.Duration
+	max       int
+
+	mu          sync.Mutex
+	buckets     []nonceBucket // 按起始时间升序
+	count       int
+	lastCleanup time.Time
+}
+
+type nonceBucket struct {
+	start time.Time
+	seen  map[string]struct{}
+}
+
+// NewNonceStore 创建一个新的 NonceStore；repo 为 nil 时不持久化
+func NewNonceStore(repo *repository.NonceRepository) *NonceStore {
+	// 请求时间窗为 ±5 分钟，nonce 至少须保留 10 分钟，才能覆盖其时间戳仍被接受的整个区间
+	ttl := 10 * time.Minute
+	if v := time.Duration(config.AppConfig.Nonce.TTLSec) * time.Second; v > ttl {
+		ttl = v
+	}
+	max := 100000
+	if v := config.AppConfig.Nonce.MaxEntries; v > 0 {
+		max = v
+	}
+	bucketDur := ttl / 10
+	if bucketDur < time.Second {
+		bucketDur = time.Second
+	}
+	return &NonceStore{repo: repo, ttl: ttl, bucketDur: bucketDur, max: max}
+}
+
+// Use 登记一次性 nonce；已使用过返回 ErrNonceReplay。调用方应先校验签名与请求时间窗，
+// 避免未认证的请求占满存储。
+func (s *NonceStore) Use(ctx context.Context, scope string, nonce []byte) error {
+	now := time.Now()
+	key := scope + "\x00" + string(nonce)
+
+	s.mu.Lock()
+	s.expire(now)
+	for _, b := range s.buckets {
+		if _, ok := b.seen[key]; ok {
+			s.mu.Unlock()
+			return ErrNonceReplay
+		}
+	}
+	if s.count >= s.max {
+		s.mu.Unlock()
+		log.Warn().Int("max", s.max).Str("scope", scope).Msg("nonce 存储已满，拒绝签名请求")
+		return ErrNonceStoreFull
+	}
+	s.current(now).seen[key] = struct{}{}
+	s.count++
+	cleanup := s.repo != nil && now.Sub(s.lastCleanup) >= s.bucketDur
+	if cleanup {
+		s.lastCleanup = now
+	}
+	s.mu.Unlock()
+
+	if s.repo == nil {
+		return nil
+	}
+	if cleanup {
+		go func() {
+			if _, err := s.repo.DeleteExpired(context.Background(), now); err != nil {
+				log.Warn().Err(err).Msg("清理过期 nonce 失败")
+			}
+		}()
+	}
+	sum := sha256.Sum256(append([]byte(scope+"\x00"), nonce...))
+	ok, err := s.repo.Insert(ctx, scope, hex.EncodeToString(sum[:]), now.Add(s.ttl))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNonceReplay
+	}
+	return nil
+}
+
+// expire 淘汰最后接收时间已超过 ttl 的整桶（持有 mu）
+func (s *NonceStore) expire(now time.Time) {
+	n := 0
+	for n < len(s.buckets) && now.Sub(s.buckets[n].start) > s.bucketDur+s.ttl {
+		s.count -= len(s.buckets[n].seen)
+		n++
+	}
+	if n > 0 {
+		s.buckets = append(s.buckets[:0], s.buckets[n:]...)
+	}
+}
+
+// current 返回 now 所在的桶，必要时新建（持有 mu）
+func (s *NonceStore) current(now time.Time) nonceBucket {
+	start := now.Truncate(s.bucketDur)
+	if n := len(s.buckets); n > 0 && !s.buckets[n-1].start.Before(start) {
+		return s.buckets[n-1]
+	}
+	b := nonceBucket{start: start, seen: make(map[string]struct{})}
+	s.buckets = append(s.buckets, b)
+	return b
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/config"
+	"myflowhub/pkg/database"
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/testdb"
+)
+
+// age 将存储中所有桶的接收时间前移 d，模拟时间流逝
+func (s *NonceStore) age(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.buckets {
+		s.buckets[i].start = s.buckets[i].start.Add(-d)
+	}
+}
+
+func (s *NonceStore) entries() (buckets, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets), s.count
+}
+
+func setNonceConfig(t *testing.T, maxEntries int) {
+	t.Helper()
+	prev := config.AppConfig.Nonce
+	t.Cleanup(func() { config.AppConfig.Nonce = prev })
+	config.AppConfig.Nonce.TTLSec = 0
+	config.AppConfig.Nonce.MaxEntries = maxEntries
+}
+
+func TestNonceReplay(t *testing.T) {
+	setNonceConfig(t, 0)
+	ctx := context.Background()
+	s := NewNonceStore(nil)
+	if err := s.Use(ctx, "auth", []byte("n1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Use(ctx, "auth", []byte("n1")); !errors.Is(err, ErrNonceReplay) {
+		t.Fatalf("replay: %v", err)
+	}
+	// 不同请求类型互不影响
+	if err := s.Use(ctx, "rotate", []byte("n1")); err != nil {
+		t.Fatalf("other scope: %v", err)
+	}
+	if err := s.Use(ctx, "auth", []byte("n2")); err != nil {
+		t.Fatalf("fresh nonce: %v", err)
+	}
+}
+
+func TestNonceBucketExpiry(t *testing.T) {
+	setNonceConfig(t, 0)
+	ctx := context.Background()
+	s := NewNonceStore(nil)
+	if s.ttl != 10*time.Minute || s.bucketDur != time.Minute {
+		t.Fatalf("ttl %v bucket %v", s.ttl, s.bucketDur)
+	}
+	if err := s.Use(ctx, "auth", []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	// 一个桶周期后登记的 nonce 进入新桶
+	s.age(s.bucketDur)
+	if err := s.Use(ctx, "auth", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if b, n := s.entries(); b != 2 || n != 2 {
+		t.Fatalf("buckets %d entries %d", b, n)
+	}
+
+	// 未满 ttl 仍视为重放
+	s.age(s.ttl - s.bucketDur)
+	if err := s.Use(ctx, "auth", []byte("old")); !errors.Is(err, ErrNonceReplay) {
+		t.Fatalf("old nonce within ttl: %v", err)
+	}
+	// 旧桶整体过期后其 nonce 可再次使用，新桶不受影响
+	s.age(s.bucketDur + time.Second)
+	if err := s.Use(ctx, "auth", []byte("new")); !errors.Is(err, ErrNonceReplay) {
+		t.Fatalf("new nonce within ttl: %v", err)
+	}
+	if b, n := s.entries(); b != 1 || n != 1 {
+		t.Fatalf("after expiry: buckets %d entries %d", b, n)
+	}
+	if err := s.Use(ctx, "auth", []byte("old")); err != nil {
+		t.Fatalf("expired nonce: %v", err)
+	}
+}
+
+func TestNonceMaxEntries(t *testing.T) {
+	setNonceConfig(t, 3)
+	ctx := context.Background()
+	s := NewNonceStore(nil)
+	for i := range 3 {
+		if err := s.Use(ctx, "auth", fmt.Appendf(nil, "n%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Use(ctx, "auth", []byte("n3")); !errors.Is(err, ErrNonceStoreFull) {
+		t.Fatalf("over limit: %v", err)
+	}
+	// 已满时重放仍按重放拒绝，且拒绝不占用条目
+	if err := s.Use(ctx, "auth", []byte("n0")); !errors.Is(err, ErrNonceReplay) {
+		t.Fatalf("replay when full: %v", err)
+	}
+	s.age(s.ttl + 2*s.bucketDur)
+	if err := s.Use(ctx, "auth", []byte("n3")); err != nil {
+		t.Fatalf("after expiry: %v", err)
+	}
+	if _, n := s.entries(); n != 1 {
+		t.Fatalf("entries %d", n)
+	}
+}
+
+// useConcurrently 以 n 个协程同时登记 nonce，返回成功次数
+func useConcurrently(stores []*NonceStore, n int, nonce func(i int) []byte) (ok int, errs []error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			err := stores[i%len(stores)].Use(context.Background(), "auth", nonce(i))
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				ok++
+			case !errors.Is(err, ErrNonceReplay):
+				errs = append(errs, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return ok, errs
+}
+
+func TestNonceConcurrentUse(t *testing.T) {
+	setNonceConfig(t, 0)
+	s := NewNonceStore(nil)
+	if ok, errs := useConcurrently([]*NonceStore{s}, 64, func(int) []byte { return []byte("same") }); ok != 1 || len(errs) > 0 {
+		t.Fatalf("same nonce accepted %d times, errors %v", ok, errs)
+	}
+	if ok, errs := useConcurrently([]*NonceStore{s}, 64, func(i int) []byte { return fmt.Appendf(nil, "n%d", i) }); ok != 64 || len(errs) > 0 {
+		t.Fatalf("distinct nonces accepted %d times, errors %v", ok, errs)
+	}
+	if _, n := s.entries(); n != 65 {
+		t.Fatalf("entries %d", n)
+	}
+}
+
+func TestNonceDatabaseUniqueness(t *testing.T) {
+	setNonceConfig(t, 0)
+	ctx := context.Background()
+	db := testdb.Open(t)
+	repo := repository.NewNonceRepository(db)
+
+	// 两个 Hub 进程（或重启前后）各自的内存存储共享同一数据库：nonce 只能使用一次
+	a, b := NewNonceStore(repo), NewNonceStore(repo)
+	if err := a.Use(ctx, "auth", []byte("n1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Use(ctx, "auth", []byte("n1")); !errors.Is(err, ErrNonceReplay) {
+		t.Fatalf("replay on another hub: %v", err)
+	}
+	if err := b.Use(ctx, "rotate", []byte("n1")); err != nil {
+		t.Fatalf("other scope: %v", err)
+	}
+	var stored []database.UsedNonce
+	if err := db.Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || stored[0].Value == "n1" || len(stored[0].Value) != 64 {
+		t.Fatalf("stored %+v", stored)
+	}
+	if d := time.Until(stored[0].ExpiresAt); d > a.ttl || d < a.ttl-time.Minute {
+		t.Fatalf("expires in %v", d)
+	}
+
+	// 并发：多个存储同时登记同一 nonce，仅一次成功（SQLite 内存库按单连接串行写入）
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	stores := []*NonceStore{NewNonceStore(repo), NewNonceStore(repo), NewNonceStore(repo), NewNonceStore(repo)}
+	if ok, errs := useConcurrently(stores, 32, func(int) []byte { return []byte("race") }); ok != 1 || len(errs) > 0 {
+		t.Fatalf("same nonce accepted %d times across hubs, errors %v", ok, errs)
+	}
+
+	// 过期记录清理后可再次插入（独立数据库，避免与存储的后台清理交错）
+	repo = repository.NewNonceRepository(testdb.Open(t))
+	if ok, err := repo.Insert(ctx, "auth", "expired", time.Now().Add(-time.Second)); err != nil || !ok {
+		t.Fatalf("insert: %v %v", ok, err)
+	}
+	if ok, err := repo.Insert(ctx, "auth", "expired", time.Now().Add(time.Minute)); err != nil || ok {
+		t.Fatalf("duplicate insert: %v %v", ok, err)
+	}
+	if n, err := repo.DeleteExpired(ctx, time.Now()); err != nil || n != 1 {
+		t.Fatalf("deleted %d: %v", n, err)
+	}
+	if ok, err := repo.Insert(ctx, "auth", "expired", time.Now().Add(time.Minute)); err != nil || !ok {
+		t.Fatalf("insert after cleanup: %v %v", ok, err)
+	}
+}
@@ -0,0 +1,566 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/pkg/protocol/binproto/filexfer"
+	"myflowhub/server/internal/repository"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/datatypes"
+)
+
+// 活动状态
+const (
+	OTAStatusDraft     = "draft"
+	OTAStatusRunning   = "running"
+	OTAStatusPaused    = "paused"
+	OTAStatusAborted   = "aborted"
+	OTAStatusCompleted = "completed"
+)
+
+// 设备升级状态
+const (
+	OTAStatePending     = "pending"
+	OTAStateDownloading = "downloading"
+	OTAStateInstalling  = "installing"
+	OTAStateSucceeded   = "succeeded"
+	OTAStateFailed      = "failed"
+	OTAStateSkipped     = "skipped" // 批次开始后长时间离线，未下发即跳过
+)
+
+// 设备上报状态所用的变量名
+const (
+	OTAVarState    = "ota_state"
+	OTAVarProgress = "ota_progress"
+	OTAVarError    = "ota_error"
+	OTAVarVersion  = "fw_version"
+	OTAVarModel    = "hw_model"
+)
+
+const (
+	otaMaxConcurrent   = 16               // 单个活动同时下发的设备上限
+	otaPendingTimeout  = 24 * time.Hour   // 批次开始后设备一直未上线的时限，超时跳过
+	otaDownloadTimeout = 2 * time.Hour    // 单台设备下载固件的时限
+	otaInstallTimeout  = 30 * time.Minute // 下载完成后等待设备上报结果的时限
+)
+
+var ErrOTABadAction = errors.New("invalid campaign action")
+
+// FrameSender 向设备主动下发帧（由 hub.Server 实现，不阻塞）
+type FrameSender interface {
+	SendTo(target uint64, typeID uint16, msgID uint64, payload []byte) error
+}
+
+// OTAService 管理固件制品、升级活动与分批下发
+type OTAService struct {
+	repo       *repository.OTARepository
+	deviceRepo *repository.DeviceRepository
+	varRepo    *repository.VariableRepository
+	files      *FileService
+	presence   *PresenceService // 在线视图（含经中继上报的下级）
+	transport  FrameSender
+	msgSeq     uint64
+
+	mu      sync.Mutex
+	senders map[uint64]*filexfer.Sender // OTADeviceState.ID -> 在途下发
+}
+
+// NewOTAService 创建一个新的 OTAService
+func NewOTAService(repo *repository.OTARepository, deviceRepo *repository.DeviceRepository, varRepo *repository.VariableRepository, files *FileService, presence *PresenceService) *OTAService {
+	return &OTAService{
+		repo:       repo,
+		deviceRepo: deviceRepo,
+		varRepo:    varRepo,
+		files:      files,
+		presence:   presence,
+		senders:    make(map[uint64]*filexfer.Sender),
+	}
+}
+
+// SetTransport 注入下发通道（hub.Server 创建后调用）
+func (s *OTAService) SetTransport(t FrameSender) { s.transport = t }
+
+// CreateArtifact 登记固件制品；file_id 须为已完成上传且哈希一致的文件
+func (s *OTAService) CreateArtifact(ctx context.Context, version, hardwareModel string, fileID uint64, sha256Hex string, createdBy *uint64) (*database.FirmwareArtifact, error) {
+	if strings.TrimSpace(version) == "" {
+		return nil, fmt.Errorf("version required")
+	}
+	f, err := s.files.GetFile(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found")
+	}
+	if f.Status != "completed" {
+		return nil, fmt.Errorf("file upload not completed")
+	}
+	if sha256Hex != "" && !strings.EqualFold(sha256Hex, f.SHA256) {
+		return nil, fmt.Errorf("sha256 mismatch")
+	}
+	a := &database.FirmwareArtifact{
+		Version:       version,
+		HardwareModel: hardwareModel,
+		FileID:        fileID,
+		Size:          f.Size,
+		SHA256:        f.SHA256,
+		CreatedBy:     createdBy,
+	}
+	if err := s.repo.CreateArtifact(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// ListArtifacts 列出固件制品
+func (s *OTAService) ListArtifacts(ctx context.Context) ([]database.FirmwareArtifact, error) {
+	return s.repo.ListArtifacts(ctx)
+}
+
+// CreateCampaign 创建活动（draft），按目标条件与型号解析设备集合并分配批次
+func (s *OTAService) CreateCampaign(ctx context.Context, spec bin.OTACampaignSpec, createdBy *uint64) (*database.OTACampaign, error) {
+	art, err := s.repo.FindArtifact(ctx, spec.ArtifactID)
+	if err != nil {
+		return nil, fmt.Errorf("artifact not found")
+	}
+	if spec.OwnerUserID == nil && spec.ParentID == nil && (spec.Tag == nil || *spec.Tag == "") {
+		return nil, fmt.Errorf("target required: owner, parent or tag")
+	}
+	stages, err := normalizeStages(spec.Stages)
+	if err != nil {
+		return nil, err
+	}
+	if spec.MaxFailurePct > 100 {
+		return nil, fmt.Errorf("max_failure_pct out of range")
+	}
+	devices, err := s.resolveTargets(ctx, spec, art)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].DeviceUID < devices[j].DeviceUID })
+	states := make([]database.OTADeviceState, 0, len(devices))
+	for i, d := range devices {
+		states = append(states, database.OTADeviceState{DeviceUID: d.DeviceUID, Stage: stageOf(i, len(devices), stages), State: OTAStatePending})
+	}
+	stagesJSON, _ := json.Marshal(stages)
+	c := &database.OTACampaign{
+		Name:              spec.Name,
+		ArtifactID:        art.ID,
+		Status:            OTAStatusDraft,
+		TargetOwnerUserID: spec.OwnerUserID,
+		TargetParentID:    spec.ParentID,
+		TargetTag:         spec.Tag,
+		Stages:            datatypes.JSON(stagesJSON),
+		MaxFailurePct:     spec.MaxFailurePct,
+		CreatedBy:         createdBy,
+	}
+	if err := s.repo.CreateCampaign(ctx, c, states); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListCampaigns 列出活动
+func (s *OTAService) ListCampaigns(ctx context.Context) ([]database.OTACampaign, error) {
+	return s.repo.ListCampaigns(ctx)
+}
+
+// CampaignStatus 返回活动及其设备状态
+func (s *OTAService) CampaignStatus(ctx context.Context, id uint64) (*database.OTACampaign, []database.OTADeviceState, error) {
+	c, err := s.repo.FindCampaign(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	states, err := s.repo.ListStates(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, states, nil
+}
+
+// Counts 返回活动的设备总数、成功数与失败数
+func (s *OTAService) Counts(ctx context.Context, id uint64) (total, succeeded, failed uint32) {
+	m, err := s.repo.CountStates(ctx, id)
+	if err != nil {
+		return 0, 0, 0
+	}
+	for _, n := range m {
+		total += uint32(n)
+	}
+	return total, uint32(m[OTAStateSucceeded]), uint32(m[OTAStateFailed])
+}
+
+// Control 启动/暂停/恢复/中止活动
+func (s *OTAService) Control(ctx context.Context, id uint64, action string) error {
+	c, err := s.repo.FindCampaign(ctx, id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case action == "start" && c.Status == OTAStatusDraft,
+		action == "resume" && c.Status == OTAStatusPaused:
+		// 等待上线的时限自启动/恢复起重新计算，暂停期间不计入
+		now := time.Now()
+		c.Status, c.StageStartedAt = OTAStatusRunning, &now
+	case action == "pause" && c.Status == OTAStatusRunning:
+		// 暂停只停止新的下发，在途传输继续完成
+		c.Status = OTAStatusPaused
+	case action == "abort" && (c.Status == OTAStatusDraft || c.Status == OTAStatusRunning || c.Status == OTAStatusPaused):
+		c.Status = OTAStatusAborted
+		s.abortTransfers(ctx, c.ID)
+	default:
+		return ErrOTABadAction
+	}
+	return s.repo.SaveCampaign(ctx, c)
+}
+
+// Run 周期性推进运行中的活动（阻塞，应在独立协程中调用）
+func (s *OTAService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.Tick(context.Background())
+	}
+}
+
+// Tick 推进一次所有运行中的活动
+func (s *OTAService) Tick(ctx context.Context) {
+	if s.transport == nil {
+		return
+	}
+	campaigns, err := s.repo.ListCampaignsByStatus(ctx, OTAStatusRunning)
+	if err != nil {
+		log.Error().Err(err).Msg("查询运行中的 OTA 活动失败")
+		return
+	}
+	for i := range campaigns {
+		s.tickCampaign(ctx, &campaigns[i])
+	}
+}
+
+func (s *OTAService) tickCampaign(ctx context.Context, c *database.OTACampaign) {
+	art, err := s.repo.FindArtifact(ctx, c.ArtifactID)
+	if err != nil {
+		log.Error().Err(err).Uint64("campaign", c.ID).Msg("OTA 活动的固件制品不存在")
+		return
+	}
+	states, err := s.repo.ListStates(ctx, c.ID)
+	if err != nil {
+		return
+	}
+	active := 0
+	for _, st := range states {
+		if st.State == OTAStateDownloading {
+			active++
+		}
+	}
+	for i := range states {
+		st := &states[i]
+		if st.Stage > c.CurrentStage {
+			continue
+		}
+		changed := false
+		switch st.State {
+		case OTAStatePending:
+			if !s.presence.IsOnline(st.DeviceUID) {
+				if c.StageStartedAt != nil && time.Since(*c.StageStartedAt) > otaPendingTimeout {
+					st.State, st.Error = OTAStateSkipped, "device offline"
+					changed = true
+					break
+				}
+				continue
+			}
+			if active >= otaMaxConcurrent {
+				continue
+			}
+			if changed = s.startDownload(ctx, st, art); changed {
+				active++
+			}
+		case OTAStateDownloading:
+			changed = s.pollDownload(st, art)
+		case OTAStateInstalling:
+			changed = s.pollReport(ctx, st, art)
+		}
+		if changed {
+			if err := s.repo.SaveState(ctx, st); err != nil {
+				log.Error().Err(err).Uint64("device", st.DeviceUID).Msg("保存 OTA 设备状态失败")
+			}
+		}
+	}
+	s.advance(ctx, c, states)
+}
+
+func (s *OTAService) startDownload(ctx context.Context, st *database.OTADeviceState, art *database.FirmwareArtifact) bool {
+	uid := st.DeviceUID
+	sender, err := s.files.SendStored(ctx, art.FileID, uid, func(typeID uint16, payload []byte) error {
+		return s.transport.SendTo(uid, typeID, atomic.AddUint64(&s.msgSeq, 1), payload)
+	})
+	if err != nil {
+		st.State, st.Error = OTAStateFailed, err.Error()
+		return true
+	}
+	s.mu.Lock()
+	s.senders[st.ID] = sender
+	s.mu.Unlock()
+	now := time.Now()
+	st.State, st.Progress, st.Error, st.StartedAt, st.InstallingSince = OTAStateDownloading, 0, "", &now, nil
+	log.Info().Uint64("device", uid).Str("version", art.Version).Msg("开始下发固件")
+	return true
+}
+
+func (s *OTAService) pollDownload(st *database.OTADeviceState, art *database.FirmwareArtifact) bool {
+	s.mu.Lock()
+	sender, ok := s.senders[st.ID]
+	s.mu.Unlock()
+	if !ok {
+		// Hub 重启后在途传输已丢失：重新排队
+		st.State, st.Progress = OTAStatePending, 0
+		return true
+	}
+	switch sender.State() {
+	case filexfer.StateDone:
+		s.dropSender(st.ID)
+		now := time.Now()
+		st.State, st.Progress, st.InstallingSince = OTAStateInstalling, 0, &now
+		return true
+	case filexfer.StateFailed, filexfer.StateCancelled:
+		s.dropSender(st.ID)
+		if !s.presence.IsOnline(st.DeviceUID) {
+			// 设备离线导致中断：待重连后重新下发
+			st.State, st.Progress = OTAStatePending, 0
+			return true
+		}
+		st.State, st.Error = OTAStateFailed, "download failed"
+		return true
+	}
+	if st.StartedAt != nil && time.Since(*st.StartedAt) > otaDownloadTimeout {
+		s.dropSender(st.ID)
+		_ = sender.Cancel("download timeout")
+		st.State, st.Error = OTAStateFailed, "download timeout"
+		return true
+	}
+	if art.Size == 0 {
+		return false
+	}
+	p := uint32(sender.Acked() * 100 / art.Size)
+	if p == st.Progress {
+		return false
+	}
+	st.Progress = p
+	return true
+}
+
+// pollReport 读取设备上报的变量（仅采信下发开始后更新的值）
+func (s *OTAService) pollReport(ctx context.Context, st *database.OTADeviceState, art *database.FirmwareArtifact) bool {
+	dev, err := s.deviceRepo.FindByUID(ctx, st.DeviceUID)
+	if err != nil {
+		return false
+	}
+	vars, err := s.varRepo.FindByDeviceID(ctx, dev.ID)
+	if err != nil {
+		return false
+	}
+	fresh := make(map[string]datatypes.JSON, len(vars))
+	for _, v := range vars {
+		if st.StartedAt == nil || v.UpdatedAt.After(*st.StartedAt) {
+			fresh[v.VariableName] = v.Value
+		}
+	}
+	if v, ok := fresh[OTAVarVersion]; ok && jsonString(v) == art.Version {
+		st.State, st.Progress, st.Error = OTAStateSucceeded, 100, ""
+		return true
+	}
+	changed := false
+	if v, ok := fresh[OTAVarProgress]; ok {
+		var p float64
+		if json.Unmarshal(v, &p) == nil && p >= 0 && p <= 100 && uint32(p) != st.Progress {
+			st.Progress = uint32(p)
+			changed = true
+		}
+	}
+	switch jsonString(fresh[OTAVarState]) {
+	case OTAStateSucceeded:
+		st.State, st.Progress = OTAStateSucceeded, 100
+		return true
+	case OTAStateFailed:
+		st.State, st.Error = OTAStateFailed, truncate(jsonString(fresh[OTAVarError]), 512)
+		return true
+	}
+	if st.InstallingSince == nil {
+		// 升级前的记录没有该时刻：自此开始计时
+		now := time.Now()
+		st.InstallingSince = &now
+		return true
+	}
+	if time.Since(*st.InstallingSince) > otaInstallTimeout {
+		st.State, st.Error = OTAStateFailed, "install timeout"
+		return true
+	}
+	return changed
+}
+
+// advance 当前批次全部结束（成功、失败或跳过）后推进到下一批；失败率超限则自动暂停
+func (s *OTAService) advance(ctx context.Context, c *database.OTACampaign, states []database.OTADeviceState) {
+	var stageTotal, stageFailed int
+	for _, st := range states {
+		if st.Stage > c.CurrentStage {
+			continue
+		}
+		if st.State != OTAStateSucceeded && st.State != OTAStateFailed && st.State != OTAStateSkipped {
+			return
+		}
+		if st.Stage == c.CurrentStage {
+			stageTotal++
+			if st.State == OTAStateFailed {
+				stageFailed++
+			}
+		}
+	}
+	var stages []uint32
+	_ = json.Unmarshal(c.Stages, &stages)
+	switch {
+	case c.MaxFailurePct > 0 && stageTotal > 0 && uint32(stageFailed*100/stageTotal) > c.MaxFailurePct:
+		c.Status = OTAStatusPaused
+		log.Warn().Uint64("campaign", c.ID).Uint32("stage", c.CurrentStage).Int("failed", stageFailed).Int("total", stageTotal).Msg("OTA 批次失败率超限，活动已自动暂停")
+	case int(c.CurrentStage)+1 < len(stages):
+		now := time.Now()
+		c.CurrentStage, c.StageStartedAt = c.CurrentStage+1, &now
+		log.Info().Uint64("campaign", c.ID).Uint32("stage", c.CurrentStage).Msg("OTA 活动进入下一批次")
+	default:
+		c.Status = OTAStatusCompleted
+		log.Info().Uint64("campaign", c.ID).Msg("OTA 活动已完成")
+	}
+	if err := s.repo.SaveCampaign(ctx, c); err != nil {
+		log.Error().Err(err).Uint64("campaign", c.ID).Msg("保存 OTA 活动失败")
+	}
+}
+
+func (s *OTAService) abortTransfers(ctx context.Context, campaignID uint64) {
+	states, err := s.repo.ListStates(ctx, campaignID)
+	if err != nil {
+		return
+	}
+	for i := range states {
+		st := &states[i]
+		if st.State != OTAStateDownloading {
+			continue
+		}
+		s.mu.Lock()
+		sender := s.senders[st.ID]
+		delete(s.senders, st.ID)
+		s.mu.Unlock()
+		if sender != nil {
+			_ = sender.Cancel("campaign aborted")
+		}
+		st.State, st.Error = OTAStateFailed, "campaign aborted"
+		_ = s.repo.SaveState(ctx, st)
+	}
+}
+
+func (s *OTAService) dropSender(stateID uint64) {
+	s.mu.Lock()
+	delete(s.senders, stateID)
+	s.mu.Unlock()
+}
+
+// resolveTargets 按所有者/子树/标签（取交集）与固件型号筛选目标设备
+func (s *OTAService) resolveTargets(ctx context.Context, spec bin.OTACampaignSpec, art *database.FirmwareArtifact) ([]database.Device, error) {
+	var devices []database.Device
+	var err error
+	switch {
+	case spec.ParentID != nil:
+		devices, err = s.deviceRepo.ListDescendantsOfUID(ctx, *spec.ParentID)
+	case spec.OwnerUserID != nil:
+		devices, err = s.deviceRepo.ListByOwner(ctx, *spec.OwnerUserID)
+	default:
+		devices, err = s.deviceRepo.FindAll(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := make([]database.Device, 0, len(devices))
+	for _, d := range devices {
+		if !d.Approved || d.Role == database.RoleManager || d.Role == database.RoleHub {
+			continue
+		}
+		if spec.OwnerUserID != nil && (d.OwnerUserID == nil || *d.OwnerUserID != *spec.OwnerUserID) {
+			continue
+		}
+		if spec.Tag != nil && *spec.Tag != "" && !hasTag(d.Tags, *spec.Tag) {
+			continue
+		}
+		if art.HardwareModel != "" {
+			v, err := s.varRepo.FindByOwnerAndName(ctx, d.ID, OTAVarModel)
+			if err != nil || jsonString(v.Value) != art.HardwareModel {
+				continue
+			}
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// hasTag 设备标签（Device.Tags，逗号分隔，由用户/管理员设置）是否包含 tag
+func hasTag(tags, tag string) bool {
+	for _, t := range strings.Split(tags, ",") {
+		if strings.TrimSpace(t) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeStages 校验累计百分比：严格递增、范围 1~100 且最后一批为 100；为空时一次全量
+func normalizeStages(in []uint32) ([]uint32, error) {
+	if len(in) == 0 {
+		return []uint32{100}, nil
+	}
+	var prev uint32
+	for _, p := range in {
+		if p == 0 || p > 100 || p <= prev {
+			return nil, fmt.Errorf("stages must be increasing percentages within 1~100")
+		}
+		prev = p
+	}
+	if prev != 100 {
+		return nil, fmt.Errorf("last stage must be 100")
+	}
+	return append([]uint32(nil), in...), nil
+}
+
+// stageOf 返回第 i 台设备（共 n 台）所在批次
+func stageOf(i, n int, stages []uint32) uint32 {
+	for j, p := range stages {
+		if i < (n*int(p)+99)/100 {
+			return uint32(j)
+		}
+	}
+	return uint32(len(stages) - 1)
+}
+
+// jsonString 将 JSON 值解释为字符串（非字符串时返回原始文本）
+func jsonString(v datatypes.JSON) string {
+	if len(v) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	return string(v)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/pkg/protocol/binproto/filexfer"
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/testdb"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// sentFrame 经 FrameSender 下发的一帧
+type sentFrame struct {
+	target  uint64
+	typeID  uint16
+	payload []byte
+}
+
+// recordingSender 记录下发帧的 FrameSender
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []sentFrame
+}
+
+func (r *recordingSender) SendTo(target uint64, typeID uint16, _ uint64, payload []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, sentFrame{target, typeID, payload})
+	return nil
+}
+
+// take 取出并清空已记录的帧
+func (r *recordingSender) take() []sentFrame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := r.sent
+	r.sent = nil
+	return out
+}
+
+type otaFixture struct {
+	db       *gorm.DB
+	svc      *OTAService
+	presence *PresenceService
+	vars     *repository.VariableRepository
+	art      *database.FirmwareArtifact
+	seq      int
+}
+
+// newOTAFixture 创建内存数据库上的 OTAService，并登记一个 hardwareModel 型号的 1.1.0 固件
+func newOTAFixture(t *testing.T, hardwareModel string) *otaFixture {
+	t.Helper()
+	db := testdb.Open(t)
+	content := []byte("firmware image 1.1.0")
+	path := filepath.Join(t.TempDir(), "fw.bin")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	file := &database.StoredFile{TransferID: 1, OwnerDeviceID: 1, Filename: "fw.bin", Size: uint64(len(content)), SHA256: hex.EncodeToString(sum[:]), Path: path, Received: uint64(len(content)), Status: "completed"}
+	if err := db.Create(file).Error; err != nil {
+		t.Fatal(err)
+	}
+	devices := repository.NewDeviceRepository(db)
+	f := &otaFixture{db: db, presence: NewPresenceService(devices), vars: repository.NewVariableRepository(db)}
+	f.svc = NewOTAService(repository.NewOTARepository(db), devices, f.vars, NewFileService(repository.NewFileRepository(db)), f.presence)
+	f.svc.SetTransport(&recordingSender{})
+	art, err := f.svc.CreateArtifact(context.Background(), "1.1.0", hardwareModel, file.ID, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.art = art
+	return f
+}
+
+// addDevice 登记一台设备，model 非空时同时写入型号变量
+func (f *otaFixture) addDevice(t *testing.T, d database.Device, model string) database.Device {
+	t.Helper()
+	f.seq++
+	d.HardwareID = fmt.Sprintf("hw-%d", f.seq)
+	if d.Role == "" {
+		d.Role = database.RoleNode
+	}
+	if err := f.db.Create(&d).Error; err != nil {
+		t.Fatal(err)
+	}
+	if model != "" {
+		f.setVar(t, d.ID, OTAVarModel, `"`+model+`"`)
+	}
+	return d
+}
+
+func (f *otaFixture) setVar(t *testing.T, deviceID uint64, name, value string) {
+	t.Helper()
+	v := &database.DeviceVariable{OwnerDeviceID: deviceID, VariableName: name, Value: datatypes.JSON(value)}
+	if err := f.vars.Upsert(context.Background(), v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// approvedNodes 登记 n 台已审批的普通设备，返回其 UID
+func (f *otaFixture) approvedNodes(t *testing.T, n int, owner uint64) []uint64 {
+	t.Helper()
+	uids := make([]uint64, 0, n)
+	for range n {
+		uids = append(uids, f.addDevice(t, database.Device{Approved: true, OwnerUserID: &owner}, "").DeviceUID)
+	}
+	return uids
+}
+
+func (f *otaFixture) online(via uint64, uids ...uint64) {
+	items := make([]bin.PresenceItem, 0, len(uids))
+	for _, uid := range uids {
+		items = append(items, bin.PresenceItem{DeviceUID: uid, Online: true})
+	}
+	f.presence.Apply(via, items, false)
+}
+
+func (f *otaFixture) campaign(t *testing.T, spec bin.OTACampaignSpec) *database.OTACampaign {
+	t.Helper()
+	spec.ArtifactID = f.art.ID
+	c, err := f.svc.CreateCampaign(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// states 返回活动内各设备的状态（按 UID）
+func (f *otaFixture) states(t *testing.T, id uint64) (*database.OTACampaign, map[uint64]database.OTADeviceState) {
+	t.Helper()
+	c, list, err := f.svc.CampaignStatus(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[uint64]database.OTADeviceState, len(list))
+	for _, st := range list {
+		m[st.DeviceUID] = st
+	}
+	return c, m
+}
+
+// finishDownload 模拟设备接收完整个固件
+func (f *otaFixture) finishDownload(t *testing.T, st database.OTADeviceState) {
+	t.Helper()
+	f.svc.mu.Lock()
+	sender := f.svc.senders[st.ID]
+	f.svc.mu.Unlock()
+	if sender == nil {
+		t.Fatalf("no transfer for device %d", st.DeviceUID)
+	}
+	if err := sender.HandleInitResp(true, nil, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.HandleAck(f.art.Size); err != nil {
+		t.Fatal(err)
+	}
+	sender.HandleCompleteResp(true)
+}
+
+func (f *otaFixture) updateState(t *testing.T, id uint64, cols map[string]any) {
+	t.Helper()
+	if err := f.db.Model(&database.OTADeviceState{}).Where("id = ?", id).Updates(cols).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOTAResolveTargets(t *testing.T) {
+	f := newOTAFixture(t, "esp32")
+	owner, other := uint64(7), uint64(8)
+	root := f.addDevice(t, database.Device{Approved: true, Role: database.RoleRelay, OwnerUserID: &owner}, "esp32")
+	child := f.addDevice(t, database.Device{Approved: true, ParentID: &root.ID, OwnerUserID: &owner, Tags: "lab, east"}, "esp32")
+	grandchild := f.addDevice(t, database.Device{Approved: true, ParentID: &child.ID, OwnerUserID: &owner, Tags: "east"}, "esp32")
+	foreign := f.addDevice(t, database.Device{Approved: true, ParentID: &root.ID, OwnerUserID: &other, Tags: "east"}, "esp32")
+	outside := f.addDevice(t, database.Device{Approved: true, OwnerUserID: &owner, Tags: "west"}, "esp32")
+	// 以下设备不会成为目标：未审批、型号不符、未上报型号、管理端
+	f.addDevice(t, database.Device{Approved: false, ParentID: &root.ID, OwnerUserID: &owner, Tags: "east"}, "esp32")
+	f.addDevice(t, database.Device{Approved: true, ParentID: &root.ID, OwnerUserID: &owner, Tags: "east"}, "esp8266")
+	f.addDevice(t, database.Device{Approved: true, ParentID: &root.ID, OwnerUserID: &owner, Tags: "east"}, "")
+	f.addDevice(t, database.Device{Approved: true, ParentID: &root.ID, Role: database.RoleManager, OwnerUserID: &owner}, "esp32")
+
+	tag := "east"
+	cases := []struct {
+		name string
+		spec bin.OTACampaignSpec
+		want []uint64
+	}{
+		{"owner", bin.OTACampaignSpec{OwnerUserID: &owner}, []uint64{root.DeviceUID, child.DeviceUID, grandchild.DeviceUID, outside.DeviceUID}},
+		{"subtree", bin.OTACampaignSpec{ParentID: &root.DeviceUID}, []uint64{child.DeviceUID, grandchild.DeviceUID, foreign.DeviceUID}},
+		{"subtree and owner", bin.OTACampaignSpec{ParentID: &root.DeviceUID, OwnerUserID: &other}, []uint64{foreign.DeviceUID}},
+		{"tag", bin.OTACampaignSpec{Tag: &tag}, []uint64{child.DeviceUID, grandchild.DeviceUID, foreign.DeviceUID}},
+		{"owner and tag", bin.OTACampaignSpec{OwnerUserID: &owner, Tag: &tag}, []uint64{child.DeviceUID, grandchild.DeviceUID}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := f.campaign(t, tc.spec)
+			_, states := f.states(t, c.ID)
+			var got []uint64
+			for uid := range states {
+				got = append(got, uid)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("targets %v, want %v", got, tc.want)
+			}
+			for _, uid := range tc.want {
+				if _, ok := states[uid]; !ok {
+					t.Fatalf("targets %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+
+	if _, err := f.svc.CreateCampaign(context.Background(), bin.OTACampaignSpec{ArtifactID: f.art.ID}, nil); err == nil {
+		t.Fatal("campaign without target accepted")
+	}
+}
+
+func TestOTAStages(t *testing.T) {
+	for _, bad := range [][]uint32{{0, 100}, {50, 50, 100}, {60, 40, 100}, {10, 50}, {101}} {
+		if _, err := normalizeStages(bad); err == nil {
+			t.Errorf("stages %v accepted", bad)
+		}
+	}
+
+	f := newOTAFixture(t, "")
+	uids := f.approvedNodes(t, 10, 1)
+	owner := uint64(1)
+	c := f.campaign(t, bin.OTACampaignSpec{OwnerUserID: &owner, Stages: []uint32{10, 50, 100}})
+	_, states := f.states(t, c.ID)
+	perStage := make([]int, 3)
+	for i, uid := range uids {
+		st := states[uid]
+		perStage[st.Stage]++
+		// 批次按 UID 升序划分
+		if i > 0 && st.Stage < states[uids[i-1]].Stage {
+			t.Fatalf("device %d in stage %d after stage %d", uid, st.Stage, states[uids[i-1]].Stage)
+		}
+	}
+	if !reflect.DeepEqual(perStage, []int{1, 4, 5}) {
+		t.Fatalf("devices per stage %v", perStage)
+	}
+
+	// 只下发当前批次；批次全部结束后推进到下一批
+	ctx := context.Background()
+	f.online(0, uids...)
+	if err := f.svc.Control(ctx, c.ID, "start"); err != nil {
+		t.Fatal(err)
+	}
+	f.svc.Tick(ctx)
+	_, states = f.states(t, c.ID)
+	for _, uid := range uids {
+		if want := map[bool]string{true: OTAStateDownloading, false: OTAStatePending}[states[uid].Stage == 0]; states[uid].State != want {
+			t.Fatalf("device %d stage %d: %s", uid, states[uid].Stage, states[uid].State)
+		}
+	}
+	f.updateState(t, states[uids[0]].ID, map[string]any{"state": OTAStateSucceeded})
+	f.svc.dropSender(states[uids[0]].ID)
+	f.svc.Tick(ctx)
+	camp, states := f.states(t, c.ID)
+	if camp.CurrentStage != 1 || camp.StageStartedAt == nil {
+		t.Fatalf("campaign stage %d started %v", camp.CurrentStage, camp.StageStartedAt)
+	}
+	f.svc.Tick(ctx)
+	_, states = f.states(t, c.ID)
+	for _, uid := range uids[1:5] {
+		if states[uid].State != OTAStateDownloading {
+			t.Fatalf("stage 1 device %d: %s", uid, states[uid].State)
+		}
+	}
+}
+
+func TestOTAFailureRatePauses(t *testing.T) {
+	f := newOTAFixture(t, "")
+	owner := uint64(1)
+	uids := f.approvedNodes(t, 4, owner)
+	c := f.campaign(t, bin.OTACampaignSpec{OwnerUserID: &owner, Stages: []uint32{50, 100}, MaxFailurePct: 40})
+	ctx := context.Background()
+	if err := f.svc.Control(ctx, c.ID, "start"); err != nil {
+		t.Fatal(err)
+	}
+	_, states := f.states(t, c.ID)
+	f.updateState(t, states[uids[0]].ID, map[string]any{"state": OTAStateFailed})
+	f.updateState(t, states[uids[1]].ID, map[string]any{"state": OTAStateSucceeded})
+	f.svc.Tick(ctx)
+	camp, _ := f.states(t, c.ID)
+	if camp.Status != OTAStatusPaused || camp.CurrentStage != 0 {
+		t.Fatalf("campaign %s stage %d", camp.Status, camp.CurrentStage)
+	}
+	total, ok, failed := f.svc.Counts(ctx, c.ID)
+	if total != 4 || ok != 1 || failed != 1 {
+		t.Fatalf("counts %d/%d/%d", total, ok, failed)
+	}
+}
+
+func TestOTAPauseAbort(t *testing.T) {
+	f := newOTAFixture(t, "")
+	owner := uint64(1)
+	uids := f.approvedNodes(t, 2, owner)
+	c := f.campaign(t, bin.OTACampaignSpec{OwnerUserID: &owner})
+	ctx := context.Background()
+	if err := f.svc.Control(ctx, c.ID, "pause"); err != ErrOTABadAction {
+		t.Fatalf("pause draft: %v", err)
+	}
+	if err := f.svc.Control(ctx, c.ID, "start"); err != nil {
+		t.Fatal(err)
+	}
+	f.online(0, uids[0])
+	f.svc.Tick(ctx)
+	if err := f.svc.Control(ctx, c.ID, "pause"); err != nil {
+		t.Fatal(err)
+	}
+	// 暂停后不再下发新设备，在途传输保留
+	f.online(0, uids[1])
+	f.svc.Tick(ctx)
+	_, states := f.states(t, c.ID)
+	if states[uids[0]].State != OTAStateDownloading || states[uids[1]].State != OTAStatePending {
+		t.Fatalf("after pause: %s %s", states[uids[0]].State, states[uids[1]].State)
+	}
+	f.svc.mu.Lock()
+	sender := f.svc.senders[states[uids[0]].ID]
+	f.svc.mu.Unlock()
+
+	if err := f.svc.Control(ctx, c.ID, "abort"); err != nil {
+		t.Fatal(err)
+	}
+	camp, states := f.states(t, c.ID)
+	if camp.Status != OTAStatusAborted {
+		t.Fatalf("status %s", camp.Status)
+	}
+	if st := states[uids[0]]; st.State != OTAStateFailed || st.Error != "campaign aborted" {
+		t.Fatalf("aborted transfer: %s %q", st.State, st.Error)
+	}
+	if sender.State() != filexfer.StateCancelled {
+		t.Fatalf("transfer not cancelled: %v", sender.State())
+	}
+	if err := f.svc.Control(ctx, c.ID, "resume"); err != ErrOTABadAction {
+		t.Fatalf("resume aborted: %v", err)
+	}
+}
+
+func TestOTAReportedVariables(t *testing.T) {
+	f := newOTAFixture(t, "")
+	owner := uint64(1)
+	uids := f.approvedNodes(t, 4, owner)
+	ctx := context.Background()
+	// 下发前遗留的 fw_version 不应被采信
+	f.setVar(t, mustDeviceID(t, f, uids[0]), OTAVarVersion, `"1.1.0"`)
+	time.Sleep(10 * time.Millisecond)
+
+	c := f.campaign(t, bin.OTACampaignSpec{OwnerUserID: &owner})
+	f.online(0, uids...)
+	if err := f.svc.Control(ctx, c.ID, "start"); err != nil {
+		t.Fatal(err)
+	}
+	f.svc.Tick(ctx)
+	_, states := f.states(t, c.ID)
+	for _, uid := range uids {
+		f.finishDownload(t, states[uid])
+	}
+	f.svc.Tick(ctx)
+	_, states = f.states(t, c.ID)
+	for _, uid := range uids {
+		if st := states[uid]; st.State != OTAStateInstalling || st.InstallingSince == nil {
+			t.Fatalf("device %d after download: %s", uid, st.State)
+		}
+	}
+
+	ids := make([]uint64, len(uids))
+	for i, uid := range uids {
+		ids[i] = mustDeviceID(t, f, uid)
+	}
+	f.setVar(t, ids[1], OTAVarVersion, `"1.1.0"`)
+	f.setVar(t, ids[2], OTAVarState, `"failed"`)
+	f.setVar(t, ids[2], OTAVarError, `"flash write error"`)
+	f.setVar(t, ids[3], OTAVarProgress, `42`)
+	f.svc.Tick(ctx)
+	_, states = f.states(t, c.ID)
+	if st := states[uids[0]]; st.State != OTAStateInstalling {
+		t.Fatalf("stale version accepted: %s", st.State)
+	}
+	if st := states[uids[1]]; st.State != OTAStateSucceeded || st.Progress != 100 {
+		t.Fatalf("version report: %s %d", st.State, st.Progress)
+	}
+	if st := states[uids[2]]; st.State != OTAStateFailed || st.Error != "flash write error" {
+		t.Fatalf("failure report: %s %q", st.State, st.Error)
+	}
+	if st := states[uids[3]]; st.State != OTAStateInstalling || st.Progress != 42 {
+		t.Fatalf("progress report: %s %d", st.State, st.Progress)
+	}
+
+	// 进度上报不重置安装计时
+	f.updateState(t, states[uids[3]].ID, map[string]any{"installing_since": time.Now().Add(-otaInstallTimeout - time.Minute)})
+	f.setVar(t, ids[0], OTAVarState, `"succeeded"`)
+	f.setVar(t, ids[3], OTAVarProgress, `80`)
+	f.svc.Tick(ctx)
+	camp, states := f.states(t, c.ID)
+	if st := states[uids[0]]; st.State != OTAStateSucceeded {
+		t.Fatalf("state report: %s", st.State)
+	}
+	if st := states[uids[3]]; st.State != OTAStateFailed || st.Error != "install timeout" {
+		t.Fatalf("install timeout: %s %q", st.State, st.Error)
+	}
+	if camp.Status != OTAStatusCompleted {
+		t.Fatalf("campaign %s", camp.Status)
+	}
+}
+
+func TestOTAPresenceAndTimeouts(t *testing.T) {
+	f := newOTAFixture(t, "")
+	owner := uint64(1)
+	uids := f.approvedNodes(t, 4, owner)
+	c := f.campaign(t, bin.OTACampaignSpec{OwnerUserID: &owner, Stages: []uint32{75, 100}})
+	ctx := context.Background()
+	if err := f.svc.Control(ctx, c.ID, "start"); err != nil {
+		t.Fatal(err)
+	}
+	// uids[0] 直连、uids[1] 经中继在线、uids[2] 离线
+	f.online(0, uids[0])
+	f.online(900, uids[1])
+	f.svc.Tick(ctx)
+	_, states := f.states(t, c.ID)
+	if states[uids[0]].State != OTAStateDownloading || states[uids[1]].State != OTAStateDownloading {
+		t.Fatalf("online devices: %s %s", states[uids[0]].State, states[uids[1]].State)
+	}
+	if states[uids[2]].State != OTAStatePending {
+		t.Fatalf("offline device: %s", states[uids[2]].State)
+	}
+
+	// 下载超时判定失败；离线超过时限的设备跳过，批次随之推进
+	f.updateState(t, states[uids[0]].ID, map[string]any{"started_at": time.Now().Add(-otaDownloadTimeout - time.Minute)})
+	f.finishDownload(t, states[uids[1]])
+	f.svc.Tick(ctx)
+	_, states = f.states(t, c.ID)
+	if st := states[uids[0]]; st.State != OTAStateFailed || st.Error != "download timeout" {
+		t.Fatalf("download timeout: %s %q", st.State, st.Error)
+	}
+	f.setVar(t, mustDeviceID(t, f, uids[1]), OTAVarVersion, `"1.1.0"`)
+	if err := f.db.Model(&database.OTACampaign{}).Where("id = ?", c.ID).Update("stage_started_at", time.Now().Add(-otaPendingTimeout-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	f.svc.Tick(ctx)
+	camp, states := f.states(t, c.ID)
+	if st := states[uids[2]]; st.State != OTAStateSkipped {
+		t.Fatalf("offline device after deadline: %s", st.State)
+	}
+	if states[uids[1]].State != OTAStateSucceeded || camp.CurrentStage != 1 {
+		t.Fatalf("stage %d, device %s", camp.CurrentStage, states[uids[1]].State)
+	}
+	if states[uids[3]].State != OTAStatePending {
+		t.Fatalf("next stage device skipped with the old deadline: %s", states[uids[3]].State)
+	}
+}
+
+func mustDeviceID(t *testing.T, f *otaFixture, uid uint64) uint64 {
+	t.Helper()
+	d, err := repository.NewDeviceRepository(f.db).FindByUID(context.Background(), uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.ID
+}
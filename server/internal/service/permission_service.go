@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 	"myflowhub/server/internal/repository"
 )
@@ -16,8 +17,8 @@ func NewPermissionService(deviceRepo *repository.DeviceRepository) *PermissionSe
 }
 
 // IsAdminDevice: 设备是否为管理器（视为具备 admin.manage）
-func (p *PermissionService) IsAdminDevice(deviceUID uint64) bool {
-	dev, err := p.deviceRepo.FindByUID(deviceUID)
+func (p *PermissionService) IsAdminDevice(ctx context.Context, deviceUID uint64) bool {
+	dev, err := p.deviceRepo.FindByUID(ctx, deviceUID)
 	if err != nil {
 		return false
 	}
@@ -25,27 +26,27 @@ func (p *PermissionService) IsAdminDevice(deviceUID uint64) bool {
 }
 
 // CanAccessAllDevices: 只有管理员可获取所有设备
-func (p *PermissionService) CanAccessAllDevices(requesterUID uint64) bool {
-	return p.IsAdminDevice(requesterUID)
+func (p *PermissionService) CanAccessAllDevices(ctx context.Context, requesterUID uint64) bool {
+	return p.IsAdminDevice(ctx, requesterUID)
 }
 
 // CanReadVarsForDevice: 管理员或目标即自己
-func (p *PermissionService) CanReadVarsForDevice(requesterUID uint64, targetUID uint64) bool {
-	if p.IsAdminDevice(requesterUID) {
+func (p *PermissionService) CanReadVarsForDevice(ctx context.Context, requesterUID uint64, targetUID uint64) bool {
+	if p.IsAdminDevice(ctx, requesterUID) {
 		return true
 	}
 	return requesterUID == targetUID
 }
 
 // CanWriteVarsForDevice: 管理员或目标即自己
-func (p *PermissionService) CanWriteVarsForDevice(requesterUID uint64, targetUID uint64) bool {
-	if p.IsAdminDevice(requesterUID) {
+func (p *PermissionService) CanWriteVarsForDevice(ctx context.Context, requesterUID uint64, targetUID uint64) bool {
+	if p.IsAdminDevice(ctx, requesterUID) {
 		return true
 	}
 	return requesterUID == targetUID
 }
 
 // CanManageDevice: 只有管理员（最小实现；未来加入所有者判断）
-func (p *PermissionService) CanManageDevice(requesterUID uint64, targetUID uint64) bool {
-	return p.IsAdminDevice(requesterUID)
+func (p *PermissionService) CanManageDevice(ctx context.Context, requesterUID uint64, targetUID uint64) bool {
+	return p.IsAdminDevice(ctx, requesterUID)
 }
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+type presenceEntry struct {
+	online   bool
+	via      uint64
+	lastSeen time.Time
+	dirty    bool // lastSeen 尚未落库
+}
+
+// PresenceService 维护设备在线视图（内存），并定期将最近在线时间写回 Device.LastSeen。
+// 由 hub.Server 在 Run 协程内调用，方法仅持有内存锁，不访问数据库。
+type PresenceService struct {
+	mu      sync.Mutex
+	entries map[uint64]*presenceEntry
+	devices *repository.DeviceRepository
+}
+
+// NewPresenceService 创建一个新的 PresenceService
+func NewPresenceService(devices *repository.DeviceRepository) *PresenceService {
+	return &PresenceService{entries: make(map[uint64]*presenceEntry), devices: devices}
+}
+
+// Apply 应用上线/下线事件，返回实际变化的条目；设备下线时经由它上报的下级一并下线
+func (p *PresenceService) Apply(via uint64, items []bin.PresenceItem, snapshot bool) []bin.PresenceItem {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var changed []bin.PresenceItem
+	seen := make(map[uint64]struct{}, len(items))
+	for _, it := range items {
+		if it.DeviceUID == 0 {
+			continue
+		}
+		at := now
+		if it.LastSeenSec > 0 {
+			at = time.Unix(it.LastSeenSec, 0)
+		}
+		if it.Online {
+			seen[it.DeviceUID] = struct{}{}
+			e := p.entry(it.DeviceUID)
+			if !e.online || e.via != via {
+				changed = append(changed, bin.PresenceItem{DeviceUID: it.DeviceUID, Online: true, LastSeenSec: at.Unix(), Via: via})
+			}
+			e.online, e.via = true, via
+			p.touch(e, at)
+			continue
+		}
+		// 中继上报的下线仅在设备仍经由该中继时生效（设备可能已改为直连或经其他中继上线）
+		if e, ok := p.entries[it.DeviceUID]; ok && e.online && (via == 0 || e.via == via) {
+			changed = append(changed, p.offline(it.DeviceUID, e, at)...)
+		}
+	}
+	if snapshot {
+		for uid, e := range p.entries {
+			if _, ok := seen[uid]; !ok && e.online && e.via == via {
+				changed = append(changed, p.offline(uid, e, now)...)
+			}
+		}
+	}
+	return changed
+}
+
+func (p *PresenceService) entry(uid uint64) *presenceEntry {
+	e, ok := p.entries[uid]
+	if !ok {
+		e = &presenceEntry{}
+		p.entries[uid] = e
+	}
+	return e
+}
+
+func (p *PresenceService) touch(e *presenceEntry, at time.Time) {
+	if at.After(e.lastSeen) {
+		e.lastSeen = at
+		e.dirty = true
+	}
+}
+
+// offline 标记设备下线，并级联下线经由它上报的设备
+func (p *PresenceService) offline(uid uint64, e *presenceEntry, at time.Time) []bin.PresenceItem {
+	e.online = false
+	p.touch(e, at)
+	out := []bin.PresenceItem{{DeviceUID: uid, Online: false, LastSeenSec: e.lastSeen.Unix(), Via: e.via}}
+	for cuid, ce := range p.entries {
+		if ce.online && ce.via == uid {
+			ce.online = false
+			p.touch(ce, at)
+			out = append(out, bin.PresenceItem{DeviceUID: cuid, Online: false, LastSeenSec: ce.lastSeen.Unix(), Via: uid})
+		}
+	}
+	return out
+}
+
+// Seen 刷新在线设备的最近活跃时间
+func (p *PresenceService) Seen(deviceUID uint64, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.entries[deviceUID]; ok && e.online {
+		p.touch(e, at)
+	}
+}
+
+// Touch 记录无常驻连接的接入（如 HTTP 上报）的活跃时间，不改变在线状态
+func (p *PresenceService) Touch(deviceUID uint64, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.touch(p.entry(deviceUID), at)
+}
+
+// Snapshot 返回当前在线设备（按 UID 排序）
+func (p *PresenceService) Snapshot() []bin.PresenceItem {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]bin.PresenceItem, 0, len(p.entries))
+	for uid, e := range p.entries {
+		if e.online {
+			out = append(out, bin.PresenceItem{DeviceUID: uid, Online: true, LastSeenSec: e.lastSeen.Unix(), Via: e.via})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeviceUID < out[j].DeviceUID })
+	return out
+}
+
+// Get 返回设备在线状态；从未上线过时 ok=false
+func (p *PresenceService) Get(deviceUID uint64) (item bin.PresenceItem, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[deviceUID]
+	if !ok {
+		return bin.PresenceItem{DeviceUID: deviceUID}, false
+	}
+	return bin.PresenceItem{DeviceUID: deviceUID, Online: e.online, LastSeenSec: e.lastSeen.Unix(), Via: e.via}, true
+}
+
+// IsOnline 判断设备是否在线（含经中继上报的下级）
+func (p *PresenceService) IsOnline(deviceUID uint64) bool {
+	it, _ := p.Get(deviceUID)
+	return it.Online
+}
+
+// Run 周期性将最近在线时间写回数据库（阻塞，需在独立协程中运行）
+func (p *PresenceService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		p.Flush()
+	}
+}
+
+// Flush 将变化的最近在线时间写回数据库
+func (p *PresenceService) Flush() {
+	type pending struct {
+		uid uint64
+		at  time.Time
+	}
+	p.mu.Lock()
+	var batch []pending
+	for uid, e := range p.entries {
+		if e.dirty {
+			batch = append(batch, pending{uid, e.lastSeen})
+			e.dirty = false
+		}
+	}
+	p.mu.Unlock()
+	for _, it := range batch {
+		if err := p.devices.UpdateLastSeen(context.Background(), it.uid, it.at); err != nil {
+			log.Warn().Err(err).Uint64("device", it.uid).Msg("写入设备最近在线时间失败")
+		}
+	}
+}
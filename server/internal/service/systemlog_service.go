@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"myflowhub/pkg/database"
 	"myflowhub/server/internal/repository"
@@ -15,8 +16,8 @@ func NewSystemLogService(repo *repository.SystemLogRepository) *SystemLogService
 	return &SystemLogService{repo: repo}
 }
 
-func (s *SystemLogService) Write(l *database.SystemLog) error {
-	return s.repo.Create(l)
+func (s *SystemLogService) Write(ctx context.Context, l *database.SystemLog) error {
+	return s.repo.Create(ctx, l)
 }
 
 // Helper to write a simple log
@@ -27,7 +28,7 @@ func (s *SystemLogService) Info(source, message string, details any) error {
 			l.Details = b
 		}
 	}
-	return s.Write(l)
+	return s.Write(context.Background(), l)
 }
 func (s *SystemLogService) Warn(source, message string, details any) error {
 	l := &database.SystemLog{Level: "warn", Source: source, Message: message, At: time.Now()}
@@ -36,7 +37,7 @@ func (s *SystemLogService) Warn(source, message string, details any) error {
 			l.Details = b
 		}
 	}
-	return s.Write(l)
+	return s.Write(context.Background(), l)
 }
 func (s *SystemLogService) Error(source, message string, details any) error {
 	l := &database.SystemLog{Level: "error", Source: source, Message: message, At: time.Now()}
@@ -45,13 +46,13 @@ func (s *SystemLogService) Error(source, message string, details any) error {
 			l.Details = b
 		}
 	}
-	return s.Write(l)
+	return s.Write(context.Background(), l)
 }
 
 type SystemLogListInput = repository.SystemLogFilter
 
 type SystemLogListOutput = repository.PagedSystemLogs
 
-func (s *SystemLogService) List(in SystemLogListInput) (*SystemLogListOutput, error) {
-	return s.repo.List(in)
+func (s *SystemLogService) List(ctx context.Context, in SystemLogListInput) (*SystemLogListOutput, error) {
+	return s.repo.List(ctx, in)
 }
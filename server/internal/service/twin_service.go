@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync/atomic"
+
+	"myflowhub/pkg/database"
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/repository"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/datatypes"
+)
+
+var (
+	ErrTwinVersionConflict = errors.New("twin version conflict")
+	ErrTwinBadPatch        = errors.New("patch must be a JSON object")
+)
+
+// TwinService 维护设备孪生：合并 desired/reported、计算差量并推送给设备
+type TwinService struct {
+	repo      *repository.TwinRepository
+	transport FrameSender
+	msgSeq    uint64
+}
+
+// NewTwinService 创建一个新的 TwinService
+func NewTwinService(repo *repository.TwinRepository) *TwinService {
+	return &TwinService{repo: repo}
+}
+
+// SetTransport 注入下发通道（hub.Server 创建后调用）
+func (s *TwinService) SetTransport(t FrameSender) { s.transport = t }
+
+// Get 返回完整孪生与当前差量
+func (s *TwinService) Get(ctx context.Context, deviceUID uint64) (*database.DeviceTwin, []byte, error) {
+	t, err := s.repo.FindOrCreate(ctx, deviceUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	delta, err := twinDelta(t.Desired, t.Reported)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, delta, nil
+}
+
+// UpdateDesired 以 JSON Merge Patch 更新 desired 并向设备推送差量，返回新版本
+func (s *TwinService) UpdateDesired(ctx context.Context, deviceUID uint64, patch []byte, expectedVersion *uint64) (uint64, error) {
+	t, err := s.repo.FindOrCreate(ctx, deviceUID)
+	if err != nil {
+		return 0, err
+	}
+	if expectedVersion != nil && *expectedVersion != t.DesiredVersion {
+		return 0, ErrTwinVersionConflict
+	}
+	merged, err := applyMergePatch(t.Desired, patch)
+	if err != nil {
+		return 0, err
+	}
+	ok, err := s.repo.UpdateDesired(ctx, deviceUID, t.DesiredVersion, merged)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrTwinVersionConflict
+	}
+	t.Desired, t.DesiredVersion = merged, t.DesiredVersion+1
+	s.push(t, true)
+	return t.DesiredVersion, nil
+}
+
+// UpdateReported 以 JSON Merge Patch 更新 reported，返回新版本
+func (s *TwinService) UpdateReported(ctx context.Context, deviceUID uint64, patch []byte, expectedVersion *uint64) (uint64, error) {
+	t, err := s.repo.FindOrCreate(ctx, deviceUID)
+	if err != nil {
+		return 0, err
+	}
+	if expectedVersion != nil && *expectedVersion != t.ReportedVersion {
+		return 0, ErrTwinVersionConflict
+	}
+	merged, err := applyMergePatch(t.Reported, patch)
+	if err != nil {
+		return 0, err
+	}
+	ok, err := s.repo.UpdateReported(ctx, deviceUID, t.ReportedVersion, merged)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrTwinVersionConflict
+	}
+	return t.ReportedVersion + 1, nil
+}
+
+// PushPending 设备上线时推送尚未同步的差量（无差量不推送）
+func (s *TwinService) PushPending(ctx context.Context, deviceUID uint64) {
+	t, err := s.repo.FindOrCreate(ctx, deviceUID)
+	if err != nil {
+		log.Warn().Err(err).Uint64("device", deviceUID).Msg("读取设备孪生失败")
+		return
+	}
+	s.push(t, false)
+}
+
+// push 向设备下发 TWIN_DELTA；always=false 时差量为空则跳过
+func (s *TwinService) push(t *database.DeviceTwin, always bool) {
+	if s.transport == nil {
+		return
+	}
+	delta, err := twinDelta(t.Desired, t.Reported)
+	if err != nil {
+		return
+	}
+	if !always && string(delta) == "{}" {
+		return
+	}
+	pl := bin.EncodeTwinDelta(0, t.DeviceUID, delta, t.DesiredVersion, t.ReportedVersion)
+	if err := s.transport.SendTo(t.DeviceUID, bin.TypeTwinDelta, atomic.AddUint64(&s.msgSeq, 1), pl); err != nil {
+		log.Warn().Err(err).Uint64("device", t.DeviceUID).Msg("推送孪生差量失败")
+	}
+}
+
+// applyMergePatch 按 RFC 7386 将 patch 合并到 doc（两者均须为 JSON 对象）
+func applyMergePatch(doc datatypes.JSON, patch []byte) (datatypes.JSON, error) {
+	var p map[string]any
+	if err := json.Unmarshal(patch, &p); err != nil || p == nil {
+		return nil, ErrTwinBadPatch
+	}
+	target := map[string]any{}
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &target); err != nil || target == nil {
+			target = map[string]any{}
+		}
+	}
+	out, _ := json.Marshal(mergePatch(target, p))
+	return datatypes.JSON(out), nil
+}
+
+func mergePatch(target any, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = map[string]any{}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = mergePatch(tm[k], v)
+	}
+	return tm
+}
+
+// twinDelta 计算 desired 中与 reported 不一致的部分（对象逐层比较）
+func twinDelta(desired, reported datatypes.JSON) ([]byte, error) {
+	var d, r map[string]any
+	if len(desired) > 0 {
+		if err := json.Unmarshal(desired, &d); err != nil {
+			return nil, err
+		}
+	}
+	if len(reported) > 0 {
+		if err := json.Unmarshal(reported, &r); err != nil {
+			return nil, err
+		}
+	}
+	out, err := json.Marshal(diffObject(d, r))
+	return out, err
+}
+
+func diffObject(desired, reported map[string]any) map[string]any {
+	out := map[string]any{}
+	for k, dv := range desired {
+		rv, ok := reported[k]
+		if dm, isObj := dv.(map[string]any); isObj && ok {
+			if rm, both := rv.(map[string]any); both {
+				if sub := diffObject(dm, rm); len(sub) > 0 {
+					out[k] = sub
+				}
+				continue
+			}
+		}
+		if !ok || !reflect.DeepEqual(dv, rv) {
+			out[k] = dv
+		}
+	}
+	return out
+}
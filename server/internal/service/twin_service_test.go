@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	bin "myflowhub/pkg/protocol/binproto"
+	"myflowhub/server/internal/repository"
+	"myflowhub/server/internal/testdb"
+
+	"gorm.io/datatypes"
+)
+
+// jsonEqual 按语义比较两段 JSON
+func jsonEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid json %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	cases := []struct {
+		name, doc, patch, want string
+	}{
+		{"add", `{"a":1}`, `{"b":2}`, `{"a":1,"b":2}`},
+		{"replace", `{"a":1}`, `{"a":"x"}`, `{"a":"x"}`},
+		{"delete with null", `{"a":1,"b":2}`, `{"a":null}`, `{"b":2}`},
+		{"delete missing key", `{"a":1}`, `{"z":null}`, `{"a":1}`},
+		{"nested merge", `{"led":{"r":1,"g":2}}`, `{"led":{"g":3,"b":4}}`, `{"led":{"r":1,"g":3,"b":4}}`},
+		{"nested delete", `{"led":{"r":1,"g":2}}`, `{"led":{"r":null}}`, `{"led":{"g":2}}`},
+		{"object replaces scalar", `{"a":1}`, `{"a":{"b":1}}`, `{"a":{"b":1}}`},
+		{"array replaced whole", `{"a":[1,2,3]}`, `{"a":[4]}`, `{"a":[4]}`},
+		{"nulls inside new object dropped", `{}`, `{"a":{"b":null,"c":1}}`, `{"a":{"c":1}}`},
+		{"empty doc", ``, `{"a":1}`, `{"a":1}`},
+		{"empty patch", `{"a":1}`, `{}`, `{"a":1}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := applyMergePatch(datatypes.JSON(tc.doc), []byte(tc.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			jsonEqual(t, out, tc.want)
+		})
+	}
+	for _, bad := range []string{`[1]`, `"x"`, `null`, `{`} {
+		if _, err := applyMergePatch(datatypes.JSON(`{}`), []byte(bad)); !errors.Is(err, ErrTwinBadPatch) {
+			t.Errorf("patch %s: err = %v", bad, err)
+		}
+	}
+}
+
+func TestTwinDelta(t *testing.T) {
+	cases := []struct {
+		name, desired, reported, want string
+	}{
+		{"in sync", `{"a":1,"b":{"c":2}}`, `{"a":1,"b":{"c":2},"extra":true}`, `{}`},
+		{"changed value", `{"a":1}`, `{"a":2}`, `{"a":1}`},
+		{"missing in reported", `{"a":1}`, `{}`, `{"a":1}`},
+		{"nested difference only", `{"led":{"r":1,"g":2}}`, `{"led":{"r":1,"g":0}}`, `{"led":{"g":2}}`},
+		{"object vs scalar", `{"led":{"r":1}}`, `{"led":"off"}`, `{"led":{"r":1}}`},
+		{"arrays compared whole", `{"a":[1,2]}`, `{"a":[1]}`, `{"a":[1,2]}`},
+		{"empty documents", ``, ``, `{}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := twinDelta(datatypes.JSON(tc.desired), datatypes.JSON(tc.reported))
+			if err != nil {
+				t.Fatal(err)
+			}
+			jsonEqual(t, out, tc.want)
+		})
+	}
+}
+
+func TestTwinVersionsAndPush(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.Open(t)
+	repo := repository.NewTwinRepository(db)
+	svc := NewTwinService(repo)
+	tr := &recordingSender{}
+	svc.SetTransport(tr)
+	const dev = 10001
+	ver := func(v uint64) *uint64 { return &v }
+
+	// desired：期望版本须与当前一致，每次写入版本加一并推送差量
+	if v, err := svc.UpdateDesired(ctx, dev, []byte(`{"led":"on","fan":1}`), ver(0)); err != nil || v != 1 {
+		t.Fatalf("first desired update: v=%d err=%v", v, err)
+	}
+	if _, err := svc.UpdateDesired(ctx, dev, []byte(`{"led":"off"}`), ver(0)); !errors.Is(err, ErrTwinVersionConflict) {
+		t.Fatalf("stale desired version: %v", err)
+	}
+	if v, err := svc.UpdateDesired(ctx, dev, []byte(`{"fan":2}`), nil); err != nil || v != 2 {
+		t.Fatalf("unconditional desired update: v=%d err=%v", v, err)
+	}
+	sent := tr.take()
+	if len(sent) != 2 {
+		t.Fatalf("pushed %d deltas", len(sent))
+	}
+	for _, f := range sent {
+		if f.target != dev || f.typeID != bin.TypeTwinDelta {
+			t.Fatalf("push %+v", f)
+		}
+	}
+	_, uid, delta, dv, rv, err := bin.DecodeTwinDelta(sent[1].payload)
+	if err != nil || uid != dev || dv != 2 || rv != 0 {
+		t.Fatalf("delta header uid=%d versions %d/%d err=%v", uid, dv, rv, err)
+	}
+	jsonEqual(t, delta, `{"led":"on","fan":2}`)
+
+	// 存储层的乐观锁：以过期版本写入不生效
+	if ok, err := repo.UpdateDesired(ctx, dev, 1, datatypes.JSON(`{}`)); err != nil || ok {
+		t.Fatalf("stale repository write applied: ok=%v err=%v", ok, err)
+	}
+
+	// reported：版本独立计数；设备上报后差量收敛
+	if v, err := svc.UpdateReported(ctx, dev, []byte(`{"led":"on"}`), ver(0)); err != nil || v != 1 {
+		t.Fatalf("reported update: v=%d err=%v", v, err)
+	}
+	if _, err := svc.UpdateReported(ctx, dev, []byte(`{"fan":2}`), ver(0)); !errors.Is(err, ErrTwinVersionConflict) {
+		t.Fatalf("stale reported version: %v", err)
+	}
+	twin, delta, err := svc.Get(ctx, dev)
+	if err != nil || twin.DesiredVersion != 2 || twin.ReportedVersion != 1 {
+		t.Fatalf("twin versions %+v err=%v", twin, err)
+	}
+	jsonEqual(t, delta, `{"fan":2}`)
+	svc.PushPending(ctx, dev)
+	if sent := tr.take(); len(sent) != 1 {
+		t.Fatalf("pending delta pushes: %d", len(sent))
+	}
+
+	if _, err := svc.UpdateReported(ctx, dev, []byte(`{"fan":2}`), ver(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, delta, _ = svc.Get(ctx, dev); string(delta) != "{}" {
+		t.Fatalf("delta after convergence %s", delta)
+	}
+	svc.PushPending(ctx, dev)
+	if sent := tr.take(); len(sent) != 0 {
+		t.Fatalf("empty delta pushed on reconnect")
+	}
+
+	if _, err := svc.UpdateDesired(ctx, dev, []byte(`[1]`), nil); !errors.Is(err, ErrTwinBadPatch) {
+		t.Fatalf("bad patch: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 	"myflowhub/server/internal/repository"
 
@@ -15,29 +16,29 @@ func NewUserService(repo *repository.UserRepository) *UserService {
 	return &UserService{repo: repo}
 }
 
-func (s *UserService) List() ([]database.User, error) {
-	return s.repo.FindAll()
+func (s *UserService) List(ctx context.Context) ([]database.User, error) {
+	return s.repo.FindAll(ctx)
 }
 
-func (s *UserService) Get(id uint64) (*database.User, error) {
-	return s.repo.FindByID(id)
+func (s *UserService) Get(ctx context.Context, id uint64) (*database.User, error) {
+	return s.repo.FindByID(ctx, id)
 }
 
-func (s *UserService) GetByUsername(username string) (*database.User, error) {
-	return s.repo.FindByUsername(username)
+func (s *UserService) GetByUsername(ctx context.Context, username string) (*database.User, error) {
+	return s.repo.FindByUsername(ctx, username)
 }
 
-func (s *UserService) Create(username, displayName, password string) (*database.User, error) {
+func (s *UserService) Create(ctx context.Context, username, displayName, password string) (*database.User, error) {
 	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	u := &database.User{Username: username, DisplayName: displayName, PasswordHash: string(hash)}
-	if err := s.repo.Create(u); err != nil {
+	if err := s.repo.Create(ctx, u); err != nil {
 		return nil, err
 	}
 	return u, nil
 }
 
-func (s *UserService) Update(id uint64, displayName *string, password *string, disabled *bool) error {
-	u, err := s.repo.FindByID(id)
+func (s *UserService) Update(ctx context.Context, id uint64, displayName *string, password *string, disabled *bool) error {
+	u, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -51,26 +52,26 @@ func (s *UserService) Update(id uint64, displayName *string, password *string, d
 	if disabled != nil {
 		u.Disabled = *disabled
 	}
-	return s.repo.Update(u)
+	return s.repo.Update(ctx, u)
 }
 
-func (s *UserService) Delete(id uint64) error {
-	return s.repo.Delete(id)
+func (s *UserService) Delete(ctx context.Context, id uint64) error {
+	return s.repo.Delete(ctx, id)
 }
 
 // UpdateDisplayName 仅更新显示名
-func (s *UserService) UpdateDisplayName(id uint64, displayName string) error {
-	u, err := s.repo.FindByID(id)
+func (s *UserService) UpdateDisplayName(ctx context.Context, id uint64, displayName string) error {
+	u, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
 	u.DisplayName = displayName
-	return s.repo.Update(u)
+	return s.repo.Update(ctx, u)
 }
 
 // ChangePasswordWithVerify 校验旧密码后更新密码
-func (s *UserService) ChangePasswordWithVerify(id uint64, oldPassword, newPassword string) error {
-	u, err := s.repo.FindByID(id)
+func (s *UserService) ChangePasswordWithVerify(ctx context.Context, id uint64, oldPassword, newPassword string) error {
+	u, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -79,5 +80,5 @@ func (s *UserService) ChangePasswordWithVerify(id uint64, oldPassword, newPasswo
 	}
 	hash, _ := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	u.PasswordHash = string(hash)
-	return s.repo.Update(u)
+	return s.repo.Update(ctx, u)
 }
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"myflowhub/pkg/database"
 	"myflowhub/server/internal/repository"
 )
@@ -16,31 +17,31 @@ func NewVariableService(repo *repository.VariableRepository) *VariableService {
 }
 
 // GetVariablesByDeviceID 根据设备 ID 获取变量
-func (s *VariableService) GetVariablesByDeviceID(deviceID uint64) ([]database.DeviceVariable, error) {
-	return s.repo.FindByDeviceID(deviceID)
+func (s *VariableService) GetVariablesByDeviceID(ctx context.Context, deviceID uint64) ([]database.DeviceVariable, error) {
+	return s.repo.FindByDeviceID(ctx, deviceID)
 }
 
 // GetVariableByOwnerAndName 根据所有者和变量名获取变量
-func (s *VariableService) GetVariableByOwnerAndName(ownerID uint64, name string) (*database.DeviceVariable, error) {
-	return s.repo.FindByOwnerAndName(ownerID, name)
+func (s *VariableService) GetVariableByOwnerAndName(ctx context.Context, ownerID uint64, name string) (*database.DeviceVariable, error) {
+	return s.repo.FindByOwnerAndName(ctx, ownerID, name)
 }
 
 // UpsertVariable 更新或创建变量
-func (s *VariableService) UpsertVariable(variable *database.DeviceVariable) error {
-	return s.repo.Upsert(variable)
+func (s *VariableService) UpsertVariable(ctx context.Context, variable *database.DeviceVariable) error {
+	return s.repo.Upsert(ctx, variable)
 }
 
 // GetVariableByDeviceUIDAndVarName 根据设备 UID 和变量名获取变量
-func (s *VariableService) GetVariableByDeviceUIDAndVarName(deviceUID, varName string) (*database.DeviceVariable, error) {
-	return s.repo.FindByDeviceUIDAndVarName(deviceUID, varName)
+func (s *VariableService) GetVariableByDeviceUIDAndVarName(ctx context.Context, deviceUID, varName string) (*database.DeviceVariable, error) {
+	return s.repo.FindByDeviceUIDAndVarName(ctx, deviceUID, varName)
 }
 
 // GetAllVariables 获取所有变量
-func (s *VariableService) GetAllVariables() ([]database.DeviceVariable, error) {
-	return s.repo.FindAll()
+func (s *VariableService) GetAllVariables(ctx context.Context) ([]database.DeviceVariable, error) {
+	return s.repo.FindAll(ctx)
 }
 
 // DeleteVariable 删除变量
-func (s *VariableService) DeleteVariable(ownerID uint64, name string) error {
-	return s.repo.Delete(ownerID, name)
+func (s *VariableService) DeleteVariable(ctx context.Context, ownerID uint64, name string) error {
+	return s.repo.Delete(ctx, ownerID, name)
 }
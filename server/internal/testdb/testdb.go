@@ -0,0 +1,89 @@
+// Package testdb 为测试提供已迁移全部模型的内存 SQLite 数据库（仅供 _test.go 引用）
+package testdb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"myflowhub/pkg/database"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var seq atomic.Uint64
+
+// Open 创建一个独立的内存数据库，测试结束时关闭
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:testdb%d?mode=memory&cache=shared&_busy_timeout=5000", seq.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SQLite 仅允许主键自增：Device.DeviceUID 改为插入前按最大值加一分配（与 PostgreSQL 序列从 10000 起一致）
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&database.Device{}); err != nil {
+		t.Fatal(err)
+	}
+	uidField := stmt.Schema.LookUpField("DeviceUID")
+	uidField.AutoIncrement, uidField.HasDefaultValue = false, false
+	err = db.Callback().Create().Before("gorm:create").Register("testdb:device_uid", func(tx *gorm.DB) {
+		if tx.Statement.Schema != stmt.Schema || tx.Statement.ReflectValue.Kind() != reflect.Struct {
+			return
+		}
+		if _, zero := uidField.ValueOf(tx.Statement.Context, tx.Statement.ReflectValue); zero {
+			var next uint64
+			tx.Session(&gorm.Session{NewDB: true}).Raw("SELECT COALESCE(MAX(device_uid), 9999) + 1 FROM devices").Scan(&next)
+			_ = uidField.Set(tx.Statement.Context, tx.Statement.ReflectValue, next)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(database.Models()...); err != nil {
+		t.Fatal(err)
+	}
+	if err := textJSONColumns(db); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// Global 创建内存数据库并设为 database.DB（供直接访问全局连接的代码使用），测试结束时恢复
+func Global(t testing.TB) *gorm.DB {
+	t.Helper()
+	db := Open(t)
+	prev := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = prev })
+	return db
+}
+
+// textJSONColumns 将 JSON 列重建为 TEXT 列：SQLite 按类型名推断亲和性，JSON 列为 NUMERIC，
+// 数值型 JSON（如 42）会被存为整数而无法再读回 datatypes.JSON。表在迁移后为空，直接按改写后的语句重建。
+func textJSONColumns(db *gorm.DB) error {
+	var tables []struct{ Name, SQL string }
+	if err := db.Raw("SELECT name, sql FROM sqlite_master WHERE type = 'table' AND sql LIKE '%` JSON%'").Scan(&tables).Error; err != nil {
+		return err
+	}
+	for _, tb := range tables {
+		var indexes []string
+		if err := db.Raw("SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", tb.Name).Scan(&indexes).Error; err != nil {
+			return err
+		}
+		stmts := append([]string{"DROP TABLE `" + tb.Name + "`", strings.ReplaceAll(tb.SQL, "` JSON", "` TEXT")}, indexes...)
+		for _, q := range stmts {
+			if err := db.Exec(q).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
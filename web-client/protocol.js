@@ -34,6 +34,11 @@ export const Type = {
     KEY_DEVICES_RESP: 177,
 }
 
+// --- Header flags (aligned with Go binproto.Flag*) ---
+export const Flag = {
+    E2E: 0x0001, // 负载端到端加密，Hub 不解密
+}
+
 // --- Binary Writer/Reader ---
 class Writer {
     constructor(initialSize = 256) {
@@ -146,7 +151,7 @@ export function encodeFrame(typeID, msgID, source, target, payload) {
     const out = new Uint8Array(38 + payloadLen)
     // typeID
     writeU16LE(out, 0, Number(typeID & 0xffff))
-    // reserved 4 bytes already zero by default
+    // flags(2B) 与 reserved(2B) 默认为 0
     // msgID/source/target/timestamp
     writeU64LE(out, 6, msgID)
     writeU64LE(out, 14, source)
@@ -163,7 +168,8 @@ export function decodeFrame(frame) {
     const r = new Reader(frame)
     const header = {
         typeID: r.readU16(),
-        reserved: r.readBytes(4),
+        flags: r.readU16(), // 见 Flag
+        reserved: r.readBytes(2),
         msgID: r.readU64(),
         source: r.readU64(),
         target: r.readU64(),